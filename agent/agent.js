@@ -1,20 +1,37 @@
 require('dotenv').config()
 const axios = require('axios')
+const crypto = require('crypto')
+const fs = require('fs')
 const os = require('os')
+const path = require('path')
 
 const { createMockExecutor } = require('./executors/mock-executor')
 const { createPlaywrightExecutor } = require('./executors/playwright-executor')
 
 const baseURL = process.env.BASE_URL || 'http://127.0.0.1:8080'
 const agentKey = process.env.AGENT_KEY || 'local-agent-001'
+const agentToken = process.env.AGENT_TOKEN || ''
 const agentName = process.env.AGENT_NAME || 'Local Agent'
 const agentHostname = process.env.AGENT_HOSTNAME || os.hostname()
 const pollIntervalMs = Number(process.env.POLL_INTERVAL_MS || 8000)
 const mode = (process.env.AGENT_MODE || 'mock').toLowerCase()
+const declaredJobTypes = parseList(process.env.AGENT_JOB_TYPES)
+const declaredShopIds = parseList(process.env.AGENT_SHOP_IDS).map(Number).filter((id) => id > 0)
+const maxConcurrency = 1
+// 与服务端协商的执行端协议版本，见 backend/pkg/automation/protocol/schema
+const protocolVersion = 1
+
+function parseList(raw) {
+  return String(raw || '')
+    .split(',')
+    .map((item) => item.trim())
+    .filter(Boolean)
+}
 
 const client = axios.create({
   baseURL,
   timeout: 30000,
+  headers: agentToken ? { 'X-Agent-Token': agentToken } : {},
 })
 
 let executor = null
@@ -38,11 +55,15 @@ async function heartbeat() {
     agent_key: agentKey,
     name: agentName,
     hostname: agentHostname,
+    protocol_version: protocolVersion,
     capabilities: {
       mode,
       browser: mode === 'playwright',
       version: 'm2.5',
       executor: currentExecutor.name,
+      job_types: declaredJobTypes,
+      shop_ids: declaredShopIds,
+      max_concurrency: maxConcurrency,
     },
   })
 }
@@ -69,6 +90,43 @@ async function reportJob(job, results, status) {
   })
 }
 
+// 从明细错误信息中提取 "screenshot=<path>" 形式的本地截图路径
+function collectScreenshots(results) {
+  const list = Array.isArray(results) ? results : []
+  const paths = new Set()
+  for (const item of list) {
+    for (const message of [item.step_exit_error, item.step_reprice_error, item.step_readd_error]) {
+      const match = /screenshot=([^;]+)/.exec(message || '')
+      if (match) paths.add(match[1].trim())
+    }
+  }
+  return [...paths]
+}
+
+async function uploadArtifact(job, filePath, artifactType) {
+  const data = fs.readFileSync(filePath)
+  const form = new FormData()
+  form.append('agent_key', agentKey)
+  form.append('job_id', String(job.job_id))
+  form.append('artifact_type', artifactType)
+  form.append('sha256', crypto.createHash('sha256').update(data).digest('hex'))
+  form.append('file', new Blob([data], { type: 'image/png' }), path.basename(filePath))
+  await client.post('/api/v1/automation/agent/artifacts', form)
+}
+
+// 截图上传失败不影响任务回报，本地文件仍保留
+async function uploadScreenshots(job, results) {
+  for (const filePath of collectScreenshots(results)) {
+    if (!fs.existsSync(filePath)) continue
+    try {
+      await uploadArtifact(job, filePath, 'screenshot')
+    } catch (error) {
+      const message = error?.response?.data?.message || error.message
+      console.error(`[Agent] upload screenshot failed (${filePath}):`, message)
+    }
+  }
+}
+
 function summarizeStatus(results) {
   const list = Array.isArray(results) ? results : []
   const failedCount = list.filter((item) => item.overall_status === 'failed').length
@@ -81,6 +139,7 @@ async function executeJob(job) {
   const currentExecutor = getExecutor()
   const results = await currentExecutor.executeJob(job)
   const status = summarizeStatus(results)
+  await uploadScreenshots(job, results)
   await reportJob(job, results, status)
 }
 
@@ -101,6 +160,11 @@ async function loop() {
     console.log(`[Agent] reported job #${job.job_id}`)
   } catch (error) {
     const message = error?.response?.data?.message || error.message
+    if (error?.response?.status === 426) {
+      console.error('[Agent] protocol version rejected by server:', message)
+      await shutdown()
+      return
+    }
     console.error('[Agent] loop error:', message)
   } finally {
     isRunning = false
@@ -0,0 +1,136 @@
+// 自动化执行端（Go 版）：实现与 Node Agent 相同的心跳 / 拉任务 / 回报协议。
+//
+// 环境变量：
+//
+//	BASE_URL              后端地址，默认 http://127.0.0.1:8080
+//	AGENT_KEY / AGENT_NAME / AGENT_HOSTNAME / AGENT_TOKEN
+//	AGENT_MODE            mock（默认，模拟执行全部任务类型）/ api（仅通过 Ozon Seller API 执行官方活动同步任务）
+//	AGENT_SHOP_IDS        声明服务的店铺 ID，逗号分隔
+//	POLL_INTERVAL_MS      轮询间隔，默认 8000
+//	OZON_SHOP_CREDENTIALS api 模式下各店铺凭证，格式 shop_id:client_id:api_key，多个用逗号分隔
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"ozon-manager/pkg/automation/agentclient"
+	"ozon-manager/pkg/ozon"
+)
+
+func main() {
+	hostname, _ := os.Hostname()
+	client, err := agentclient.NewClient(agentclient.Options{
+		BaseURL:  envOrDefault("BASE_URL", "http://127.0.0.1:8080"),
+		AgentKey: envOrDefault("AGENT_KEY", "go-agent-001"),
+		Name:     envOrDefault("AGENT_NAME", "Go Agent"),
+		Hostname: envOrDefault("AGENT_HOSTNAME", hostname),
+		Token:    os.Getenv("AGENT_TOKEN"),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create agent client: %v", err)
+	}
+
+	executor, err := buildExecutor(strings.ToLower(envOrDefault("AGENT_MODE", "mock")), os.Getenv("OZON_SHOP_CREDENTIALS"))
+	if err != nil {
+		log.Fatalf("Failed to create executor: %v", err)
+	}
+
+	shopIDs, err := parseShopIDs(os.Getenv("AGENT_SHOP_IDS"))
+	if err != nil {
+		log.Fatalf("Invalid AGENT_SHOP_IDS: %v", err)
+	}
+	pollInterval := 8 * time.Second
+	if raw := os.Getenv("POLL_INTERVAL_MS"); raw != "" {
+		ms, err := strconv.Atoi(raw)
+		if err != nil || ms <= 0 {
+			log.Fatalf("Invalid POLL_INTERVAL_MS: %s", raw)
+		}
+		pollInterval = time.Duration(ms) * time.Millisecond
+	}
+
+	runner := agentclient.NewRunner(client, executor, agentclient.RunnerOptions{
+		PollInterval: pollInterval,
+		ShopIDs:      shopIDs,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("[Agent] start: %s -> %s, executor=%s, job_types=%v", client.AgentKey(), envOrDefault("BASE_URL", "http://127.0.0.1:8080"), executor.Name(), executor.JobTypes())
+	if err := runner.Run(ctx); err != nil {
+		log.Fatalf("Agent stopped: %v", err)
+	}
+	log.Printf("[Agent] stopped")
+}
+
+func buildExecutor(mode, rawCredentials string) (agentclient.Executor, error) {
+	switch mode {
+	case "mock":
+		return agentclient.NewRouter(agentclient.NewMockExecutor()), nil
+	case "api":
+		clients, err := parseShopCredentials(rawCredentials)
+		if err != nil {
+			return nil, err
+		}
+		if len(clients) == 0 {
+			return nil, fmt.Errorf("OZON_SHOP_CREDENTIALS is required in api mode")
+		}
+		return agentclient.NewRouter(agentclient.NewOzonAPIExecutor(func(shopID uint) (agentclient.OzonActionsAPI, bool) {
+			client, ok := clients[shopID]
+			return client, ok
+		})), nil
+	default:
+		return nil, fmt.Errorf("unsupported AGENT_MODE: %s", mode)
+	}
+}
+
+func parseShopCredentials(raw string) (map[uint]*ozon.Client, error) {
+	clients := make(map[uint]*ozon.Client)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid shop credential entry, want shop_id:client_id:api_key")
+		}
+		shopID, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 32)
+		if err != nil || shopID == 0 {
+			return nil, fmt.Errorf("invalid shop id in credentials: %s", parts[0])
+		}
+		clients[uint(shopID)] = ozon.NewClient(strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2]))
+	}
+	return clients, nil
+}
+
+func parseShopIDs(raw string) ([]uint, error) {
+	result := make([]uint, 0)
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(item, 10, 32)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("invalid shop id: %s", item)
+		}
+		result = append(result, uint(id))
+	}
+	return result, nil
+}
+
+func envOrDefault(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
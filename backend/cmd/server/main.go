@@ -1,16 +1,24 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"ozon-manager/internal/config"
 	"ozon-manager/internal/handler"
 	"ozon-manager/internal/middleware"
+	"ozon-manager/internal/model"
 	"ozon-manager/internal/repository"
 	"ozon-manager/internal/service"
+	"ozon-manager/pkg/artifactstore"
 	"ozon-manager/pkg/logger"
 )
 
@@ -42,25 +50,75 @@ func main() {
 	automationRepo := repository.NewAutomationRepository(db)
 	autoPromotionRepo := repository.NewAutoPromotionRepository(db)
 	operationLogRepo := repository.NewOperationLogRepository(db)
+	backgroundTaskRepo := repository.NewBackgroundTaskRepository(db)
+	priceHistoryRepo := repository.NewPriceHistoryRepository(db)
+	pricePlanRepo := repository.NewPricePlanRepository(db)
+	repriceRuleRepo := repository.NewRepriceRuleRepository(db)
+
+	// 初始化后台任务队列
+	taskQueue := service.NewTaskQueue(backgroundTaskRepo, service.TaskQueueOptions{
+		Workers:      cfg.TaskQueue.Workers,
+		PollInterval: time.Duration(cfg.TaskQueue.PollIntervalMs) * time.Millisecond,
+		Retention:    time.Duration(cfg.TaskQueue.RetentionDays) * 24 * time.Hour,
+	})
+	taskQueue.Register(model.BackgroundTaskTypeOperationLog, service.TaskOptions{MaxAttempts: 5, Timeout: time.Minute},
+		service.TypedTaskHandler(func(ctx context.Context, entry model.OperationLog) error {
+			return operationLogRepo.Create(&entry)
+		}))
 
 	// 初始化Service
 	authService := service.NewAuthService(userRepo, shopRepo)
 	userService := service.NewUserService(userRepo, shopRepo)
 	shopService := service.NewShopService(shopRepo, userRepo)
+	priceHistoryService := service.NewPriceHistoryService(priceHistoryRepo)
 	productService := service.NewProductService(productRepo, shopRepo, promotionRepo)
+	productService.ConfigurePriceHistory(priceHistoryService)
 	ozonCatalogService := service.NewOzonCatalogService(ozonCatalogRepo, shopRepo)
+	ozonCatalogService.ConfigureTaskQueue(taskQueue)
+	ozonCatalogService.ConfigurePriceHistory(priceHistoryService)
 	automationService := service.NewAutomationService(automationRepo, productRepo, shopRepo)
+	automationService.ConfigureTaskQueue(taskQueue)
+	automationService.ConfigurePriceHistory(priceHistoryService)
+	automationService.ConfigureShopCircuitBreaker(service.ShopCircuitBreakerOptions{
+		Threshold: cfg.Automation.BreakerThreshold,
+		Cooldown:  time.Duration(cfg.Automation.BreakerCooldownMinutes) * time.Minute,
+	})
+	shopService.ConfigureShopGuard(automationService.ShopGuard())
+	automationService.StartJobSweeper()
+	artifactStore, err := newArtifactStore(&cfg.Artifact)
+	if err != nil {
+		log.Fatalf("Failed to init artifact store: %v", err)
+	}
+	automationService.ConfigureArtifacts(artifactStore, service.AutomationArtifactOptions{
+		InlineMaxBytes: cfg.Artifact.InlineMaxKB * 1024,
+		MaxUploadBytes: int64(cfg.Artifact.MaxUploadMB) * 1024 * 1024,
+		Retention:      time.Duration(cfg.Artifact.RetentionDays) * 24 * time.Hour,
+	})
+	automationService.StartArtifactJanitor()
 	promotionService := service.NewPromotionService(productRepo, promotionRepo, shopRepo, automationService)
+	promotionService.ConfigureTaskQueue(taskQueue)
+	promotionService.ConfigurePriceHistory(priceHistoryService)
+	promotionService.StartReconciler()
+	promotionService.StartActionLifecycle()
 	autoPromotionService := service.NewAutoPromotionService(autoPromotionRepo, productRepo, promotionRepo, shopRepo, ozonCatalogRepo, ozonCatalogService, automationService, promotionService)
+	autoPromotionService.ConfigureTaskQueue(taskQueue)
+	autoPromotionService.ConfigureProductCosts(repriceRuleRepo)
 	autoPromotionService.StartScheduler()
+	pricePlanService := service.NewPricePlanService(pricePlanRepo, productRepo, promotionRepo, shopRepo, automationService, priceHistoryService)
+	pricePlanService.ConfigureTaskQueue(taskQueue)
+	pricePlanService.StartScheduler()
+	repriceRuleService := service.NewRepriceRuleService(repriceRuleRepo, productRepo, promotionRepo, ozonCatalogRepo, shopRepo, automationService, promotionService, priceHistoryService)
+	taskQueue.Start()
 
 	// 初始化Handler
 	authHandler := handler.NewAuthHandler(authService)
 	userHandler := handler.NewUserHandler(userService)
 	shopHandler := handler.NewShopHandler(shopService)
-	productHandler := handler.NewProductHandler(productService, shopService, ozonCatalogService)
+	productHandler := handler.NewProductHandler(productService, shopService, ozonCatalogService, priceHistoryService)
 	promotionHandler := handler.NewPromotionHandler(promotionService, shopService)
 	autoPromotionHandler := handler.NewAutoPromotionHandler(autoPromotionService, shopService)
+	pricePlanHandler := handler.NewPricePlanHandler(pricePlanService, shopService)
+	repriceRuleHandler := handler.NewRepriceRuleHandler(repriceRuleService, shopService)
 	automationHandler := handler.NewAutomationHandler(automationService, shopService)
 	extensionHandler := handler.NewExtensionHandler(automationService, shopService)
 	operationLogHandler := handler.NewOperationLogHandler(operationLogRepo)
@@ -93,7 +151,7 @@ func main() {
 		},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Disposition"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "X-Checksum-Sha256"},
 		AllowCredentials: true,
 	}))
 
@@ -121,7 +179,7 @@ func main() {
 		// 需要认证的路由
 		authenticated := api.Group("")
 		authenticated.Use(middleware.AuthMiddleware())
-		authenticated.Use(middleware.OperationLogMiddleware(db))
+		authenticated.Use(middleware.OperationLogMiddleware(taskQueue))
 		{
 			// 认证相关（所有角色）
 			authenticated.POST("/auth/logout", authHandler.Logout)
@@ -147,6 +205,7 @@ func main() {
 				// 系统概览
 				superAdmin.GET("/overview", shopHandler.GetSystemOverview)
 				superAdmin.GET("/extension-status", automationHandler.GetExtensionStatus)
+				superAdmin.GET("/automation/queue-metrics", automationHandler.GetQueueMetrics)
 			}
 
 			// ========== 店铺管理员专用路由 ==========
@@ -159,6 +218,9 @@ func main() {
 				shopAdmin.PUT("/shops/:id", shopHandler.UpdateMyShop)
 				shopAdmin.GET("/shops/:id/execution-engine", shopHandler.GetMyShopExecutionEngine)
 				shopAdmin.PUT("/shops/:id/execution-engine", shopHandler.UpdateMyShopExecutionEngine)
+				shopAdmin.GET("/shops/:id/guard", shopHandler.GetMyShopGuard)
+				shopAdmin.PUT("/shops/:id/write-pause", shopHandler.UpdateMyShopWritePause)
+				shopAdmin.POST("/shops/:id/circuit-breakers/reset", shopHandler.ResetMyShopCircuitBreakers)
 				shopAdmin.DELETE("/shops/:id", shopHandler.DeleteMyShop)
 
 				// 员工管理
@@ -179,8 +241,10 @@ func main() {
 				{
 					products.GET("", productHandler.GetProducts)
 					products.POST("/sync", productHandler.SyncProducts)
+					products.PUT("/tags", productHandler.UpdateTags)
 					products.GET("/ozon-catalog", productHandler.GetOzonCatalog)
 					products.POST("/ozon-catalog/refresh", productHandler.RefreshOzonCatalog)
+					products.GET("/price-history", productHandler.GetPriceHistory)
 					products.GET("/:id", productHandler.GetProduct)
 				}
 
@@ -190,12 +254,35 @@ func main() {
 					// 活动管理
 					promotions.GET("/actions", promotionHandler.GetActions)
 					promotions.GET("/actions/:id/products", promotionHandler.GetActionProducts)
+					promotions.GET("/actions/:id/participation", promotionHandler.GetActionParticipation)
 					promotions.POST("/actions/manual", promotionHandler.CreateManualAction)
 					promotions.DELETE("/actions/:id", promotionHandler.DeleteAction)
 					promotions.PUT("/actions/:id/display-name", promotionHandler.UpdateActionDisplayName)
 					promotions.PUT("/actions/sort-order", promotionHandler.UpdateActionsSortOrder)
+					promotions.PUT("/actions/:id/successors", promotionHandler.UpdateActionSuccessors)
 					promotions.POST("/sync-actions", promotionHandler.SyncActions)
 
+					// 与 Ozon 对账推广状态
+					promotions.POST("/reconcile", promotionHandler.StartReconcile)
+					promotions.GET("/reconcile/reports", promotionHandler.ListReconcileReports)
+					promotions.GET("/reconcile/reports/:id", promotionHandler.GetReconcileReport)
+
+					// 活动到期处理与脱离提醒
+					promotions.GET("/expirations", promotionHandler.ListPromotionExpirations)
+					promotions.GET("/expirations/:id", promotionHandler.GetPromotionExpiration)
+					promotions.POST("/expirations/read", promotionHandler.MarkPromotionExpirationsRead)
+
+					// 促销日历
+					promotions.GET("/calendar", promotionHandler.GetPromotionCalendar)
+
+					// 批量操作快照与撤销
+					promotions.GET("/operations", promotionHandler.ListBulkOperations)
+					promotions.GET("/operations/:id", promotionHandler.GetBulkOperation)
+					promotions.POST("/operations/:id/revert", promotionHandler.RevertBulkOperation)
+
+					// 促销参与流水
+					promotions.GET("/participation/products/:id", promotionHandler.GetProductParticipation)
+
 					// V1 接口（保持兼容）
 					promotions.POST("/batch-enroll", promotionHandler.BatchEnroll)
 					promotions.POST("/process-loss", promotionHandler.ProcessLoss)
@@ -213,9 +300,40 @@ func main() {
 					promotions.POST("/unified-reprice-promote", promotionHandler.UnifiedRepricePromote)
 					promotions.GET("/auto-add/config", autoPromotionHandler.GetConfig)
 					promotions.PUT("/auto-add/config", autoPromotionHandler.UpdateConfig)
+					promotions.GET("/auto-add/configs", autoPromotionHandler.ListConfigs)
+					promotions.POST("/auto-add/configs", autoPromotionHandler.CreateConfig)
+					promotions.PUT("/auto-add/configs/:id", autoPromotionHandler.UpdateConfigByID)
+					promotions.DELETE("/auto-add/configs/:id", autoPromotionHandler.DeleteConfig)
+					promotions.POST("/auto-add/preview", autoPromotionHandler.Preview)
 					promotions.POST("/auto-add/runs", autoPromotionHandler.StartRun)
 					promotions.GET("/auto-add/runs", autoPromotionHandler.ListRuns)
 					promotions.GET("/auto-add/runs/:id", autoPromotionHandler.GetRunDetail)
+					promotions.POST("/auto-add/runs/:id/retry-failed", autoPromotionHandler.RetryFailedItems)
+					promotions.POST("/simulate", autoPromotionHandler.Simulate)
+					promotions.POST("/simulate/export", autoPromotionHandler.ExportSimulation)
+				}
+
+				// 定时改价计划
+				pricePlans := business.Group("/price-plans")
+				{
+					pricePlans.POST("", pricePlanHandler.CreatePlan)
+					pricePlans.GET("", pricePlanHandler.ListPlans)
+					pricePlans.GET("/:id", pricePlanHandler.GetPlan)
+					pricePlans.POST("/:id/cancel", pricePlanHandler.CancelPlan)
+					pricePlans.POST("/:id/end", pricePlanHandler.EndPlan)
+				}
+
+				// 规则改价
+				repriceRules := business.Group("/reprice-rules")
+				{
+					repriceRules.GET("", repriceRuleHandler.ListRules)
+					repriceRules.POST("", repriceRuleHandler.CreateRule)
+					repriceRules.PUT("/:id", repriceRuleHandler.UpdateRule)
+					repriceRules.DELETE("/:id", repriceRuleHandler.DeleteRule)
+					repriceRules.GET("/costs", repriceRuleHandler.ListCosts)
+					repriceRules.PUT("/costs", repriceRuleHandler.SaveCosts)
+					repriceRules.POST("/preview", repriceRuleHandler.Preview)
+					repriceRules.POST("/apply", repriceRuleHandler.Apply)
 				}
 
 				automation := business.Group("/automation")
@@ -226,8 +344,12 @@ func main() {
 					automation.POST("/jobs/:id/confirm", automationHandler.ConfirmJob)
 					automation.POST("/jobs/:id/cancel", automationHandler.CancelJob)
 					automation.POST("/jobs/:id/retry-failed", automationHandler.RetryFailedItems)
+					automation.GET("/jobs/:id/artifacts", automationHandler.GetJobArtifacts)
+					automation.GET("/artifacts/:id/download", automationHandler.DownloadArtifact)
+					automation.GET("/workflows/:id", automationHandler.GetWorkflow)
 					automation.GET("/events", automationHandler.GetEvents)
 					automation.GET("/agents", automationHandler.GetAgentStatus)
+					automation.GET("/dispatch-diagnostics", automationHandler.GetDispatchDiagnostics)
 				}
 
 				extension := business.Group("/extension")
@@ -236,15 +358,28 @@ func main() {
 					extension.POST("/poll", extensionHandler.Poll)
 					extension.POST("/report", extensionHandler.Report)
 					extension.POST("/reprice", extensionHandler.Reprice)
+					extension.POST("/artifacts", extensionHandler.UploadArtifact)
 				}
 
+				// Agent 通道免登录：配置共享令牌后全部校验；未配置时兼容已有部署，心跳/拉取/回报不校验
 				agent := api.Group("/automation/agent")
+				if cfg.Automation.AgentToken != "" {
+					agent.Use(middleware.AgentTokenMiddleware(cfg.Automation.AgentToken))
+				} else {
+					log.Printf("⚠️  Warning: automation.agent_token is empty, agent heartbeat/poll/report are unauthenticated and agent artifact upload is rejected")
+				}
 				{
 					agent.POST("/heartbeat", automationHandler.AgentHeartbeat)
 					agent.POST("/poll", automationHandler.AgentPoll)
 					agent.POST("/report", automationHandler.AgentReport)
 				}
 
+				// 产物上传始终要求令牌，未配置时拒绝
+				agentArtifacts := api.Group("/automation/agent", middleware.AgentTokenMiddleware(cfg.Automation.AgentToken))
+				{
+					agentArtifacts.POST("/artifacts", automationHandler.AgentUploadArtifact)
+				}
+
 				// Excel导入导出
 				excel := business.Group("/excel")
 				{
@@ -268,21 +403,70 @@ func main() {
 
 	// 启动服务器
 	addr := fmt.Sprintf(":%d", cfg.Server.Port)
+	srv := &http.Server{Addr: addr, Handler: r}
+	serverErr := make(chan error, 1)
 
 	if cfg.Server.TLS.Enabled {
 		log.Printf("Starting HTTPS server on %s", addr)
 		log.Printf("TLS Certificate: %s", cfg.Server.TLS.CertFile)
 		log.Printf("TLS Key: %s", cfg.Server.TLS.KeyFile)
 		log.Printf("Default super admin account: super_admin / admin123")
-		if err := r.RunTLS(addr, cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile); err != nil {
-			log.Fatalf("Failed to start HTTPS server: %v", err)
-		}
+		go func() {
+			serverErr <- srv.ListenAndServeTLS(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+		}()
 	} else {
 		log.Printf("⚠️  Warning: Running HTTP server (insecure)")
 		log.Printf("Server starting on %s", addr)
 		log.Printf("Default super admin account: super_admin / admin123")
-		if err := r.Run(addr); err != nil {
+		go func() {
+			serverErr <- srv.ListenAndServe()
+		}()
+	}
+
+	stopCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("Failed to start server: %v", err)
 		}
+	case <-stopCtx.Done():
+	}
+	stop()
+
+	// 优雅停止：先停定时器不再产生新工作，再停止接收请求，最后排空执行中的后台任务
+	shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeoutSeconds) * time.Second
+	log.Printf("Shutting down, waiting up to %s for running work", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	autoPromotionService.StopScheduler()
+	pricePlanService.StopScheduler()
+	promotionService.StopReconciler()
+	automationService.StopBackgroundWorkers()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP server shutdown: %v", err)
+	}
+	if err := taskQueue.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Background tasks interrupted, they will resume on next start: %v", err)
+	}
+	log.Printf("Server stopped")
+}
+
+// newArtifactStore 按配置创建自动化产物存储后端
+func newArtifactStore(cfg *config.ArtifactConfig) (artifactstore.Store, error) {
+	switch cfg.Backend {
+	case "s3":
+		return artifactstore.NewS3Store(artifactstore.S3Options{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+		})
+	case "", "local":
+		return artifactstore.NewLocalStore(cfg.LocalDir)
+	default:
+		return nil, fmt.Errorf("unsupported artifact backend: %s", cfg.Backend)
 	}
 }
@@ -9,12 +9,17 @@ type Config struct {
 	Database DatabaseConfig `mapstructure:"database"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
 	Log      LogConfig      `mapstructure:"log"`
+	Artifact   ArtifactConfig   `mapstructure:"artifact"`
+	Automation AutomationConfig `mapstructure:"automation"`
+	TaskQueue  TaskQueueConfig  `mapstructure:"task_queue"`
 }
 
 type ServerConfig struct {
 	Port int         `mapstructure:"port"`
 	Mode string      `mapstructure:"mode"`
 	TLS  TLSConfig   `mapstructure:"tls"`
+	// ShutdownTimeoutSeconds 收到停止信号后等待请求与执行中任务完成的时间，超时后中断的任务下次启动继续
+	ShutdownTimeoutSeconds int `mapstructure:"shutdown_timeout_seconds"`
 }
 
 type TLSConfig struct {
@@ -42,11 +47,57 @@ type LogConfig struct {
 	Format string `mapstructure:"format"`
 }
 
+// ArtifactConfig 自动化任务产物存储配置
+type ArtifactConfig struct {
+	Backend       string           `mapstructure:"backend"` // local / s3
+	LocalDir      string           `mapstructure:"local_dir"`
+	InlineMaxKB   int              `mapstructure:"inline_max_kb"`
+	MaxUploadMB   int              `mapstructure:"max_upload_mb"`
+	RetentionDays int              `mapstructure:"retention_days"`
+	S3            ArtifactS3Config `mapstructure:"s3"`
+}
+
+type ArtifactS3Config struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+}
+
+type AutomationConfig struct {
+	// AgentToken 执行端调用 /automation/agent/* 时需携带的 X-Agent-Token，为空时心跳/拉取/回报不校验，产物上传拒绝
+	AgentToken string `mapstructure:"agent_token"`
+	// BreakerThreshold 同一店铺同类故障连续出现多少次后熔断
+	BreakerThreshold int `mapstructure:"breaker_threshold"`
+	// BreakerCooldownMinutes 熔断持续时间，到期后放行一次试探
+	BreakerCooldownMinutes int `mapstructure:"breaker_cooldown_minutes"`
+}
+
+// TaskQueueConfig 后台任务队列配置
+type TaskQueueConfig struct {
+	Workers        int `mapstructure:"workers"`
+	PollIntervalMs int `mapstructure:"poll_interval_ms"`
+	// RetentionDays 成功任务保留天数，失败任务不自动清理
+	RetentionDays int `mapstructure:"retention_days"`
+}
+
 var GlobalConfig *Config
 
 func LoadConfig(path string) (*Config, error) {
 	viper.SetConfigFile(path)
 	viper.SetConfigType("yaml")
+	viper.SetDefault("artifact.backend", "local")
+	viper.SetDefault("artifact.local_dir", "data/artifacts")
+	viper.SetDefault("artifact.inline_max_kb", 256)
+	viper.SetDefault("artifact.max_upload_mb", 20)
+	viper.SetDefault("artifact.retention_days", 30)
+	viper.SetDefault("automation.breaker_threshold", 5)
+	viper.SetDefault("automation.breaker_cooldown_minutes", 30)
+	viper.SetDefault("task_queue.workers", 4)
+	viper.SetDefault("task_queue.poll_interval_ms", 1000)
+	viper.SetDefault("task_queue.retention_days", 7)
+	viper.SetDefault("server.shutdown_timeout_seconds", 30)
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
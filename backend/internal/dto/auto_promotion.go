@@ -1,36 +1,119 @@
 package dto
 
+// AutoPromotionSelectionRule 自动加促销选品规则，各条件之间为“且”，未填写的条件不限制
+type AutoPromotionSelectionRule struct {
+	// ListingDateFrom / ListingDateTo 上架日期区间（YYYY-MM-DD，含首尾），未填写时使用目标日期当天
+	ListingDateFrom string `json:"listing_date_from,omitempty"`
+	ListingDateTo   string `json:"listing_date_to,omitempty"`
+	// CategoryIDs / TypeIDs Ozon 类目与类型 ID，命中任一即可
+	CategoryIDs []int64 `json:"category_ids,omitempty"`
+	TypeIDs     []int64 `json:"type_ids,omitempty"`
+	MinStockFBO *int    `json:"min_stock_fbo,omitempty"`
+	MaxStockFBO *int    `json:"max_stock_fbo,omitempty"`
+	MinStockFBS *int    `json:"min_stock_fbs,omitempty"`
+	MaxStockFBS *int    `json:"max_stock_fbs,omitempty"`
+	// MinPrice / MaxPrice 按 Ozon 目录当前售价
+	MinPrice *float64 `json:"min_price,omitempty"`
+	MaxPrice *float64 `json:"max_price,omitempty"`
+	// MinDiscountPercent / MaxDiscountPercent 按各活动候选的折扣比例，超出范围视为该活动不可加入
+	MinDiscountPercent *float64 `json:"min_discount_percent,omitempty"`
+	MaxDiscountPercent *float64 `json:"max_discount_percent,omitempty"`
+	// Visibilities Ozon 目录可见性，命中任一即可
+	Visibilities []string `json:"visibilities,omitempty"`
+	// Tags 商品本地标签，包含任一即可；ExcludeTags 包含任一即排除
+	Tags        []string `json:"tags,omitempty"`
+	ExcludeTags []string `json:"exclude_tags,omitempty"`
+	// IncludeSKUs 额外纳入的 SKU，不受上架日期限制；ExcludeSKUs 始终排除
+	IncludeSKUs []string `json:"include_skus,omitempty"`
+	ExcludeSKUs []string `json:"exclude_skus,omitempty"`
+	// CandidateMatch all：需是全部所选活动的候选；any：是任一活动的候选即可，只加入可加入的活动
+	CandidateMatch string `json:"candidate_match,omitempty"`
+}
+
 type AutoPromotionConfigRequest struct {
+	// ID 为空时：新建接口创建配置，旧的单配置接口更新店铺最早的配置
+	ID                uint   `json:"id"`
 	ShopID            uint   `json:"shop_id" binding:"required"`
+	Name              string `json:"name"`
 	Enabled           bool   `json:"enabled"`
 	ScheduleTime      string `json:"schedule_time"`
-	TargetDate        string `json:"target_date" binding:"required"`
+	TargetDate        string `json:"target_date"`
 	OfficialActionIDs []uint `json:"official_action_ids"`
 	ShopActionIDs     []uint `json:"shop_action_ids"`
+	// TargetMode fixed_date（默认，按 TargetDate）/ days_before（触发日前第 N 天）/ recent_days（触发日前 N 天内且未报名）
+	TargetMode       string `json:"target_mode"`
+	TargetOffsetDays int    `json:"target_offset_days"`
+	// SelectionRules 为空时不限制
+	SelectionRules *AutoPromotionSelectionRule `json:"selection_rules"`
 }
 
 type AutoPromotionConfigResponse struct {
 	ID                uint   `json:"id,omitempty"`
 	ShopID            uint   `json:"shop_id"`
+	Name              string `json:"name"`
 	Enabled           bool   `json:"enabled"`
 	ScheduleTime      string `json:"schedule_time"`
 	TargetDate        string `json:"target_date"`
 	OfficialActionIDs []uint `json:"official_action_ids"`
 	ShopActionIDs     []uint `json:"shop_action_ids"`
 	UpdatedAt         string `json:"updated_at,omitempty"`
+
+	TargetMode       string                     `json:"target_mode"`
+	TargetOffsetDays int                        `json:"target_offset_days"`
+	SelectionRules   AutoPromotionSelectionRule `json:"selection_rules"`
+	// Conflicts 与同店铺其他已启用配置的冲突，只对已启用的配置计算
+	Conflicts []AutoPromotionConfigConflict `json:"conflicts,omitempty"`
+}
+
+// AutoPromotionConfigConflict 配置冲突：schedule 执行时间相同（依次执行）；overlap 选品范围与活动重叠（会重复提交）
+type AutoPromotionConfigConflict struct {
+	ConfigID uint   `json:"config_id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Message  string `json:"message"`
 }
 
 type AutoPromotionRunRequest struct {
-	ShopID            uint   `json:"shop_id" binding:"required"`
-	TargetDate        string `json:"target_date" binding:"required"`
+	ShopID uint `json:"shop_id" binding:"required"`
+	// ConfigID 手动执行归属的配置，为空时不归属任何配置
+	ConfigID          *uint  `json:"config_id"`
+	TargetDate        string `json:"target_date"`
 	OfficialActionIDs []uint `json:"official_action_ids"`
 	ShopActionIDs     []uint `json:"shop_action_ids"`
+
+	TargetMode       string                      `json:"target_mode"`
+	TargetOffsetDays int                         `json:"target_offset_days"`
+	SelectionRules   *AutoPromotionSelectionRule `json:"selection_rules"`
+}
+
+type AutoPromotionPreviewItem struct {
+	OzonProductID   int64                       `json:"ozon_product_id"`
+	SourceSKU       string                      `json:"source_sku"`
+	ProductName     string                      `json:"product_name"`
+	ListingDate     string                      `json:"listing_date,omitempty"`
+	OfficialResults []AutoPromotionActionResult `json:"official_results,omitempty"`
+	ShopResults     []AutoPromotionActionResult `json:"shop_results,omitempty"`
+	// Reason 被排除的原因，只在 excluded 中返回
+	Reason string `json:"reason,omitempty"`
+}
+
+type AutoPromotionPreviewResponse struct {
+	ListingDateFrom string                     `json:"listing_date_from"`
+	ListingDateTo   string                     `json:"listing_date_to"`
+	TotalScanned    int                        `json:"total_scanned"`
+	Matched         []AutoPromotionPreviewItem `json:"matched"`
+	Excluded        []AutoPromotionPreviewItem `json:"excluded"`
+}
+
+type AutoPromotionRetryRequest struct {
+	ShopID uint `json:"shop_id" binding:"required"`
 }
 
 type AutoPromotionRunListRequest struct {
-	ShopID   uint `form:"shop_id" binding:"required"`
-	Page     int  `form:"page,default=1"`
-	PageSize int  `form:"page_size,default=20"`
+	ShopID   uint  `form:"shop_id" binding:"required"`
+	ConfigID *uint `form:"config_id"`
+	Page     int   `form:"page,default=1"`
+	PageSize int   `form:"page_size,default=20"`
 }
 
 type AutoPromotionActionResult struct {
@@ -43,6 +126,8 @@ type AutoPromotionActionResult struct {
 	Error             string  `json:"error,omitempty"`
 	ActionPrice       float64 `json:"action_price,omitempty"`
 	MaxActionPrice    float64 `json:"max_action_price,omitempty"`
+	// JobID 店铺活动提交的 automation job，续跑时等待该任务而不重复提交
+	JobID uint `json:"job_id,omitempty"`
 }
 
 type AutoPromotionRunItemResponse struct {
@@ -61,6 +146,8 @@ type AutoPromotionRunItemResponse struct {
 
 type AutoPromotionRunSummaryResponse struct {
 	ID              uint   `json:"id"`
+	ConfigID        *uint  `json:"config_id,omitempty"`
+	ConfigName      string `json:"config_name,omitempty"`
 	TriggerMode     string `json:"trigger_mode"`
 	TriggerDate     string `json:"trigger_date"`
 	TargetDate      string `json:"target_date"`
@@ -85,10 +172,15 @@ type AutoPromotionRunListResponse struct {
 type AutoPromotionRunDetailResponse struct {
 	AutoPromotionRunSummaryResponse
 	ShopID            uint                      `json:"shop_id"`
-	ConfigID          *uint                     `json:"config_id,omitempty"`
 	TriggeredBy       *uint                     `json:"triggered_by,omitempty"`
 	ScheduleTime      string                    `json:"schedule_time,omitempty"`
 	OfficialActionIDs []uint                    `json:"official_action_ids"`
 	ShopActionIDs     []uint                    `json:"shop_action_ids"`
+	SelectionRules    AutoPromotionSelectionRule `json:"selection_rules"`
+	TargetMode        string                    `json:"target_mode"`
+	TargetOffsetDays  int                       `json:"target_offset_days"`
+	// ListingDateFrom / ListingDateTo 本次运行实际使用的上架日期区间
+	ListingDateFrom string `json:"listing_date_from,omitempty"`
+	ListingDateTo   string `json:"listing_date_to,omitempty"`
 	Items             []AutoPromotionRunItemResponse `json:"items"`
 }
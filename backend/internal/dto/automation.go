@@ -32,6 +32,8 @@ type AutomationJobSummary struct {
 	CreatedBy    uint    `json:"created_by"`
 	JobType      string  `json:"job_type"`
 	Status       string  `json:"status"`
+	Priority     int     `json:"priority"`
+	WorkflowID   *uint   `json:"workflow_id,omitempty"`
 	DryRun       bool    `json:"dry_run"`
 	TotalItems   int     `json:"total_items"`
 	SuccessItems int     `json:"success_items"`
@@ -40,6 +42,23 @@ type AutomationJobSummary struct {
 	CompletedAt  *string `json:"completed_at,omitempty"`
 }
 
+// AutomationWorkflowResponse 工作流及其任务的汇总状态
+type AutomationWorkflowResponse struct {
+	ID            uint                   `json:"id"`
+	ShopID        uint                   `json:"shop_id"`
+	CreatedBy     uint                   `json:"created_by"`
+	WorkflowType  string                 `json:"workflow_type"`
+	Status        string                 `json:"status"`
+	TotalJobs     int                    `json:"total_jobs"`
+	CompletedJobs int                    `json:"completed_jobs"`
+	FailedJobs    int                    `json:"failed_jobs"`
+	ErrorMessage  string                 `json:"error_message"`
+	Result        map[string]interface{} `json:"result,omitempty"`
+	CreatedAt     string                 `json:"created_at"`
+	CompletedAt   *string                `json:"completed_at,omitempty"`
+	Jobs          []AutomationJobSummary `json:"jobs"`
+}
+
 type AutomationJobItemDetail struct {
 	ID                uint    `json:"id"`
 	ProductID         *uint   `json:"product_id,omitempty"`
@@ -52,6 +71,9 @@ type AutomationJobItemDetail struct {
 	StepExitError     string  `json:"step_exit_error,omitempty"`
 	StepRepriceError  string  `json:"step_reprice_error,omitempty"`
 	StepReaddError    string  `json:"step_readd_error,omitempty"`
+	RetryCount        int     `json:"retry_count"`
+	NextRetryAt       *string `json:"next_retry_at,omitempty"`
+	LastErrorKind     string  `json:"last_error_kind,omitempty"`
 }
 
 type AutomationJobDetailResponse struct {
@@ -60,6 +82,9 @@ type AutomationJobDetailResponse struct {
 	CreatedBy            uint                      `json:"created_by"`
 	JobType              string                    `json:"job_type"`
 	Status               string                    `json:"status"`
+	Priority             int                       `json:"priority"`
+	WorkflowID           *uint                     `json:"workflow_id,omitempty"`
+	OnSuccess            string                    `json:"on_success,omitempty"`
 	DryRun               bool                      `json:"dry_run"`
 	RequiresConfirmation bool                      `json:"requires_confirmation"`
 	RateLimit            int                       `json:"rate_limit"`
@@ -67,6 +92,8 @@ type AutomationJobDetailResponse struct {
 	SuccessItems         int                       `json:"success_items"`
 	FailedItems          int                       `json:"failed_items"`
 	ErrorMessage         string                    `json:"error_message,omitempty"`
+	RequeueCount         int                       `json:"requeue_count"`
+	NextRetryAt          *string                   `json:"next_retry_at,omitempty"`
 	CreatedAt            string                    `json:"created_at"`
 	UpdatedAt            string                    `json:"updated_at"`
 	StartedAt            *string                   `json:"started_at,omitempty"`
@@ -75,10 +102,19 @@ type AutomationJobDetailResponse struct {
 }
 
 type AgentHeartbeatRequest struct {
-	AgentKey     string                 `json:"agent_key" binding:"required"`
-	Name         string                 `json:"name" binding:"required"`
-	Hostname     string                 `json:"hostname"`
-	Capabilities map[string]interface{} `json:"capabilities"`
+	AgentKey string `json:"agent_key" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Hostname string `json:"hostname"`
+	// ProtocolVersion 执行端实现的协议版本，未填写按 v1 处理
+	ProtocolVersion int                    `json:"protocol_version"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+}
+
+type AgentHeartbeatResponse struct {
+	AgentID         uint   `json:"agent_id"`
+	AgentKey        string `json:"agent_key"`
+	Status          string `json:"status"`
+	ProtocolVersion int    `json:"protocol_version"`
 }
 
 type AgentPollRequest struct {
@@ -97,6 +133,8 @@ type AgentJobPayload struct {
 	RateLimit int                       `json:"rate_limit"`
 	Items     []AutomationJobCreateItem `json:"items"`
 	Meta      map[string]interface{}    `json:"meta,omitempty"`
+	// ProtocolVersion 组装该任务所用的协议版本
+	ProtocolVersion int `json:"protocol_version"`
 }
 
 type AgentReportRequest struct {
@@ -152,11 +190,79 @@ type AutomationEventListResponse struct {
 }
 
 type AgentStatusItem struct {
-	ID              uint    `json:"id"`
-	AgentKey        string  `json:"agent_key"`
-	Name            string  `json:"name"`
-	Hostname        string  `json:"hostname"`
-	Status          string  `json:"status"`
-	LastHeartbeatAt *string `json:"last_heartbeat_at,omitempty"`
-	UpdatedAt       string  `json:"updated_at"`
+	ID              uint     `json:"id"`
+	AgentKey        string   `json:"agent_key"`
+	Name            string   `json:"name"`
+	Hostname        string   `json:"hostname"`
+	Status          string   `json:"status"`
+	Kind            string   `json:"kind,omitempty"`
+	Version         string   `json:"version,omitempty"`
+	JobTypes        []string `json:"job_types"`
+	ShopIDs         []uint   `json:"shop_ids"`
+	MaxConcurrency  int      `json:"max_concurrency"`
+	RunningJobs     int      `json:"running_jobs"`
+	LastHeartbeatAt *string  `json:"last_heartbeat_at,omitempty"`
+	UpdatedAt       string   `json:"updated_at"`
+}
+
+// AgentCapabilities 执行端在心跳中声明的能力，job_types/shop_ids 为空表示不限制
+type AgentCapabilities struct {
+	Kind           string   `json:"kind,omitempty"`
+	Version        string   `json:"version,omitempty"`
+	JobTypes       []string `json:"job_types,omitempty"`
+	ShopIDs        []uint   `json:"shop_ids,omitempty"`
+	MaxConcurrency int      `json:"max_concurrency,omitempty"`
+	// ProtocolVersion 服务端与执行端协商后的协议版本，由服务端写入
+	ProtocolVersion int `json:"protocol_version,omitempty"`
+}
+
+type PendingJobDiagnosisRequest struct {
+	ShopID uint `form:"shop_id" binding:"required"`
+}
+
+type ExecutorIneligibility struct {
+	AgentKey string `json:"agent_key"`
+	Name     string `json:"name"`
+	Reason   string `json:"reason"`
+}
+
+type PendingJobDiagnosis struct {
+	JobID         uint                    `json:"job_id"`
+	JobType       string                  `json:"job_type"`
+	Priority      int                     `json:"priority"`
+	ExecutionMode string                  `json:"execution_mode"`
+	CreatedAt     string                  `json:"created_at"`
+	Eligible      []string                `json:"eligible"`
+	Ineligible    []ExecutorIneligibility `json:"ineligible"`
+	Summary       string                  `json:"summary,omitempty"`
+}
+
+type AutomationQueueMetricItem struct {
+	ShopID           uint   `json:"shop_id"`
+	ShopName         string `json:"shop_name"`
+	JobType          string `json:"job_type"`
+	Status           string `json:"status"`
+	Depth            int64  `json:"depth"`
+	MaxPriority      int    `json:"max_priority"`
+	OldestCreatedAt  string `json:"oldest_created_at"`
+	OldestAgeSeconds int64  `json:"oldest_age_seconds"`
+}
+
+type AutomationQueueMetricsResponse struct {
+	GeneratedAt   string                      `json:"generated_at"`
+	TotalPending  int64                       `json:"total_pending"`
+	TotalRunning  int64                       `json:"total_running"`
+	TotalAwaiting int64                       `json:"total_awaiting"`
+	Items         []AutomationQueueMetricItem `json:"items"`
+}
+
+type AutomationArtifactItem struct {
+	ID           uint   `json:"id"`
+	JobID        uint   `json:"job_id"`
+	ArtifactType string `json:"artifact_type"`
+	StoragePath  string `json:"storage_path"`
+	Checksum     string `json:"checksum"`
+	ContentType  string `json:"content_type"`
+	SizeBytes    int64  `json:"size_bytes"`
+	CreatedAt    string `json:"created_at"`
 }
@@ -0,0 +1,64 @@
+package dto
+
+// BulkOperationOfficialAction 快照中商品参与的官方活动
+type BulkOperationOfficialAction struct {
+	ActionID    int64   `json:"action_id"`
+	ActionPrice float64 `json:"action_price"`
+}
+
+// BulkOperationShopAction 操作会涉及的店铺活动，Joined 为操作前商品是否在该活动中
+type BulkOperationShopAction struct {
+	PromotionActionID uint   `json:"promotion_action_id"`
+	Title             string `json:"title"`
+	Joined            bool   `json:"joined"`
+}
+
+type BulkOperationListRequest struct {
+	ShopID        uint   `form:"shop_id" binding:"required"`
+	OperationType string `form:"operation_type"`
+	Page          int    `form:"page,default=1"`
+	PageSize      int    `form:"page_size,default=20"`
+}
+
+// BulkOperationRevertRequest 撤销批量操作
+type BulkOperationRevertRequest struct {
+	ShopID uint `json:"shop_id" binding:"required"`
+}
+
+type BulkOperationItemResponse struct {
+	ID              uint                          `json:"id"`
+	ProductID       uint                          `json:"product_id"`
+	SourceSKU       string                        `json:"source_sku"`
+	OzonProductID   int64                         `json:"ozon_product_id"`
+	PreviousPrice   float64                       `json:"previous_price"`
+	WasPromoted     bool                          `json:"was_promoted"`
+	OfficialActions []BulkOperationOfficialAction `json:"official_actions"`
+	ShopActions     []BulkOperationShopAction     `json:"shop_actions"`
+	RevertStatus    string                        `json:"revert_status"`
+	RevertNote      string                        `json:"revert_note,omitempty"`
+	RevertedAt      string                        `json:"reverted_at,omitempty"`
+}
+
+type BulkOperationResponse struct {
+	ID            uint                        `json:"id"`
+	ShopID        uint                        `json:"shop_id"`
+	OperationType string                      `json:"operation_type"`
+	Status        string                      `json:"status"`
+	CreatedBy     uint                        `json:"created_by"`
+	ItemCount     int                         `json:"item_count"`
+	JobIDs        []uint                      `json:"job_ids"`
+	RestoredCount int                         `json:"restored_count"`
+	FailedCount   int                         `json:"failed_count"`
+	RevertJobIDs  []uint                      `json:"revert_job_ids"`
+	ErrorMessage  string                      `json:"error_message,omitempty"`
+	RevertedAt    string                      `json:"reverted_at,omitempty"`
+	CreatedAt     string                      `json:"created_at"`
+	Items         []BulkOperationItemResponse `json:"items,omitempty"`
+}
+
+type BulkOperationListResponse struct {
+	Total    int64                   `json:"total"`
+	Page     int                     `json:"page"`
+	PageSize int                     `json:"page_size"`
+	Items    []BulkOperationResponse `json:"items"`
+}
@@ -5,16 +5,20 @@ type ExtensionRegisterRequest struct {
 	ExtensionID string `json:"extension_id" binding:"required,max=120"`
 	Name        string `json:"name" binding:"max=120"`
 	Version     string `json:"version" binding:"max=60"`
+	// ProtocolVersion 插件实现的协议版本，未填写按 v1 处理
+	ProtocolVersion int `json:"protocol_version"`
 }
 
 type ExtensionRegisterResponse struct {
-	AgentKey       string `json:"agent_key"`
-	PollIntervalMS int    `json:"poll_interval_ms"`
+	AgentKey        string `json:"agent_key"`
+	PollIntervalMS  int    `json:"poll_interval_ms"`
+	ProtocolVersion int    `json:"protocol_version"`
 }
 
 type ExtensionPollRequest struct {
-	ShopID      uint   `json:"shop_id" binding:"required"`
-	ExtensionID string `json:"extension_id" binding:"required,max=120"`
+	ShopID          uint   `json:"shop_id" binding:"required"`
+	ExtensionID     string `json:"extension_id" binding:"required,max=120"`
+	ProtocolVersion int    `json:"protocol_version"`
 }
 
 type ExtensionPollResponse struct {
@@ -34,6 +38,7 @@ type ExtensionRepriceRequest struct {
 	ShopID    uint    `json:"shop_id" binding:"required"`
 	SourceSKU string  `json:"source_sku" binding:"required"`
 	NewPrice  float64 `json:"new_price" binding:"required,gt=0"`
+	JobID     *uint   `json:"job_id,omitempty"` // 所属自动化任务，用于价格变动流水归属
 }
 
 type ExtensionRepriceResponse struct {
@@ -0,0 +1,50 @@
+package dto
+
+// OperationPlan 试运行（dry_run）返回的执行计划：按实际执行顺序列出会发起的 Ozon 接口调用
+// 与会创建的店铺活动任务，生成计划时不调用 Ozon、不写库、不创建任务
+type OperationPlan struct {
+	OfficialCalls []PlannedOzonCall `json:"official_calls"`
+	ShopJobs      []PlannedShopJob  `json:"shop_jobs"`
+	// Skipped 实际执行时会被跳过的 SKU
+	Skipped []PlannedSkip `json:"skipped"`
+	// Warnings 不影响计划内容但会导致实际执行失败的情况，如店铺当前被禁止写入
+	Warnings []string `json:"warnings"`
+}
+
+// PlannedOzonCall 单次 Ozon 接口调用
+type PlannedOzonCall struct {
+	// Operation activate / deactivate / update_price
+	Operation     string  `json:"operation"`
+	Endpoint      string  `json:"endpoint"`
+	ActionID      int64   `json:"action_id,omitempty"`
+	ActionTitle   string  `json:"action_title,omitempty"`
+	SourceSKU     string  `json:"source_sku"`
+	OzonProductID int64   `json:"ozon_product_id"`
+	Price         float64 `json:"price,omitempty"`
+}
+
+type PlannedShopAction struct {
+	PromotionActionID uint   `json:"promotion_action_id"`
+	SourceActionID    string `json:"source_action_id"`
+	Title             string `json:"title"`
+}
+
+type PlannedJobItem struct {
+	SourceSKU   string  `json:"source_sku"`
+	TargetPrice float64 `json:"target_price,omitempty"`
+}
+
+// PlannedShopJob 会创建的浏览器插件任务
+type PlannedShopJob struct {
+	JobType string `json:"job_type"`
+	// Operation promo_unified_* 任务的 declare / remove；Reason remove_reprice_readd 任务的 meta.reason
+	Operation   string              `json:"operation,omitempty"`
+	Reason      string              `json:"reason,omitempty"`
+	ShopActions []PlannedShopAction `json:"shop_actions"`
+	Items       []PlannedJobItem    `json:"items"`
+}
+
+type PlannedSkip struct {
+	SourceSKU string `json:"source_sku"`
+	Reason    string `json:"reason"`
+}
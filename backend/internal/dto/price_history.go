@@ -0,0 +1,37 @@
+package dto
+
+// PriceHistoryListRequest 价格变动查询，日期为 YYYY-MM-DD（含首尾），不填不限制
+type PriceHistoryListRequest struct {
+	ShopID    uint   `form:"shop_id" binding:"required"`
+	SourceSKU string `form:"source_sku"`
+	Source    string `form:"source"`
+	PriceType string `form:"price_type"`
+	DateFrom  string `form:"date_from"`
+	DateTo    string `form:"date_to"`
+	Page      int    `form:"page,default=1"`
+	PageSize  int    `form:"page_size,default=20"`
+}
+
+type PriceHistoryItem struct {
+	ID            uint    `json:"id"`
+	ProductID     *uint   `json:"product_id,omitempty"`
+	OzonProductID int64   `json:"ozon_product_id"`
+	SourceSKU     string  `json:"source_sku"`
+	PriceType     string  `json:"price_type"`
+	OldPrice      float64 `json:"old_price"`
+	NewPrice      float64 `json:"new_price"`
+	Currency      string  `json:"currency,omitempty"`
+	ActionID      int64   `json:"action_id,omitempty"`
+	Source        string  `json:"source"`
+	ChangedBy     *uint   `json:"changed_by,omitempty"`
+	JobID         *uint   `json:"job_id,omitempty"`
+	Reference     string  `json:"reference,omitempty"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+type PriceHistoryListResponse struct {
+	Total    int64              `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+	Items    []PriceHistoryItem `json:"items"`
+}
@@ -0,0 +1,67 @@
+package dto
+
+type PricePlanItemInput struct {
+	SourceSKU   string  `json:"source_sku" binding:"required"`
+	TargetPrice float64 `json:"target_price" binding:"required,gt=0"`
+}
+
+// CreatePricePlanRequest 创建定时改价计划，时间格式为 YYYY-MM-DD HH:mm（服务器时区）
+type CreatePricePlanRequest struct {
+	ShopID  uint                 `json:"shop_id" binding:"required"`
+	Name    string               `json:"name" binding:"required,max=100"`
+	StartAt string               `json:"start_at" binding:"required"`
+	EndAt   string               `json:"end_at" binding:"required"`
+	Items   []PricePlanItemInput `json:"items" binding:"required,min=1,dive"`
+}
+
+type PricePlanListRequest struct {
+	ShopID   uint   `form:"shop_id" binding:"required"`
+	Status   string `form:"status"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=20"`
+}
+
+// PricePlanOperateRequest 取消或提前结束计划
+type PricePlanOperateRequest struct {
+	ShopID uint `json:"shop_id" binding:"required"`
+}
+
+type PricePlanItemResponse struct {
+	ID            uint    `json:"id"`
+	ProductID     uint    `json:"product_id"`
+	SourceSKU     string  `json:"source_sku"`
+	OzonProductID int64   `json:"ozon_product_id"`
+	TargetPrice   float64 `json:"target_price"`
+	PreviousPrice float64 `json:"previous_price"`
+	Status        string  `json:"status"`
+	Note          string  `json:"note,omitempty"`
+	AppliedAt     string  `json:"applied_at,omitempty"`
+	RevertedAt    string  `json:"reverted_at,omitempty"`
+}
+
+type PricePlanResponse struct {
+	ID            uint                    `json:"id"`
+	ShopID        uint                    `json:"shop_id"`
+	Name          string                  `json:"name"`
+	Status        string                  `json:"status"`
+	StartAt       string                  `json:"start_at"`
+	EndAt         string                  `json:"end_at"`
+	CreatedBy     uint                    `json:"created_by"`
+	ItemCount     int                     `json:"item_count"`
+	AppliedCount  int                     `json:"applied_count"`
+	SkippedCount  int                     `json:"skipped_count"`
+	FailedCount   int                     `json:"failed_count"`
+	RevertedCount int                     `json:"reverted_count"`
+	ErrorMessage  string                  `json:"error_message,omitempty"`
+	AppliedAt     string                  `json:"applied_at,omitempty"`
+	RevertedAt    string                  `json:"reverted_at,omitempty"`
+	CreatedAt     string                  `json:"created_at"`
+	Items         []PricePlanItemResponse `json:"items,omitempty"`
+}
+
+type PricePlanListResponse struct {
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+	Items    []PricePlanResponse `json:"items"`
+}
@@ -0,0 +1,93 @@
+package dto
+
+// PromotionCalendarRequest 促销日历（只读本地活动缓存，不调用 Ozon）
+type PromotionCalendarRequest struct {
+	ShopID uint `form:"shop_id" binding:"required"`
+	// From / To 查询日期范围（YYYY-MM-DD，含首尾），默认从今天起 7 天
+	From string `form:"from"`
+	To   string `form:"to"`
+	// GroupBy sku（默认）/ category
+	GroupBy string `form:"group_by"`
+	// Keyword 按 SKU、商品名称或类目模糊筛选
+	Keyword string `form:"keyword"`
+	// Issue 只返回存在该情况的分组：overlap / drop_out / candidate
+	Issue    string `form:"issue"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=20"`
+}
+
+// PromotionCalendarAction 查询范围内的活动时间线
+type PromotionCalendarAction struct {
+	PromotionActionID uint   `json:"promotion_action_id"`
+	ActionID          int64  `json:"action_id,omitempty"`
+	SourceActionID    string `json:"source_action_id,omitempty"`
+	Source            string `json:"source"`
+	Title             string `json:"title"`
+	DateStart         string `json:"date_start,omitempty"`
+	DateEnd           string `json:"date_end,omitempty"`
+	// Phase running（已开始）/ upcoming（范围内开始）
+	Phase              string `json:"phase"`
+	ParticipatingCount int    `json:"participating_count"`
+	CandidateCount     int    `json:"candidate_count"`
+}
+
+// PromotionCalendarOverlap 同一商品同时参与的两个活动及重叠时间段
+type PromotionCalendarOverlap struct {
+	FirstActionID  uint     `json:"first_action_id"`
+	SecondActionID uint     `json:"second_action_id"`
+	From           string   `json:"from"`
+	To             string   `json:"to"`
+	SKUCount       int      `json:"sku_count"`
+	SKUs           []string `json:"skus,omitempty"`
+}
+
+// PromotionCalendarDropOut 活动结束后商品不再参与任何活动
+type PromotionCalendarDropOut struct {
+	At              string `json:"at"`
+	EndingActionIDs []uint `json:"ending_action_ids"`
+	// ResumeAt 范围内重新进入活动的时间，为空表示到范围结束都不在活动中
+	ResumeAt        string `json:"resume_at,omitempty"`
+	ResumeActionIDs []uint `json:"resume_action_ids,omitempty"`
+	// CandidateActionIDs 脱离时正在进行、商品是候选的活动，可用于补位
+	CandidateActionIDs []uint   `json:"candidate_action_ids"`
+	SKUCount           int      `json:"sku_count"`
+	SKUs               []string `json:"skus,omitempty"`
+}
+
+// PromotionCalendarCandidate 商品是候选、尚未参与的活动
+type PromotionCalendarCandidate struct {
+	PromotionActionID uint     `json:"promotion_action_id"`
+	SKUCount          int      `json:"sku_count"`
+	SKUs              []string `json:"skus,omitempty"`
+}
+
+// PromotionCalendarGroup 单个 SKU 或类目的日历；按类目分组时各项带 SKU 数与示例 SKU
+type PromotionCalendarGroup struct {
+	Key        string                       `json:"key"`
+	Name       string                       `json:"name"`
+	Category   string                       `json:"category"`
+	SKUCount   int                          `json:"sku_count"`
+	ActionIDs  []uint                       `json:"action_ids"`
+	Overlaps   []PromotionCalendarOverlap   `json:"overlaps"`
+	DropOuts   []PromotionCalendarDropOut   `json:"drop_outs"`
+	Candidates []PromotionCalendarCandidate `json:"candidates"`
+}
+
+type PromotionCalendarSummary struct {
+	SKUCount          int `json:"sku_count"`
+	OverlapSKUCount   int `json:"overlap_sku_count"`
+	DropOutSKUCount   int `json:"drop_out_sku_count"`
+	CandidateSKUCount int `json:"candidate_sku_count"`
+}
+
+type PromotionCalendarResponse struct {
+	From     string                    `json:"from"`
+	To       string                    `json:"to"`
+	GroupBy  string                    `json:"group_by"`
+	Actions  []PromotionCalendarAction `json:"actions"`
+	Summary  PromotionCalendarSummary  `json:"summary"`
+	Total    int64                     `json:"total"`
+	Page     int                       `json:"page"`
+	PageSize int                       `json:"page_size"`
+	Groups   []PromotionCalendarGroup  `json:"groups"`
+}
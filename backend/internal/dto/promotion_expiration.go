@@ -0,0 +1,53 @@
+package dto
+
+// UpdateActionSuccessorsRequest 设置活动到期后自动报名的后续活动，为空表示不自动报名
+type UpdateActionSuccessorsRequest struct {
+	ShopID             uint   `json:"shop_id" binding:"required"`
+	SuccessorActionIDs []uint `json:"successor_action_ids"`
+}
+
+type PromotionExpirationListRequest struct {
+	ShopID uint `form:"shop_id" binding:"required"`
+	// UnreadOnly 只返回有商品脱离全部活动且尚未查看的提醒
+	UnreadOnly bool `form:"unread_only"`
+	Page       int  `form:"page,default=1"`
+	PageSize   int  `form:"page_size,default=20"`
+}
+
+// PromotionExpirationReadRequest 标记到期提醒已读，IDs 为空时标记店铺全部提醒
+type PromotionExpirationReadRequest struct {
+	ShopID uint   `json:"shop_id" binding:"required"`
+	IDs    []uint `json:"ids"`
+}
+
+type PromotionExpirationResponse struct {
+	ID                uint     `json:"id"`
+	ShopID            uint     `json:"shop_id"`
+	PromotionActionID uint     `json:"promotion_action_id"`
+	ActionID          int64    `json:"action_id"`
+	Source            string   `json:"source"`
+	Title             string   `json:"title"`
+	DateEnd           string   `json:"date_end"`
+	Status            string   `json:"status"`
+	SKUCount          int      `json:"sku_count"`
+	ExitedCount       int      `json:"exited_count"`
+	UnpromotedCount   int      `json:"unpromoted_count"`
+	SuccessorEnrolled int      `json:"successor_enrolled"`
+	SuccessorFailed   int      `json:"successor_failed"`
+	JobIDs            []uint   `json:"job_ids"`
+	UncoveredCount    int      `json:"uncovered_count"`
+	UncoveredSKUs     []string `json:"uncovered_skus,omitempty"`
+	ErrorMessage      string   `json:"error_message,omitempty"`
+	Read              bool     `json:"read"`
+	CompletedAt       string   `json:"completed_at,omitempty"`
+	CreatedAt         string   `json:"created_at"`
+}
+
+type PromotionExpirationListResponse struct {
+	Total int64 `json:"total"`
+	// UnreadCount 有商品脱离全部活动且尚未查看的提醒数，用于页面角标
+	UnreadCount int64                         `json:"unread_count"`
+	Page        int                           `json:"page"`
+	PageSize    int                           `json:"page_size"`
+	Items       []PromotionExpirationResponse `json:"items"`
+}
@@ -0,0 +1,65 @@
+package dto
+
+type ProductParticipationRequest struct {
+	ShopID   uint `form:"shop_id" binding:"required"`
+	Page     int  `form:"page,default=1"`
+	PageSize int  `form:"page_size,default=20"`
+}
+
+// ActionParticipationRequest 活动加入 / 退出报表查询，日期为 YYYY-MM-DD（含首尾），不填不限制
+type ActionParticipationRequest struct {
+	ShopID    uint   `form:"shop_id" binding:"required"`
+	DateFrom  string `form:"date_from"`
+	DateTo    string `form:"date_to"`
+	EventType string `form:"event_type"` // joined / left，为空时两者都返回
+	Page      int    `form:"page,default=1"`
+	PageSize  int    `form:"page_size,default=20"`
+}
+
+type PromotionParticipationEventResponse struct {
+	ID            uint    `json:"id"`
+	ProductID     uint    `json:"product_id"`
+	SourceSKU     string  `json:"source_sku"`
+	OzonProductID int64   `json:"ozon_product_id"`
+	EventType     string  `json:"event_type"`
+	ActionSource  string  `json:"action_source,omitempty"`
+	ActionID      int64   `json:"action_id,omitempty"`
+	ActionTitle   string  `json:"action_title,omitempty"`
+	ActionPrice   float64 `json:"action_price,omitempty"`
+	Price         float64 `json:"price,omitempty"`
+	PreviousPrice float64 `json:"previous_price,omitempty"`
+	Origin        string  `json:"origin"`
+	TriggeredBy   *uint   `json:"triggered_by,omitempty"`
+	Reference     string  `json:"reference,omitempty"`
+	Note          string  `json:"note,omitempty"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+// ProductParticipationResponse 单个商品的促销参与时间线
+type ProductParticipationResponse struct {
+	ProductID uint                                  `json:"product_id"`
+	SourceSKU string                                `json:"source_sku"`
+	Total     int64                                 `json:"total"`
+	Page      int                                   `json:"page"`
+	PageSize  int                                   `json:"page_size"`
+	Items     []PromotionParticipationEventResponse `json:"items"`
+}
+
+// ActionParticipationResponse 活动在时间范围内的加入 / 退出报表
+type ActionParticipationResponse struct {
+	ActionID       uint   `json:"action_id"`
+	OzonActionID   int64  `json:"ozon_action_id"`
+	ActionTitle    string `json:"action_title"`
+	Source         string `json:"source"`
+	DateFrom       string `json:"date_from,omitempty"`
+	DateTo         string `json:"date_to,omitempty"`
+	JoinedCount    int64  `json:"joined_count"`
+	LeftCount      int64  `json:"left_count"`
+	JoinedProducts int64  `json:"joined_products"`
+	LeftProducts   int64  `json:"left_products"`
+
+	Total    int64                                 `json:"total"`
+	Page     int                                   `json:"page"`
+	PageSize int                                   `json:"page_size"`
+	Items    []PromotionParticipationEventResponse `json:"items"`
+}
@@ -0,0 +1,51 @@
+package dto
+
+// PromotionReconcileRequest 手动触发促销状态对账
+type PromotionReconcileRequest struct {
+	ShopID uint `json:"shop_id" binding:"required"`
+}
+
+type PromotionReconcileListRequest struct {
+	ShopID   uint `form:"shop_id" binding:"required"`
+	Page     int  `form:"page,default=1"`
+	PageSize int  `form:"page_size,default=20"`
+}
+
+// PromotionDriftItem 一条本地与 Ozon 不一致的推广状态及处理结果
+type PromotionDriftItem struct {
+	Type          string `json:"type"`
+	ProductID     uint   `json:"product_id,omitempty"`
+	SourceSKU     string `json:"source_sku,omitempty"`
+	OzonProductID int64  `json:"ozon_product_id,omitempty"`
+	ActionID      int64  `json:"action_id,omitempty"`
+	ActionTitle   string `json:"action_title,omitempty"`
+	Detail        string `json:"detail"`
+}
+
+type PromotionReconcileReportResponse struct {
+	ID              uint                 `json:"id"`
+	ShopID          uint                 `json:"shop_id"`
+	TriggerMode     string               `json:"trigger_mode"`
+	Status          string               `json:"status"`
+	ActionsChecked  int                  `json:"actions_checked"`
+	ActionsFailed   int                  `json:"actions_failed"`
+	RemoteProducts  int                  `json:"remote_products"`
+	AddedCount      int                  `json:"added_count"`
+	ExitedCount     int                  `json:"exited_count"`
+	FlagsFixedCount int                  `json:"flags_fixed_count"`
+	UnknownCount    int                  `json:"unknown_count"`
+	ErrorMessage    string               `json:"error_message,omitempty"`
+	StartedAt       string               `json:"started_at,omitempty"`
+	CompletedAt     string               `json:"completed_at,omitempty"`
+	CreatedAt       string               `json:"created_at"`
+	Drift           []PromotionDriftItem `json:"drift,omitempty"`
+	// DriftTruncated 差异条数超过保存上限时为 true，计数字段仍为完整统计
+	DriftTruncated bool `json:"drift_truncated,omitempty"`
+}
+
+type PromotionReconcileListResponse struct {
+	Total    int64                              `json:"total"`
+	Page     int                                `json:"page"`
+	PageSize int                                `json:"page_size"`
+	Items    []PromotionReconcileReportResponse `json:"items"`
+}
@@ -0,0 +1,62 @@
+package dto
+
+// PromotionSimulationRequest 促销模拟（只读本地缓存，不调用 Ozon）
+type PromotionSimulationRequest struct {
+	ShopID uint `json:"shop_id" binding:"required"`
+	// ConfigID 不为空时按自动加促销配置的活动与选品规则模拟，忽略下面的活动与 SKU
+	ConfigID          *uint    `json:"config_id"`
+	OfficialActionIDs []uint   `json:"official_action_ids"`
+	ShopActionIDs     []uint   `json:"shop_action_ids"`
+	SourceSKUs        []string `json:"source_skus"`
+}
+
+// PromotionSimulationItem 单个 SKU 在单个活动中的模拟结果
+type PromotionSimulationItem struct {
+	PromotionActionID uint   `json:"promotion_action_id"`
+	ActionTitle       string `json:"action_title"`
+	Source            string `json:"source"`
+	SourceSKU         string `json:"source_sku"`
+	ProductName       string `json:"product_name"`
+	// Status candidate / already_active / ineligible
+	Status          string  `json:"status"`
+	Reason          string  `json:"reason,omitempty"`
+	CurrentPrice    float64 `json:"current_price"`
+	ActionPrice     float64 `json:"action_price"`
+	MaxActionPrice  float64 `json:"max_action_price"`
+	DiscountPercent float64 `json:"discount_percent"`
+	// HasCost 为 false 时未录入成本价，毛利相关字段为 0
+	HasCost       bool    `json:"has_cost"`
+	Cost          float64 `json:"cost"`
+	MarginPercent float64 `json:"margin_percent"`
+	ProfitPerUnit float64 `json:"profit_per_unit"`
+}
+
+// PromotionSimulationActionSummary 单个活动的汇总，金额按每个参与 SKU 各售出一件计算
+type PromotionSimulationActionSummary struct {
+	PromotionActionID  uint    `json:"promotion_action_id"`
+	ActionID           int64   `json:"action_id,omitempty"`
+	SourceActionID     string  `json:"source_action_id,omitempty"`
+	Title              string  `json:"title"`
+	Source             string  `json:"source"`
+	CandidateCount     int     `json:"candidate_count"`
+	AlreadyActiveCount int     `json:"already_active_count"`
+	IneligibleCount    int     `json:"ineligible_count"`
+	AvgDiscountPercent float64 `json:"avg_discount_percent"`
+	CurrentRevenue     float64 `json:"current_revenue"`
+	ActionRevenue      float64 `json:"action_revenue"`
+	// 以下只统计已录入成本价的 SKU
+	WithCostCount    int     `json:"with_cost_count"`
+	ActionProfit     float64 `json:"action_profit"`
+	AvgMarginPercent float64 `json:"avg_margin_percent"`
+	LossCount        int     `json:"loss_count"`
+}
+
+type PromotionSimulationResponse struct {
+	// ListingDateFrom / ListingDateTo 按配置模拟时解析出的上架日期区间
+	ListingDateFrom string                             `json:"listing_date_from,omitempty"`
+	ListingDateTo   string                             `json:"listing_date_to,omitempty"`
+	Actions         []PromotionSimulationActionSummary `json:"actions"`
+	Items           []PromotionSimulationItem          `json:"items"`
+	// MissingSKUs 本地商品库中不存在的 SKU
+	MissingSKUs []string `json:"missing_skus"`
+}
@@ -0,0 +1,128 @@
+package dto
+
+// RepriceRuleRequest 创建或更新改价规则
+type RepriceRuleRequest struct {
+	ID                  uint    `json:"-"`
+	ShopID              uint    `json:"shop_id" binding:"required"`
+	Name                string  `json:"name" binding:"required,max=100"`
+	Enabled             bool    `json:"enabled"`
+	Priority            int     `json:"priority"`
+	SKUPrefix           string  `json:"sku_prefix" binding:"max=120"`
+	TargetMarginPercent float64 `json:"target_margin_percent" binding:"gte=0,lt=100"`
+	KeepActionEligible  bool    `json:"keep_action_eligible"`
+	PriceEnding         string  `json:"price_ending"`
+	MinPrice            float64 `json:"min_price" binding:"gte=0"`
+	MaxPrice            float64 `json:"max_price" binding:"gte=0"`
+}
+
+type RepriceRuleResponse struct {
+	ID                  uint    `json:"id"`
+	ShopID              uint    `json:"shop_id"`
+	Name                string  `json:"name"`
+	Enabled             bool    `json:"enabled"`
+	Priority            int     `json:"priority"`
+	SKUPrefix           string  `json:"sku_prefix"`
+	TargetMarginPercent float64 `json:"target_margin_percent"`
+	KeepActionEligible  bool    `json:"keep_action_eligible"`
+	PriceEnding         string  `json:"price_ending"`
+	MinPrice            float64 `json:"min_price"`
+	MaxPrice            float64 `json:"max_price"`
+	CreatedBy           uint    `json:"created_by"`
+	CreatedAt           string  `json:"created_at"`
+	UpdatedAt           string  `json:"updated_at"`
+}
+
+type ProductCostInput struct {
+	SourceSKU string  `json:"source_sku" binding:"required"`
+	Cost      float64 `json:"cost" binding:"gte=0"` // 0 表示删除该 SKU 的成本价
+}
+
+// SaveProductCostsRequest 批量写入成本价
+type SaveProductCostsRequest struct {
+	ShopID uint               `json:"shop_id" binding:"required"`
+	Items  []ProductCostInput `json:"items" binding:"required,min=1,dive"`
+}
+
+type SaveProductCostsResponse struct {
+	SavedCount   int `json:"saved_count"`
+	DeletedCount int `json:"deleted_count"`
+}
+
+type ProductCostListRequest struct {
+	ShopID   uint   `form:"shop_id" binding:"required"`
+	Keyword  string `form:"keyword"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=20"`
+}
+
+type ProductCostItem struct {
+	SourceSKU string  `json:"source_sku"`
+	Cost      float64 `json:"cost"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+type ProductCostListResponse struct {
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+	Items    []ProductCostItem `json:"items"`
+}
+
+// RepricePreviewRequest 按规则计算建议价格（试运行，不改价）
+type RepricePreviewRequest struct {
+	ShopID uint `json:"shop_id" binding:"required"`
+	// RuleIDs 为空时使用全部已启用规则
+	RuleIDs []uint `json:"rule_ids"`
+	// SourceSKUs 为空时评估店铺全部商品
+	SourceSKUs  []string `json:"source_skus"`
+	OnlyChanges bool     `json:"only_changes"`
+}
+
+type RepriceProposal struct {
+	ProductID     uint    `json:"product_id"`
+	SourceSKU     string  `json:"source_sku"`
+	Name          string  `json:"name"`
+	RuleID        uint    `json:"rule_id"`
+	RuleName      string  `json:"rule_name"`
+	CurrentPrice  float64 `json:"current_price"`
+	Cost          float64 `json:"cost"`
+	ActionCeiling float64 `json:"action_ceiling"` // 所在及候选活动中最低的最高活动价，0 表示无限制
+	ProposedPrice float64 `json:"proposed_price"`
+	// Decision change / unchanged / skipped
+	Decision string `json:"decision"`
+	Note     string `json:"note,omitempty"`
+	// InShopActions 商品在店铺活动中，执行时通过“退出-改价-重新报名”任务改价
+	InShopActions bool `json:"in_shop_actions"`
+}
+
+type RepricePreviewSummary struct {
+	Evaluated     int `json:"evaluated"`
+	Changed       int `json:"changed"`
+	Unchanged     int `json:"unchanged"`
+	Skipped       int `json:"skipped"`
+	InShopActions int `json:"in_shop_actions"`
+}
+
+type RepricePreviewResponse struct {
+	Summary RepricePreviewSummary `json:"summary"`
+	Items   []RepriceProposal     `json:"items"`
+}
+
+// RepriceApplyRequest 执行人工确认后的建议价格
+type RepriceApplyRequest struct {
+	ShopID uint          `json:"shop_id" binding:"required"`
+	Items  []RepriceItem `json:"items" binding:"required,min=1,dive"`
+}
+
+type RepriceApplyFailure struct {
+	SourceSKU string `json:"source_sku"`
+	Message   string `json:"message"`
+}
+
+type RepriceApplyResponse struct {
+	DirectUpdated int                   `json:"direct_updated"`
+	JobItemCount  int                   `json:"job_item_count"`
+	JobIDs        []uint                `json:"job_ids"`
+	Failures      []RepriceApplyFailure `json:"failures"`
+	Message       string                `json:"message"`
+}
@@ -78,6 +78,35 @@ type ShopExecutionEngineResponse struct {
 	ExecutionEngineMode string `json:"execution_engine_mode"`
 }
 
+type UpdateShopWritePauseRequest struct {
+	Paused *bool  `json:"paused" binding:"required"`
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+type ResetShopCircuitBreakerRequest struct {
+	// Source 为空表示重置全部来源
+	Source string `json:"source" binding:"omitempty,oneof=executor ozon_api"`
+}
+
+type ShopCircuitBreakerStatus struct {
+	Source string `json:"source"`
+	// State closed / open / half_open（熔断期已过，等待试探结果）
+	State               string `json:"state"`
+	FailureClass        string `json:"failure_class,omitempty"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	LastError           string `json:"last_error,omitempty"`
+	OpenedAt            string `json:"opened_at,omitempty"`
+	OpenUntil           string `json:"open_until,omitempty"`
+}
+
+type ShopGuardStatus struct {
+	ShopID             uint                       `json:"shop_id"`
+	WritesPaused       bool                       `json:"writes_paused"`
+	WritesPausedReason string                     `json:"writes_paused_reason,omitempty"`
+	WritesPausedAt     string                     `json:"writes_paused_at,omitempty"`
+	Breakers           []ShopCircuitBreakerStatus `json:"breakers"`
+}
+
 // 商品相关
 type ProductListRequest struct {
 	ShopID     uint   `form:"shop_id"`
@@ -100,6 +129,7 @@ type ProductItem struct {
 	CurrentPrice float64         `json:"current_price"`
 	IsLoss       bool            `json:"is_loss"`
 	IsPromoted   bool            `json:"is_promoted"`
+	Tags         []string        `json:"tags"`
 	Promotions   []PromotionInfo `json:"promotions"`
 	LossInfo     *LossInfo       `json:"loss_info,omitempty"`
 }
@@ -120,6 +150,13 @@ type SyncProductsRequest struct {
 	ShopID uint `json:"shop_id" binding:"required"`
 }
 
+// UpdateProductTagsRequest 批量设置商品本地标签，Tags 整体替换原有标签
+type UpdateProductTagsRequest struct {
+	ShopID     uint     `json:"shop_id" binding:"required"`
+	ProductIDs []uint   `json:"product_ids" binding:"required,min=1"`
+	Tags       []string `json:"tags"`
+}
+
 // ========== 三层角色系统相关 ==========
 
 // 店铺管理员信息（系统管理员视角）
@@ -268,6 +305,9 @@ type ActionProductsResponse struct {
 	Page           int                 `json:"page"`
 	PageSize       int                 `json:"page_size"`
 	Items          []ActionProductItem `json:"items"`
+	// SyncPending 店铺活动商品已发起后台同步，Items 为缓存数据
+	SyncPending bool  `json:"sync_pending"`
+	WorkflowID  *uint `json:"workflow_id,omitempty"`
 }
 
 // 批量报名V2请求（支持选择具体活动）
@@ -276,6 +316,8 @@ type BatchEnrollV2Request struct {
 	ActionIDs       []int64 `json:"action_ids" binding:"required,min=1"`
 	ExcludeLoss     bool    `json:"exclude_loss"`
 	ExcludePromoted bool    `json:"exclude_promoted"`
+	// DryRun 只返回执行计划，不调用 Ozon
+	DryRun bool `json:"dry_run"`
 }
 
 // 处理亏损商品V2请求（支持选择重新报名活动）
@@ -319,6 +361,7 @@ type UnifiedEnrollRequest struct {
 	SourceSKUs      []string `json:"source_skus"`                         // 店铺活动用：指定 SKU 列表
 	ExcludeLoss     bool     `json:"exclude_loss"`                        // 官方活动用
 	ExcludePromoted bool     `json:"exclude_promoted"`                    // 官方活动用
+	DryRun          bool     `json:"dry_run"`                             // 只返回执行计划，不调用 Ozon、不创建任务
 }
 
 // UnifiedRemoveRequest 统一退出请求
@@ -326,13 +369,15 @@ type UnifiedRemoveRequest struct {
 	ShopID     uint     `json:"shop_id" binding:"required"`
 	ActionIDs  []uint   `json:"action_ids" binding:"required,min=1"`
 	SourceSKUs []string `json:"source_skus" binding:"required,min=1"`
+	DryRun     bool     `json:"dry_run"`
 }
 
 // UnifiedOperationResponse 统一操作响应
 type UnifiedOperationResponse struct {
-	Mode    string               `json:"mode"`              // "sync" 或 "async"
+	Mode    string               `json:"mode"`              // "sync"、"async" 或 "dry_run"
 	Results *BatchEnrollResponse `json:"results,omitempty"` // sync 模式下的结果
 	JobID   *uint                `json:"job_id,omitempty"`  // async 模式下的 job ID
+	Plan    *OperationPlan       `json:"plan,omitempty"`    // dry_run 模式下的执行计划
 	Message string               `json:"message,omitempty"`
 }
 
@@ -341,6 +386,7 @@ type UnifiedProcessLossRequest struct {
 	ShopID          uint   `json:"shop_id" binding:"required"`
 	LossProductIDs  []uint `json:"loss_product_ids" binding:"required,min=1"`
 	RejoinActionIDs []uint `json:"rejoin_action_ids"`
+	DryRun          bool   `json:"dry_run"`
 }
 
 // UnifiedRepricePromoteRequest 统一改价推广请求
@@ -348,13 +394,15 @@ type UnifiedRepricePromoteRequest struct {
 	ShopID            uint          `json:"shop_id" binding:"required"`
 	Products          []RepriceItem `json:"products" binding:"required,min=1,dive"`
 	ReenrollActionIDs []uint        `json:"reenroll_action_ids"`
+	DryRun            bool          `json:"dry_run"`
 }
 
 // UnifiedProcessLossResponse 统一亏损处理响应
 type UnifiedProcessLossResponse struct {
-	Mode    string               `json:"mode"` // "sync" 或 "async"
+	Mode    string               `json:"mode"` // "sync"、"async" 或 "dry_run"
 	Result  *ProcessLossResponse `json:"result,omitempty"`
 	JobID   *uint                `json:"job_id,omitempty"`
+	Plan    *OperationPlan       `json:"plan,omitempty"`
 	Message string               `json:"message,omitempty"`
 }
 
@@ -369,8 +417,9 @@ type UnifiedRepricePromoteResult struct {
 
 // UnifiedRepricePromoteResponse 统一改价推广响应
 type UnifiedRepricePromoteResponse struct {
-	Mode    string                       `json:"mode"` // "sync" 或 "async"
+	Mode    string                       `json:"mode"` // "sync"、"async" 或 "dry_run"
 	Result  *UnifiedRepricePromoteResult `json:"result,omitempty"`
 	JobID   *uint                        `json:"job_id,omitempty"`
+	Plan    *OperationPlan               `json:"plan,omitempty"`
 	Message string                       `json:"message,omitempty"`
 }
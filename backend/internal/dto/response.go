@@ -12,6 +12,8 @@ type BatchEnrollResponse struct {
 	EnrolledCount int            `json:"enrolled_count"`
 	FailedCount   int            `json:"failed_count"`
 	Details       []EnrollDetail `json:"details,omitempty"`
+	// Plan 试运行时的执行计划，此时不返回 Details
+	Plan *OperationPlan `json:"plan,omitempty"`
 }
 
 type EnrollDetail struct {
@@ -113,6 +115,9 @@ type StatsOverview struct {
 	LossProducts       int64 `json:"loss_products"`
 	PromotedProducts   int64 `json:"promoted_products"`
 	PromotableProducts int64 `json:"promotable_products"`
+
+	// LastReconciledAt 最近一次成功与 Ozon 对账的时间，推广数量以此时的 Ozon 状态为准
+	LastReconciledAt string `json:"last_reconciled_at,omitempty"`
 }
 
 type SyncActionsSummary struct {
@@ -124,5 +129,6 @@ type SyncActionsResult struct {
 	Actions         interface{}        `json:"actions"`
 	SyncSummary     SyncActionsSummary `json:"sync_summary"`
 	ShopSyncPending bool               `json:"shop_sync_pending"`
+	WorkflowID      *uint              `json:"workflow_id,omitempty"`
 	PartialErrors   map[string]string  `json:"partial_errors"`
 }
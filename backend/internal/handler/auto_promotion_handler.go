@@ -1,13 +1,17 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"ozon-manager/internal/dto"
 	"ozon-manager/internal/middleware"
+	"ozon-manager/internal/model"
 	"ozon-manager/internal/service"
+	"ozon-manager/pkg/excel"
 )
 
 type AutoPromotionHandler struct {
@@ -68,6 +72,124 @@ func (h *AutoPromotionHandler) UpdateConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "保存成功", Data: resp})
 }
 
+// ListConfigs 列出店铺的全部自动加促销配置
+func (h *AutoPromotionHandler) ListConfigs(c *gin.Context) {
+	shopID, err := strconv.ParseUint(c.Query("shop_id"), 10, 32)
+	if err != nil || shopID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "缺少shop_id参数"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, uint(shopID), claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	resp, err := h.autoPromotionService.ListConfigs(uint(shopID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "获取自动加促销配置失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: resp})
+}
+
+// CreateConfig 新建具名配置
+func (h *AutoPromotionHandler) CreateConfig(c *gin.Context) {
+	var req dto.AutoPromotionConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	c.Set("shop_id", req.ShopID)
+
+	resp, err := h.autoPromotionService.CreateConfig(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "创建自动加促销配置失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "创建成功", Data: resp})
+}
+
+// UpdateConfigByID 更新指定配置
+func (h *AutoPromotionHandler) UpdateConfigByID(c *gin.Context) {
+	configID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || configID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "无效的配置ID"})
+		return
+	}
+
+	var req dto.AutoPromotionConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+	req.ID = uint(configID)
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	c.Set("shop_id", req.ShopID)
+
+	resp, err := h.autoPromotionService.UpdateConfig(&req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.Response{Code: 404, Message: "配置不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "保存自动加促销配置失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "保存成功", Data: resp})
+}
+
+// DeleteConfig 删除配置，历史运行保留
+func (h *AutoPromotionHandler) DeleteConfig(c *gin.Context) {
+	configID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || configID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "无效的配置ID"})
+		return
+	}
+
+	shopID, err := strconv.ParseUint(c.Query("shop_id"), 10, 32)
+	if err != nil || shopID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "缺少shop_id参数"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, uint(shopID), claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	c.Set("shop_id", uint(shopID))
+
+	if err := h.autoPromotionService.DeleteConfig(uint(shopID), uint(configID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.Response{Code: 404, Message: "配置不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "删除自动加促销配置失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "删除成功"})
+}
+
 func (h *AutoPromotionHandler) StartRun(c *gin.Context) {
 	var req dto.AutoPromotionRunRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -85,6 +207,9 @@ func (h *AutoPromotionHandler) StartRun(c *gin.Context) {
 
 	resp, err := h.autoPromotionService.StartManualRun(claims.UserID, &req)
 	if err != nil {
+		if respondShopBlocked(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "触发自动加促销失败: " + err.Error()})
 		return
 	}
@@ -92,6 +217,29 @@ func (h *AutoPromotionHandler) StartRun(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "已创建自动加促销任务", Data: resp})
 }
 
+// Preview 按选品规则预览会选中的商品及其余商品的排除原因，不创建运行
+func (h *AutoPromotionHandler) Preview(c *gin.Context) {
+	var req dto.AutoPromotionRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	resp, err := h.autoPromotionService.PreviewSelection(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "预览选品失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: resp})
+}
+
 func (h *AutoPromotionHandler) ListRuns(c *gin.Context) {
 	var req dto.AutoPromotionRunListRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
@@ -141,3 +289,141 @@ func (h *AutoPromotionHandler) GetRunDetail(c *gin.Context) {
 
 	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: resp})
 }
+
+func (h *AutoPromotionHandler) RetryFailedItems(c *gin.Context) {
+	runID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || runID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "无效的任务ID"})
+		return
+	}
+
+	var req dto.AutoPromotionRetryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	c.Set("shop_id", req.ShopID)
+
+	resp, err := h.autoPromotionService.RetryFailedItems(claims.UserID, req.ShopID, uint(runID))
+	if err != nil {
+		if respondShopBlocked(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "重试失败商品失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "已重新提交失败商品", Data: resp})
+}
+
+// Simulate 模拟报名促销的活动价、折扣与毛利，不调用 Ozon
+// POST /api/v1/promotions/simulate
+func (h *AutoPromotionHandler) Simulate(c *gin.Context) {
+	var req dto.PromotionSimulationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	resp, err := h.autoPromotionService.Simulate(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "促销模拟失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: resp})
+}
+
+// ExportSimulation 导出促销模拟结果
+// POST /api/v1/promotions/simulate/export
+func (h *AutoPromotionHandler) ExportSimulation(c *gin.Context) {
+	var req dto.PromotionSimulationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	resp, err := h.autoPromotionService.Simulate(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "促销模拟失败: " + err.Error()})
+		return
+	}
+
+	summaries := make([]excel.SimulationActionSummary, 0, len(resp.Actions))
+	for _, action := range resp.Actions {
+		summaries = append(summaries, excel.SimulationActionSummary{
+			Title:              action.Title,
+			Source:             action.Source,
+			CandidateCount:     action.CandidateCount,
+			AlreadyActiveCount: action.AlreadyActiveCount,
+			IneligibleCount:    action.IneligibleCount,
+			AvgDiscountPercent: action.AvgDiscountPercent,
+			CurrentRevenue:     action.CurrentRevenue,
+			ActionRevenue:      action.ActionRevenue,
+			WithCostCount:      action.WithCostCount,
+			ActionProfit:       action.ActionProfit,
+			AvgMarginPercent:   action.AvgMarginPercent,
+			LossCount:          action.LossCount,
+		})
+	}
+	items := make([]excel.SimulationItem, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		items = append(items, excel.SimulationItem{
+			ActionTitle:     item.ActionTitle,
+			SourceSKU:       item.SourceSKU,
+			ProductName:     item.ProductName,
+			Status:          simulationStatusLabel(item.Status),
+			Reason:          item.Reason,
+			CurrentPrice:    item.CurrentPrice,
+			ActionPrice:     item.ActionPrice,
+			MaxActionPrice:  item.MaxActionPrice,
+			DiscountPercent: item.DiscountPercent,
+			HasCost:         item.HasCost,
+			Cost:            item.Cost,
+			MarginPercent:   item.MarginPercent,
+			ProfitPerUnit:   item.ProfitPerUnit,
+		})
+	}
+
+	f, err := excel.ExportPromotionSimulation(summaries, items)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "生成Excel失败"})
+		return
+	}
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", "attachment; filename=promotion_simulation.xlsx")
+	if err := f.Write(c.Writer); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "写入Excel失败"})
+	}
+}
+
+func simulationStatusLabel(status string) string {
+	switch status {
+	case model.PromotionActionCandidateStatusCandidate:
+		return "候选"
+	case model.PromotionActionCandidateStatusAlreadyActive:
+		return "已在活动中"
+	default:
+		return "不符合"
+	}
+}
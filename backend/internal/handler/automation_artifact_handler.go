@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/middleware"
+	"ozon-manager/internal/model"
+	"ozon-manager/internal/service"
+)
+
+// AgentUploadArtifact 本地执行端上传产物（multipart：agent_key、job_id、artifact_type、file、可选 sha256）
+func (h *AutomationHandler) AgentUploadArtifact(c *gin.Context) {
+	maxBytes := h.automationService.MaxArtifactUploadBytes()
+	limitArtifactUploadBody(c, maxBytes)
+	upload, err := readArtifactUpload(c, maxBytes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: err.Error()})
+		return
+	}
+	agentKey := strings.TrimSpace(c.PostForm("agent_key"))
+	if agentKey == "" {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "missing agent_key"})
+		return
+	}
+
+	artifact, err := h.automationService.UploadAgentArtifact(agentKey, upload)
+	if err != nil {
+		status := artifactUploadErrorStatus(err)
+		c.JSON(status, dto.Response{Code: status, Message: "failed to upload artifact: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "artifact uploaded", Data: buildAutomationArtifactItem(artifact)})
+}
+
+// UploadArtifact 浏览器插件上传产物（multipart：shop_id、extension_id、job_id、artifact_type、file、可选 sha256）
+func (h *ExtensionHandler) UploadArtifact(c *gin.Context) {
+	maxBytes := h.automationService.MaxArtifactUploadBytes()
+	limitArtifactUploadBody(c, maxBytes)
+	shopID, err := strconv.ParseUint(c.PostForm("shop_id"), 10, 32)
+	if err != nil || shopID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "missing shop_id"})
+		return
+	}
+	extensionID := strings.TrimSpace(c.PostForm("extension_id"))
+	if extensionID == "" {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "missing extension_id"})
+		return
+	}
+	upload, err := readArtifactUpload(c, maxBytes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: err.Error()})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, uint(shopID), claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "no access to this shop"})
+		return
+	}
+
+	c.Set("shop_id", uint(shopID))
+
+	artifact, err := h.automationService.UploadExtensionArtifact(claims.UserID, uint(shopID), extensionID, upload)
+	if err != nil {
+		status := artifactUploadErrorStatus(err)
+		c.JSON(status, dto.Response{Code: status, Message: "failed to upload artifact: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "artifact uploaded", Data: buildAutomationArtifactItem(artifact)})
+}
+
+func (h *AutomationHandler) GetJobArtifacts(c *gin.Context) {
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "invalid job id"})
+		return
+	}
+
+	shopID, err := strconv.ParseUint(c.Query("shop_id"), 10, 32)
+	if err != nil || shopID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "missing shop_id"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, uint(shopID), claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "no access to this shop"})
+		return
+	}
+
+	artifacts, err := h.automationService.ListJobArtifacts(uint(shopID), uint(jobID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Response{Code: 404, Message: "automation job not found"})
+		return
+	}
+
+	items := make([]dto.AutomationArtifactItem, 0, len(artifacts))
+	for index := range artifacts {
+		items = append(items, buildAutomationArtifactItem(&artifacts[index]))
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: items})
+}
+
+// DownloadArtifact 下载产物原始内容，服务端读取时校验 SHA-256
+func (h *AutomationHandler) DownloadArtifact(c *gin.Context) {
+	artifactID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "invalid artifact id"})
+		return
+	}
+
+	shopID, err := strconv.ParseUint(c.Query("shop_id"), 10, 32)
+	if err != nil || shopID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "missing shop_id"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, uint(shopID), claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "no access to this shop"})
+		return
+	}
+
+	artifact, data, err := h.automationService.DownloadArtifact(uint(shopID), uint(artifactID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Response{Code: 404, Message: "artifact not available: " + err.Error()})
+		return
+	}
+
+	contentType := artifact.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=artifact_%d_%s", artifact.ID, artifact.ArtifactType))
+	c.Header("X-Checksum-Sha256", artifact.Checksum)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// artifactMultipartOverhead 上传请求体中表单字段与 multipart 边界的额外余量
+const artifactMultipartOverhead = 1 << 20
+
+// artifactUploadErrorStatus 调用方错误映射为 4xx，其余（存储后端故障等）为 500
+func artifactUploadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrArtifactUploadInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrArtifactUploaderUnknown), errors.Is(err, service.ErrArtifactJobForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrArtifactJobNotRunning):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// limitArtifactUploadBody 在解析表单前限制请求体大小，避免超大文件先被读入内存或临时文件
+func limitArtifactUploadBody(c *gin.Context, maxBytes int64) {
+	if maxBytes > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+artifactMultipartOverhead)
+	}
+}
+
+func readArtifactUpload(c *gin.Context, maxBytes int64) (*service.AutomationArtifactUpload, error) {
+	jobID, err := strconv.ParseUint(c.PostForm("job_id"), 10, 32)
+	if err != nil || jobID == 0 {
+		return nil, fmt.Errorf("missing job_id")
+	}
+	artifactType := strings.TrimSpace(c.PostForm("artifact_type"))
+	if artifactType == "" || len(artifactType) > 50 {
+		return nil, fmt.Errorf("invalid artifact_type")
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, fmt.Errorf("artifact exceeds max upload size of %d bytes", maxBytes)
+		}
+		return nil, fmt.Errorf("please upload a file")
+	}
+	defer file.Close()
+	if maxBytes > 0 && header.Size > maxBytes {
+		return nil, fmt.Errorf("artifact exceeds max upload size of %d bytes", maxBytes)
+	}
+
+	var reader io.Reader = file
+	if maxBytes > 0 {
+		reader = io.LimitReader(file, maxBytes+1)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file")
+	}
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("artifact exceeds max upload size of %d bytes", maxBytes)
+	}
+
+	return &service.AutomationArtifactUpload{
+		JobID:        uint(jobID),
+		ArtifactType: artifactType,
+		FileName:     header.Filename,
+		ContentType:  uploadContentType(header),
+		Data:         data,
+		SHA256:       c.PostForm("sha256"),
+	}, nil
+}
+
+func uploadContentType(header *multipart.FileHeader) string {
+	if header == nil {
+		return ""
+	}
+	return header.Header.Get("Content-Type")
+}
+
+func buildAutomationArtifactItem(artifact *model.AutomationArtifact) dto.AutomationArtifactItem {
+	return dto.AutomationArtifactItem{
+		ID:           artifact.ID,
+		JobID:        artifact.JobID,
+		ArtifactType: artifact.ArtifactType,
+		StoragePath:  artifact.StoragePath,
+		Checksum:     artifact.Checksum,
+		ContentType:  artifact.ContentType,
+		SizeBytes:    artifact.SizeBytes,
+		CreatedAt:    artifact.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
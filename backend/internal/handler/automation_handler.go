@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -10,6 +11,7 @@ import (
 	"ozon-manager/internal/middleware"
 	"ozon-manager/internal/model"
 	"ozon-manager/internal/service"
+	"ozon-manager/pkg/automation/protocol"
 )
 
 type AutomationHandler struct {
@@ -72,26 +74,8 @@ func (h *AutomationHandler) GetJobs(c *gin.Context) {
 	}
 
 	items := make([]dto.AutomationJobSummary, 0, len(jobs))
-	for _, job := range jobs {
-		var completedAt *string
-		if job.CompletedAt != nil {
-			formatted := job.CompletedAt.Format("2006-01-02 15:04:05")
-			completedAt = &formatted
-		}
-
-		items = append(items, dto.AutomationJobSummary{
-			ID:           job.ID,
-			ShopID:       job.ShopID,
-			CreatedBy:    job.CreatedBy,
-			JobType:      job.JobType,
-			Status:       job.Status,
-			DryRun:       job.DryRun,
-			TotalItems:   job.TotalItems,
-			SuccessItems: job.SuccessItems,
-			FailedItems:  job.FailedItems,
-			CreatedAt:    job.CreatedAt.Format("2006-01-02 15:04:05"),
-			CompletedAt:  completedAt,
-		})
+	for index := range jobs {
+		items = append(items, buildAutomationJobSummary(&jobs[index]))
 	}
 
 	c.JSON(http.StatusOK, dto.Response{
@@ -136,6 +120,38 @@ func (h *AutomationHandler) GetJobDetail(c *gin.Context) {
 	})
 }
 
+func (h *AutomationHandler) GetWorkflow(c *gin.Context) {
+	workflowID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "invalid workflow id"})
+		return
+	}
+
+	shopID, err := strconv.ParseUint(c.Query("shop_id"), 10, 32)
+	if err != nil || shopID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "missing shop_id"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, uint(shopID), claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "no access to this shop"})
+		return
+	}
+
+	workflow, err := h.automationService.GetWorkflow(uint(shopID), uint(workflowID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.Response{Code: 404, Message: "automation workflow not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    200,
+		Message: "success",
+		Data:    buildAutomationWorkflow(workflow),
+	})
+}
+
 func (h *AutomationHandler) AgentHeartbeat(c *gin.Context) {
 	var req dto.AgentHeartbeatRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -143,8 +159,11 @@ func (h *AutomationHandler) AgentHeartbeat(c *gin.Context) {
 		return
 	}
 
-	agent, err := h.automationService.AgentHeartbeat(&req)
+	data, err := h.automationService.AgentHeartbeat(&req)
 	if err != nil {
+		if respondIncompatibleProtocol(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "failed to update heartbeat"})
 		return
 	}
@@ -152,11 +171,7 @@ func (h *AutomationHandler) AgentHeartbeat(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.Response{
 		Code:    200,
 		Message: "heartbeat accepted",
-		Data: map[string]interface{}{
-			"agent_id":  agent.ID,
-			"agent_key": agent.AgentKey,
-			"status":    agent.Status,
-		},
+		Data:    data,
 	})
 }
 
@@ -169,6 +184,9 @@ func (h *AutomationHandler) AgentPoll(c *gin.Context) {
 
 	job, err := h.automationService.AgentPoll(&req)
 	if err != nil {
+		if respondIncompatibleProtocol(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "failed to poll job: " + err.Error()})
 		return
 	}
@@ -182,47 +200,11 @@ func (h *AutomationHandler) AgentPoll(c *gin.Context) {
 		return
 	}
 
-	items := make([]dto.AutomationJobCreateItem, 0, len(job.Items))
-	for _, item := range job.Items {
-		items = append(items, dto.AutomationJobCreateItem{
-			SourceSKU:   item.SourceSKU,
-			TargetPrice: item.TargetPrice,
-		})
-	}
-
-	meta := map[string]interface{}{}
-	switch job.JobType {
-	case model.AutomationJobTypeSyncActionCandidates:
-		if artifact, err := h.automationService.GetLatestArtifact(job.ID, "sync_action_candidates_meta"); err == nil {
-			_ = json.Unmarshal(artifact.Meta, &meta)
-		}
-	case model.AutomationJobTypeSyncActionProducts:
-		if artifact, err := h.automationService.GetLatestArtifact(job.ID, "sync_action_products_meta"); err == nil {
-			_ = json.Unmarshal(artifact.Meta, &meta)
-		}
-	case model.AutomationJobTypeShopActionDeclare, model.AutomationJobTypeShopActionRemove:
-		if artifact, err := h.automationService.GetLatestArtifact(job.ID, "shop_action_meta"); err == nil {
-			_ = json.Unmarshal(artifact.Meta, &meta)
-		}
-	case model.AutomationJobTypePromoUnifiedEnroll, model.AutomationJobTypePromoUnifiedRemove:
-		if artifact, err := h.automationService.GetLatestArtifact(job.ID, "promo_unified_meta"); err == nil {
-			_ = json.Unmarshal(artifact.Meta, &meta)
-		}
-	}
-
 	c.JSON(http.StatusOK, dto.Response{
 		Code:    200,
 		Message: "job assigned",
 		Data: dto.AgentPollResponse{
-			Job: &dto.AgentJobPayload{
-				JobID:     job.ID,
-				ShopID:    job.ShopID,
-				JobType:   job.JobType,
-				DryRun:    job.DryRun,
-				RateLimit: job.RateLimit,
-				Items:     items,
-				Meta:      meta,
-			},
+			Job: h.automationService.BuildJobPayload(job, dispatchableJobItems(job.Items)),
 		},
 	})
 }
@@ -377,15 +359,29 @@ func (h *AutomationHandler) GetAgentStatus(c *gin.Context) {
 		return
 	}
 
+	runningByAgent, err := h.automationService.CountRunningJobsByAgent()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "failed to list agents"})
+		return
+	}
+
 	items := make([]dto.AgentStatusItem, 0, len(agents))
-	for _, agent := range agents {
+	for index := range agents {
+		agent := &agents[index]
 		lastHeartbeatAt := service.FormatAutomationTime(agent.LastHeartbeatAt)
+		caps := service.AgentDeclaredCapabilities(agent)
 		items = append(items, dto.AgentStatusItem{
 			ID:              agent.ID,
 			AgentKey:        agent.AgentKey,
 			Name:            agent.Name,
 			Hostname:        agent.Hostname,
 			Status:          agent.Status,
+			Kind:            caps.Kind,
+			Version:         caps.Version,
+			JobTypes:        caps.JobTypes,
+			ShopIDs:         caps.ShopIDs,
+			MaxConcurrency:  caps.MaxConcurrency,
+			RunningJobs:     runningByAgent[agent.ID],
 			LastHeartbeatAt: lastHeartbeatAt,
 			UpdatedAt:       agent.UpdatedAt.Format("2006-01-02 15:04:05"),
 		})
@@ -394,6 +390,27 @@ func (h *AutomationHandler) GetAgentStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: items})
 }
 
+func (h *AutomationHandler) GetDispatchDiagnostics(c *gin.Context) {
+	var req dto.PendingJobDiagnosisRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "invalid query params"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "no access to this shop"})
+		return
+	}
+
+	items, err := h.automationService.DiagnosePendingJobs(req.ShopID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "failed to diagnose pending jobs"})
+		return
+	}
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: items})
+}
+
 func (h *AutomationHandler) GetExtensionStatus(c *gin.Context) {
 	items, err := h.automationService.GetExtensionStatus()
 	if err != nil {
@@ -403,6 +420,86 @@ func (h *AutomationHandler) GetExtensionStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: items})
 }
 
+func (h *AutomationHandler) GetQueueMetrics(c *gin.Context) {
+	metrics, err := h.automationService.GetQueueMetrics()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "failed to load queue metrics"})
+		return
+	}
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: metrics})
+}
+
+// respondIncompatibleProtocol 执行端协议版本不受支持时返回 426，提示升级执行端
+func respondIncompatibleProtocol(c *gin.Context, err error) bool {
+	var incompatible *protocol.IncompatibleVersionError
+	if !errors.As(err, &incompatible) {
+		return false
+	}
+	c.JSON(http.StatusUpgradeRequired, dto.Response{Code: http.StatusUpgradeRequired, Message: incompatible.Error()})
+	return true
+}
+
+// dispatchableJobItems 下发给执行端的明细：仅包含待执行的明细，重试轮次不重复执行已成功或死信的明细
+func dispatchableJobItems(jobItems []model.AutomationJobItem) []dto.AutomationJobCreateItem {
+	items := make([]dto.AutomationJobCreateItem, 0, len(jobItems))
+	for _, item := range jobItems {
+		if item.OverallStatus != "" && item.OverallStatus != model.AutomationStepStatusPending {
+			continue
+		}
+		items = append(items, dto.AutomationJobCreateItem{
+			SourceSKU:   item.SourceSKU,
+			TargetPrice: item.TargetPrice,
+		})
+	}
+	return items
+}
+
+func buildAutomationJobSummary(job *model.AutomationJob) dto.AutomationJobSummary {
+	return dto.AutomationJobSummary{
+		ID:           job.ID,
+		ShopID:       job.ShopID,
+		CreatedBy:    job.CreatedBy,
+		JobType:      job.JobType,
+		Status:       job.Status,
+		Priority:     job.Priority,
+		WorkflowID:   job.WorkflowID,
+		DryRun:       job.DryRun,
+		TotalItems:   job.TotalItems,
+		SuccessItems: job.SuccessItems,
+		FailedItems:  job.FailedItems,
+		CreatedAt:    job.CreatedAt.Format("2006-01-02 15:04:05"),
+		CompletedAt:  service.FormatAutomationTime(job.CompletedAt),
+	}
+}
+
+func buildAutomationWorkflow(workflow *model.AutomationWorkflow) dto.AutomationWorkflowResponse {
+	jobs := make([]dto.AutomationJobSummary, 0, len(workflow.Jobs))
+	for index := range workflow.Jobs {
+		jobs = append(jobs, buildAutomationJobSummary(&workflow.Jobs[index]))
+	}
+
+	var result map[string]interface{}
+	if len(workflow.Result) > 0 {
+		_ = json.Unmarshal(workflow.Result, &result)
+	}
+
+	return dto.AutomationWorkflowResponse{
+		ID:            workflow.ID,
+		ShopID:        workflow.ShopID,
+		CreatedBy:     workflow.CreatedBy,
+		WorkflowType:  workflow.WorkflowType,
+		Status:        workflow.Status,
+		TotalJobs:     workflow.TotalJobs,
+		CompletedJobs: workflow.CompletedJobs,
+		FailedJobs:    workflow.FailedJobs,
+		ErrorMessage:  workflow.ErrorMessage,
+		Result:        result,
+		CreatedAt:     workflow.CreatedAt.Format("2006-01-02 15:04:05"),
+		CompletedAt:   service.FormatAutomationTime(workflow.CompletedAt),
+		Jobs:          jobs,
+	}
+}
+
 func buildAutomationJobDetail(job *model.AutomationJob) dto.AutomationJobDetailResponse {
 	startedAt := service.FormatAutomationTime(job.StartedAt)
 	completedAt := service.FormatAutomationTime(job.CompletedAt)
@@ -413,6 +510,9 @@ func buildAutomationJobDetail(job *model.AutomationJob) dto.AutomationJobDetailR
 		CreatedBy:            job.CreatedBy,
 		JobType:              job.JobType,
 		Status:               job.Status,
+		Priority:             job.Priority,
+		WorkflowID:           job.WorkflowID,
+		OnSuccess:            job.OnSuccess,
 		DryRun:               job.DryRun,
 		RequiresConfirmation: job.RequiresConfirmation,
 		RateLimit:            job.RateLimit,
@@ -420,6 +520,8 @@ func buildAutomationJobDetail(job *model.AutomationJob) dto.AutomationJobDetailR
 		SuccessItems:         job.SuccessItems,
 		FailedItems:          job.FailedItems,
 		ErrorMessage:         job.ErrorMessage,
+		RequeueCount:         job.RequeueCount,
+		NextRetryAt:          service.FormatAutomationTime(job.NextRetryAt),
 		CreatedAt:            job.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:            job.UpdatedAt.Format("2006-01-02 15:04:05"),
 		StartedAt:            startedAt,
@@ -443,6 +545,9 @@ func mapAutomationJobItems(items []model.AutomationJobItem) []dto.AutomationJobI
 			StepExitError:     item.StepExitError,
 			StepRepriceError:  item.StepRepriceError,
 			StepReaddError:    item.StepReaddError,
+			RetryCount:        item.RetryCount,
+			NextRetryAt:       service.FormatAutomationTime(item.NextRetryAt),
+			LastErrorKind:     item.LastErrorKind,
 		})
 	}
 	return result
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/middleware"
+)
+
+// ListBulkOperations 批量操作快照列表
+// GET /api/v1/promotions/operations
+func (h *PromotionHandler) ListBulkOperations(c *gin.Context) {
+	var req dto.BulkOperationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	resp, err := h.promotionService.ListBulkOperations(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "获取操作记录失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: resp})
+}
+
+// GetBulkOperation 批量操作快照详情（含每个商品的撤销结果）
+// GET /api/v1/promotions/operations/:id
+func (h *PromotionHandler) GetBulkOperation(c *gin.Context) {
+	operationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || operationID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "无效的操作ID"})
+		return
+	}
+
+	shopID, err := strconv.ParseUint(c.Query("shop_id"), 10, 32)
+	if err != nil || shopID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "缺少shop_id参数"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, uint(shopID), claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	resp, err := h.promotionService.GetBulkOperation(uint(shopID), uint(operationID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.Response{Code: 404, Message: "操作记录不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "获取操作记录失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: resp})
+}
+
+// RevertBulkOperation 撤销批量操作，按快照恢复价格与活动参与
+// POST /api/v1/promotions/operations/:id/revert
+func (h *PromotionHandler) RevertBulkOperation(c *gin.Context) {
+	operationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || operationID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "无效的操作ID"})
+		return
+	}
+
+	var req dto.BulkOperationRevertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	c.Set("shop_id", req.ShopID)
+
+	resp, err := h.promotionService.RevertBulkOperation(claims.UserID, req.ShopID, uint(operationID))
+	if err != nil {
+		if respondShopBlocked(c, err) {
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.Response{Code: 404, Message: "操作记录不存在"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "撤销已开始", Data: resp})
+}
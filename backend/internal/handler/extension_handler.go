@@ -1,13 +1,11 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"ozon-manager/internal/dto"
 	"ozon-manager/internal/middleware"
-	"ozon-manager/internal/model"
 	"ozon-manager/internal/service"
 )
 
@@ -40,6 +38,9 @@ func (h *ExtensionHandler) Register(c *gin.Context) {
 
 	data, err := h.automationService.ExtensionRegister(claims.UserID, &req)
 	if err != nil {
+		if respondIncompatibleProtocol(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "failed to register extension: " + err.Error()})
 		return
 	}
@@ -62,6 +63,9 @@ func (h *ExtensionHandler) Poll(c *gin.Context) {
 
 	job, err := h.automationService.ExtensionPoll(claims.UserID, &req)
 	if err != nil {
+		if respondIncompatibleProtocol(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "failed to poll job: " + err.Error()})
 		return
 	}
@@ -74,51 +78,11 @@ func (h *ExtensionHandler) Poll(c *gin.Context) {
 		return
 	}
 
-	items := make([]dto.AutomationJobCreateItem, 0, len(job.Items))
-	for _, item := range job.Items {
-		items = append(items, dto.AutomationJobCreateItem{
-			SourceSKU:   item.SourceSKU,
-			TargetPrice: item.TargetPrice,
-		})
-	}
-
-	meta := map[string]interface{}{}
-	switch job.JobType {
-	case model.AutomationJobTypeSyncActionCandidates:
-		if artifact, err := h.automationService.GetLatestArtifact(job.ID, "sync_action_candidates_meta"); err == nil {
-			_ = json.Unmarshal(artifact.Meta, &meta)
-		}
-	case model.AutomationJobTypeSyncActionProducts:
-		if artifact, err := h.automationService.GetLatestArtifact(job.ID, "sync_action_products_meta"); err == nil {
-			_ = json.Unmarshal(artifact.Meta, &meta)
-		}
-	case model.AutomationJobTypeRemoveRepriceReadd:
-		if artifact, err := h.automationService.GetLatestArtifact(job.ID, "remove_reprice_readd_meta"); err == nil {
-			_ = json.Unmarshal(artifact.Meta, &meta)
-		}
-	case model.AutomationJobTypeShopActionDeclare, model.AutomationJobTypeShopActionRemove:
-		if artifact, err := h.automationService.GetLatestArtifact(job.ID, "shop_action_meta"); err == nil {
-			_ = json.Unmarshal(artifact.Meta, &meta)
-		}
-	case model.AutomationJobTypePromoUnifiedEnroll, model.AutomationJobTypePromoUnifiedRemove:
-		if artifact, err := h.automationService.GetLatestArtifact(job.ID, "promo_unified_meta"); err == nil {
-			_ = json.Unmarshal(artifact.Meta, &meta)
-		}
-	}
-
 	c.JSON(http.StatusOK, dto.Response{
 		Code:    200,
 		Message: "job assigned",
 		Data: dto.ExtensionPollResponse{
-			Job: &dto.AgentJobPayload{
-				JobID:     job.ID,
-				ShopID:    job.ShopID,
-				JobType:   job.JobType,
-				DryRun:    job.DryRun,
-				RateLimit: job.RateLimit,
-				Items:     items,
-				Meta:      meta,
-			},
+			Job: h.automationService.BuildJobPayload(job, dispatchableJobItems(job.Items)),
 		},
 	})
 }
@@ -161,7 +125,10 @@ func (h *ExtensionHandler) Reprice(c *gin.Context) {
 
 	c.Set("shop_id", req.ShopID)
 
-	if err := h.automationService.ExtensionRepriceProduct(req.ShopID, req.SourceSKU, req.NewPrice); err != nil {
+	if err := h.automationService.ExtensionRepriceProduct(claims.UserID, req.ShopID, req.SourceSKU, req.NewPrice, req.JobID); err != nil {
+		if respondShopBlocked(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "failed to reprice: " + err.Error()})
 		return
 	}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/middleware"
+	"ozon-manager/internal/service"
+)
+
+type PricePlanHandler struct {
+	pricePlanService *service.PricePlanService
+	shopService      *service.ShopService
+}
+
+func NewPricePlanHandler(pricePlanService *service.PricePlanService, shopService *service.ShopService) *PricePlanHandler {
+	return &PricePlanHandler{
+		pricePlanService: pricePlanService,
+		shopService:      shopService,
+	}
+}
+
+// CreatePlan 创建定时改价计划
+// POST /api/v1/price-plans
+func (h *PricePlanHandler) CreatePlan(c *gin.Context) {
+	var req dto.CreatePricePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+	c.Set("shop_id", req.ShopID)
+
+	resp, err := h.pricePlanService.CreatePlan(claims.UserID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "计划已创建", Data: resp})
+}
+
+// ListPlans 定时改价计划列表
+// GET /api/v1/price-plans
+func (h *PricePlanHandler) ListPlans(c *gin.Context) {
+	var req dto.PricePlanListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	resp, err := h.pricePlanService.ListPlans(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "获取计划列表失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: resp})
+}
+
+// GetPlan 定时改价计划详情（含商品明细）
+// GET /api/v1/price-plans/:id
+func (h *PricePlanHandler) GetPlan(c *gin.Context) {
+	planID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || planID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "无效的计划ID"})
+		return
+	}
+
+	shopID, err := strconv.ParseUint(c.Query("shop_id"), 10, 32)
+	if err != nil || shopID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "缺少shop_id参数"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, uint(shopID), claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	resp, err := h.pricePlanService.GetPlan(uint(shopID), uint(planID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.Response{Code: 404, Message: "计划不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "获取计划失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: resp})
+}
+
+// CancelPlan 取消尚未开始的计划
+// POST /api/v1/price-plans/:id/cancel
+func (h *PricePlanHandler) CancelPlan(c *gin.Context) {
+	h.operatePlan(c, h.pricePlanService.CancelPlan, "计划已取消")
+}
+
+// EndPlan 提前结束已生效的计划并恢复原价，失败的计划可用于重试恢复
+// POST /api/v1/price-plans/:id/end
+func (h *PricePlanHandler) EndPlan(c *gin.Context) {
+	h.operatePlan(c, h.pricePlanService.EndPlan, "已开始恢复原价")
+}
+
+func (h *PricePlanHandler) operatePlan(c *gin.Context, operate func(shopID, planID uint) error, successMessage string) {
+	planID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || planID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "无效的计划ID"})
+		return
+	}
+
+	var req dto.PricePlanOperateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+	c.Set("shop_id", req.ShopID)
+
+	if err := operate(req.ShopID, uint(planID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.Response{Code: 404, Message: "计划不存在"})
+			return
+		}
+		if respondShopBlocked(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: successMessage})
+}
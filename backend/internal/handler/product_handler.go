@@ -12,20 +12,23 @@ import (
 )
 
 type ProductHandler struct {
-	productService     *service.ProductService
-	shopService        *service.ShopService
-	ozonCatalogService *service.OzonCatalogService
+	productService      *service.ProductService
+	shopService         *service.ShopService
+	ozonCatalogService  *service.OzonCatalogService
+	priceHistoryService *service.PriceHistoryService
 }
 
 func NewProductHandler(
 	productService *service.ProductService,
 	shopService *service.ShopService,
 	ozonCatalogService *service.OzonCatalogService,
+	priceHistoryService *service.PriceHistoryService,
 ) *ProductHandler {
 	return &ProductHandler{
-		productService:     productService,
-		shopService:        shopService,
-		ozonCatalogService: ozonCatalogService,
+		productService:      productService,
+		shopService:         shopService,
+		ozonCatalogService:  ozonCatalogService,
+		priceHistoryService: priceHistoryService,
 	}
 }
 
@@ -135,6 +138,47 @@ func (h *ProductHandler) SyncProducts(c *gin.Context) {
 	})
 }
 
+// UpdateTags 批量设置商品标签
+// PUT /api/v1/products/tags
+func (h *ProductHandler) UpdateTags(c *gin.Context) {
+	var req dto.UpdateProductTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{
+			Code:    400,
+			Message: "请求参数错误",
+		})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{
+			Code:    403,
+			Message: "无权访问该店铺",
+		})
+		return
+	}
+
+	c.Set("shop_id", req.ShopID)
+
+	count, err := h.productService.UpdateTags(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{
+			Code:    500,
+			Message: "更新商品标签失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    200,
+		Message: "更新成功",
+		Data: map[string]int64{
+			"updated_count": count,
+		},
+	})
+}
+
 // GetOzonCatalog 获取 Ozon 商品目录（缓存 + 刷新状态）
 // GET /api/v1/products/ozon-catalog
 func (h *ProductHandler) GetOzonCatalog(c *gin.Context) {
@@ -227,6 +271,49 @@ func (h *ProductHandler) RefreshOzonCatalog(c *gin.Context) {
 	})
 }
 
+// GetPriceHistory 查询价格变动流水，可按 SKU 与日期范围过滤
+// GET /api/v1/products/price-history
+func (h *ProductHandler) GetPriceHistory(c *gin.Context) {
+	var req dto.PriceHistoryListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{
+			Code:    400,
+			Message: "请求参数错误",
+		})
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{
+			Code:    403,
+			Message: "无权访问该店铺",
+		})
+		return
+	}
+
+	resp, err := h.priceHistoryService.ListPriceHistory(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    200,
+		Message: "success",
+		Data:    resp,
+	})
+}
+
 // GetStats 获取统计数据
 // GET /api/v1/stats/overview
 func (h *ProductHandler) GetStats(c *gin.Context) {
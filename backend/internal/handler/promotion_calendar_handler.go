@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/middleware"
+)
+
+// GetPromotionCalendar 促销日历：活动重叠、脱离促销的时间点与候选活动
+// GET /api/v1/promotions/calendar
+func (h *PromotionHandler) GetPromotionCalendar(c *gin.Context) {
+	var req dto.PromotionCalendarRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 200 {
+		req.PageSize = 20
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	resp, err := h.promotionService.GetPromotionCalendar(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "获取促销日历失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: resp})
+}
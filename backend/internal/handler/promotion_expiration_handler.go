@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/middleware"
+)
+
+// UpdateActionSuccessors 设置活动到期后自动报名的后续活动
+// PUT /api/v1/promotions/actions/:id/successors
+func (h *PromotionHandler) UpdateActionSuccessors(c *gin.Context) {
+	actionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || actionID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "无效的活动ID"})
+		return
+	}
+
+	var req dto.UpdateActionSuccessorsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	c.Set("shop_id", req.ShopID)
+
+	if err := h.promotionService.UpdateActionSuccessors(req.ShopID, uint(actionID), req.SuccessorActionIDs); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.Response{Code: 404, Message: "活动不存在"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "更新成功"})
+}
+
+// ListPromotionExpirations 活动到期处理记录列表
+// GET /api/v1/promotions/expirations
+func (h *PromotionHandler) ListPromotionExpirations(c *gin.Context) {
+	var req dto.PromotionExpirationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	resp, err := h.promotionService.ListPromotionExpirations(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "获取到期记录失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: resp})
+}
+
+// GetPromotionExpiration 活动到期处理详情（含脱离全部活动的商品）
+// GET /api/v1/promotions/expirations/:id
+func (h *PromotionHandler) GetPromotionExpiration(c *gin.Context) {
+	expirationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || expirationID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "无效的记录ID"})
+		return
+	}
+
+	shopID, err := strconv.ParseUint(c.Query("shop_id"), 10, 32)
+	if err != nil || shopID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "缺少shop_id参数"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, uint(shopID), claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	resp, err := h.promotionService.GetPromotionExpiration(uint(shopID), uint(expirationID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.Response{Code: 404, Message: "到期记录不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "获取到期记录失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: resp})
+}
+
+// MarkPromotionExpirationsRead 标记到期提醒已读
+// POST /api/v1/promotions/expirations/read
+func (h *PromotionHandler) MarkPromotionExpirationsRead(c *gin.Context) {
+	var req dto.PromotionExpirationReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	count, err := h.promotionService.MarkPromotionExpirationsRead(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "标记已读失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: map[string]int64{"count": count}})
+}
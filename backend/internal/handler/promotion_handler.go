@@ -51,8 +51,11 @@ func (h *PromotionHandler) BatchEnroll(c *gin.Context) {
 	// 设置shop_id供操作日志使用
 	c.Set("shop_id", req.ShopID)
 
-	resp, err := h.promotionService.BatchEnrollPromotions(&req)
+	resp, err := h.promotionService.BatchEnrollPromotions(claims.UserID, &req)
 	if err != nil {
+		if respondShopBlocked(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{
 			Code:    500,
 			Message: "批量报名失败: " + err.Error(),
@@ -91,8 +94,11 @@ func (h *PromotionHandler) ProcessLoss(c *gin.Context) {
 
 	c.Set("shop_id", req.ShopID)
 
-	resp, err := h.promotionService.ProcessLossProducts(&req)
+	resp, err := h.promotionService.ProcessLossProducts(claims.UserID, &req)
 	if err != nil {
+		if respondShopBlocked(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{
 			Code:    500,
 			Message: "处理亏损商品失败: " + err.Error(),
@@ -131,7 +137,10 @@ func (h *PromotionHandler) RemoveRepricePromote(c *gin.Context) {
 
 	c.Set("shop_id", req.ShopID)
 
-	if err := h.promotionService.RemoveRepricePromote(&req); err != nil {
+	if err := h.promotionService.RemoveRepricePromote(claims.UserID, &req); err != nil {
+		if respondShopBlocked(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{
 			Code:    500,
 			Message: "操作失败: " + err.Error(),
@@ -302,7 +311,10 @@ func (h *PromotionHandler) ImportReprice(c *gin.Context) {
 	}
 
 	// 执行操作
-	if err := h.promotionService.RemoveRepricePromote(req); err != nil {
+	if err := h.promotionService.RemoveRepricePromote(claims.UserID, req); err != nil {
+		if respondShopBlocked(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{
 			Code:    500,
 			Message: "操作失败: " + err.Error(),
@@ -549,8 +561,11 @@ func (h *PromotionHandler) BatchEnrollV2(c *gin.Context) {
 
 	c.Set("shop_id", req.ShopID)
 
-	resp, err := h.promotionService.BatchEnrollToActions(&req)
+	resp, err := h.promotionService.BatchEnrollToActions(claims.UserID, &req)
 	if err != nil {
+		if respondShopBlocked(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{
 			Code:    500,
 			Message: "批量报名失败: " + err.Error(),
@@ -558,9 +573,13 @@ func (h *PromotionHandler) BatchEnrollV2(c *gin.Context) {
 		return
 	}
 
+	message := "批量报名完成"
+	if req.DryRun {
+		message = "试运行完成，未调用 Ozon"
+	}
 	c.JSON(http.StatusOK, dto.Response{
 		Code:    200,
-		Message: "批量报名完成",
+		Message: message,
 		Data:    resp,
 	})
 }
@@ -589,8 +608,11 @@ func (h *PromotionHandler) ProcessLossV2(c *gin.Context) {
 
 	c.Set("shop_id", req.ShopID)
 
-	resp, err := h.promotionService.ProcessLossProductsV2(&req)
+	resp, err := h.promotionService.ProcessLossProductsV2(claims.UserID, &req)
 	if err != nil {
+		if respondShopBlocked(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{
 			Code:    500,
 			Message: "处理亏损商品失败: " + err.Error(),
@@ -629,7 +651,10 @@ func (h *PromotionHandler) RemoveRepricePromoteV2(c *gin.Context) {
 
 	c.Set("shop_id", req.ShopID)
 
-	if err := h.promotionService.RemoveRepricePromoteV2(&req); err != nil {
+	if err := h.promotionService.RemoveRepricePromoteV2(claims.UserID, &req); err != nil {
+		if respondShopBlocked(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{
 			Code:    500,
 			Message: "操作失败: " + err.Error(),
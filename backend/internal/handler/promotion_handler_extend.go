@@ -33,6 +33,9 @@ func (h *PromotionHandler) UnifiedEnroll(c *gin.Context) {
 
 	resp, err := h.promotionService.UnifiedEnroll(claims.UserID, &req)
 	if err != nil {
+		if respondShopBlocked(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{
 			Code:    500,
 			Message: "操作失败: " + err.Error(),
@@ -72,6 +75,9 @@ func (h *PromotionHandler) UnifiedRemove(c *gin.Context) {
 
 	resp, err := h.promotionService.UnifiedRemove(claims.UserID, &req)
 	if err != nil {
+		if respondShopBlocked(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{
 			Code:    500,
 			Message: "操作失败: " + err.Error(),
@@ -111,6 +117,9 @@ func (h *PromotionHandler) UnifiedProcessLoss(c *gin.Context) {
 
 	resp, err := h.promotionService.UnifiedProcessLoss(claims.UserID, &req)
 	if err != nil {
+		if respondShopBlocked(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{
 			Code:    500,
 			Message: "操作失败: " + err.Error(),
@@ -150,6 +159,9 @@ func (h *PromotionHandler) UnifiedRepricePromote(c *gin.Context) {
 
 	resp, err := h.promotionService.UnifiedRepricePromote(claims.UserID, &req)
 	if err != nil {
+		if respondShopBlocked(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.Response{
 			Code:    500,
 			Message: "操作失败: " + err.Error(),
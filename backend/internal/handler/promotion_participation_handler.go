@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/middleware"
+)
+
+// GetProductParticipation 商品促销参与时间线
+// GET /api/v1/promotions/participation/products/:id
+func (h *PromotionHandler) GetProductParticipation(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || productID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "无效的商品ID"})
+		return
+	}
+
+	var req dto.ProductParticipationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	resp, err := h.promotionService.GetProductParticipation(req.ShopID, uint(productID), &req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.Response{Code: 404, Message: "商品不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: 500, Message: "获取参与记录失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: resp})
+}
+
+// GetActionParticipation 活动加入 / 退出报表
+// GET /api/v1/promotions/actions/:id/participation
+func (h *PromotionHandler) GetActionParticipation(c *gin.Context) {
+	actionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || actionID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "无效的活动ID"})
+		return
+	}
+
+	var req dto.ActionParticipationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: "请求参数错误"})
+		return
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+
+	claims := middleware.GetCurrentUser(c)
+	if err := h.shopService.CheckUserAccessByRole(claims.UserID, req.ShopID, claims.Role); err != nil {
+		c.JSON(http.StatusForbidden, dto.Response{Code: 403, Message: "无权访问该店铺"})
+		return
+	}
+
+	resp, err := h.promotionService.GetActionParticipation(uint(actionID), &req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, dto.Response{Code: 404, Message: "活动不存在"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, dto.Response{Code: 400, Message: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: 200, Message: "success", Data: resp})
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -442,3 +444,123 @@ func (h *ShopHandler) UpdateMyShopExecutionEngine(c *gin.Context) {
 		Data:    data,
 	})
 }
+
+// respondShopBlocked 店铺暂停写入或熔断中时返回 423，提示在店铺设置中处理
+func respondShopBlocked(c *gin.Context, err error) bool {
+	var blocked *service.ShopBlockedError
+	if !errors.As(err, &blocked) {
+		return false
+	}
+	c.JSON(http.StatusLocked, dto.Response{Code: http.StatusLocked, Message: blocked.Error()})
+	return true
+}
+
+func respondMyShopGuardError(c *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	if err == service.ErrShopNotFound {
+		statusCode = http.StatusNotFound
+	} else if err == service.ErrShopNotBelongToYou {
+		statusCode = http.StatusForbidden
+	}
+	c.JSON(statusCode, dto.Response{
+		Code:    statusCode,
+		Message: err.Error(),
+	})
+}
+
+// GetMyShopGuard 获取店铺暂停写入开关与熔断状态
+// GET /api/v1/my/shops/:id/guard
+func (h *ShopHandler) GetMyShopGuard(c *gin.Context) {
+	shopID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{
+			Code:    400,
+			Message: "无效的店铺ID",
+		})
+		return
+	}
+
+	ownerID := middleware.GetCurrentUserID(c)
+	data, err := h.shopService.GetMyShopGuard(uint(shopID), ownerID)
+	if err != nil {
+		respondMyShopGuardError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    200,
+		Message: "success",
+		Data:    data,
+	})
+}
+
+// UpdateMyShopWritePause 暂停 / 恢复店铺全部自动写操作
+// PUT /api/v1/my/shops/:id/write-pause
+func (h *ShopHandler) UpdateMyShopWritePause(c *gin.Context) {
+	shopID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{
+			Code:    400,
+			Message: "无效的店铺ID",
+		})
+		return
+	}
+
+	var req dto.UpdateShopWritePauseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{
+			Code:    400,
+			Message: "请求参数错误",
+		})
+		return
+	}
+
+	ownerID := middleware.GetCurrentUserID(c)
+	data, err := h.shopService.UpdateMyShopWritePause(uint(shopID), ownerID, &req)
+	if err != nil {
+		respondMyShopGuardError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    200,
+		Message: "更新成功",
+		Data:    data,
+	})
+}
+
+// ResetMyShopCircuitBreakers 人工关闭店铺熔断
+// POST /api/v1/my/shops/:id/circuit-breakers/reset
+func (h *ShopHandler) ResetMyShopCircuitBreakers(c *gin.Context) {
+	shopID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{
+			Code:    400,
+			Message: "无效的店铺ID",
+		})
+		return
+	}
+
+	// 请求体可省略，省略时重置全部来源
+	var req dto.ResetShopCircuitBreakerRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, dto.Response{
+			Code:    400,
+			Message: "请求参数错误",
+		})
+		return
+	}
+
+	ownerID := middleware.GetCurrentUserID(c)
+	data, err := h.shopService.ResetMyShopCircuitBreakers(uint(shopID), ownerID, req.Source)
+	if err != nil {
+		respondMyShopGuardError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    200,
+		Message: "熔断已重置",
+		Data:    data,
+	})
+}
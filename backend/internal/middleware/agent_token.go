@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"ozon-manager/internal/dto"
+)
+
+const AgentTokenHeader = "X-Agent-Token"
+
+// AgentTokenMiddleware 本地执行端共享令牌校验，token 为空时拒绝所有请求
+func AgentTokenMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.JSON(http.StatusForbidden, dto.Response{
+				Code:    403,
+				Message: "服务端未配置执行端令牌（automation.agent_token），该接口不可用",
+			})
+			c.Abort()
+			return
+		}
+		provided := c.GetHeader(AgentTokenHeader)
+		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.JSON(http.StatusUnauthorized, dto.Response{
+				Code:    401,
+				Message: "无效的执行端令牌",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -9,13 +9,19 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"gorm.io/datatypes"
-	"gorm.io/gorm"
 	"ozon-manager/internal/model"
+	"ozon-manager/pkg/logger"
 )
 
-// OperationLogMiddleware 操作日志记录中间件
-func OperationLogMiddleware(db *gorm.DB) gin.HandlerFunc {
+// TaskEnqueuer 后台任务入队接口，*service.TaskQueue 即满足该接口
+type TaskEnqueuer interface {
+	Enqueue(taskType string, payload interface{}) error
+}
+
+// OperationLogMiddleware 操作日志记录中间件，日志经持久化任务队列写入
+func OperationLogMiddleware(tasks TaskEnqueuer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 只记录非GET请求
 		if c.Request.Method == "GET" {
@@ -91,10 +97,13 @@ func OperationLogMiddleware(db *gorm.DB) gin.HandlerFunc {
 			CompletedAt:     &now,
 		}
 
-		// 异步保存日志
-		go func() {
-			db.Create(&log)
-		}()
+		// 入队后由后台 worker 写入，写库失败会自动重试
+		if err := tasks.Enqueue(model.BackgroundTaskTypeOperationLog, log); err != nil {
+			logger.Log.Warn("enqueue operation log failed",
+				zap.String("operation_type", operationType),
+				zap.Error(err),
+			)
+		}
 	}
 }
 
@@ -151,21 +160,37 @@ func normalizeErrorField(value interface{}) string {
 // parseOperationType 解析操作类型
 func parseOperationType(path, method string) string {
 	operationMap := map[string]string{
-		"POST /api/v1/promotions/batch-enroll":           "batch_enroll",
-		"POST /api/v1/promotions/process-loss":           "process_loss",
-		"POST /api/v1/promotions/remove-reprice-promote": "remove_reprice_promote",
-		"PUT /api/v1/promotions/auto-add/config":         "auto_promotion_config",
-		"POST /api/v1/promotions/auto-add/runs":          "auto_promotion_run",
-		"POST /api/v1/excel/import-loss":                 "import_loss",
-		"POST /api/v1/excel/import-reprice":              "import_reprice",
-		"POST /api/v1/products/sync":                     "sync_products",
-		"POST /api/v1/products/ozon-catalog/refresh":     "sync_ozon_catalog",
-		"POST /api/v1/users":                             "create_user",
-		"PUT /api/v1/users/:id/status":                   "update_user_status",
-		"PUT /api/v1/users/:id/shops":                    "update_user_shops",
-		"POST /api/v1/shops":                             "create_shop",
-		"PUT /api/v1/shops/:id":                          "update_shop",
-		"DELETE /api/v1/shops/:id":                       "delete_shop",
+		"POST /api/v1/promotions/batch-enroll":                   "batch_enroll",
+		"POST /api/v1/promotions/process-loss":                   "process_loss",
+		"POST /api/v1/promotions/remove-reprice-promote":         "remove_reprice_promote",
+		"PUT /api/v1/promotions/auto-add/config":                 "auto_promotion_config",
+		"POST /api/v1/promotions/auto-add/configs":               "auto_promotion_config_create",
+		"PUT /api/v1/promotions/auto-add/configs/:id":            "auto_promotion_config",
+		"DELETE /api/v1/promotions/auto-add/configs/:id":         "auto_promotion_config_delete",
+		"POST /api/v1/promotions/auto-add/runs":                  "auto_promotion_run",
+		"POST /api/v1/promotions/auto-add/runs/:id/retry-failed": "auto_promotion_retry",
+		"POST /api/v1/promotions/reconcile":                      "promotion_reconcile",
+		"POST /api/v1/promotions/operations/:id/revert":          "bulk_operation_revert",
+		"PUT /api/v1/promotions/actions/:id/successors":          "update_action_successors",
+		"POST /api/v1/price-plans":                               "price_plan_create",
+		"POST /api/v1/price-plans/:id/cancel":                    "price_plan_cancel",
+		"POST /api/v1/price-plans/:id/end":                       "price_plan_end",
+		"POST /api/v1/reprice-rules":                             "reprice_rule_create",
+		"PUT /api/v1/reprice-rules/:id":                          "reprice_rule_update",
+		"DELETE /api/v1/reprice-rules/:id":                       "reprice_rule_delete",
+		"PUT /api/v1/reprice-rules/costs":                        "save_product_costs",
+		"POST /api/v1/reprice-rules/apply":                       "reprice_rule_apply",
+		"POST /api/v1/excel/import-loss":                         "import_loss",
+		"POST /api/v1/excel/import-reprice":                      "import_reprice",
+		"POST /api/v1/products/sync":                             "sync_products",
+		"PUT /api/v1/products/tags":                              "update_product_tags",
+		"POST /api/v1/products/ozon-catalog/refresh":             "sync_ozon_catalog",
+		"POST /api/v1/users":                                     "create_user",
+		"PUT /api/v1/users/:id/status":                           "update_user_status",
+		"PUT /api/v1/users/:id/shops":                            "update_user_shops",
+		"POST /api/v1/shops":                                     "create_shop",
+		"PUT /api/v1/shops/:id":                                  "update_shop",
+		"DELETE /api/v1/shops/:id":                               "delete_shop",
 	}
 
 	key := method + " " + path
@@ -10,11 +10,22 @@ const (
 	AutoPromotionTriggerModeManual    = "manual"
 	AutoPromotionTriggerModeScheduled = "scheduled"
 
+	// AutoPromotionTargetModeFixedDate 按固定的目标日期选品
+	AutoPromotionTargetModeFixedDate = "fixed_date"
+	// AutoPromotionTargetModeDaysBefore 按触发日期前第 N 天上架的商品选品
+	AutoPromotionTargetModeDaysBefore = "days_before"
+	// AutoPromotionTargetModeRecentDays 按触发日期前 N 天内上架、尚未加入全部所选活动的商品选品
+	AutoPromotionTargetModeRecentDays = "recent_days"
+
 	AutoPromotionRunStatusPending        = "pending"
 	AutoPromotionRunStatusRunning        = "running"
 	AutoPromotionRunStatusSuccess        = "success"
 	AutoPromotionRunStatusPartialSuccess = "partial_success"
 	AutoPromotionRunStatusFailed         = "failed"
+	// AutoPromotionRunStatusInterrupted 服务停止时被中断，重启后自动继续执行
+	AutoPromotionRunStatusInterrupted = "interrupted"
+	// AutoPromotionRunStatusWaiting 等待执行端任务结束，任务全部结束后自动继续执行
+	AutoPromotionRunStatusWaiting = "waiting"
 
 	AutoPromotionItemStatusPending = "pending"
 	AutoPromotionItemStatusSuccess = "success"
@@ -52,14 +63,20 @@ func (PromotionActionCandidate) TableName() string {
 
 type AutoPromotionConfig struct {
 	ID                uint           `gorm:"primaryKey" json:"id"`
-	ShopID            uint           `gorm:"not null;uniqueIndex" json:"shop_id"`
+	ShopID            uint           `gorm:"not null;index;uniqueIndex:idx_auto_promotion_config_shop_name" json:"shop_id"`
+	Name              string         `gorm:"size:100;not null;uniqueIndex:idx_auto_promotion_config_shop_name" json:"name"`
 	Enabled           bool           `gorm:"not null;default:false" json:"enabled"`
 	ScheduleTime      string         `gorm:"size:5;not null;default:09:05" json:"schedule_time"`
 	TargetDate        time.Time      `gorm:"type:date;not null" json:"target_date"`
 	OfficialActionIDs datatypes.JSON `gorm:"type:jsonb;not null" json:"official_action_ids"`
 	ShopActionIDs     datatypes.JSON `gorm:"type:jsonb;not null" json:"shop_action_ids"`
-	CreatedAt         time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt         time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	// TargetMode 目标日期模式，相对模式下 TargetOffsetDays 为相对触发日期的天数，TargetDate 不再使用
+	TargetMode       string `gorm:"size:20;not null;default:fixed_date" json:"target_mode"`
+	TargetOffsetDays int    `gorm:"not null;default:0" json:"target_offset_days"`
+	// SelectionRules 选品规则（dto.AutoPromotionSelectionRule），为空时沿用按目标日期 + 全部活动候选的选品
+	SelectionRules datatypes.JSON `gorm:"type:jsonb" json:"selection_rules"`
+	CreatedAt      time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 func (AutoPromotionConfig) TableName() string {
@@ -83,10 +100,14 @@ type AutoPromotionRun struct {
 	SkippedItems    int            `gorm:"default:0" json:"skipped_items"`
 	ConfigSnapshot  datatypes.JSON `gorm:"type:jsonb" json:"config_snapshot"`
 	ErrorMessage    string         `gorm:"type:text" json:"error_message"`
-	StartedAt       *time.Time     `json:"started_at"`
-	CompletedAt     *time.Time     `json:"completed_at"`
-	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	// CandidateJobIDs 店铺活动 ID -> 候选商品同步任务 ID，续跑时复用已提交的同步任务
+	CandidateJobIDs datatypes.JSON `gorm:"type:jsonb" json:"candidate_job_ids"`
+	// WaitingJobIDs 状态为 waiting 时等待结束的 automation job
+	WaitingJobIDs datatypes.JSON `gorm:"type:jsonb" json:"waiting_job_ids"`
+	StartedAt     *time.Time     `json:"started_at"`
+	CompletedAt   *time.Time     `json:"completed_at"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 
 	RunItems []AutoPromotionRunItem `gorm:"foreignKey:RunID" json:"run_items,omitempty"`
 }
@@ -19,14 +19,23 @@ const (
 	AutomationJobStatusFailed          = "failed"
 	AutomationJobStatusCanceled        = "canceled"
 	AutomationJobStatusDryRunCompleted = "dry_run_completed"
+	// AutomationJobStatusRetryWait 存在待自动重试的明细，到达 next_retry_at 后重新排队
+	AutomationJobStatusRetryWait = "retry_wait"
 
 	AutomationStepStatusPending = "pending"
 	AutomationStepStatusSkipped = "skipped"
 	AutomationStepStatusSuccess = "success"
 	AutomationStepStatusFailed  = "failed"
+	// AutomationStepStatusDeadLetter 不可重试或已耗尽重试次数的明细，不再自动重试
+	AutomationStepStatusDeadLetter = "dead_letter"
 
 	AutomationAgentStatusOnline  = "online"
 	AutomationAgentStatusOffline = "offline"
+
+	// 任务优先级：界面交互触发的任务优先于定时后台同步
+	AutomationJobPriorityBackground  = 10
+	AutomationJobPriorityNormal      = 50
+	AutomationJobPriorityInteractive = 100
 )
 
 type AutomationJob struct {
@@ -36,6 +45,10 @@ type AutomationJob struct {
 	AssignedAgentID      *uint      `gorm:"index" json:"assigned_agent_id"`
 	JobType              string     `gorm:"size:50;not null;index" json:"job_type"`
 	Status               string     `gorm:"size:30;not null;default:pending;index" json:"status"`
+	Priority             int        `gorm:"not null;default:50;index" json:"priority"`
+	WorkflowID           *uint      `gorm:"index" json:"workflow_id"`
+	OnSuccess            string     `gorm:"size:50" json:"on_success"`
+	ContinuationStatus   string     `gorm:"size:20" json:"continuation_status"`
 	DryRun               bool       `gorm:"default:false" json:"dry_run"`
 	RequiresConfirmation bool       `gorm:"default:false" json:"requires_confirmation"`
 	RateLimit            int        `gorm:"default:30" json:"rate_limit"`
@@ -43,6 +56,8 @@ type AutomationJob struct {
 	SuccessItems         int        `gorm:"default:0" json:"success_items"`
 	FailedItems          int        `gorm:"default:0" json:"failed_items"`
 	ErrorMessage         string     `gorm:"type:text" json:"error_message"`
+	RequeueCount         int        `gorm:"default:0" json:"requeue_count"`
+	NextRetryAt          *time.Time `gorm:"index" json:"next_retry_at"`
 	StartedAt            *time.Time `json:"started_at"`
 	CompletedAt          *time.Time `json:"completed_at"`
 	CreatedAt            time.Time  `gorm:"autoCreateTime" json:"created_at"`
@@ -59,21 +74,23 @@ func (AutomationJob) TableName() string {
 }
 
 type AutomationJobItem struct {
-	ID                uint      `gorm:"primaryKey" json:"id"`
-	JobID             uint      `gorm:"not null;index;uniqueIndex:idx_automation_job_source_sku" json:"job_id"`
-	ProductID         *uint     `gorm:"index" json:"product_id"`
-	SourceSKU         string    `gorm:"size:100;not null;uniqueIndex:idx_automation_job_source_sku" json:"source_sku"`
-	TargetPrice       float64   `gorm:"type:decimal(12,2);not null" json:"target_price"`
-	OverallStatus     string    `gorm:"size:20;not null;default:pending;index" json:"overall_status"`
-	StepExitStatus    string    `gorm:"size:20;not null;default:pending" json:"step_exit_status"`
-	StepRepriceStatus string    `gorm:"size:20;not null;default:pending" json:"step_reprice_status"`
-	StepReaddStatus   string    `gorm:"size:20;not null;default:pending" json:"step_readd_status"`
-	StepExitError     string    `gorm:"type:text" json:"step_exit_error"`
-	StepRepriceError  string    `gorm:"type:text" json:"step_reprice_error"`
-	StepReaddError    string    `gorm:"type:text" json:"step_readd_error"`
-	RetryCount        int       `gorm:"default:0" json:"retry_count"`
-	CreatedAt         time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt         time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	JobID             uint       `gorm:"not null;index;uniqueIndex:idx_automation_job_source_sku" json:"job_id"`
+	ProductID         *uint      `gorm:"index" json:"product_id"`
+	SourceSKU         string     `gorm:"size:100;not null;uniqueIndex:idx_automation_job_source_sku" json:"source_sku"`
+	TargetPrice       float64    `gorm:"type:decimal(12,2);not null" json:"target_price"`
+	OverallStatus     string     `gorm:"size:20;not null;default:pending;index" json:"overall_status"`
+	StepExitStatus    string     `gorm:"size:20;not null;default:pending" json:"step_exit_status"`
+	StepRepriceStatus string     `gorm:"size:20;not null;default:pending" json:"step_reprice_status"`
+	StepReaddStatus   string     `gorm:"size:20;not null;default:pending" json:"step_readd_status"`
+	StepExitError     string     `gorm:"type:text" json:"step_exit_error"`
+	StepRepriceError  string     `gorm:"type:text" json:"step_reprice_error"`
+	StepReaddError    string     `gorm:"type:text" json:"step_readd_error"`
+	RetryCount        int        `gorm:"default:0" json:"retry_count"`
+	NextRetryAt       *time.Time `json:"next_retry_at"`
+	LastErrorKind     string     `gorm:"size:20" json:"last_error_kind"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 
 	Job     AutomationJob `gorm:"foreignKey:JobID" json:"job,omitempty"`
 	Product *Product      `gorm:"foreignKey:ProductID" json:"product,omitempty"`
@@ -122,6 +139,8 @@ type AutomationArtifact struct {
 	ArtifactType string         `gorm:"size:50;not null" json:"artifact_type"`
 	StoragePath  string         `gorm:"size:500;not null" json:"storage_path"`
 	Checksum     string         `gorm:"size:128" json:"checksum"`
+	ContentType  string         `gorm:"size:100" json:"content_type"`
+	SizeBytes    int64          `gorm:"default:0" json:"size_bytes"`
 	Meta         datatypes.JSON `gorm:"type:jsonb" json:"meta"`
 	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
 
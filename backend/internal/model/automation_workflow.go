@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	// AutomationJobStatusWaiting 任务依赖的前置任务尚未全部成功，暂不参与调度
+	AutomationJobStatusWaiting = "waiting"
+
+	AutomationWorkflowStatusRunning        = "running"
+	AutomationWorkflowStatusSuccess        = "success"
+	AutomationWorkflowStatusPartialSuccess = "partial_success"
+	AutomationWorkflowStatusFailed         = "failed"
+	AutomationWorkflowStatusCanceled       = "canceled"
+
+	AutomationWorkflowTypeSyncShopActions    = "sync_shop_actions"
+	AutomationWorkflowTypeSyncActionProducts = "sync_action_products"
+
+	// 任务成功后的续接动作
+	AutomationContinuationImportShopActions    = "import_shop_actions"
+	AutomationContinuationImportActionProducts = "import_action_products"
+
+	// AutomationContinuationRecordParticipation 店铺活动任务结束后按明细结果写促销参与流水
+	AutomationContinuationRecordParticipation = "record_participation"
+
+	// 续接动作执行状态：任务结束后抢占为 queued 并提交后台任务，同一任务只执行一次
+	AutomationContinuationStatusQueued = "queued"
+	AutomationContinuationStatusDone   = "done"
+	AutomationContinuationStatusFailed = "failed"
+)
+
+// AutomationWorkflow 一组相互依赖的自动化任务，状态由其下所有任务汇总得出
+type AutomationWorkflow struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	ShopID        uint           `gorm:"not null;index" json:"shop_id"`
+	CreatedBy     uint           `gorm:"not null;index" json:"created_by"`
+	WorkflowType  string         `gorm:"size:50;not null;index" json:"workflow_type"`
+	Status        string         `gorm:"size:30;not null;default:running;index" json:"status"`
+	TotalJobs     int            `gorm:"default:0" json:"total_jobs"`
+	CompletedJobs int            `gorm:"default:0" json:"completed_jobs"`
+	FailedJobs    int            `gorm:"default:0" json:"failed_jobs"`
+	ErrorMessage  string         `gorm:"type:text" json:"error_message"`
+	Result        datatypes.JSON `gorm:"type:jsonb" json:"result"`
+	CompletedAt   *time.Time     `json:"completed_at"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Jobs []AutomationJob `gorm:"foreignKey:WorkflowID" json:"jobs,omitempty"`
+}
+
+func (AutomationWorkflow) TableName() string {
+	return "automation_workflows"
+}
+
+// AutomationJobDependency 任务依赖关系：JobID 需等待 DependsOnJobID 成功后才能执行
+type AutomationJobDependency struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	JobID          uint      `gorm:"not null;uniqueIndex:idx_automation_job_dependency" json:"job_id"`
+	DependsOnJobID uint      `gorm:"not null;index;uniqueIndex:idx_automation_job_dependency" json:"depends_on_job_id"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (AutomationJobDependency) TableName() string {
+	return "automation_job_dependencies"
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// 后台任务状态
+const (
+	BackgroundTaskStatusPending   = "pending"
+	BackgroundTaskStatusRunning   = "running"
+	BackgroundTaskStatusSucceeded = "succeeded"
+	BackgroundTaskStatusFailed    = "failed"
+)
+
+// 后台任务类型
+const (
+	BackgroundTaskTypeOperationLog       = "operation_log"
+	BackgroundTaskTypeOzonCatalogRefresh = "ozon_catalog_refresh"
+	BackgroundTaskTypeAutoPromotionRun   = "auto_promotion_run"
+	BackgroundTaskTypePromotionReconcile = "promotion_reconcile"
+
+	BackgroundTaskTypePricePlan           = "price_plan"
+	BackgroundTaskTypeBulkOperationRevert = "bulk_operation_revert"
+	BackgroundTaskTypePromotionExpiration = "promotion_expiration"
+	// BackgroundTaskTypeAutomationContinuation 自动化任务结束后的续接动作与下游任务释放
+	BackgroundTaskTypeAutomationContinuation = "automation_continuation"
+)
+
+// BackgroundTask 进程内后台任务的持久化队列，替代直接起 goroutine，进程退出或崩溃后任务不会丢失。
+// 领取时以 locked_until 作为可见性超时，执行中的 worker 定期续期，超时未续期的任务可被重新领取。
+type BackgroundTask struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	TaskType       string         `gorm:"size:50;not null;index" json:"task_type"`
+	Payload        datatypes.JSON `gorm:"type:jsonb" json:"payload"`
+	Status         string         `gorm:"size:20;not null;default:pending" json:"status"`
+	Attempts       int            `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts    int            `gorm:"not null;default:3" json:"max_attempts"`
+	TimeoutSeconds int            `gorm:"not null;default:300" json:"timeout_seconds"`
+	RunAt          time.Time      `gorm:"not null" json:"run_at"`
+	LockedBy       string         `gorm:"size:120" json:"locked_by"`
+	LockedUntil    *time.Time     `json:"locked_until"`
+	LastError      string         `gorm:"type:text" json:"last_error"`
+	StartedAt      *time.Time     `json:"started_at"`
+	CompletedAt    *time.Time     `json:"completed_at"`
+	CreatedAt      time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (BackgroundTask) TableName() string {
+	return "background_tasks"
+}
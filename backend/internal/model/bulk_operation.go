@@ -0,0 +1,82 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	// 批量操作类型：对应改价与报名 / 退出活动的各个入口
+	BulkOperationTypeBatchEnroll           = "batch_enroll"
+	BulkOperationTypeProcessLoss           = "process_loss"
+	BulkOperationTypeRemoveReprice         = "remove_reprice"
+	BulkOperationTypeUnifiedEnroll         = "unified_enroll"
+	BulkOperationTypeUnifiedRemove         = "unified_remove"
+	BulkOperationTypeUnifiedProcessLoss    = "unified_process_loss"
+	BulkOperationTypeUnifiedRepricePromote = "unified_reprice_promote"
+	BulkOperationTypeRepriceRule           = "reprice_rule"
+
+	BulkOperationStatusApplied   = "applied"
+	BulkOperationStatusReverting = "reverting"
+	BulkOperationStatusReverted  = "reverted"
+	// BulkOperationStatusRevertFailed 有商品未能恢复或撤销中途出错，可再次撤销重试未恢复的商品
+	BulkOperationStatusRevertFailed = "revert_failed"
+
+	BulkOperationItemStatusPending  = "pending"
+	BulkOperationItemStatusRestored = "restored"
+	// BulkOperationItemStatusQueued 官方活动与价格已恢复，店铺活动部分已提交浏览器插件任务
+	BulkOperationItemStatusQueued = "queued"
+	BulkOperationItemStatusFailed = "failed"
+)
+
+// BulkOperation 批量改价 / 报名操作的快照：执行前记录每个商品的价格与活动参与情况，用于撤销
+type BulkOperation struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	ShopID        uint   `gorm:"not null;index" json:"shop_id"`
+	OperationType string `gorm:"size:40;not null" json:"operation_type"`
+	Status        string `gorm:"size:20;not null;default:applied" json:"status"`
+	CreatedBy     uint   `json:"created_by"`
+	ItemCount     int    `gorm:"not null;default:0" json:"item_count"`
+	// JobIDs 操作创建的店铺活动任务，任务结束前不能撤销
+	JobIDs        datatypes.JSON `gorm:"type:jsonb" json:"job_ids"`
+	RestoredCount int            `gorm:"not null;default:0" json:"restored_count"`
+	FailedCount   int            `gorm:"not null;default:0" json:"failed_count"`
+	// RevertJobIDs 撤销时创建的店铺活动任务
+	RevertJobIDs datatypes.JSON `gorm:"type:jsonb" json:"revert_job_ids"`
+	ErrorMessage string         `gorm:"type:text" json:"error_message"`
+	RevertedBy   *uint          `json:"reverted_by"`
+	RevertedAt   *time.Time     `json:"reverted_at"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Items []BulkOperationItem `gorm:"foreignKey:OperationID" json:"items,omitempty"`
+}
+
+func (BulkOperation) TableName() string {
+	return "bulk_operations"
+}
+
+// BulkOperationItem 单个商品操作前的状态
+type BulkOperationItem struct {
+	ID            uint    `gorm:"primaryKey" json:"id"`
+	OperationID   uint    `gorm:"not null;index" json:"operation_id"`
+	ProductID     uint    `gorm:"not null" json:"product_id"`
+	SourceSKU     string  `gorm:"size:120;not null" json:"source_sku"`
+	OzonProductID int64   `json:"ozon_product_id"`
+	PreviousPrice float64 `gorm:"type:decimal(12,2)" json:"previous_price"`
+	WasPromoted   bool    `gorm:"not null;default:false" json:"was_promoted"`
+	// OfficialActions 操作前参与的官方活动及活动价（dto.BulkOperationOfficialAction）
+	OfficialActions datatypes.JSON `gorm:"type:jsonb" json:"official_actions"`
+	// ShopActions 操作会涉及的店铺活动及操作前是否在活动中（dto.BulkOperationShopAction）
+	ShopActions  datatypes.JSON `gorm:"type:jsonb" json:"shop_actions"`
+	RevertStatus string         `gorm:"size:20;not null;default:pending" json:"revert_status"`
+	RevertNote   string         `gorm:"type:text" json:"revert_note"`
+	RevertedAt   *time.Time     `json:"reverted_at"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (BulkOperationItem) TableName() string {
+	return "bulk_operation_items"
+}
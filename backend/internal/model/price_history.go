@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+const (
+	PriceTypePrice          = "price"
+	PriceTypeOldPrice       = "old_price"
+	PriceTypeMinPrice       = "min_price"
+	PriceTypeMarketingPrice = "marketing_price"
+	PriceTypeActionPrice    = "action_price"
+
+	// 价格变动来源：同步类为观测到的变化，其余为本系统下发的改价
+	PriceSourceProductSync      = "product_sync"
+	PriceSourceCatalogSync      = "catalog_sync"
+	PriceSourceLossProcess      = "loss_process"
+	PriceSourceRepriceImport    = "reprice_import"
+	PriceSourceExtensionReprice = "extension_reprice"
+	PriceSourceAutoPromotion    = "auto_promotion"
+	PriceSourceAutomationJob    = "automation_job"
+
+	PriceSourcePricePlan   = "price_plan"
+	PriceSourceRepriceRule = "reprice_rule"
+	PriceSourceBulkRevert  = "bulk_revert"
+)
+
+// PriceHistory 价格变动流水：记录同步观测到的和本系统下发的每次价格变化
+type PriceHistory struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	ShopID        uint      `gorm:"not null;index" json:"shop_id"`
+	ProductID     *uint     `gorm:"index" json:"product_id"` // 目录同步的记录可能没有本地商品
+	OzonProductID int64     `json:"ozon_product_id"`
+	SourceSKU     string    `gorm:"size:120;index" json:"source_sku"`
+	PriceType     string    `gorm:"size:30;not null" json:"price_type"`
+	OldPrice      float64   `gorm:"type:decimal(12,2)" json:"old_price"` // 变动前的同类价格，未知时为 0
+	NewPrice      float64   `gorm:"type:decimal(12,2)" json:"new_price"`
+	Currency      string    `gorm:"size:10" json:"currency"`
+	ActionID      int64     `json:"action_id"` // 仅 action_price 有值
+	Source        string    `gorm:"size:30;not null" json:"source"`
+	ChangedBy     *uint     `json:"changed_by"`
+	JobID         *uint     `gorm:"index" json:"job_id"`
+	Reference     string    `gorm:"size:100" json:"reference"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (PriceHistory) TableName() string {
+	return "price_history"
+}
@@ -0,0 +1,73 @@
+package model
+
+import "time"
+
+const (
+	PricePlanStatusScheduled = "scheduled"
+	PricePlanStatusApplying  = "applying"
+	PricePlanStatusActive    = "active"
+	PricePlanStatusReverting = "reverting"
+	PricePlanStatusCompleted = "completed"
+	PricePlanStatusCancelled = "cancelled"
+	// PricePlanStatusFailed 改价或回滚中途失败，已改价的商品可通过回滚接口重试恢复
+	PricePlanStatusFailed = "failed"
+
+	PricePlanItemStatusPending = "pending"
+	PricePlanItemStatusApplied = "applied"
+	// PricePlanItemStatusSkipped 开始时与亏损处理或活动价冲突，未改价
+	PricePlanItemStatusSkipped = "skipped"
+	PricePlanItemStatusFailed  = "failed"
+	// PricePlanItemStatusReverted 已恢复为改价前价格
+	PricePlanItemStatusReverted = "reverted"
+	// PricePlanItemStatusRevertSkipped 计划期间价格已被其他操作修改，不再回滚
+	PricePlanItemStatusRevertSkipped = "revert_skipped"
+	PricePlanItemStatusRevertFailed  = "revert_failed"
+)
+
+// PricePlan 定时改价计划：开始时把计划内商品改为目标价，结束时恢复为开始前记录的价格
+type PricePlan struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	ShopID        uint       `gorm:"not null;index" json:"shop_id"`
+	Name          string     `gorm:"size:100;not null" json:"name"`
+	Status        string     `gorm:"size:20;not null;default:scheduled" json:"status"`
+	StartAt       time.Time  `gorm:"not null" json:"start_at"`
+	EndAt         time.Time  `gorm:"not null" json:"end_at"`
+	CreatedBy     uint       `json:"created_by"`
+	ItemCount     int        `gorm:"not null;default:0" json:"item_count"`
+	AppliedCount  int        `gorm:"not null;default:0" json:"applied_count"`
+	SkippedCount  int        `gorm:"not null;default:0" json:"skipped_count"`
+	FailedCount   int        `gorm:"not null;default:0" json:"failed_count"`
+	RevertedCount int        `gorm:"not null;default:0" json:"reverted_count"`
+	ErrorMessage  string     `gorm:"type:text" json:"error_message"`
+	AppliedAt     *time.Time `json:"applied_at"`
+	RevertedAt    *time.Time `json:"reverted_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Items []PricePlanItem `gorm:"foreignKey:PlanID" json:"items,omitempty"`
+}
+
+func (PricePlan) TableName() string {
+	return "price_plans"
+}
+
+// PricePlanItem 计划内单个商品的目标价与改价前价格
+type PricePlanItem struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	PlanID        uint       `gorm:"not null;index" json:"plan_id"`
+	ProductID     uint       `gorm:"not null" json:"product_id"`
+	SourceSKU     string     `gorm:"size:120;not null" json:"source_sku"`
+	OzonProductID int64      `json:"ozon_product_id"`
+	TargetPrice   float64    `gorm:"type:decimal(12,2);not null" json:"target_price"`
+	PreviousPrice float64    `gorm:"type:decimal(12,2)" json:"previous_price"` // 开始改价时的 Ozon 售价，结束时恢复为该价格
+	Status        string     `gorm:"size:20;not null;default:pending" json:"status"`
+	Note          string     `gorm:"type:text" json:"note"` // 冲突说明或失败原因
+	AppliedAt     *time.Time `json:"applied_at"`
+	RevertedAt    *time.Time `json:"reverted_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (PricePlanItem) TableName() string {
+	return "price_plan_items"
+}
@@ -22,6 +22,9 @@ type Product struct {
 	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 
+	// Tags 本地标签（字符串数组），用于自动加促销选品规则
+	Tags datatypes.JSON `gorm:"type:jsonb" json:"tags"`
+
 	// 关联
 	Shop             Shop              `gorm:"foreignKey:ShopID" json:"shop,omitempty"`
 	LossProducts     []LossProduct     `gorm:"foreignKey:ProductID" json:"loss_products,omitempty"`
@@ -92,6 +95,7 @@ type PromotionAction struct {
 	Status               string         `gorm:"size:20;default:active" json:"status"` // active / expired / disabled
 	SortOrder            int            `gorm:"default:0" json:"sort_order"`          // 排序顺序
 	SourcePayload        datatypes.JSON `gorm:"type:jsonb" json:"source_payload"`
+	SuccessorActionIDs   datatypes.JSON `gorm:"type:jsonb" json:"successor_action_ids"` // 到期后自动报名的后续活动（promotion_actions.id）
 	LastSyncedAt         *time.Time     `json:"last_synced_at"`
 	LastProductsSyncedAt *time.Time     `json:"last_products_synced_at"`
 	CreatedAt            time.Time      `gorm:"autoCreateTime" json:"created_at"`
@@ -146,7 +150,7 @@ func (PromotionActionProduct) TableName() string {
 // OzonProductCatalogItem Ozon 商品目录缓存表（官网商品列表近似视图）
 type OzonProductCatalogItem struct {
 	ID                 uint           `gorm:"primaryKey" json:"id"`
-	ShopID             uint           `gorm:"not null;uniqueIndex:idx_ozon_catalog_shop_product;index:idx_ozon_catalog_shop_date,priority:1;index:idx_ozon_catalog_shop_visibility,priority:1;index:idx_ozon_catalog_shop_offer,priority:1" json:"shop_id"`
+	ShopID             uint           `gorm:"not null;uniqueIndex:idx_ozon_catalog_shop_product;index:idx_ozon_catalog_shop_date,priority:1;index:idx_ozon_catalog_shop_visibility,priority:1;index:idx_ozon_catalog_shop_offer,priority:1;index:idx_ozon_catalog_shop_category,priority:1" json:"shop_id"`
 	OzonProductID      int64          `gorm:"not null;uniqueIndex:idx_ozon_catalog_shop_product" json:"ozon_product_id"`
 	OfferID            string         `gorm:"size:120;index:idx_ozon_catalog_shop_offer,priority:2" json:"offer_id"`
 	SKU                int64          `json:"sku"`
@@ -162,6 +166,8 @@ type OzonProductCatalogItem struct {
 	StockTotal         int            `json:"stock_total"`
 	StockFBO           int            `json:"stock_fbo"`
 	StockFBS           int            `json:"stock_fbs"`
+	CategoryID         int64          `gorm:"not null;default:0;index:idx_ozon_catalog_shop_category,priority:2" json:"category_id"` // Ozon description_category_id
+	TypeID             int64          `gorm:"not null;default:0" json:"type_id"`
 	ListingDate        *time.Time     `gorm:"index:idx_ozon_catalog_shop_date,priority:2" json:"listing_date"`
 	ListingDateSource  string         `gorm:"size:20;not null;default:local_sync" json:"listing_date_source"` // ozon / local_sync
 	SyncToken          string         `gorm:"size:64;index" json:"sync_token"`
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	PromotionActionStatusActive  = "active"
+	PromotionActionStatusExpired = "expired"
+
+	PromotionExpirationStatusPending = "pending"
+	PromotionExpirationStatusRunning = "running"
+	PromotionExpirationStatusSuccess = "success"
+	// PromotionExpirationStatusPartialSuccess 推广记录已更新，后续活动有报名失败或店铺暂停写入未能报名
+	PromotionExpirationStatusPartialSuccess = "partial_success"
+	PromotionExpirationStatusFailed         = "failed"
+)
+
+// PromotionActionExpiration 活动到期处理记录：活动过了结束时间后置为 expired，
+// 退出本地推广记录并按配置报名后续活动；UncoveredSKUs 为处理后不在任何活动中的商品，
+// ReadAt 为空表示用户尚未查看该提醒。同一活动同一结束时间只处理一次。
+type PromotionActionExpiration struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	ShopID            uint      `gorm:"not null;index" json:"shop_id"`
+	PromotionActionID uint      `gorm:"not null;uniqueIndex:idx_promotion_expiration_action_end" json:"promotion_action_id"`
+	ActionID          int64     `json:"action_id"`
+	Source            string    `gorm:"size:20" json:"source"`
+	Title             string    `gorm:"size:200" json:"title"`
+	DateEnd           time.Time `gorm:"not null;uniqueIndex:idx_promotion_expiration_action_end" json:"date_end"`
+	Status            string    `gorm:"size:20;not null;default:pending" json:"status"`
+	// SKUs 到期时参与该活动的商品，开始处理前保存，重试时以此为准
+	SKUs            datatypes.JSON `gorm:"type:jsonb" json:"skus"`
+	SKUCount        int            `gorm:"not null;default:0" json:"sku_count"`
+	ExitedCount     int            `gorm:"not null;default:0" json:"exited_count"`
+	UnpromotedCount int            `gorm:"not null;default:0" json:"unpromoted_count"`
+	// SuccessorEnrolled 报名成功或已提交店铺活动任务的商品数
+	SuccessorEnrolled int            `gorm:"not null;default:0" json:"successor_enrolled"`
+	SuccessorFailed   int            `gorm:"not null;default:0" json:"successor_failed"`
+	JobIDs            datatypes.JSON `gorm:"type:jsonb" json:"job_ids"`
+	UncoveredCount    int            `gorm:"not null;default:0" json:"uncovered_count"`
+	UncoveredSKUs     datatypes.JSON `gorm:"type:jsonb" json:"uncovered_skus"`
+	ErrorMessage      string         `gorm:"type:text" json:"error_message"`
+	ReadAt            *time.Time     `json:"read_at"`
+	CompletedAt       *time.Time     `json:"completed_at"`
+	CreatedAt         time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (PromotionActionExpiration) TableName() string {
+	return "promotion_action_expirations"
+}
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+const (
+	PromotionParticipationJoined   = "joined"
+	PromotionParticipationLeft     = "left"
+	PromotionParticipationRepriced = "repriced"
+
+	// 流水来源：对应写入推广状态的各条链路
+	PromotionParticipationOriginBatchEnroll   = "batch_enroll"
+	PromotionParticipationOriginProcessLoss   = "process_loss"
+	PromotionParticipationOriginRemoveReprice = "remove_reprice"
+	PromotionParticipationOriginUnifiedRemove = "unified_remove"
+	PromotionParticipationOriginAutoPromotion = "auto_promotion"
+	PromotionParticipationOriginReconcile     = "reconcile"
+	PromotionParticipationOriginAutomationJob = "automation_job"
+	PromotionParticipationOriginBulkRevert    = "bulk_revert"
+	PromotionParticipationOriginActionExpired = "action_expired"
+)
+
+// PromotionParticipationEvent 促销参与流水：商品加入 / 退出活动及改价的只追加记录。
+// PromotedProduct 只保留当前状态，历史时间线以本表为准。
+type PromotionParticipationEvent struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	ShopID        uint      `gorm:"not null;index" json:"shop_id"`
+	ProductID     uint      `gorm:"not null;index" json:"product_id"`
+	SourceSKU     string    `gorm:"size:100" json:"source_sku"`
+	OzonProductID int64     `json:"ozon_product_id"`
+	EventType     string    `gorm:"size:20;not null" json:"event_type"` // joined / left / repriced
+	ActionSource  string    `gorm:"size:20" json:"action_source"`       // official / shop，改价为空
+	ActionID      int64     `gorm:"index" json:"action_id"`
+	ActionTitle   string    `gorm:"size:200" json:"action_title"`
+	ActionPrice   float64   `gorm:"type:decimal(12,2)" json:"action_price"`
+	Price         float64   `gorm:"type:decimal(12,2)" json:"price"`
+	PreviousPrice float64   `gorm:"type:decimal(12,2)" json:"previous_price"`
+	Origin        string    `gorm:"size:30;not null" json:"origin"`
+	TriggeredBy   *uint     `json:"triggered_by"`
+	Reference     string    `gorm:"size:100" json:"reference"` // 关联对象，例如 automation_job:12
+	Note          string    `gorm:"type:text" json:"note"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (PromotionParticipationEvent) TableName() string {
+	return "promotion_participation_events"
+}
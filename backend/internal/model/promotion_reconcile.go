@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	PromotionReconcileTriggerManual    = "manual"
+	PromotionReconcileTriggerScheduled = "scheduled"
+
+	PromotionReconcileStatusPending        = "pending"
+	PromotionReconcileStatusRunning        = "running"
+	PromotionReconcileStatusSuccess        = "success"
+	PromotionReconcileStatusPartialSuccess = "partial_success"
+	PromotionReconcileStatusFailed         = "failed"
+
+	// PromotionDriftMissingLocal Ozon 活动中有该商品，本地没有有效推广记录
+	PromotionDriftMissingLocal = "missing_local"
+	// PromotionDriftMissingRemote 本地记录在活动中，Ozon 活动商品列表里已没有
+	PromotionDriftMissingRemote = "missing_remote"
+	// PromotionDriftActionEnded 本地记录所属的官方活动已不在 Ozon 活动列表中
+	PromotionDriftActionEnded = "action_ended"
+	// PromotionDriftFlagMismatch 商品 is_promoted 与推广记录不一致
+	PromotionDriftFlagMismatch = "flag_mismatch"
+	// PromotionDriftUnknownProduct Ozon 活动中的商品在本地商品表中不存在，无法补记
+	PromotionDriftUnknownProduct = "unknown_product"
+)
+
+// PromotionReconcileReport 促销状态对账报告：以 Ozon 活动商品为准修正本地推广记录，每次对账一条
+type PromotionReconcileReport struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	ShopID          uint           `gorm:"not null;index" json:"shop_id"`
+	TriggerMode     string         `gorm:"size:20;not null" json:"trigger_mode"` // manual / scheduled
+	Status          string         `gorm:"size:20;not null;default:pending" json:"status"`
+	ActionsChecked  int            `gorm:"not null;default:0" json:"actions_checked"`
+	ActionsFailed   int            `gorm:"not null;default:0" json:"actions_failed"`
+	RemoteProducts  int            `gorm:"not null;default:0" json:"remote_products"`
+	AddedCount      int            `gorm:"not null;default:0" json:"added_count"`
+	ExitedCount     int            `gorm:"not null;default:0" json:"exited_count"`
+	FlagsFixedCount int            `gorm:"not null;default:0" json:"flags_fixed_count"`
+	UnknownCount    int            `gorm:"not null;default:0" json:"unknown_count"`
+	Drift           datatypes.JSON `gorm:"type:jsonb" json:"drift"`
+	ErrorMessage    string         `gorm:"type:text" json:"error_message"`
+	StartedAt       *time.Time     `json:"started_at"`
+	CompletedAt     *time.Time     `json:"completed_at"`
+	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (PromotionReconcileReport) TableName() string {
+	return "promotion_reconcile_reports"
+}
@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+const (
+	// 价格尾数：按规则算出的价格调整为 X.90 / X.99，为空时只保留两位小数
+	RepricePriceEndingNone = ""
+	RepricePriceEnding90   = ".90"
+	RepricePriceEnding99   = ".99"
+)
+
+// RepriceRule 店铺改价规则：按成本与目标毛利率定价，可限制在活动最高价以内以保持活动资格，
+// 再按价格尾数取整并套用上下限。同一商品按优先级匹配第一条启用的规则
+type RepriceRule struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	ShopID    uint   `gorm:"not null;index;uniqueIndex:idx_reprice_rule_shop_name" json:"shop_id"`
+	Name      string `gorm:"size:100;not null;uniqueIndex:idx_reprice_rule_shop_name" json:"name"`
+	Enabled   bool   `gorm:"not null;default:true" json:"enabled"`
+	Priority  int    `gorm:"not null;default:0" json:"priority"` // 越小越先匹配
+	SKUPrefix string `gorm:"size:120" json:"sku_prefix"`         // 为空时匹配全部商品
+	// TargetMarginPercent 目标毛利率（占售价的百分比），为 0 时以当前售价为基准只做限价与取整
+	TargetMarginPercent float64 `gorm:"type:decimal(6,2);not null;default:0" json:"target_margin_percent"`
+	// KeepActionEligible 不高于所在活动及候选活动的最高活动价（MaxActionPrice），避免失去活动资格
+	KeepActionEligible bool      `gorm:"not null;default:false" json:"keep_action_eligible"`
+	PriceEnding        string    `gorm:"size:10" json:"price_ending"`
+	MinPrice           float64   `gorm:"type:decimal(12,2);not null;default:0" json:"min_price"` // 0 表示不限
+	MaxPrice           float64   `gorm:"type:decimal(12,2);not null;default:0" json:"max_price"` // 0 表示不限
+	CreatedBy          uint      `json:"created_by"`
+	CreatedAt          time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (RepriceRule) TableName() string {
+	return "reprice_rules"
+}
+
+// ProductCost 商品成本价，按毛利率定价的规则依赖该表
+type ProductCost struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ShopID    uint      `gorm:"not null;uniqueIndex:idx_product_cost_shop_sku" json:"shop_id"`
+	SourceSKU string    `gorm:"size:120;not null;uniqueIndex:idx_product_cost_shop_sku" json:"source_sku"`
+	Cost      float64   `gorm:"type:decimal(12,2);not null" json:"cost"`
+	UpdatedBy uint      `json:"updated_by"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (ProductCost) TableName() string {
+	return "product_costs"
+}
@@ -12,15 +12,19 @@ const (
 
 // Shop 店铺表
 type Shop struct {
-	ID                  uint      `gorm:"primaryKey" json:"id"`
-	Name                string    `gorm:"size:100;not null" json:"name"`
-	ClientID            string    `gorm:"size:50;uniqueIndex;not null" json:"client_id"`
-	ApiKey              string    `gorm:"size:200;not null" json:"-"` // 不返回给前端
-	IsActive            bool      `gorm:"default:true" json:"is_active"`
-	ExecutionEngineMode string    `gorm:"size:20;not null;default:auto" json:"execution_engine_mode"`
-	OwnerID             uint      `gorm:"not null;index" json:"owner_id"` // 店铺所属的店铺管理员ID
-	CreatedAt           time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt           time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	Name                string     `gorm:"size:100;not null" json:"name"`
+	ClientID            string     `gorm:"size:50;uniqueIndex;not null" json:"client_id"`
+	ApiKey              string     `gorm:"size:200;not null" json:"-"` // 不返回给前端
+	IsActive            bool       `gorm:"default:true" json:"is_active"`
+	ExecutionEngineMode string     `gorm:"size:20;not null;default:auto" json:"execution_engine_mode"`
+	OwnerID             uint       `gorm:"not null;index" json:"owner_id"`              // 店铺所属的店铺管理员ID
+	WritesPaused        bool       `gorm:"not null;default:false" json:"writes_paused"` // 手动暂停全部自动写操作（报名、退出、改价及写类自动化任务）
+	WritesPausedReason  string     `gorm:"size:255" json:"writes_paused_reason,omitempty"`
+	WritesPausedAt      *time.Time `json:"writes_paused_at,omitempty"`
+	WritesPausedBy      *uint      `json:"writes_paused_by,omitempty"`
+	CreatedAt           time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 
 	// 关联
 	Owner *User  `gorm:"foreignKey:OwnerID" json:"owner,omitempty"` // 所属管理员
@@ -31,6 +35,30 @@ func (Shop) TableName() string {
 	return "shops"
 }
 
+const (
+	ShopFailureSourceExecutor = "executor"
+	ShopFailureSourceOzonAPI  = "ozon_api"
+)
+
+// ShopCircuitBreaker 店铺熔断记录，存在即表示该来源已熔断；open_until 之后放行试探，成功则删除
+type ShopCircuitBreaker struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	ShopID uint `gorm:"not null;uniqueIndex:idx_shop_circuit_breaker" json:"shop_id"`
+	// Source executor（Agent/插件执行结果）/ ozon_api（Seller API 调用）
+	Source              string    `gorm:"size:20;not null;uniqueIndex:idx_shop_circuit_breaker" json:"source"`
+	FailureClass        string    `gorm:"size:30;not null" json:"failure_class"`
+	ConsecutiveFailures int       `gorm:"not null;default:0" json:"consecutive_failures"`
+	LastError           string    `gorm:"type:text" json:"last_error"`
+	OpenedAt            time.Time `gorm:"not null" json:"opened_at"`
+	OpenUntil           time.Time `gorm:"not null" json:"open_until"`
+	CreatedAt           time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (ShopCircuitBreaker) TableName() string {
+	return "shop_circuit_breakers"
+}
+
 // UserShop 用户-店铺关联表
 type UserShop struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
@@ -16,25 +16,48 @@ func NewAutoPromotionRepository(db *gorm.DB) *AutoPromotionRepository {
 	return &AutoPromotionRepository{db: db}
 }
 
+// FindConfigByShopID 返回店铺最早创建的配置，供只支持单配置的旧接口使用
 func (r *AutoPromotionRepository) FindConfigByShopID(shopID uint) (*model.AutoPromotionConfig, error) {
 	var config model.AutoPromotionConfig
-	err := r.db.Where("shop_id = ?", shopID).First(&config).Error
+	err := r.db.Where("shop_id = ?", shopID).Order("id ASC").First(&config).Error
 	if err != nil {
 		return nil, err
 	}
 	return &config, nil
 }
 
-func (r *AutoPromotionRepository) UpsertConfig(config *model.AutoPromotionConfig) error {
-	return r.db.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "shop_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"enabled", "schedule_time", "target_date", "official_action_ids", "shop_action_ids", "updated_at"}),
-	}).Create(config).Error
+func (r *AutoPromotionRepository) FindConfigByIDAndShop(configID, shopID uint) (*model.AutoPromotionConfig, error) {
+	var config model.AutoPromotionConfig
+	err := r.db.Where("id = ? AND shop_id = ?", configID, shopID).First(&config).Error
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (r *AutoPromotionRepository) ListConfigsByShop(shopID uint) ([]model.AutoPromotionConfig, error) {
+	configs := make([]model.AutoPromotionConfig, 0)
+	err := r.db.Where("shop_id = ?", shopID).Order("id ASC").Find(&configs).Error
+	return configs, err
+}
+
+func (r *AutoPromotionRepository) CreateConfig(config *model.AutoPromotionConfig) error {
+	return r.db.Create(config).Error
+}
+
+func (r *AutoPromotionRepository) UpdateConfig(config *model.AutoPromotionConfig) error {
+	return r.db.Save(config).Error
+}
+
+// DeleteConfig 删除配置，历史运行保留（config_id 置空），快照中仍有配置名称
+func (r *AutoPromotionRepository) DeleteConfig(configID, shopID uint) (bool, error) {
+	result := r.db.Where("id = ? AND shop_id = ?", configID, shopID).Delete(&model.AutoPromotionConfig{})
+	return result.RowsAffected > 0, result.Error
 }
 
 func (r *AutoPromotionRepository) ListEnabledConfigs() ([]model.AutoPromotionConfig, error) {
 	configs := make([]model.AutoPromotionConfig, 0)
-	err := r.db.Where("enabled = ?", true).Order("shop_id ASC").Find(&configs).Error
+	err := r.db.Where("enabled = ?", true).Order("shop_id ASC, schedule_time ASC, id ASC").Find(&configs).Error
 	return configs, err
 }
 
@@ -43,6 +66,8 @@ func (r *AutoPromotionRepository) FindActiveRunByShop(shopID uint) (*model.AutoP
 	err := r.db.Where("shop_id = ? AND status IN ?", shopID, []string{
 		model.AutoPromotionRunStatusPending,
 		model.AutoPromotionRunStatusRunning,
+		model.AutoPromotionRunStatusInterrupted,
+		model.AutoPromotionRunStatusWaiting,
 	}).Order("id DESC").First(&run).Error
 	if err != nil {
 		return nil, err
@@ -65,8 +90,9 @@ func (r *AutoPromotionRepository) CreateRun(run *model.AutoPromotionRun) error {
 	return r.db.Create(run).Error
 }
 
+// UpdateRun 只保存运行本身，明细由 ReplaceRunItems / SaveRunItemResults 维护
 func (r *AutoPromotionRepository) UpdateRun(run *model.AutoPromotionRun) error {
-	return r.db.Save(run).Error
+	return r.db.Omit(clause.Associations).Save(run).Error
 }
 
 func (r *AutoPromotionRepository) ReplaceRunItems(runID uint, items []model.AutoPromotionRunItem) error {
@@ -84,7 +110,96 @@ func (r *AutoPromotionRepository) ReplaceRunItems(runID uint, items []model.Auto
 	})
 }
 
-func (r *AutoPromotionRepository) ListRunsByShop(shopID uint, page, pageSize int) ([]model.AutoPromotionRun, int64, error) {
+// SaveRunItemResults 执行过程中逐项保存明细的状态与各活动结果，中断后可据此续跑
+func (r *AutoPromotionRepository) SaveRunItemResults(items []model.AutoPromotionRunItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range items {
+			if err := tx.Model(&model.AutoPromotionRunItem{}).
+				Where("id = ? AND run_id = ?", item.ID, item.RunID).
+				Updates(map[string]interface{}{
+					"overall_status":   item.OverallStatus,
+					"official_status":  item.OfficialStatus,
+					"shop_status":      item.ShopStatus,
+					"official_results": item.OfficialResults,
+					"shop_results":     item.ShopResults,
+					"updated_at":       time.Now(),
+				}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ResetRunForRetry 将已结束的运行重新置为待执行并保存重置后的明细；
+// 运行已不是 failed / partial_success（例如被并发重试）时返回 false
+func (r *AutoPromotionRepository) ResetRunForRetry(runID uint, items []model.AutoPromotionRunItem) (bool, error) {
+	reset := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.AutoPromotionRun{}).
+			Where("id = ? AND status IN ?", runID, []string{
+				model.AutoPromotionRunStatusFailed,
+				model.AutoPromotionRunStatusPartialSuccess,
+			}).
+			Updates(map[string]interface{}{
+				"status":        model.AutoPromotionRunStatusPending,
+				"error_message": "",
+				"completed_at":  nil,
+				"updated_at":    time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		reset = true
+		return (&AutoPromotionRepository{db: tx}).SaveRunItemResults(items)
+	})
+	return reset, err
+}
+
+// ListWaitingRuns 返回等待执行端任务的运行，shopID 为 0 时不限店铺
+func (r *AutoPromotionRepository) ListWaitingRuns(shopID uint) ([]model.AutoPromotionRun, error) {
+	query := r.db.Where("status = ?", model.AutoPromotionRunStatusWaiting)
+	if shopID > 0 {
+		query = query.Where("shop_id = ?", shopID)
+	}
+	var runs []model.AutoPromotionRun
+	err := query.Order("id ASC").Find(&runs).Error
+	return runs, err
+}
+
+// ResumeWaitingRun 将等待中的运行置回待执行；运行已不是 waiting（例如已被其他回报唤醒）时返回 false
+func (r *AutoPromotionRepository) ResumeWaitingRun(runID uint) (bool, error) {
+	result := r.db.Model(&model.AutoPromotionRun{}).
+		Where("id = ? AND status = ?", runID, model.AutoPromotionRunStatusWaiting).
+		Updates(map[string]interface{}{
+			"status":          model.AutoPromotionRunStatusPending,
+			"waiting_job_ids": nil,
+			"updated_at":      time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FailWaitingRun 等待超时的运行置为失败；运行已不是 waiting 时返回 false
+func (r *AutoPromotionRepository) FailWaitingRun(runID uint, message string) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&model.AutoPromotionRun{}).
+		Where("id = ? AND status = ?", runID, model.AutoPromotionRunStatusWaiting).
+		Updates(map[string]interface{}{
+			"status":        model.AutoPromotionRunStatusFailed,
+			"error_message": message,
+			"completed_at":  now,
+			"updated_at":    now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *AutoPromotionRepository) ListRunsByShop(shopID uint, configID *uint, page, pageSize int) ([]model.AutoPromotionRun, int64, error) {
 	if page <= 0 {
 		page = 1
 	}
@@ -96,6 +211,9 @@ func (r *AutoPromotionRepository) ListRunsByShop(shopID uint, page, pageSize int
 	var total int64
 
 	query := r.db.Model(&model.AutoPromotionRun{}).Where("shop_id = ?", shopID)
+	if configID != nil {
+		query = query.Where("config_id = ?", *configID)
+	}
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
@@ -118,13 +236,12 @@ func (r *AutoPromotionRepository) FindRunByIDAndShop(runID, shopID uint) (*model
 	return &run, nil
 }
 
-func (r *AutoPromotionRepository) MarkStaleRunningRunsFailed(staleBefore time.Time) error {
+// MarkStaleRunningRunsInterrupted 把启动前遗留的 running 运行标记为 interrupted，由任务队列重新领取后从已落库的进度继续
+func (r *AutoPromotionRepository) MarkStaleRunningRunsInterrupted(staleBefore time.Time) error {
 	return r.db.Model(&model.AutoPromotionRun{}).
 		Where("status = ? AND updated_at < ?", model.AutoPromotionRunStatusRunning, staleBefore).
 		Updates(map[string]interface{}{
-			"status":         model.AutoPromotionRunStatusFailed,
-			"error_message":  "后台重启后将超时运行标记为失败",
-			"completed_at":   time.Now(),
-			"updated_at":     time.Now(),
+			"status":     model.AutoPromotionRunStatusInterrupted,
+			"updated_at": time.Now(),
 		}).Error
 }
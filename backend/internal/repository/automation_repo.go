@@ -7,33 +7,24 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"ozon-manager/internal/model"
+	"ozon-manager/pkg/artifactstore"
 )
 
 type AutomationRepository struct {
 	db *gorm.DB
 }
 
+// pendingJobOrder 待执行任务的出队顺序：优先级高者优先，同优先级先进先出
+const pendingJobOrder = "priority DESC, created_at ASC, id ASC"
+
 func NewAutomationRepository(db *gorm.DB) *AutomationRepository {
 	return &AutomationRepository{db: db}
 }
 
 func (r *AutomationRepository) CreateJobWithItems(job *model.AutomationJob, items []model.AutomationJobItem) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(job).Error; err != nil {
-			return err
-		}
-
-		if len(items) == 0 {
-			return nil
-		}
-
-		for index := range items {
-			items[index].JobID = job.ID
-		}
-
-		return tx.CreateInBatches(items, 200).Error
-	})
+	return r.CreateJobWithDependencies(job, items, nil)
 }
 
 func (r *AutomationRepository) CreateJobEvent(event *model.AutomationJobEvent) error {
@@ -146,7 +137,7 @@ func (r *AutomationRepository) AcquirePendingJobForAgent(agentID uint) (*model.A
 				model.AutomationJobTypePromoUnifiedEnroll,
 				model.AutomationJobTypePromoUnifiedRemove,
 			}).
-			Order("created_at ASC").
+			Order(pendingJobOrder).
 			First(&job).Error
 		if findErr != nil {
 			return findErr
@@ -183,7 +174,7 @@ func (r *AutomationRepository) AcquirePendingJobForShop(shopID uint, jobTypes []
 			query = query.Where("job_type IN ?", jobTypes)
 		}
 
-		findErr := query.Order("created_at ASC").First(&job).Error
+		findErr := query.Order(pendingJobOrder).First(&job).Error
 		if findErr != nil {
 			return findErr
 		}
@@ -226,10 +217,116 @@ func (r *AutomationRepository) ListPendingJobsByTypes(jobTypes []string, limit i
 	}
 
 	var jobs []model.AutomationJob
-	err := query.Order("created_at ASC").Limit(limit).Find(&jobs).Error
+	err := query.Order(pendingJobOrder).Limit(limit).Find(&jobs).Error
 	return jobs, err
 }
 
+// ListPendingJobHeadsByShop 按店铺分组返回每个店铺队首的 perShopLimit 个待执行任务，
+// 避免单个店铺堆积的大量任务把其他店铺挤出候选列表。
+func (r *AutomationRepository) ListPendingJobHeadsByShop(jobTypes []string, perShopLimit int) ([]model.AutomationJob, error) {
+	if perShopLimit <= 0 {
+		perShopLimit = 5
+	}
+
+	inner := r.db.Model(&model.AutomationJob{}).
+		Select("automation_jobs.*, ROW_NUMBER() OVER (PARTITION BY shop_id ORDER BY "+pendingJobOrder+") AS shop_rank").
+		Where("status = ? AND dry_run = ?", model.AutomationJobStatusPending, false)
+	if len(jobTypes) > 0 {
+		inner = inner.Where("job_type IN ?", jobTypes)
+	}
+
+	var jobs []model.AutomationJob
+	err := r.db.Table("(?) AS ranked", inner).
+		Where("shop_rank <= ?", perShopLimit).
+		Order(pendingJobOrder).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// CountRunningJobsByShop 统计各店铺当前执行中的任务数，用于公平调度加权。
+func (r *AutomationRepository) CountRunningJobsByShop(jobTypes []string) (map[uint]int, error) {
+	type row struct {
+		ShopID uint
+		Total  int
+	}
+
+	query := r.db.Model(&model.AutomationJob{}).
+		Select("shop_id, COUNT(*) AS total").
+		Where("status = ?", model.AutomationJobStatusRunning)
+	if len(jobTypes) > 0 {
+		query = query.Where("job_type IN ?", jobTypes)
+	}
+
+	var rows []row
+	if err := query.Group("shop_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint]int, len(rows))
+	for _, item := range rows {
+		result[item.ShopID] = item.Total
+	}
+	return result, nil
+}
+
+// CountRunningJobsByAgent 统计各执行端当前执行中的任务数，用于并发上限判断
+func (r *AutomationRepository) CountRunningJobsByAgent() (map[uint]int, error) {
+	type row struct {
+		AssignedAgentID uint
+		Total           int
+	}
+
+	var rows []row
+	err := r.db.Model(&model.AutomationJob{}).
+		Select("assigned_agent_id, COUNT(*) AS total").
+		Where("status = ? AND assigned_agent_id IS NOT NULL", model.AutomationJobStatusRunning).
+		Group("assigned_agent_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint]int, len(rows))
+	for _, item := range rows {
+		result[item.AssignedAgentID] = item.Total
+	}
+	return result, nil
+}
+
+func (r *AutomationRepository) ListPendingJobsByShop(shopID uint, limit int) ([]model.AutomationJob, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	var jobs []model.AutomationJob
+	err := r.db.Where("shop_id = ? AND status = ? AND dry_run = ?", shopID, model.AutomationJobStatusPending, false).
+		Order(pendingJobOrder).
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// AutomationQueueStat 按店铺、任务类型、状态聚合的队列统计
+type AutomationQueueStat struct {
+	ShopID        uint
+	JobType       string
+	Status        string
+	Depth         int64
+	MaxPriority   int
+	OldestCreated time.Time
+}
+
+func (r *AutomationRepository) ListQueueStats(statuses []string) ([]AutomationQueueStat, error) {
+	var stats []AutomationQueueStat
+	err := r.db.Model(&model.AutomationJob{}).
+		Select("shop_id, job_type, status, COUNT(*) AS depth, MAX(priority) AS max_priority, MIN(created_at) AS oldest_created").
+		Where("status IN ?", statuses).
+		Group("shop_id, job_type, status").
+		Order("shop_id ASC, job_type ASC, status ASC").
+		Scan(&stats).Error
+	return stats, err
+}
+
 func (r *AutomationRepository) AcquirePendingJobByIDForAgent(jobID uint, agentID uint) (*model.AutomationJob, error) {
 	now := time.Now()
 	updateResult := r.db.Model(&model.AutomationJob{}).
@@ -336,8 +433,30 @@ func (r *AutomationRepository) FindPendingJobByTypeAndShop(jobType string, shopI
 	return &job, nil
 }
 
-func (r *AutomationRepository) UpdateJobAndItemsByReport(jobID uint, status string, results []model.AutomationJobItem) error {
+// FindPendingJobByTypeShopAndItem 按明细 SKU 查找店铺内同类型仍在排队或执行中的任务，单明细的同步类任务以 SKU 区分同步目标
+func (r *AutomationRepository) FindPendingJobByTypeShopAndItem(jobType string, shopID uint, sourceSKU string) (*model.AutomationJob, error) {
+	var job model.AutomationJob
+	err := r.db.Where("job_type = ? AND shop_id = ? AND status IN ?", jobType, shopID, []string{model.AutomationJobStatusPending, model.AutomationJobStatusRunning}).
+		Where("EXISTS (SELECT 1 FROM automation_job_items WHERE automation_job_items.job_id = automation_jobs.id AND automation_job_items.source_sku = ?)", sourceSKU).
+		Order("id DESC").
+		First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *AutomationRepository) UpdateJobAndItemsByReport(jobID, agentID uint, status string, results []model.AutomationJobItem) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定仍在执行且分配给回报方的任务，期间被重新排队或改派的任务不再接受该回报
+		var job model.AutomationJob
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ? AND status = ? AND assigned_agent_id = ?", jobID, model.AutomationJobStatusRunning, agentID).
+			First(&job).Error; err != nil {
+			return err
+		}
+
 		successCount := 0
 		failedCount := 0
 
@@ -350,6 +469,8 @@ func (r *AutomationRepository) UpdateJobAndItemsByReport(jobID uint, status stri
 				"step_exit_error":     result.StepExitError,
 				"step_reprice_error":  result.StepRepriceError,
 				"step_readd_error":    result.StepReaddError,
+				"next_retry_at":       nil,
+				"last_error_kind":     "",
 			}
 			if err := tx.Model(&model.AutomationJobItem{}).
 				Where("job_id = ? AND source_sku = ?", jobID, result.SourceSKU).
@@ -364,18 +485,72 @@ func (r *AutomationRepository) UpdateJobAndItemsByReport(jobID uint, status stri
 			}
 		}
 
+		// 重试轮次只回报部分明细，按库内全部明细汇总计数与状态
+		storedSuccess, storedFailed, err := countJobItemOutcomes(tx, jobID)
+		if err != nil {
+			return err
+		}
+		if storedSuccess+storedFailed > 0 {
+			successCount = storedSuccess
+			failedCount = storedFailed
+		}
+
 		now := time.Now()
 		jobUpdates := map[string]interface{}{
-			"status":        status,
+			"status":        deriveReportedJobStatus(status, storedSuccess, storedFailed),
 			"success_items": successCount,
 			"failed_items":  failedCount,
 			"error_message": deriveJobErrorMessage(status, results),
+			"next_retry_at": nil,
 			"completed_at":  &now,
 		}
 		return tx.Model(&model.AutomationJob{}).Where("id = ?", jobID).Updates(jobUpdates).Error
 	})
 }
 
+// countJobItemOutcomes 统计任务明细中已成功（含跳过）与失败（含死信）的数量
+func countJobItemOutcomes(tx *gorm.DB, jobID uint) (int, int, error) {
+	type outcomeRow struct {
+		OverallStatus string
+		Count         int
+	}
+	var rows []outcomeRow
+	if err := tx.Model(&model.AutomationJobItem{}).
+		Select("overall_status, COUNT(*) AS count").
+		Where("job_id = ?", jobID).
+		Group("overall_status").
+		Scan(&rows).Error; err != nil {
+		return 0, 0, err
+	}
+
+	success, failed := 0, 0
+	for _, row := range rows {
+		switch row.OverallStatus {
+		case model.AutomationStepStatusSuccess, model.AutomationStepStatusSkipped:
+			success += row.Count
+		case model.AutomationStepStatusFailed, model.AutomationStepStatusDeadLetter:
+			failed += row.Count
+		}
+	}
+	return success, failed, nil
+}
+
+// deriveReportedJobStatus 结合库内明细结果修正回报状态：
+// 重试轮次全部成功但此前存在死信明细时为部分成功，回报失败但此前已有成功明细时同样为部分成功。
+func deriveReportedJobStatus(reported string, storedSuccess, storedFailed int) string {
+	switch reported {
+	case model.AutomationJobStatusSuccess:
+		if storedFailed > 0 {
+			return model.AutomationJobStatusPartialSuccess
+		}
+	case model.AutomationJobStatusFailed:
+		if storedSuccess > 0 {
+			return model.AutomationJobStatusPartialSuccess
+		}
+	}
+	return reported
+}
+
 func deriveJobErrorMessage(status string, results []model.AutomationJobItem) string {
 	if status != model.AutomationJobStatusFailed && status != model.AutomationJobStatusPartialSuccess {
 		return ""
@@ -411,10 +586,11 @@ func (r *AutomationRepository) UpdateJobStatus(jobID uint, status string) error
 	return r.db.Model(&model.AutomationJob{}).Where("id = ?", jobID).Updates(updates).Error
 }
 
+// ResetFailedItemsForRetry 人工重跑：失败与死信明细全部重新排队
 func (r *AutomationRepository) ResetFailedItemsForRetry(jobID uint) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Model(&model.AutomationJobItem{}).
-			Where("job_id = ? AND overall_status = ?", jobID, model.AutomationStepStatusFailed).
+			Where("job_id = ? AND overall_status IN ?", jobID, []string{model.AutomationStepStatusFailed, model.AutomationStepStatusDeadLetter}).
 			Updates(map[string]interface{}{
 				"overall_status":      model.AutomationStepStatusPending,
 				"step_exit_status":    model.AutomationStepStatusPending,
@@ -423,6 +599,8 @@ func (r *AutomationRepository) ResetFailedItemsForRetry(jobID uint) error {
 				"step_exit_error":     "",
 				"step_reprice_error":  "",
 				"step_readd_error":    "",
+				"next_retry_at":       nil,
+				"last_error_kind":     "",
 				"retry_count":         gorm.Expr("retry_count + 1"),
 			}).Error; err != nil {
 			return err
@@ -434,6 +612,7 @@ func (r *AutomationRepository) ResetFailedItemsForRetry(jobID uint) error {
 			"assigned_agent_id": nil,
 			"started_at":        nil,
 			"completed_at":      nil,
+			"next_retry_at":     nil,
 		}).Error
 	})
 }
@@ -488,11 +667,64 @@ func (r *AutomationRepository) CreateArtifact(jobID uint, artifactType string, p
 		JobID:        jobID,
 		ArtifactType: artifactType,
 		StoragePath:  "inline://agent",
+		Checksum:     artifactstore.Checksum(metaBytes),
+		ContentType:  "application/json",
+		SizeBytes:    int64(len(metaBytes)),
 		Meta:         metaBytes,
 	}
 	return r.db.Create(artifact).Error
 }
 
+// CreateArtifactRecord 登记已写入存储后端的产物
+func (r *AutomationRepository) CreateArtifactRecord(artifact *model.AutomationArtifact) error {
+	return r.db.Create(artifact).Error
+}
+
+func (r *AutomationRepository) ListArtifactsByJob(jobID uint) ([]model.AutomationArtifact, error) {
+	var artifacts []model.AutomationArtifact
+	err := r.db.Select("id", "job_id", "job_item_id", "artifact_type", "storage_path", "checksum", "content_type", "size_bytes", "created_at").
+		Where("job_id = ?", jobID).
+		Order("id DESC").
+		Find(&artifacts).Error
+	return artifacts, err
+}
+
+// FindArtifactByIDAndShop 按店铺校验归属后返回产物
+func (r *AutomationRepository) FindArtifactByIDAndShop(artifactID, shopID uint) (*model.AutomationArtifact, error) {
+	var artifact model.AutomationArtifact
+	err := r.db.
+		Joins("JOIN automation_jobs ON automation_jobs.id = automation_artifacts.job_id").
+		Where("automation_artifacts.id = ? AND automation_jobs.shop_id = ?", artifactID, shopID).
+		First(&artifact).Error
+	if err != nil {
+		return nil, err
+	}
+	return &artifact, nil
+}
+
+// ListExpiredArtifacts 返回创建早于 before 且所属任务已结束的产物，用于保留期清理
+func (r *AutomationRepository) ListExpiredArtifacts(before time.Time, limit int) ([]model.AutomationArtifact, error) {
+	var artifacts []model.AutomationArtifact
+	err := r.db.Select("automation_artifacts.id", "automation_artifacts.job_id", "automation_artifacts.storage_path").
+		Joins("JOIN automation_jobs ON automation_jobs.id = automation_artifacts.job_id").
+		Where("automation_artifacts.created_at < ?", before).
+		Where("automation_jobs.status IN ?", []string{
+			model.AutomationJobStatusSuccess,
+			model.AutomationJobStatusPartialSuccess,
+			model.AutomationJobStatusFailed,
+			model.AutomationJobStatusCanceled,
+			model.AutomationJobStatusDryRunCompleted,
+		}).
+		Order("automation_artifacts.id ASC").
+		Limit(limit).
+		Find(&artifacts).Error
+	return artifacts, err
+}
+
+func (r *AutomationRepository) DeleteArtifact(artifactID uint) error {
+	return r.db.Delete(&model.AutomationArtifact{}, artifactID).Error
+}
+
 func (r *AutomationRepository) FindLatestArtifactByJob(jobID uint, artifactType string) (*model.AutomationArtifact, error) {
 	var artifact model.AutomationArtifact
 	err := r.db.Where("job_id = ? AND artifact_type = ?", jobID, artifactType).Order("id DESC").First(&artifact).Error
@@ -501,3 +733,165 @@ func (r *AutomationRepository) FindLatestArtifactByJob(jobID uint, artifactType
 	}
 	return &artifact, nil
 }
+
+// ListRunningJobsStartedBefore 返回开始执行早于 before 的执行中任务，用于超时巡检
+func (r *AutomationRepository) ListRunningJobsStartedBefore(before time.Time) ([]model.AutomationJob, error) {
+	var jobs []model.AutomationJob
+	err := r.db.
+		Where("status = ? AND started_at IS NOT NULL AND started_at < ?", model.AutomationJobStatusRunning, before).
+		Order("id ASC").
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// ListAwaitConfirmJobsCreatedBefore 返回创建早于 before 仍待确认的任务
+func (r *AutomationRepository) ListAwaitConfirmJobsCreatedBefore(before time.Time) ([]model.AutomationJob, error) {
+	var jobs []model.AutomationJob
+	err := r.db.
+		Where("status = ? AND created_at < ?", model.AutomationJobStatusAwaitConfirm, before).
+		Order("id ASC").
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// RequeueStaleJob 将超时的执行中任务重新放回队列。
+// 以 status/started_at 作为条件，避免覆盖巡检期间刚刚回报的结果。
+func (r *AutomationRepository) RequeueStaleJob(job *model.AutomationJob, message string) (bool, error) {
+	affected := int64(0)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.AutomationJob{}).
+			Where("id = ? AND status = ? AND started_at = ?", job.ID, model.AutomationJobStatusRunning, job.StartedAt).
+			Updates(map[string]interface{}{
+				"status":            model.AutomationJobStatusPending,
+				"assigned_agent_id": nil,
+				"started_at":        nil,
+				"error_message":     message,
+				"requeue_count":     gorm.Expr("requeue_count + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		affected = result.RowsAffected
+		if affected == 0 {
+			return nil
+		}
+		return tx.Model(&model.AutomationJobItem{}).
+			Where("job_id = ?", job.ID).
+			Updates(map[string]interface{}{
+				"overall_status":      model.AutomationStepStatusPending,
+				"step_exit_status":    model.AutomationStepStatusPending,
+				"step_reprice_status": model.AutomationStepStatusPending,
+				"step_readd_status":   model.AutomationStepStatusPending,
+			}).Error
+	})
+	return affected > 0, err
+}
+
+// ExpireJob 将仍处于 fromStatus 的任务置为终态并记录原因，返回是否实际更新
+func (r *AutomationRepository) ExpireJob(jobID uint, fromStatus, toStatus, message string) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&model.AutomationJob{}).
+		Where("id = ? AND status = ?", jobID, fromStatus).
+		Updates(map[string]interface{}{
+			"status":        toStatus,
+			"error_message": message,
+			"completed_at":  &now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// AutomationItemRetryUpdate 单个失败明细的重试决策
+type AutomationItemRetryUpdate struct {
+	ItemID      uint
+	ErrorKind   string
+	NextRetryAt *time.Time
+	DeadLetter  bool
+}
+
+// ApplyItemRetryUpdates 写入明细重试决策；nextRetryAt 非空时任务转为 retry_wait 等待自动重试
+func (r *AutomationRepository) ApplyItemRetryUpdates(jobID uint, updates []AutomationItemRetryUpdate, nextRetryAt *time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, update := range updates {
+			values := map[string]interface{}{
+				"last_error_kind": update.ErrorKind,
+				"next_retry_at":   update.NextRetryAt,
+			}
+			if update.DeadLetter {
+				values["overall_status"] = model.AutomationStepStatusDeadLetter
+				values["next_retry_at"] = nil
+			}
+			if err := tx.Model(&model.AutomationJobItem{}).
+				Where("id = ? AND job_id = ?", update.ItemID, jobID).
+				Updates(values).Error; err != nil {
+				return err
+			}
+		}
+
+		if nextRetryAt == nil {
+			return nil
+		}
+		return tx.Model(&model.AutomationJob{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+			"status":        model.AutomationJobStatusRetryWait,
+			"next_retry_at": nextRetryAt,
+			"completed_at":  nil,
+		}).Error
+	})
+}
+
+func (r *AutomationRepository) ListRetryDueJobs(now time.Time) ([]model.AutomationJob, error) {
+	var jobs []model.AutomationJob
+	err := r.db.
+		Where("status = ? AND next_retry_at <= ?", model.AutomationJobStatusRetryWait, now).
+		Order("next_retry_at ASC").
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// RequeueRetryItems 将已排期重试的失败明细恢复为 pending，并把任务放回队列
+func (r *AutomationRepository) RequeueRetryItems(jobID uint) (bool, error) {
+	affected := int64(0)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.AutomationJob{}).
+			Where("id = ? AND status = ?", jobID, model.AutomationJobStatusRetryWait).
+			Updates(map[string]interface{}{
+				"status":            model.AutomationJobStatusPending,
+				"assigned_agent_id": nil,
+				"started_at":        nil,
+				"completed_at":      nil,
+				"next_retry_at":     nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		affected = result.RowsAffected
+		if affected == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&model.AutomationJobItem{}).
+			Where("job_id = ? AND overall_status = ? AND next_retry_at IS NOT NULL", jobID, model.AutomationStepStatusFailed).
+			Updates(map[string]interface{}{
+				"overall_status":      model.AutomationStepStatusPending,
+				"step_exit_status":    model.AutomationStepStatusPending,
+				"step_reprice_status": model.AutomationStepStatusPending,
+				"step_readd_status":   model.AutomationStepStatusPending,
+				"step_exit_error":     "",
+				"step_reprice_error":  "",
+				"step_readd_error":    "",
+				"next_retry_at":       nil,
+				"retry_count":         gorm.Expr("retry_count + 1"),
+			}).Error; err != nil {
+			return err
+		}
+
+		success, failed, err := countJobItemOutcomes(tx, jobID)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&model.AutomationJob{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+			"success_items": success,
+			"failed_items":  failed,
+		}).Error
+	})
+	return affected > 0, err
+}
@@ -42,3 +42,20 @@ func TestDeriveJobErrorMessageSupportsPartialSuccess(t *testing.T) {
 		t.Fatalf("deriveJobErrorMessage() = %q", got)
 	}
 }
+
+func TestDeriveReportedJobStatusAccountsForEarlierRounds(t *testing.T) {
+	t.Parallel()
+
+	if got := deriveReportedJobStatus(model.AutomationJobStatusSuccess, 3, 0); got != model.AutomationJobStatusSuccess {
+		t.Fatalf("deriveReportedJobStatus() = %q", got)
+	}
+	if got := deriveReportedJobStatus(model.AutomationJobStatusSuccess, 2, 1); got != model.AutomationJobStatusPartialSuccess {
+		t.Fatalf("deriveReportedJobStatus() with dead letters = %q", got)
+	}
+	if got := deriveReportedJobStatus(model.AutomationJobStatusFailed, 2, 1); got != model.AutomationJobStatusPartialSuccess {
+		t.Fatalf("deriveReportedJobStatus() with earlier successes = %q", got)
+	}
+	if got := deriveReportedJobStatus(model.AutomationJobStatusFailed, 0, 0); got != model.AutomationJobStatusFailed {
+		t.Fatalf("deriveReportedJobStatus() without items = %q", got)
+	}
+}
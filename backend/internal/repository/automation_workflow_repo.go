@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"ozon-manager/internal/model"
+)
+
+// 依赖检查结果
+const (
+	dependencyStateSatisfied = "satisfied"
+	dependencyStateWaiting   = "waiting"
+	dependencyStateBroken    = "broken"
+)
+
+func (r *AutomationRepository) CreateWorkflow(workflow *model.AutomationWorkflow) error {
+	if workflow.Status == "" {
+		workflow.Status = model.AutomationWorkflowStatusRunning
+	}
+	return r.db.Create(workflow).Error
+}
+
+func (r *AutomationRepository) FindWorkflowByID(workflowID uint) (*model.AutomationWorkflow, error) {
+	var workflow model.AutomationWorkflow
+	if err := r.db.Where("id = ?", workflowID).First(&workflow).Error; err != nil {
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+func (r *AutomationRepository) FindWorkflowByIDAndShop(workflowID, shopID uint) (*model.AutomationWorkflow, error) {
+	var workflow model.AutomationWorkflow
+	err := r.db.
+		Where("id = ? AND shop_id = ?", workflowID, shopID).
+		Preload("Jobs", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		First(&workflow).Error
+	if err != nil {
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+// CreateJobWithDependencies 创建任务并登记其依赖。
+// 依赖任务行在事务内加锁，避免依赖恰好在登记前完成而导致任务永远停留在 waiting。
+// 依赖全部成功且续接动作已完成时任务保持调用方给定的初始状态；存在失败/取消的依赖时直接取消。
+func (r *AutomationRepository) CreateJobWithDependencies(job *model.AutomationJob, items []model.AutomationJobItem, dependsOn []uint) error {
+	if job.Priority <= 0 {
+		job.Priority = model.AutomationJobPriorityNormal
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(dependsOn) > 0 {
+			var dependencies []model.AutomationJob
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Select("id", "status", "on_success", "continuation_status").
+				Where("id IN ?", dependsOn).
+				Find(&dependencies).Error; err != nil {
+				return err
+			}
+
+			switch resolveDependencyState(dependencies, len(dependsOn)) {
+			case dependencyStateWaiting:
+				job.Status = model.AutomationJobStatusWaiting
+			case dependencyStateBroken:
+				now := time.Now()
+				job.Status = model.AutomationJobStatusCanceled
+				job.ErrorMessage = "依赖任务未成功完成"
+				job.CompletedAt = &now
+			}
+		}
+
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+
+		if len(dependsOn) > 0 {
+			rows := make([]model.AutomationJobDependency, 0, len(dependsOn))
+			for _, dependsOnID := range dependsOn {
+				rows = append(rows, model.AutomationJobDependency{JobID: job.ID, DependsOnJobID: dependsOnID})
+			}
+			if err := tx.Create(&rows).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(items) == 0 {
+			return nil
+		}
+		for index := range items {
+			items[index].JobID = job.ID
+		}
+		return tx.CreateInBatches(items, 200).Error
+	})
+}
+
+// ListWaitingDependents 返回依赖指定任务且仍在等待的任务
+func (r *AutomationRepository) ListWaitingDependents(jobID uint) ([]model.AutomationJob, error) {
+	var jobs []model.AutomationJob
+	err := r.db.
+		Where("status = ?", model.AutomationJobStatusWaiting).
+		Where("id IN (?)", r.db.Model(&model.AutomationJobDependency{}).Select("job_id").Where("depends_on_job_id = ?", jobID)).
+		Order("id ASC").
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// ResolveWaitingJob 重新检查等待中任务的依赖：全部成功则转为 pending，存在失败则取消。
+// 返回任务最新状态；任务已不在 waiting 时原样返回其状态。
+func (r *AutomationRepository) ResolveWaitingJob(jobID uint) (string, error) {
+	status := ""
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var job model.AutomationJob
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "status").
+			Where("id = ?", jobID).
+			First(&job).Error; err != nil {
+			return err
+		}
+		status = job.Status
+		if job.Status != model.AutomationJobStatusWaiting {
+			return nil
+		}
+
+		var dependencies []model.AutomationJob
+		if err := tx.Model(&model.AutomationJob{}).
+			Select("id", "status", "on_success", "continuation_status").
+			Where("id IN (?)", tx.Model(&model.AutomationJobDependency{}).Select("depends_on_job_id").Where("job_id = ?", jobID)).
+			Find(&dependencies).Error; err != nil {
+			return err
+		}
+		var expected int64
+		if err := tx.Model(&model.AutomationJobDependency{}).Where("job_id = ?", jobID).Count(&expected).Error; err != nil {
+			return err
+		}
+
+		var updates map[string]interface{}
+		switch resolveDependencyState(dependencies, int(expected)) {
+		case dependencyStateSatisfied:
+			updates = map[string]interface{}{"status": model.AutomationJobStatusPending}
+		case dependencyStateBroken:
+			now := time.Now()
+			updates = map[string]interface{}{
+				"status":        model.AutomationJobStatusCanceled,
+				"error_message": "依赖任务未成功完成",
+				"completed_at":  &now,
+			}
+		default:
+			return nil
+		}
+		if err := tx.Model(&model.AutomationJob{}).Where("id = ?", jobID).Updates(updates).Error; err != nil {
+			return err
+		}
+		status = updates["status"].(string)
+		return nil
+	})
+	return status, err
+}
+
+func (r *AutomationRepository) ListWorkflowJobStatuses(workflowID uint) ([]string, error) {
+	var statuses []string
+	err := r.db.Model(&model.AutomationJob{}).Where("workflow_id = ?", workflowID).Order("id ASC").Pluck("status", &statuses).Error
+	return statuses, err
+}
+
+// FindWorkflowFirstError 返回工作流中第一个失败任务的错误信息
+func (r *AutomationRepository) FindWorkflowFirstError(workflowID uint) (string, error) {
+	var job model.AutomationJob
+	err := r.db.Select("id", "error_message").
+		Where("workflow_id = ? AND status IN ?", workflowID, []string{model.AutomationJobStatusFailed, model.AutomationJobStatusCanceled, model.AutomationJobStatusPartialSuccess}).
+		Where("error_message <> ''").
+		Order("id ASC").
+		First(&job).Error
+	if err != nil {
+		return "", err
+	}
+	return job.ErrorMessage, nil
+}
+
+// ClaimJobContinuation 抢占任务的续接动作，返回 false 表示已被抢占（重复回报或并发结束）
+func (r *AutomationRepository) ClaimJobContinuation(jobID uint) (bool, error) {
+	result := r.db.Model(&model.AutomationJob{}).
+		Where("id = ? AND (continuation_status IS NULL OR continuation_status = '')", jobID).
+		Update("continuation_status", model.AutomationContinuationStatusQueued)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *AutomationRepository) UpdateJobContinuationStatus(jobID uint, status string) error {
+	return r.db.Model(&model.AutomationJob{}).Where("id = ?", jobID).Update("continuation_status", status).Error
+}
+
+// ListStaleQueuedContinuations 返回续接动作已排队超过期限、却没有待执行或执行中后台任务的任务，
+// 通常是提交后台任务前进程退出导致
+func (r *AutomationRepository) ListStaleQueuedContinuations(taskType string, before time.Time, limit int) ([]model.AutomationJob, error) {
+	var jobs []model.AutomationJob
+	err := r.db.
+		Where("continuation_status = ? AND completed_at < ?", model.AutomationContinuationStatusQueued, before).
+		Where("NOT EXISTS (SELECT 1 FROM background_tasks bt WHERE bt.task_type = ? AND bt.status IN ? AND bt.payload->>'job_id' = automation_jobs.id::text)",
+			taskType, []string{model.BackgroundTaskStatusPending, model.BackgroundTaskStatusRunning}).
+		Order("id ASC").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// MarkJobFailed 将已结束的任务改判为失败（例如续接动作失败）
+func (r *AutomationRepository) MarkJobFailed(jobID uint, message string) error {
+	now := time.Now()
+	return r.db.Model(&model.AutomationJob{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+		"status":        model.AutomationJobStatusFailed,
+		"error_message": message,
+		"completed_at":  &now,
+	}).Error
+}
+
+func (r *AutomationRepository) UpdateWorkflow(workflowID uint, updates map[string]interface{}) error {
+	return r.db.Model(&model.AutomationWorkflow{}).Where("id = ?", workflowID).Updates(updates).Error
+}
+
+// resolveDependencyState 根据依赖任务状态判断下游任务能否执行。
+// 成功结束的依赖还需续接动作已完成（无续接动作或 continuation_status=done），否则继续等待。
+// expected 为登记的依赖数量，依赖任务被删除（查不到）视为失败。
+func resolveDependencyState(dependencies []model.AutomationJob, expected int) string {
+	if len(dependencies) < expected {
+		return dependencyStateBroken
+	}
+	state := dependencyStateSatisfied
+	for _, dependency := range dependencies {
+		switch dependency.Status {
+		case model.AutomationJobStatusSuccess, model.AutomationJobStatusPartialSuccess:
+			if !dependencyContinuationSettled(dependency) {
+				state = dependencyStateWaiting
+			}
+		case model.AutomationJobStatusFailed, model.AutomationJobStatusCanceled:
+			return dependencyStateBroken
+		default:
+			state = dependencyStateWaiting
+		}
+	}
+	return state
+}
+
+// dependencyContinuationSettled 依赖任务的续接动作是否已完成；成功结束但尚未抢占续接时同样视为未完成
+func dependencyContinuationSettled(dependency model.AutomationJob) bool {
+	if dependency.ContinuationStatus == model.AutomationContinuationStatusDone {
+		return true
+	}
+	return dependency.OnSuccess == "" && dependency.ContinuationStatus == ""
+}
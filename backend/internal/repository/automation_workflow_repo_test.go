@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"testing"
+
+	"ozon-manager/internal/model"
+)
+
+func TestResolveDependencyState(t *testing.T) {
+	t.Parallel()
+
+	success := model.AutomationJob{Status: model.AutomationJobStatusSuccess}
+	partial := model.AutomationJob{Status: model.AutomationJobStatusPartialSuccess}
+	running := model.AutomationJob{Status: model.AutomationJobStatusRunning}
+	failed := model.AutomationJob{Status: model.AutomationJobStatusFailed}
+	continued := model.AutomationJob{Status: model.AutomationJobStatusSuccess, OnSuccess: model.AutomationContinuationImportShopActions, ContinuationStatus: model.AutomationContinuationStatusDone}
+	queued := model.AutomationJob{Status: model.AutomationJobStatusSuccess, OnSuccess: model.AutomationContinuationImportShopActions, ContinuationStatus: model.AutomationContinuationStatusQueued}
+	unclaimed := model.AutomationJob{Status: model.AutomationJobStatusPartialSuccess, OnSuccess: model.AutomationContinuationImportShopActions}
+
+	cases := []struct {
+		name         string
+		dependencies []model.AutomationJob
+		expected     int
+		want         string
+	}{
+		{"all succeeded", []model.AutomationJob{success, partial}, 2, dependencyStateSatisfied},
+		{"continuation done", []model.AutomationJob{success, continued}, 2, dependencyStateSatisfied},
+		{"continuation queued", []model.AutomationJob{success, queued}, 2, dependencyStateWaiting},
+		{"continuation not claimed yet", []model.AutomationJob{unclaimed}, 1, dependencyStateWaiting},
+		{"still running", []model.AutomationJob{success, running}, 2, dependencyStateWaiting},
+		{"one failed", []model.AutomationJob{running, failed}, 2, dependencyStateBroken},
+		{"dependency missing", []model.AutomationJob{success}, 2, dependencyStateBroken},
+	}
+	for _, tc := range cases {
+		if got := resolveDependencyState(tc.dependencies, tc.expected); got != tc.want {
+			t.Fatalf("%s: resolveDependencyState() = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"ozon-manager/internal/model"
+)
+
+type BackgroundTaskRepository struct {
+	db *gorm.DB
+}
+
+func NewBackgroundTaskRepository(db *gorm.DB) *BackgroundTaskRepository {
+	return &BackgroundTaskRepository{db: db}
+}
+
+// Create 入队后台任务
+func (r *BackgroundTaskRepository) Create(task *model.BackgroundTask) error {
+	return r.db.Create(task).Error
+}
+
+// Claim 领取一个到期的待执行任务，或可见性超时未续期的执行中任务。
+// 使用 FOR UPDATE SKIP LOCKED，多个 worker / 多个实例并发领取时互不阻塞、不会重复领取。
+func (r *BackgroundTaskRepository) Claim(taskTypes []string, workerID string, now time.Time) (*model.BackgroundTask, error) {
+	if len(taskTypes) == 0 {
+		return nil, nil
+	}
+
+	var claimed *model.BackgroundTask
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var task model.BackgroundTask
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("task_type IN ?", taskTypes).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?)",
+				model.BackgroundTaskStatusPending, now,
+				model.BackgroundTaskStatusRunning, now).
+			Order("run_at ASC, id ASC").
+			First(&task).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		lockedUntil := now.Add(time.Duration(task.TimeoutSeconds) * time.Second)
+		if err := tx.Model(&model.BackgroundTask{}).
+			Where("id = ?", task.ID).
+			Updates(map[string]interface{}{
+				"status":       model.BackgroundTaskStatusRunning,
+				"attempts":     task.Attempts + 1,
+				"locked_by":    workerID,
+				"locked_until": lockedUntil,
+				"started_at":   now,
+			}).Error; err != nil {
+			return err
+		}
+
+		task.Status = model.BackgroundTaskStatusRunning
+		task.Attempts++
+		task.LockedBy = workerID
+		task.LockedUntil = &lockedUntil
+		task.StartedAt = &now
+		claimed = &task
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// ownedBy 只更新仍由该 worker 持有的任务，租约过期被他人领取后旧 worker 的结果不再生效
+func (r *BackgroundTaskRepository) ownedBy(taskID uint, workerID string) *gorm.DB {
+	return r.db.Model(&model.BackgroundTask{}).
+		Where("id = ? AND status = ? AND locked_by = ?", taskID, model.BackgroundTaskStatusRunning, workerID)
+}
+
+// Extend 续期可见性超时，返回 false 表示租约已丢失
+func (r *BackgroundTaskRepository) Extend(taskID uint, workerID string, lockedUntil time.Time) (bool, error) {
+	result := r.ownedBy(taskID, workerID).Update("locked_until", lockedUntil)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Complete 标记任务成功
+func (r *BackgroundTaskRepository) Complete(taskID uint, workerID string, now time.Time) error {
+	return r.ownedBy(taskID, workerID).Updates(map[string]interface{}{
+		"status":       model.BackgroundTaskStatusSucceeded,
+		"locked_by":    "",
+		"locked_until": nil,
+		"last_error":   "",
+		"completed_at": now,
+	}).Error
+}
+
+// Retry 任务失败后重新排队，runAt 之前不会被领取
+func (r *BackgroundTaskRepository) Retry(taskID uint, workerID string, runAt time.Time, message string) error {
+	return r.ownedBy(taskID, workerID).Updates(map[string]interface{}{
+		"status":       model.BackgroundTaskStatusPending,
+		"run_at":       runAt,
+		"locked_by":    "",
+		"locked_until": nil,
+		"last_error":   message,
+	}).Error
+}
+
+// Fail 标记任务最终失败
+func (r *BackgroundTaskRepository) Fail(taskID uint, workerID string, now time.Time, message string) error {
+	return r.ownedBy(taskID, workerID).Updates(map[string]interface{}{
+		"status":       model.BackgroundTaskStatusFailed,
+		"locked_by":    "",
+		"locked_until": nil,
+		"last_error":   message,
+		"completed_at": now,
+	}).Error
+}
+
+// Release 退还被中断的任务（服务停止时），不计入执行次数，下次启动后立即重新领取
+func (r *BackgroundTaskRepository) Release(taskID uint, workerID string, now time.Time, message string) error {
+	return r.ownedBy(taskID, workerID).Updates(map[string]interface{}{
+		"status":       model.BackgroundTaskStatusPending,
+		"attempts":     gorm.Expr("GREATEST(attempts - 1, 0)"),
+		"run_at":       now,
+		"locked_by":    "",
+		"locked_until": nil,
+		"last_error":   message,
+	}).Error
+}
+
+// DeleteFinishedBefore 清理已成功且完成时间早于 before 的任务，失败任务保留以便排查
+func (r *BackgroundTaskRepository) DeleteFinishedBefore(before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND completed_at < ?", model.BackgroundTaskStatusSucceeded, before).
+		Delete(&model.BackgroundTask{})
+	return result.RowsAffected, result.Error
+}
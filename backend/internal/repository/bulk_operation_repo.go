@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"ozon-manager/internal/model"
+)
+
+// === BulkOperation ===
+
+// CreateBulkOperation 创建操作快照及其商品明细
+func (r *PromotionRepository) CreateBulkOperation(operation *model.BulkOperation) error {
+	return r.db.Create(operation).Error
+}
+
+// UpdateBulkOperation 保存操作本身，不级联保存明细
+func (r *PromotionRepository) UpdateBulkOperation(operation *model.BulkOperation) error {
+	return r.db.Omit("Items").Save(operation).Error
+}
+
+func (r *PromotionRepository) UpdateBulkOperationItem(item *model.BulkOperationItem) error {
+	return r.db.Save(item).Error
+}
+
+func (r *PromotionRepository) FindBulkOperationByIDAndShop(id, shopID uint) (*model.BulkOperation, error) {
+	var operation model.BulkOperation
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Where("id = ? AND shop_id = ?", id, shopID).First(&operation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &operation, nil
+}
+
+// ListBulkOperations 分页查询店铺的操作快照，列表不返回明细
+func (r *PromotionRepository) ListBulkOperations(shopID uint, operationType string, page, pageSize int) ([]model.BulkOperation, int64, error) {
+	var operations []model.BulkOperation
+	var total int64
+
+	query := r.db.Model(&model.BulkOperation{}).Where("shop_id = ?", shopID)
+	if operationType != "" {
+		query = query.Where("operation_type = ?", operationType)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&operations).Error
+	return operations, total, err
+}
+
+// StartBulkOperationRevert 将可撤销的操作置为撤销中，返回 false 表示状态已被其他请求改变
+func (r *PromotionRepository) StartBulkOperationRevert(id uint, revertedBy uint) (bool, error) {
+	result := r.db.Model(&model.BulkOperation{}).
+		Where("id = ? AND status IN ?", id, []string{model.BulkOperationStatusApplied, model.BulkOperationStatusRevertFailed}).
+		Updates(map[string]interface{}{
+			"status":      model.BulkOperationStatusReverting,
+			"reverted_by": revertedBy,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindActivePromotedProductsByProductIDs 批量查询商品当前有效的推广记录
+func (r *PromotionRepository) FindActivePromotedProductsByProductIDs(productIDs []uint) ([]model.PromotedProduct, error) {
+	pps := make([]model.PromotedProduct, 0)
+	if len(productIDs) == 0 {
+		return pps, nil
+	}
+	err := r.db.Where("product_id IN ? AND status = ?", productIDs, "active").Find(&pps).Error
+	return pps, err
+}
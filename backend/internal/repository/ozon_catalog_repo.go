@@ -104,6 +104,8 @@ func (r *OzonCatalogRepository) UpsertBatch(items []model.OzonProductCatalogItem
 			"stock_total",
 			"stock_fbo",
 			"stock_fbs",
+			"category_id",
+			"type_id",
 			"listing_date",
 			"listing_date_source",
 			"sync_token",
@@ -152,8 +154,13 @@ func (r *OzonCatalogRepository) GetLatestSyncedAt(shopID uint) (*time.Time, erro
 }
 
 func (r *OzonCatalogRepository) ListByListingDate(shopID uint, targetDate time.Time) ([]model.OzonProductCatalogItem, error) {
-	start := time.Date(targetDate.Year(), targetDate.Month(), targetDate.Day(), 0, 0, 0, 0, targetDate.Location())
-	end := start.Add(24 * time.Hour)
+	return r.ListByListingDateRange(shopID, targetDate, targetDate)
+}
+
+// ListByListingDateRange 按上架日期区间查询目录商品，from 与 to 均按自然日计算且包含当天
+func (r *OzonCatalogRepository) ListByListingDateRange(shopID uint, from, to time.Time) ([]model.OzonProductCatalogItem, error) {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, to.Location()).Add(24 * time.Hour)
 
 	items := make([]model.OzonProductCatalogItem, 0)
 	err := r.db.Where("shop_id = ? AND listing_date >= ? AND listing_date < ?", shopID, start, end).
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"ozon-manager/internal/model"
+)
+
+type PriceHistoryQuery struct {
+	ShopID    uint
+	SourceSKU string
+	Source    string
+	PriceType string
+	From      *time.Time
+	To        *time.Time
+	Page      int
+	PageSize  int
+}
+
+type PriceHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceHistoryRepository(db *gorm.DB) *PriceHistoryRepository {
+	return &PriceHistoryRepository{db: db}
+}
+
+// CreateBatch 追加价格变动记录
+func (r *PriceHistoryRepository) CreateBatch(entries []model.PriceHistory) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(&entries, 200).Error
+}
+
+// List 按店铺分页查询价格变动，可按 SKU、来源、价格类型与时间范围过滤，按时间倒序
+func (r *PriceHistoryRepository) List(query PriceHistoryQuery) ([]model.PriceHistory, int64, error) {
+	var entries []model.PriceHistory
+	var total int64
+
+	db := r.db.Model(&model.PriceHistory{}).Where("shop_id = ?", query.ShopID)
+	if query.SourceSKU != "" {
+		db = db.Where("source_sku = ?", query.SourceSKU)
+	}
+	if query.Source != "" {
+		db = db.Where("source = ?", query.Source)
+	}
+	if query.PriceType != "" {
+		db = db.Where("price_type = ?", query.PriceType)
+	}
+	if query.From != nil {
+		db = db.Where("created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		db = db.Where("created_at < ?", *query.To)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (query.Page - 1) * query.PageSize
+	err := db.Order("created_at DESC, id DESC").Offset(offset).Limit(query.PageSize).Find(&entries).Error
+	return entries, total, err
+}
+
+// FindJobRepricedSKUs 查询某个自动化任务已记录过改价的 SKU
+func (r *PriceHistoryRepository) FindJobRepricedSKUs(jobID uint) (map[string]struct{}, error) {
+	var skus []string
+	err := r.db.Model(&model.PriceHistory{}).
+		Where("job_id = ? AND price_type = ?", jobID, model.PriceTypePrice).
+		Distinct().Pluck("source_sku", &skus).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]struct{}, len(skus))
+	for _, sku := range skus {
+		result[sku] = struct{}{}
+	}
+	return result, nil
+}
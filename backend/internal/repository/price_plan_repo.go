@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"ozon-manager/internal/model"
+)
+
+// pricePlanOpenStatuses 尚未结束、会占用商品价格的计划状态
+var pricePlanOpenStatuses = []string{
+	model.PricePlanStatusScheduled,
+	model.PricePlanStatusApplying,
+	model.PricePlanStatusActive,
+	model.PricePlanStatusReverting,
+}
+
+type PricePlanRepository struct {
+	db *gorm.DB
+}
+
+func NewPricePlanRepository(db *gorm.DB) *PricePlanRepository {
+	return &PricePlanRepository{db: db}
+}
+
+// CreatePlan 创建计划及其商品明细
+func (r *PricePlanRepository) CreatePlan(plan *model.PricePlan) error {
+	return r.db.Create(plan).Error
+}
+
+// UpdatePlan 保存计划本身，不级联保存明细
+func (r *PricePlanRepository) UpdatePlan(plan *model.PricePlan) error {
+	return r.db.Omit("Items").Save(plan).Error
+}
+
+func (r *PricePlanRepository) UpdateItem(item *model.PricePlanItem) error {
+	return r.db.Save(item).Error
+}
+
+func (r *PricePlanRepository) FindPlanByIDAndShop(id, shopID uint) (*model.PricePlan, error) {
+	var plan model.PricePlan
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Where("id = ? AND shop_id = ?", id, shopID).First(&plan).Error
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// ListPlans 分页查询店铺的计划，列表不返回明细
+func (r *PricePlanRepository) ListPlans(shopID uint, status string, page, pageSize int) ([]model.PricePlan, int64, error) {
+	var plans []model.PricePlan
+	var total int64
+
+	query := r.db.Model(&model.PricePlan{}).Where("shop_id = ?", shopID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("start_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&plans).Error
+	return plans, total, err
+}
+
+// FindOverlappingSKUs 查询时间段与 [startAt, endAt) 重叠、尚未结束的计划中已包含的 SKU，返回 SKU → 计划 ID
+func (r *PricePlanRepository) FindOverlappingSKUs(shopID uint, skus []string, startAt, endAt time.Time) (map[string]uint, error) {
+	result := make(map[string]uint)
+	if len(skus) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		SourceSKU string
+		PlanID    uint
+	}
+	err := r.db.Table("price_plan_items").
+		Select("price_plan_items.source_sku, price_plan_items.plan_id").
+		Joins("JOIN price_plans ON price_plans.id = price_plan_items.plan_id").
+		Where("price_plans.shop_id = ? AND price_plans.status IN ?", shopID, pricePlanOpenStatuses).
+		Where("price_plans.start_at < ? AND price_plans.end_at > ?", endAt, startAt).
+		Where("price_plan_items.source_sku IN ?", skus).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.SourceSKU] = row.PlanID
+	}
+	return result, nil
+}
+
+// FindDuePlans 查询已到开始时间待改价、或已到结束时间待回滚的计划
+func (r *PricePlanRepository) FindDuePlans(now time.Time) ([]model.PricePlan, error) {
+	var plans []model.PricePlan
+	err := r.db.Where("(status = ? AND start_at <= ?) OR (status = ? AND end_at <= ?)",
+		model.PricePlanStatusScheduled, now,
+		model.PricePlanStatusActive, now,
+	).Order("id ASC").Find(&plans).Error
+	return plans, err
+}
+
+// TransitionStatus 仅当计划当前处于 from 中的状态时切换为 to，返回是否切换成功，用于防止重复触发
+func (r *PricePlanRepository) TransitionStatus(id uint, from []string, to string) (bool, error) {
+	result := r.db.Model(&model.PricePlan{}).
+		Where("id = ? AND status IN ?", id, from).
+		Update("status", to)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
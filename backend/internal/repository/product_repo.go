@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 	"ozon-manager/internal/model"
 )
@@ -169,6 +170,14 @@ func (r *ProductRepository) UpdatePromotedStatus(id uint, isPromoted bool) error
 	return r.db.Model(&model.Product{}).Where("id = ?", id).Update("is_promoted", isPromoted).Error
 }
 
+// UpdateTags 批量替换店铺内指定商品的标签，返回更新数量
+func (r *ProductRepository) UpdateTags(shopID uint, productIDs []uint, tags datatypes.JSON) (int64, error) {
+	result := r.db.Model(&model.Product{}).
+		Where("shop_id = ? AND id IN ?", shopID, productIDs).
+		Update("tags", tags)
+	return result.RowsAffected, result.Error
+}
+
 // Upsert 创建或更新商品
 func (r *ProductRepository) Upsert(product *model.Product) error {
 	return r.db.Where("shop_id = ? AND ozon_product_id = ?", product.ShopID, product.OzonProductID).
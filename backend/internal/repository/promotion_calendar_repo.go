@@ -0,0 +1,27 @@
+package repository
+
+import "ozon-manager/internal/model"
+
+// ListCalendarActionProducts 查询活动内商品，只取日历所需的列
+func (r *PromotionRepository) ListCalendarActionProducts(shopID uint, actionIDs []uint) ([]model.PromotionActionProduct, error) {
+	items := make([]model.PromotionActionProduct, 0)
+	if len(actionIDs) == 0 {
+		return items, nil
+	}
+	err := r.db.Select("promotion_action_id", "source_sku", "name", "category_name", "status").
+		Where("shop_id = ? AND promotion_action_id IN ?", shopID, actionIDs).
+		Find(&items).Error
+	return items, err
+}
+
+// ListCalendarActionCandidates 查询活动候选商品，只取日历所需的列
+func (r *PromotionRepository) ListCalendarActionCandidates(shopID uint, actionIDs []uint) ([]model.PromotionActionCandidate, error) {
+	items := make([]model.PromotionActionCandidate, 0)
+	if len(actionIDs) == 0 {
+		return items, nil
+	}
+	err := r.db.Select("promotion_action_id", "source_sku", "status").
+		Where("shop_id = ? AND promotion_action_id IN ?", shopID, actionIDs).
+		Find(&items).Error
+	return items, err
+}
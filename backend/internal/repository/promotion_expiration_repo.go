@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"ozon-manager/internal/model"
+)
+
+// === PromotionActionExpiration ===
+
+// FindEndedActivePromotionActions 查询店铺中已过结束时间但仍为 active 的活动
+func (r *PromotionRepository) FindEndedActivePromotionActions(shopID uint, now time.Time) ([]model.PromotionAction, error) {
+	var pas []model.PromotionAction
+	err := r.db.Where("shop_id = ? AND status = ? AND date_end IS NOT NULL AND date_end <= ?", shopID, model.PromotionActionStatusActive, now).
+		Order("date_end ASC, id ASC").
+		Find(&pas).Error
+	return pas, err
+}
+
+// ExpirePromotionAction 在一个事务内将活动置为 expired 并创建到期处理记录。
+// 返回 false 表示活动已被其他实例处理，或同一结束时间已处理过（活动同步后重新变为 active）。
+func (r *PromotionRepository) ExpirePromotionAction(expiration *model.PromotionActionExpiration) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.PromotionAction{}).
+			Where("id = ? AND status = ?", expiration.PromotionActionID, model.PromotionActionStatusActive).
+			Update("status", model.PromotionActionStatusExpired)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		var count int64
+		if err := tx.Model(&model.PromotionActionExpiration{}).
+			Where("promotion_action_id = ? AND date_end = ?", expiration.PromotionActionID, expiration.DateEnd).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		if err := tx.Create(expiration).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+func (r *PromotionRepository) UpdatePromotionExpiration(expiration *model.PromotionActionExpiration) error {
+	return r.db.Save(expiration).Error
+}
+
+func (r *PromotionRepository) FindPromotionExpirationByIDAndShop(id, shopID uint) (*model.PromotionActionExpiration, error) {
+	var expiration model.PromotionActionExpiration
+	if err := r.db.Where("id = ? AND shop_id = ?", id, shopID).First(&expiration).Error; err != nil {
+		return nil, err
+	}
+	return &expiration, nil
+}
+
+// ListPromotionExpirations 分页查询到期处理记录，列表不返回商品明细；unreadOnly 只查未读的脱离提醒
+func (r *PromotionRepository) ListPromotionExpirations(shopID uint, unreadOnly bool, page, pageSize int) ([]model.PromotionActionExpiration, int64, error) {
+	var expirations []model.PromotionActionExpiration
+	var total int64
+
+	query := r.db.Model(&model.PromotionActionExpiration{}).Where("shop_id = ?", shopID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL AND uncovered_count > 0")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Omit("skus", "uncovered_skus").Order("id DESC").Offset(offset).Limit(pageSize).Find(&expirations).Error
+	return expirations, total, err
+}
+
+// CountUnreadPromotionExpirations 统计有商品脱离全部活动且尚未查看的到期提醒
+func (r *PromotionRepository) CountUnreadPromotionExpirations(shopID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.PromotionActionExpiration{}).
+		Where("shop_id = ? AND read_at IS NULL AND uncovered_count > 0", shopID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkPromotionExpirationsRead 将到期提醒标记为已读，ids 为空时标记店铺全部提醒
+func (r *PromotionRepository) MarkPromotionExpirationsRead(shopID uint, ids []uint) (int64, error) {
+	query := r.db.Model(&model.PromotionActionExpiration{}).Where("shop_id = ? AND read_at IS NULL", shopID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// MarkStalePromotionExpirationsFailed 进程重启后将长时间未结束的到期处理置为失败
+func (r *PromotionRepository) MarkStalePromotionExpirationsFailed(before time.Time) error {
+	now := time.Now()
+	return r.db.Model(&model.PromotionActionExpiration{}).
+		Where("status IN ? AND created_at < ?", []string{
+			model.PromotionExpirationStatusPending,
+			model.PromotionExpirationStatusRunning,
+		}, before).
+		Updates(map[string]interface{}{
+			"status":        model.PromotionExpirationStatusFailed,
+			"error_message": "到期处理超时未完成",
+			"completed_at":  &now,
+		}).Error
+}
+
+// FindActivePromotedProductsByAction 查询店铺在指定官方活动中的有效推广记录（预加载 Product）
+func (r *PromotionRepository) FindActivePromotedProductsByAction(shopID uint, actionID int64) ([]model.PromotedProduct, error) {
+	var pps []model.PromotedProduct
+	err := r.db.Joins("JOIN products ON products.id = promoted_products.product_id").
+		Where("products.shop_id = ? AND promoted_products.action_id = ? AND promoted_products.status = ?", shopID, actionID, "active").
+		Preload("Product").
+		Find(&pps).Error
+	return pps, err
+}
+
+func (r *PromotionRepository) UpdatePromotionActionSuccessors(id uint, successorIDs datatypes.JSON) error {
+	return r.db.Model(&model.PromotionAction{}).Where("id = ?", id).Update("successor_action_ids", successorIDs).Error
+}
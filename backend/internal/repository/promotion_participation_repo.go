@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"ozon-manager/internal/model"
+)
+
+// === PromotionParticipationEvent ===
+
+// CreateParticipationEvents 追加促销参与流水
+func (r *PromotionRepository) CreateParticipationEvents(events []model.PromotionParticipationEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(&events, 200).Error
+}
+
+// ListProductParticipationEvents 分页查询单个商品的参与流水，按时间倒序
+func (r *PromotionRepository) ListProductParticipationEvents(shopID, productID uint, page, pageSize int) ([]model.PromotionParticipationEvent, int64, error) {
+	var events []model.PromotionParticipationEvent
+	var total int64
+
+	query := r.db.Model(&model.PromotionParticipationEvent{}).Where("shop_id = ? AND product_id = ?", shopID, productID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&events).Error
+	return events, total, err
+}
+
+func (r *PromotionRepository) actionParticipationQuery(shopID uint, actionID int64, from, to *time.Time) *gorm.DB {
+	query := r.db.Model(&model.PromotionParticipationEvent{}).
+		Where("shop_id = ? AND action_id = ? AND event_type IN ?", shopID, actionID, []string{
+			model.PromotionParticipationJoined,
+			model.PromotionParticipationLeft,
+		})
+	if from != nil {
+		query = query.Where("created_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("created_at < ?", *to)
+	}
+	return query
+}
+
+// ListActionParticipationEvents 分页查询活动在时间范围内的加入 / 退出流水，eventType 为空时两者都返回
+func (r *PromotionRepository) ListActionParticipationEvents(shopID uint, actionID int64, eventType string, from, to *time.Time, page, pageSize int) ([]model.PromotionParticipationEvent, int64, error) {
+	var events []model.PromotionParticipationEvent
+	var total int64
+
+	query := r.actionParticipationQuery(shopID, actionID, from, to)
+	if eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&events).Error
+	return events, total, err
+}
+
+// CountActionParticipationEvents 统计活动在时间范围内的加入 / 退出次数与涉及商品数
+func (r *PromotionRepository) CountActionParticipationEvents(shopID uint, actionID int64, from, to *time.Time) (map[string]int64, map[string]int64, error) {
+	type row struct {
+		EventType string
+		Events    int64
+		Products  int64
+	}
+	var rows []row
+	err := r.actionParticipationQuery(shopID, actionID, from, to).
+		Select("event_type, COUNT(*) AS events, COUNT(DISTINCT product_id) AS products").
+		Group("event_type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(map[string]int64, len(rows))
+	products := make(map[string]int64, len(rows))
+	for _, item := range rows {
+		events[item.EventType] = item.Events
+		products[item.EventType] = item.Products
+	}
+	return events, products, nil
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"ozon-manager/internal/model"
+)
+
+// === PromotionReconcileReport ===
+
+func (r *PromotionRepository) CreateReconcileReport(report *model.PromotionReconcileReport) error {
+	return r.db.Create(report).Error
+}
+
+func (r *PromotionRepository) UpdateReconcileReport(report *model.PromotionReconcileReport) error {
+	return r.db.Save(report).Error
+}
+
+func (r *PromotionRepository) FindReconcileReportByIDAndShop(id, shopID uint) (*model.PromotionReconcileReport, error) {
+	var report model.PromotionReconcileReport
+	if err := r.db.Where("id = ? AND shop_id = ?", id, shopID).First(&report).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// FindActiveReconcileReport 查询店铺排队中或执行中的对账
+func (r *PromotionRepository) FindActiveReconcileReport(shopID uint) (*model.PromotionReconcileReport, error) {
+	var report model.PromotionReconcileReport
+	err := r.db.Where("shop_id = ? AND status IN ?", shopID, []string{
+		model.PromotionReconcileStatusPending,
+		model.PromotionReconcileStatusRunning,
+	}).Order("id DESC").First(&report).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// FindLatestReconcileReport 查询店铺最近一次对账（不含差异明细）
+func (r *PromotionRepository) FindLatestReconcileReport(shopID uint) (*model.PromotionReconcileReport, error) {
+	var report model.PromotionReconcileReport
+	if err := r.db.Omit("drift").Where("shop_id = ?", shopID).Order("id DESC").First(&report).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// FindLatestCompletedReconcileReport 查询店铺最近一次完成（含部分成功）的对账
+func (r *PromotionRepository) FindLatestCompletedReconcileReport(shopID uint) (*model.PromotionReconcileReport, error) {
+	var report model.PromotionReconcileReport
+	err := r.db.Omit("drift").
+		Where("shop_id = ? AND status IN ?", shopID, []string{
+			model.PromotionReconcileStatusSuccess,
+			model.PromotionReconcileStatusPartialSuccess,
+		}).
+		Order("id DESC").First(&report).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ListReconcileReports 分页查询对账报告，列表不返回差异明细
+func (r *PromotionRepository) ListReconcileReports(shopID uint, page, pageSize int) ([]model.PromotionReconcileReport, int64, error) {
+	var reports []model.PromotionReconcileReport
+	var total int64
+
+	query := r.db.Model(&model.PromotionReconcileReport{}).Where("shop_id = ?", shopID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Omit("drift").Order("id DESC").Offset(offset).Limit(pageSize).Find(&reports).Error
+	return reports, total, err
+}
+
+// MarkStaleReconcileReportsFailed 进程重启后将长时间未结束的对账置为失败
+func (r *PromotionRepository) MarkStaleReconcileReportsFailed(before time.Time) error {
+	now := time.Now()
+	return r.db.Model(&model.PromotionReconcileReport{}).
+		Where("status IN ? AND created_at < ?", []string{
+			model.PromotionReconcileStatusPending,
+			model.PromotionReconcileStatusRunning,
+		}, before).
+		Updates(map[string]interface{}{
+			"status":        model.PromotionReconcileStatusFailed,
+			"error_message": "对账超时未完成",
+			"completed_at":  &now,
+		}).Error
+}
+
+// ApplyPromotionReconcile 在一个事务内补记 Ozon 上已参与的推广记录并退出 Ozon 上已不存在的记录。
+// 补记时优先恢复同商品同活动的历史记录，没有历史记录才新建。
+func (r *PromotionRepository) ApplyPromotionReconcile(activate []model.PromotedProduct, exitIDs []uint) error {
+	if len(activate) == 0 && len(exitIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for index := range activate {
+			item := activate[index]
+			var existing model.PromotedProduct
+			err := tx.Where("product_id = ? AND action_id = ?", item.ProductID, item.ActionID).
+				Order("CASE WHEN promotion_type = 'custom' THEN 0 ELSE 1 END, id DESC").
+				First(&existing).Error
+			if err == gorm.ErrRecordNotFound {
+				item.Status = "active"
+				if createErr := tx.Create(&item).Error; createErr != nil {
+					return createErr
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if updateErr := tx.Model(&model.PromotedProduct{}).Where("id = ?", existing.ID).Updates(map[string]interface{}{
+				"status":       "active",
+				"action_price": item.ActionPrice,
+				"exited_at":    nil,
+				"updated_at":   now,
+			}).Error; updateErr != nil {
+				return updateErr
+			}
+		}
+
+		if len(exitIDs) > 0 {
+			if err := tx.Model(&model.PromotedProduct{}).
+				Where("id IN ? AND status = ?", exitIDs, "active").
+				Updates(map[string]interface{}{
+					"status":    "exited",
+					"exited_at": &now,
+				}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindPromotedFlagMismatches 查询 is_promoted 与是否存在有效推广记录不一致的商品
+func (r *PromotionRepository) FindPromotedFlagMismatches(shopID uint) ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.Where("shop_id = ?", shopID).
+		Where("is_promoted <> EXISTS (SELECT 1 FROM promoted_products pp WHERE pp.product_id = products.id AND pp.status = ?)", "active").
+		Find(&products).Error
+	return products, err
+}
@@ -92,6 +92,17 @@ func (r *PromotionRepository) ExitPromotion(productID uint, promotionType string
 		}).Error
 }
 
+// ExitPromotionAction 退出商品在指定官方活动中的推广记录
+func (r *PromotionRepository) ExitPromotionAction(productID uint, actionID int64) error {
+	now := time.Now()
+	return r.db.Model(&model.PromotedProduct{}).
+		Where("product_id = ? AND action_id = ? AND status = ?", productID, actionID, "active").
+		Updates(map[string]interface{}{
+			"status":    "exited",
+			"exited_at": &now,
+		}).Error
+}
+
 func (r *PromotionRepository) ExitAllPromotions(productID uint) error {
 	now := time.Now()
 	return r.db.Model(&model.PromotedProduct{}).
@@ -165,6 +176,7 @@ func (r *PromotionRepository) UpsertPromotionAction(pa *model.PromotionAction) e
 	pa.ID = existing.ID
 	pa.DisplayName = existing.DisplayName
 	pa.SortOrder = existing.SortOrder
+	pa.SuccessorActionIDs = existing.SuccessorActionIDs
 	return r.db.Save(pa).Error
 }
 
@@ -374,3 +386,27 @@ func (r *PromotionRepository) ListActionProductsByActionIDsAndSourceSKUs(shopID
 		Find(&items).Error
 	return items, err
 }
+
+// ListActionPriceCeilings 查询活动内商品与候选商品的最高活动价，用于改价规则限价；
+// 只取限价所需的列，返回的 PromotionActionProduct / PromotionActionCandidate 其他字段为空
+func (r *PromotionRepository) ListActionPriceCeilings(shopID uint, actionIDs []uint) ([]model.PromotionActionProduct, []model.PromotionActionCandidate, error) {
+	products := make([]model.PromotionActionProduct, 0)
+	candidates := make([]model.PromotionActionCandidate, 0)
+	if len(actionIDs) == 0 {
+		return products, candidates, nil
+	}
+
+	err := r.db.Select("promotion_action_id", "source_sku", "status", "max_action_price").
+		Where("shop_id = ? AND promotion_action_id IN ?", shopID, actionIDs).
+		Find(&products).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	err = r.db.Select("promotion_action_id", "source_sku", "status", "max_action_price").
+		Where("shop_id = ? AND promotion_action_id IN ?", shopID, actionIDs).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	return products, candidates, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"ozon-manager/internal/model"
+)
+
+type RepriceRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewRepriceRuleRepository(db *gorm.DB) *RepriceRuleRepository {
+	return &RepriceRuleRepository{db: db}
+}
+
+// ListRulesByShop 按匹配顺序（优先级、ID）列出店铺的全部规则
+func (r *RepriceRuleRepository) ListRulesByShop(shopID uint) ([]model.RepriceRule, error) {
+	rules := make([]model.RepriceRule, 0)
+	err := r.db.Where("shop_id = ?", shopID).Order("priority ASC, id ASC").Find(&rules).Error
+	return rules, err
+}
+
+func (r *RepriceRuleRepository) FindRuleByIDAndShop(id, shopID uint) (*model.RepriceRule, error) {
+	var rule model.RepriceRule
+	if err := r.db.Where("id = ? AND shop_id = ?", id, shopID).First(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *RepriceRuleRepository) CreateRule(rule *model.RepriceRule) error {
+	return r.db.Create(rule).Error
+}
+
+func (r *RepriceRuleRepository) UpdateRule(rule *model.RepriceRule) error {
+	return r.db.Save(rule).Error
+}
+
+func (r *RepriceRuleRepository) DeleteRule(id, shopID uint) (bool, error) {
+	result := r.db.Where("id = ? AND shop_id = ?", id, shopID).Delete(&model.RepriceRule{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpsertCosts 按 (shop_id, source_sku) 写入成本价，已存在的覆盖
+func (r *RepriceRuleRepository) UpsertCosts(costs []model.ProductCost) error {
+	if len(costs) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "shop_id"}, {Name: "source_sku"}},
+		DoUpdates: clause.AssignmentColumns([]string{"cost", "updated_by", "updated_at"}),
+	}).CreateInBatches(costs, 500).Error
+}
+
+func (r *RepriceRuleRepository) DeleteCosts(shopID uint, skus []string) error {
+	if len(skus) == 0 {
+		return nil
+	}
+	return r.db.Where("shop_id = ? AND source_sku IN ?", shopID, skus).Delete(&model.ProductCost{}).Error
+}
+
+// ListCosts 分页查询成本价，keyword 按 SKU 模糊匹配
+func (r *RepriceRuleRepository) ListCosts(shopID uint, keyword string, page, pageSize int) ([]model.ProductCost, int64, error) {
+	var costs []model.ProductCost
+	var total int64
+
+	query := r.db.Model(&model.ProductCost{}).Where("shop_id = ?", shopID)
+	if keyword != "" {
+		query = query.Where("source_sku ILIKE ?", "%"+keyword+"%")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("source_sku ASC").Offset(offset).Limit(pageSize).Find(&costs).Error
+	return costs, total, err
+}
+
+// FindCostMap 返回店铺全部成本价，SKU → 成本
+func (r *RepriceRuleRepository) FindCostMap(shopID uint) (map[string]float64, error) {
+	var costs []model.ProductCost
+	if err := r.db.Where("shop_id = ?", shopID).Find(&costs).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string]float64, len(costs))
+	for _, cost := range costs {
+		result[cost.SourceSKU] = cost.Cost
+	}
+	return result, nil
+}
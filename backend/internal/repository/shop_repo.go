@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"time"
+
 	"ozon-manager/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ShopRepository struct {
@@ -158,3 +161,43 @@ func (r *ShopRepository) GetExecutionEngineMode(shopID uint) (string, error) {
 func (r *ShopRepository) UpdateExecutionEngineMode(shopID uint, mode string) error {
 	return r.db.Model(&model.Shop{}).Where("id = ?", shopID).Update("execution_engine_mode", mode).Error
 }
+
+// UpdateWritesPaused 设置或解除店铺写操作暂停
+func (r *ShopRepository) UpdateWritesPaused(shopID uint, paused bool, reason string, userID *uint) error {
+	updates := map[string]interface{}{
+		"writes_paused":        paused,
+		"writes_paused_reason": reason,
+		"writes_paused_at":     nil,
+		"writes_paused_by":     nil,
+	}
+	if paused {
+		now := time.Now()
+		updates["writes_paused_at"] = &now
+		updates["writes_paused_by"] = userID
+	}
+	return r.db.Model(&model.Shop{}).Where("id = ?", shopID).Updates(updates).Error
+}
+
+// ListCircuitBreakers 获取全部熔断记录
+func (r *ShopRepository) ListCircuitBreakers() ([]model.ShopCircuitBreaker, error) {
+	breakers := make([]model.ShopCircuitBreaker, 0)
+	err := r.db.Order("shop_id ASC, source ASC").Find(&breakers).Error
+	return breakers, err
+}
+
+// UpsertCircuitBreaker 写入店铺某来源的熔断状态
+func (r *ShopRepository) UpsertCircuitBreaker(breaker *model.ShopCircuitBreaker) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "shop_id"}, {Name: "source"}},
+		DoUpdates: clause.AssignmentColumns([]string{"failure_class", "consecutive_failures", "last_error", "opened_at", "open_until", "updated_at"}),
+	}).Create(breaker).Error
+}
+
+// DeleteCircuitBreakers 关闭店铺熔断，source 为空时关闭全部来源
+func (r *ShopRepository) DeleteCircuitBreakers(shopID uint, source string) error {
+	query := r.db.Where("shop_id = ?", shopID)
+	if source != "" {
+		query = query.Where("source = ?", source)
+	}
+	return query.Delete(&model.ShopCircuitBreaker{}).Error
+}
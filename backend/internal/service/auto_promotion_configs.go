@@ -0,0 +1,303 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+)
+
+const (
+	autoPromotionDefaultConfigName = "默认配置"
+	autoPromotionConfigNameMaxLen  = 100
+
+	autoPromotionConflictSchedule = "schedule"
+	autoPromotionConflictOverlap  = "overlap"
+
+	// autoPromotionScheduleCatchUp 同店铺多个配置执行时间相同或相近时，后者在此窗口内顺延执行
+	autoPromotionScheduleCatchUp = time.Hour
+)
+
+// ListConfigs 列出店铺的全部配置，并标注已启用配置之间的冲突
+func (s *AutoPromotionService) ListConfigs(shopID uint) ([]dto.AutoPromotionConfigResponse, error) {
+	configs, err := s.autoRepo.ListConfigsByShop(shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := dateOnlyValue(time.Now())
+	items := make([]dto.AutoPromotionConfigResponse, 0, len(configs))
+	for index := range configs {
+		resp, err := toAutoPromotionConfigDTO(&configs[index])
+		if err != nil {
+			return nil, err
+		}
+		resp.Conflicts = detectConfigConflicts(&configs[index], configs, today)
+		items = append(items, *resp)
+	}
+	return items, nil
+}
+
+// GetConfig 返回店铺最早创建的配置，供只支持单配置的旧接口使用
+func (s *AutoPromotionService) GetConfig(shopID uint) (*dto.AutoPromotionConfigResponse, error) {
+	config, err := s.autoRepo.FindConfigByShopID(shopID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			yesterday := time.Now().AddDate(0, 0, -1)
+			return &dto.AutoPromotionConfigResponse{
+				ShopID:            shopID,
+				Name:              autoPromotionDefaultConfigName,
+				Enabled:           false,
+				ScheduleTime:      autoPromotionDefaultScheduleTime,
+				TargetDate:        yesterday.Format("2006-01-02"),
+				OfficialActionIDs: []uint{},
+				ShopActionIDs:     []uint{},
+				TargetMode:        model.AutoPromotionTargetModeFixedDate,
+				SelectionRules:    dto.AutoPromotionSelectionRule{CandidateMatch: autoPromotionCandidateMatchAll},
+			}, nil
+		}
+		return nil, err
+	}
+	return toAutoPromotionConfigDTO(config)
+}
+
+func (s *AutoPromotionService) CreateConfig(req *dto.AutoPromotionConfigRequest) (*dto.AutoPromotionConfigResponse, error) {
+	return s.saveConfig(req, &model.AutoPromotionConfig{ShopID: req.ShopID})
+}
+
+// UpdateConfig 更新配置；req.ID 为空时（旧的单配置接口）更新店铺最早的配置，店铺还没有配置时新建
+func (s *AutoPromotionService) UpdateConfig(req *dto.AutoPromotionConfigRequest) (*dto.AutoPromotionConfigResponse, error) {
+	var (
+		config *model.AutoPromotionConfig
+		err    error
+	)
+	if req.ID == 0 {
+		config, err = s.autoRepo.FindConfigByShopID(req.ShopID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s.CreateConfig(req)
+		}
+		if err == nil && strings.TrimSpace(req.Name) == "" {
+			req.Name = config.Name
+		}
+	} else {
+		config, err = s.autoRepo.FindConfigByIDAndShop(req.ID, req.ShopID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.saveConfig(req, config)
+}
+
+// DeleteConfig 删除配置，已产生的运行记录保留
+func (s *AutoPromotionService) DeleteConfig(shopID, configID uint) error {
+	deleted, err := s.autoRepo.DeleteConfig(configID, shopID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// saveConfig 校验请求并写入 config；启用的配置与其他已启用配置的选品范围和活动重叠时拒绝保存，
+// 执行时间相同只作为提示返回
+func (s *AutoPromotionService) saveConfig(req *dto.AutoPromotionConfigRequest, config *model.AutoPromotionConfig) (*dto.AutoPromotionConfigResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = autoPromotionDefaultConfigName
+	}
+	if utf8.RuneCountInString(name) > autoPromotionConfigNameMaxLen {
+		return nil, fmt.Errorf("配置名称不能超过 %d 个字符", autoPromotionConfigNameMaxLen)
+	}
+
+	target, err := normalizeAutoPromotionTarget(req.TargetMode, req.TargetOffsetDays, req.TargetDate)
+	if err != nil {
+		return nil, err
+	}
+	if target.Date.IsZero() {
+		// 相对模式不使用固定日期，列仍为必填，记录保存当天
+		target.Date = dateOnlyValue(time.Now())
+	}
+
+	scheduleTime, err := normalizeScheduleTime(req.ScheduleTime)
+	if err != nil {
+		return nil, err
+	}
+
+	selectionRules, err := normalizeSelectionRule(req.SelectionRules)
+	if err != nil {
+		return nil, err
+	}
+
+	officialIDs := uniqueUints(req.OfficialActionIDs)
+	shopIDs := uniqueUints(req.ShopActionIDs)
+	if req.Enabled && len(officialIDs)+len(shopIDs) == 0 {
+		return nil, fmt.Errorf("启用自动加促销前至少选择一个活动")
+	}
+	if len(officialIDs)+len(shopIDs) > 0 {
+		if err := s.validateSelectedActions(req.ShopID, officialIDs, shopIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	officialBytes, _ := json.Marshal(officialIDs)
+	shopBytes, _ := json.Marshal(shopIDs)
+	config.Name = name
+	config.Enabled = req.Enabled
+	config.ScheduleTime = scheduleTime
+	config.TargetDate = target.Date
+	config.OfficialActionIDs = officialBytes
+	config.ShopActionIDs = shopBytes
+	config.TargetMode = target.Mode
+	config.TargetOffsetDays = target.OffsetDays
+	config.SelectionRules = encodeSelectionRule(selectionRules)
+
+	others, err := s.autoRepo.ListConfigsByShop(req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range others {
+		if other.ID != config.ID && other.Name == name {
+			return nil, fmt.Errorf("配置名称「%s」已存在", name)
+		}
+	}
+	conflicts := detectConfigConflicts(config, others, dateOnlyValue(time.Now()))
+	for _, conflict := range conflicts {
+		if conflict.Type == autoPromotionConflictOverlap {
+			return nil, fmt.Errorf("%s，请调整上架日期或活动，或先停用其中一个配置", conflict.Message)
+		}
+	}
+
+	if config.ID == 0 {
+		err = s.autoRepo.CreateConfig(config)
+	} else {
+		err = s.autoRepo.UpdateConfig(config)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := s.autoRepo.FindConfigByIDAndShop(config.ID, req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := toAutoPromotionConfigDTO(saved)
+	if err != nil {
+		return nil, err
+	}
+	resp.Conflicts = conflicts
+	return resp, nil
+}
+
+// detectConfigConflicts 检查 config 与同店铺其他已启用配置的冲突：
+//   - schedule：执行时间相同，同一店铺同时只执行一个运行，后者顺延
+//   - overlap：加入相同活动且按 today 解析出的上架日期区间重叠（或包含相同 SKU），同一商品会被重复提交
+//
+// 只按上架日期区间、包含 SKU 与活动判断，不考虑库存、价格等其他规则条件
+func detectConfigConflicts(config *model.AutoPromotionConfig, others []model.AutoPromotionConfig, today time.Time) []dto.AutoPromotionConfigConflict {
+	if !config.Enabled {
+		return nil
+	}
+
+	rule := decodeSelectionRule(config.SelectionRules)
+	from, to := configListingDateRange(config, rule, today)
+	actionIDs := configActionIDs(config)
+
+	conflicts := make([]dto.AutoPromotionConfigConflict, 0)
+	for index := range others {
+		other := &others[index]
+		if other.ID == config.ID || !other.Enabled {
+			continue
+		}
+
+		if strings.TrimSpace(other.ScheduleTime) == strings.TrimSpace(config.ScheduleTime) {
+			conflicts = append(conflicts, dto.AutoPromotionConfigConflict{
+				ConfigID: other.ID,
+				Name:     other.Name,
+				Type:     autoPromotionConflictSchedule,
+				Message:  fmt.Sprintf("与配置「%s」执行时间同为 %s，将依次执行", other.Name, strings.TrimSpace(config.ScheduleTime)),
+			})
+		}
+
+		if !sharesAnyUint(actionIDs, configActionIDs(other)) {
+			continue
+		}
+		otherRule := decodeSelectionRule(other.SelectionRules)
+		otherFrom, otherTo := configListingDateRange(other, otherRule, today)
+		if !from.After(otherTo) && !otherFrom.After(to) {
+			overlapFrom, overlapTo := from, to
+			if otherFrom.After(overlapFrom) {
+				overlapFrom = otherFrom
+			}
+			if otherTo.Before(overlapTo) {
+				overlapTo = otherTo
+			}
+			conflicts = append(conflicts, dto.AutoPromotionConfigConflict{
+				ConfigID: other.ID,
+				Name:     other.Name,
+				Type:     autoPromotionConflictOverlap,
+				Message: fmt.Sprintf("与配置「%s」都会把 %s 至 %s 上架的商品加入相同活动",
+					other.Name, overlapFrom.Format("2006-01-02"), overlapTo.Format("2006-01-02")),
+			})
+			continue
+		}
+		if sharesAnyString(rule.IncludeSKUs, otherRule.IncludeSKUs) {
+			conflicts = append(conflicts, dto.AutoPromotionConfigConflict{
+				ConfigID: other.ID,
+				Name:     other.Name,
+				Type:     autoPromotionConflictOverlap,
+				Message:  fmt.Sprintf("与配置「%s」包含相同的 SKU 并加入相同活动", other.Name),
+			})
+		}
+	}
+	return conflicts
+}
+
+func configListingDateRange(config *model.AutoPromotionConfig, rule dto.AutoPromotionSelectionRule, today time.Time) (time.Time, time.Time) {
+	targetFrom, targetTo := configTarget(config).resolve(today)
+	return resolveListingDateRange(rule, targetFrom, targetTo, today)
+}
+
+func configActionIDs(config *model.AutoPromotionConfig) []uint {
+	return append(decodeActionIDs(config.OfficialActionIDs), decodeActionIDs(config.ShopActionIDs)...)
+}
+
+func sharesAnyUint(left, right []uint) bool {
+	set := make(map[uint]struct{}, len(left))
+	for _, value := range left {
+		set[value] = struct{}{}
+	}
+	for _, value := range right {
+		if _, exists := set[value]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+func sharesAnyString(left, right []string) bool {
+	set := stringSet(left, false)
+	for _, value := range right {
+		if _, exists := set[strings.TrimSpace(value)]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// isConfigDue 执行时间已到且未超过顺延窗口；同店铺其他运行占用时，下一轮扫描再尝试
+func isConfigDue(scheduleTime string, now time.Time) bool {
+	scheduled, err := time.ParseInLocation("15:04", strings.TrimSpace(scheduleTime), now.Location())
+	if err != nil {
+		return false
+	}
+	dueAt := time.Date(now.Year(), now.Month(), now.Day(), scheduled.Hour(), scheduled.Minute(), 0, 0, now.Location())
+	return !now.Before(dueAt) && now.Before(dueAt.Add(autoPromotionScheduleCatchUp))
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/datatypes"
+	"ozon-manager/internal/model"
+)
+
+func TestDetectConfigConflicts(t *testing.T) {
+	t.Parallel()
+
+	today := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	newListings := model.AutoPromotionConfig{
+		ID:                1,
+		Name:              "新品",
+		Enabled:           true,
+		ScheduleTime:      "09:05",
+		TargetMode:        model.AutoPromotionTargetModeDaysBefore,
+		TargetOffsetDays:  1,
+		OfficialActionIDs: datatypes.JSON(`[11]`),
+		ShopActionIDs:     datatypes.JSON(`[]`),
+	}
+	olderStock := model.AutoPromotionConfig{
+		ID:                2,
+		Name:              "老库存",
+		Enabled:           true,
+		ScheduleTime:      "18:00",
+		TargetMode:        model.AutoPromotionTargetModeRecentDays,
+		TargetOffsetDays:  30,
+		OfficialActionIDs: datatypes.JSON(`[]`),
+		ShopActionIDs:     datatypes.JSON(`[22]`),
+	}
+
+	configs := []model.AutoPromotionConfig{newListings, olderStock}
+	if conflicts := detectConfigConflicts(&configs[0], configs, today); len(conflicts) != 0 {
+		t.Fatalf("different actions and schedules should not conflict, got %+v", conflicts)
+	}
+
+	// 同一活动且上架区间重叠（昨天落在最近 30 天内）
+	configs[1].ShopActionIDs = datatypes.JSON(`[11]`)
+	conflicts := detectConfigConflicts(&configs[0], configs, today)
+	if len(conflicts) != 1 || conflicts[0].Type != autoPromotionConflictOverlap || conflicts[0].ConfigID != 2 {
+		t.Fatalf("overlap conflicts = %+v, want one overlap with config 2", conflicts)
+	}
+
+	// 停用的配置不参与冲突检查
+	configs[1].Enabled = false
+	if conflicts := detectConfigConflicts(&configs[0], configs, today); len(conflicts) != 0 {
+		t.Fatalf("disabled config should be ignored, got %+v", conflicts)
+	}
+
+	// 执行时间相同只提示
+	configs[1].Enabled = true
+	configs[1].ShopActionIDs = datatypes.JSON(`[22]`)
+	configs[1].ScheduleTime = "09:05"
+	conflicts = detectConfigConflicts(&configs[0], configs, today)
+	if len(conflicts) != 1 || conflicts[0].Type != autoPromotionConflictSchedule {
+		t.Fatalf("schedule conflicts = %+v, want one schedule conflict", conflicts)
+	}
+}
+
+func TestIsConfigDueWithinCatchUpWindow(t *testing.T) {
+	t.Parallel()
+
+	day := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 19, hour, minute, 30, 0, time.UTC)
+	}
+	tests := []struct {
+		now  time.Time
+		want bool
+	}{
+		{now: day(9, 4), want: false},
+		{now: day(9, 5), want: true},
+		{now: day(9, 40), want: true},
+		{now: day(10, 5), want: false},
+	}
+	for _, tt := range tests {
+		if got := isConfigDue("09:05", tt.now); got != tt.want {
+			t.Fatalf("isConfigDue(09:05, %s) = %v, want %v", tt.now.Format("15:04:05"), got, tt.want)
+		}
+	}
+}
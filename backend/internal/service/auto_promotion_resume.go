@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+)
+
+// persistSelectedItems 选品完成后立即落库全部明细（状态为待处理），后续每个活动执行完再逐项更新
+func (s *AutoPromotionService) persistSelectedItems(run *model.AutoPromotionRun, states map[string]*autoPromotionItemState) error {
+	keys := sortedStateKeys(states)
+	items := make([]model.AutoPromotionRunItem, 0, len(keys))
+	for _, sku := range keys {
+		items = append(items, buildRunItem(run.ID, states[sku]))
+	}
+	if err := s.autoRepo.ReplaceRunItems(run.ID, items); err != nil {
+		return err
+	}
+	for index, sku := range keys {
+		states[sku].ItemID = items[index].ID
+	}
+	return s.autoRepo.UpdateRun(run)
+}
+
+// persistItemStates 保存指定商品的当前结果
+func (s *AutoPromotionService) persistItemStates(states map[string]*autoPromotionItemState, skus []string) error {
+	items := make([]model.AutoPromotionRunItem, 0, len(skus))
+	for _, sku := range skus {
+		state := states[sku]
+		if state == nil || state.ItemID == 0 {
+			continue
+		}
+		items = append(items, buildRunItem(0, state))
+	}
+	return s.autoRepo.SaveRunItemResults(items)
+}
+
+// restoreItemStates 从已落库的明细还原执行状态。官方活动报名是同步接口，
+// 中断时可能已提交但结果未落库，先刷新已报名缓存，把这些商品识别为已在活动中
+func (s *AutoPromotionService) restoreItemStates(ctx context.Context, run *model.AutoPromotionRun, officialActions []model.PromotionAction) (map[string]*autoPromotionItemState, error) {
+	for _, action := range officialActions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		actionCopy := action
+		if err := s.promotionService.refreshOfficialActionProducts(&actionCopy); err != nil {
+			return nil, fmt.Errorf("刷新官方活动已报名商品失败: %s: %w", displayActionName(action), err)
+		}
+	}
+
+	productIDs := make([]int64, 0, len(run.RunItems))
+	sourceSKUs := make([]string, 0, len(run.RunItems))
+	for _, item := range run.RunItems {
+		productIDs = append(productIDs, item.OzonProductID)
+		sourceSKUs = append(sourceSKUs, item.SourceSKU)
+	}
+
+	localProducts, err := s.productRepo.FindByOzonProductIDs(run.ShopID, uniqueInt64s(productIDs))
+	if err != nil {
+		return nil, fmt.Errorf("查询本地商品失败: %w", err)
+	}
+	officialExisting, err := s.promotionRepo.ListActionProductsByActionIDsAndSourceSKUs(run.ShopID, actionIDsForActions(officialActions), uniqueStrings(sourceSKUs))
+	if err != nil {
+		return nil, fmt.Errorf("查询官方活动已报名缓存失败: %w", err)
+	}
+	existing := groupActionProductsByActionAndSKU(officialExisting)
+
+	states := make(map[string]*autoPromotionItemState, len(run.RunItems))
+	for _, item := range run.RunItems {
+		states[item.SourceSKU] = restoreItemState(item, localProducts[item.OzonProductID], existing)
+	}
+	return states, nil
+}
+
+func restoreItemState(item model.AutoPromotionRunItem, product model.Product, officialExisting map[uint]map[string]model.PromotionActionProduct) *autoPromotionItemState {
+	if product.ID == 0 {
+		// 本地商品已不存在时按明细快照还原
+		product = model.Product{OzonProductID: item.OzonProductID, SourceSKU: item.SourceSKU, Name: item.ProductName}
+		if item.ProductID != nil {
+			product.ID = *item.ProductID
+		}
+	}
+	listingDate := item.ListingDate
+	state := &autoPromotionItemState{
+		ItemID:  item.ID,
+		Product: product,
+		CatalogItem: model.OzonProductCatalogItem{
+			OzonProductID: item.OzonProductID,
+			Name:          item.ProductName,
+			ListingDate:   &listingDate,
+		},
+		OfficialResults: decodeActionResults(item.OfficialResults),
+		ShopResults:     decodeActionResults(item.ShopResults),
+	}
+
+	for index := range state.OfficialResults {
+		result := &state.OfficialResults[index]
+		if result.Status != model.PromotionActionCandidateStatusCandidate {
+			continue
+		}
+		if actionItems, ok := officialExisting[result.PromotionActionID]; ok {
+			if _, exists := actionItems[item.SourceSKU]; exists {
+				result.Status = model.PromotionActionCandidateStatusAlreadyActive
+			}
+		}
+	}
+
+	for _, results := range [][]dto.AutoPromotionActionResult{state.OfficialResults, state.ShopResults} {
+		for _, result := range results {
+			switch result.Status {
+			case model.AutoPromotionItemStatusFailed:
+				state.Blocked = true
+			case model.AutoPromotionItemStatusSuccess:
+				state.HasExecutedStep = true
+			}
+		}
+	}
+	return state
+}
+
+// buildRunItem 由执行状态生成明细记录，仍有待处理的活动时整体状态为 pending
+func buildRunItem(runID uint, state *autoPromotionItemState) model.AutoPromotionRunItem {
+	overallStatus, officialStatus, shopStatus := summarizeRunItemStatuses(state)
+	officialBytes, _ := json.Marshal(state.OfficialResults)
+	shopBytes, _ := json.Marshal(state.ShopResults)
+
+	item := model.AutoPromotionRunItem{
+		ID:              state.ItemID,
+		RunID:           runID,
+		ProductID:       &state.Product.ID,
+		OzonProductID:   state.Product.OzonProductID,
+		SourceSKU:       state.Product.SourceSKU,
+		ProductName:     firstNonEmpty(strings.TrimSpace(state.Product.Name), strings.TrimSpace(state.CatalogItem.Name), state.Product.SourceSKU),
+		OverallStatus:   overallStatus,
+		OfficialStatus:  officialStatus,
+		ShopStatus:      shopStatus,
+		OfficialResults: officialBytes,
+		ShopResults:     shopBytes,
+	}
+	if state.CatalogItem.ListingDate != nil {
+		item.ListingDate = dateOnlyValue(*state.CatalogItem.ListingDate)
+	}
+	return item
+}
+
+func summarizeRunItemStatuses(state *autoPromotionItemState) (string, string, string) {
+	officialPending := hasPendingActionResults(state.OfficialResults)
+	shopPending := hasPendingActionResults(state.ShopResults)
+	if !officialPending && !shopPending {
+		return summarizeItemStatuses(state)
+	}
+
+	officialStatus := model.AutoPromotionItemStatusPending
+	if !officialPending {
+		officialStatus = summarizeActionResults(state.OfficialResults)
+	}
+	shopStatus := model.AutoPromotionItemStatusPending
+	if !shopPending {
+		shopStatus = summarizeActionResults(state.ShopResults)
+	}
+	if officialStatus == model.AutoPromotionItemStatusFailed || shopStatus == model.AutoPromotionItemStatusFailed {
+		return model.AutoPromotionItemStatusFailed, officialStatus, shopStatus
+	}
+	return model.AutoPromotionItemStatusPending, officialStatus, shopStatus
+}
+
+func hasPendingActionResults(results []dto.AutoPromotionActionResult) bool {
+	for _, result := range results {
+		if result.Status == model.PromotionActionCandidateStatusCandidate {
+			return true
+		}
+	}
+	return false
+}
+
+// resetActionResultsForRetry 失败与因前置失败而跳过的结果重新置为待加入，返回是否有改动
+func resetActionResultsForRetry(results []dto.AutoPromotionActionResult) bool {
+	changed := false
+	for index := range results {
+		result := &results[index]
+		if result.Status != model.AutoPromotionItemStatusFailed && result.Status != model.AutoPromotionItemStatusSkipped {
+			continue
+		}
+		result.Status = model.PromotionActionCandidateStatusCandidate
+		result.Error = ""
+		result.JobID = 0
+		changed = true
+	}
+	return changed
+}
+
+// RetryFailedItems 只重试运行中失败或未完成的商品，已成功的商品不再提交
+func (s *AutoPromotionService) RetryFailedItems(userID, shopID, runID uint) (*dto.AutoPromotionRunSummaryResponse, error) {
+	run, err := s.autoRepo.FindRunByIDAndShop(runID, shopID)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != model.AutoPromotionRunStatusFailed && run.Status != model.AutoPromotionRunStatusPartialSuccess {
+		return nil, fmt.Errorf("运行当前状态不支持重试")
+	}
+	if err := s.shopGuard.CheckScheduledRun(shopID); err != nil {
+		return nil, err
+	}
+	if activeRun, err := s.autoRepo.FindActiveRunByShop(shopID); err == nil && activeRun != nil {
+		return nil, fmt.Errorf("已有自动加促销任务正在执行中")
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	items := make([]model.AutoPromotionRunItem, 0)
+	for _, item := range run.RunItems {
+		if item.OverallStatus != model.AutoPromotionItemStatusFailed && item.OverallStatus != model.AutoPromotionItemStatusPending {
+			continue
+		}
+		state := restoreItemState(item, model.Product{}, nil)
+		resetActionResultsForRetry(state.OfficialResults)
+		resetActionResultsForRetry(state.ShopResults)
+		state.Blocked = false
+		retried := buildRunItem(run.ID, state)
+		// 重置后的明细只更新状态与结果，商品信息保持原样
+		item.OverallStatus = retried.OverallStatus
+		item.OfficialStatus = retried.OfficialStatus
+		item.ShopStatus = retried.ShopStatus
+		item.OfficialResults = retried.OfficialResults
+		item.ShopResults = retried.ShopResults
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("没有失败或未完成的商品可重试")
+	}
+
+	reset, err := s.autoRepo.ResetRunForRetry(run.ID, items)
+	if err != nil {
+		return nil, err
+	}
+	if !reset {
+		return nil, fmt.Errorf("运行状态已变化，请刷新后重试")
+	}
+
+	input := runInputFromSnapshot(run, &userID)
+
+	run.Status = model.AutoPromotionRunStatusPending
+	run.ErrorMessage = ""
+	run.CompletedAt = nil
+	run.RunItems = nil
+	if err := s.dispatchRun(run, input); err != nil {
+		return nil, err
+	}
+	return toAutoPromotionRunSummaryDTO(run), nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+)
+
+func mustActionResults(t *testing.T, results ...dto.AutoPromotionActionResult) []byte {
+	t.Helper()
+	raw, err := json.Marshal(results)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return raw
+}
+
+func TestRestoreItemStateMarksSubmittedOfficialActionsAndBlocksFailedItems(t *testing.T) {
+	t.Parallel()
+
+	productID := uint(7)
+	item := model.AutoPromotionRunItem{
+		ID:            3,
+		ProductID:     &productID,
+		OzonProductID: 101,
+		SourceSKU:     "SKU-101",
+		ProductName:   "A",
+		ListingDate:   time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+		OfficialResults: mustActionResults(t,
+			dto.AutoPromotionActionResult{PromotionActionID: 11, Status: model.PromotionActionCandidateStatusCandidate},
+			dto.AutoPromotionActionResult{PromotionActionID: 12, Status: model.PromotionActionCandidateStatusCandidate},
+		),
+		ShopResults: mustActionResults(t,
+			dto.AutoPromotionActionResult{PromotionActionID: 22, Status: model.AutoPromotionItemStatusFailed, JobID: 9},
+		),
+	}
+	existing := map[uint]map[string]model.PromotionActionProduct{
+		11: {"SKU-101": {PromotionActionID: 11, SourceSKU: "SKU-101"}},
+	}
+
+	state := restoreItemState(item, model.Product{}, existing)
+	if state.ItemID != 3 || state.Product.ID != 7 || state.Product.SourceSKU != "SKU-101" {
+		t.Fatalf("restored item = %+v, want product snapshot from run item", state)
+	}
+	if got := state.OfficialResults[0].Status; got != model.PromotionActionCandidateStatusAlreadyActive {
+		t.Fatalf("submitted official result status = %s, want already_active", got)
+	}
+	if got := state.OfficialResults[1].Status; got != model.PromotionActionCandidateStatusCandidate {
+		t.Fatalf("pending official result status = %s, want candidate", got)
+	}
+	if !state.Blocked {
+		t.Fatalf("item with a failed result should stay blocked on resume")
+	}
+	if state.ShopResults[0].JobID != 9 {
+		t.Fatalf("shop result job id = %d, want 9", state.ShopResults[0].JobID)
+	}
+}
+
+func TestSummarizeRunItemStatusesKeepsPendingUntilAllActionsFinish(t *testing.T) {
+	t.Parallel()
+
+	state := &autoPromotionItemState{
+		OfficialResults: []dto.AutoPromotionActionResult{{PromotionActionID: 11, Status: model.AutoPromotionItemStatusSuccess}},
+		ShopResults:     []dto.AutoPromotionActionResult{{PromotionActionID: 22, Status: model.PromotionActionCandidateStatusCandidate}},
+	}
+	overall, official, shop := summarizeRunItemStatuses(state)
+	if overall != model.AutoPromotionItemStatusPending || official != model.AutoPromotionItemStatusSuccess || shop != model.AutoPromotionItemStatusPending {
+		t.Fatalf("in progress statuses = %s/%s/%s", overall, official, shop)
+	}
+
+	state.ShopResults[0].Status = model.AutoPromotionItemStatusSuccess
+	if overall, _, _ := summarizeRunItemStatuses(state); overall != model.AutoPromotionItemStatusSuccess {
+		t.Fatalf("finished overall status = %s, want success", overall)
+	}
+}
+
+func TestResetActionResultsForRetryOnlyResetsUnfinishedResults(t *testing.T) {
+	t.Parallel()
+
+	results := []dto.AutoPromotionActionResult{
+		{PromotionActionID: 11, Status: model.AutoPromotionItemStatusSuccess},
+		{PromotionActionID: 12, Status: model.AutoPromotionItemStatusFailed, Error: "店铺活动执行超时", JobID: 5},
+		{PromotionActionID: 13, Status: model.AutoPromotionItemStatusSkipped, Error: "前置活动失败，已跳过"},
+		{PromotionActionID: 14, Status: model.PromotionActionCandidateStatusAlreadyActive},
+	}
+	if !resetActionResultsForRetry(results) {
+		t.Fatalf("resetActionResultsForRetry() = false, want true")
+	}
+
+	want := []string{
+		model.AutoPromotionItemStatusSuccess,
+		model.PromotionActionCandidateStatusCandidate,
+		model.PromotionActionCandidateStatusCandidate,
+		model.PromotionActionCandidateStatusAlreadyActive,
+	}
+	for index, result := range results {
+		if result.Status != want[index] {
+			t.Fatalf("result %d status = %s, want %s", index, result.Status, want[index])
+		}
+	}
+	if results[1].Error != "" || results[1].JobID != 0 {
+		t.Fatalf("failed result was not cleared: %+v", results[1])
+	}
+}
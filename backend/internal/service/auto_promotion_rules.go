@@ -0,0 +1,553 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+)
+
+const (
+	autoPromotionCandidateMatchAll = "all"
+	autoPromotionCandidateMatchAny = "any"
+)
+
+// autoPromotionSelectionSource 选品所需的数据，均已按店铺与所选活动查询好
+type autoPromotionSelectionSource struct {
+	// ListingDateFrom / ListingDateTo 上架日期区间（含首尾），为零值时不按日期过滤
+	ListingDateFrom time.Time
+	ListingDateTo   time.Time
+	// ExcludeEnrolled 为 true 时已加入全部所选活动的商品不再选中
+	ExcludeEnrolled    bool
+	CatalogItems       []model.OzonProductCatalogItem
+	LocalProducts      map[int64]model.Product
+	OfficialActions    []model.PromotionAction
+	ShopActions        []model.PromotionAction
+	OfficialCandidates []model.PromotionActionCandidate
+	ShopCandidates     []model.PromotionActionCandidate
+	OfficialExisting   []model.PromotionActionProduct
+}
+
+// autoPromotionSelection 选品结果：选中的商品与其余商品的排除原因
+type autoPromotionSelection struct {
+	States          map[string]*autoPromotionItemState
+	Excluded        []dto.AutoPromotionPreviewItem
+	TotalScanned    int
+	TotalCandidates int
+	ListingDateFrom time.Time
+	ListingDateTo   time.Time
+}
+
+// normalizeSelectionRule 校验并规范化选品规则，rule 为空时返回默认规则
+func normalizeSelectionRule(rule *dto.AutoPromotionSelectionRule) (dto.AutoPromotionSelectionRule, error) {
+	if rule == nil {
+		return dto.AutoPromotionSelectionRule{CandidateMatch: autoPromotionCandidateMatchAll}, nil
+	}
+	normalized := *rule
+
+	normalized.ListingDateFrom = strings.TrimSpace(normalized.ListingDateFrom)
+	normalized.ListingDateTo = strings.TrimSpace(normalized.ListingDateTo)
+	from, err := parseDateOnly(normalized.ListingDateFrom)
+	if err != nil {
+		return normalized, fmt.Errorf("invalid listing_date_from, expected YYYY-MM-DD")
+	}
+	to, err := parseDateOnly(normalized.ListingDateTo)
+	if err != nil {
+		return normalized, fmt.Errorf("invalid listing_date_to, expected YYYY-MM-DD")
+	}
+	if to != nil && from == nil {
+		return normalized, fmt.Errorf("设置上架结束日期时必须同时设置开始日期")
+	}
+	if from != nil && to != nil && from.After(*to) {
+		return normalized, fmt.Errorf("上架开始日期不能晚于结束日期")
+	}
+
+	for _, bound := range []struct {
+		name     string
+		min, max *int
+	}{
+		{"FBO 库存", normalized.MinStockFBO, normalized.MaxStockFBO},
+		{"FBS 库存", normalized.MinStockFBS, normalized.MaxStockFBS},
+	} {
+		if (bound.min != nil && *bound.min < 0) || (bound.max != nil && *bound.max < 0) {
+			return normalized, fmt.Errorf("%s条件不能为负数", bound.name)
+		}
+		if bound.min != nil && bound.max != nil && *bound.min > *bound.max {
+			return normalized, fmt.Errorf("%s下限不能大于上限", bound.name)
+		}
+	}
+	for _, bound := range []struct {
+		name     string
+		min, max *float64
+	}{
+		{"价格", normalized.MinPrice, normalized.MaxPrice},
+		{"折扣", normalized.MinDiscountPercent, normalized.MaxDiscountPercent},
+	} {
+		if (bound.min != nil && *bound.min < 0) || (bound.max != nil && *bound.max < 0) {
+			return normalized, fmt.Errorf("%s条件不能为负数", bound.name)
+		}
+		if bound.min != nil && bound.max != nil && *bound.min > *bound.max {
+			return normalized, fmt.Errorf("%s下限不能大于上限", bound.name)
+		}
+	}
+
+	normalized.CategoryIDs = uniqueInt64s(normalized.CategoryIDs)
+	normalized.TypeIDs = uniqueInt64s(normalized.TypeIDs)
+	normalized.Visibilities = uniqueStrings(upperStrings(normalized.Visibilities))
+	normalized.Tags = uniqueStrings(normalized.Tags)
+	normalized.ExcludeTags = uniqueStrings(normalized.ExcludeTags)
+	normalized.IncludeSKUs = uniqueStrings(normalized.IncludeSKUs)
+	normalized.ExcludeSKUs = uniqueStrings(normalized.ExcludeSKUs)
+
+	switch strings.ToLower(strings.TrimSpace(normalized.CandidateMatch)) {
+	case "", autoPromotionCandidateMatchAll:
+		normalized.CandidateMatch = autoPromotionCandidateMatchAll
+	case autoPromotionCandidateMatchAny:
+		normalized.CandidateMatch = autoPromotionCandidateMatchAny
+	default:
+		return normalized, fmt.Errorf("invalid candidate_match, expected all or any")
+	}
+	return normalized, nil
+}
+
+func encodeSelectionRule(rule dto.AutoPromotionSelectionRule) datatypes.JSON {
+	raw, _ := json.Marshal(rule)
+	return raw
+}
+
+func decodeSelectionRule(raw datatypes.JSON) dto.AutoPromotionSelectionRule {
+	rule := dto.AutoPromotionSelectionRule{}
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &rule)
+	}
+	if normalized, err := normalizeSelectionRule(&rule); err == nil {
+		return normalized
+	}
+	return rule
+}
+
+// resolveListingDateRange 规则未设置上架日期时使用目标日期解析出的区间；只设置开始日期时截止到今天
+func resolveListingDateRange(rule dto.AutoPromotionSelectionRule, targetFrom, targetTo time.Time, now time.Time) (time.Time, time.Time) {
+	from, _ := parseDateOnly(rule.ListingDateFrom)
+	if from == nil {
+		return dateOnlyValue(targetFrom), dateOnlyValue(targetTo)
+	}
+	to, _ := parseDateOnly(rule.ListingDateTo)
+	if to == nil {
+		today := dateOnlyValue(now)
+		return *from, time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, from.Location())
+	}
+	return *from, *to
+}
+
+// selectByRule 按规则查询候选范围并选品，只读本地缓存（目录、候选、已报名），不调用 Ozon
+func (s *AutoPromotionService) selectByRule(
+	shopID uint,
+	from time.Time,
+	to time.Time,
+	rule dto.AutoPromotionSelectionRule,
+	excludeEnrolled bool,
+	officialActions []model.PromotionAction,
+	shopActions []model.PromotionAction,
+) (*autoPromotionSelection, error) {
+	catalogItems, err := s.ozonCatalogRepo.ListByListingDateRange(shopID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("按日期查询目录商品失败: %w", err)
+	}
+
+	excluded := make([]dto.AutoPromotionPreviewItem, 0)
+	if len(rule.IncludeSKUs) > 0 {
+		includedProducts, err := s.productRepo.FindBySourceSKUs(shopID, rule.IncludeSKUs)
+		if err != nil {
+			return nil, fmt.Errorf("查询包含列表商品失败: %w", err)
+		}
+		productIDs := make([]int64, 0, len(includedProducts))
+		for _, product := range includedProducts {
+			productIDs = append(productIDs, product.OzonProductID)
+		}
+		includedCatalog, err := s.ozonCatalogRepo.FindExistingByProductIDs(shopID, productIDs)
+		if err != nil {
+			return nil, fmt.Errorf("查询包含列表目录商品失败: %w", err)
+		}
+
+		inScope := make(map[int64]struct{}, len(catalogItems))
+		for _, item := range catalogItems {
+			inScope[item.OzonProductID] = struct{}{}
+		}
+		for _, sku := range rule.IncludeSKUs {
+			product, exists := includedProducts[sku]
+			if !exists {
+				excluded = append(excluded, dto.AutoPromotionPreviewItem{SourceSKU: sku, Reason: "包含列表中的 SKU 在本地商品库中不存在"})
+				continue
+			}
+			item, exists := includedCatalog[product.OzonProductID]
+			if !exists {
+				excluded = append(excluded, dto.AutoPromotionPreviewItem{
+					OzonProductID: product.OzonProductID,
+					SourceSKU:     sku,
+					ProductName:   product.Name,
+					Reason:        "包含列表中的 SKU 不在 Ozon 商品目录中，请先刷新目录",
+				})
+				continue
+			}
+			if _, exists := inScope[item.OzonProductID]; !exists {
+				inScope[item.OzonProductID] = struct{}{}
+				catalogItems = append(catalogItems, item)
+			}
+		}
+	}
+
+	localProducts, err := s.productRepo.FindByOzonProductIDs(shopID, collectCatalogProductIDs(catalogItems))
+	if err != nil {
+		return nil, fmt.Errorf("查询本地商品失败: %w", err)
+	}
+
+	sourceSKUs := make([]string, 0, len(localProducts))
+	for _, item := range catalogItems {
+		if product, exists := localProducts[item.OzonProductID]; exists {
+			sourceSKUs = append(sourceSKUs, product.SourceSKU)
+		}
+	}
+	sourceSKUs = uniqueStrings(sourceSKUs)
+
+	officialActionIDs := actionIDsForActions(officialActions)
+	shopActionIDs := actionIDsForActions(shopActions)
+	officialCandidates, err := s.promotionRepo.ListActionCandidatesByActionIDsAndSourceSKUs(shopID, officialActionIDs, sourceSKUs)
+	if err != nil {
+		return nil, fmt.Errorf("查询官方活动候选缓存失败: %w", err)
+	}
+	shopCandidates, err := s.promotionRepo.ListActionCandidatesByActionIDsAndSourceSKUs(shopID, shopActionIDs, sourceSKUs)
+	if err != nil {
+		return nil, fmt.Errorf("查询店铺活动候选缓存失败: %w", err)
+	}
+	officialExisting, err := s.promotionRepo.ListActionProductsByActionIDsAndSourceSKUs(shopID, officialActionIDs, sourceSKUs)
+	if err != nil {
+		return nil, fmt.Errorf("查询官方活动已报名缓存失败: %w", err)
+	}
+
+	selection := evaluateSelectionRule(rule, autoPromotionSelectionSource{
+		ListingDateFrom:    from,
+		ListingDateTo:      to,
+		ExcludeEnrolled:    excludeEnrolled,
+		CatalogItems:       catalogItems,
+		LocalProducts:      localProducts,
+		OfficialActions:    officialActions,
+		ShopActions:        shopActions,
+		OfficialCandidates: officialCandidates,
+		ShopCandidates:     shopCandidates,
+		OfficialExisting:   officialExisting,
+	})
+	selection.Excluded = append(excluded, selection.Excluded...)
+	return selection, nil
+}
+
+// PreviewSelection 按规则预览选品结果，只读本地缓存不刷新目录与候选，结果可能与实际运行略有差异
+func (s *AutoPromotionService) PreviewSelection(req *dto.AutoPromotionRunRequest) (*dto.AutoPromotionPreviewResponse, error) {
+	target, err := normalizeAutoPromotionTarget(req.TargetMode, req.TargetOffsetDays, req.TargetDate)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := normalizeSelectionRule(req.SelectionRules)
+	if err != nil {
+		return nil, err
+	}
+
+	officialIDs := uniqueUints(req.OfficialActionIDs)
+	shopIDs := uniqueUints(req.ShopActionIDs)
+	if len(officialIDs)+len(shopIDs) == 0 {
+		return nil, fmt.Errorf("请至少选择一个促销活动")
+	}
+	actions, err := s.resolveActions(req.ShopID, officialIDs, shopIDs)
+	if err != nil {
+		return nil, err
+	}
+	officialActions, shopActions := splitActionsBySource(actions)
+
+	now := time.Now()
+	targetFrom, targetTo := target.resolve(now)
+	from, to := resolveListingDateRange(rule, targetFrom, targetTo, now)
+	selection, err := s.selectByRule(req.ShopID, from, to, rule, target.excludeEnrolled(), officialActions, shopActions)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]dto.AutoPromotionPreviewItem, 0, len(selection.States))
+	for _, sku := range sortedStateKeys(selection.States) {
+		state := selection.States[sku]
+		item := dto.AutoPromotionPreviewItem{
+			OzonProductID:   state.Product.OzonProductID,
+			SourceSKU:       state.Product.SourceSKU,
+			ProductName:     firstNonEmpty(strings.TrimSpace(state.Product.Name), strings.TrimSpace(state.CatalogItem.Name)),
+			OfficialResults: state.OfficialResults,
+			ShopResults:     state.ShopResults,
+		}
+		if state.CatalogItem.ListingDate != nil {
+			item.ListingDate = state.CatalogItem.ListingDate.Format("2006-01-02")
+		}
+		matched = append(matched, item)
+	}
+
+	return &dto.AutoPromotionPreviewResponse{
+		ListingDateFrom: selection.ListingDateFrom.Format("2006-01-02"),
+		ListingDateTo:   selection.ListingDateTo.Format("2006-01-02"),
+		TotalScanned:    selection.TotalScanned,
+		Matched:         matched,
+		Excluded:        selection.Excluded,
+	}, nil
+}
+
+// evaluateSelectionRule 按规则逐个判断目录商品，返回选中的商品及其余商品的首个排除原因
+func evaluateSelectionRule(rule dto.AutoPromotionSelectionRule, source autoPromotionSelectionSource) *autoPromotionSelection {
+	officialCandidateMap := groupCandidatesByActionAndSKU(source.OfficialCandidates)
+	shopCandidateMap := groupCandidatesByActionAndSKU(source.ShopCandidates)
+	officialExistingMap := groupActionProductsByActionAndSKU(source.OfficialExisting)
+	includeSKUs := stringSet(rule.IncludeSKUs, false)
+	excludeSKUs := stringSet(rule.ExcludeSKUs, false)
+
+	selection := &autoPromotionSelection{
+		States:          make(map[string]*autoPromotionItemState),
+		Excluded:        make([]dto.AutoPromotionPreviewItem, 0),
+		TotalScanned:    len(source.CatalogItems),
+		ListingDateFrom: source.ListingDateFrom,
+		ListingDateTo:   source.ListingDateTo,
+	}
+	exclude := func(item model.OzonProductCatalogItem, product model.Product, reason string) {
+		preview := dto.AutoPromotionPreviewItem{
+			OzonProductID: item.OzonProductID,
+			SourceSKU:     firstNonEmpty(product.SourceSKU, item.OfferID),
+			ProductName:   firstNonEmpty(strings.TrimSpace(product.Name), strings.TrimSpace(item.Name)),
+			Reason:        reason,
+		}
+		if item.ListingDate != nil {
+			preview.ListingDate = item.ListingDate.Format("2006-01-02")
+		}
+		selection.Excluded = append(selection.Excluded, preview)
+	}
+
+	for _, catalogItem := range source.CatalogItems {
+		product, exists := source.LocalProducts[catalogItem.OzonProductID]
+		if !exists {
+			exclude(catalogItem, product, "本地商品库中没有该商品，请先同步商品")
+			continue
+		}
+		selection.TotalCandidates++
+		sku := strings.TrimSpace(product.SourceSKU)
+		if sku == "" || catalogItem.ListingDate == nil {
+			exclude(catalogItem, product, "缺少 SKU 或上架日期")
+			continue
+		}
+		if _, exists := excludeSKUs[sku]; exists {
+			exclude(catalogItem, product, "在排除 SKU 列表中")
+			continue
+		}
+		_, included := includeSKUs[sku]
+		if reason := checkCatalogItemRule(rule, source, catalogItem, product, included); reason != "" {
+			exclude(catalogItem, product, reason)
+			continue
+		}
+
+		state := &autoPromotionItemState{
+			Product:     product,
+			CatalogItem: catalogItem,
+		}
+		misses := make([]string, 0)
+		for _, action := range source.OfficialActions {
+			if actionItems, ok := officialExistingMap[action.ID]; ok {
+				if _, exists := actionItems[sku]; exists {
+					state.OfficialResults = append(state.OfficialResults, dto.AutoPromotionActionResult{
+						PromotionActionID: action.ID,
+						ActionID:          action.ActionID,
+						Title:             displayActionName(action),
+						Source:            action.Source,
+						Status:            model.PromotionActionCandidateStatusAlreadyActive,
+					})
+					continue
+				}
+			}
+
+			candidate, ok := officialCandidateMap[action.ID][sku]
+			if !ok {
+				misses = append(misses, fmt.Sprintf("不是活动「%s」的候选商品", displayActionName(action)))
+				continue
+			}
+			if reason := checkDiscountRule(rule, candidate.DiscountPercent); reason != "" {
+				misses = append(misses, fmt.Sprintf("活动「%s」%s", displayActionName(action), reason))
+				continue
+			}
+			state.OfficialResults = append(state.OfficialResults, dto.AutoPromotionActionResult{
+				PromotionActionID: action.ID,
+				ActionID:          action.ActionID,
+				Title:             displayActionName(action),
+				Source:            action.Source,
+				Status:            model.PromotionActionCandidateStatusCandidate,
+				ActionPrice:       candidate.ActionPrice,
+				MaxActionPrice:    candidate.MaxActionPrice,
+			})
+		}
+
+		for _, action := range source.ShopActions {
+			candidate, ok := shopCandidateMap[action.ID][sku]
+			if !ok {
+				misses = append(misses, fmt.Sprintf("不是活动「%s」的候选商品", displayActionName(action)))
+				continue
+			}
+
+			resultStatus := model.PromotionActionCandidateStatusCandidate
+			if candidate.Status == model.PromotionActionCandidateStatusActive {
+				resultStatus = model.PromotionActionCandidateStatusAlreadyActive
+			} else if reason := checkDiscountRule(rule, candidate.DiscountPercent); reason != "" {
+				misses = append(misses, fmt.Sprintf("活动「%s」%s", displayActionName(action), reason))
+				continue
+			}
+			state.ShopResults = append(state.ShopResults, dto.AutoPromotionActionResult{
+				PromotionActionID: action.ID,
+				SourceActionID:    action.SourceActionID,
+				Title:             displayActionName(action),
+				Source:            action.Source,
+				Status:            resultStatus,
+				ActionPrice:       candidate.ActionPrice,
+				MaxActionPrice:    candidate.MaxActionPrice,
+			})
+		}
+
+		if rule.CandidateMatch == autoPromotionCandidateMatchAny {
+			if len(state.OfficialResults)+len(state.ShopResults) == 0 {
+				exclude(catalogItem, product, "不是任一所选活动的候选商品: "+strings.Join(misses, "；"))
+				continue
+			}
+		} else if len(misses) > 0 {
+			exclude(catalogItem, product, misses[0])
+			continue
+		}
+		if source.ExcludeEnrolled && !hasPendingActionResults(state.OfficialResults) && !hasPendingActionResults(state.ShopResults) {
+			exclude(catalogItem, product, "已加入全部所选活动")
+			continue
+		}
+
+		selection.States[sku] = state
+	}
+
+	return selection
+}
+
+// checkCatalogItemRule 检查目录维度的条件，返回首个不满足的原因；included 为包含列表中的 SKU，不受上架日期限制
+func checkCatalogItemRule(rule dto.AutoPromotionSelectionRule, source autoPromotionSelectionSource, item model.OzonProductCatalogItem, product model.Product, included bool) string {
+	if !included && !source.ListingDateFrom.IsZero() {
+		listingDay := dateOnlyValue(*item.ListingDate)
+		from := dateOnlyValue(source.ListingDateFrom)
+		to := dateOnlyValue(source.ListingDateTo)
+		if listingDay.Before(time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, listingDay.Location())) ||
+			listingDay.After(time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, listingDay.Location())) {
+			return fmt.Sprintf("上架日期 %s 不在 %s 至 %s 之间", listingDay.Format("2006-01-02"), from.Format("2006-01-02"), to.Format("2006-01-02"))
+		}
+	}
+	if len(rule.CategoryIDs) > 0 && !containsInt64(rule.CategoryIDs, item.CategoryID) {
+		return fmt.Sprintf("类目 %d 不在规则范围内", item.CategoryID)
+	}
+	if len(rule.TypeIDs) > 0 && !containsInt64(rule.TypeIDs, item.TypeID) {
+		return fmt.Sprintf("类型 %d 不在规则范围内", item.TypeID)
+	}
+	if rule.MinStockFBO != nil && item.StockFBO < *rule.MinStockFBO {
+		return fmt.Sprintf("FBO 库存 %d 低于 %d", item.StockFBO, *rule.MinStockFBO)
+	}
+	if rule.MaxStockFBO != nil && item.StockFBO > *rule.MaxStockFBO {
+		return fmt.Sprintf("FBO 库存 %d 高于 %d", item.StockFBO, *rule.MaxStockFBO)
+	}
+	if rule.MinStockFBS != nil && item.StockFBS < *rule.MinStockFBS {
+		return fmt.Sprintf("FBS 库存 %d 低于 %d", item.StockFBS, *rule.MinStockFBS)
+	}
+	if rule.MaxStockFBS != nil && item.StockFBS > *rule.MaxStockFBS {
+		return fmt.Sprintf("FBS 库存 %d 高于 %d", item.StockFBS, *rule.MaxStockFBS)
+	}
+	price := item.Price
+	if price <= 0 {
+		price = product.CurrentPrice
+	}
+	if rule.MinPrice != nil && price < *rule.MinPrice {
+		return fmt.Sprintf("售价 %.2f 低于 %.2f", price, *rule.MinPrice)
+	}
+	if rule.MaxPrice != nil && price > *rule.MaxPrice {
+		return fmt.Sprintf("售价 %.2f 高于 %.2f", price, *rule.MaxPrice)
+	}
+	if len(rule.Visibilities) > 0 && !containsString(rule.Visibilities, strings.ToUpper(strings.TrimSpace(item.Visibility))) {
+		return fmt.Sprintf("可见性 %s 不符合规则", firstNonEmpty(item.Visibility, "-"))
+	}
+
+	if len(rule.Tags) > 0 || len(rule.ExcludeTags) > 0 {
+		tags := stringSet(decodeProductTags(product.Tags), true)
+		if len(rule.Tags) > 0 && !hasAnyString(tags, rule.Tags) {
+			return "没有规则要求的标签: " + strings.Join(rule.Tags, ", ")
+		}
+		for _, tag := range rule.ExcludeTags {
+			if _, exists := tags[strings.ToLower(tag)]; exists {
+				return "带有排除标签: " + tag
+			}
+		}
+	}
+	return ""
+}
+
+func checkDiscountRule(rule dto.AutoPromotionSelectionRule, discountPercent float64) string {
+	if rule.MinDiscountPercent != nil && discountPercent < *rule.MinDiscountPercent {
+		return fmt.Sprintf("折扣 %.1f%% 低于 %.1f%%", discountPercent, *rule.MinDiscountPercent)
+	}
+	if rule.MaxDiscountPercent != nil && discountPercent > *rule.MaxDiscountPercent {
+		return fmt.Sprintf("折扣 %.1f%% 高于 %.1f%%", discountPercent, *rule.MaxDiscountPercent)
+	}
+	return ""
+}
+
+func decodeProductTags(raw datatypes.JSON) []string {
+	if len(raw) == 0 {
+		return []string{}
+	}
+	tags := make([]string, 0)
+	_ = json.Unmarshal(raw, &tags)
+	return uniqueStrings(tags)
+}
+
+// stringSet 构建字符串集合，foldCase 时按小写比较
+func stringSet(values []string, foldCase bool) map[string]struct{} {
+	result := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		trimmed := strings.TrimSpace(value)
+		if trimmed == "" {
+			continue
+		}
+		if foldCase {
+			trimmed = strings.ToLower(trimmed)
+		}
+		result[trimmed] = struct{}{}
+	}
+	return result
+}
+
+func hasAnyString(set map[string]struct{}, values []string) bool {
+	for _, value := range values {
+		if _, exists := set[strings.ToLower(strings.TrimSpace(value))]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+func upperStrings(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		result = append(result, strings.ToUpper(value))
+	}
+	return result
+}
+
+func containsInt64(values []int64, target int64) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
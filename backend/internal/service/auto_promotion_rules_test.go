@@ -0,0 +1,138 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/datatypes"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+)
+
+func intPtr(value int) *int {
+	return &value
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+func newRuleTestSource() autoPromotionSelectionSource {
+	inRange := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	outOfRange := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	return autoPromotionSelectionSource{
+		ListingDateFrom: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		ListingDateTo:   time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+		CatalogItems: []model.OzonProductCatalogItem{
+			{OzonProductID: 101, ListingDate: &inRange, StockFBO: 10, Price: 500, Visibility: "VISIBLE"},
+			{OzonProductID: 202, ListingDate: &inRange, StockFBO: 0, Price: 500, Visibility: "VISIBLE"},
+			{OzonProductID: 303, ListingDate: &outOfRange, StockFBO: 10, Price: 500, Visibility: "VISIBLE"},
+			{OzonProductID: 404, ListingDate: &inRange, StockFBO: 10, Price: 500, Visibility: "VISIBLE"},
+		},
+		LocalProducts: map[int64]model.Product{
+			101: {ID: 1, OzonProductID: 101, SourceSKU: "SKU-101", Tags: datatypes.JSON(`["Summer"]`)},
+			202: {ID: 2, OzonProductID: 202, SourceSKU: "SKU-202", Tags: datatypes.JSON(`["summer"]`)},
+			303: {ID: 3, OzonProductID: 303, SourceSKU: "SKU-303", Tags: datatypes.JSON(`["summer"]`)},
+			404: {ID: 4, OzonProductID: 404, SourceSKU: "SKU-404", Tags: datatypes.JSON(`["summer","clearance"]`)},
+		},
+		OfficialActions: []model.PromotionAction{{ID: 11, ActionID: 9001, Source: "official", Title: "弹性"}},
+		ShopActions:     []model.PromotionAction{{ID: 22, Source: "shop", SourceActionID: "shop-28", Title: "28"}},
+		OfficialCandidates: []model.PromotionActionCandidate{
+			{PromotionActionID: 11, SourceSKU: "SKU-101", DiscountPercent: 20},
+			{PromotionActionID: 11, SourceSKU: "SKU-202", DiscountPercent: 20},
+			{PromotionActionID: 11, SourceSKU: "SKU-303", DiscountPercent: 20},
+			{PromotionActionID: 11, SourceSKU: "SKU-404", DiscountPercent: 20},
+		},
+	}
+}
+
+func TestEvaluateSelectionRuleReportsExclusionReasons(t *testing.T) {
+	t.Parallel()
+
+	rule := dto.AutoPromotionSelectionRule{
+		MinStockFBO:    intPtr(1),
+		Tags:           []string{"summer"},
+		ExcludeTags:    []string{"Clearance"},
+		CandidateMatch: autoPromotionCandidateMatchAny,
+	}
+	selection := evaluateSelectionRule(rule, newRuleTestSource())
+
+	if len(selection.States) != 1 || selection.States["SKU-101"] == nil {
+		t.Fatalf("selected = %v, want only SKU-101", sortedStateKeys(selection.States))
+	}
+	if got := selection.States["SKU-101"]; len(got.OfficialResults) != 1 || len(got.ShopResults) != 0 {
+		t.Fatalf("any mode should only record eligible actions, got %+v", got)
+	}
+
+	wantReasons := map[string]string{
+		"SKU-202": "FBO 库存",
+		"SKU-303": "上架日期",
+		"SKU-404": "排除标签",
+	}
+	if len(selection.Excluded) != len(wantReasons) {
+		t.Fatalf("excluded = %+v, want %d items", selection.Excluded, len(wantReasons))
+	}
+	for _, item := range selection.Excluded {
+		if want := wantReasons[item.SourceSKU]; want == "" || !strings.Contains(item.Reason, want) {
+			t.Fatalf("excluded %s reason = %q, want it to mention %q", item.SourceSKU, item.Reason, want)
+		}
+	}
+}
+
+func TestEvaluateSelectionRuleCandidateMatchAndDiscount(t *testing.T) {
+	t.Parallel()
+
+	source := newRuleTestSource()
+	source.ShopCandidates = []model.PromotionActionCandidate{
+		{PromotionActionID: 22, SourceSKU: "SKU-101", DiscountPercent: 40},
+	}
+
+	all := evaluateSelectionRule(dto.AutoPromotionSelectionRule{CandidateMatch: autoPromotionCandidateMatchAll}, source)
+	if len(all.States) != 1 || all.States["SKU-101"] == nil {
+		t.Fatalf("all mode selected = %v, want only SKU-101", sortedStateKeys(all.States))
+	}
+
+	capped := evaluateSelectionRule(dto.AutoPromotionSelectionRule{
+		MaxDiscountPercent: floatPtr(30),
+		CandidateMatch:     autoPromotionCandidateMatchAll,
+	}, source)
+	if len(capped.States) != 0 {
+		t.Fatalf("discount cap in all mode selected = %v, want none", sortedStateKeys(capped.States))
+	}
+
+	anyCapped := evaluateSelectionRule(dto.AutoPromotionSelectionRule{
+		MaxDiscountPercent: floatPtr(30),
+		IncludeSKUs:        []string{"SKU-303"},
+		CandidateMatch:     autoPromotionCandidateMatchAny,
+	}, source)
+	state := anyCapped.States["SKU-101"]
+	if state == nil || len(state.OfficialResults) != 1 || len(state.ShopResults) != 0 {
+		t.Fatalf("any mode should keep SKU-101 for the official action only, got %+v", state)
+	}
+	if anyCapped.States["SKU-303"] == nil {
+		t.Fatalf("included SKU should bypass the listing date range")
+	}
+}
+
+func TestNormalizeSelectionRuleValidatesBounds(t *testing.T) {
+	t.Parallel()
+
+	if _, err := normalizeSelectionRule(&dto.AutoPromotionSelectionRule{MinPrice: floatPtr(200), MaxPrice: floatPtr(100)}); err == nil {
+		t.Fatalf("expected error for min price above max price")
+	}
+	if _, err := normalizeSelectionRule(&dto.AutoPromotionSelectionRule{ListingDateFrom: "2026-03-10", ListingDateTo: "2026-03-01"}); err == nil {
+		t.Fatalf("expected error for reversed listing date range")
+	}
+	if _, err := normalizeSelectionRule(&dto.AutoPromotionSelectionRule{CandidateMatch: "some"}); err == nil {
+		t.Fatalf("expected error for unknown candidate_match")
+	}
+
+	rule, err := normalizeSelectionRule(&dto.AutoPromotionSelectionRule{Visibilities: []string{"visible", " visible "}})
+	if err != nil {
+		t.Fatalf("normalizeSelectionRule() error = %v", err)
+	}
+	if rule.CandidateMatch != autoPromotionCandidateMatchAll || len(rule.Visibilities) != 1 || rule.Visibilities[0] != "VISIBLE" {
+		t.Fatalf("normalized rule = %+v", rule)
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -22,8 +23,8 @@ const (
 	autoPromotionSchedulerInterval         = time.Minute
 	autoPromotionRunStaleAfter             = 2 * time.Hour
 	autoPromotionOfficialCandidatePageSize = 200
-	autoPromotionShopCandidateWaitTimeout  = 60 * time.Second
-	autoPromotionShopActionWaitTimeout     = 5 * time.Minute
+	// autoPromotionRunWaitTimeout 运行等待执行端任务的最长时间，超时后置为失败，可重试未完成的商品
+	autoPromotionRunWaitTimeout = 30 * time.Minute
 )
 
 type AutoPromotionService struct {
@@ -35,6 +36,10 @@ type AutoPromotionService struct {
 	ozonCatalogService *OzonCatalogService
 	automationService  *AutomationService
 	promotionService   *PromotionService
+	costRepo           *repository.RepriceRuleRepository
+	shopGuard          *ShopGuard
+	taskQueue          *TaskQueue
+	loops              backgroundLoops
 }
 
 type autoPromotionConfigSnapshot struct {
@@ -42,6 +47,14 @@ type autoPromotionConfigSnapshot struct {
 	TargetDate        string `json:"target_date"`
 	OfficialActionIDs []uint `json:"official_action_ids"`
 	ShopActionIDs     []uint `json:"shop_action_ids"`
+	ConfigName        string `json:"config_name,omitempty"`
+
+	SelectionRules   *dto.AutoPromotionSelectionRule `json:"selection_rules,omitempty"`
+	TargetMode       string                          `json:"target_mode,omitempty"`
+	TargetOffsetDays int                             `json:"target_offset_days,omitempty"`
+	// ListingDateFrom / ListingDateTo 创建运行时解析出的上架日期区间
+	ListingDateFrom string `json:"listing_date_from,omitempty"`
+	ListingDateTo   string `json:"listing_date_to,omitempty"`
 }
 
 type autoPromotionCandidateSnapshot struct {
@@ -60,20 +73,38 @@ type autoPromotionCandidateSnapshotItem struct {
 	Status          string  `json:"status"`
 }
 
+// autoPromotionRunInput 运行参数，同时作为后台任务载荷持久化
 type autoPromotionRunInput struct {
-	RunID             uint
-	ConfigID          *uint
-	ShopID            uint
-	TriggeredBy       *uint
-	TriggerMode       string
-	TriggerDate       time.Time
-	TargetDate        time.Time
-	ScheduleTime      string
-	OfficialActionIDs []uint
-	ShopActionIDs     []uint
+	RunID             uint      `json:"run_id"`
+	ConfigID          *uint     `json:"config_id,omitempty"`
+	ConfigName        string    `json:"config_name,omitempty"`
+	ShopID            uint      `json:"shop_id"`
+	TriggeredBy       *uint     `json:"triggered_by,omitempty"`
+	TriggerMode       string    `json:"trigger_mode"`
+	TriggerDate       time.Time `json:"trigger_date"`
+	TargetDate        time.Time `json:"target_date"`
+	ScheduleTime      string    `json:"schedule_time,omitempty"`
+	OfficialActionIDs []uint    `json:"official_action_ids"`
+	ShopActionIDs     []uint    `json:"shop_action_ids"`
+
+	SelectionRules   dto.AutoPromotionSelectionRule `json:"selection_rules"`
+	TargetMode       string                         `json:"target_mode,omitempty"`
+	TargetOffsetDays int                            `json:"target_offset_days,omitempty"`
+	ListingDateFrom  time.Time                      `json:"listing_date_from"`
+	ListingDateTo    time.Time                      `json:"listing_date_to"`
+}
+
+// listingDateRange 创建运行时已解析的上架日期区间；升级前入队的任务没有区间，按目标日期与规则重新计算
+func (input autoPromotionRunInput) listingDateRange() (time.Time, time.Time) {
+	if input.ListingDateFrom.IsZero() || input.ListingDateTo.IsZero() {
+		return resolveListingDateRange(input.SelectionRules, input.TargetDate, input.TargetDate, time.Now())
+	}
+	return input.ListingDateFrom, input.ListingDateTo
 }
 
 type autoPromotionItemState struct {
+	// ItemID 已落库明细的 ID，执行过程中按此逐项保存结果
+	ItemID          uint
 	Product         model.Product
 	CatalogItem     model.OzonProductCatalogItem
 	OfficialResults []dto.AutoPromotionActionResult
@@ -92,7 +123,7 @@ func NewAutoPromotionService(
 	automationService *AutomationService,
 	promotionService *PromotionService,
 ) *AutoPromotionService {
-	return &AutoPromotionService{
+	s := &AutoPromotionService{
 		autoRepo:           autoRepo,
 		productRepo:        productRepo,
 		promotionRepo:      promotionRepo,
@@ -101,89 +132,79 @@ func NewAutoPromotionService(
 		ozonCatalogService: ozonCatalogService,
 		automationService:  automationService,
 		promotionService:   promotionService,
+		shopGuard:          resolveShopGuard(shopRepo, automationService),
 	}
+	if automationService != nil {
+		automationService.OnJobFinished(s.handleAutomationJobFinished)
+	}
+	return s
 }
 
-func (s *AutoPromotionService) StartScheduler() {
-	_ = s.autoRepo.MarkStaleRunningRunsFailed(time.Now().Add(-autoPromotionRunStaleAfter))
-
-	go func() {
-		s.scanDueConfigs(time.Now())
-		ticker := time.NewTicker(autoPromotionSchedulerInterval)
-		defer ticker.Stop()
-
-		for now := range ticker.C {
-			s.scanDueConfigs(now)
-		}
-	}()
+// ConfigureTaskQueue 运行改为通过持久化任务队列执行，已创建的运行不会因进程退出而丢失
+func (s *AutoPromotionService) ConfigureTaskQueue(queue *TaskQueue) {
+	s.taskQueue = queue
+	// 运行中途失败不整体重试（可按明细重试失败商品）；服务停止时中断的运行退还队列不计次数，
+	// 进程崩溃后被重新领取的运行从已落库的明细续跑
+	queue.Register(model.BackgroundTaskTypeAutoPromotionRun, TaskOptions{
+		MaxAttempts: 2,
+		Timeout:     5 * time.Minute,
+	}, TypedTaskHandler(s.handleRunTask))
 }
 
-func (s *AutoPromotionService) GetConfig(shopID uint) (*dto.AutoPromotionConfigResponse, error) {
-	config, err := s.autoRepo.FindConfigByShopID(shopID)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			yesterday := time.Now().AddDate(0, 0, -1)
-			return &dto.AutoPromotionConfigResponse{
-				ShopID:            shopID,
-				Enabled:           false,
-				ScheduleTime:      autoPromotionDefaultScheduleTime,
-				TargetDate:        yesterday.Format("2006-01-02"),
-				OfficialActionIDs: []uint{},
-				ShopActionIDs:     []uint{},
-			}, nil
-		}
-		return nil, err
+// dispatchRun 将已创建的运行交给任务队列执行，入队失败时运行直接置为失败
+func (s *AutoPromotionService) dispatchRun(run *model.AutoPromotionRun, input autoPromotionRunInput) error {
+	if s.taskQueue == nil {
+		go s.executeRun(context.Background(), input)
+		return nil
 	}
-	return toAutoPromotionConfigDTO(config)
+	if err := s.taskQueue.Enqueue(model.BackgroundTaskTypeAutoPromotionRun, input); err != nil {
+		finishedAt := time.Now()
+		run.Status = model.AutoPromotionRunStatusFailed
+		run.ErrorMessage = "提交运行失败: " + err.Error()
+		run.CompletedAt = &finishedAt
+		_ = s.autoRepo.UpdateRun(run)
+		return err
+	}
+	return nil
 }
 
-func (s *AutoPromotionService) UpdateConfig(req *dto.AutoPromotionConfigRequest) (*dto.AutoPromotionConfigResponse, error) {
-	targetDate, err := parseDateOnly(req.TargetDate)
-	if err != nil || targetDate == nil {
-		return nil, fmt.Errorf("invalid target_date, expected YYYY-MM-DD")
+func (s *AutoPromotionService) handleRunTask(ctx context.Context, input autoPromotionRunInput) error {
+	run, err := s.autoRepo.FindRunByIDAndShop(input.RunID, input.ShopID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
 	}
-
-	scheduleTime, err := normalizeScheduleTime(req.ScheduleTime)
 	if err != nil {
-		return nil, err
-	}
-
-	officialIDs := uniqueUints(req.OfficialActionIDs)
-	shopIDs := uniqueUints(req.ShopActionIDs)
-	if req.Enabled && len(officialIDs)+len(shopIDs) == 0 {
-		return nil, fmt.Errorf("启用自动加促销前至少选择一个活动")
+		return err
 	}
-	if len(officialIDs)+len(shopIDs) > 0 {
-		if err := s.validateSelectedActions(req.ShopID, officialIDs, shopIDs); err != nil {
-			return nil, err
-		}
+	switch run.Status {
+	case model.AutoPromotionRunStatusPending, model.AutoPromotionRunStatusInterrupted, model.AutoPromotionRunStatusRunning:
+		// running 表示上次执行中进程退出、任务被重新领取；明细已逐项落库，从未完成的商品继续
+		return s.executeRun(ctx, input)
 	}
+	return nil
+}
 
-	officialBytes, _ := json.Marshal(officialIDs)
-	shopBytes, _ := json.Marshal(shopIDs)
-	config := &model.AutoPromotionConfig{
-		ShopID:            req.ShopID,
-		Enabled:           req.Enabled,
-		ScheduleTime:      scheduleTime,
-		TargetDate:        dateOnlyValue(*targetDate),
-		OfficialActionIDs: officialBytes,
-		ShopActionIDs:     shopBytes,
-	}
-	if err := s.autoRepo.UpsertConfig(config); err != nil {
-		return nil, err
-	}
+func (s *AutoPromotionService) StartScheduler() {
+	_ = s.autoRepo.MarkStaleRunningRunsInterrupted(time.Now().Add(-autoPromotionRunStaleAfter))
+
+	s.loops.Go(autoPromotionSchedulerInterval, s.scanDueConfigs)
+	s.loops.Go(autoPromotionSchedulerInterval, s.checkWaitingRuns)
+}
+
+// StopScheduler 停止定时扫描，不再触发新的自动加促销运行；执行中的运行由任务队列排空
+func (s *AutoPromotionService) StopScheduler() {
+	s.loops.Stop()
+}
 
-	saved, err := s.autoRepo.FindConfigByShopID(req.ShopID)
+func (s *AutoPromotionService) StartManualRun(userID uint, req *dto.AutoPromotionRunRequest) (*dto.AutoPromotionRunSummaryResponse, error) {
+	target, err := normalizeAutoPromotionTarget(req.TargetMode, req.TargetOffsetDays, req.TargetDate)
 	if err != nil {
 		return nil, err
 	}
-	return toAutoPromotionConfigDTO(saved)
-}
 
-func (s *AutoPromotionService) StartManualRun(userID uint, req *dto.AutoPromotionRunRequest) (*dto.AutoPromotionRunSummaryResponse, error) {
-	targetDate, err := parseDateOnly(req.TargetDate)
-	if err != nil || targetDate == nil {
-		return nil, fmt.Errorf("invalid target_date, expected YYYY-MM-DD")
+	selectionRules, err := normalizeSelectionRule(req.SelectionRules)
+	if err != nil {
+		return nil, err
 	}
 
 	officialIDs := uniqueUints(req.OfficialActionIDs)
@@ -194,6 +215,17 @@ func (s *AutoPromotionService) StartManualRun(userID uint, req *dto.AutoPromotio
 	if err := s.validateSelectedActions(req.ShopID, officialIDs, shopIDs); err != nil {
 		return nil, err
 	}
+	if err := s.shopGuard.CheckScheduledRun(req.ShopID); err != nil {
+		return nil, err
+	}
+
+	var config *model.AutoPromotionConfig
+	if req.ConfigID != nil {
+		config, err = s.autoRepo.FindConfigByIDAndShop(*req.ConfigID, req.ShopID)
+		if err != nil {
+			return nil, fmt.Errorf("配置不存在: %w", err)
+		}
+	}
 
 	if activeRun, err := s.autoRepo.FindActiveRunByShop(req.ShopID); err == nil && activeRun != nil {
 		return nil, fmt.Errorf("已有自动加促销任务正在执行中")
@@ -207,23 +239,29 @@ func (s *AutoPromotionService) StartManualRun(userID uint, req *dto.AutoPromotio
 		TriggeredBy:       &userID,
 		TriggerMode:       model.AutoPromotionTriggerModeManual,
 		TriggerDate:       dateOnlyValue(now),
-		TargetDate:        dateOnlyValue(*targetDate),
 		OfficialActionIDs: officialIDs,
 		ShopActionIDs:     shopIDs,
+		SelectionRules:    selectionRules,
+	}
+	if config != nil {
+		input.ConfigID = &config.ID
+		input.ConfigName = config.Name
 	}
 
-	run, err := s.createRun(input)
+	run, err := s.createRun(&input, target)
 	if err != nil {
 		return nil, err
 	}
 	input.RunID = run.ID
-	go s.executeRun(input)
+	if err := s.dispatchRun(run, input); err != nil {
+		return nil, err
+	}
 
 	return toAutoPromotionRunSummaryDTO(run), nil
 }
 
 func (s *AutoPromotionService) ListRuns(req *dto.AutoPromotionRunListRequest) (*dto.AutoPromotionRunListResponse, error) {
-	runs, total, err := s.autoRepo.ListRunsByShop(req.ShopID, req.Page, req.PageSize)
+	runs, total, err := s.autoRepo.ListRunsByShop(req.ShopID, req.ConfigID, req.Page, req.PageSize)
 	if err != nil {
 		return nil, err
 	}
@@ -267,11 +305,15 @@ func (s *AutoPromotionService) GetRunDetail(shopID uint, runID uint) (*dto.AutoP
 	return &dto.AutoPromotionRunDetailResponse{
 		AutoPromotionRunSummaryResponse: *toAutoPromotionRunSummaryDTO(run),
 		ShopID:                          run.ShopID,
-		ConfigID:                        run.ConfigID,
 		TriggeredBy:                     run.TriggeredBy,
 		ScheduleTime:                    snapshot.ScheduleTime,
 		OfficialActionIDs:               snapshot.OfficialActionIDs,
 		ShopActionIDs:                   snapshot.ShopActionIDs,
+		SelectionRules:                  snapshotSelectionRule(snapshot),
+		TargetMode:                      firstNonEmpty(snapshot.TargetMode, model.AutoPromotionTargetModeFixedDate),
+		TargetOffsetDays:                snapshot.TargetOffsetDays,
+		ListingDateFrom:                 snapshot.ListingDateFrom,
+		ListingDateTo:                   snapshot.ListingDateTo,
 		Items:                           items,
 	}, nil
 }
@@ -282,9 +324,8 @@ func (s *AutoPromotionService) scanDueConfigs(now time.Time) {
 		return
 	}
 
-	currentMinute := now.Format("15:04")
 	for _, config := range configs {
-		if strings.TrimSpace(config.ScheduleTime) != currentMinute {
+		if !isConfigDue(config.ScheduleTime, now) {
 			continue
 		}
 
@@ -303,30 +344,45 @@ func (s *AutoPromotionService) scanDueConfigs(now time.Time) {
 
 		input := autoPromotionRunInput{
 			ConfigID:          &config.ID,
+			ConfigName:        config.Name,
 			ShopID:            config.ShopID,
 			TriggerMode:       model.AutoPromotionTriggerModeScheduled,
 			TriggerDate:       triggerDate,
-			TargetDate:        dateOnlyValue(config.TargetDate),
 			ScheduleTime:      strings.TrimSpace(config.ScheduleTime),
 			OfficialActionIDs: decodeActionIDs(config.OfficialActionIDs),
 			ShopActionIDs:     decodeActionIDs(config.ShopActionIDs),
+			SelectionRules:    decodeSelectionRule(config.SelectionRules),
 		}
 
-		run, err := s.createRun(input)
+		run, err := s.createRun(&input, configTarget(&config))
 		if err != nil {
 			continue
 		}
 		input.RunID = run.ID
-		go s.executeRun(input)
+		_ = s.dispatchRun(run, input)
 	}
 }
 
-func (s *AutoPromotionService) createRun(input autoPromotionRunInput) (*model.AutoPromotionRun, error) {
+// createRun 按触发日期解析目标日期与上架日期区间，写入 input 并记录到运行快照
+func (s *AutoPromotionService) createRun(input *autoPromotionRunInput, target autoPromotionTarget) (*model.AutoPromotionRun, error) {
+	targetFrom, targetTo := target.resolve(input.TriggerDate)
+	input.TargetDate = targetTo
+	input.TargetMode = target.Mode
+	input.TargetOffsetDays = target.OffsetDays
+	input.ListingDateFrom, input.ListingDateTo = resolveListingDateRange(input.SelectionRules, targetFrom, targetTo, time.Now())
+
+	selectionRules := input.SelectionRules
 	snapshotBytes, _ := json.Marshal(autoPromotionConfigSnapshot{
 		ScheduleTime:      input.ScheduleTime,
 		TargetDate:        input.TargetDate.Format("2006-01-02"),
 		OfficialActionIDs: input.OfficialActionIDs,
 		ShopActionIDs:     input.ShopActionIDs,
+		ConfigName:        input.ConfigName,
+		SelectionRules:    &selectionRules,
+		TargetMode:        input.TargetMode,
+		TargetOffsetDays:  input.TargetOffsetDays,
+		ListingDateFrom:   input.ListingDateFrom.Format("2006-01-02"),
+		ListingDateTo:     input.ListingDateTo.Format("2006-01-02"),
 	})
 
 	run := &model.AutoPromotionRun{
@@ -345,15 +401,19 @@ func (s *AutoPromotionService) createRun(input autoPromotionRunInput) (*model.Au
 	return run, nil
 }
 
-func (s *AutoPromotionService) executeRun(input autoPromotionRunInput) {
+// executeRun 执行运行；服务停止导致 ctx 取消时运行标记为 interrupted 并返回错误，任务退还队列后重新执行。
+// 需要等待执行端任务时运行挂起为 waiting 并结束本次后台任务，任务结束后重新提交
+func (s *AutoPromotionService) executeRun(ctx context.Context, input autoPromotionRunInput) error {
 	run, err := s.autoRepo.FindRunByIDAndShop(input.RunID, input.ShopID)
 	if err != nil {
-		return
+		return nil
 	}
 
 	now := time.Now()
 	run.Status = model.AutoPromotionRunStatusRunning
-	run.StartedAt = &now
+	if run.StartedAt == nil {
+		run.StartedAt = &now
+	}
 	run.ErrorMessage = ""
 	_ = s.autoRepo.UpdateRun(run)
 
@@ -364,118 +424,82 @@ func (s *AutoPromotionService) executeRun(input autoPromotionRunInput) {
 		}
 	}
 
-	if execErr := s.runExecution(run, input); execErr != nil {
-		finishedAt := time.Now()
-		run.Status = model.AutoPromotionRunStatusFailed
-		run.ErrorMessage = execErr.Error()
-		run.CompletedAt = &finishedAt
+	execErr := s.runExecution(ctx, run, input)
+	if execErr == nil {
+		return nil
+	}
+	var waitErr *autoPromotionWaitError
+	if errors.As(execErr, &waitErr) {
+		s.suspendRun(run, waitErr.JobIDs)
+		return nil
+	}
+	if ctx.Err() != nil {
+		run.Status = model.AutoPromotionRunStatusInterrupted
+		run.ErrorMessage = "服务停止，运行已中断，重启后自动继续"
 		_ = s.autoRepo.UpdateRun(run)
+		return ctx.Err()
 	}
+	finishedAt := time.Now()
+	run.Status = model.AutoPromotionRunStatusFailed
+	run.ErrorMessage = execErr.Error()
+	run.CompletedAt = &finishedAt
+	_ = s.autoRepo.UpdateRun(run)
+	return nil
 }
 
-func (s *AutoPromotionService) runExecution(run *model.AutoPromotionRun, input autoPromotionRunInput) error {
+func (s *AutoPromotionService) runExecution(ctx context.Context, run *model.AutoPromotionRun, input autoPromotionRunInput) error {
+	// 定时运行照常建档，店铺暂停写入或熔断时直接以失败结束，便于在运行记录中看到原因
+	if err := s.shopGuard.CheckScheduledRun(input.ShopID); err != nil {
+		return err
+	}
 	actions, err := s.resolveActions(input.ShopID, input.OfficialActionIDs, input.ShopActionIDs)
 	if err != nil {
 		return err
 	}
 	officialActions, shopActions := splitActionsBySource(actions)
 
-	if err := s.ozonCatalogService.RefreshShopCatalogSync(input.ShopID); err != nil {
-		return fmt.Errorf("刷新 Ozon 商品目录失败: %w", err)
-	}
-
-	for _, action := range officialActions {
-		actionCopy := action
-		if err := s.refreshOfficialCandidates(&actionCopy); err != nil {
-			return fmt.Errorf("刷新官方活动候选商品失败: %s: %w", displayActionName(action), err)
-		}
-		if err := s.promotionService.refreshOfficialActionProducts(&actionCopy); err != nil {
-			return fmt.Errorf("刷新官方活动已报名商品失败: %s: %w", displayActionName(action), err)
-		}
-	}
-
 	triggerUserID := uint(0)
 	if input.TriggeredBy != nil {
 		triggerUserID = *input.TriggeredBy
 	}
-	for _, action := range shopActions {
-		actionCopy := action
-		if err := s.refreshShopCandidates(&actionCopy, triggerUserID); err != nil {
-			return fmt.Errorf("刷新店铺活动候选商品失败: %s: %w", displayActionName(action), err)
-		}
-	}
-
-	catalogItems, err := s.ozonCatalogRepo.ListByListingDate(input.ShopID, input.TargetDate)
-	if err != nil {
-		return fmt.Errorf("按日期查询目录商品失败: %w", err)
-	}
-
-	localProducts, err := s.productRepo.FindByOzonProductIDs(input.ShopID, collectCatalogProductIDs(catalogItems))
-	if err != nil {
-		return fmt.Errorf("查询本地商品失败: %w", err)
-	}
-
-	run.TotalCandidates = 0
-	for _, item := range catalogItems {
-		if _, exists := localProducts[item.OzonProductID]; exists {
-			run.TotalCandidates++
-		}
-	}
-
-	sourceSKUs := make([]string, 0, run.TotalCandidates)
-	for _, item := range catalogItems {
-		if product, exists := localProducts[item.OzonProductID]; exists {
-			sourceSKUs = append(sourceSKUs, product.SourceSKU)
-		}
-	}
-	sourceSKUs = uniqueStrings(sourceSKUs)
-
-	officialActionIDs := actionIDsForActions(officialActions)
-	shopActionIDs := actionIDsForActions(shopActions)
 
-	officialCandidates, err := s.promotionRepo.ListActionCandidatesByActionIDsAndSourceSKUs(input.ShopID, officialActionIDs, sourceSKUs)
-	if err != nil {
-		return fmt.Errorf("查询官方活动候选缓存失败: %w", err)
-	}
-	shopCandidates, err := s.promotionRepo.ListActionCandidatesByActionIDsAndSourceSKUs(input.ShopID, shopActionIDs, sourceSKUs)
-	if err != nil {
-		return fmt.Errorf("查询店铺活动候选缓存失败: %w", err)
+	var selectedStates map[string]*autoPromotionItemState
+	if len(run.RunItems) > 0 {
+		// 已落库选品结果：续跑或重试，只处理尚未完成的商品
+		selectedStates, err = s.restoreItemStates(ctx, run, officialActions)
+	} else {
+		selectedStates, err = s.selectRunItems(ctx, run, input, officialActions, shopActions, triggerUserID)
 	}
-	officialExisting, err := s.promotionRepo.ListActionProductsByActionIDsAndSourceSKUs(input.ShopID, officialActionIDs, sourceSKUs)
 	if err != nil {
-		return fmt.Errorf("查询官方活动已报名缓存失败: %w", err)
+		return err
 	}
 
-	selectedStates := s.selectEligibleItems(catalogItems, localProducts, officialActions, shopActions, officialCandidates, shopCandidates, officialExisting)
-	run.TotalSelected = len(selectedStates)
-	run.TotalProcessed = len(selectedStates)
-
 	if len(selectedStates) == 0 {
 		finishedAt := time.Now()
 		run.Status = model.AutoPromotionRunStatusSuccess
 		run.CompletedAt = &finishedAt
-		if err := s.autoRepo.ReplaceRunItems(run.ID, []model.AutoPromotionRunItem{}); err != nil {
-			return err
-		}
 		return s.autoRepo.UpdateRun(run)
 	}
 
-	if err := s.executeOfficialActions(input.ShopID, officialActions, selectedStates); err != nil {
+	ledgerSource := participationSource{
+		Origin:      model.PromotionParticipationOriginAutoPromotion,
+		TriggeredBy: input.TriggeredBy,
+		Reference:   fmt.Sprintf("auto_promotion_run:%d", run.ID),
+	}
+	if err := s.executeOfficialActions(ctx, input.ShopID, officialActions, selectedStates, ledgerSource); err != nil {
 		return err
 	}
-	if err := s.executeShopActions(input.ShopID, triggerUserID, shopActions, selectedStates); err != nil {
+	if err := s.executeShopActions(ctx, input.ShopID, triggerUserID, autoPromotionJobPriority(input.TriggerMode), shopActions, selectedStates, ledgerSource); err != nil {
 		return err
 	}
 
 	runItems := make([]model.AutoPromotionRunItem, 0, len(selectedStates))
-	runKeys := sortedStateKeys(selectedStates)
 	successCount := 0
 	failedCount := 0
 	skippedCount := 0
-	for _, sku := range runKeys {
-		state := selectedStates[sku]
-		overallStatus, officialStatus, shopStatus := summarizeItemStatuses(state)
-		switch overallStatus {
+	for _, sku := range sortedStateKeys(selectedStates) {
+		item := buildRunItem(run.ID, selectedStates[sku])
+		switch item.OverallStatus {
 		case model.AutoPromotionItemStatusFailed:
 			failedCount++
 		case model.AutoPromotionItemStatusSkipped:
@@ -483,24 +507,10 @@ func (s *AutoPromotionService) runExecution(run *model.AutoPromotionRun, input a
 		default:
 			successCount++
 		}
-
-		officialBytes, _ := json.Marshal(state.OfficialResults)
-		shopBytes, _ := json.Marshal(state.ShopResults)
-		runItems = append(runItems, model.AutoPromotionRunItem{
-			ProductID:       &state.Product.ID,
-			OzonProductID:   state.Product.OzonProductID,
-			SourceSKU:       state.Product.SourceSKU,
-			ProductName:     firstNonEmpty(strings.TrimSpace(state.Product.Name), strings.TrimSpace(state.CatalogItem.Name), state.Product.SourceSKU),
-			ListingDate:     dateOnlyValue(*state.CatalogItem.ListingDate),
-			OverallStatus:   overallStatus,
-			OfficialStatus:  officialStatus,
-			ShopStatus:      shopStatus,
-			OfficialResults: officialBytes,
-			ShopResults:     shopBytes,
-		})
+		runItems = append(runItems, item)
 	}
 
-	if err := s.autoRepo.ReplaceRunItems(run.ID, runItems); err != nil {
+	if err := s.autoRepo.SaveRunItemResults(runItems); err != nil {
 		return err
 	}
 
@@ -513,6 +523,79 @@ func (s *AutoPromotionService) runExecution(run *model.AutoPromotionRun, input a
 	return s.autoRepo.UpdateRun(run)
 }
 
+// selectRunItems 首次执行：刷新目录与候选缓存后选品，选中的商品立即落库为待处理明细。
+// 店铺活动候选由执行端同步，先提交同步任务，任务未全部结束时挂起运行等待
+func (s *AutoPromotionService) selectRunItems(
+	ctx context.Context,
+	run *model.AutoPromotionRun,
+	input autoPromotionRunInput,
+	officialActions []model.PromotionAction,
+	shopActions []model.PromotionAction,
+	triggerUserID uint,
+) (map[string]*autoPromotionItemState, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	candidateJobs, err := s.submitShopCandidateJobs(run, shopActions, triggerUserID, autoPromotionJobPriority(input.TriggerMode))
+	if err != nil {
+		return nil, err
+	}
+	if unfinished := unfinishedAutomationJobIDs(candidateJobs); len(unfinished) > 0 {
+		return nil, &autoPromotionWaitError{JobIDs: unfinished}
+	}
+
+	if err := s.ozonCatalogService.RefreshShopCatalogSync(ctx, input.ShopID); err != nil {
+		return nil, fmt.Errorf("刷新 Ozon 商品目录失败: %w", err)
+	}
+
+	for _, action := range officialActions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		actionCopy := action
+		if err := s.refreshOfficialCandidates(&actionCopy); err != nil {
+			return nil, fmt.Errorf("刷新官方活动候选商品失败: %s: %w", displayActionName(action), err)
+		}
+		if err := s.promotionService.refreshOfficialActionProducts(&actionCopy); err != nil {
+			return nil, fmt.Errorf("刷新官方活动已报名商品失败: %s: %w", displayActionName(action), err)
+		}
+	}
+
+	for _, action := range shopActions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		actionCopy := action
+		if err := s.importShopCandidates(&actionCopy, candidateJobs[action.ID]); err != nil {
+			return nil, fmt.Errorf("刷新店铺活动候选商品失败: %s: %w", displayActionName(action), err)
+		}
+	}
+
+	listingFrom, listingTo := input.listingDateRange()
+	excludeEnrolled := autoPromotionTarget{Mode: input.TargetMode}.excludeEnrolled()
+	selection, err := s.selectByRule(input.ShopID, listingFrom, listingTo, input.SelectionRules, excludeEnrolled, officialActions, shopActions)
+	if err != nil {
+		return nil, err
+	}
+	selectedStates := selection.States
+	run.TotalCandidates = selection.TotalCandidates
+	run.TotalSelected = len(selectedStates)
+	run.TotalProcessed = len(selectedStates)
+
+	if err := s.persistSelectedItems(run, selectedStates); err != nil {
+		return nil, err
+	}
+	return selectedStates, nil
+}
+
+// autoPromotionJobPriority 定时触发的运行以后台优先级创建 automation job，手动触发的按普通优先级
+func autoPromotionJobPriority(triggerMode string) int {
+	if triggerMode == model.AutoPromotionTriggerModeScheduled {
+		return model.AutomationJobPriorityBackground
+	}
+	return model.AutomationJobPriorityNormal
+}
+
 func (s *AutoPromotionService) validateSelectedActions(shopID uint, officialIDs []uint, shopIDs []uint) error {
 	_, err := s.resolveActions(shopID, officialIDs, shopIDs)
 	return err
@@ -560,7 +643,7 @@ func (s *AutoPromotionService) refreshOfficialCandidates(action *model.Promotion
 	if err != nil {
 		return err
 	}
-	client := ozon.NewClient(shop.ClientID, shop.ApiKey)
+	client := s.shopGuard.OzonClient(shop)
 
 	seenLastIDs := make(map[string]struct{})
 	lastID := ""
@@ -636,32 +719,16 @@ func (s *AutoPromotionService) refreshOfficialCandidates(action *model.Promotion
 	return s.promotionRepo.ReplaceActionCandidates(action, dedupeCandidates(candidates))
 }
 
-func (s *AutoPromotionService) refreshShopCandidates(action *model.PromotionAction, userID uint) error {
-	if s.automationService == nil {
-		return fmt.Errorf("automation service unavailable")
+// importShopCandidates 由已结束的候选同步任务导入店铺活动候选商品
+func (s *AutoPromotionService) importShopCandidates(action *model.PromotionAction, job *model.AutomationJob) error {
+	if job == nil {
+		return fmt.Errorf("shop action candidates sync job missing")
 	}
-	if userID == 0 {
-		shop, err := s.shopRepo.FindByID(action.ShopID)
-		if err != nil {
-			return err
-		}
-		userID = shop.OwnerID
+	if job.Status != model.AutomationJobStatusSuccess && job.Status != model.AutomationJobStatusPartialSuccess {
+		return fmt.Errorf("%s", automationJobFailureMessage(job, "shop action candidates sync failed"))
 	}
 
-	job, err := s.automationService.CreateSyncActionCandidatesJob(userID, action.ShopID, action.ID, action.SourceActionID)
-	if err != nil {
-		return err
-	}
-
-	waitedJob, waitErr := s.automationService.WaitForJobCompletion(job.ID, autoPromotionShopCandidateWaitTimeout)
-	if waitErr != nil {
-		return fmt.Errorf("shop action candidates sync timeout")
-	}
-	if waitedJob.Status != model.AutomationJobStatusSuccess && waitedJob.Status != model.AutomationJobStatusPartialSuccess {
-		return fmt.Errorf("%s", automationJobFailureMessage(waitedJob, "shop action candidates sync failed"))
-	}
-
-	artifact, err := s.automationService.GetLatestArtifact(waitedJob.ID, "action_candidates_snapshot")
+	artifact, err := s.automationService.GetLatestArtifact(job.ID, "action_candidates_snapshot")
 	if err != nil {
 		return err
 	}
@@ -702,6 +769,7 @@ func (s *AutoPromotionService) refreshShopCandidates(action *model.PromotionActi
 	return s.promotionRepo.ReplaceActionCandidates(action, dedupeCandidates(candidates))
 }
 
+// selectEligibleItems 按默认规则选品：必须是全部所选活动的候选，不限制上架日期以外的条件
 func (s *AutoPromotionService) selectEligibleItems(
 	catalogItems []model.OzonProductCatalogItem,
 	localProducts map[int64]model.Product,
@@ -711,102 +779,19 @@ func (s *AutoPromotionService) selectEligibleItems(
 	shopCandidates []model.PromotionActionCandidate,
 	officialExisting []model.PromotionActionProduct,
 ) map[string]*autoPromotionItemState {
-	officialCandidateMap := groupCandidatesByActionAndSKU(officialCandidates)
-	shopCandidateMap := groupCandidatesByActionAndSKU(shopCandidates)
-	officialExistingMap := groupActionProductsByActionAndSKU(officialExisting)
-
-	states := make(map[string]*autoPromotionItemState)
-	for _, catalogItem := range catalogItems {
-		product, exists := localProducts[catalogItem.OzonProductID]
-		if !exists || catalogItem.ListingDate == nil {
-			continue
-		}
-		sku := strings.TrimSpace(product.SourceSKU)
-		if sku == "" {
-			continue
-		}
-
-		state := &autoPromotionItemState{
-			Product:     product,
-			CatalogItem: catalogItem,
-		}
-
-		eligible := true
-		for _, action := range officialActions {
-			if actionItems, ok := officialExistingMap[action.ID]; ok {
-				if _, exists := actionItems[sku]; exists {
-					state.OfficialResults = append(state.OfficialResults, dto.AutoPromotionActionResult{
-						PromotionActionID: action.ID,
-						ActionID:          action.ActionID,
-						Title:             displayActionName(action),
-						Source:            action.Source,
-						Status:            model.PromotionActionCandidateStatusAlreadyActive,
-					})
-					continue
-				}
-			}
-
-			actionCandidates, ok := officialCandidateMap[action.ID]
-			if !ok {
-				eligible = false
-				break
-			}
-			candidate, ok := actionCandidates[sku]
-			if !ok {
-				eligible = false
-				break
-			}
-			state.OfficialResults = append(state.OfficialResults, dto.AutoPromotionActionResult{
-				PromotionActionID: action.ID,
-				ActionID:          action.ActionID,
-				Title:             displayActionName(action),
-				Source:            action.Source,
-				Status:            model.PromotionActionCandidateStatusCandidate,
-				ActionPrice:       candidate.ActionPrice,
-				MaxActionPrice:    candidate.MaxActionPrice,
-			})
-		}
-		if !eligible {
-			continue
-		}
-
-		for _, action := range shopActions {
-			actionCandidates, ok := shopCandidateMap[action.ID]
-			if !ok {
-				eligible = false
-				break
-			}
-			candidate, ok := actionCandidates[sku]
-			if !ok {
-				eligible = false
-				break
-			}
-
-			resultStatus := model.PromotionActionCandidateStatusCandidate
-			if candidate.Status == model.PromotionActionCandidateStatusActive {
-				resultStatus = model.PromotionActionCandidateStatusAlreadyActive
-			}
-			state.ShopResults = append(state.ShopResults, dto.AutoPromotionActionResult{
-				PromotionActionID: action.ID,
-				SourceActionID:    action.SourceActionID,
-				Title:             displayActionName(action),
-				Source:            action.Source,
-				Status:            resultStatus,
-				ActionPrice:       candidate.ActionPrice,
-				MaxActionPrice:    candidate.MaxActionPrice,
-			})
-		}
-		if !eligible {
-			continue
-		}
-
-		states[sku] = state
-	}
-
-	return states
+	selection := evaluateSelectionRule(dto.AutoPromotionSelectionRule{CandidateMatch: autoPromotionCandidateMatchAll}, autoPromotionSelectionSource{
+		CatalogItems:       catalogItems,
+		LocalProducts:      localProducts,
+		OfficialActions:    officialActions,
+		ShopActions:        shopActions,
+		OfficialCandidates: officialCandidates,
+		ShopCandidates:     shopCandidates,
+		OfficialExisting:   officialExisting,
+	})
+	return selection.States
 }
 
-func (s *AutoPromotionService) executeOfficialActions(shopID uint, actions []model.PromotionAction, states map[string]*autoPromotionItemState) error {
+func (s *AutoPromotionService) executeOfficialActions(ctx context.Context, shopID uint, actions []model.PromotionAction, states map[string]*autoPromotionItemState, source participationSource) error {
 	if len(actions) == 0 || len(states) == 0 {
 		return nil
 	}
@@ -815,9 +800,12 @@ func (s *AutoPromotionService) executeOfficialActions(shopID uint, actions []mod
 	if err != nil {
 		return err
 	}
-	client := ozon.NewClient(shop.ClientID, shop.ApiKey)
+	client := s.shopGuard.OzonClient(shop)
 
 	for _, action := range actions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		payload := make([]ozon.ActivateProductItem, 0)
 		skusByProductID := make(map[int64]string)
 		orderedSKUs := sortedStateKeys(states)
@@ -829,8 +817,9 @@ func (s *AutoPromotionService) executeOfficialActions(shopID uint, actions []mod
 				continue
 			}
 
+			// 只处理待加入的结果，续跑时已完成（成功 / 失败 / 已在活动中）的不再提交
 			result := findActionResultByID(state.OfficialResults, action.ID)
-			if result == nil || result.Status == model.PromotionActionCandidateStatusAlreadyActive {
+			if result == nil || result.Status != model.PromotionActionCandidateStatusCandidate {
 				continue
 			}
 
@@ -851,6 +840,9 @@ func (s *AutoPromotionService) executeOfficialActions(shopID uint, actions []mod
 		}
 
 		if len(payload) == 0 {
+			if err := s.persistItemStates(states, orderedSKUs); err != nil {
+				return err
+			}
 			continue
 		}
 
@@ -867,6 +859,9 @@ func (s *AutoPromotionService) executeOfficialActions(shopID uint, actions []mod
 					}
 				}
 			}
+			if err := s.persistItemStates(states, orderedSKUs); err != nil {
+				return err
+			}
 			continue
 		}
 
@@ -879,6 +874,8 @@ func (s *AutoPromotionService) executeOfficialActions(shopID uint, actions []mod
 			rejectedByProductID[item.ProductID] = strings.TrimSpace(item.Reason)
 		}
 
+		joined := make([]model.PromotionParticipationEvent, 0, len(payload))
+		actionPrices := make([]model.PriceHistory, 0, len(payload))
 		for _, item := range payload {
 			sku := skusByProductID[item.ProductID]
 			state := states[sku]
@@ -899,6 +896,8 @@ func (s *AutoPromotionService) executeOfficialActions(shopID uint, actions []mod
 			if _, exists := successProductIDs[item.ProductID]; exists {
 				result.Status = model.AutoPromotionItemStatusSuccess
 				state.HasExecutedStep = true
+				joined = append(joined, source.actionEvent(model.PromotionParticipationJoined, state.Product, "official", action.ActionID, action.Title, item.ActionPrice))
+				actionPrices = append(actionPrices, appliedActionPrice(state.Product, action.ActionID, item.ActionPrice, model.PriceSourceAutoPromotion, source.TriggeredBy, source.Reference))
 				continue
 			}
 
@@ -906,12 +905,17 @@ func (s *AutoPromotionService) executeOfficialActions(shopID uint, actions []mod
 			result.Error = "官方活动未返回明确成功结果"
 			state.Blocked = true
 		}
+		s.promotionService.recordParticipation(shopID, joined...)
+		s.promotionService.priceHistory.Record(actionPrices...)
+		if err := s.persistItemStates(states, orderedSKUs); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (s *AutoPromotionService) executeShopActions(shopID uint, userID uint, actions []model.PromotionAction, states map[string]*autoPromotionItemState) error {
+func (s *AutoPromotionService) executeShopActions(ctx context.Context, shopID uint, userID uint, priority int, actions []model.PromotionAction, states map[string]*autoPromotionItemState, source participationSource) error {
 	if len(actions) == 0 || len(states) == 0 {
 		return nil
 	}
@@ -925,8 +929,14 @@ func (s *AutoPromotionService) executeShopActions(shopID uint, userID uint, acti
 	}
 
 	for _, action := range actions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		orderedSKUs := sortedStateKeys(states)
+		// 续跑时已提交过 job 的商品按 job 分组，等待原 job 的结果而不是重复提交
+		submittedSKUs := make(map[uint][]string)
 		actionSKUs := make([]string, 0)
-		for _, sku := range sortedStateKeys(states) {
+		for _, sku := range orderedSKUs {
 			state := states[sku]
 			if state.Blocked {
 				markActionSkipped(state, &action, "shop")
@@ -934,80 +944,129 @@ func (s *AutoPromotionService) executeShopActions(shopID uint, userID uint, acti
 			}
 
 			result := findActionResultBySourceActionID(state.ShopResults, action.ID, action.SourceActionID)
-			if result == nil || result.Status == model.PromotionActionCandidateStatusAlreadyActive {
+			if result == nil || result.Status != model.PromotionActionCandidateStatusCandidate {
+				continue
+			}
+			if result.JobID > 0 {
+				submittedSKUs[result.JobID] = append(submittedSKUs[result.JobID], sku)
 				continue
 			}
 			actionSKUs = append(actionSKUs, sku)
 		}
 
-		if len(actionSKUs) == 0 {
-			continue
+		jobIDs := make([]uint, 0, len(submittedSKUs)+1)
+		for jobID := range submittedSKUs {
+			jobIDs = append(jobIDs, jobID)
 		}
+		sort.Slice(jobIDs, func(i, j int) bool { return jobIDs[i] < jobIDs[j] })
 
-		job, err := s.promotionService.CreateShopActionJob(userID, shopID, model.AutomationJobTypeShopActionDeclare, action.SourceActionID, actionSKUs)
-		if err != nil {
-			for _, sku := range actionSKUs {
-				if state := states[sku]; state != nil {
-					if result := findActionResultBySourceActionID(state.ShopResults, action.ID, action.SourceActionID); result != nil {
-						result.Status = model.AutoPromotionItemStatusFailed
-						result.Error = err.Error()
+		if len(actionSKUs) > 0 {
+			job, err := s.promotionService.CreateShopActionJob(userID, shopID, model.AutomationJobTypeShopActionDeclare, action.SourceActionID, actionSKUs, priority)
+			if err != nil {
+				failShopActionItems(states, &action, actionSKUs, err.Error())
+			} else {
+				for _, sku := range actionSKUs {
+					if result := findActionResultBySourceActionID(states[sku].ShopResults, action.ID, action.SourceActionID); result != nil {
+						result.JobID = job.ID
 					}
-					state.Blocked = true
 				}
+				submittedSKUs[job.ID] = actionSKUs
+				jobIDs = append(jobIDs, job.ID)
+			}
+			// 先记下 job，运行挂起或进程退出后续跑时接上该 job
+			if err := s.persistItemStates(states, orderedSKUs); err != nil {
+				return err
 			}
-			continue
 		}
 
-		waitedJob, waitErr := s.automationService.WaitForJobCompletion(job.ID, autoPromotionShopActionWaitTimeout)
-		if waitErr != nil {
-			for _, sku := range actionSKUs {
+		unfinished := make([]uint, 0)
+		for _, jobID := range jobIDs {
+			skus := submittedSKUs[jobID]
+			job, err := s.automationService.FindJobByIDAndShop(jobID, shopID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				failShopActionItems(states, &action, skus, "店铺活动任务不存在")
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if !isAutomationJobFinished(job.Status) {
+				unfinished = append(unfinished, jobID)
+				continue
+			}
+			applyShopJobResults(states, &action, skus, job)
+
+			joined := make([]model.PromotionParticipationEvent, 0, len(skus))
+			for _, sku := range skus {
 				if state := states[sku]; state != nil {
-					if result := findActionResultBySourceActionID(state.ShopResults, action.ID, action.SourceActionID); result != nil {
-						result.Status = model.AutoPromotionItemStatusFailed
-						result.Error = "店铺活动执行超时"
+					result := findActionResultBySourceActionID(state.ShopResults, action.ID, action.SourceActionID)
+					if result != nil && result.Status == model.AutoPromotionItemStatusSuccess {
+						joined = append(joined, source.actionEvent(model.PromotionParticipationJoined, state.Product, "shop", action.ActionID, action.Title, 0))
 					}
-					state.Blocked = true
 				}
 			}
-			continue
+			s.promotionService.recordParticipation(shopID, joined...)
 		}
-
-		itemBySKU := make(map[string]model.AutomationJobItem, len(waitedJob.Items))
-		for _, item := range waitedJob.Items {
-			itemBySKU[item.SourceSKU] = item
+		if err := s.persistItemStates(states, orderedSKUs); err != nil {
+			return err
+		}
+		if len(unfinished) > 0 {
+			// 后续活动依赖本活动的结果（失败的商品不再加入后续活动），本活动的任务全部结束后再继续
+			return &autoPromotionWaitError{JobIDs: unfinished}
 		}
+	}
 
-		for _, sku := range actionSKUs {
-			state := states[sku]
-			if state == nil {
-				continue
-			}
-			result := findActionResultBySourceActionID(state.ShopResults, action.ID, action.SourceActionID)
-			if result == nil {
-				continue
-			}
+	return nil
+}
 
-			jobItem, exists := itemBySKU[sku]
-			if !exists {
+// failShopActionItems 将一批商品在该店铺活动上的结果置为失败，并阻断其后续活动
+func failShopActionItems(states map[string]*autoPromotionItemState, action *model.PromotionAction, skus []string, message string) {
+	for _, sku := range skus {
+		if state := states[sku]; state != nil {
+			if result := findActionResultBySourceActionID(state.ShopResults, action.ID, action.SourceActionID); result != nil {
 				result.Status = model.AutoPromotionItemStatusFailed
-				result.Error = "店铺活动未返回商品执行结果"
-				state.Blocked = true
-				continue
+				result.Error = message
 			}
+			state.Blocked = true
+		}
+	}
+}
 
-			if jobItem.OverallStatus == model.AutomationStepStatusSuccess || jobItem.OverallStatus == model.AutomationStepStatusSkipped {
-				result.Status = model.AutoPromotionItemStatusSuccess
-				state.HasExecutedStep = true
-				continue
-			}
+// applyShopJobResults 按 automation job 的明细结果更新各商品在该店铺活动上的结果
+func applyShopJobResults(states map[string]*autoPromotionItemState, action *model.PromotionAction, skus []string, job *model.AutomationJob) {
+	itemBySKU := make(map[string]model.AutomationJobItem, len(job.Items))
+	for _, item := range job.Items {
+		itemBySKU[item.SourceSKU] = item
+	}
 
+	for _, sku := range skus {
+		state := states[sku]
+		if state == nil {
+			continue
+		}
+		result := findActionResultBySourceActionID(state.ShopResults, action.ID, action.SourceActionID)
+		if result == nil {
+			continue
+		}
+
+		jobItem, exists := itemBySKU[sku]
+		if !exists {
 			result.Status = model.AutoPromotionItemStatusFailed
-			result.Error = firstNonEmpty(jobItem.StepReaddError, jobItem.StepExitError, jobItem.StepRepriceError, waitedJob.ErrorMessage, "店铺活动执行失败")
+			result.Error = "店铺活动未返回商品执行结果"
 			state.Blocked = true
+			continue
 		}
-	}
 
-	return nil
+		if jobItem.OverallStatus == model.AutomationStepStatusSuccess || jobItem.OverallStatus == model.AutomationStepStatusSkipped {
+			result.Status = model.AutoPromotionItemStatusSuccess
+			state.HasExecutedStep = true
+			continue
+		}
+
+		result.Status = model.AutoPromotionItemStatusFailed
+		result.Error = firstNonEmpty(jobItem.StepReaddError, jobItem.StepExitError, jobItem.StepRepriceError, job.ErrorMessage, "店铺活动执行失败")
+		state.Blocked = true
+	}
 }
 
 func toAutoPromotionConfigDTO(config *model.AutoPromotionConfig) (*dto.AutoPromotionConfigResponse, error) {
@@ -1023,6 +1082,9 @@ func toAutoPromotionConfigDTO(config *model.AutoPromotionConfig) (*dto.AutoPromo
 		OfficialActionIDs: decodeActionIDs(config.OfficialActionIDs),
 		ShopActionIDs:     decodeActionIDs(config.ShopActionIDs),
 		UpdatedAt:         config.UpdatedAt.Format("2006-01-02 15:04:05"),
+		TargetMode:        configTarget(config).Mode,
+		TargetOffsetDays:  config.TargetOffsetDays,
+		SelectionRules:    decodeSelectionRule(config.SelectionRules),
 	}, nil
 }
 
@@ -1042,6 +1104,8 @@ func toAutoPromotionRunSummaryDTO(run *model.AutoPromotionRun) *dto.AutoPromotio
 
 	return &dto.AutoPromotionRunSummaryResponse{
 		ID:              run.ID,
+		ConfigID:        run.ConfigID,
+		ConfigName:      decodeAutoPromotionConfigSnapshot(run.ConfigSnapshot).ConfigName,
 		TriggerMode:     run.TriggerMode,
 		TriggerDate:     run.TriggerDate.Format("2006-01-02"),
 		TargetDate:      run.TargetDate.Format("2006-01-02"),
@@ -1068,6 +1132,15 @@ func decodeAutoPromotionConfigSnapshot(raw datatypes.JSON) autoPromotionConfigSn
 	return snapshot
 }
 
+// snapshotSelectionRule 旧运行的快照没有选品规则，按默认规则返回
+func snapshotSelectionRule(snapshot autoPromotionConfigSnapshot) dto.AutoPromotionSelectionRule {
+	rule, err := normalizeSelectionRule(snapshot.SelectionRules)
+	if err != nil {
+		return *snapshot.SelectionRules
+	}
+	return rule
+}
+
 func decodeActionIDs(raw datatypes.JSON) []uint {
 	if len(raw) == 0 {
 		return []uint{}
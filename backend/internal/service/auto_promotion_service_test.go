@@ -73,3 +73,14 @@ func TestSelectEligibleItemsRequiresAllChosenActions(t *testing.T) {
 		t.Fatalf("expected both official and shop results to be recorded")
 	}
 }
+
+func TestAutoPromotionJobPriority(t *testing.T) {
+	t.Parallel()
+
+	if got := autoPromotionJobPriority(model.AutoPromotionTriggerModeScheduled); got != model.AutomationJobPriorityBackground {
+		t.Fatalf("scheduled priority = %d, want %d", got, model.AutomationJobPriorityBackground)
+	}
+	if got := autoPromotionJobPriority(model.AutoPromotionTriggerModeManual); got != model.AutomationJobPriorityNormal {
+		t.Fatalf("manual priority = %d, want %d", got, model.AutomationJobPriorityNormal)
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ozon-manager/internal/model"
+)
+
+const autoPromotionMaxTargetOffsetDays = 365
+
+// autoPromotionTarget 目标日期设置：固定日期或相对触发日期的天数
+type autoPromotionTarget struct {
+	Mode       string
+	OffsetDays int
+	Date       time.Time
+}
+
+// normalizeAutoPromotionTarget 校验目标日期设置；相对模式下 targetDate 可为空
+func normalizeAutoPromotionTarget(mode string, offsetDays int, targetDate string) (autoPromotionTarget, error) {
+	target := autoPromotionTarget{Mode: strings.TrimSpace(mode), OffsetDays: offsetDays}
+	if target.Mode == "" {
+		target.Mode = model.AutoPromotionTargetModeFixedDate
+	}
+
+	date, err := parseDateOnly(targetDate)
+	if err != nil {
+		return target, fmt.Errorf("invalid target_date, expected YYYY-MM-DD")
+	}
+	if date != nil {
+		target.Date = dateOnlyValue(*date)
+	}
+
+	switch target.Mode {
+	case model.AutoPromotionTargetModeFixedDate:
+		if date == nil {
+			return target, fmt.Errorf("invalid target_date, expected YYYY-MM-DD")
+		}
+		target.OffsetDays = 0
+	case model.AutoPromotionTargetModeDaysBefore:
+		if offsetDays < 0 || offsetDays > autoPromotionMaxTargetOffsetDays {
+			return target, fmt.Errorf("相对天数需在 0 到 %d 之间", autoPromotionMaxTargetOffsetDays)
+		}
+	case model.AutoPromotionTargetModeRecentDays:
+		if offsetDays < 1 || offsetDays > autoPromotionMaxTargetOffsetDays {
+			return target, fmt.Errorf("相对天数需在 1 到 %d 之间", autoPromotionMaxTargetOffsetDays)
+		}
+	default:
+		return target, fmt.Errorf("invalid target_mode, expected fixed_date, days_before or recent_days")
+	}
+	return target, nil
+}
+
+// resolve 按触发日期计算上架日期区间（含首尾）
+//   - fixed_date：目标日期当天
+//   - days_before：触发日前第 N 天
+//   - recent_days：触发日前 N 天至前 1 天
+func (t autoPromotionTarget) resolve(triggerDate time.Time) (time.Time, time.Time) {
+	day := dateOnlyValue(triggerDate)
+	switch t.Mode {
+	case model.AutoPromotionTargetModeDaysBefore:
+		target := day.AddDate(0, 0, -t.OffsetDays)
+		return target, target
+	case model.AutoPromotionTargetModeRecentDays:
+		return day.AddDate(0, 0, -t.OffsetDays), day.AddDate(0, 0, -1)
+	default:
+		return t.Date, t.Date
+	}
+}
+
+// excludeEnrolled 最近 N 天模式每天会重复覆盖同一批商品，已加入全部所选活动的不再选中
+func (t autoPromotionTarget) excludeEnrolled() bool {
+	return t.Mode == model.AutoPromotionTargetModeRecentDays
+}
+
+func configTarget(config *model.AutoPromotionConfig) autoPromotionTarget {
+	mode := strings.TrimSpace(config.TargetMode)
+	if mode == "" {
+		mode = model.AutoPromotionTargetModeFixedDate
+	}
+	return autoPromotionTarget{
+		Mode:       mode,
+		OffsetDays: config.TargetOffsetDays,
+		Date:       dateOnlyValue(config.TargetDate),
+	}
+}
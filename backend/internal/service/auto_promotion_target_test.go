@@ -0,0 +1,98 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+)
+
+func TestAutoPromotionTargetResolve(t *testing.T) {
+	t.Parallel()
+
+	trigger := time.Date(2026, 10, 19, 9, 5, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		target   autoPromotionTarget
+		wantFrom string
+		wantTo   string
+	}{
+		{
+			name:     "fixed date ignores trigger date",
+			target:   autoPromotionTarget{Mode: model.AutoPromotionTargetModeFixedDate, Date: time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)},
+			wantFrom: "2026-03-10",
+			wantTo:   "2026-03-10",
+		},
+		{
+			name:     "days before trigger date",
+			target:   autoPromotionTarget{Mode: model.AutoPromotionTargetModeDaysBefore, OffsetDays: 1},
+			wantFrom: "2026-10-18",
+			wantTo:   "2026-10-18",
+		},
+		{
+			name:     "recent days excludes trigger date",
+			target:   autoPromotionTarget{Mode: model.AutoPromotionTargetModeRecentDays, OffsetDays: 7},
+			wantFrom: "2026-10-12",
+			wantTo:   "2026-10-18",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, to := tt.target.resolve(trigger)
+			if got := from.Format("2006-01-02"); got != tt.wantFrom {
+				t.Fatalf("from = %s, want %s", got, tt.wantFrom)
+			}
+			if got := to.Format("2006-01-02"); got != tt.wantTo {
+				t.Fatalf("to = %s, want %s", got, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestNormalizeAutoPromotionTarget(t *testing.T) {
+	t.Parallel()
+
+	if _, err := normalizeAutoPromotionTarget("", 0, ""); err == nil {
+		t.Fatalf("fixed date mode without target_date should fail")
+	}
+	if _, err := normalizeAutoPromotionTarget(model.AutoPromotionTargetModeRecentDays, 0, ""); err == nil {
+		t.Fatalf("recent_days with zero days should fail")
+	}
+	if _, err := normalizeAutoPromotionTarget("weekly", 1, ""); err == nil {
+		t.Fatalf("unknown target_mode should fail")
+	}
+
+	target, err := normalizeAutoPromotionTarget(model.AutoPromotionTargetModeDaysBefore, 2, "")
+	if err != nil {
+		t.Fatalf("normalizeAutoPromotionTarget() error = %v", err)
+	}
+	if !target.Date.IsZero() || target.OffsetDays != 2 {
+		t.Fatalf("target = %+v, want relative target without date", target)
+	}
+}
+
+func TestEvaluateSelectionRuleExcludesEnrolledItemsInRecentDaysMode(t *testing.T) {
+	t.Parallel()
+
+	source := newRuleTestSource()
+	source.ShopActions = nil
+	source.OfficialExisting = []model.PromotionActionProduct{{PromotionActionID: 11, SourceSKU: "SKU-101"}}
+
+	rule := dto.AutoPromotionSelectionRule{CandidateMatch: autoPromotionCandidateMatchAll}
+	if selection := evaluateSelectionRule(rule, source); selection.States["SKU-101"] == nil {
+		t.Fatalf("enrolled item should stay selected as already_active by default")
+	}
+
+	source.ExcludeEnrolled = true
+	selection := evaluateSelectionRule(rule, source)
+	if selection.States["SKU-101"] != nil {
+		t.Fatalf("enrolled item should be excluded when ExcludeEnrolled is set")
+	}
+	if selection.States["SKU-202"] == nil {
+		t.Fatalf("item not yet enrolled should stay selected")
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"ozon-manager/internal/model"
+)
+
+// autoPromotionWaitError 运行需等待执行端任务结束：运行挂起为 waiting，任务结束回调唤醒后从已落库的进度继续
+type autoPromotionWaitError struct {
+	JobIDs []uint
+}
+
+func (e *autoPromotionWaitError) Error() string {
+	return fmt.Sprintf("等待执行端任务 %v 结束", e.JobIDs)
+}
+
+// suspendRun 运行挂起为 waiting，释放后台任务。挂起前等待的任务可能已经结束，挂起后立即检查一次
+func (s *AutoPromotionService) suspendRun(run *model.AutoPromotionRun, jobIDs []uint) {
+	payload, _ := json.Marshal(uniqueUints(jobIDs))
+	run.Status = model.AutoPromotionRunStatusWaiting
+	run.WaitingJobIDs = payload
+	if err := s.autoRepo.UpdateRun(run); err != nil {
+		return
+	}
+	s.resumeRunIfReady(run)
+}
+
+// handleAutomationJobFinished 执行端任务结束（含失败、取消）后唤醒等待它的运行
+func (s *AutoPromotionService) handleAutomationJobFinished(job *model.AutomationJob) {
+	runs, err := s.autoRepo.ListWaitingRuns(job.ShopID)
+	if err != nil {
+		return
+	}
+	for index := range runs {
+		for _, jobID := range decodeActionIDs(runs[index].WaitingJobIDs) {
+			if jobID == job.ID {
+				s.resumeRunIfReady(&runs[index])
+				break
+			}
+		}
+	}
+}
+
+// checkWaitingRuns 兜底检查等待中的运行：任务已全部结束但未被唤醒的重新提交，等待超时的置为失败
+func (s *AutoPromotionService) checkWaitingRuns(now time.Time) {
+	runs, err := s.autoRepo.ListWaitingRuns(0)
+	if err != nil {
+		return
+	}
+	for index := range runs {
+		run := &runs[index]
+		if s.resumeRunIfReady(run) {
+			continue
+		}
+		if run.UpdatedAt.Before(now.Add(-autoPromotionRunWaitTimeout)) {
+			_, _ = s.autoRepo.FailWaitingRun(run.ID, "等待执行端任务超时，可重试未完成的商品")
+		}
+	}
+}
+
+// resumeRunIfReady 等待的任务全部结束后将运行置回待执行并重新提交；
+// 回报、挂起与兜底检查可能同时触发，由 waiting -> pending 的原子切换保证只提交一次
+func (s *AutoPromotionService) resumeRunIfReady(run *model.AutoPromotionRun) bool {
+	for _, jobID := range decodeActionIDs(run.WaitingJobIDs) {
+		job, err := s.automationService.FindJobByIDAndShop(jobID, run.ShopID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil || !isAutomationJobFinished(job.Status) {
+			return false
+		}
+	}
+
+	resumed, err := s.autoRepo.ResumeWaitingRun(run.ID)
+	if err != nil || !resumed {
+		return false
+	}
+	run.Status = model.AutoPromotionRunStatusPending
+	run.WaitingJobIDs = nil
+	_ = s.dispatchRun(run, runInputFromSnapshot(run, run.TriggeredBy))
+	return true
+}
+
+// runInputFromSnapshot 由运行记录与配置快照还原运行参数，用于重试与等待结束后续跑
+func runInputFromSnapshot(run *model.AutoPromotionRun, triggeredBy *uint) autoPromotionRunInput {
+	snapshot := decodeAutoPromotionConfigSnapshot(run.ConfigSnapshot)
+	input := autoPromotionRunInput{
+		RunID:             run.ID,
+		ConfigID:          run.ConfigID,
+		ConfigName:        snapshot.ConfigName,
+		ShopID:            run.ShopID,
+		TriggeredBy:       triggeredBy,
+		TriggerMode:       run.TriggerMode,
+		TriggerDate:       run.TriggerDate,
+		TargetDate:        run.TargetDate,
+		ScheduleTime:      snapshot.ScheduleTime,
+		OfficialActionIDs: snapshot.OfficialActionIDs,
+		ShopActionIDs:     snapshot.ShopActionIDs,
+		SelectionRules:    snapshotSelectionRule(snapshot),
+		TargetMode:        snapshot.TargetMode,
+		TargetOffsetDays:  snapshot.TargetOffsetDays,
+	}
+	if from, err := parseDateOnly(snapshot.ListingDateFrom); err == nil && from != nil {
+		input.ListingDateFrom = *from
+	}
+	if to, err := parseDateOnly(snapshot.ListingDateTo); err == nil && to != nil {
+		input.ListingDateTo = *to
+	}
+	return input
+}
+
+// submitShopCandidateJobs 为每个店铺活动提交候选商品同步任务，续跑时复用运行记录中已提交的任务，返回活动 ID -> 同步任务
+func (s *AutoPromotionService) submitShopCandidateJobs(run *model.AutoPromotionRun, actions []model.PromotionAction, userID uint, priority int) (map[uint]*model.AutomationJob, error) {
+	jobs := make(map[uint]*model.AutomationJob, len(actions))
+	if len(actions) == 0 {
+		return jobs, nil
+	}
+	if s.automationService == nil {
+		return nil, fmt.Errorf("automation service unavailable")
+	}
+
+	jobIDs := decodeCandidateJobIDs(run.CandidateJobIDs)
+	submitted := false
+	for _, action := range actions {
+		if jobID, ok := jobIDs[action.ID]; ok {
+			if job, err := s.automationService.FindJobByIDAndShop(jobID, run.ShopID); err == nil {
+				jobs[action.ID] = job
+				continue
+			}
+		}
+		if userID == 0 {
+			shop, err := s.shopRepo.FindByID(run.ShopID)
+			if err != nil {
+				return nil, err
+			}
+			userID = shop.OwnerID
+		}
+		job, err := s.automationService.CreateSyncActionCandidatesJob(userID, run.ShopID, action.ID, action.SourceActionID, priority)
+		if err != nil {
+			return nil, fmt.Errorf("刷新店铺活动候选商品失败: %s: %w", displayActionName(action), err)
+		}
+		jobIDs[action.ID] = job.ID
+		jobs[action.ID] = job
+		submitted = true
+	}
+
+	if submitted {
+		// 先记下任务，挂起后续跑时接上这些任务而不是重复提交
+		run.CandidateJobIDs = encodeCandidateJobIDs(jobIDs)
+		if err := s.autoRepo.UpdateRun(run); err != nil {
+			return nil, err
+		}
+	}
+	return jobs, nil
+}
+
+// unfinishedAutomationJobIDs 返回尚未结束的任务 ID（升序）
+func unfinishedAutomationJobIDs(jobs map[uint]*model.AutomationJob) []uint {
+	ids := make([]uint, 0)
+	for _, job := range jobs {
+		if job != nil && !isAutomationJobFinished(job.Status) {
+			ids = append(ids, job.ID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func decodeCandidateJobIDs(raw datatypes.JSON) map[uint]uint {
+	jobIDs := make(map[uint]uint)
+	if len(raw) == 0 {
+		return jobIDs
+	}
+	_ = json.Unmarshal(raw, &jobIDs)
+	return jobIDs
+}
+
+func encodeCandidateJobIDs(jobIDs map[uint]uint) datatypes.JSON {
+	payload, _ := json.Marshal(jobIDs)
+	return payload
+}
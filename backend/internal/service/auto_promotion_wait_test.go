@@ -0,0 +1,59 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"ozon-manager/internal/model"
+)
+
+func TestUnfinishedAutomationJobIDs(t *testing.T) {
+	t.Parallel()
+
+	jobs := map[uint]*model.AutomationJob{
+		1: {ID: 12, Status: model.AutomationJobStatusRunning},
+		2: {ID: 5, Status: model.AutomationJobStatusPending},
+		3: {ID: 7, Status: model.AutomationJobStatusSuccess},
+		4: {ID: 8, Status: model.AutomationJobStatusCanceled},
+		5: {ID: 9, Status: model.AutomationJobStatusFailed},
+		6: nil,
+	}
+	if got, want := unfinishedAutomationJobIDs(jobs), []uint{5, 12}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unfinished = %v, want %v", got, want)
+	}
+}
+
+func TestCandidateJobIDsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	jobIDs := map[uint]uint{3: 101, 7: 102}
+	if got := decodeCandidateJobIDs(encodeCandidateJobIDs(jobIDs)); !reflect.DeepEqual(got, jobIDs) {
+		t.Fatalf("decoded = %v, want %v", got, jobIDs)
+	}
+	if got := decodeCandidateJobIDs(nil); len(got) != 0 {
+		t.Fatalf("decoded empty = %v", got)
+	}
+}
+
+func TestRunInputFromSnapshot(t *testing.T) {
+	t.Parallel()
+
+	userID := uint(9)
+	run := &model.AutoPromotionRun{
+		ID:             4,
+		ShopID:         2,
+		TriggerMode:    model.AutoPromotionTriggerModeScheduled,
+		ConfigSnapshot: []byte(`{"target_date":"2026-10-19","official_action_ids":[1],"shop_action_ids":[2,3],"listing_date_from":"2026-10-12","listing_date_to":"2026-10-18"}`),
+	}
+
+	input := runInputFromSnapshot(run, &userID)
+	if input.RunID != 4 || input.ShopID != 2 || input.TriggeredBy != &userID {
+		t.Fatalf("input = %+v", input)
+	}
+	if !reflect.DeepEqual(input.ShopActionIDs, []uint{2, 3}) {
+		t.Fatalf("shop actions = %v", input.ShopActionIDs)
+	}
+	if input.ListingDateFrom.Format("2006-01-02") != "2026-10-12" || input.ListingDateTo.Format("2006-01-02") != "2026-10-18" {
+		t.Fatalf("listing range = %v - %v", input.ListingDateFrom, input.ListingDateTo)
+	}
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ozon-manager/internal/model"
+	"ozon-manager/pkg/artifactstore"
+)
+
+const (
+	artifactInlineScheme    = "inline"
+	artifactJanitorInterval = time.Hour
+	artifactJanitorBatch    = 200
+	artifactStoreTimeout    = 30 * time.Second
+)
+
+// 产物上传的调用方错误，处理器据此区分 4xx 与存储故障
+var (
+	ErrArtifactUploadInvalid   = errors.New("invalid artifact upload")
+	ErrArtifactUploaderUnknown = errors.New("uploader not registered")
+	ErrArtifactJobForbidden    = errors.New("job is not assigned to this uploader")
+	ErrArtifactJobNotRunning   = errors.New("job is not running")
+)
+
+// AutomationArtifactOptions 产物存储策略
+type AutomationArtifactOptions struct {
+	// InlineMaxBytes 回报 meta 不超过该大小时直接存数据库，超过则写入存储后端
+	InlineMaxBytes int
+	MaxUploadBytes int64
+	// Retention 任务结束后产物的保留时长，0 表示不清理
+	Retention time.Duration
+}
+
+// AutomationArtifactUpload 执行端上传的产物文件
+type AutomationArtifactUpload struct {
+	JobID        uint
+	ArtifactType string
+	FileName     string
+	ContentType  string
+	Data         []byte
+	// SHA256 客户端计算的校验和，非空时与服务端计算结果比对
+	SHA256 string
+}
+
+// ConfigureArtifacts 设置产物存储后端；未配置时产物仍以 inline 方式写入数据库，且不支持文件上传
+func (s *AutomationService) ConfigureArtifacts(store artifactstore.Store, options AutomationArtifactOptions) {
+	s.artifactStore = store
+	s.artifactOptions = options
+}
+
+// MaxArtifactUploadBytes 单个上传产物的大小上限，0 表示不限制
+func (s *AutomationService) MaxArtifactUploadBytes() int64 {
+	return s.artifactOptions.MaxUploadBytes
+}
+
+// storeArtifact 保存 JSON 产物：小产物直接写入 meta，大产物写入存储后端、数据库只保留索引
+func (s *AutomationService) storeArtifact(jobID uint, artifactType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if s.artifactStore == nil || s.artifactOptions.InlineMaxBytes <= 0 || len(data) <= s.artifactOptions.InlineMaxBytes {
+		return s.automationRepo.CreateArtifact(jobID, artifactType, payload)
+	}
+	_, err = s.putArtifact(jobID, artifactType, "payload.json", "application/json", data)
+	return err
+}
+
+func (s *AutomationService) putArtifact(jobID uint, artifactType, fileName, contentType string, data []byte) (*model.AutomationArtifact, error) {
+	checksum := artifactstore.Checksum(data)
+	key := artifactKey(jobID, artifactType, checksum, fileName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), artifactStoreTimeout)
+	defer cancel()
+	if err := s.artifactStore.Put(ctx, key, data, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store artifact: %w", err)
+	}
+
+	artifact := &model.AutomationArtifact{
+		JobID:        jobID,
+		ArtifactType: artifactType,
+		StoragePath:  artifactstore.URI(s.artifactStore, key),
+		Checksum:     checksum,
+		ContentType:  contentType,
+		SizeBytes:    int64(len(data)),
+	}
+	if err := s.automationRepo.CreateArtifactRecord(artifact); err != nil {
+		_ = s.artifactStore.Delete(ctx, key)
+		return nil, err
+	}
+	return artifact, nil
+}
+
+// GetLatestArtifact 返回任务最新的指定类型产物；存于外部存储的 JSON 产物会读回 Meta 并校验
+func (s *AutomationService) GetLatestArtifact(jobID uint, artifactType string) (*model.AutomationArtifact, error) {
+	artifact, err := s.automationRepo.FindLatestArtifactByJob(jobID, artifactType)
+	if err != nil {
+		return nil, err
+	}
+	if len(artifact.Meta) > 0 || !isJSONContentType(artifact.ContentType) {
+		return artifact, nil
+	}
+	data, err := s.readArtifact(artifact)
+	if err != nil {
+		return nil, err
+	}
+	artifact.Meta = data
+	return artifact, nil
+}
+
+// UploadAgentArtifact 本地执行端上传产物，任务需分配给该执行端且仍在执行中
+func (s *AutomationService) UploadAgentArtifact(agentKey string, upload *AutomationArtifactUpload) (*model.AutomationArtifact, error) {
+	agent, err := s.automationRepo.FindAgentByKey(strings.TrimSpace(agentKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: agent %s", ErrArtifactUploaderUnknown, strings.TrimSpace(agentKey))
+	}
+	return s.uploadArtifact(agent.ID, 0, upload)
+}
+
+// UploadExtensionArtifact 浏览器插件上传产物
+func (s *AutomationService) UploadExtensionArtifact(userID, shopID uint, extensionID string, upload *AutomationArtifactUpload) (*model.AutomationArtifact, error) {
+	agent, err := s.automationRepo.FindAgentByKey(extensionAgentKey(userID, shopID, extensionID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: extension %s", ErrArtifactUploaderUnknown, extensionID)
+	}
+	return s.uploadArtifact(agent.ID, shopID, upload)
+}
+
+func (s *AutomationService) uploadArtifact(agentID, shopID uint, upload *AutomationArtifactUpload) (*model.AutomationArtifact, error) {
+	if s.artifactStore == nil {
+		return nil, fmt.Errorf("artifact store is not configured")
+	}
+	if len(upload.Data) == 0 {
+		return nil, fmt.Errorf("%w: artifact file is empty", ErrArtifactUploadInvalid)
+	}
+	if s.artifactOptions.MaxUploadBytes > 0 && int64(len(upload.Data)) > s.artifactOptions.MaxUploadBytes {
+		return nil, fmt.Errorf("%w: artifact exceeds max upload size of %d bytes", ErrArtifactUploadInvalid, s.artifactOptions.MaxUploadBytes)
+	}
+	if err := artifactstore.VerifyChecksum(upload.Data, upload.SHA256); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrArtifactUploadInvalid, err)
+	}
+
+	job, err := s.automationRepo.FindJobByID(upload.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: job not found", ErrArtifactUploadInvalid)
+	}
+	if shopID != 0 && job.ShopID != shopID {
+		return nil, fmt.Errorf("%w: job does not belong to shop", ErrArtifactJobForbidden)
+	}
+	if job.Status != model.AutomationJobStatusRunning {
+		return nil, ErrArtifactJobNotRunning
+	}
+	if job.AssignedAgentID == nil || *job.AssignedAgentID != agentID {
+		return nil, ErrArtifactJobForbidden
+	}
+
+	contentType := strings.TrimSpace(upload.ContentType)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	artifact, err := s.putArtifact(job.ID, upload.ArtifactType, upload.FileName, contentType, upload.Data)
+	if err != nil {
+		return nil, err
+	}
+	_ = s.createSimpleEvent(job.ID, "artifact_uploaded", fmt.Sprintf("artifact %s uploaded (%d bytes)", upload.ArtifactType, len(upload.Data)), nil)
+	return artifact, nil
+}
+
+func (s *AutomationService) ListJobArtifacts(shopID, jobID uint) ([]model.AutomationArtifact, error) {
+	if _, err := s.automationRepo.FindJobByIDAndShop(jobID, shopID); err != nil {
+		return nil, err
+	}
+	return s.automationRepo.ListArtifactsByJob(jobID)
+}
+
+// DownloadArtifact 读取产物内容并校验 SHA-256
+func (s *AutomationService) DownloadArtifact(shopID, artifactID uint) (*model.AutomationArtifact, []byte, error) {
+	artifact, err := s.automationRepo.FindArtifactByIDAndShop(artifactID, shopID)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := s.readArtifact(artifact)
+	if err != nil {
+		return nil, nil, err
+	}
+	return artifact, data, nil
+}
+
+func (s *AutomationService) readArtifact(artifact *model.AutomationArtifact) ([]byte, error) {
+	scheme, key, ok := artifactstore.ParseURI(artifact.StoragePath)
+	if !ok || scheme == artifactInlineScheme {
+		return artifact.Meta, nil
+	}
+	if s.artifactStore == nil || s.artifactStore.Scheme() != scheme {
+		return nil, fmt.Errorf("artifact storage %s is not available", scheme)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), artifactStoreTimeout)
+	defer cancel()
+	data, err := s.artifactStore.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := artifactstore.VerifyChecksum(data, artifact.Checksum); err != nil {
+		return nil, fmt.Errorf("artifact #%d is corrupted: %w", artifact.ID, err)
+	}
+	return data, nil
+}
+
+// StartArtifactJanitor 启动产物保留期清理，每小时删除已结束任务中过期的产物
+func (s *AutomationService) StartArtifactJanitor() {
+	if s.artifactOptions.Retention <= 0 {
+		return
+	}
+	s.loops.Go(artifactJanitorInterval, func(now time.Time) {
+		s.CleanupExpiredArtifacts(now)
+	})
+}
+
+// CleanupExpiredArtifacts 删除超过保留期的产物（存储对象与数据库记录），返回删除数量
+func (s *AutomationService) CleanupExpiredArtifacts(now time.Time) int {
+	artifacts, err := s.automationRepo.ListExpiredArtifacts(now.Add(-s.artifactOptions.Retention), artifactJanitorBatch)
+	if err != nil {
+		return 0
+	}
+	deleted := 0
+	for _, artifact := range artifacts {
+		scheme, key, ok := artifactstore.ParseURI(artifact.StoragePath)
+		if ok && scheme != artifactInlineScheme {
+			if s.artifactStore == nil || s.artifactStore.Scheme() != scheme {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), artifactStoreTimeout)
+			err := s.artifactStore.Delete(ctx, key)
+			cancel()
+			if err != nil && !errors.Is(err, artifactstore.ErrNotFound) {
+				continue
+			}
+		}
+		if err := s.automationRepo.DeleteArtifact(artifact.ID); err == nil {
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// artifactKey 生成存储 key：jobs/<job_id>/<type>/<checksum 前缀>-<文件名>
+func artifactKey(jobID uint, artifactType, checksum, fileName string) string {
+	name := sanitizeArtifactName(fileName)
+	if name == "" {
+		name = "artifact"
+	}
+	prefix := checksum
+	if len(prefix) > 16 {
+		prefix = prefix[:16]
+	}
+	return fmt.Sprintf("jobs/%d/%s/%s-%s", jobID, sanitizeArtifactName(artifactType), prefix, name)
+}
+
+func sanitizeArtifactName(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if index := strings.LastIndexAny(raw, "/\\"); index >= 0 {
+		raw = raw[index+1:]
+	}
+	var builder strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			builder.WriteRune(r)
+		default:
+			builder.WriteRune('_')
+		}
+	}
+	name := strings.Trim(builder.String(), ".")
+	if len(name) > 100 {
+		name = name[len(name)-100:]
+	}
+	return name
+}
+
+func isJSONContentType(contentType string) bool {
+	return contentType == "" || strings.HasPrefix(strings.ToLower(contentType), "application/json")
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+
+	"ozon-manager/internal/model"
+	"ozon-manager/pkg/artifactstore"
+)
+
+func TestArtifactKeySanitizesNames(t *testing.T) {
+	t.Parallel()
+
+	checksum := artifactstore.Checksum([]byte("shot"))
+	key := artifactKey(12, "screenshot", checksum, `C:\tmp\..\ошибка 1.png`)
+	want := "jobs/12/screenshot/" + checksum[:16] + "-_______1.png"
+	if key != want {
+		t.Fatalf("artifactKey() = %s, want %s", key, want)
+	}
+
+	if key := artifactKey(3, "payload", checksum, ""); key != "jobs/3/payload/"+checksum[:16]+"-artifact" {
+		t.Fatalf("artifactKey() with empty name = %s", key)
+	}
+}
+
+func TestReadArtifactReturnsInlineMeta(t *testing.T) {
+	t.Parallel()
+
+	service := &AutomationService{}
+	artifact := &model.AutomationArtifact{StoragePath: "inline://agent", Meta: []byte(`{"a":1}`)}
+	data, err := service.readArtifact(artifact)
+	if err != nil || string(data) != `{"a":1}` {
+		t.Fatalf("readArtifact() = %q, %v", data, err)
+	}
+
+	external := &model.AutomationArtifact{StoragePath: "s3://jobs/1/a.json"}
+	if _, err := service.readArtifact(external); err == nil {
+		t.Fatalf("readArtifact() without configured store should fail")
+	}
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+)
+
+const agentOnlineWindow = 90 * time.Second
+
+// agentCapabilities 执行端在心跳/注册时声明的能力。
+// 未声明 job_types / shop_ids 的旧版执行端视为支持全部任务类型与店铺，max_concurrency<=0 视为不限并发。
+type agentCapabilities struct {
+	Kind           string   `json:"kind"`
+	Version        string   `json:"version"`
+	JobTypes       []string `json:"job_types"`
+	ShopIDs        []uint   `json:"shop_ids"`
+	MaxConcurrency int      `json:"max_concurrency"`
+	// ProtocolVersion 心跳/注册时协商出的协议版本，旧版执行端未记录时为 0（按 v1 处理）
+	ProtocolVersion int `json:"protocol_version"`
+}
+
+func parseAgentCapabilities(raw []byte) agentCapabilities {
+	caps := agentCapabilities{}
+	if len(raw) == 0 {
+		return caps
+	}
+	_ = json.Unmarshal(raw, &caps)
+	caps.Kind = strings.TrimSpace(caps.Kind)
+	caps.Version = strings.TrimSpace(caps.Version)
+
+	jobTypes := make([]string, 0, len(caps.JobTypes))
+	for _, jobType := range caps.JobTypes {
+		if trimmed := strings.TrimSpace(jobType); trimmed != "" {
+			jobTypes = append(jobTypes, trimmed)
+		}
+	}
+	caps.JobTypes = uniqueStrings(jobTypes)
+	caps.ShopIDs = uniqueUints(caps.ShopIDs)
+	return caps
+}
+
+// supportedJobTypes 返回执行端声明且服务端认可的任务类型
+func (c agentCapabilities) supportedJobTypes(serverTypes []string) []string {
+	if len(c.JobTypes) == 0 {
+		return serverTypes
+	}
+	allowed := make(map[string]struct{}, len(serverTypes))
+	for _, jobType := range serverTypes {
+		allowed[jobType] = struct{}{}
+	}
+	result := make([]string, 0, len(c.JobTypes))
+	for _, jobType := range c.JobTypes {
+		if _, ok := allowed[jobType]; ok {
+			result = append(result, jobType)
+		}
+	}
+	return result
+}
+
+func (c agentCapabilities) supportsJobType(jobType string) bool {
+	if len(c.JobTypes) == 0 {
+		return true
+	}
+	for _, item := range c.JobTypes {
+		if item == jobType {
+			return true
+		}
+	}
+	return false
+}
+
+func (c agentCapabilities) servesShop(shopID uint) bool {
+	if len(c.ShopIDs) == 0 {
+		return true
+	}
+	for _, item := range c.ShopIDs {
+		if item == shopID {
+			return true
+		}
+	}
+	return false
+}
+
+func (c agentCapabilities) hasFreeSlot(running int) bool {
+	return c.MaxConcurrency <= 0 || running < c.MaxConcurrency
+}
+
+// AgentDeclaredCapabilities 返回执行端声明的能力（供 Agent 状态列表展示）
+func AgentDeclaredCapabilities(agent *model.AutomationAgent) dto.AgentCapabilities {
+	if agent == nil {
+		return dto.AgentCapabilities{}
+	}
+	caps := parseAgentCapabilities(agent.Capabilities)
+	return dto.AgentCapabilities{
+		Kind:            caps.Kind,
+		Version:         caps.Version,
+		JobTypes:        caps.JobTypes,
+		ShopIDs:         caps.ShopIDs,
+		MaxConcurrency:  caps.MaxConcurrency,
+		ProtocolVersion: caps.ProtocolVersion,
+	}
+}
+
+func (s *AutomationService) CountRunningJobsByAgent() (map[uint]int, error) {
+	return s.automationRepo.CountRunningJobsByAgent()
+}
+
+func isExtensionAgentKey(agentKey string) bool {
+	return strings.HasPrefix(agentKey, "ext:")
+}
+
+// extensionAgentShopID 解析插件执行端 key（ext:<user_id>:<shop_id>:<extension_id>）中的店铺ID
+func extensionAgentShopID(agentKey string) (uint, bool) {
+	parts := strings.SplitN(agentKey, ":", 4)
+	if len(parts) != 4 || parts[0] != "ext" {
+		return 0, false
+	}
+	shopID, err := strconv.ParseUint(parts[2], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(shopID), true
+}
+
+func isExtensionAgentForShop(agentKey string, shopID uint) bool {
+	agentShopID, ok := extensionAgentShopID(agentKey)
+	return ok && agentShopID == shopID
+}
+
+func isAgentOnline(agent *model.AutomationAgent, now time.Time) bool {
+	return agent != nil && agent.LastHeartbeatAt != nil && now.Sub(*agent.LastHeartbeatAt) <= agentOnlineWindow
+}
+
+// agentIneligibleReason 判断某个执行端能否领取任务，返回空字符串表示可以领取
+func agentIneligibleReason(job *model.AutomationJob, agent *model.AutomationAgent, caps agentCapabilities, mode string, hasOnlineExtension bool, running int, now time.Time) string {
+	if !isAgentOnline(agent, now) {
+		return "执行端离线"
+	}
+	if isExtensionAgentKey(agent.AgentKey) {
+		if !shouldExtensionAcquire(mode) {
+			return fmt.Sprintf("店铺执行引擎为 %s，不允许插件领取", mode)
+		}
+		if !containsString(extensionSupportedJobTypes(), job.JobType) {
+			return fmt.Sprintf("插件不支持任务类型 %s", job.JobType)
+		}
+	} else {
+		if !shouldAgentAcquire(mode, hasOnlineExtension) {
+			if mode == model.ShopExecutionEngineAuto {
+				return "店铺为 auto 模式且插件在线，优先由插件执行"
+			}
+			return fmt.Sprintf("店铺执行引擎为 %s，不允许 Agent 领取", mode)
+		}
+		if !containsString(agentSupportedJobTypes(), job.JobType) {
+			return fmt.Sprintf("服务端不允许 Agent 执行任务类型 %s", job.JobType)
+		}
+	}
+	if !caps.supportsJobType(job.JobType) {
+		return fmt.Sprintf("未声明支持任务类型 %s", job.JobType)
+	}
+	if !caps.servesShop(job.ShopID) {
+		return fmt.Sprintf("未声明服务店铺 %d", job.ShopID)
+	}
+	if !caps.hasFreeSlot(running) {
+		return fmt.Sprintf("已达最大并发 %d/%d", running, caps.MaxConcurrency)
+	}
+	return ""
+}
+
+// DiagnosePendingJobs 说明店铺内每个待执行任务当前可由哪些执行端领取，以及其他执行端不可领取的原因
+func (s *AutomationService) DiagnosePendingJobs(shopID uint) ([]dto.PendingJobDiagnosis, error) {
+	jobs, err := s.automationRepo.ListPendingJobsByShop(shopID, 100)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return []dto.PendingJobDiagnosis{}, nil
+	}
+
+	mode, err := s.resolveShopExecutionEngineMode(shopID)
+	if err != nil {
+		return nil, err
+	}
+	agents, err := s.automationRepo.ListAgents()
+	if err != nil {
+		return nil, err
+	}
+	runningByAgent, err := s.automationRepo.CountRunningJobsByAgent()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	hasOnlineExtension := false
+	for index := range agents {
+		agent := &agents[index]
+		if isExtensionAgentForShop(agent.AgentKey, shopID) && isAgentOnline(agent, now) {
+			hasOnlineExtension = true
+			break
+		}
+	}
+
+	result := make([]dto.PendingJobDiagnosis, 0, len(jobs))
+	for index := range jobs {
+		job := &jobs[index]
+		diagnosis := dto.PendingJobDiagnosis{
+			JobID:         job.ID,
+			JobType:       job.JobType,
+			Priority:      job.Priority,
+			ExecutionMode: mode,
+			CreatedAt:     job.CreatedAt.Format("2006-01-02 15:04:05"),
+			Eligible:      []string{},
+			Ineligible:    []dto.ExecutorIneligibility{},
+		}
+		// 店铺熔断或暂停写入时任何执行端都不会领取
+		if blockErr := s.shopGuard.CheckDispatch(shopID, job.JobType); blockErr != nil {
+			diagnosis.Summary = blockErr.Error()
+			result = append(result, diagnosis)
+			continue
+		}
+
+		for agentIndex := range agents {
+			agent := &agents[agentIndex]
+			// 其他店铺的插件与该任务无关，不参与诊断
+			if isExtensionAgentKey(agent.AgentKey) && !isExtensionAgentForShop(agent.AgentKey, shopID) {
+				continue
+			}
+			caps := parseAgentCapabilities(agent.Capabilities)
+			reason := agentIneligibleReason(job, agent, caps, mode, hasOnlineExtension, runningByAgent[agent.ID], now)
+			if reason == "" {
+				diagnosis.Eligible = append(diagnosis.Eligible, agent.AgentKey)
+				continue
+			}
+			diagnosis.Ineligible = append(diagnosis.Ineligible, dto.ExecutorIneligibility{
+				AgentKey: agent.AgentKey,
+				Name:     agent.Name,
+				Reason:   reason,
+			})
+		}
+
+		if len(diagnosis.Eligible) == 0 {
+			diagnosis.Summary = "没有可领取该任务的执行端"
+			if len(agents) == 0 {
+				diagnosis.Summary = "尚无任何执行端注册"
+			}
+		}
+		result = append(result, diagnosis)
+	}
+
+	return result, nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"ozon-manager/internal/model"
+)
+
+func TestParseAgentCapabilitiesLegacyPayload(t *testing.T) {
+	t.Parallel()
+
+	caps := parseAgentCapabilities([]byte(`{"mode":"mock","version":"m2.5","executor":"mock"}`))
+	if caps.Version != "m2.5" {
+		t.Fatalf("version = %q, want m2.5", caps.Version)
+	}
+	if !caps.supportsJobType(model.AutomationJobTypeSyncShopActions) {
+		t.Fatalf("legacy agent should support every job type")
+	}
+	if !caps.servesShop(42) {
+		t.Fatalf("legacy agent should serve every shop")
+	}
+	if !caps.hasFreeSlot(100) {
+		t.Fatalf("legacy agent should not be concurrency limited")
+	}
+}
+
+func TestAgentCapabilitiesSupportedJobTypesIntersectsServerTypes(t *testing.T) {
+	t.Parallel()
+
+	caps := parseAgentCapabilities([]byte(`{"job_types":["sync_shop_actions"," unknown_type ","sync_shop_actions"]}`))
+	got := caps.supportedJobTypes(agentSupportedJobTypes())
+	if len(got) != 1 || got[0] != model.AutomationJobTypeSyncShopActions {
+		t.Fatalf("supportedJobTypes() = %v", got)
+	}
+}
+
+func TestExtensionAgentShopID(t *testing.T) {
+	t.Parallel()
+
+	if shopID, ok := extensionAgentShopID("ext:5:3:abc"); !ok || shopID != 3 {
+		t.Fatalf("extensionAgentShopID() = %d, %v", shopID, ok)
+	}
+	if isExtensionAgentForShop("ext:5:3:abc", 5) {
+		t.Fatalf("user id segment must not match shop id")
+	}
+	if _, ok := extensionAgentShopID("local-agent-001"); ok {
+		t.Fatalf("plain agent key should not parse as extension")
+	}
+}
+
+func TestAgentIneligibleReason(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	fresh := now.Add(-10 * time.Second)
+	stale := now.Add(-5 * time.Minute)
+	job := &model.AutomationJob{ID: 1, ShopID: 7, JobType: model.AutomationJobTypeSyncActionProducts}
+
+	tests := []struct {
+		name       string
+		agent      model.AutomationAgent
+		caps       string
+		mode       string
+		running    int
+		wantReason bool
+	}{
+		{name: "offline agent", agent: model.AutomationAgent{AgentKey: "a1", LastHeartbeatAt: &stale}, mode: model.ShopExecutionEngineAgent, wantReason: true},
+		{name: "eligible legacy agent", agent: model.AutomationAgent{AgentKey: "a1", LastHeartbeatAt: &fresh}, mode: model.ShopExecutionEngineAgent},
+		{name: "job type not declared", agent: model.AutomationAgent{AgentKey: "a1", LastHeartbeatAt: &fresh}, caps: `{"job_types":["sync_shop_actions"]}`, mode: model.ShopExecutionEngineAgent, wantReason: true},
+		{name: "shop not declared", agent: model.AutomationAgent{AgentKey: "a1", LastHeartbeatAt: &fresh}, caps: `{"shop_ids":[8]}`, mode: model.ShopExecutionEngineAgent, wantReason: true},
+		{name: "concurrency exhausted", agent: model.AutomationAgent{AgentKey: "a1", LastHeartbeatAt: &fresh}, caps: `{"max_concurrency":1}`, mode: model.ShopExecutionEngineAgent, running: 1, wantReason: true},
+		{name: "extension mode blocks agent", agent: model.AutomationAgent{AgentKey: "a1", LastHeartbeatAt: &fresh}, mode: model.ShopExecutionEngineExtension, wantReason: true},
+		{name: "agent mode blocks extension", agent: model.AutomationAgent{AgentKey: "ext:1:7:x", LastHeartbeatAt: &fresh}, mode: model.ShopExecutionEngineAgent, wantReason: true},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			caps := parseAgentCapabilities([]byte(tc.caps))
+			reason := agentIneligibleReason(job, &tc.agent, caps, tc.mode, false, tc.running, now)
+			if tc.wantReason && reason == "" {
+				t.Fatalf("expected ineligible reason, got eligible")
+			}
+			if !tc.wantReason && reason != "" {
+				t.Fatalf("unexpected reason: %s", reason)
+			}
+		})
+	}
+}
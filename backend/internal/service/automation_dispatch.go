@@ -0,0 +1,40 @@
+package service
+
+import (
+	"encoding/json"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+	"ozon-manager/pkg/automation/protocol"
+)
+
+// jobMetaArtifactTypes 任务类型 -> 创建任务时写入的 meta 工件类型，下发任务时随 payload 一并返回
+var jobMetaArtifactTypes = map[string]string{
+	model.AutomationJobTypeSyncActionCandidates: "sync_action_candidates_meta",
+	model.AutomationJobTypeSyncActionProducts:   "sync_action_products_meta",
+	model.AutomationJobTypeRemoveRepriceReadd:   "remove_reprice_readd_meta",
+	model.AutomationJobTypeShopActionDeclare:    "shop_action_meta",
+	model.AutomationJobTypeShopActionRemove:     "shop_action_meta",
+	model.AutomationJobTypePromoUnifiedEnroll:   "promo_unified_meta",
+	model.AutomationJobTypePromoUnifiedRemove:   "promo_unified_meta",
+}
+
+// BuildJobPayload 按当前协议版本组装下发给 Agent / 插件的任务
+func (s *AutomationService) BuildJobPayload(job *model.AutomationJob, items []dto.AutomationJobCreateItem) *dto.AgentJobPayload {
+	meta := map[string]interface{}{}
+	if artifactType, ok := jobMetaArtifactTypes[job.JobType]; ok {
+		if artifact, err := s.GetLatestArtifact(job.ID, artifactType); err == nil {
+			_ = json.Unmarshal(artifact.Meta, &meta)
+		}
+	}
+	return &dto.AgentJobPayload{
+		JobID:           job.ID,
+		ShopID:          job.ShopID,
+		JobType:         job.JobType,
+		DryRun:          job.DryRun,
+		RateLimit:       job.RateLimit,
+		Items:           items,
+		Meta:            meta,
+		ProtocolVersion: protocol.Version,
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"regexp"
 	"strings"
 
 	"ozon-manager/internal/model"
@@ -29,3 +30,75 @@ func firstNonEmptyServiceTrimmed(values ...string) string {
 	}
 	return ""
 }
+
+// 明细失败分类
+const (
+	automationErrorRetryable = "retryable"
+	automationErrorPermanent = "permanent"
+	automationErrorUnknown   = "unknown"
+)
+
+// 可重试：超时、网络、登录失效、限流、页面/浏览器异常等瞬时故障
+var automationRetryableErrorMarkers = []string{
+	"timeout", "timed out", "超时",
+	"net::", "econnreset", "econnrefused", "socket hang up", "network", "网络",
+	"not logged in", "login required", "please login", "please log in", "未登录", "登录失效", "登录已过期",
+	"too many requests", "rate limit", "限流",
+	"bad gateway", "service unavailable",
+	"target closed", "browser has been closed", "navigation",
+	"element not found", "waiting for selector", "页面元素未找到",
+}
+
+// automationRetryableStatusPattern 限流与网关类 HTTP 状态码，须带 status / HTTP 前缀，避免误匹配 SKU、价格等数字
+var automationRetryableStatusPattern = regexp.MustCompile(`(?:status(?: code)?|http(?:/[\d.]+)?)\s*[:=]?\s*(?:429|502|503|504)\b`)
+
+// 不可重试：数据本身不满足条件，重试不会改变结果。只收录指向商品/活动/价格的短语，
+// 页面元素找不到等泛化的 not found / invalid 属于瞬时故障
+var automationPermanentErrorMarkers = []string{
+	"not in action", "not eligible", "unsupported", "not supported",
+	"sku not found", "product not found", "offer not found", "action not found",
+	"invalid sku", "invalid product", "invalid offer", "invalid price", "invalid action",
+	"不在活动", "不符合", "不支持",
+	"商品不存在", "活动不存在", "未找到商品", "未找到活动",
+	"价格无效", "无效的价格", "无效的商品",
+}
+
+// classifyAutomationError 将明细错误信息归类为可重试、不可重试或未知
+func classifyAutomationError(message string) string {
+	normalized := strings.ToLower(strings.TrimSpace(message))
+	if normalized == "" {
+		return automationErrorUnknown
+	}
+	for _, marker := range automationPermanentErrorMarkers {
+		if strings.Contains(normalized, marker) {
+			return automationErrorPermanent
+		}
+	}
+	for _, marker := range automationRetryableErrorMarkers {
+		if strings.Contains(normalized, marker) {
+			return automationErrorRetryable
+		}
+	}
+	if automationRetryableStatusPattern.MatchString(normalized) {
+		return automationErrorRetryable
+	}
+	return automationErrorUnknown
+}
+
+// recordExecutorOutcome 将执行端回报的任务结果计入店铺熔断统计：
+// 有明细成功说明执行端可用，整单失败时按失败原因归类计数
+func (s *AutomationService) recordExecutorOutcome(jobID uint) {
+	if s.shopGuard == nil {
+		return
+	}
+	job, err := s.automationRepo.FindJobByID(jobID)
+	if err != nil {
+		return
+	}
+	switch job.Status {
+	case model.AutomationJobStatusSuccess, model.AutomationJobStatusPartialSuccess:
+		s.shopGuard.RecordSuccess(job.ShopID, model.ShopFailureSourceExecutor)
+	case model.AutomationJobStatusFailed:
+		s.shopGuard.RecordFailure(job.ShopID, model.ShopFailureSourceExecutor, automationJobFailureMessage(job, ""))
+	}
+}
@@ -45,3 +45,31 @@ func TestAutomationJobFailureMessageFallsBackWhenNoErrors(t *testing.T) {
 		t.Fatalf("automationJobFailureMessage() = %q", got)
 	}
 }
+
+func TestClassifyAutomationError(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"page.goto: Timeout 60000ms exceeded":  automationErrorRetryable,
+		"not logged in: please login manually": automationErrorRetryable,
+		"Request failed with status code 503":  automationErrorRetryable,
+		"HTTP 429: Too Many Requests":          automationErrorRetryable,
+		"ozon api status: 429":                 automationErrorRetryable,
+		"element not found: button.submit":     automationErrorRetryable,
+		"SKU not in action":                    automationErrorPermanent,
+		"商品不在活动中":                              automationErrorPermanent,
+		"sku not found in seller catalog":      automationErrorPermanent,
+		"invalid price for sku 42":             automationErrorPermanent,
+		"插件不支持该任务类型: sync_action_candidates":   automationErrorPermanent,
+		"sku 4291 rejected":                    automationErrorUnknown,
+		"price 1503.00 exceeds max":            automationErrorUnknown,
+		"invalid session state":                automationErrorUnknown,
+		"something odd happened":               automationErrorUnknown,
+		"   ":                                  automationErrorUnknown,
+	}
+	for message, want := range cases {
+		if got := classifyAutomationError(message); got != want {
+			t.Fatalf("classifyAutomationError(%q) = %s, want %s", message, got, want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+
+	"ozon-manager/internal/model"
+	"ozon-manager/pkg/automation/protocol"
+)
+
+// TestReportSnapshotsMatchProtocolSchema 执行端回报的快照结构须在 schema/v1.json 中声明
+func TestReportSnapshotsMatchProtocolSchema(t *testing.T) {
+	t.Parallel()
+
+	snapshots := map[string]interface{}{
+		"ShopActionSnapshot": shopActionSnapshot{},
+		"ActionItemSnapshot": shopActionProductSnapshotItem{},
+	}
+	for definition, value := range snapshots {
+		for _, problem := range protocol.CheckStructFields(definition, value) {
+			t.Error(problem)
+		}
+	}
+	for _, problem := range protocol.CheckStructFields("ActionItemSnapshot", autoPromotionCandidateSnapshotItem{}) {
+		t.Error(problem)
+	}
+}
+
+func TestParseAgentCapabilitiesProtocolVersion(t *testing.T) {
+	t.Parallel()
+
+	caps := parseAgentCapabilities([]byte(`{"kind":"go-agent","protocol_version":1}`))
+	if caps.ProtocolVersion != 1 {
+		t.Fatalf("protocol_version = %d, want 1", caps.ProtocolVersion)
+	}
+	if legacy := parseAgentCapabilities([]byte(`{"kind":"node-agent"}`)); legacy.ProtocolVersion != 0 {
+		t.Fatalf("legacy protocol_version = %d, want 0", legacy.ProtocolVersion)
+	}
+	if _, err := protocol.Negotiate(0); err != nil {
+		t.Fatalf("legacy executor should negotiate, got %v", err)
+	}
+}
+
+func TestJobMetaArtifactTypesCoverDispatchableJobs(t *testing.T) {
+	t.Parallel()
+
+	for _, jobType := range agentSupportedJobTypes() {
+		if jobType == model.AutomationJobTypeSyncShopActions {
+			continue
+		}
+		if _, ok := jobMetaArtifactTypes[jobType]; !ok {
+			t.Errorf("job type %s has no meta artifact mapping", jobType)
+		}
+	}
+}
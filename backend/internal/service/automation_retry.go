@@ -0,0 +1,159 @@
+package service
+
+import (
+	"time"
+
+	"ozon-manager/internal/model"
+	"ozon-manager/internal/repository"
+)
+
+// automationRetryPolicy 明细失败后的自动重试策略
+type automationRetryPolicy struct {
+	// MaxAttempts 含首次执行在内的最多执行次数
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// RetryUnknown 无法归类的错误是否重试；只读同步任务重试无副作用，写操作默认不重试
+	RetryUnknown bool
+}
+
+var defaultAutomationRetryPolicy = automationRetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   2 * time.Minute,
+	MaxDelay:    30 * time.Minute,
+}
+
+var automationRetryPolicies = map[string]automationRetryPolicy{
+	model.AutomationJobTypeSyncShopActions:      {MaxAttempts: 4, BaseDelay: time.Minute, MaxDelay: 15 * time.Minute, RetryUnknown: true},
+	model.AutomationJobTypeSyncActionCandidates: {MaxAttempts: 4, BaseDelay: time.Minute, MaxDelay: 15 * time.Minute, RetryUnknown: true},
+	model.AutomationJobTypeSyncActionProducts:   {MaxAttempts: 4, BaseDelay: time.Minute, MaxDelay: 15 * time.Minute, RetryUnknown: true},
+	model.AutomationJobTypeRemoveRepriceReadd:   {MaxAttempts: 3, BaseDelay: 5 * time.Minute, MaxDelay: time.Hour},
+}
+
+func automationRetryPolicyFor(jobType string) automationRetryPolicy {
+	if policy, ok := automationRetryPolicies[jobType]; ok {
+		return policy
+	}
+	return defaultAutomationRetryPolicy
+}
+
+// backoff 第 attempt 次重试（从 1 开始）前的等待时间，按指数增长并封顶
+func (p automationRetryPolicy) backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+func (p automationRetryPolicy) shouldRetry(kind string, retryCount int) bool {
+	if retryCount+1 >= p.MaxAttempts {
+		return false
+	}
+	switch kind {
+	case automationErrorRetryable:
+		return true
+	case automationErrorUnknown:
+		return p.RetryUnknown
+	}
+	return false
+}
+
+// planItemRetries 为本次新失败的明细（尚未排期）决定重试时间或转入死信，
+// 返回明细更新与任务下一次重试时间（nil 表示无需再重试）
+func planItemRetries(job *model.AutomationJob, now time.Time) ([]repository.AutomationItemRetryUpdate, *time.Time) {
+	policy := automationRetryPolicyFor(job.JobType)
+	updates := make([]repository.AutomationItemRetryUpdate, 0)
+	var nextRetryAt *time.Time
+
+	for _, item := range job.Items {
+		if item.OverallStatus != model.AutomationStepStatusFailed {
+			continue
+		}
+		if item.NextRetryAt != nil {
+			// 上一轮已排期的明细沿用原计划
+			if nextRetryAt == nil || item.NextRetryAt.Before(*nextRetryAt) {
+				retryAt := *item.NextRetryAt
+				nextRetryAt = &retryAt
+			}
+			continue
+		}
+
+		kind := classifyAutomationError(firstNonEmptyServiceTrimmed(item.StepExitError, item.StepRepriceError, item.StepReaddError))
+		update := repository.AutomationItemRetryUpdate{ItemID: item.ID, ErrorKind: kind}
+		if policy.shouldRetry(kind, item.RetryCount) {
+			retryAt := now.Add(policy.backoff(item.RetryCount + 1))
+			update.NextRetryAt = &retryAt
+			if nextRetryAt == nil || retryAt.Before(*nextRetryAt) {
+				nextRetryAt = &retryAt
+			}
+		} else {
+			update.DeadLetter = true
+		}
+		updates = append(updates, update)
+	}
+
+	return updates, nextRetryAt
+}
+
+// applyRetryPolicy 在任务回报后按重试策略处理失败明细。
+// 返回 true 表示任务已进入 retry_wait，尚未到达终态。
+func (s *AutomationService) applyRetryPolicy(jobID uint) bool {
+	job, err := s.automationRepo.FindJobByID(jobID)
+	if err != nil || job.DryRun {
+		return false
+	}
+	if job.Status != model.AutomationJobStatusFailed && job.Status != model.AutomationJobStatusPartialSuccess {
+		return false
+	}
+
+	updates, nextRetryAt := planItemRetries(job, time.Now())
+	if len(updates) == 0 && nextRetryAt == nil {
+		return false
+	}
+	if err := s.automationRepo.ApplyItemRetryUpdates(job.ID, updates, nextRetryAt); err != nil {
+		return false
+	}
+
+	deadLetters := 0
+	for _, update := range updates {
+		if update.DeadLetter {
+			deadLetters++
+		}
+	}
+	if deadLetters > 0 {
+		_ = s.createSimpleEvent(job.ID, "job_items_dead_lettered", "items moved to dead letter: not retryable or attempts exhausted", nil)
+	}
+	if nextRetryAt != nil {
+		_ = s.createSimpleEvent(job.ID, "job_retry_scheduled", "failed items scheduled for retry at "+nextRetryAt.Format("2006-01-02 15:04:05"), nil)
+		return true
+	}
+	return false
+}
+
+// requeueDueRetries 将到达重试时间的任务重新排队，返回处理数量
+func (s *AutomationService) requeueDueRetries(now time.Time) int {
+	jobs, err := s.automationRepo.ListRetryDueJobs(now)
+	if err != nil {
+		return 0
+	}
+	requeued := 0
+	for _, job := range jobs {
+		ok, requeueErr := s.automationRepo.RequeueRetryItems(job.ID)
+		if requeueErr != nil || !ok {
+			continue
+		}
+		_ = s.createSimpleEvent(job.ID, "job_retry_requeued", "scheduled retry started, failed items re-queued", nil)
+		requeued++
+	}
+	return requeued
+}
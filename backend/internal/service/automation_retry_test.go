@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"ozon-manager/internal/model"
+)
+
+func TestAutomationRetryPolicyBackoffIsExponentialAndCapped(t *testing.T) {
+	t.Parallel()
+
+	policy := automationRetryPolicy{MaxAttempts: 5, BaseDelay: time.Minute, MaxDelay: 5 * time.Minute}
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for index, expected := range want {
+		if got := policy.backoff(index + 1); got != expected {
+			t.Fatalf("backoff(%d) = %s, want %s", index+1, got, expected)
+		}
+	}
+}
+
+func TestPlanItemRetriesSchedulesRetryableAndDeadLettersPermanent(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	job := &model.AutomationJob{
+		JobType: model.AutomationJobTypeShopActionDeclare,
+		Items: []model.AutomationJobItem{
+			{ID: 1, OverallStatus: model.AutomationStepStatusSuccess},
+			{ID: 2, OverallStatus: model.AutomationStepStatusFailed, StepExitError: "Timeout 30000ms exceeded"},
+			{ID: 3, OverallStatus: model.AutomationStepStatusFailed, StepExitError: "SKU not in action"},
+			{ID: 4, OverallStatus: model.AutomationStepStatusFailed, StepExitError: "unexpected dialog"},
+			{ID: 5, OverallStatus: model.AutomationStepStatusFailed, StepExitError: "timeout", RetryCount: 2},
+		},
+	}
+
+	updates, nextRetryAt := planItemRetries(job, now)
+	if len(updates) != 4 {
+		t.Fatalf("planItemRetries() updates = %d, want 4", len(updates))
+	}
+	byID := map[uint]bool{}
+	for _, update := range updates {
+		byID[update.ItemID] = update.DeadLetter
+	}
+	if byID[2] {
+		t.Fatalf("retryable item should be scheduled, not dead-lettered")
+	}
+	if !byID[3] || !byID[4] || !byID[5] {
+		t.Fatalf("permanent, unknown (write job) and exhausted items should be dead-lettered: %+v", updates)
+	}
+	if nextRetryAt == nil || !nextRetryAt.Equal(now.Add(defaultAutomationRetryPolicy.BaseDelay)) {
+		t.Fatalf("nextRetryAt = %v", nextRetryAt)
+	}
+}
+
+func TestPlanItemRetriesRetriesUnknownErrorsForSyncJobs(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	job := &model.AutomationJob{
+		JobType: model.AutomationJobTypeSyncShopActions,
+		Items: []model.AutomationJobItem{
+			{ID: 1, OverallStatus: model.AutomationStepStatusFailed, StepExitError: "未获取到店铺活动数据", RetryCount: 1},
+		},
+	}
+
+	updates, nextRetryAt := planItemRetries(job, now)
+	if len(updates) != 1 || updates[0].DeadLetter || updates[0].ErrorKind != automationErrorUnknown {
+		t.Fatalf("planItemRetries() = %+v", updates)
+	}
+	if nextRetryAt == nil || !nextRetryAt.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("nextRetryAt = %v, want second backoff step", nextRetryAt)
+	}
+}
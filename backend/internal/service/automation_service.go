@@ -2,24 +2,46 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
 	"ozon-manager/internal/dto"
 	"ozon-manager/internal/model"
 	"ozon-manager/internal/repository"
-	"ozon-manager/pkg/ozon"
+	"ozon-manager/pkg/artifactstore"
+	"ozon-manager/pkg/automation/protocol"
 )
 
 type AutomationService struct {
 	automationRepo *repository.AutomationRepository
 	productRepo    *repository.ProductRepository
 	shopRepo       *repository.ShopRepository
+
+	continuationsMu sync.RWMutex
+	continuations   map[string]AutomationContinuation
+	jobListeners    []AutomationJobListener
+	taskQueue       *TaskQueue
+
+	artifactStore   artifactstore.Store
+	artifactOptions AutomationArtifactOptions
+
+	shopGuard *ShopGuard
+
+	loops backgroundLoops
+
+	priceHistory *PriceHistoryService
 }
 
-const extensionPollIntervalMS = 5000
+const (
+	extensionPollIntervalMS = 5000
+	// agentPollPerShopHeads 每次轮询从每个店铺队首取出的候选任务数
+	agentPollPerShopHeads = 5
+)
 
 func NewAutomationService(
 	automationRepo *repository.AutomationRepository,
@@ -30,9 +52,29 @@ func NewAutomationService(
 		automationRepo: automationRepo,
 		productRepo:    productRepo,
 		shopRepo:       shopRepo,
+		continuations:  make(map[string]AutomationContinuation),
+		shopGuard:      NewShopGuard(shopRepo, defaultShopCircuitBreakerOptions),
 	}
 }
 
+// ConfigureShopCircuitBreaker 按配置重建店铺熔断器，需在其他服务取用 ShopGuard 之前调用
+func (s *AutomationService) ConfigureShopCircuitBreaker(options ShopCircuitBreakerOptions) {
+	s.shopGuard = NewShopGuard(s.shopRepo, options)
+}
+
+// ConfigurePriceHistory 插件改价时记录价格变动
+func (s *AutomationService) ConfigurePriceHistory(priceHistory *PriceHistoryService) {
+	s.priceHistory = priceHistory
+}
+
+// ShopGuard 返回店铺写保护与熔断器，供其他服务的写操作共用
+func (s *AutomationService) ShopGuard() *ShopGuard {
+	if s == nil {
+		return nil
+	}
+	return s.shopGuard
+}
+
 func (s *AutomationService) CreateJob(userID uint, req *dto.CreateAutomationJobRequest) (*model.AutomationJob, error) {
 	if _, err := s.shopRepo.FindByID(req.ShopID); err != nil {
 		return nil, fmt.Errorf("shop not found: %w", err)
@@ -56,6 +98,7 @@ func (s *AutomationService) CreateJob(userID uint, req *dto.CreateAutomationJobR
 		CreatedBy:            userID,
 		JobType:              req.JobType,
 		Status:               jobStatus,
+		Priority:             model.AutomationJobPriorityInteractive,
 		DryRun:               req.DryRun,
 		RequiresConfirmation: req.RequiresConfirmation,
 		RateLimit:            rateLimit,
@@ -130,12 +173,13 @@ func (s *AutomationService) CreateJob(userID uint, req *dto.CreateAutomationJobR
 	return s.automationRepo.FindJobByIDAndShop(job.ID, req.ShopID)
 }
 
-func (s *AutomationService) CreateSyncShopActionsJob(userID uint, shopID uint) (*model.AutomationJob, error) {
+func (s *AutomationService) CreateSyncShopActionsJob(userID uint, shopID uint, link *AutomationJobLink) (*model.AutomationJob, error) {
 	job := &model.AutomationJob{
 		ShopID:     shopID,
 		CreatedBy:  userID,
 		JobType:    model.AutomationJobTypeSyncShopActions,
 		Status:     model.AutomationJobStatusPending,
+		Priority:   model.AutomationJobPriorityInteractive,
 		RateLimit:  1,
 		TotalItems: 1,
 	}
@@ -147,18 +191,19 @@ func (s *AutomationService) CreateSyncShopActionsJob(userID uint, shopID uint) (
 		StepRepriceStatus: model.AutomationStepStatusPending,
 		StepReaddStatus:   model.AutomationStepStatusPending,
 	}}
-	if err := s.automationRepo.CreateJobWithItems(job, items); err != nil {
+	if err := s.createLinkedJob(job, items, link); err != nil {
 		return nil, err
 	}
 	return s.automationRepo.FindJobByIDAndShop(job.ID, shopID)
 }
 
-func (s *AutomationService) CreateSyncActionCandidatesJob(userID uint, shopID uint, promotionActionID uint, sourceActionID string) (*model.AutomationJob, error) {
+func (s *AutomationService) CreateSyncActionCandidatesJob(userID uint, shopID uint, promotionActionID uint, sourceActionID string, priority int) (*model.AutomationJob, error) {
 	job := &model.AutomationJob{
 		ShopID:     shopID,
 		CreatedBy:  userID,
 		JobType:    model.AutomationJobTypeSyncActionCandidates,
 		Status:     model.AutomationJobStatusPending,
+		Priority:   priority,
 		RateLimit:  1,
 		TotalItems: 1,
 	}
@@ -173,68 +218,57 @@ func (s *AutomationService) CreateSyncActionCandidatesJob(userID uint, shopID ui
 	if err := s.automationRepo.CreateJobWithItems(job, items); err != nil {
 		return nil, err
 	}
-	meta := map[string]interface{}{
-		"promotion_action_id": promotionActionID,
-		"source_action_id":    sourceActionID,
+	meta := protocol.SyncActionMeta{
+		PromotionActionID: promotionActionID,
+		SourceActionID:    sourceActionID,
 	}
-	if err := s.automationRepo.CreateArtifact(job.ID, "sync_action_candidates_meta", meta); err != nil {
+	if err := s.storeArtifact(job.ID, "sync_action_candidates_meta", meta); err != nil {
 		return nil, err
 	}
 	return s.automationRepo.FindJobByIDAndShop(job.ID, shopID)
 }
 
-func (s *AutomationService) CreateSyncActionProductsJob(userID uint, shopID uint, promotionActionID uint, sourceActionID string) (*model.AutomationJob, error) {
+// syncActionProductsItemSKU 店铺活动商品同步任务的占位明细，以活动 ID 区分同步目标
+func syncActionProductsItemSKU(promotionActionID uint) string {
+	return fmt.Sprintf("__sync_action_products__:%d", promotionActionID)
+}
+
+// FindActiveSyncActionProductsJob 返回该店铺活动仍在排队或执行中的商品同步任务
+func (s *AutomationService) FindActiveSyncActionProductsJob(shopID, promotionActionID uint) (*model.AutomationJob, error) {
+	return s.automationRepo.FindPendingJobByTypeShopAndItem(model.AutomationJobTypeSyncActionProducts, shopID, syncActionProductsItemSKU(promotionActionID))
+}
+
+func (s *AutomationService) CreateSyncActionProductsJob(userID uint, shopID uint, promotionActionID uint, sourceActionID string, link *AutomationJobLink) (*model.AutomationJob, error) {
 	job := &model.AutomationJob{
 		ShopID:     shopID,
 		CreatedBy:  userID,
 		JobType:    model.AutomationJobTypeSyncActionProducts,
 		Status:     model.AutomationJobStatusPending,
+		Priority:   model.AutomationJobPriorityNormal,
 		RateLimit:  1,
 		TotalItems: 1,
 	}
 	items := []model.AutomationJobItem{{
-		SourceSKU:         fmt.Sprintf("__sync_action_products__:%d", promotionActionID),
+		SourceSKU:         syncActionProductsItemSKU(promotionActionID),
 		TargetPrice:       0.01,
 		OverallStatus:     model.AutomationStepStatusPending,
 		StepExitStatus:    model.AutomationStepStatusPending,
 		StepRepriceStatus: model.AutomationStepStatusPending,
 		StepReaddStatus:   model.AutomationStepStatusPending,
 	}}
-	if err := s.automationRepo.CreateJobWithItems(job, items); err != nil {
+	if err := s.createLinkedJob(job, items, link); err != nil {
 		return nil, err
 	}
-	meta := map[string]interface{}{
-		"promotion_action_id": promotionActionID,
-		"source_action_id":    sourceActionID,
+	meta := protocol.SyncActionMeta{
+		PromotionActionID: promotionActionID,
+		SourceActionID:    sourceActionID,
 	}
-	if err := s.automationRepo.CreateArtifact(job.ID, "sync_action_products_meta", meta); err != nil {
+	if err := s.storeArtifact(job.ID, "sync_action_products_meta", meta); err != nil {
 		return nil, err
 	}
 	return s.automationRepo.FindJobByIDAndShop(job.ID, shopID)
 }
 
-func (s *AutomationService) WaitForJobCompletion(jobID uint, timeout time.Duration) (*model.AutomationJob, error) {
-	deadline := time.Now().Add(timeout)
-	for {
-		job, err := s.automationRepo.FindJobByID(jobID)
-		if err != nil {
-			return nil, err
-		}
-		switch job.Status {
-		case model.AutomationJobStatusSuccess, model.AutomationJobStatusPartialSuccess, model.AutomationJobStatusFailed, model.AutomationJobStatusCanceled:
-			return job, nil
-		}
-		if time.Now().After(deadline) {
-			return job, fmt.Errorf("job timeout")
-		}
-		time.Sleep(800 * time.Millisecond)
-	}
-}
-
-func (s *AutomationService) GetLatestArtifact(jobID uint, artifactType string) (*model.AutomationArtifact, error) {
-	return s.automationRepo.FindLatestArtifactByJob(jobID, artifactType)
-}
-
 func (s *AutomationService) FindLatestCompletedSyncShopActionsJob(shopID uint) (*model.AutomationJob, error) {
 	job, err := s.automationRepo.FindLatestJobByShopAndTypesAndStatuses(
 		shopID,
@@ -258,9 +292,29 @@ func (s *AutomationService) GetJobDetail(shopID, jobID uint) (*model.AutomationJ
 	return s.automationRepo.FindJobByIDAndShop(jobID, shopID)
 }
 
-func (s *AutomationService) AgentHeartbeat(req *dto.AgentHeartbeatRequest) (*model.AutomationAgent, error) {
-	capabilities, _ := json.Marshal(req.Capabilities)
-	return s.automationRepo.UpsertAgentByKey(req.AgentKey, req.Name, req.Hostname, capabilities)
+// AgentHeartbeat 协商协议版本并更新执行端心跳，版本不兼容时返回 *protocol.IncompatibleVersionError 且不登记执行端
+func (s *AutomationService) AgentHeartbeat(req *dto.AgentHeartbeatRequest) (*dto.AgentHeartbeatResponse, error) {
+	version, err := protocol.Negotiate(req.ProtocolVersion)
+	if err != nil {
+		return nil, err
+	}
+	capabilities := make(map[string]interface{}, len(req.Capabilities)+1)
+	for key, value := range req.Capabilities {
+		capabilities[key] = value
+	}
+	capabilities["protocol_version"] = version
+	capabilityBytes, _ := json.Marshal(capabilities)
+
+	agent, err := s.automationRepo.UpsertAgentByKey(req.AgentKey, req.Name, req.Hostname, capabilityBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.AgentHeartbeatResponse{
+		AgentID:         agent.ID,
+		AgentKey:        agent.AgentKey,
+		Status:          agent.Status,
+		ProtocolVersion: version,
+	}, nil
 }
 
 func (s *AutomationService) AgentPoll(req *dto.AgentPollRequest) (*model.AutomationJob, error) {
@@ -269,13 +323,43 @@ func (s *AutomationService) AgentPoll(req *dto.AgentPollRequest) (*model.Automat
 		return nil, fmt.Errorf("agent not found: %w", err)
 	}
 
-	candidates, err := s.automationRepo.ListPendingJobsByTypes(agentSupportedJobTypes(), 100)
+	caps := parseAgentCapabilities(agent.Capabilities)
+	// 服务端升级后不再支持的执行端在领取任务前被拒绝，避免执行到一半才因协议不符失败
+	if _, err := protocol.Negotiate(caps.ProtocolVersion); err != nil {
+		return nil, err
+	}
+	jobTypes := caps.supportedJobTypes(agentSupportedJobTypes())
+	if len(jobTypes) == 0 {
+		return nil, nil
+	}
+	if caps.MaxConcurrency > 0 {
+		runningByAgent, countErr := s.automationRepo.CountRunningJobsByAgent()
+		if countErr != nil {
+			return nil, countErr
+		}
+		if !caps.hasFreeSlot(runningByAgent[agent.ID]) {
+			return nil, nil
+		}
+	}
+
+	pending, err := s.automationRepo.ListPendingJobHeadsByShop(jobTypes, agentPollPerShopHeads)
+	if err != nil {
+		return nil, err
+	}
+	runningByShop, err := s.automationRepo.CountRunningJobsByShop(jobTypes)
 	if err != nil {
 		return nil, err
 	}
+	candidates := orderJobsForFairDispatch(pending, runningByShop)
 
 	var job *model.AutomationJob
 	for _, candidate := range candidates {
+		if !caps.servesShop(candidate.ShopID) {
+			continue
+		}
+		if s.shopGuard.CheckDispatch(candidate.ShopID, candidate.JobType) != nil {
+			continue
+		}
 		allow, allowErr := s.canAgentAcquireJob(candidate.ShopID)
 		if allowErr != nil {
 			if allowErr == gorm.ErrRecordNotFound {
@@ -323,9 +407,13 @@ func (s *AutomationService) AgentReport(req *dto.AgentReportRequest) error {
 	if err != nil {
 		return fmt.Errorf("job not found")
 	}
-
-	if job.Status != model.AutomationJobStatusRunning {
-		return fmt.Errorf("job is not running")
+	agent, err := s.automationRepo.FindAgentByKey(req.AgentKey)
+	if err != nil {
+		return fmt.Errorf("agent not registered")
+	}
+	// 任务超时被重新排队后可能已由其他执行端领取，迟到的回报不能覆盖新一轮的结果
+	if err := validateReportingAgent(job, agent.ID); err != nil {
+		return err
 	}
 
 	results := make([]model.AutomationJobItem, 0, len(req.Results))
@@ -354,13 +442,16 @@ func (s *AutomationService) AgentReport(req *dto.AgentReportRequest) error {
 		return fmt.Errorf("invalid report status")
 	}
 
-	if err := s.automationRepo.UpdateJobAndItemsByReport(req.JobID, targetStatus, results); err != nil {
+	if err := s.automationRepo.UpdateJobAndItemsByReport(req.JobID, agent.ID, targetStatus, results); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("job is no longer assigned to this agent")
+		}
 		return fmt.Errorf("failed to update report: %w", err)
 	}
 
 	if len(req.Meta) > 0 {
 		artifactType := artifactTypeForJob(job.JobType, "agent_payload")
-		_ = s.automationRepo.CreateArtifact(req.JobID, artifactType, req.Meta)
+		_ = s.storeArtifact(req.JobID, artifactType, req.Meta)
 	}
 
 	payload := map[string]interface{}{
@@ -377,10 +468,18 @@ func (s *AutomationService) AgentReport(req *dto.AgentReportRequest) error {
 	}
 	_ = s.automationRepo.CreateJobEvent(event)
 
+	s.recordExecutorOutcome(req.JobID)
+	if !s.applyRetryPolicy(req.JobID) {
+		s.handleJobFinished(req.JobID)
+	}
 	return nil
 }
 
 func (s *AutomationService) ExtensionRegister(userID uint, req *dto.ExtensionRegisterRequest) (*dto.ExtensionRegisterResponse, error) {
+	version, err := protocol.Negotiate(req.ProtocolVersion)
+	if err != nil {
+		return nil, err
+	}
 	agentKey := extensionAgentKey(userID, req.ShopID, req.ExtensionID)
 	agentName := strings.TrimSpace(req.Name)
 	if agentName == "" {
@@ -388,11 +487,15 @@ func (s *AutomationService) ExtensionRegister(userID uint, req *dto.ExtensionReg
 	}
 
 	capabilities := map[string]interface{}{
-		"kind":         "chrome_extension",
-		"user_id":      userID,
-		"shop_id":      req.ShopID,
-		"extension_id": req.ExtensionID,
-		"version":      req.Version,
+		"kind":             "chrome_extension",
+		"user_id":          userID,
+		"shop_id":          req.ShopID,
+		"extension_id":     req.ExtensionID,
+		"version":          req.Version,
+		"job_types":        extensionSupportedJobTypes(),
+		"shop_ids":         []uint{req.ShopID},
+		"max_concurrency":  1,
+		"protocol_version": version,
 	}
 	capabilityBytes, _ := json.Marshal(capabilities)
 	hostname := fmt.Sprintf("shop-%d", req.ShopID)
@@ -402,16 +505,18 @@ func (s *AutomationService) ExtensionRegister(userID uint, req *dto.ExtensionReg
 	}
 
 	return &dto.ExtensionRegisterResponse{
-		AgentKey:       agentKey,
-		PollIntervalMS: extensionPollIntervalMS,
+		AgentKey:        agentKey,
+		PollIntervalMS:  extensionPollIntervalMS,
+		ProtocolVersion: version,
 	}, nil
 }
 
 func (s *AutomationService) ExtensionPoll(userID uint, req *dto.ExtensionPollRequest) (*model.AutomationJob, error) {
 	if _, err := s.ExtensionRegister(userID, &dto.ExtensionRegisterRequest{
-		ShopID:      req.ShopID,
-		ExtensionID: req.ExtensionID,
-		Name:        "Chrome Extension",
+		ShopID:          req.ShopID,
+		ExtensionID:     req.ExtensionID,
+		Name:            "Chrome Extension",
+		ProtocolVersion: req.ProtocolVersion,
 	}); err != nil {
 		return nil, err
 	}
@@ -430,7 +535,11 @@ func (s *AutomationService) ExtensionPoll(userID uint, req *dto.ExtensionPollReq
 		return nil, fmt.Errorf("extension not registered: %w", err)
 	}
 
-	job, err := s.automationRepo.AcquirePendingJobForShop(req.ShopID, extensionSupportedJobTypes(), &agent.ID)
+	jobTypes := s.shopGuard.DispatchableJobTypes(req.ShopID, extensionSupportedJobTypes())
+	if len(jobTypes) == 0 {
+		return nil, nil
+	}
+	job, err := s.automationRepo.AcquirePendingJobForShop(req.ShopID, jobTypes, &agent.ID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -503,13 +612,16 @@ func (s *AutomationService) ExtensionReport(userID uint, req *dto.ExtensionRepor
 		return fmt.Errorf("invalid report status")
 	}
 
-	if err := s.automationRepo.UpdateJobAndItemsByReport(req.JobID, targetStatus, results); err != nil {
+	if err := s.automationRepo.UpdateJobAndItemsByReport(req.JobID, agent.ID, targetStatus, results); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("job is no longer assigned to this agent")
+		}
 		return fmt.Errorf("failed to update report: %w", err)
 	}
 
 	if len(req.Meta) > 0 {
 		artifactType := artifactTypeForJob(job.JobType, "extension_payload")
-		_ = s.automationRepo.CreateArtifact(req.JobID, artifactType, req.Meta)
+		_ = s.storeArtifact(req.JobID, artifactType, req.Meta)
 	}
 
 	payload := map[string]interface{}{
@@ -528,10 +640,15 @@ func (s *AutomationService) ExtensionReport(userID uint, req *dto.ExtensionRepor
 	}
 	_ = s.automationRepo.CreateJobEvent(event)
 
+	s.recordExecutorOutcome(req.JobID)
+	if !s.applyRetryPolicy(req.JobID) {
+		s.handleJobFinished(req.JobID)
+	}
 	return nil
 }
 
-func (s *AutomationService) ExtensionRepriceProduct(shopID uint, sourceSKU string, newPrice float64) error {
+// ExtensionRepriceProduct 插件执行任务时的改价，jobID 为空表示非任务内调用
+func (s *AutomationService) ExtensionRepriceProduct(userID, shopID uint, sourceSKU string, newPrice float64, jobID *uint) error {
 	sku := strings.TrimSpace(sourceSKU)
 	if sku == "" {
 		return fmt.Errorf("invalid source sku")
@@ -540,6 +657,16 @@ func (s *AutomationService) ExtensionRepriceProduct(shopID uint, sourceSKU strin
 		return fmt.Errorf("invalid new price")
 	}
 
+	if jobID != nil {
+		if _, err := s.automationRepo.FindJobByIDAndShop(*jobID, shopID); err != nil {
+			return fmt.Errorf("job not found for shop: %w", err)
+		}
+	}
+
+	if err := s.shopGuard.CheckWritable(shopID); err != nil {
+		return err
+	}
+
 	shop, err := s.shopRepo.GetWithCredentials(shopID)
 	if err != nil {
 		return fmt.Errorf("shop not found: %w", err)
@@ -549,12 +676,16 @@ func (s *AutomationService) ExtensionRepriceProduct(shopID uint, sourceSKU strin
 		return fmt.Errorf("product not found for source sku: %s", sku)
 	}
 
-	client := ozon.NewClient(shop.ClientID, shop.ApiKey)
+	client := s.shopGuard.OzonClient(shop)
 	priceStr := fmt.Sprintf("%.2f", newPrice)
 	if err := client.UpdateSinglePrice(product.OzonProductID, priceStr, "", ""); err != nil {
 		return fmt.Errorf("failed to update ozon price: %w", err)
 	}
 
+	entry := appliedPriceChange(*product, newPrice, model.PriceSourceExtensionReprice, userIDPointer(userID), "")
+	entry.JobID = jobID
+	s.priceHistory.Record(entry)
+
 	if err := s.productRepo.UpdatePrice(product.ID, newPrice); err != nil {
 		return fmt.Errorf("failed to update local price: %w", err)
 	}
@@ -591,7 +722,11 @@ func (s *AutomationService) CancelJob(userID, shopID, jobID uint) error {
 		return err
 	}
 
-	return s.createSimpleEvent(job.ID, "job_canceled", "job canceled by user", &userID)
+	if err := s.createSimpleEvent(job.ID, "job_canceled", "job canceled by user", &userID); err != nil {
+		return err
+	}
+	s.handleJobFinished(job.ID)
+	return nil
 }
 
 func (s *AutomationService) RetryFailedItems(userID, shopID, jobID uint) error {
@@ -600,13 +735,13 @@ func (s *AutomationService) RetryFailedItems(userID, shopID, jobID uint) error {
 		return err
 	}
 
-	if job.Status != model.AutomationJobStatusFailed && job.Status != model.AutomationJobStatusPartialSuccess {
+	if job.Status != model.AutomationJobStatusFailed && job.Status != model.AutomationJobStatusPartialSuccess && job.Status != model.AutomationJobStatusRetryWait {
 		return fmt.Errorf("job does not support retry in current status")
 	}
 
 	hasFailed := false
 	for _, item := range job.Items {
-		if item.OverallStatus == model.AutomationStepStatusFailed {
+		if item.OverallStatus == model.AutomationStepStatusFailed || item.OverallStatus == model.AutomationStepStatusDeadLetter {
 			hasFailed = true
 			break
 		}
@@ -627,7 +762,7 @@ func (s *AutomationService) ListEvents(req *dto.AutomationEventListRequest) ([]m
 }
 
 func (s *AutomationService) ListAgents() ([]model.AutomationAgent, error) {
-	staleBefore := time.Now().Add(-90 * time.Second)
+	staleBefore := time.Now().Add(-agentOnlineWindow)
 	_ = s.automationRepo.MarkStaleAgentsOffline(staleBefore)
 
 	agents, err := s.automationRepo.ListAgents()
@@ -642,7 +777,7 @@ func (s *AutomationService) ListAgents() ([]model.AutomationAgent, error) {
 			agent.Status = model.AutomationAgentStatusOffline
 			continue
 		}
-		if now.Sub(*agent.LastHeartbeatAt) > 90*time.Second {
+		if now.Sub(*agent.LastHeartbeatAt) > agentOnlineWindow {
 			agent.Status = model.AutomationAgentStatusOffline
 			continue
 		}
@@ -703,6 +838,60 @@ func (s *AutomationService) GetExtensionStatus() ([]dto.ExtensionStatusItem, err
 	return items, nil
 }
 
+// GetQueueMetrics 返回按店铺、任务类型、状态聚合的队列深度与最老任务等待时长
+func (s *AutomationService) GetQueueMetrics() (*dto.AutomationQueueMetricsResponse, error) {
+	stats, err := s.automationRepo.ListQueueStats([]string{
+		model.AutomationJobStatusWaiting,
+		model.AutomationJobStatusRetryWait,
+		model.AutomationJobStatusPending,
+		model.AutomationJobStatusAwaitConfirm,
+		model.AutomationJobStatusRunning,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	shopNames := make(map[uint]string)
+	if shops, shopErr := s.shopRepo.FindAll(); shopErr == nil {
+		for _, shop := range shops {
+			shopNames[shop.ID] = shop.Name
+		}
+	}
+
+	now := time.Now()
+	resp := &dto.AutomationQueueMetricsResponse{
+		GeneratedAt: now.Format("2006-01-02 15:04:05"),
+		Items:       make([]dto.AutomationQueueMetricItem, 0, len(stats)),
+	}
+	for _, stat := range stats {
+		switch stat.Status {
+		case model.AutomationJobStatusPending:
+			resp.TotalPending += stat.Depth
+		case model.AutomationJobStatusRunning:
+			resp.TotalRunning += stat.Depth
+		case model.AutomationJobStatusAwaitConfirm:
+			resp.TotalAwaiting += stat.Depth
+		}
+
+		age := int64(0)
+		if !stat.OldestCreated.IsZero() && now.After(stat.OldestCreated) {
+			age = int64(now.Sub(stat.OldestCreated).Seconds())
+		}
+		resp.Items = append(resp.Items, dto.AutomationQueueMetricItem{
+			ShopID:           stat.ShopID,
+			ShopName:         shopNames[stat.ShopID],
+			JobType:          stat.JobType,
+			Status:           stat.Status,
+			Depth:            stat.Depth,
+			MaxPriority:      stat.MaxPriority,
+			OldestCreatedAt:  stat.OldestCreated.Format("2006-01-02 15:04:05"),
+			OldestAgeSeconds: age,
+		})
+	}
+
+	return resp, nil
+}
+
 func (s *AutomationService) createSimpleEvent(jobID uint, eventType, message string, createdBy *uint) error {
 	payloadBytes, _ := json.Marshal(map[string]interface{}{})
 	event := &model.AutomationJobEvent{
@@ -761,6 +950,74 @@ func agentSupportedJobTypes() []string {
 	return extensionSupportedJobTypes()
 }
 
+// orderJobsForFairDispatch 对候选任务做跨店铺的加权公平排序。
+// 每轮选择"队首任务优先级 / (1 + 已在执行数 + 本轮已分配数)"最高的店铺，
+// 因此高优先级任务先出队，同时单个店铺积压的任务不会饿死其他店铺。
+func orderJobsForFairDispatch(jobs []model.AutomationJob, runningByShop map[uint]int) []model.AutomationJob {
+	if len(jobs) <= 1 {
+		return jobs
+	}
+
+	queues := make(map[uint][]model.AutomationJob)
+	shopOrder := make([]uint, 0)
+	for _, job := range jobs {
+		if _, exists := queues[job.ShopID]; !exists {
+			shopOrder = append(shopOrder, job.ShopID)
+		}
+		queues[job.ShopID] = append(queues[job.ShopID], job)
+	}
+	for _, shopID := range shopOrder {
+		queue := queues[shopID]
+		sort.SliceStable(queue, func(i, j int) bool {
+			return pendingJobLess(queue[i], queue[j])
+		})
+	}
+
+	assigned := make(map[uint]int, len(shopOrder))
+	ordered := make([]model.AutomationJob, 0, len(jobs))
+	for len(ordered) < len(jobs) {
+		var bestShop uint
+		var bestJob *model.AutomationJob
+		bestScore := -1.0
+		for _, shopID := range shopOrder {
+			queue := queues[shopID]
+			if len(queue) == 0 {
+				continue
+			}
+			head := &queue[0]
+			score := float64(effectiveJobPriority(head.Priority)) / float64(1+runningByShop[shopID]+assigned[shopID])
+			if bestJob == nil || score > bestScore || (score == bestScore && pendingJobLess(*head, *bestJob)) {
+				bestShop = shopID
+				bestJob = head
+				bestScore = score
+			}
+		}
+
+		ordered = append(ordered, *bestJob)
+		queues[bestShop] = queues[bestShop][1:]
+		assigned[bestShop]++
+	}
+
+	return ordered
+}
+
+func pendingJobLess(a, b model.AutomationJob) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+func effectiveJobPriority(priority int) int {
+	if priority <= 0 {
+		return model.AutomationJobPriorityNormal
+	}
+	return priority
+}
+
 func (s *AutomationService) canAgentAcquireJob(shopID uint) (bool, error) {
 	mode, err := s.resolveShopExecutionEngineMode(shopID)
 	if err != nil {
@@ -819,11 +1076,22 @@ func validateJobAssignedAgent(job *model.AutomationJob, agentID uint) error {
 		return fmt.Errorf("job not found")
 	}
 	if job.AssignedAgentID == nil || *job.AssignedAgentID != agentID {
-		return fmt.Errorf("job is not assigned to this extension")
+		return fmt.Errorf("job is not assigned to this agent")
 	}
 	return nil
 }
 
+// validateReportingAgent 只接受当前执行中且仍分配给回报方的任务
+func validateReportingAgent(job *model.AutomationJob, agentID uint) error {
+	if job == nil {
+		return fmt.Errorf("job not found")
+	}
+	if job.Status != model.AutomationJobStatusRunning {
+		return fmt.Errorf("job is not running")
+	}
+	return validateJobAssignedAgent(job, agentID)
+}
+
 func extensionAgentKey(userID, shopID uint, extensionID string) string {
 	safe := sanitizeExtensionID(extensionID)
 	if safe == "" {
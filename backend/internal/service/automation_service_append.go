@@ -9,7 +9,7 @@ func (s *AutomationService) CreateJobWithItems(job *model.AutomationJob, items [
 }
 
 func (s *AutomationService) CreateArtifact(jobID uint, artifactType string, payload interface{}) error {
-	return s.automationRepo.CreateArtifact(jobID, artifactType, payload)
+	return s.storeArtifact(jobID, artifactType, payload)
 }
 
 func (s *AutomationService) FindJobByIDAndShop(jobID, shopID uint) (*model.AutomationJob, error) {
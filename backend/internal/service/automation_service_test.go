@@ -2,6 +2,7 @@ package service
 
 import (
 	"testing"
+	"time"
 
 	"ozon-manager/internal/model"
 )
@@ -125,3 +126,85 @@ func TestValidateJobAssignedAgent(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateReportingAgentRejectsStaleAgentAfterRequeue(t *testing.T) {
+	t.Parallel()
+
+	staleAgentID := uint(9)
+	newAgentID := uint(10)
+	job := &model.AutomationJob{ID: 1, Status: model.AutomationJobStatusRunning, AssignedAgentID: &staleAgentID}
+	if err := validateReportingAgent(job, staleAgentID); err != nil {
+		t.Fatalf("assigned agent should be able to report: %v", err)
+	}
+
+	// 超时巡检重新排队：状态回到 pending 并清空分配
+	job.Status = model.AutomationJobStatusPending
+	job.AssignedAgentID = nil
+	if err := validateReportingAgent(job, staleAgentID); err == nil {
+		t.Fatal("report for a requeued job should be rejected")
+	}
+
+	// 其他执行端领取后，原执行端的迟到回报仍被拒绝
+	job.Status = model.AutomationJobStatusRunning
+	job.AssignedAgentID = &newAgentID
+	if err := validateReportingAgent(job, staleAgentID); err == nil {
+		t.Fatal("late report from the previously assigned agent should be rejected")
+	}
+	if err := validateReportingAgent(job, newAgentID); err != nil {
+		t.Fatalf("newly assigned agent should be able to report: %v", err)
+	}
+}
+
+func TestOrderJobsForFairDispatchInterleavesShops(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	jobs := []model.AutomationJob{
+		{ID: 1, ShopID: 1, Priority: model.AutomationJobPriorityNormal, CreatedAt: base},
+		{ID: 2, ShopID: 1, Priority: model.AutomationJobPriorityNormal, CreatedAt: base.Add(time.Second)},
+		{ID: 3, ShopID: 1, Priority: model.AutomationJobPriorityNormal, CreatedAt: base.Add(2 * time.Second)},
+		{ID: 4, ShopID: 2, Priority: model.AutomationJobPriorityNormal, CreatedAt: base.Add(time.Minute)},
+	}
+
+	ordered := orderJobsForFairDispatch(jobs, nil)
+	gotIDs := make([]uint, 0, len(ordered))
+	for _, job := range ordered {
+		gotIDs = append(gotIDs, job.ID)
+	}
+	want := []uint{1, 4, 2, 3}
+	for index := range want {
+		if gotIDs[index] != want[index] {
+			t.Fatalf("ordered ids = %v, want %v", gotIDs, want)
+		}
+	}
+}
+
+func TestOrderJobsForFairDispatchPrefersInteractivePriority(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	jobs := []model.AutomationJob{
+		{ID: 1, ShopID: 1, Priority: model.AutomationJobPriorityBackground, CreatedAt: base},
+		{ID: 2, ShopID: 2, Priority: model.AutomationJobPriorityInteractive, CreatedAt: base.Add(time.Hour)},
+	}
+
+	ordered := orderJobsForFairDispatch(jobs, nil)
+	if ordered[0].ID != 2 {
+		t.Fatalf("first job = %d, want interactive job 2", ordered[0].ID)
+	}
+}
+
+func TestOrderJobsForFairDispatchPenalizesBusyShops(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	jobs := []model.AutomationJob{
+		{ID: 1, ShopID: 1, Priority: model.AutomationJobPriorityNormal, CreatedAt: base},
+		{ID: 2, ShopID: 2, Priority: model.AutomationJobPriorityNormal, CreatedAt: base.Add(time.Hour)},
+	}
+
+	ordered := orderJobsForFairDispatch(jobs, map[uint]int{1: 3})
+	if ordered[0].ID != 2 {
+		t.Fatalf("first job = %d, want job 2 from idle shop", ordered[0].ID)
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"ozon-manager/internal/model"
+)
+
+const (
+	automationSweepInterval = time.Minute
+	// automationDefaultJobTimeout 未单独配置的任务类型的执行超时
+	automationDefaultJobTimeout = 30 * time.Minute
+	// automationConfirmExpiry 待确认任务的有效期，超时未确认自动取消
+	automationConfirmExpiry = 24 * time.Hour
+	// automationMaxRequeue 幂等任务超时后最多重新排队的次数
+	automationMaxRequeue = 2
+	// automationContinuationStaleAfter 续接动作排队超过该时间仍没有后台任务时重新提交
+	automationContinuationStaleAfter   = 10 * time.Minute
+	automationContinuationRecoverBatch = 100
+)
+
+// automationJobTimeouts 各任务类型从开始执行起的超时时间
+var automationJobTimeouts = map[string]time.Duration{
+	model.AutomationJobTypeSyncShopActions:      10 * time.Minute,
+	model.AutomationJobTypeSyncActionCandidates: 15 * time.Minute,
+	model.AutomationJobTypeSyncActionProducts:   15 * time.Minute,
+	model.AutomationJobTypeShopActionDeclare:    30 * time.Minute,
+	model.AutomationJobTypeShopActionRemove:     30 * time.Minute,
+	model.AutomationJobTypePromoUnifiedEnroll:   30 * time.Minute,
+	model.AutomationJobTypePromoUnifiedRemove:   30 * time.Minute,
+	model.AutomationJobTypeRemoveRepriceReadd:   60 * time.Minute,
+}
+
+// automationIdempotentJobTypes 只读取后台数据的任务，超时后可安全重新执行
+var automationIdempotentJobTypes = map[string]bool{
+	model.AutomationJobTypeSyncShopActions:      true,
+	model.AutomationJobTypeSyncActionCandidates: true,
+	model.AutomationJobTypeSyncActionProducts:   true,
+}
+
+const (
+	overdueActionNone    = ""
+	overdueActionRequeue = "requeue"
+	overdueActionFail    = "fail"
+	overdueActionCancel  = "cancel"
+)
+
+func automationJobTimeout(jobType string) time.Duration {
+	if timeout, ok := automationJobTimeouts[jobType]; ok {
+		return timeout
+	}
+	return automationDefaultJobTimeout
+}
+
+func minAutomationJobTimeout() time.Duration {
+	minTimeout := automationDefaultJobTimeout
+	for _, timeout := range automationJobTimeouts {
+		if timeout < minTimeout {
+			minTimeout = timeout
+		}
+	}
+	return minTimeout
+}
+
+// classifyOverdueJob 判断任务是否超时以及应如何处理，返回处理动作与写入任务的错误信息
+func classifyOverdueJob(job *model.AutomationJob, now time.Time) (string, string) {
+	switch job.Status {
+	case model.AutomationJobStatusRunning:
+		if job.StartedAt == nil {
+			return overdueActionNone, ""
+		}
+		timeout := automationJobTimeout(job.JobType)
+		if now.Sub(*job.StartedAt) <= timeout {
+			return overdueActionNone, ""
+		}
+		if automationIdempotentJobTypes[job.JobType] && job.RequeueCount < automationMaxRequeue {
+			return overdueActionRequeue, fmt.Sprintf("执行超过 %s 未回报，已重新排队（第 %d 次）", timeout, job.RequeueCount+1)
+		}
+		return overdueActionFail, fmt.Sprintf("执行超过 %s 未回报，判定超时失败", timeout)
+	case model.AutomationJobStatusAwaitConfirm:
+		if now.Sub(job.CreatedAt) <= automationConfirmExpiry {
+			return overdueActionNone, ""
+		}
+		return overdueActionCancel, fmt.Sprintf("超过 %s 未确认，任务已自动取消", automationConfirmExpiry)
+	}
+	return overdueActionNone, ""
+}
+
+// StartJobSweeper 启动后台巡检：执行超时的任务重新排队或判定失败，过期未确认的任务自动取消，到期的自动重试重新排队
+func (s *AutomationService) StartJobSweeper() {
+	s.loops.Go(automationSweepInterval, func(now time.Time) {
+		s.SweepOverdueJobs(now)
+	})
+}
+
+// StopBackgroundWorkers 停止任务巡检与产物清理，并等待正在进行的一轮结束
+func (s *AutomationService) StopBackgroundWorkers() {
+	s.loops.Stop()
+}
+
+// SweepOverdueJobs 执行一次巡检：处理超时任务，把到达重试时间的任务重新排队，
+// 并重新提交丢失后台任务的续接动作，返回被处理的任务数
+func (s *AutomationService) SweepOverdueJobs(now time.Time) int {
+	handled := s.requeueDueRetries(now)
+
+	runningJobs, err := s.automationRepo.ListRunningJobsStartedBefore(now.Add(-minAutomationJobTimeout()))
+	if err == nil {
+		for index := range runningJobs {
+			if s.handleOverdueJob(&runningJobs[index], now) {
+				handled++
+			}
+		}
+	}
+
+	confirmJobs, err := s.automationRepo.ListAwaitConfirmJobsCreatedBefore(now.Add(-automationConfirmExpiry))
+	if err == nil {
+		for index := range confirmJobs {
+			if s.handleOverdueJob(&confirmJobs[index], now) {
+				handled++
+			}
+		}
+	}
+
+	handled += s.recoverStaleContinuations(now)
+	return handled
+}
+
+func (s *AutomationService) handleOverdueJob(job *model.AutomationJob, now time.Time) bool {
+	action, message := classifyOverdueJob(job, now)
+	switch action {
+	case overdueActionRequeue:
+		requeued, err := s.automationRepo.RequeueStaleJob(job, message)
+		if err != nil || !requeued {
+			return false
+		}
+		_ = s.createSimpleEvent(job.ID, "job_timeout_requeued", message, nil)
+		s.shopGuard.RecordFailure(job.ShopID, model.ShopFailureSourceExecutor, "timeout: "+message)
+		return true
+	case overdueActionFail:
+		expired, err := s.automationRepo.ExpireJob(job.ID, model.AutomationJobStatusRunning, model.AutomationJobStatusFailed, message)
+		if err != nil || !expired {
+			return false
+		}
+		_ = s.createSimpleEvent(job.ID, "job_timeout_failed", message, nil)
+		s.shopGuard.RecordFailure(job.ShopID, model.ShopFailureSourceExecutor, "timeout: "+message)
+		s.handleJobFinished(job.ID)
+		return true
+	case overdueActionCancel:
+		expired, err := s.automationRepo.ExpireJob(job.ID, model.AutomationJobStatusAwaitConfirm, model.AutomationJobStatusCanceled, message)
+		if err != nil || !expired {
+			return false
+		}
+		_ = s.createSimpleEvent(job.ID, "job_confirm_expired", message, nil)
+		s.handleJobFinished(job.ID)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"ozon-manager/internal/model"
+)
+
+func TestClassifyOverdueJobRequeuesIdempotentSync(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	startedAt := now.Add(-11 * time.Minute)
+	job := &model.AutomationJob{
+		JobType:   model.AutomationJobTypeSyncShopActions,
+		Status:    model.AutomationJobStatusRunning,
+		StartedAt: &startedAt,
+	}
+
+	if action, _ := classifyOverdueJob(job, now); action != overdueActionRequeue {
+		t.Fatalf("classifyOverdueJob() = %q, want requeue", action)
+	}
+
+	job.RequeueCount = automationMaxRequeue
+	if action, _ := classifyOverdueJob(job, now); action != overdueActionFail {
+		t.Fatalf("classifyOverdueJob() after max requeue = %q, want fail", action)
+	}
+}
+
+func TestClassifyOverdueJobFailsNonIdempotentJob(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	startedAt := now.Add(-45 * time.Minute)
+	job := &model.AutomationJob{
+		JobType:   model.AutomationJobTypeShopActionDeclare,
+		Status:    model.AutomationJobStatusRunning,
+		StartedAt: &startedAt,
+	}
+	if action, message := classifyOverdueJob(job, now); action != overdueActionFail || message == "" {
+		t.Fatalf("classifyOverdueJob() = %q, %q", action, message)
+	}
+
+	// 改价任务超时更长，45 分钟内不处理
+	job.JobType = model.AutomationJobTypeRemoveRepriceReadd
+	if action, _ := classifyOverdueJob(job, now); action != overdueActionNone {
+		t.Fatalf("classifyOverdueJob() for reprice = %q, want none", action)
+	}
+}
+
+func TestClassifyOverdueJobExpiresConfirmation(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	job := &model.AutomationJob{
+		JobType:   model.AutomationJobTypeRemoveRepriceReadd,
+		Status:    model.AutomationJobStatusAwaitConfirm,
+		CreatedAt: now.Add(-automationConfirmExpiry - time.Minute),
+	}
+	if action, _ := classifyOverdueJob(job, now); action != overdueActionCancel {
+		t.Fatalf("classifyOverdueJob() = %q, want cancel", action)
+	}
+
+	job.CreatedAt = now.Add(-time.Hour)
+	if action, _ := classifyOverdueJob(job, now); action != overdueActionNone {
+		t.Fatalf("classifyOverdueJob() = %q, want none", action)
+	}
+}
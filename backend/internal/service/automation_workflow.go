@@ -0,0 +1,387 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"ozon-manager/internal/model"
+)
+
+// AutomationContinuation 任务成功后执行的续接动作，例如导入产物、创建后续任务。
+// 返回错误时该任务会被标记为失败，依赖它的任务随之取消。
+type AutomationContinuation func(job *model.AutomationJob) error
+
+// AutomationJobListener 任务进入终态（含失败、取消）后的回调，在回报请求内执行，只做状态检查与提交后台任务
+type AutomationJobListener func(job *model.AutomationJob)
+
+// AutomationJobLink 描述任务在工作流中的位置：所属工作流、前置依赖与成功后的续接动作
+type AutomationJobLink struct {
+	WorkflowID *uint
+	DependsOn  []uint
+	OnSuccess  string
+}
+
+func (l *AutomationJobLink) apply(job *model.AutomationJob) {
+	if l == nil {
+		return
+	}
+	job.WorkflowID = l.WorkflowID
+	job.OnSuccess = l.OnSuccess
+}
+
+func (l *AutomationJobLink) dependencies() []uint {
+	if l == nil {
+		return nil
+	}
+	return uniqueUints(l.DependsOn)
+}
+
+// createLinkedJob 按 link 创建任务并刷新所属工作流的任务统计
+func (s *AutomationService) createLinkedJob(job *model.AutomationJob, items []model.AutomationJobItem, link *AutomationJobLink) error {
+	link.apply(job)
+	if err := s.automationRepo.CreateJobWithDependencies(job, items, link.dependencies()); err != nil {
+		return err
+	}
+	if job.WorkflowID != nil {
+		s.refreshWorkflowStatus(*job.WorkflowID)
+	}
+	return nil
+}
+
+// automationContinuationTask 续接动作后台任务载荷，以任务 ID 为键
+type automationContinuationTask struct {
+	JobID uint `json:"job_id"`
+}
+
+// ConfigureTaskQueue 续接动作通过持久化任务队列异步执行，回报接口不再同步等待续接完成
+func (s *AutomationService) ConfigureTaskQueue(queue *TaskQueue) {
+	s.taskQueue = queue
+	queue.Register(model.BackgroundTaskTypeAutomationContinuation, TaskOptions{
+		MaxAttempts: 3,
+		Timeout:     5 * time.Minute,
+		OnFailed:    s.handleContinuationTaskFailed,
+	}, TypedTaskHandler(s.handleContinuationTask))
+}
+
+// RegisterContinuation 注册续接动作，通常在各业务服务初始化时调用
+func (s *AutomationService) RegisterContinuation(name string, fn AutomationContinuation) {
+	s.continuationsMu.Lock()
+	defer s.continuationsMu.Unlock()
+	if s.continuations == nil {
+		s.continuations = make(map[string]AutomationContinuation)
+	}
+	s.continuations[name] = fn
+}
+
+// OnJobFinished 注册任务结束回调，供挂起等待执行端任务的业务在任务结束后继续执行
+func (s *AutomationService) OnJobFinished(fn AutomationJobListener) {
+	s.continuationsMu.Lock()
+	defer s.continuationsMu.Unlock()
+	s.jobListeners = append(s.jobListeners, fn)
+}
+
+func (s *AutomationService) notifyJobFinished(job *model.AutomationJob) {
+	s.continuationsMu.RLock()
+	listeners := append([]AutomationJobListener(nil), s.jobListeners...)
+	s.continuationsMu.RUnlock()
+	for _, fn := range listeners {
+		fn(job)
+	}
+}
+
+func (s *AutomationService) continuation(name string) (AutomationContinuation, bool) {
+	s.continuationsMu.RLock()
+	defer s.continuationsMu.RUnlock()
+	fn, ok := s.continuations[name]
+	return fn, ok
+}
+
+func (s *AutomationService) CreateWorkflow(userID, shopID uint, workflowType string) (*model.AutomationWorkflow, error) {
+	workflow := &model.AutomationWorkflow{
+		ShopID:       shopID,
+		CreatedBy:    userID,
+		WorkflowType: workflowType,
+		Status:       model.AutomationWorkflowStatusRunning,
+	}
+	if err := s.automationRepo.CreateWorkflow(workflow); err != nil {
+		return nil, fmt.Errorf("failed to create automation workflow: %w", err)
+	}
+	return workflow, nil
+}
+
+func (s *AutomationService) GetWorkflow(shopID, workflowID uint) (*model.AutomationWorkflow, error) {
+	return s.automationRepo.FindWorkflowByIDAndShop(workflowID, shopID)
+}
+
+// FindActiveJobByTypeAndShop 返回店铺内同类型仍在排队或执行中的任务
+func (s *AutomationService) FindActiveJobByTypeAndShop(jobType string, shopID uint) (*model.AutomationJob, error) {
+	return s.automationRepo.FindPendingJobByTypeAndShop(jobType, shopID)
+}
+
+// FailWorkflow 工作流未能创建出任务时直接置为失败
+func (s *AutomationService) FailWorkflow(workflowID uint, message string) error {
+	now := time.Now()
+	return s.automationRepo.UpdateWorkflow(workflowID, map[string]interface{}{
+		"status":        model.AutomationWorkflowStatusFailed,
+		"error_message": message,
+		"completed_at":  &now,
+	})
+}
+
+// SetWorkflowResult 记录工作流的业务结果（如导入数量），供前端轮询展示
+func (s *AutomationService) SetWorkflowResult(workflowID uint, result map[string]interface{}) error {
+	payload, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return s.automationRepo.UpdateWorkflow(workflowID, map[string]interface{}{"result": payload})
+}
+
+// handleJobFinished 在任务进入终态后推进工作流：有续接动作时提交后台任务执行，
+// 续接完成后再释放或取消下游任务；否则直接释放下游任务并汇总工作流状态。
+// 最后通知任务结束回调
+func (s *AutomationService) handleJobFinished(jobID uint) {
+	job, err := s.automationRepo.FindJobByID(jobID)
+	if err != nil {
+		return
+	}
+
+	if continuationDue(job) {
+		s.dispatchContinuation(job)
+	} else {
+		s.advanceWorkflow(job)
+	}
+	s.notifyJobFinished(job)
+}
+
+// isAutomationJobFinished 任务是否已进入终态
+func isAutomationJobFinished(status string) bool {
+	switch status {
+	case model.AutomationJobStatusSuccess, model.AutomationJobStatusPartialSuccess,
+		model.AutomationJobStatusFailed, model.AutomationJobStatusCanceled:
+		return true
+	}
+	return false
+}
+
+// continuationDue 任务成功结束且有续接动作时需执行续接
+func continuationDue(job *model.AutomationJob) bool {
+	if job.OnSuccess == "" {
+		return false
+	}
+	return job.Status == model.AutomationJobStatusSuccess || job.Status == model.AutomationJobStatusPartialSuccess
+}
+
+// dispatchContinuation 抢占续接动作并提交后台任务；已被抢占时说明是重复回报，直接忽略
+func (s *AutomationService) dispatchContinuation(job *model.AutomationJob) {
+	claimed, err := s.automationRepo.ClaimJobContinuation(job.ID)
+	if err != nil || !claimed {
+		return
+	}
+
+	enqueueErr := fmt.Errorf("task queue not configured")
+	if s.taskQueue != nil {
+		enqueueErr = s.taskQueue.Enqueue(model.BackgroundTaskTypeAutomationContinuation, automationContinuationTask{JobID: job.ID})
+	}
+	if enqueueErr == nil {
+		return
+	}
+	s.failContinuation(job, fmt.Sprintf("提交续接动作 %s 失败: %v", job.OnSuccess, enqueueErr))
+	s.advanceWorkflow(job)
+}
+
+func (s *AutomationService) handleContinuationTask(ctx context.Context, payload automationContinuationTask) error {
+	job, err := s.automationRepo.FindJobByID(payload.JobID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if job.ContinuationStatus != model.AutomationContinuationStatusQueued {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.runContinuation(job)
+	s.advanceWorkflow(job)
+	return nil
+}
+
+// handleContinuationTaskFailed 续接后台任务最终失败（次数用尽或载荷无效）时任务改判为失败并取消下游任务，
+// 避免 continuation_status 停留在 queued 使下游永远等待
+func (s *AutomationService) handleContinuationTaskFailed(raw json.RawMessage, message string) {
+	var payload automationContinuationTask
+	if err := json.Unmarshal(raw, &payload); err != nil || payload.JobID == 0 {
+		return
+	}
+	job, err := s.automationRepo.FindJobByID(payload.JobID)
+	if err != nil || job.ContinuationStatus != model.AutomationContinuationStatusQueued {
+		return
+	}
+	s.failContinuation(job, fmt.Sprintf("续接动作 %s 未能执行: %s", job.OnSuccess, message))
+	s.advanceWorkflow(job)
+}
+
+// recoverStaleContinuations 续接动作已排队却没有对应的后台任务（提交前进程退出）时重新提交，
+// 提交失败则按续接失败处理
+func (s *AutomationService) recoverStaleContinuations(now time.Time) int {
+	if s.taskQueue == nil {
+		return 0
+	}
+	jobs, err := s.automationRepo.ListStaleQueuedContinuations(model.BackgroundTaskTypeAutomationContinuation, now.Add(-automationContinuationStaleAfter), automationContinuationRecoverBatch)
+	if err != nil {
+		return 0
+	}
+	for index := range jobs {
+		job := &jobs[index]
+		if enqueueErr := s.taskQueue.Enqueue(model.BackgroundTaskTypeAutomationContinuation, automationContinuationTask{JobID: job.ID}); enqueueErr != nil {
+			s.failContinuation(job, fmt.Sprintf("重新提交续接动作 %s 失败: %v", job.OnSuccess, enqueueErr))
+			s.advanceWorkflow(job)
+			continue
+		}
+		_ = s.createSimpleEvent(job.ID, "job_continuation_requeued", fmt.Sprintf("continuation %s had no background task, requeued", job.OnSuccess), nil)
+	}
+	return len(jobs)
+}
+
+// advanceWorkflow 释放或取消下游任务并刷新所属工作流状态
+func (s *AutomationService) advanceWorkflow(job *model.AutomationJob) {
+	s.releaseDependents(job.ID)
+
+	if job.WorkflowID != nil {
+		s.refreshWorkflowStatus(*job.WorkflowID)
+	}
+}
+
+func (s *AutomationService) runContinuation(job *model.AutomationJob) {
+	fn, ok := s.continuation(job.OnSuccess)
+	var runErr error
+	if !ok {
+		runErr = fmt.Errorf("continuation %s not registered", job.OnSuccess)
+	} else {
+		runErr = fn(job)
+	}
+
+	if runErr == nil {
+		_ = s.automationRepo.UpdateJobContinuationStatus(job.ID, model.AutomationContinuationStatusDone)
+		_ = s.createSimpleEvent(job.ID, "job_continuation_completed", fmt.Sprintf("continuation %s completed", job.OnSuccess), nil)
+		return
+	}
+
+	s.failContinuation(job, fmt.Sprintf("续接动作 %s 失败: %v", job.OnSuccess, runErr))
+}
+
+// failContinuation 续接失败时任务整体置为失败，依赖它的任务随之取消
+func (s *AutomationService) failContinuation(job *model.AutomationJob, message string) {
+	_ = s.automationRepo.UpdateJobContinuationStatus(job.ID, model.AutomationContinuationStatusFailed)
+	_ = s.automationRepo.MarkJobFailed(job.ID, message)
+	job.Status = model.AutomationJobStatusFailed
+	_ = s.createSimpleEvent(job.ID, "job_continuation_failed", message, nil)
+}
+
+func (s *AutomationService) releaseDependents(jobID uint) {
+	dependents, err := s.automationRepo.ListWaitingDependents(jobID)
+	if err != nil {
+		return
+	}
+	for _, dependent := range dependents {
+		status, resolveErr := s.automationRepo.ResolveWaitingJob(dependent.ID)
+		if resolveErr != nil {
+			continue
+		}
+		switch status {
+		case model.AutomationJobStatusPending:
+			_ = s.createSimpleEvent(dependent.ID, "job_dependencies_satisfied", "all dependencies completed, job queued", nil)
+		case model.AutomationJobStatusCanceled:
+			_ = s.createSimpleEvent(dependent.ID, "job_dependency_failed", fmt.Sprintf("dependency job #%d did not succeed, job canceled", jobID), nil)
+			// 取消向下游继续传播
+			s.handleJobFinished(dependent.ID)
+		}
+	}
+}
+
+func (s *AutomationService) refreshWorkflowStatus(workflowID uint) {
+	workflow, err := s.automationRepo.FindWorkflowByID(workflowID)
+	if err != nil || isWorkflowFinished(workflow.Status) {
+		return
+	}
+	statuses, err := s.automationRepo.ListWorkflowJobStatuses(workflowID)
+	if err != nil {
+		return
+	}
+
+	summary := summarizeWorkflow(statuses)
+	updates := map[string]interface{}{
+		"status":         summary.Status,
+		"total_jobs":     summary.Total,
+		"completed_jobs": summary.Completed,
+		"failed_jobs":    summary.Failed,
+	}
+	if isWorkflowFinished(summary.Status) {
+		now := time.Now()
+		updates["completed_at"] = &now
+		if summary.Status != model.AutomationWorkflowStatusSuccess {
+			if message, msgErr := s.automationRepo.FindWorkflowFirstError(workflowID); msgErr == nil {
+				updates["error_message"] = message
+			}
+		}
+	}
+	_ = s.automationRepo.UpdateWorkflow(workflowID, updates)
+}
+
+type workflowSummary struct {
+	Status    string
+	Total     int
+	Completed int
+	Failed    int
+}
+
+// summarizeWorkflow 由任务状态汇总工作流状态：仍有未结束任务时为 running，
+// 全部成功为 success，全部失败/取消为 failed，其余为 partial_success。
+func summarizeWorkflow(statuses []string) workflowSummary {
+	summary := workflowSummary{Total: len(statuses)}
+	finished := 0
+	partial := false
+	for _, status := range statuses {
+		switch status {
+		case model.AutomationJobStatusSuccess:
+			summary.Completed++
+			finished++
+		case model.AutomationJobStatusPartialSuccess:
+			summary.Completed++
+			finished++
+			partial = true
+		case model.AutomationJobStatusFailed, model.AutomationJobStatusCanceled:
+			summary.Failed++
+			finished++
+		}
+	}
+
+	switch {
+	case summary.Total == 0 || finished < summary.Total:
+		summary.Status = model.AutomationWorkflowStatusRunning
+	case summary.Failed == 0 && !partial:
+		summary.Status = model.AutomationWorkflowStatusSuccess
+	case summary.Completed == 0:
+		summary.Status = model.AutomationWorkflowStatusFailed
+	default:
+		summary.Status = model.AutomationWorkflowStatusPartialSuccess
+	}
+	return summary
+}
+
+func isWorkflowFinished(status string) bool {
+	switch status {
+	case model.AutomationWorkflowStatusSuccess, model.AutomationWorkflowStatusPartialSuccess,
+		model.AutomationWorkflowStatusFailed, model.AutomationWorkflowStatusCanceled:
+		return true
+	}
+	return false
+}
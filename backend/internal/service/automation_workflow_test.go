@@ -0,0 +1,85 @@
+package service
+
+import (
+	"testing"
+
+	"ozon-manager/internal/model"
+)
+
+func TestSummarizeWorkflowRunningWhileJobsUnfinished(t *testing.T) {
+	t.Parallel()
+
+	got := summarizeWorkflow([]string{
+		model.AutomationJobStatusSuccess,
+		model.AutomationJobStatusWaiting,
+	})
+	if got.Status != model.AutomationWorkflowStatusRunning || got.Total != 2 || got.Completed != 1 {
+		t.Fatalf("summarizeWorkflow() = %+v", got)
+	}
+}
+
+func TestSummarizeWorkflowTerminalStates(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		statuses []string
+		want     string
+	}{
+		{"all success", []string{model.AutomationJobStatusSuccess, model.AutomationJobStatusSuccess}, model.AutomationWorkflowStatusSuccess},
+		{"partial job", []string{model.AutomationJobStatusSuccess, model.AutomationJobStatusPartialSuccess}, model.AutomationWorkflowStatusPartialSuccess},
+		{"dependent canceled", []string{model.AutomationJobStatusSuccess, model.AutomationJobStatusCanceled}, model.AutomationWorkflowStatusPartialSuccess},
+		{"all failed", []string{model.AutomationJobStatusFailed, model.AutomationJobStatusCanceled}, model.AutomationWorkflowStatusFailed},
+		{"empty", nil, model.AutomationWorkflowStatusRunning},
+	}
+	for _, tc := range cases {
+		if got := summarizeWorkflow(tc.statuses); got.Status != tc.want {
+			t.Fatalf("%s: summarizeWorkflow() status = %s, want %s", tc.name, got.Status, tc.want)
+		}
+	}
+}
+
+func TestAutomationJobLinkApply(t *testing.T) {
+	t.Parallel()
+
+	workflowID := uint(7)
+	link := &AutomationJobLink{
+		WorkflowID: &workflowID,
+		DependsOn:  []uint{3, 3, 4},
+		OnSuccess:  model.AutomationContinuationImportShopActions,
+	}
+	job := &model.AutomationJob{}
+	link.apply(job)
+	if job.WorkflowID == nil || *job.WorkflowID != 7 || job.OnSuccess != model.AutomationContinuationImportShopActions {
+		t.Fatalf("apply() job = %+v", job)
+	}
+	if deps := link.dependencies(); len(deps) != 2 {
+		t.Fatalf("dependencies() = %v, want deduplicated", deps)
+	}
+
+	var nilLink *AutomationJobLink
+	nilLink.apply(job)
+	if nilLink.dependencies() != nil {
+		t.Fatalf("nil link should have no dependencies")
+	}
+}
+
+func TestContinuationDueOnlyForSucceededJobs(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		job  model.AutomationJob
+		want bool
+	}{
+		{"success", model.AutomationJob{OnSuccess: model.AutomationContinuationImportShopActions, Status: model.AutomationJobStatusSuccess}, true},
+		{"partial", model.AutomationJob{OnSuccess: model.AutomationContinuationImportShopActions, Status: model.AutomationJobStatusPartialSuccess}, true},
+		{"failed", model.AutomationJob{OnSuccess: model.AutomationContinuationImportShopActions, Status: model.AutomationJobStatusFailed}, false},
+		{"no continuation", model.AutomationJob{Status: model.AutomationJobStatusSuccess}, false},
+	}
+	for _, tc := range cases {
+		if got := continuationDue(&tc.job); got != tc.want {
+			t.Fatalf("%s: continuationDue() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
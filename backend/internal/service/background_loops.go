@@ -0,0 +1,61 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// backgroundLoops 管理服务内的定时巡检 goroutine，零值可用。
+// Stop 后不再触发新的一轮，并等待正在执行的一轮结束。
+type backgroundLoops struct {
+	mu      sync.Mutex
+	stop    chan struct{}
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+func (l *backgroundLoops) stopChan() chan struct{} {
+	if l.stop == nil {
+		l.stop = make(chan struct{})
+	}
+	return l.stop
+}
+
+// Go 立即执行一次 fn，之后每隔 interval 执行一次，直到 Stop
+func (l *backgroundLoops) Go(interval time.Duration, fn func(now time.Time)) {
+	l.mu.Lock()
+	if l.stopped {
+		l.mu.Unlock()
+		return
+	}
+	stop := l.stopChan()
+	l.wg.Add(1)
+	l.mu.Unlock()
+
+	go func() {
+		defer l.wg.Done()
+		fn(time.Now())
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				fn(now)
+			}
+		}
+	}()
+}
+
+// Stop 停止所有定时巡检并等待当前一轮执行结束，可重复调用
+func (l *backgroundLoops) Stop() {
+	l.mu.Lock()
+	if !l.stopped {
+		l.stopped = true
+		close(l.stopChan())
+	}
+	l.mu.Unlock()
+	l.wg.Wait()
+}
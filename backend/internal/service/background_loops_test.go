@@ -0,0 +1,47 @@
+package service
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackgroundLoopsStopWaitsForRunningRound(t *testing.T) {
+	t.Parallel()
+
+	var loops backgroundLoops
+	var rounds int32
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	loops.Go(time.Millisecond, func(now time.Time) {
+		if atomic.AddInt32(&rounds, 1) == 1 {
+			close(entered)
+			<-release
+		}
+	})
+	<-entered
+
+	stopped := make(chan struct{})
+	go func() {
+		loops.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatalf("Stop() returned while a round was still running")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	<-stopped
+	after := atomic.LoadInt32(&rounds)
+	time.Sleep(10 * time.Millisecond)
+	if got := atomic.LoadInt32(&rounds); got != after {
+		t.Fatalf("rounds after Stop() = %d, want %d", got, after)
+	}
+
+	loops.Go(time.Millisecond, func(now time.Time) {
+		t.Errorf("loop started after Stop()")
+	})
+	loops.Stop()
+}
@@ -0,0 +1,678 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+	"ozon-manager/pkg/automation/protocol"
+	"ozon-manager/pkg/ozon"
+)
+
+// 批量操作快照与撤销：改价、报名 / 退出活动前记录每个商品的价格与活动参与情况，
+// 撤销时通过同样的 Ozon 改价、报名接口（店铺活动为浏览器插件任务）恢复
+
+const bulkOperationRevertReason = "bulk_operation_revert"
+
+type bulkOperationRevertPayload struct {
+	OperationID uint `json:"operation_id"`
+	ShopID      uint `json:"shop_id"`
+}
+
+// bulkOperationTarget 需要快照的商品及操作会涉及的店铺活动
+type bulkOperationTarget struct {
+	Product     model.Product
+	ShopActions []model.PromotionAction
+}
+
+func bulkOperationTargets(products []model.Product, shopActions []model.PromotionAction) []bulkOperationTarget {
+	targets := make([]bulkOperationTarget, 0, len(products))
+	for _, product := range products {
+		targets = append(targets, bulkOperationTarget{Product: product, ShopActions: shopActions})
+	}
+	return targets
+}
+
+func lossProductsOf(lossProducts []model.LossProduct) []model.Product {
+	products := make([]model.Product, 0, len(lossProducts))
+	for _, lp := range lossProducts {
+		products = append(products, lp.Product)
+	}
+	return products
+}
+
+func lossProductTargets(lossProducts []model.LossProduct) []bulkOperationTarget {
+	return bulkOperationTargets(lossProductsOf(lossProducts), nil)
+}
+
+// skuTargets 按 SKU 查找本地商品，找不到的商品操作时也会跳过，不记录快照
+func (s *PromotionService) skuTargets(shopID uint, skus []string, shopActions []model.PromotionAction) ([]bulkOperationTarget, error) {
+	products, err := s.productRepo.FindBySourceSKUs(shopID, uniqueSKUs(skus))
+	if err != nil {
+		return nil, err
+	}
+	list := make([]model.Product, 0, len(products))
+	for _, sku := range uniqueSKUs(skus) {
+		if product, ok := products[sku]; ok {
+			list = append(list, product)
+		}
+	}
+	return bulkOperationTargets(list, shopActions), nil
+}
+
+// snapshotRepriceItems 改价类操作按导入的 SKU 保存快照
+func (s *PromotionService) snapshotRepriceItems(userID, shopID uint, operationType string, items []dto.RepriceItem, shopActions []model.PromotionAction) (*model.BulkOperation, error) {
+	skus := make([]string, 0, len(items))
+	for _, item := range items {
+		skus = append(skus, item.SourceSKU)
+	}
+	targets, err := s.skuTargets(shopID, skus, shopActions)
+	if err != nil {
+		return nil, fmt.Errorf("保存操作快照失败: %w", err)
+	}
+	return s.snapshotBulkOperation(userID, shopID, operationType, targets)
+}
+
+// snapshotBulkOperation 在执行批量操作前保存快照；没有商品时不创建，返回 nil
+func (s *PromotionService) snapshotBulkOperation(userID, shopID uint, operationType string, targets []bulkOperationTarget) (*model.BulkOperation, error) {
+	merged := make([]bulkOperationTarget, 0, len(targets))
+	indexByProduct := make(map[uint]int, len(targets))
+	for _, target := range targets {
+		if index, ok := indexByProduct[target.Product.ID]; ok {
+			merged[index].ShopActions = append(merged[index].ShopActions, target.ShopActions...)
+			continue
+		}
+		indexByProduct[target.Product.ID] = len(merged)
+		merged = append(merged, target)
+	}
+	if len(merged) == 0 {
+		return nil, nil
+	}
+
+	productIDs := make([]uint, 0, len(merged))
+	skus := make([]string, 0, len(merged))
+	scopeIDs := make([]uint, 0)
+	for _, target := range merged {
+		productIDs = append(productIDs, target.Product.ID)
+		skus = append(skus, target.Product.SourceSKU)
+		for _, action := range target.ShopActions {
+			scopeIDs = append(scopeIDs, action.ID)
+		}
+	}
+
+	promoted, err := s.promotionRepo.FindActivePromotedProductsByProductIDs(productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("保存操作快照失败: %w", err)
+	}
+	promotedByProduct := make(map[uint][]model.PromotedProduct)
+	for _, pp := range promoted {
+		promotedByProduct[pp.ProductID] = append(promotedByProduct[pp.ProductID], pp)
+	}
+
+	actionProducts, err := s.promotionRepo.ListActionProductsByActionIDsAndSourceSKUs(shopID, uniqueUints(scopeIDs), skus)
+	if err != nil {
+		return nil, fmt.Errorf("保存操作快照失败: %w", err)
+	}
+	joined := make(map[string]map[uint]struct{})
+	for _, row := range actionProducts {
+		if joined[row.SourceSKU] == nil {
+			joined[row.SourceSKU] = make(map[uint]struct{})
+		}
+		joined[row.SourceSKU][row.PromotionActionID] = struct{}{}
+	}
+
+	operation := &model.BulkOperation{
+		ShopID:        shopID,
+		OperationType: operationType,
+		Status:        model.BulkOperationStatusApplied,
+		CreatedBy:     userID,
+		ItemCount:     len(merged),
+		JobIDs:        datatypes.JSON("[]"),
+		RevertJobIDs:  datatypes.JSON("[]"),
+		Items:         make([]model.BulkOperationItem, 0, len(merged)),
+	}
+	for _, target := range merged {
+		operation.Items = append(operation.Items, buildBulkOperationItem(target, promotedByProduct[target.Product.ID], joined[target.Product.SourceSKU]))
+	}
+	if err := s.promotionRepo.CreateBulkOperation(operation); err != nil {
+		return nil, fmt.Errorf("保存操作快照失败: %w", err)
+	}
+	return operation, nil
+}
+
+// buildBulkOperationItem 记录商品当前价格、参与的官方活动，以及在操作涉及的各店铺活动中是否已参与
+func buildBulkOperationItem(target bulkOperationTarget, promoted []model.PromotedProduct, joined map[uint]struct{}) model.BulkOperationItem {
+	official := make([]dto.BulkOperationOfficialAction, 0, len(promoted))
+	seenOfficial := make(map[int64]struct{}, len(promoted))
+	for _, pp := range promoted {
+		if _, ok := seenOfficial[pp.ActionID]; ok {
+			continue
+		}
+		seenOfficial[pp.ActionID] = struct{}{}
+		official = append(official, dto.BulkOperationOfficialAction{ActionID: pp.ActionID, ActionPrice: pp.ActionPrice})
+	}
+
+	shop := make([]dto.BulkOperationShopAction, 0, len(target.ShopActions))
+	seenShop := make(map[uint]struct{}, len(target.ShopActions))
+	for _, action := range target.ShopActions {
+		if _, ok := seenShop[action.ID]; ok {
+			continue
+		}
+		seenShop[action.ID] = struct{}{}
+		_, isJoined := joined[action.ID]
+		shop = append(shop, dto.BulkOperationShopAction{
+			PromotionActionID: action.ID,
+			Title:             displayActionName(action),
+			Joined:            isJoined,
+		})
+	}
+
+	officialJSON, _ := json.Marshal(official)
+	shopJSON, _ := json.Marshal(shop)
+	return model.BulkOperationItem{
+		ProductID:       target.Product.ID,
+		SourceSKU:       target.Product.SourceSKU,
+		OzonProductID:   target.Product.OzonProductID,
+		PreviousPrice:   target.Product.CurrentPrice,
+		WasPromoted:     target.Product.IsPromoted,
+		OfficialActions: officialJSON,
+		ShopActions:     shopJSON,
+		RevertStatus:    model.BulkOperationItemStatusPending,
+	}
+}
+
+// linkBulkOperationJobs 记录操作创建的店铺活动任务，撤销前需等待这些任务结束
+func (s *PromotionService) linkBulkOperationJobs(operation *model.BulkOperation, jobIDs ...uint) {
+	if operation == nil || len(jobIDs) == 0 {
+		return
+	}
+	ids := append(decodeActionIDs(operation.JobIDs), jobIDs...)
+	encoded, _ := json.Marshal(uniqueUints(ids))
+	operation.JobIDs = encoded
+	_ = s.promotionRepo.UpdateBulkOperation(operation)
+}
+
+// bulkItemRevertPlan 单个商品恢复到快照状态需要执行的操作
+type bulkItemRevertPlan struct {
+	// Deactivate 当前参与、快照中没有的官方活动
+	Deactivate []model.PromotedProduct
+	// Activate 快照中参与、当前未参与或活动价不同的官方活动，按快照活动价报名
+	Activate []dto.BulkOperationOfficialAction
+	// RestorePrice 直接调用 Ozon 改价接口恢复原价
+	RestorePrice bool
+	// ShopReadd 操作前参与的店铺活动，需要恢复原价时通过 remove_reprice_readd 任务退出 → 恢复原价 → 重新报名
+	ShopReadd []uint
+	// ShopDeclare 操作前参与、无需改价的店铺活动，重新申报
+	ShopDeclare []uint
+	// ShopRemove 操作前未参与的店铺活动，退出
+	ShopRemove []uint
+}
+
+func planBulkOperationItemRevert(item model.BulkOperationItem, current []model.PromotedProduct, currentPrice float64) bulkItemRevertPlan {
+	plan := bulkItemRevertPlan{}
+
+	official := decodeBulkOperationOfficialActions(item.OfficialActions)
+	snapshotActions := make(map[int64]struct{}, len(official))
+	for _, action := range official {
+		snapshotActions[action.ActionID] = struct{}{}
+	}
+	currentPrices := make(map[int64]float64, len(current))
+	for _, pp := range current {
+		currentPrices[pp.ActionID] = pp.ActionPrice
+		if _, ok := snapshotActions[pp.ActionID]; !ok {
+			plan.Deactivate = append(plan.Deactivate, pp)
+		}
+	}
+	for _, action := range official {
+		if price, ok := currentPrices[action.ActionID]; ok && !priceChanged(price, action.ActionPrice) {
+			continue
+		}
+		plan.Activate = append(plan.Activate, action)
+	}
+
+	needsPrice := item.PreviousPrice > 0 && priceChanged(currentPrice, item.PreviousPrice)
+	joined := make([]uint, 0)
+	for _, action := range decodeBulkOperationShopActions(item.ShopActions) {
+		if action.Joined {
+			joined = append(joined, action.PromotionActionID)
+		} else {
+			plan.ShopRemove = append(plan.ShopRemove, action.PromotionActionID)
+		}
+	}
+	switch {
+	case needsPrice && len(joined) > 0:
+		plan.ShopReadd = joined
+	case needsPrice:
+		plan.RestorePrice = true
+	case len(joined) > 0:
+		plan.ShopDeclare = joined
+	}
+	return plan
+}
+
+// RevertBulkOperation 撤销批量操作：校验后置为撤销中，由后台任务按快照恢复
+func (s *PromotionService) RevertBulkOperation(userID, shopID, operationID uint) (*dto.BulkOperationResponse, error) {
+	if err := s.shopGuard.CheckWritable(shopID); err != nil {
+		return nil, err
+	}
+	operation, err := s.promotionRepo.FindBulkOperationByIDAndShop(operationID, shopID)
+	if err != nil {
+		return nil, err
+	}
+	switch operation.Status {
+	case model.BulkOperationStatusReverted:
+		return nil, fmt.Errorf("该操作已撤销")
+	case model.BulkOperationStatusReverting:
+		return nil, fmt.Errorf("该操作正在撤销中")
+	}
+	if s.automationService != nil {
+		for _, jobID := range decodeActionIDs(operation.JobIDs) {
+			job, findErr := s.automationService.FindJobByIDAndShop(jobID, shopID)
+			if findErr != nil {
+				continue
+			}
+			switch job.Status {
+			case model.AutomationJobStatusSuccess, model.AutomationJobStatusPartialSuccess, model.AutomationJobStatusFailed, model.AutomationJobStatusCanceled:
+			default:
+				return nil, fmt.Errorf("操作创建的店铺活动任务 #%d 尚未结束，请等待任务结束后再撤销", jobID)
+			}
+		}
+	}
+
+	started, err := s.promotionRepo.StartBulkOperationRevert(operation.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !started {
+		return nil, fmt.Errorf("该操作正在撤销中或已撤销")
+	}
+	operation.Status = model.BulkOperationStatusReverting
+	operation.RevertedBy = userIDPointer(userID)
+
+	payload := bulkOperationRevertPayload{OperationID: operation.ID, ShopID: shopID}
+	if s.taskQueue == nil {
+		go func() { _ = s.handleBulkOperationRevertTask(context.Background(), payload) }()
+	} else if err := s.taskQueue.Enqueue(model.BackgroundTaskTypeBulkOperationRevert, payload); err != nil {
+		operation.Status = model.BulkOperationStatusRevertFailed
+		operation.ErrorMessage = "提交撤销失败: " + err.Error()
+		_ = s.promotionRepo.UpdateBulkOperation(operation)
+		return nil, err
+	}
+	return toBulkOperationDTO(operation, false), nil
+}
+
+// registerBulkOperationTasks 撤销通过持久化任务队列执行
+func (s *PromotionService) registerBulkOperationTasks(queue *TaskQueue) {
+	queue.Register(model.BackgroundTaskTypeBulkOperationRevert, TaskOptions{
+		MaxAttempts: 3,
+		Timeout:     5 * time.Minute,
+	}, TypedTaskHandler(s.handleBulkOperationRevertTask))
+}
+
+func (s *PromotionService) handleBulkOperationRevertTask(ctx context.Context, payload bulkOperationRevertPayload) error {
+	operation, err := s.promotionRepo.FindBulkOperationByIDAndShop(payload.OperationID, payload.ShopID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if operation.Status != model.BulkOperationStatusReverting {
+		return nil
+	}
+
+	if err := s.revertBulkOperation(ctx, operation); err != nil {
+		if ctx.Err() != nil {
+			// 服务停止：操作保持撤销中，任务退还队列后重新处理未恢复的商品
+			return err
+		}
+		summarizeBulkOperation(operation)
+		operation.Status = model.BulkOperationStatusRevertFailed
+		operation.ErrorMessage = err.Error()
+		_ = s.promotionRepo.UpdateBulkOperation(operation)
+	}
+	return nil
+}
+
+// bulkShopRevertGroup 活动组合相同的商品合并为一个店铺活动任务
+type bulkShopRevertGroup struct {
+	JobType   string
+	ActionIDs []uint
+	Items     []*model.BulkOperationItem
+}
+
+// revertBulkOperation 逐个商品同步恢复官方活动与价格，店铺活动部分按活动组合创建浏览器插件任务
+func (s *PromotionService) revertBulkOperation(ctx context.Context, operation *model.BulkOperation) error {
+	if err := s.shopGuard.CheckWritable(operation.ShopID); err != nil {
+		return err
+	}
+	shop, err := s.shopRepo.GetWithCredentials(operation.ShopID)
+	if err != nil {
+		return fmt.Errorf("shop not found: %w", err)
+	}
+	client := s.shopGuard.OzonClient(shop)
+
+	var userID uint
+	if operation.RevertedBy != nil {
+		userID = *operation.RevertedBy
+	}
+	source := userParticipationSource(model.PromotionParticipationOriginBulkRevert, userID)
+	source.Reference = fmt.Sprintf("bulk_operation:%d", operation.ID)
+
+	groups := make(map[string]*bulkShopRevertGroup)
+	addToGroup := func(jobType string, actionIDs []uint, item *model.BulkOperationItem) {
+		if len(actionIDs) == 0 {
+			return
+		}
+		ids := uniqueUints(actionIDs)
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		key := fmt.Sprintf("%s:%v", jobType, ids)
+		if groups[key] == nil {
+			groups[key] = &bulkShopRevertGroup{JobType: jobType, ActionIDs: ids}
+		}
+		groups[key].Items = append(groups[key].Items, item)
+	}
+
+	for index := range operation.Items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		item := &operation.Items[index]
+		if item.RevertStatus == model.BulkOperationItemStatusRestored || item.RevertStatus == model.BulkOperationItemStatusQueued {
+			continue
+		}
+
+		plan, err := s.revertBulkOperationItem(client, operation.ShopID, item, source)
+		if err != nil {
+			item.RevertStatus = model.BulkOperationItemStatusFailed
+			item.RevertNote = err.Error()
+			_ = s.promotionRepo.UpdateBulkOperationItem(item)
+			continue
+		}
+		addToGroup(model.AutomationJobTypePromoUnifiedRemove, plan.ShopRemove, item)
+		addToGroup(model.AutomationJobTypeRemoveRepriceReadd, plan.ShopReadd, item)
+		addToGroup(model.AutomationJobTypePromoUnifiedEnroll, plan.ShopDeclare, item)
+		if len(plan.ShopRemove)+len(plan.ShopReadd)+len(plan.ShopDeclare) == 0 {
+			now := time.Now()
+			item.RevertStatus = model.BulkOperationItemStatusRestored
+			item.RevertNote = ""
+			item.RevertedAt = &now
+			_ = s.promotionRepo.UpdateBulkOperationItem(item)
+		}
+	}
+
+	jobIDs := s.createBulkShopRevertJobs(userID, operation.ShopID, groups)
+	ids := append(decodeActionIDs(operation.RevertJobIDs), jobIDs...)
+	operation.RevertJobIDs, _ = json.Marshal(uniqueUints(ids))
+
+	now := time.Now()
+	summarizeBulkOperation(operation)
+	operation.RevertedAt = &now
+	operation.Status = model.BulkOperationStatusReverted
+	operation.ErrorMessage = ""
+	if operation.FailedCount > 0 {
+		operation.Status = model.BulkOperationStatusRevertFailed
+		operation.ErrorMessage = fmt.Sprintf("%d 个商品未能恢复，可再次撤销重试", operation.FailedCount)
+	}
+	return s.promotionRepo.UpdateBulkOperation(operation)
+}
+
+// revertBulkOperationItem 按快照退出多余的官方活动、恢复原价、重新报名原来的官方活动，返回店铺活动部分的计划
+func (s *PromotionService) revertBulkOperationItem(client *ozon.Client, shopID uint, item *model.BulkOperationItem, source participationSource) (bulkItemRevertPlan, error) {
+	product, err := s.productRepo.FindByID(item.ProductID)
+	if err != nil {
+		return bulkItemRevertPlan{}, fmt.Errorf("商品不存在")
+	}
+	current, err := s.promotionRepo.FindPromotedProductsByProductID(product.ID)
+	if err != nil {
+		return bulkItemRevertPlan{}, err
+	}
+	plan := planBulkOperationItemRevert(*item, current, product.CurrentPrice)
+
+	for _, pp := range plan.Deactivate {
+		if _, err := client.DeactivateProducts(pp.ActionID, []int64{product.OzonProductID}); err != nil {
+			return plan, fmt.Errorf("退出活动 %d 失败: %v", pp.ActionID, err)
+		}
+		_ = s.promotionRepo.ExitPromotionAction(product.ID, pp.ActionID)
+		s.recordParticipation(shopID, source.actionEvent(model.PromotionParticipationLeft, *product, "official", pp.ActionID, "", pp.ActionPrice))
+	}
+
+	if plan.RestorePrice {
+		priceStr := fmt.Sprintf("%.2f", item.PreviousPrice)
+		if err := client.UpdateSinglePrice(product.OzonProductID, priceStr, "", ""); err != nil {
+			return plan, fmt.Errorf("恢复原价失败: %v", err)
+		}
+		_ = s.productRepo.UpdatePrice(product.ID, item.PreviousPrice)
+		s.recordParticipation(shopID, source.repricedEvent(*product, item.PreviousPrice))
+		s.priceHistory.Record(appliedPriceChange(*product, item.PreviousPrice, model.PriceSourceBulkRevert, source.TriggeredBy, source.Reference))
+		product.CurrentPrice = item.PreviousPrice
+	}
+
+	for _, action := range plan.Activate {
+		resp, err := client.ActivateProducts(action.ActionID, []ozon.ActivateProductItem{{
+			ProductID:   product.OzonProductID,
+			ActionPrice: action.ActionPrice,
+		}})
+		if err != nil {
+			return plan, fmt.Errorf("重新报名活动 %d 失败: %v", action.ActionID, err)
+		}
+		if len(resp.Result.Rejected) > 0 {
+			return plan, fmt.Errorf("重新报名活动 %d 被拒绝: %s", action.ActionID, resp.Result.Rejected[0].Reason)
+		}
+		_ = s.promotionRepo.ApplyPromotionReconcile([]model.PromotedProduct{{
+			ProductID:     product.ID,
+			PromotionType: "custom",
+			ActionID:      action.ActionID,
+			ActionPrice:   action.ActionPrice,
+		}}, nil)
+		s.recordParticipation(shopID, source.actionEvent(model.PromotionParticipationJoined, *product, "official", action.ActionID, "", action.ActionPrice))
+	}
+
+	_ = s.productRepo.UpdatePromotedStatus(product.ID, item.WasPromoted)
+	return plan, nil
+}
+
+// createBulkShopRevertJobs 先退出、再改价重新报名、最后申报，任务创建失败的商品记为失败
+func (s *PromotionService) createBulkShopRevertJobs(userID, shopID uint, groups map[string]*bulkShopRevertGroup) []uint {
+	jobIDs := make([]uint, 0)
+	if len(groups) == 0 {
+		return jobIDs
+	}
+
+	keys := make([]string, 0, len(groups))
+	actionIDs := make([]uint, 0)
+	for key, group := range groups {
+		keys = append(keys, key)
+		actionIDs = append(actionIDs, group.ActionIDs...)
+	}
+	jobOrder := map[string]int{
+		model.AutomationJobTypePromoUnifiedRemove: 0,
+		model.AutomationJobTypeRemoveRepriceReadd: 1,
+		model.AutomationJobTypePromoUnifiedEnroll: 2,
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		left, right := groups[keys[i]], groups[keys[j]]
+		if jobOrder[left.JobType] != jobOrder[right.JobType] {
+			return jobOrder[left.JobType] < jobOrder[right.JobType]
+		}
+		return keys[i] < keys[j]
+	})
+
+	actions, err := s.promotionRepo.FindPromotionActionsByIDs(shopID, uniqueUints(actionIDs))
+	actionsByID := make(map[uint]model.PromotionAction, len(actions))
+	if err == nil {
+		for _, action := range actions {
+			actionsByID[action.ID] = action
+		}
+	}
+
+	// 商品可能同时属于多个任务：任一任务创建失败即记为失败，否则记为已提交任务
+	failures := make(map[*model.BulkOperationItem]string)
+	itemJobs := make(map[*model.BulkOperationItem][]string)
+	ordered := make([]*model.BulkOperationItem, 0)
+	for _, key := range keys {
+		group := groups[key]
+		for _, item := range group.Items {
+			if _, ok := itemJobs[item]; !ok {
+				itemJobs[item] = []string{}
+				ordered = append(ordered, item)
+			}
+		}
+
+		groupActions := make([]model.PromotionAction, 0, len(group.ActionIDs))
+		for _, id := range group.ActionIDs {
+			if action, ok := actionsByID[id]; ok {
+				groupActions = append(groupActions, action)
+			}
+		}
+		var job *model.AutomationJob
+		var createErr error
+		switch {
+		case len(groupActions) < len(group.ActionIDs):
+			createErr = fmt.Errorf("店铺活动已不存在")
+		case group.JobType == model.AutomationJobTypeRemoveRepriceReadd:
+			items := make([]dto.RepriceItem, 0, len(group.Items))
+			for _, item := range group.Items {
+				items = append(items, dto.RepriceItem{SourceSKU: item.SourceSKU, NewPrice: item.PreviousPrice})
+			}
+			job, createErr = s.createRemoveRepriceReaddJob(userID, shopID, items, &protocol.RemoveRepriceReaddMeta{
+				Reason:      bulkOperationRevertReason,
+				ShopActions: buildShopActionsMeta(groupActions),
+			})
+		default:
+			skus := make([]string, 0, len(group.Items))
+			for _, item := range group.Items {
+				skus = append(skus, item.SourceSKU)
+			}
+			job, createErr = s.CreateUnifiedShopActionsJob(userID, shopID, group.JobType, groupActions, skus)
+		}
+
+		for _, item := range group.Items {
+			if createErr != nil {
+				failures[item] = "创建店铺活动任务失败: " + createErr.Error()
+				continue
+			}
+			itemJobs[item] = append(itemJobs[item], fmt.Sprintf("#%d", job.ID))
+		}
+		if createErr == nil {
+			jobIDs = append(jobIDs, job.ID)
+		}
+	}
+
+	now := time.Now()
+	for _, item := range ordered {
+		if message, failed := failures[item]; failed {
+			item.RevertStatus = model.BulkOperationItemStatusFailed
+			item.RevertNote = message
+		} else {
+			item.RevertStatus = model.BulkOperationItemStatusQueued
+			item.RevertNote = "店铺活动任务 " + strings.Join(itemJobs[item], "、")
+			item.RevertedAt = &now
+		}
+		_ = s.promotionRepo.UpdateBulkOperationItem(item)
+	}
+	return jobIDs
+}
+
+// summarizeBulkOperation 按明细状态重新统计撤销计数
+func summarizeBulkOperation(operation *model.BulkOperation) {
+	operation.RestoredCount, operation.FailedCount = 0, 0
+	for _, item := range operation.Items {
+		switch item.RevertStatus {
+		case model.BulkOperationItemStatusRestored, model.BulkOperationItemStatusQueued:
+			operation.RestoredCount++
+		case model.BulkOperationItemStatusFailed:
+			operation.FailedCount++
+		}
+	}
+}
+
+// ListBulkOperations 查询店铺的批量操作快照
+func (s *PromotionService) ListBulkOperations(req *dto.BulkOperationListRequest) (*dto.BulkOperationListResponse, error) {
+	operations, total, err := s.promotionRepo.ListBulkOperations(req.ShopID, req.OperationType, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.BulkOperationResponse, 0, len(operations))
+	for index := range operations {
+		items = append(items, *toBulkOperationDTO(&operations[index], false))
+	}
+	return &dto.BulkOperationListResponse{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Items:    items,
+	}, nil
+}
+
+// GetBulkOperation 查询批量操作快照及各商品的撤销结果
+func (s *PromotionService) GetBulkOperation(shopID, operationID uint) (*dto.BulkOperationResponse, error) {
+	operation, err := s.promotionRepo.FindBulkOperationByIDAndShop(operationID, shopID)
+	if err != nil {
+		return nil, err
+	}
+	return toBulkOperationDTO(operation, true), nil
+}
+
+func decodeBulkOperationOfficialActions(raw datatypes.JSON) []dto.BulkOperationOfficialAction {
+	result := make([]dto.BulkOperationOfficialAction, 0)
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &result)
+	}
+	return result
+}
+
+func decodeBulkOperationShopActions(raw datatypes.JSON) []dto.BulkOperationShopAction {
+	result := make([]dto.BulkOperationShopAction, 0)
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &result)
+	}
+	return result
+}
+
+func toBulkOperationDTO(operation *model.BulkOperation, withItems bool) *dto.BulkOperationResponse {
+	resp := &dto.BulkOperationResponse{
+		ID:            operation.ID,
+		ShopID:        operation.ShopID,
+		OperationType: operation.OperationType,
+		Status:        operation.Status,
+		CreatedBy:     operation.CreatedBy,
+		ItemCount:     operation.ItemCount,
+		JobIDs:        decodeActionIDs(operation.JobIDs),
+		RestoredCount: operation.RestoredCount,
+		FailedCount:   operation.FailedCount,
+		RevertJobIDs:  decodeActionIDs(operation.RevertJobIDs),
+		ErrorMessage:  operation.ErrorMessage,
+		RevertedAt:    formatOptionalTime(operation.RevertedAt),
+		CreatedAt:     operation.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if !withItems {
+		return resp
+	}
+	resp.Items = make([]dto.BulkOperationItemResponse, 0, len(operation.Items))
+	for _, item := range operation.Items {
+		resp.Items = append(resp.Items, dto.BulkOperationItemResponse{
+			ID:              item.ID,
+			ProductID:       item.ProductID,
+			SourceSKU:       item.SourceSKU,
+			OzonProductID:   item.OzonProductID,
+			PreviousPrice:   item.PreviousPrice,
+			WasPromoted:     item.WasPromoted,
+			OfficialActions: decodeBulkOperationOfficialActions(item.OfficialActions),
+			ShopActions:     decodeBulkOperationShopActions(item.ShopActions),
+			RevertStatus:    item.RevertStatus,
+			RevertNote:      item.RevertNote,
+			RevertedAt:      formatOptionalTime(item.RevertedAt),
+		})
+	}
+	return resp
+}
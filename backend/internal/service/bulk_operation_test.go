@@ -0,0 +1,107 @@
+package service
+
+import (
+	"testing"
+
+	"ozon-manager/internal/model"
+)
+
+func TestBuildBulkOperationItemRecordsPriceAndParticipation(t *testing.T) {
+	t.Parallel()
+
+	target := bulkOperationTarget{
+		Product: model.Product{ID: 7, SourceSKU: "SKU-7", OzonProductID: 7007, CurrentPrice: 129, IsPromoted: true},
+		ShopActions: []model.PromotionAction{
+			{ID: 31, Title: "店铺满减"},
+			{ID: 32, Title: "店铺折扣"},
+			{ID: 31, Title: "店铺满减"},
+		},
+	}
+	promoted := []model.PromotedProduct{
+		{ActionID: 9001, ActionPrice: 99},
+		{ActionID: 9001, ActionPrice: 99},
+		{ActionID: 9002, ActionPrice: 109},
+	}
+
+	item := buildBulkOperationItem(target, promoted, map[uint]struct{}{31: {}})
+	if item.PreviousPrice != 129 || !item.WasPromoted || item.RevertStatus != model.BulkOperationItemStatusPending {
+		t.Fatalf("unexpected item: %+v", item)
+	}
+
+	official := decodeBulkOperationOfficialActions(item.OfficialActions)
+	if len(official) != 2 || official[0].ActionID != 9001 || official[1].ActionPrice != 109 {
+		t.Fatalf("official actions = %+v", official)
+	}
+	shop := decodeBulkOperationShopActions(item.ShopActions)
+	if len(shop) != 2 || !shop[0].Joined || shop[1].Joined {
+		t.Fatalf("shop actions = %+v", shop)
+	}
+}
+
+func TestPlanBulkOperationItemRevertOfficialActions(t *testing.T) {
+	t.Parallel()
+
+	item := model.BulkOperationItem{
+		PreviousPrice:   129,
+		OfficialActions: []byte(`[{"action_id":9001,"action_price":99},{"action_id":9002,"action_price":109}]`),
+	}
+	current := []model.PromotedProduct{
+		{ActionID: 9001, ActionPrice: 99},
+		{ActionID: 9002, ActionPrice: 89},
+		{ActionID: 9003, ActionPrice: 79},
+	}
+
+	plan := planBulkOperationItemRevert(item, current, 129.001)
+	if len(plan.Deactivate) != 1 || plan.Deactivate[0].ActionID != 9003 {
+		t.Fatalf("deactivate = %+v", plan.Deactivate)
+	}
+	if len(plan.Activate) != 1 || plan.Activate[0].ActionID != 9002 || plan.Activate[0].ActionPrice != 109 {
+		t.Fatalf("activate = %+v", plan.Activate)
+	}
+	if plan.RestorePrice {
+		t.Fatalf("unchanged price should not be restored")
+	}
+}
+
+func TestPlanBulkOperationItemRevertShopActions(t *testing.T) {
+	t.Parallel()
+
+	item := model.BulkOperationItem{
+		PreviousPrice: 129,
+		ShopActions:   []byte(`[{"promotion_action_id":31,"joined":true},{"promotion_action_id":32,"joined":false}]`),
+	}
+
+	plan := planBulkOperationItemRevert(item, nil, 99)
+	if plan.RestorePrice || len(plan.ShopReadd) != 1 || plan.ShopReadd[0] != 31 {
+		t.Fatalf("joined shop action with price change should readd: %+v", plan)
+	}
+	if len(plan.ShopRemove) != 1 || plan.ShopRemove[0] != 32 {
+		t.Fatalf("shop remove = %+v", plan.ShopRemove)
+	}
+
+	plan = planBulkOperationItemRevert(item, nil, 129)
+	if len(plan.ShopReadd) != 0 || len(plan.ShopDeclare) != 1 || plan.ShopDeclare[0] != 31 {
+		t.Fatalf("joined shop action without price change should be declared: %+v", plan)
+	}
+
+	item.ShopActions = []byte(`[{"promotion_action_id":32,"joined":false}]`)
+	plan = planBulkOperationItemRevert(item, nil, 99)
+	if !plan.RestorePrice || len(plan.ShopReadd) != 0 {
+		t.Fatalf("price without joined shop actions should be restored directly: %+v", plan)
+	}
+}
+
+func TestSummarizeBulkOperationCountsByStatus(t *testing.T) {
+	t.Parallel()
+
+	operation := &model.BulkOperation{Items: []model.BulkOperationItem{
+		{RevertStatus: model.BulkOperationItemStatusRestored},
+		{RevertStatus: model.BulkOperationItemStatusQueued},
+		{RevertStatus: model.BulkOperationItemStatusFailed},
+		{RevertStatus: model.BulkOperationItemStatusPending},
+	}}
+	summarizeBulkOperation(operation)
+	if operation.RestoredCount != 2 || operation.FailedCount != 1 {
+		t.Fatalf("restored=%d failed=%d", operation.RestoredCount, operation.FailedCount)
+	}
+}
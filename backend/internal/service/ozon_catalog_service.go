@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -41,6 +42,14 @@ type OzonCatalogService struct {
 
 	refreshMu      sync.RWMutex
 	refreshStateBy map[uint]*ozonCatalogRefreshState
+
+	taskQueue *TaskQueue
+
+	priceHistory *PriceHistoryService
+}
+
+type ozonCatalogRefreshPayload struct {
+	ShopID uint `json:"shop_id"`
 }
 
 func NewOzonCatalogService(
@@ -54,6 +63,22 @@ func NewOzonCatalogService(
 	}
 }
 
+// ConfigureTaskQueue 后台刷新改为通过持久化任务队列执行，进程重启后未完成的刷新会继续
+func (s *OzonCatalogService) ConfigureTaskQueue(queue *TaskQueue) {
+	s.taskQueue = queue
+	queue.Register(model.BackgroundTaskTypeOzonCatalogRefresh, TaskOptions{
+		MaxAttempts: 3,
+		Timeout:     10 * time.Minute,
+		BaseDelay:   30 * time.Second,
+		MaxDelay:    5 * time.Minute,
+	}, TypedTaskHandler(s.handleRefreshTask))
+}
+
+// ConfigurePriceHistory 目录刷新时记录远端价格变动
+func (s *OzonCatalogService) ConfigurePriceHistory(priceHistory *PriceHistoryService) {
+	s.priceHistory = priceHistory
+}
+
 func (s *OzonCatalogService) GetCatalog(req *dto.OzonCatalogListRequest) (*dto.OzonCatalogListResponse, error) {
 	pageSize := req.PageSize
 	if pageSize <= 0 {
@@ -216,11 +241,18 @@ func (s *OzonCatalogService) TriggerRefresh(req *dto.OzonCatalogRefreshRequest)
 	resp := toRefreshResponse("started", state)
 	s.refreshMu.Unlock()
 
-	go s.refreshShopCatalog(req.ShopID)
+	if s.taskQueue == nil {
+		go s.refreshShopCatalog(req.ShopID)
+		return resp, nil
+	}
+	if err := s.taskQueue.Enqueue(model.BackgroundTaskTypeOzonCatalogRefresh, ozonCatalogRefreshPayload{ShopID: req.ShopID}); err != nil {
+		s.updateRefreshState(req.ShopID, err)
+		return nil, fmt.Errorf("failed to schedule catalog refresh: %w", err)
+	}
 	return resp, nil
 }
 
-func (s *OzonCatalogService) RefreshShopCatalogSync(shopID uint) error {
+func (s *OzonCatalogService) RefreshShopCatalogSync(ctx context.Context, shopID uint) error {
 	if _, err := s.shopRepo.GetWithCredentials(shopID); err != nil {
 		return fmt.Errorf("shop not found")
 	}
@@ -247,7 +279,7 @@ func (s *OzonCatalogService) RefreshShopCatalogSync(shopID uint) error {
 		}
 	}()
 
-	err := s.syncCatalogFromOzon(shopID)
+	err := s.syncCatalogFromOzon(ctx, shopID)
 	s.updateRefreshState(shopID, err)
 	return err
 }
@@ -259,10 +291,37 @@ func (s *OzonCatalogService) refreshShopCatalog(shopID uint) {
 		}
 	}()
 
-	err := s.syncCatalogFromOzon(shopID)
+	err := s.syncCatalogFromOzon(context.Background(), shopID)
 	s.updateRefreshState(shopID, err)
 }
 
+// handleRefreshTask 队列中的刷新任务；重试或重启后执行时重新标记为刷新中
+func (s *OzonCatalogService) handleRefreshTask(ctx context.Context, payload ozonCatalogRefreshPayload) error {
+	now := time.Now()
+	s.refreshMu.Lock()
+	state, exists := s.refreshStateBy[payload.ShopID]
+	if !exists {
+		state = &ozonCatalogRefreshState{}
+		s.refreshStateBy[payload.ShopID] = state
+	}
+	state.Running = true
+	if state.LastStartedAt == nil {
+		state.LastStartedAt = &now
+	}
+	s.refreshMu.Unlock()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.updateRefreshState(payload.ShopID, fmt.Errorf("panic: %v", recovered))
+			panic(recovered)
+		}
+	}()
+
+	err := s.syncCatalogFromOzon(ctx, payload.ShopID)
+	s.updateRefreshState(payload.ShopID, err)
+	return err
+}
+
 func (s *OzonCatalogService) updateRefreshState(shopID uint, refreshErr error) {
 	now := time.Now()
 
@@ -282,7 +341,7 @@ func (s *OzonCatalogService) updateRefreshState(shopID uint, refreshErr error) {
 	}
 }
 
-func (s *OzonCatalogService) syncCatalogFromOzon(shopID uint) error {
+func (s *OzonCatalogService) syncCatalogFromOzon(ctx context.Context, shopID uint) error {
 	shop, err := s.shopRepo.GetWithCredentials(shopID)
 	if err != nil {
 		return err
@@ -294,6 +353,10 @@ func (s *OzonCatalogService) syncCatalogFromOzon(shopID uint) error {
 	seenCursor := map[string]struct{}{}
 
 	for {
+		// 写库前的拉取阶段随时可以中断，已拉取的数据不落库，下次刷新重新开始
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		resp, err := client.GetProductListV3(ozonCatalogRemotePageSize, lastID, "ALL")
 		if err != nil {
 			return err
@@ -362,6 +425,9 @@ func (s *OzonCatalogService) syncCatalogFromOzon(shopID uint) error {
 			end = len(productIDs)
 		}
 		batchIDs := productIDs[start:end]
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
 		infoResp, err := client.GetProductInfoList(batchIDs, nil)
 		if err != nil {
@@ -464,6 +530,7 @@ func (s *OzonCatalogService) syncCatalogFromOzon(shopID uint) error {
 	}
 
 	items := make([]model.OzonProductCatalogItem, 0, len(catalogByProductID))
+	priceChanges := make([]model.PriceHistory, 0)
 	for productID, item := range catalogByProductID {
 		if item.ListingDate == nil {
 			if existing, ok := existingItems[productID]; ok {
@@ -489,6 +556,9 @@ func (s *OzonCatalogService) syncCatalogFromOzon(shopID uint) error {
 		if item.OfferID == "" {
 			item.OfferID = strconv.FormatInt(item.OzonProductID, 10)
 		}
+		if existing, ok := existingItems[productID]; ok {
+			priceChanges = append(priceChanges, catalogPriceChanges(existing, *item)...)
+		}
 		item.SyncToken = syncToken
 		item.LastRemoteSyncedAt = &now
 		items = append(items, *item)
@@ -497,6 +567,7 @@ func (s *OzonCatalogService) syncCatalogFromOzon(shopID uint) error {
 	if err := s.ozonCatalogRepo.UpsertBatch(items); err != nil {
 		return err
 	}
+	s.priceHistory.Record(priceChanges...)
 	return s.ozonCatalogRepo.DeleteStaleBySyncToken(shopID, syncToken)
 }
 
@@ -694,6 +765,13 @@ func mergeCatalogInfo(target *model.OzonProductCatalogItem, info ozon.ProductInf
 		target.Currency = strings.TrimSpace(info.CurrencyCode)
 	}
 
+	if info.DescriptionCategoryID > 0 {
+		target.CategoryID = info.DescriptionCategoryID
+	}
+	if info.TypeID > 0 {
+		target.TypeID = info.TypeID
+	}
+
 	target.Price = parsePrice(info.Price)
 	target.OldPrice = parsePrice(info.OldPrice)
 	target.MinPrice = parsePrice(info.MinPrice)
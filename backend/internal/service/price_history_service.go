@@ -0,0 +1,197 @@
+package service
+
+import (
+	"fmt"
+	"math"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+	"ozon-manager/internal/repository"
+)
+
+// PriceHistoryService 价格变动流水。记录为尽力写入，失败不影响改价或同步本身；
+// 未配置时（nil）所有记录调用直接忽略。
+type PriceHistoryService struct {
+	priceHistoryRepo *repository.PriceHistoryRepository
+}
+
+func NewPriceHistoryService(priceHistoryRepo *repository.PriceHistoryRepository) *PriceHistoryService {
+	return &PriceHistoryService{priceHistoryRepo: priceHistoryRepo}
+}
+
+// Record 追加价格变动记录
+func (s *PriceHistoryService) Record(entries ...model.PriceHistory) {
+	if s == nil || len(entries) == 0 {
+		return
+	}
+	_ = s.priceHistoryRepo.CreateBatch(entries)
+}
+
+// recordJobReprices 补记自动化任务中改价成功、但执行端未经后端改价接口记录的 SKU
+func (s *PriceHistoryService) recordJobReprices(job *model.AutomationJob, products map[string]model.Product) {
+	if s == nil {
+		return
+	}
+	recorded, err := s.priceHistoryRepo.FindJobRepricedSKUs(job.ID)
+	if err != nil {
+		return
+	}
+
+	entries := make([]model.PriceHistory, 0)
+	for _, item := range job.Items {
+		if item.StepRepriceStatus != model.AutomationStepStatusSuccess {
+			continue
+		}
+		if _, exists := recorded[item.SourceSKU]; exists {
+			continue
+		}
+		product, ok := products[item.SourceSKU]
+		if !ok {
+			continue
+		}
+		entry := appliedPriceChange(product, item.TargetPrice, model.PriceSourceAutomationJob, userIDPointer(job.CreatedBy), "")
+		jobID := job.ID
+		entry.JobID = &jobID
+		entries = append(entries, entry)
+	}
+	s.Record(entries...)
+}
+
+// ListPriceHistory 按店铺或 SKU 查询时间范围内的价格变动
+func (s *PriceHistoryService) ListPriceHistory(req *dto.PriceHistoryListRequest) (*dto.PriceHistoryListResponse, error) {
+	from, err := parseDateOnly(req.DateFrom)
+	if err != nil {
+		return nil, fmt.Errorf("开始日期格式错误，应为 YYYY-MM-DD")
+	}
+	to, err := parseDateOnly(req.DateTo)
+	if err != nil {
+		return nil, fmt.Errorf("结束日期格式错误，应为 YYYY-MM-DD")
+	}
+	if to != nil {
+		// 结束日期含当天
+		end := to.AddDate(0, 0, 1)
+		to = &end
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, fmt.Errorf("开始日期不能晚于结束日期")
+	}
+
+	entries, total, err := s.priceHistoryRepo.List(repository.PriceHistoryQuery{
+		ShopID:    req.ShopID,
+		SourceSKU: req.SourceSKU,
+		Source:    req.Source,
+		PriceType: req.PriceType,
+		From:      from,
+		To:        to,
+		Page:      req.Page,
+		PageSize:  req.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.PriceHistoryItem, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, dto.PriceHistoryItem{
+			ID:            entry.ID,
+			ProductID:     entry.ProductID,
+			OzonProductID: entry.OzonProductID,
+			SourceSKU:     entry.SourceSKU,
+			PriceType:     entry.PriceType,
+			OldPrice:      entry.OldPrice,
+			NewPrice:      entry.NewPrice,
+			Currency:      entry.Currency,
+			ActionID:      entry.ActionID,
+			Source:        entry.Source,
+			ChangedBy:     entry.ChangedBy,
+			JobID:         entry.JobID,
+			Reference:     entry.Reference,
+			CreatedAt:     entry.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return &dto.PriceHistoryListResponse{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Items:    items,
+	}, nil
+}
+
+func userIDPointer(userID uint) *uint {
+	if userID == 0 {
+		return nil
+	}
+	return &userID
+}
+
+func priceChanged(oldPrice, newPrice float64) bool {
+	return math.Abs(oldPrice-newPrice) >= 0.005
+}
+
+// appliedPriceChange 本系统下发改价时的记录，变动前价格取本地当前价
+func appliedPriceChange(product model.Product, newPrice float64, source string, changedBy *uint, reference string) model.PriceHistory {
+	productID := product.ID
+	return model.PriceHistory{
+		ShopID:        product.ShopID,
+		ProductID:     &productID,
+		OzonProductID: product.OzonProductID,
+		SourceSKU:     product.SourceSKU,
+		PriceType:     model.PriceTypePrice,
+		OldPrice:      product.CurrentPrice,
+		NewPrice:      newPrice,
+		Source:        source,
+		ChangedBy:     changedBy,
+		Reference:     reference,
+	}
+}
+
+// appliedActionPrice 报名活动时设置的活动价，之前的活动价未知，变动前价格留空
+func appliedActionPrice(product model.Product, actionID int64, actionPrice float64, source string, changedBy *uint, reference string) model.PriceHistory {
+	entry := appliedPriceChange(product, actionPrice, source, changedBy, reference)
+	entry.PriceType = model.PriceTypeActionPrice
+	entry.OldPrice = 0
+	entry.ActionID = actionID
+	return entry
+}
+
+// syncedPriceChange 商品同步观察到的价格变动，本地价格为空（首次同步）时不记录
+func syncedPriceChange(existing model.Product, price float64) (model.PriceHistory, bool) {
+	if existing.CurrentPrice <= 0 || price <= 0 || !priceChanged(existing.CurrentPrice, price) {
+		return model.PriceHistory{}, false
+	}
+	change := appliedPriceChange(existing, price, model.PriceSourceProductSync, nil, "")
+	return change, true
+}
+
+// catalogPriceChanges 比较目录刷新前后的各类价格。首次出现或被清空（任一侧为 0）的价格不记录，
+// 避免首次同步或详情缺失时产生大量无意义记录。
+func catalogPriceChanges(existing, current model.OzonProductCatalogItem) []model.PriceHistory {
+	pairs := []struct {
+		priceType string
+		oldPrice  float64
+		newPrice  float64
+	}{
+		{model.PriceTypePrice, existing.Price, current.Price},
+		{model.PriceTypeOldPrice, existing.OldPrice, current.OldPrice},
+		{model.PriceTypeMinPrice, existing.MinPrice, current.MinPrice},
+		{model.PriceTypeMarketingPrice, existing.MarketingPrice, current.MarketingPrice},
+	}
+
+	changes := make([]model.PriceHistory, 0)
+	for _, pair := range pairs {
+		if pair.oldPrice <= 0 || pair.newPrice <= 0 || !priceChanged(pair.oldPrice, pair.newPrice) {
+			continue
+		}
+		changes = append(changes, model.PriceHistory{
+			ShopID:        current.ShopID,
+			OzonProductID: current.OzonProductID,
+			SourceSKU:     current.OfferID,
+			PriceType:     pair.priceType,
+			OldPrice:      pair.oldPrice,
+			NewPrice:      pair.newPrice,
+			Currency:      current.Currency,
+			Source:        model.PriceSourceCatalogSync,
+		})
+	}
+	return changes
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+
+	"ozon-manager/internal/model"
+)
+
+func TestCatalogPriceChangesSkipsFirstSeenAndCleared(t *testing.T) {
+	t.Parallel()
+
+	existing := model.OzonProductCatalogItem{Price: 100, OldPrice: 150, MinPrice: 0, MarketingPrice: 95}
+	current := model.OzonProductCatalogItem{
+		ShopID:         3,
+		OzonProductID:  501,
+		OfferID:        "SKU-501",
+		Currency:       "CNY",
+		Price:          89.9,
+		OldPrice:       150.004,
+		MinPrice:       80,
+		MarketingPrice: 0,
+	}
+
+	changes := catalogPriceChanges(existing, current)
+	if len(changes) != 1 {
+		t.Fatalf("changes = %+v, want only price", changes)
+	}
+	change := changes[0]
+	if change.PriceType != model.PriceTypePrice || change.OldPrice != 100 || change.NewPrice != 89.9 {
+		t.Fatalf("change = %+v", change)
+	}
+	if change.ShopID != 3 || change.SourceSKU != "SKU-501" || change.Currency != "CNY" || change.Source != model.PriceSourceCatalogSync || change.ProductID != nil {
+		t.Fatalf("change source = %+v", change)
+	}
+}
+
+func TestAppliedPriceChangeBuilders(t *testing.T) {
+	t.Parallel()
+
+	product := model.Product{ID: 7, ShopID: 3, OzonProductID: 501, SourceSKU: "SKU-501", CurrentPrice: 120}
+	userID := uint(9)
+
+	entry := appliedPriceChange(product, 99, model.PriceSourceLossProcess, &userID, "loss_product:4")
+	if entry.ProductID == nil || *entry.ProductID != 7 || entry.OldPrice != 120 || entry.NewPrice != 99 || entry.PriceType != model.PriceTypePrice {
+		t.Fatalf("applied entry = %+v", entry)
+	}
+	if entry.ChangedBy == nil || *entry.ChangedBy != 9 || entry.Reference != "loss_product:4" {
+		t.Fatalf("applied entry source = %+v", entry)
+	}
+
+	actionEntry := appliedActionPrice(product, 9001, 88, model.PriceSourceAutoPromotion, nil, "auto_promotion_run:2")
+	if actionEntry.PriceType != model.PriceTypeActionPrice || actionEntry.ActionID != 9001 || actionEntry.OldPrice != 0 || actionEntry.NewPrice != 88 {
+		t.Fatalf("action entry = %+v", actionEntry)
+	}
+
+	if _, changed := syncedPriceChange(product, 120.001); changed {
+		t.Fatalf("sub-cent difference should not be recorded")
+	}
+	if _, changed := syncedPriceChange(model.Product{ID: 8}, 50); changed {
+		t.Fatalf("first sync without local price should not be recorded")
+	}
+	synced, changed := syncedPriceChange(product, 110)
+	if !changed || synced.Source != model.PriceSourceProductSync || synced.OldPrice != 120 || synced.NewPrice != 110 || synced.ChangedBy != nil {
+		t.Fatalf("synced entry = %+v, changed = %v", synced, changed)
+	}
+}
@@ -0,0 +1,694 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+	"ozon-manager/internal/repository"
+	"ozon-manager/pkg/ozon"
+)
+
+const (
+	pricePlanSchedulerInterval = time.Minute
+	// pricePlanBatchSize Ozon 改价与查价接口单次最多 1000 个商品
+	pricePlanBatchSize = 1000
+	pricePlanMaxItems  = 5000
+
+	pricePlanPhaseApply  = "apply"
+	pricePlanPhaseRevert = "revert"
+)
+
+type pricePlanPayload struct {
+	PlanID uint   `json:"plan_id"`
+	ShopID uint   `json:"shop_id"`
+	Phase  string `json:"phase"`
+}
+
+// PricePlanService 定时改价计划：到开始时间批量改为目标价，到结束时间恢复为开始前的 Ozon 售价。
+// 开始时与待处理亏损、活动价冲突的商品不改价；结束时售价已被其他操作修改的商品不回滚。
+type PricePlanService struct {
+	pricePlanRepo *repository.PricePlanRepository
+	productRepo   *repository.ProductRepository
+	promotionRepo *repository.PromotionRepository
+	shopRepo      *repository.ShopRepository
+	shopGuard     *ShopGuard
+	priceHistory  *PriceHistoryService
+	taskQueue     *TaskQueue
+	loops         backgroundLoops
+}
+
+func NewPricePlanService(
+	pricePlanRepo *repository.PricePlanRepository,
+	productRepo *repository.ProductRepository,
+	promotionRepo *repository.PromotionRepository,
+	shopRepo *repository.ShopRepository,
+	automationService *AutomationService,
+	priceHistory *PriceHistoryService,
+) *PricePlanService {
+	return &PricePlanService{
+		pricePlanRepo: pricePlanRepo,
+		productRepo:   productRepo,
+		promotionRepo: promotionRepo,
+		shopRepo:      shopRepo,
+		shopGuard:     resolveShopGuard(shopRepo, automationService),
+		priceHistory:  priceHistory,
+	}
+}
+
+// ConfigureTaskQueue 改价与回滚通过持久化任务队列执行，服务停止时中断的计划退还队列后继续
+func (s *PricePlanService) ConfigureTaskQueue(queue *TaskQueue) {
+	s.taskQueue = queue
+	queue.Register(model.BackgroundTaskTypePricePlan, TaskOptions{
+		MaxAttempts: 2,
+		Timeout:     10 * time.Minute,
+	}, TypedTaskHandler(s.handlePricePlanTask))
+}
+
+// StartScheduler 每分钟扫描到期的计划
+func (s *PricePlanService) StartScheduler() {
+	s.loops.Go(pricePlanSchedulerInterval, s.scanDuePlans)
+}
+
+// StopScheduler 停止定时扫描，执行中的改价由任务队列排空
+func (s *PricePlanService) StopScheduler() {
+	s.loops.Stop()
+}
+
+func (s *PricePlanService) scanDuePlans(now time.Time) {
+	plans, err := s.pricePlanRepo.FindDuePlans(now)
+	if err != nil {
+		return
+	}
+
+	for index := range plans {
+		plan := &plans[index]
+		// 暂停写入或 Ozon API 熔断期间到期的计划顺延到恢复后执行
+		if s.shopGuard.CheckWritable(plan.ShopID) != nil {
+			continue
+		}
+		switch plan.Status {
+		case model.PricePlanStatusScheduled:
+			if !now.Before(plan.EndAt) {
+				s.expirePlan(plan)
+				continue
+			}
+			_ = s.startPhase(plan, []string{model.PricePlanStatusScheduled}, model.PricePlanStatusApplying, pricePlanPhaseApply)
+		case model.PricePlanStatusActive:
+			_ = s.startPhase(plan, []string{model.PricePlanStatusActive}, model.PricePlanStatusReverting, pricePlanPhaseRevert)
+		}
+	}
+}
+
+// expirePlan 开始前一直未能执行、已过结束时间的计划直接取消，不再改价
+func (s *PricePlanService) expirePlan(plan *model.PricePlan) {
+	ok, err := s.pricePlanRepo.TransitionStatus(plan.ID, []string{model.PricePlanStatusScheduled}, model.PricePlanStatusCancelled)
+	if err != nil || !ok {
+		return
+	}
+	plan.Status = model.PricePlanStatusCancelled
+	plan.ErrorMessage = "计划在结束时间前未能开始执行，已取消"
+	_ = s.pricePlanRepo.UpdatePlan(plan)
+}
+
+// startPhase 将计划切换到执行中状态并提交任务；状态已被其他扫描或操作改变时返回错误
+func (s *PricePlanService) startPhase(plan *model.PricePlan, from []string, to, phase string) error {
+	ok, err := s.pricePlanRepo.TransitionStatus(plan.ID, from, to)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("计划状态已变化，请刷新后重试")
+	}
+
+	payload := pricePlanPayload{PlanID: plan.ID, ShopID: plan.ShopID, Phase: phase}
+	if s.taskQueue == nil {
+		go func() { _ = s.handlePricePlanTask(context.Background(), payload) }()
+		return nil
+	}
+	if err := s.taskQueue.Enqueue(model.BackgroundTaskTypePricePlan, payload); err != nil {
+		_, _ = s.pricePlanRepo.TransitionStatus(plan.ID, []string{to}, plan.Status)
+		return err
+	}
+	return nil
+}
+
+// CreatePlan 创建定时改价计划。SKU 必须是本地已同步的商品，且不能同时出现在时间段重叠的其他计划中
+func (s *PricePlanService) CreatePlan(userID uint, req *dto.CreatePricePlanRequest) (*dto.PricePlanResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("计划名称不能为空")
+	}
+	startAt, err := parsePricePlanTime(req.StartAt)
+	if err != nil {
+		return nil, fmt.Errorf("开始时间格式错误，应为 YYYY-MM-DD HH:mm")
+	}
+	endAt, err := parsePricePlanTime(req.EndAt)
+	if err != nil {
+		return nil, fmt.Errorf("结束时间格式错误，应为 YYYY-MM-DD HH:mm")
+	}
+	if !endAt.After(startAt) {
+		return nil, fmt.Errorf("结束时间必须晚于开始时间")
+	}
+	if !endAt.After(time.Now()) {
+		return nil, fmt.Errorf("结束时间已过")
+	}
+	if len(req.Items) > pricePlanMaxItems {
+		return nil, fmt.Errorf("单个计划最多 %d 个商品", pricePlanMaxItems)
+	}
+
+	skus := make([]string, 0, len(req.Items))
+	targets := make(map[string]float64, len(req.Items))
+	for _, item := range req.Items {
+		sku := strings.TrimSpace(item.SourceSKU)
+		if sku == "" {
+			return nil, fmt.Errorf("SKU 不能为空")
+		}
+		if _, exists := targets[sku]; exists {
+			return nil, fmt.Errorf("SKU %s 重复", sku)
+		}
+		targets[sku] = item.TargetPrice
+		skus = append(skus, sku)
+	}
+
+	products, err := s.productRepo.FindBySourceSKUs(req.ShopID, skus)
+	if err != nil {
+		return nil, err
+	}
+	missing := make([]string, 0)
+	for _, sku := range skus {
+		if _, ok := products[sku]; !ok {
+			missing = append(missing, sku)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("以下 SKU 在本地商品中不存在，请先同步商品: %s", summarizeSKUs(missing))
+	}
+
+	overlapping, err := s.pricePlanRepo.FindOverlappingSKUs(req.ShopID, skus, startAt, endAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, sku := range skus {
+		if planID, exists := overlapping[sku]; exists {
+			return nil, fmt.Errorf("SKU %s 已在时间段重叠的计划 #%d 中", sku, planID)
+		}
+	}
+
+	plan := &model.PricePlan{
+		ShopID:    req.ShopID,
+		Name:      name,
+		Status:    model.PricePlanStatusScheduled,
+		StartAt:   startAt,
+		EndAt:     endAt,
+		CreatedBy: userID,
+		ItemCount: len(skus),
+		Items:     make([]model.PricePlanItem, 0, len(skus)),
+	}
+	for _, sku := range skus {
+		product := products[sku]
+		plan.Items = append(plan.Items, model.PricePlanItem{
+			ProductID:     product.ID,
+			SourceSKU:     sku,
+			OzonProductID: product.OzonProductID,
+			TargetPrice:   targets[sku],
+			Status:        model.PricePlanItemStatusPending,
+		})
+	}
+	if err := s.pricePlanRepo.CreatePlan(plan); err != nil {
+		return nil, err
+	}
+	return toPricePlanDTO(plan, true), nil
+}
+
+func (s *PricePlanService) ListPlans(req *dto.PricePlanListRequest) (*dto.PricePlanListResponse, error) {
+	plans, total, err := s.pricePlanRepo.ListPlans(req.ShopID, req.Status, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]dto.PricePlanResponse, 0, len(plans))
+	for index := range plans {
+		items = append(items, *toPricePlanDTO(&plans[index], false))
+	}
+	return &dto.PricePlanListResponse{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Items:    items,
+	}, nil
+}
+
+func (s *PricePlanService) GetPlan(shopID, planID uint) (*dto.PricePlanResponse, error) {
+	plan, err := s.pricePlanRepo.FindPlanByIDAndShop(planID, shopID)
+	if err != nil {
+		return nil, err
+	}
+	return toPricePlanDTO(plan, true), nil
+}
+
+// CancelPlan 取消尚未开始的计划
+func (s *PricePlanService) CancelPlan(shopID, planID uint) error {
+	plan, err := s.pricePlanRepo.FindPlanByIDAndShop(planID, shopID)
+	if err != nil {
+		return err
+	}
+	if plan.Status != model.PricePlanStatusScheduled {
+		return fmt.Errorf("仅未开始的计划可以取消，已生效的计划请使用提前结束")
+	}
+	ok, err := s.pricePlanRepo.TransitionStatus(plan.ID, []string{model.PricePlanStatusScheduled}, model.PricePlanStatusCancelled)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("计划状态已变化，请刷新后重试")
+	}
+	return nil
+}
+
+// EndPlan 提前结束已生效的计划，或对失败的计划重新执行恢复
+func (s *PricePlanService) EndPlan(shopID, planID uint) error {
+	plan, err := s.pricePlanRepo.FindPlanByIDAndShop(planID, shopID)
+	if err != nil {
+		return err
+	}
+	if plan.Status != model.PricePlanStatusActive && plan.Status != model.PricePlanStatusFailed {
+		return fmt.Errorf("仅已生效或失败的计划可以执行恢复")
+	}
+	if err := s.shopGuard.CheckWritable(shopID); err != nil {
+		return err
+	}
+	return s.startPhase(plan, []string{model.PricePlanStatusActive, model.PricePlanStatusFailed}, model.PricePlanStatusReverting, pricePlanPhaseRevert)
+}
+
+func (s *PricePlanService) handlePricePlanTask(ctx context.Context, payload pricePlanPayload) error {
+	plan, err := s.pricePlanRepo.FindPlanByIDAndShop(payload.PlanID, payload.ShopID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var runErr error
+	switch {
+	case payload.Phase == pricePlanPhaseApply && plan.Status == model.PricePlanStatusApplying:
+		runErr = s.applyPlan(ctx, plan)
+	case payload.Phase == pricePlanPhaseRevert && plan.Status == model.PricePlanStatusReverting:
+		runErr = s.revertPlan(ctx, plan)
+	default:
+		return nil
+	}
+
+	if runErr != nil {
+		if ctx.Err() != nil {
+			// 服务停止：计划保持执行中状态，任务退还队列后从未处理的商品继续
+			return runErr
+		}
+		summarizePricePlan(plan)
+		plan.Status = model.PricePlanStatusFailed
+		plan.ErrorMessage = runErr.Error()
+		_ = s.pricePlanRepo.UpdatePlan(plan)
+	}
+	return nil
+}
+
+// applyPlan 记录改价前的 Ozon 售价后批量改为目标价
+func (s *PricePlanService) applyPlan(ctx context.Context, plan *model.PricePlan) error {
+	if err := s.shopGuard.CheckWritable(plan.ShopID); err != nil {
+		return err
+	}
+	shop, err := s.shopRepo.GetWithCredentials(plan.ShopID)
+	if err != nil {
+		return fmt.Errorf("shop not found: %w", err)
+	}
+	client := s.shopGuard.OzonClient(shop)
+
+	lossProducts, err := s.promotionRepo.FindUnprocessedLossProducts(plan.ShopID)
+	if err != nil {
+		return err
+	}
+	pendingLoss := make(map[uint]struct{}, len(lossProducts))
+	for _, lp := range lossProducts {
+		pendingLoss[lp.ProductID] = struct{}{}
+	}
+	promotedRows, err := s.promotionRepo.FindActivePromotedProducts(plan.ShopID)
+	if err != nil {
+		return err
+	}
+	promotions := make(map[uint][]model.PromotedProduct)
+	for _, row := range promotedRows {
+		promotions[row.ProductID] = append(promotions[row.ProductID], row)
+	}
+
+	pending := make([]*model.PricePlanItem, 0)
+	productIDs := make([]int64, 0)
+	for index := range plan.Items {
+		item := &plan.Items[index]
+		if item.Status != model.PricePlanItemStatusPending {
+			continue
+		}
+		_, hasPendingLoss := pendingLoss[item.ProductID]
+		if conflict := pricePlanApplyConflict(item.TargetPrice, hasPendingLoss, promotions[item.ProductID]); conflict != "" {
+			item.Status = model.PricePlanItemStatusSkipped
+			item.Note = conflict
+			_ = s.pricePlanRepo.UpdateItem(item)
+			continue
+		}
+		pending = append(pending, item)
+		productIDs = append(productIDs, item.OzonProductID)
+	}
+
+	currentPrices, err := fetchOzonPrices(client, productIDs)
+	if err != nil {
+		return fmt.Errorf("获取 Ozon 当前售价失败: %w", err)
+	}
+
+	toApply := make([]*model.PricePlanItem, 0, len(pending))
+	for _, item := range pending {
+		// 中断后重跑时保留首次记录的改价前价格，此时 Ozon 售价可能已是目标价
+		if item.PreviousPrice <= 0 {
+			item.PreviousPrice = currentPrices[item.OzonProductID]
+		}
+		switch {
+		case item.PreviousPrice <= 0:
+			item.Status = model.PricePlanItemStatusFailed
+			item.Note = "未获取到 Ozon 当前售价"
+		case !priceChanged(item.PreviousPrice, item.TargetPrice):
+			item.Status = model.PricePlanItemStatusSkipped
+			item.Note = "当前售价已等于目标价"
+		default:
+			toApply = append(toApply, item)
+		}
+		if err := s.pricePlanRepo.UpdateItem(item); err != nil {
+			return err
+		}
+	}
+
+	err = s.pushPrices(ctx, client, plan, toApply, func(item *model.PricePlanItem) float64 {
+		return item.TargetPrice
+	}, model.PricePlanItemStatusApplied, model.PricePlanItemStatusFailed)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	summarizePricePlan(plan)
+	plan.AppliedAt = &now
+	plan.ErrorMessage = ""
+	plan.Status = model.PricePlanStatusActive
+	if plan.AppliedCount == 0 {
+		// 没有商品改价成功，无需回滚
+		plan.Status = model.PricePlanStatusCompleted
+	}
+	return s.pricePlanRepo.UpdatePlan(plan)
+}
+
+// revertPlan 将已改价的商品恢复为改价前价格；期间售价已被其他操作修改的商品保留新价格
+func (s *PricePlanService) revertPlan(ctx context.Context, plan *model.PricePlan) error {
+	if err := s.shopGuard.CheckWritable(plan.ShopID); err != nil {
+		return err
+	}
+	shop, err := s.shopRepo.GetWithCredentials(plan.ShopID)
+	if err != nil {
+		return fmt.Errorf("shop not found: %w", err)
+	}
+	client := s.shopGuard.OzonClient(shop)
+
+	candidates := make([]*model.PricePlanItem, 0)
+	productIDs := make([]int64, 0)
+	for index := range plan.Items {
+		item := &plan.Items[index]
+		switch item.Status {
+		case model.PricePlanItemStatusApplied, model.PricePlanItemStatusRevertFailed:
+			candidates = append(candidates, item)
+			productIDs = append(productIDs, item.OzonProductID)
+		case model.PricePlanItemStatusPending:
+			// 改价阶段失败后提前结束，未执行的商品不再改价
+			item.Status = model.PricePlanItemStatusSkipped
+			item.Note = "计划结束前未执行"
+			_ = s.pricePlanRepo.UpdateItem(item)
+		}
+	}
+
+	currentPrices, err := fetchOzonPrices(client, productIDs)
+	if err != nil {
+		return fmt.Errorf("获取 Ozon 当前售价失败: %w", err)
+	}
+
+	toRevert := make([]*model.PricePlanItem, 0, len(candidates))
+	for _, item := range candidates {
+		current, ok := currentPrices[item.OzonProductID]
+		if !ok {
+			item.Status = model.PricePlanItemStatusRevertFailed
+			item.Note = "未获取到 Ozon 当前售价"
+			_ = s.pricePlanRepo.UpdateItem(item)
+			continue
+		}
+		if conflict := pricePlanRevertConflict(item, current); conflict != "" {
+			item.Status = model.PricePlanItemStatusRevertSkipped
+			item.Note = conflict
+			_ = s.pricePlanRepo.UpdateItem(item)
+			continue
+		}
+		toRevert = append(toRevert, item)
+	}
+
+	err = s.pushPrices(ctx, client, plan, toRevert, func(item *model.PricePlanItem) float64 {
+		return item.PreviousPrice
+	}, model.PricePlanItemStatusReverted, model.PricePlanItemStatusRevertFailed)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	summarizePricePlan(plan)
+	plan.RevertedAt = &now
+	plan.Status = model.PricePlanStatusCompleted
+	plan.ErrorMessage = ""
+	revertFailed := 0
+	for _, item := range plan.Items {
+		if item.Status == model.PricePlanItemStatusRevertFailed {
+			revertFailed++
+		}
+	}
+	if revertFailed > 0 {
+		plan.Status = model.PricePlanStatusFailed
+		plan.ErrorMessage = fmt.Sprintf("%d 个商品恢复原价失败，可重新执行恢复", revertFailed)
+	}
+	return s.pricePlanRepo.UpdatePlan(plan)
+}
+
+// pushPrices 分批调用 Ozon 改价接口，按返回结果更新明细状态、本地价格与价格流水
+func (s *PricePlanService) pushPrices(
+	ctx context.Context,
+	client *ozon.Client,
+	plan *model.PricePlan,
+	items []*model.PricePlanItem,
+	priceOf func(item *model.PricePlanItem) float64,
+	successStatus, failedStatus string,
+) error {
+	reference := fmt.Sprintf("price_plan:%d", plan.ID)
+	for start := 0; start < len(items); start += pricePlanBatchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := start + pricePlanBatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+		batch := items[start:end]
+
+		prices := make([]ozon.PriceItem, 0, len(batch))
+		for _, item := range batch {
+			prices = append(prices, ozon.PriceItem{
+				ProductID: item.OzonProductID,
+				Price:     strconv.FormatFloat(priceOf(item), 'f', 2, 64),
+			})
+		}
+		resp, err := client.UpdatePrices(prices)
+		results := make(map[int64]ozon.PriceUpdateResult)
+		if err == nil {
+			for _, result := range resp.Result {
+				results[result.ProductID] = result
+			}
+		}
+
+		now := time.Now()
+		history := make([]model.PriceHistory, 0, len(batch))
+		for _, item := range batch {
+			result, ok := results[item.OzonProductID]
+			switch {
+			case err != nil:
+				item.Status = failedStatus
+				item.Note = err.Error()
+			case !ok:
+				item.Status = failedStatus
+				item.Note = "Ozon 未返回改价结果"
+			case !result.Updated:
+				item.Status = failedStatus
+				item.Note = "Ozon 拒绝改价"
+				if len(result.Errors) > 0 {
+					item.Note = result.Errors[0].Message
+				}
+			default:
+				newPrice := priceOf(item)
+				oldPrice := item.TargetPrice
+				if successStatus == model.PricePlanItemStatusApplied {
+					oldPrice = item.PreviousPrice
+					item.AppliedAt = &now
+				} else {
+					item.RevertedAt = &now
+				}
+				item.Status = successStatus
+				item.Note = ""
+				_ = s.productRepo.UpdatePrice(item.ProductID, newPrice)
+				product := model.Product{
+					ID:            item.ProductID,
+					ShopID:        plan.ShopID,
+					OzonProductID: item.OzonProductID,
+					SourceSKU:     item.SourceSKU,
+					CurrentPrice:  oldPrice,
+				}
+				history = append(history, appliedPriceChange(product, newPrice, model.PriceSourcePricePlan, userIDPointer(plan.CreatedBy), reference))
+			}
+			if err := s.pricePlanRepo.UpdateItem(item); err != nil {
+				return err
+			}
+		}
+		s.priceHistory.Record(history...)
+	}
+	return nil
+}
+
+// fetchOzonPrices 批量查询 Ozon 当前售价，返回 Ozon 商品 ID → 售价
+func fetchOzonPrices(client *ozon.Client, productIDs []int64) (map[int64]float64, error) {
+	result := make(map[int64]float64, len(productIDs))
+	for start := 0; start < len(productIDs); start += pricePlanBatchSize {
+		end := start + pricePlanBatchSize
+		if end > len(productIDs) {
+			end = len(productIDs)
+		}
+		resp, err := client.GetProductPrices(productIDs[start:end], pricePlanBatchSize, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range resp.Result.Items {
+			if price, err := strconv.ParseFloat(item.Price.Price, 64); err == nil && price > 0 {
+				result[item.ProductID] = price
+			}
+		}
+	}
+	return result, nil
+}
+
+// pricePlanApplyConflict 判断商品开始时能否改价：待处理的亏损以亏损处理为准；
+// 目标价不高于所在官方活动的活动价时，改价会使商品被 Ozon 移出活动
+func pricePlanApplyConflict(targetPrice float64, hasPendingLoss bool, promotions []model.PromotedProduct) string {
+	if hasPendingLoss {
+		return "商品有待处理的亏损记录，以亏损处理改价为准"
+	}
+	for _, promotion := range promotions {
+		if promotion.ActionPrice > 0 && targetPrice <= promotion.ActionPrice {
+			return fmt.Sprintf("目标价 %.2f 不高于活动 %d 的活动价 %.2f，改价会使商品退出活动", targetPrice, promotion.ActionID, promotion.ActionPrice)
+		}
+	}
+	return ""
+}
+
+// pricePlanRevertConflict 结束时 Ozon 售价已不是目标价，说明期间被亏损处理、改价推广或手工改过，不再恢复
+func pricePlanRevertConflict(item *model.PricePlanItem, currentPrice float64) string {
+	if priceChanged(currentPrice, item.TargetPrice) {
+		return fmt.Sprintf("计划期间售价已被修改为 %.2f，保留当前价格", currentPrice)
+	}
+	return ""
+}
+
+// summarizePricePlan 按明细状态重新统计计划计数
+func summarizePricePlan(plan *model.PricePlan) {
+	plan.AppliedCount, plan.SkippedCount, plan.FailedCount, plan.RevertedCount = 0, 0, 0, 0
+	for _, item := range plan.Items {
+		switch item.Status {
+		case model.PricePlanItemStatusApplied, model.PricePlanItemStatusRevertSkipped, model.PricePlanItemStatusRevertFailed:
+			plan.AppliedCount++
+		case model.PricePlanItemStatusReverted:
+			plan.AppliedCount++
+			plan.RevertedCount++
+		case model.PricePlanItemStatusSkipped:
+			plan.SkippedCount++
+		case model.PricePlanItemStatusFailed:
+			plan.FailedCount++
+		}
+	}
+}
+
+// parsePricePlanTime 按服务器时区解析计划时间，支持 YYYY-MM-DD HH:mm 与 YYYY-MM-DD HH:mm:ss
+func parsePricePlanTime(input string) (time.Time, error) {
+	trimmed := strings.TrimSpace(input)
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02 15:04:05"} {
+		if parsed, err := time.ParseInLocation(layout, trimmed, time.Local); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s", input)
+}
+
+func summarizeSKUs(skus []string) string {
+	limit := 10
+	if len(skus) <= limit {
+		return strings.Join(skus, ", ")
+	}
+	return fmt.Sprintf("%s 等 %d 个", strings.Join(skus[:limit], ", "), len(skus))
+}
+
+func formatOptionalTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.Format("2006-01-02 15:04:05")
+}
+
+func toPricePlanDTO(plan *model.PricePlan, withItems bool) *dto.PricePlanResponse {
+	resp := &dto.PricePlanResponse{
+		ID:            plan.ID,
+		ShopID:        plan.ShopID,
+		Name:          plan.Name,
+		Status:        plan.Status,
+		StartAt:       plan.StartAt.Format("2006-01-02 15:04:05"),
+		EndAt:         plan.EndAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:     plan.CreatedBy,
+		ItemCount:     plan.ItemCount,
+		AppliedCount:  plan.AppliedCount,
+		SkippedCount:  plan.SkippedCount,
+		FailedCount:   plan.FailedCount,
+		RevertedCount: plan.RevertedCount,
+		ErrorMessage:  plan.ErrorMessage,
+		AppliedAt:     formatOptionalTime(plan.AppliedAt),
+		RevertedAt:    formatOptionalTime(plan.RevertedAt),
+		CreatedAt:     plan.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if !withItems {
+		return resp
+	}
+	resp.Items = make([]dto.PricePlanItemResponse, 0, len(plan.Items))
+	for _, item := range plan.Items {
+		resp.Items = append(resp.Items, dto.PricePlanItemResponse{
+			ID:            item.ID,
+			ProductID:     item.ProductID,
+			SourceSKU:     item.SourceSKU,
+			OzonProductID: item.OzonProductID,
+			TargetPrice:   item.TargetPrice,
+			PreviousPrice: item.PreviousPrice,
+			Status:        item.Status,
+			Note:          item.Note,
+			AppliedAt:     formatOptionalTime(item.AppliedAt),
+			RevertedAt:    formatOptionalTime(item.RevertedAt),
+		})
+	}
+	return resp
+}
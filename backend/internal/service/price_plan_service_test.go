@@ -0,0 +1,75 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ozon-manager/internal/model"
+)
+
+func TestPricePlanApplyConflict(t *testing.T) {
+	t.Parallel()
+
+	promotions := []model.PromotedProduct{
+		{ActionID: 9001, ActionPrice: 0},
+		{ActionID: 9002, ActionPrice: 79.5},
+	}
+
+	if conflict := pricePlanApplyConflict(120, true, nil); !strings.Contains(conflict, "亏损") {
+		t.Fatalf("pending loss conflict = %q", conflict)
+	}
+	if conflict := pricePlanApplyConflict(79.5, false, promotions); !strings.Contains(conflict, "9002") {
+		t.Fatalf("action price conflict = %q", conflict)
+	}
+	if conflict := pricePlanApplyConflict(80, false, promotions); conflict != "" {
+		t.Fatalf("target above action price should not conflict, got %q", conflict)
+	}
+}
+
+func TestPricePlanRevertConflict(t *testing.T) {
+	t.Parallel()
+
+	item := &model.PricePlanItem{TargetPrice: 99, PreviousPrice: 129}
+	if conflict := pricePlanRevertConflict(item, 99.001); conflict != "" {
+		t.Fatalf("unchanged price should revert, got %q", conflict)
+	}
+	if conflict := pricePlanRevertConflict(item, 110); conflict == "" {
+		t.Fatalf("price changed during plan should not revert")
+	}
+}
+
+func TestSummarizePricePlanCountsByStatus(t *testing.T) {
+	t.Parallel()
+
+	plan := &model.PricePlan{Items: []model.PricePlanItem{
+		{Status: model.PricePlanItemStatusReverted},
+		{Status: model.PricePlanItemStatusRevertSkipped},
+		{Status: model.PricePlanItemStatusRevertFailed},
+		{Status: model.PricePlanItemStatusSkipped},
+		{Status: model.PricePlanItemStatusFailed},
+		{Status: model.PricePlanItemStatusPending},
+	}}
+	summarizePricePlan(plan)
+	if plan.AppliedCount != 3 || plan.RevertedCount != 1 || plan.SkippedCount != 1 || plan.FailedCount != 1 {
+		t.Fatalf("counts = applied %d reverted %d skipped %d failed %d", plan.AppliedCount, plan.RevertedCount, plan.SkippedCount, plan.FailedCount)
+	}
+}
+
+func TestParsePricePlanTime(t *testing.T) {
+	t.Parallel()
+
+	parsed, err := parsePricePlanTime(" 2026-11-11 00:00 ")
+	if err != nil {
+		t.Fatalf("parse minute precision: %v", err)
+	}
+	if want := time.Date(2026, 11, 11, 0, 0, 0, 0, time.Local); !parsed.Equal(want) {
+		t.Fatalf("parsed = %v, want %v", parsed, want)
+	}
+	if _, err := parsePricePlanTime("2026-11-11 23:59:59"); err != nil {
+		t.Fatalf("parse second precision: %v", err)
+	}
+	if _, err := parsePricePlanTime("2026-11-11"); err == nil {
+		t.Fatalf("date without time should be rejected")
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -16,6 +17,7 @@ type ProductService struct {
 	productRepo   *repository.ProductRepository
 	shopRepo      *repository.ShopRepository
 	promotionRepo *repository.PromotionRepository
+	priceHistory  *PriceHistoryService
 }
 
 func NewProductService(
@@ -30,6 +32,11 @@ func NewProductService(
 	}
 }
 
+// ConfigurePriceHistory 同步时记录商品价格变动
+func (s *ProductService) ConfigurePriceHistory(priceHistory *PriceHistoryService) {
+	s.priceHistory = priceHistory
+}
+
 // GetProducts 获取商品列表
 func (s *ProductService) GetProducts(req *dto.ProductListRequest) (*dto.ProductListResponse, error) {
 	products, total, err := s.productRepo.FindWithFilters(
@@ -53,6 +60,7 @@ func (s *ProductService) GetProducts(req *dto.ProductListRequest) (*dto.ProductL
 			CurrentPrice: product.CurrentPrice,
 			IsLoss:       product.IsLoss,
 			IsPromoted:   product.IsPromoted,
+			Tags:         decodeProductTags(product.Tags),
 			Promotions:   make([]dto.PromotionInfo, 0),
 		}
 
@@ -172,6 +180,9 @@ func (s *ProductService) SyncProducts(shopID uint) (int, error) {
 			syncErrors = append(syncErrors, fmt.Sprintf("info batch [%d,%d) failed: %v", i, end, err))
 			continue
 		}
+		// 覆盖前的本地价格，用于记录价格变动
+		existingProducts, _ := s.productRepo.FindByOzonProductIDs(shopID, productIDs)
+		priceChanges := make([]model.PriceHistory, 0)
 
 		for _, info := range infoResp.ItemsList() {
 			price, _ := strconv.ParseFloat(info.Price, 64)
@@ -203,7 +214,13 @@ func (s *ProductService) SyncProducts(shopID uint) (int, error) {
 				continue
 			}
 			syncedIDs[ozonProductID] = struct{}{}
+			if existing, ok := existingProducts[ozonProductID]; ok {
+				if change, changed := syncedPriceChange(existing, price); changed {
+					priceChanges = append(priceChanges, change)
+				}
+			}
 		}
+		s.priceHistory.Record(priceChanges...)
 	}
 
 	syncedCount := len(syncedIDs)
@@ -243,6 +260,13 @@ func (s *ProductService) GetPromotableProducts(shopID uint) ([]model.Product, er
 	return s.productRepo.FindPromotable(shopID)
 }
 
+// UpdateTags 批量设置商品标签，供自动加促销选品规则使用
+func (s *ProductService) UpdateTags(req *dto.UpdateProductTagsRequest) (int64, error) {
+	tags := uniqueStrings(req.Tags)
+	raw, _ := json.Marshal(tags)
+	return s.productRepo.UpdateTags(req.ShopID, uniqueUints(req.ProductIDs), raw)
+}
+
 // GetStats 获取统计数据
 func (s *ProductService) GetStats(shopID uint) (*dto.StatsOverview, error) {
 	total, _ := s.productRepo.CountByShopID(shopID)
@@ -251,12 +275,16 @@ func (s *ProductService) GetStats(shopID uint) (*dto.StatsOverview, error) {
 
 	promotable := total - loss - promoted
 
-	return &dto.StatsOverview{
+	stats := &dto.StatsOverview{
 		TotalProducts:      total,
 		LossProducts:       loss,
 		PromotedProducts:   promoted,
 		PromotableProducts: promotable,
-	}, nil
+	}
+	if report, err := s.promotionRepo.FindLatestCompletedReconcileReport(shopID); err == nil && report.CompletedAt != nil {
+		stats.LastReconciledAt = report.CompletedAt.Format("2006-01-02 15:04:05")
+	}
+	return stats, nil
 }
 
 func getPromotionTitle(promotionType string) string {
@@ -0,0 +1,560 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+)
+
+const (
+	promotionCalendarDefaultDays = 7
+	promotionCalendarMaxDays     = 62
+	// promotionCalendarSampleSKUs 按类目分组时每项最多列出的 SKU
+	promotionCalendarSampleSKUs = 20
+	promotionCalendarTimeLayout = "2006-01-02 15:04"
+	promotionCalendarNoCategory = "未分类"
+)
+
+// calendarInterval 活动在查询范围内的时间段，End 不含
+type calendarInterval struct {
+	ActionID uint
+	Start    time.Time
+	End      time.Time
+}
+
+type calendarOverlap struct {
+	FirstActionID  uint
+	SecondActionID uint
+	Start          time.Time
+	End            time.Time
+}
+
+type calendarDropOut struct {
+	At                 time.Time
+	EndingActionIDs    []uint
+	ResumeAt           *time.Time
+	ResumeActionIDs    []uint
+	CandidateActionIDs []uint
+}
+
+// calendarSKU 单个 SKU 在查询范围内参与与候选的活动及分析结果
+type calendarSKU struct {
+	SKU           string
+	Name          string
+	Category      string
+	Participating []calendarInterval
+	Candidates    []calendarInterval
+	Overlaps      []calendarOverlap
+	DropOuts      []calendarDropOut
+}
+
+// GetPromotionCalendar 促销日历：按 SKU 或类目列出查询范围内官方与店铺活动的重叠、
+// 活动结束后脱离全部促销的时间点，以及尚未参与的候选活动；只读本地活动缓存
+func (s *PromotionService) GetPromotionCalendar(req *dto.PromotionCalendarRequest) (*dto.PromotionCalendarResponse, error) {
+	now := time.Now()
+	from, to, err := resolvePromotionCalendarRange(req.From, req.To, now)
+	if err != nil {
+		return nil, err
+	}
+	groupBy := strings.TrimSpace(req.GroupBy)
+	if groupBy == "" {
+		groupBy = "sku"
+	}
+	if groupBy != "sku" && groupBy != "category" {
+		return nil, fmt.Errorf("不支持的分组方式: %s", groupBy)
+	}
+	switch req.Issue {
+	case "", "overlap", "drop_out", "candidate":
+	default:
+		return nil, fmt.Errorf("不支持的筛选条件: %s", req.Issue)
+	}
+
+	actions, err := s.promotionRepo.FindActivePromotionActions(req.ShopID)
+	if err != nil {
+		return nil, fmt.Errorf("查询促销活动失败: %w", err)
+	}
+	intervals := make(map[uint]calendarInterval, len(actions))
+	inRange := make([]model.PromotionAction, 0, len(actions))
+	officialByActionID := make(map[int64]uint)
+	for _, action := range actions {
+		interval, ok := clipCalendarInterval(action, from, to)
+		if !ok {
+			continue
+		}
+		intervals[action.ID] = interval
+		inRange = append(inRange, action)
+		if action.Source != "shop" {
+			officialByActionID[action.ActionID] = action.ID
+		}
+	}
+	actionIDs := actionIDsForActions(inRange)
+
+	actionProducts, err := s.promotionRepo.ListCalendarActionProducts(req.ShopID, actionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("查询活动商品缓存失败: %w", err)
+	}
+	candidates, err := s.promotionRepo.ListCalendarActionCandidates(req.ShopID, actionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("查询活动候选缓存失败: %w", err)
+	}
+	promoted, err := s.promotionRepo.FindActivePromotedProducts(req.ShopID)
+	if err != nil {
+		return nil, fmt.Errorf("查询已推广商品失败: %w", err)
+	}
+
+	participating := make(map[string]map[uint]struct{})
+	candidateOf := make(map[string]map[uint]struct{})
+	names := make(map[string]string)
+	categories := make(map[string]string)
+	mark := func(target map[string]map[uint]struct{}, sku string, actionID uint) {
+		if target[sku] == nil {
+			target[sku] = make(map[uint]struct{})
+		}
+		target[sku][actionID] = struct{}{}
+	}
+	for _, row := range actionProducts {
+		if row.Status != "active" {
+			continue
+		}
+		mark(participating, row.SourceSKU, row.PromotionActionID)
+		if names[row.SourceSKU] == "" {
+			names[row.SourceSKU] = row.Name
+		}
+		if categories[row.SourceSKU] == "" {
+			categories[row.SourceSKU] = strings.TrimSpace(row.CategoryName)
+		}
+	}
+	for _, pp := range promoted {
+		if actionID, ok := officialByActionID[pp.ActionID]; ok {
+			mark(participating, pp.Product.SourceSKU, actionID)
+		}
+	}
+	for _, row := range candidates {
+		switch row.Status {
+		case model.PromotionActionCandidateStatusCandidate:
+			mark(candidateOf, row.SourceSKU, row.PromotionActionID)
+		case model.PromotionActionCandidateStatusActive, model.PromotionActionCandidateStatusAlreadyActive:
+			mark(participating, row.SourceSKU, row.PromotionActionID)
+		}
+	}
+
+	skus := make([]string, 0, len(participating)+len(candidateOf))
+	for sku := range participating {
+		skus = append(skus, sku)
+	}
+	for sku := range candidateOf {
+		skus = append(skus, sku)
+	}
+	skus = uniqueStrings(skus)
+	sort.Strings(skus)
+	products, err := s.productRepo.FindBySourceSKUs(req.ShopID, skus)
+	if err != nil {
+		return nil, fmt.Errorf("查询商品失败: %w", err)
+	}
+
+	keyword := strings.ToLower(strings.TrimSpace(req.Keyword))
+	participatingCount := make(map[uint]int)
+	candidateCount := make(map[uint]int)
+	entries := make([]*calendarSKU, 0, len(skus))
+	for _, sku := range skus {
+		entry := &calendarSKU{SKU: sku, Name: names[sku], Category: categories[sku]}
+		if product, ok := products[sku]; ok && product.Name != "" {
+			entry.Name = product.Name
+		}
+		if entry.Category == "" {
+			entry.Category = promotionCalendarNoCategory
+		}
+		if keyword != "" && !strings.Contains(strings.ToLower(sku), keyword) &&
+			!strings.Contains(strings.ToLower(entry.Name), keyword) &&
+			!strings.Contains(strings.ToLower(entry.Category), keyword) {
+			continue
+		}
+
+		for actionID := range participating[sku] {
+			entry.Participating = append(entry.Participating, intervals[actionID])
+			participatingCount[actionID]++
+		}
+		for actionID := range candidateOf[sku] {
+			if _, joined := participating[sku][actionID]; joined {
+				continue
+			}
+			entry.Candidates = append(entry.Candidates, intervals[actionID])
+			candidateCount[actionID]++
+		}
+		analyzeCalendarSKU(entry, to)
+		entries = append(entries, entry)
+	}
+
+	resp := &dto.PromotionCalendarResponse{
+		From:     from.Format("2006-01-02"),
+		To:       to.AddDate(0, 0, -1).Format("2006-01-02"),
+		GroupBy:  groupBy,
+		Actions:  make([]dto.PromotionCalendarAction, 0, len(inRange)),
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+	for _, action := range inRange {
+		phase := "running"
+		if action.DateStart != nil && action.DateStart.After(now) {
+			phase = "upcoming"
+		}
+		resp.Actions = append(resp.Actions, dto.PromotionCalendarAction{
+			PromotionActionID:  action.ID,
+			ActionID:           action.ActionID,
+			SourceActionID:     action.SourceActionID,
+			Source:             action.Source,
+			Title:              displayActionName(action),
+			DateStart:          formatCalendarTime(action.DateStart),
+			DateEnd:            formatCalendarTime(action.DateEnd),
+			Phase:              phase,
+			ParticipatingCount: participatingCount[action.ID],
+			CandidateCount:     candidateCount[action.ID],
+		})
+	}
+	sort.SliceStable(resp.Actions, func(i, j int) bool {
+		left, right := intervals[resp.Actions[i].PromotionActionID], intervals[resp.Actions[j].PromotionActionID]
+		if !left.Start.Equal(right.Start) {
+			return left.Start.Before(right.Start)
+		}
+		return left.End.Before(right.End)
+	})
+
+	resp.Summary.SKUCount = len(entries)
+	for _, entry := range entries {
+		if len(entry.Overlaps) > 0 {
+			resp.Summary.OverlapSKUCount++
+		}
+		if len(entry.DropOuts) > 0 {
+			resp.Summary.DropOutSKUCount++
+		}
+		if len(entry.Candidates) > 0 {
+			resp.Summary.CandidateSKUCount++
+		}
+	}
+
+	var groups []dto.PromotionCalendarGroup
+	if groupBy == "category" {
+		groups = groupCalendarByCategory(entries)
+	} else {
+		groups = groupCalendarBySKU(entries)
+	}
+	filtered := make([]dto.PromotionCalendarGroup, 0, len(groups))
+	for _, group := range groups {
+		switch {
+		case req.Issue == "overlap" && len(group.Overlaps) == 0,
+			req.Issue == "drop_out" && len(group.DropOuts) == 0,
+			req.Issue == "candidate" && len(group.Candidates) == 0:
+			continue
+		}
+		filtered = append(filtered, group)
+	}
+
+	resp.Total = int64(len(filtered))
+	start := (req.Page - 1) * req.PageSize
+	if start > len(filtered) {
+		start = len(filtered)
+	}
+	end := start + req.PageSize
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+	resp.Groups = filtered[start:end]
+	return resp, nil
+}
+
+// resolvePromotionCalendarRange 解析查询日期，返回 [from, to) 两个零点
+func resolvePromotionCalendarRange(fromRaw, toRaw string, now time.Time) (time.Time, time.Time, error) {
+	from := dateOnlyValue(now)
+	if strings.TrimSpace(fromRaw) != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(fromRaw), now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("开始日期格式错误，应为 YYYY-MM-DD")
+		}
+		from = parsed
+	}
+	to := from.AddDate(0, 0, promotionCalendarDefaultDays)
+	if strings.TrimSpace(toRaw) != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(toRaw), now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("结束日期格式错误，应为 YYYY-MM-DD")
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("结束日期不能早于开始日期")
+	}
+	if to.Sub(from) > promotionCalendarMaxDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("查询范围最多 %d 天", promotionCalendarMaxDays)
+	}
+	return from, to, nil
+}
+
+// clipCalendarInterval 活动时间与查询范围取交集；没有开始时间视为已开始，没有结束时间视为持续到范围结束
+func clipCalendarInterval(action model.PromotionAction, from, to time.Time) (calendarInterval, bool) {
+	interval := calendarInterval{ActionID: action.ID, Start: from, End: to}
+	if action.DateStart != nil && action.DateStart.After(from) {
+		interval.Start = *action.DateStart
+	}
+	if action.DateEnd != nil && action.DateEnd.Before(to) {
+		interval.End = *action.DateEnd
+	}
+	return interval, interval.Start.Before(interval.End)
+}
+
+// analyzeCalendarSKU 计算重叠与脱离，并为每次脱离找出当时可报名的候选活动
+func analyzeCalendarSKU(entry *calendarSKU, to time.Time) {
+	sortCalendarIntervals(entry.Participating)
+	sortCalendarIntervals(entry.Candidates)
+	entry.Overlaps = findCalendarOverlaps(entry.Participating)
+	entry.DropOuts = findCalendarDropOuts(entry.Participating, to)
+	for index := range entry.DropOuts {
+		at := entry.DropOuts[index].At
+		ids := make([]uint, 0)
+		for _, candidate := range entry.Candidates {
+			if !candidate.Start.After(at) && candidate.End.After(at) {
+				ids = append(ids, candidate.ActionID)
+			}
+		}
+		entry.DropOuts[index].CandidateActionIDs = ids
+	}
+}
+
+func sortCalendarIntervals(intervals []calendarInterval) {
+	sort.Slice(intervals, func(i, j int) bool {
+		if !intervals[i].Start.Equal(intervals[j].Start) {
+			return intervals[i].Start.Before(intervals[j].Start)
+		}
+		if !intervals[i].End.Equal(intervals[j].End) {
+			return intervals[i].End.Before(intervals[j].End)
+		}
+		return intervals[i].ActionID < intervals[j].ActionID
+	})
+}
+
+// findCalendarOverlaps 两两比较按开始时间排序的活动段，返回有交集的活动对及重叠时间段
+func findCalendarOverlaps(intervals []calendarInterval) []calendarOverlap {
+	overlaps := make([]calendarOverlap, 0)
+	for i := 0; i < len(intervals); i++ {
+		for j := i + 1; j < len(intervals); j++ {
+			if !intervals[j].Start.Before(intervals[i].End) {
+				break
+			}
+			end := intervals[i].End
+			if intervals[j].End.Before(end) {
+				end = intervals[j].End
+			}
+			overlaps = append(overlaps, calendarOverlap{
+				FirstActionID:  intervals[i].ActionID,
+				SecondActionID: intervals[j].ActionID,
+				Start:          intervals[j].Start,
+				End:            end,
+			})
+		}
+	}
+	return overlaps
+}
+
+// findCalendarDropOuts 合并按开始时间排序的活动段，覆盖在范围结束前中断即为脱离全部促销；
+// 中断后范围内重新开始的活动记为恢复
+func findCalendarDropOuts(intervals []calendarInterval, to time.Time) []calendarDropOut {
+	dropOuts := make([]calendarDropOut, 0)
+	index := 0
+	for index < len(intervals) {
+		coveredUntil := intervals[index].End
+		members := []calendarInterval{intervals[index]}
+		index++
+		for index < len(intervals) && !intervals[index].Start.After(coveredUntil) {
+			if intervals[index].End.After(coveredUntil) {
+				coveredUntil = intervals[index].End
+			}
+			members = append(members, intervals[index])
+			index++
+		}
+		if !coveredUntil.Before(to) {
+			break
+		}
+
+		dropOut := calendarDropOut{At: coveredUntil, EndingActionIDs: make([]uint, 0)}
+		for _, member := range members {
+			if member.End.Equal(coveredUntil) {
+				dropOut.EndingActionIDs = append(dropOut.EndingActionIDs, member.ActionID)
+			}
+		}
+		if index < len(intervals) {
+			resumeAt := intervals[index].Start
+			dropOut.ResumeAt = &resumeAt
+			for next := index; next < len(intervals) && intervals[next].Start.Equal(resumeAt); next++ {
+				dropOut.ResumeActionIDs = append(dropOut.ResumeActionIDs, intervals[next].ActionID)
+			}
+		}
+		dropOuts = append(dropOuts, dropOut)
+	}
+	return dropOuts
+}
+
+func groupCalendarBySKU(entries []*calendarSKU) []dto.PromotionCalendarGroup {
+	sorted := append([]*calendarSKU{}, entries...)
+	// 先列出会脱离促销的 SKU，越早脱离越靠前
+	sort.SliceStable(sorted, func(i, j int) bool {
+		left, right := sorted[i], sorted[j]
+		if (len(left.DropOuts) > 0) != (len(right.DropOuts) > 0) {
+			return len(left.DropOuts) > 0
+		}
+		if len(left.DropOuts) > 0 && !left.DropOuts[0].At.Equal(right.DropOuts[0].At) {
+			return left.DropOuts[0].At.Before(right.DropOuts[0].At)
+		}
+		return left.SKU < right.SKU
+	})
+
+	groups := make([]dto.PromotionCalendarGroup, 0, len(sorted))
+	for _, entry := range sorted {
+		group := newCalendarGroup(entry.SKU, entry.Name, entry.Category)
+		mergeCalendarSKU(&group, entry, false)
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func groupCalendarByCategory(entries []*calendarSKU) []dto.PromotionCalendarGroup {
+	indexByCategory := make(map[string]int)
+	groups := make([]dto.PromotionCalendarGroup, 0)
+	for _, entry := range entries {
+		index, ok := indexByCategory[entry.Category]
+		if !ok {
+			index = len(groups)
+			indexByCategory[entry.Category] = index
+			groups = append(groups, newCalendarGroup(entry.Category, entry.Category, entry.Category))
+		}
+		mergeCalendarSKU(&groups[index], entry, true)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].SKUCount != groups[j].SKUCount {
+			return groups[i].SKUCount > groups[j].SKUCount
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+func newCalendarGroup(key, name, category string) dto.PromotionCalendarGroup {
+	return dto.PromotionCalendarGroup{
+		Key:        key,
+		Name:       name,
+		Category:   category,
+		ActionIDs:  make([]uint, 0),
+		Overlaps:   make([]dto.PromotionCalendarOverlap, 0),
+		DropOuts:   make([]dto.PromotionCalendarDropOut, 0),
+		Candidates: make([]dto.PromotionCalendarCandidate, 0),
+	}
+}
+
+// mergeCalendarSKU 将单个 SKU 的结果并入分组，相同的重叠、脱离与候选合并计数；withSKUs 时记录示例 SKU
+func mergeCalendarSKU(group *dto.PromotionCalendarGroup, entry *calendarSKU, withSKUs bool) {
+	group.SKUCount++
+	addSample := func(skus []string) []string {
+		if withSKUs && len(skus) < promotionCalendarSampleSKUs {
+			return append(skus, entry.SKU)
+		}
+		return skus
+	}
+
+	for _, interval := range entry.Participating {
+		group.ActionIDs = append(group.ActionIDs, interval.ActionID)
+	}
+	group.ActionIDs = uniqueUints(group.ActionIDs)
+	sort.Slice(group.ActionIDs, func(i, j int) bool { return group.ActionIDs[i] < group.ActionIDs[j] })
+
+	for _, overlap := range entry.Overlaps {
+		from, to := overlap.Start.Format(promotionCalendarTimeLayout), overlap.End.Format(promotionCalendarTimeLayout)
+		found := false
+		for index := range group.Overlaps {
+			item := &group.Overlaps[index]
+			if item.FirstActionID == overlap.FirstActionID && item.SecondActionID == overlap.SecondActionID && item.From == from && item.To == to {
+				item.SKUCount++
+				item.SKUs = addSample(item.SKUs)
+				found = true
+				break
+			}
+		}
+		if !found {
+			group.Overlaps = append(group.Overlaps, dto.PromotionCalendarOverlap{
+				FirstActionID:  overlap.FirstActionID,
+				SecondActionID: overlap.SecondActionID,
+				From:           from,
+				To:             to,
+				SKUCount:       1,
+				SKUs:           addSample(nil),
+			})
+		}
+	}
+
+	for _, dropOut := range entry.DropOuts {
+		at := dropOut.At.Format(promotionCalendarTimeLayout)
+		resumeAt := formatCalendarTime(dropOut.ResumeAt)
+		found := false
+		for index := range group.DropOuts {
+			item := &group.DropOuts[index]
+			if item.At == at && item.ResumeAt == resumeAt && sameUints(item.EndingActionIDs, dropOut.EndingActionIDs) && sameUints(item.ResumeActionIDs, dropOut.ResumeActionIDs) {
+				item.CandidateActionIDs = uniqueUints(append(item.CandidateActionIDs, dropOut.CandidateActionIDs...))
+				item.SKUCount++
+				item.SKUs = addSample(item.SKUs)
+				found = true
+				break
+			}
+		}
+		if !found {
+			group.DropOuts = append(group.DropOuts, dto.PromotionCalendarDropOut{
+				At:                 at,
+				EndingActionIDs:    dropOut.EndingActionIDs,
+				ResumeAt:           resumeAt,
+				ResumeActionIDs:    dropOut.ResumeActionIDs,
+				CandidateActionIDs: dropOut.CandidateActionIDs,
+				SKUCount:           1,
+				SKUs:               addSample(nil),
+			})
+		}
+	}
+
+	for _, candidate := range entry.Candidates {
+		found := false
+		for index := range group.Candidates {
+			item := &group.Candidates[index]
+			if item.PromotionActionID == candidate.ActionID {
+				item.SKUCount++
+				item.SKUs = addSample(item.SKUs)
+				found = true
+				break
+			}
+		}
+		if !found {
+			group.Candidates = append(group.Candidates, dto.PromotionCalendarCandidate{
+				PromotionActionID: candidate.ActionID,
+				SKUCount:          1,
+				SKUs:              addSample(nil),
+			})
+		}
+	}
+}
+
+func sameUints(left, right []uint) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for index := range left {
+		if left[index] != right[index] {
+			return false
+		}
+	}
+	return true
+}
+
+func formatCalendarTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.Format(promotionCalendarTimeLayout)
+}
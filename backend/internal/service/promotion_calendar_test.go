@@ -0,0 +1,137 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"ozon-manager/internal/model"
+)
+
+func calendarDay(day int, hour int) time.Time {
+	return time.Date(2026, 10, day, hour, 0, 0, 0, time.Local)
+}
+
+func TestResolvePromotionCalendarRange(t *testing.T) {
+	t.Parallel()
+
+	now := calendarDay(19, 15)
+	from, to, err := resolvePromotionCalendarRange("", "", now)
+	if err != nil || !from.Equal(calendarDay(19, 0)) || !to.Equal(calendarDay(26, 0)) {
+		t.Fatalf("default range = %v ~ %v, err=%v", from, to, err)
+	}
+	from, to, err = resolvePromotionCalendarRange("2026-10-20", "2026-10-20", now)
+	if err != nil || !from.Equal(calendarDay(20, 0)) || !to.Equal(calendarDay(21, 0)) {
+		t.Fatalf("single day range = %v ~ %v, err=%v", from, to, err)
+	}
+	if _, _, err := resolvePromotionCalendarRange("2026-10-20", "2026-10-19", now); err == nil {
+		t.Fatalf("reversed range should fail")
+	}
+	if _, _, err := resolvePromotionCalendarRange("2026-01-01", "2026-12-31", now); err == nil {
+		t.Fatalf("range over limit should fail")
+	}
+}
+
+func TestClipCalendarInterval(t *testing.T) {
+	t.Parallel()
+
+	from, to := calendarDay(19, 0), calendarDay(26, 0)
+	start, end := calendarDay(21, 0), calendarDay(30, 0)
+	interval, ok := clipCalendarInterval(model.PromotionAction{ID: 1, DateStart: &start, DateEnd: &end}, from, to)
+	if !ok || !interval.Start.Equal(start) || !interval.End.Equal(to) {
+		t.Fatalf("clipped = %+v ok=%v", interval, ok)
+	}
+	interval, ok = clipCalendarInterval(model.PromotionAction{ID: 2}, from, to)
+	if !ok || !interval.Start.Equal(from) || !interval.End.Equal(to) {
+		t.Fatalf("open action = %+v ok=%v", interval, ok)
+	}
+	ended := calendarDay(18, 0)
+	if _, ok := clipCalendarInterval(model.PromotionAction{ID: 3, DateEnd: &ended}, from, to); ok {
+		t.Fatalf("ended action should be outside range")
+	}
+}
+
+func TestAnalyzeCalendarSKUOverlapsAndDropOuts(t *testing.T) {
+	t.Parallel()
+
+	to := calendarDay(26, 0)
+	entry := &calendarSKU{
+		SKU: "SKU-1",
+		Participating: []calendarInterval{
+			{ActionID: 2, Start: calendarDay(20, 0), End: calendarDay(22, 0)},
+			{ActionID: 1, Start: calendarDay(19, 0), End: calendarDay(21, 0)},
+			{ActionID: 3, Start: calendarDay(24, 0), End: to},
+		},
+		Candidates: []calendarInterval{
+			{ActionID: 9, Start: calendarDay(19, 0), End: calendarDay(23, 0)},
+			{ActionID: 8, Start: calendarDay(23, 0), End: to},
+		},
+	}
+	analyzeCalendarSKU(entry, to)
+
+	if len(entry.Overlaps) != 1 {
+		t.Fatalf("overlaps = %+v", entry.Overlaps)
+	}
+	overlap := entry.Overlaps[0]
+	if overlap.FirstActionID != 1 || overlap.SecondActionID != 2 || !overlap.Start.Equal(calendarDay(20, 0)) || !overlap.End.Equal(calendarDay(21, 0)) {
+		t.Fatalf("overlap = %+v", overlap)
+	}
+
+	if len(entry.DropOuts) != 1 {
+		t.Fatalf("drop outs = %+v", entry.DropOuts)
+	}
+	dropOut := entry.DropOuts[0]
+	if !dropOut.At.Equal(calendarDay(22, 0)) || len(dropOut.EndingActionIDs) != 1 || dropOut.EndingActionIDs[0] != 2 {
+		t.Fatalf("drop out = %+v", dropOut)
+	}
+	if dropOut.ResumeAt == nil || !dropOut.ResumeAt.Equal(calendarDay(24, 0)) || len(dropOut.ResumeActionIDs) != 1 || dropOut.ResumeActionIDs[0] != 3 {
+		t.Fatalf("resume = %+v", dropOut)
+	}
+	if len(dropOut.CandidateActionIDs) != 1 || dropOut.CandidateActionIDs[0] != 9 {
+		t.Fatalf("candidates at drop out = %+v", dropOut.CandidateActionIDs)
+	}
+}
+
+func TestFindCalendarDropOutsCoveredToEnd(t *testing.T) {
+	t.Parallel()
+
+	to := calendarDay(26, 0)
+	intervals := []calendarInterval{
+		{ActionID: 1, Start: calendarDay(19, 0), End: calendarDay(22, 0)},
+		{ActionID: 2, Start: calendarDay(22, 0), End: to},
+	}
+	if dropOuts := findCalendarDropOuts(intervals, to); len(dropOuts) != 0 {
+		t.Fatalf("back-to-back actions should not drop out: %+v", dropOuts)
+	}
+	if dropOuts := findCalendarDropOuts(intervals[:1], to); len(dropOuts) != 1 || dropOuts[0].ResumeAt != nil {
+		t.Fatalf("single ending action should drop out without resume: %+v", dropOuts)
+	}
+}
+
+func TestGroupCalendarByCategoryMergesSKUs(t *testing.T) {
+	t.Parallel()
+
+	to := calendarDay(26, 0)
+	entries := make([]*calendarSKU, 0)
+	for _, sku := range []string{"A", "B"} {
+		entry := &calendarSKU{
+			SKU:           sku,
+			Category:      "服饰",
+			Participating: []calendarInterval{{ActionID: 1, Start: calendarDay(19, 0), End: calendarDay(21, 0)}},
+			Candidates:    []calendarInterval{{ActionID: 5, Start: calendarDay(23, 0), End: to}},
+		}
+		analyzeCalendarSKU(entry, to)
+		entries = append(entries, entry)
+	}
+	entries = append(entries, &calendarSKU{SKU: "C", Category: promotionCalendarNoCategory})
+
+	groups := groupCalendarByCategory(entries)
+	if len(groups) != 2 || groups[0].Key != "服饰" || groups[0].SKUCount != 2 {
+		t.Fatalf("groups = %+v", groups)
+	}
+	if len(groups[0].DropOuts) != 1 || groups[0].DropOuts[0].SKUCount != 2 || len(groups[0].DropOuts[0].SKUs) != 2 {
+		t.Fatalf("drop outs = %+v", groups[0].DropOuts)
+	}
+	if len(groups[0].Candidates) != 1 || groups[0].Candidates[0].SKUCount != 2 {
+		t.Fatalf("candidates = %+v", groups[0].Candidates)
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"fmt"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+)
+
+// 试运行：活动查询与拆分、亏损记录转换、任务明细与报名价的构建与实际执行共用同一组函数；
+// 官方活动的逐个商品调用由 promotion_official_calls.go 生成步骤列表，实际执行与 planOfficialSteps 消费同一份列表
+
+const (
+	ozonEndpointActivate    = "/v1/actions/products/activate"
+	ozonEndpointDeactivate  = "/v1/actions/products/deactivate"
+	ozonEndpointImportPrice = "/v1/product/import/prices"
+)
+
+func newOperationPlan() *dto.OperationPlan {
+	return &dto.OperationPlan{
+		OfficialCalls: make([]dto.PlannedOzonCall, 0),
+		ShopJobs:      make([]dto.PlannedShopJob, 0),
+		Skipped:       make([]dto.PlannedSkip, 0),
+		Warnings:      make([]string, 0),
+	}
+}
+
+// checkPlanWritable 试运行不拦截不可写的店铺，但在计划中提示实际执行会被拒绝
+func (s *PromotionService) checkPlanWritable(plan *dto.OperationPlan, shopID uint) {
+	if err := s.shopGuard.CheckWritable(shopID); err != nil {
+		plan.Warnings = append(plan.Warnings, "实际执行会被拒绝: "+err.Error())
+	}
+}
+
+// planOfficialSteps 把单个商品的调用步骤转为计划；报名价与商品 ID 取自执行使用的同一个 activateProductItem
+func planOfficialSteps(plan *dto.OperationPlan, steps officialProductSteps) {
+	product := steps.Product
+	for _, call := range steps.calls() {
+		planned := dto.PlannedOzonCall{
+			Operation:     call.Operation,
+			ActionID:      call.ActionID,
+			ActionTitle:   call.ActionTitle,
+			SourceSKU:     product.SourceSKU,
+			OzonProductID: product.OzonProductID,
+		}
+		switch call.Operation {
+		case officialCallActivate:
+			item := activateProductItem(product)
+			planned.Endpoint = ozonEndpointActivate
+			planned.OzonProductID = item.ProductID
+			planned.Price = item.ActionPrice
+		case officialCallDeactivate:
+			planned.Endpoint = ozonEndpointDeactivate
+		case officialCallUpdatePrice:
+			planned.Endpoint = ozonEndpointImportPrice
+			planned.Price = call.Price
+		}
+		plan.OfficialCalls = append(plan.OfficialCalls, planned)
+	}
+}
+
+func plannedShopActions(actions []model.PromotionAction) []dto.PlannedShopAction {
+	result := make([]dto.PlannedShopAction, 0, len(actions))
+	for _, ref := range buildShopActionsMeta(actions) {
+		result = append(result, dto.PlannedShopAction{
+			PromotionActionID: ref.ActionDBID,
+			SourceActionID:    ref.SourceActionID,
+			Title:             ref.Title,
+		})
+	}
+	return result
+}
+
+// planUnifiedShopActionsJob 对应 CreateUnifiedShopActionsJob，明细由同一个 unifiedShopActionsJobItems 生成；
+// 店铺活动报名/退出任务的目标价只是占位，计划中不展示
+func planUnifiedShopActionsJob(plan *dto.OperationPlan, jobType string, shopActions []model.PromotionAction, skus []string) error {
+	jobItems, err := unifiedShopActionsJobItems(skus)
+	if err != nil {
+		return err
+	}
+	items := make([]dto.PlannedJobItem, 0, len(jobItems))
+	for _, item := range jobItems {
+		items = append(items, dto.PlannedJobItem{SourceSKU: item.SourceSKU})
+	}
+
+	plan.ShopJobs = append(plan.ShopJobs, dto.PlannedShopJob{
+		JobType:     jobType,
+		Operation:   unifiedShopActionsOperation(jobType),
+		ShopActions: plannedShopActions(shopActions),
+		Items:       items,
+	})
+	return nil
+}
+
+// planRemoveRepriceReaddJob 对应 createRemoveRepriceReaddJob，明细由同一个 removeRepriceReaddJobItems 生成
+func planRemoveRepriceReaddJob(plan *dto.OperationPlan, reason string, shopActions []model.PromotionAction, products []dto.RepriceItem) error {
+	jobItems, err := removeRepriceReaddJobItems(products)
+	if err != nil {
+		return err
+	}
+	items := make([]dto.PlannedJobItem, 0, len(jobItems))
+	for _, item := range jobItems {
+		items = append(items, dto.PlannedJobItem{SourceSKU: item.SourceSKU, TargetPrice: item.TargetPrice})
+	}
+
+	plan.ShopJobs = append(plan.ShopJobs, dto.PlannedShopJob{
+		JobType:     model.AutomationJobTypeRemoveRepriceReadd,
+		Reason:      reason,
+		ShopActions: plannedShopActions(shopActions),
+		Items:       items,
+	})
+	return nil
+}
+
+// planOfficialRemove 对应 removeFromOfficialActions
+func (s *PromotionService) planOfficialRemove(plan *dto.OperationPlan, shopID uint, officialActions []model.PromotionAction, sourceSKUs []string) {
+	calls := removeCalls(officialActions)
+	for _, sku := range sourceSKUs {
+		product, err := s.productRepo.FindBySourceSKU(shopID, sku)
+		if err != nil {
+			plan.Skipped = append(plan.Skipped, dto.PlannedSkip{SourceSKU: sku, Reason: "商品未找到"})
+			continue
+		}
+		planOfficialSteps(plan, officialProductSteps{Product: *product, Exit: calls})
+	}
+}
+
+// planProcessLoss 对应 ProcessLossProductsV2：退出全部活动 → 改价 → 重新报名（报名价为改价前的当前价）
+func (s *PromotionService) planProcessLoss(plan *dto.OperationPlan, lossProductIDs []uint, rejoinAction *model.PromotionAction) error {
+	lossProducts, err := s.promotionRepo.FindLossProductsByIDs(lossProductIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get loss products: %w", err)
+	}
+	for _, lp := range lossProducts {
+		exitCalls, err := s.exitAllCalls(lp.Product)
+		if err != nil {
+			return err
+		}
+		planOfficialSteps(plan, repriceProductSteps(lp.Product, exitCalls, lp.NewPrice, rejoinActionList(rejoinAction)))
+	}
+	return nil
+}
+
+// planRemoveReprice 对应 RemoveRepricePromoteV2：退出全部活动 → 改价 → 重新报名（报名价为改价前的当前价）
+func (s *PromotionService) planRemoveReprice(plan *dto.OperationPlan, shopID uint, items []dto.RepriceItem, reenrollActions []model.PromotionAction) error {
+	for _, item := range items {
+		product, err := s.productRepo.FindBySourceSKU(shopID, item.SourceSKU)
+		if err != nil {
+			plan.Skipped = append(plan.Skipped, dto.PlannedSkip{SourceSKU: item.SourceSKU, Reason: "商品未找到"})
+			continue
+		}
+		exitCalls, err := s.exitAllCalls(*product)
+		if err != nil {
+			return err
+		}
+		planOfficialSteps(plan, repriceProductSteps(*product, exitCalls, item.NewPrice, reenrollActions))
+	}
+	return nil
+}
+
+// summarizeOperationPlan 试运行的提示信息
+func summarizeOperationPlan(plan *dto.OperationPlan) string {
+	msg := fmt.Sprintf("试运行：将发起 %d 次 Ozon 接口调用，创建 %d 个店铺活动任务", len(plan.OfficialCalls), len(plan.ShopJobs))
+	if len(plan.Skipped) > 0 {
+		msg += fmt.Sprintf("，跳过 %d 个 SKU", len(plan.Skipped))
+	}
+	if len(plan.Warnings) > 0 {
+		msg += "；" + plan.Warnings[0]
+	}
+	return msg
+}
+
+// planUnifiedProcessLoss UnifiedProcessLoss 的试运行，路由与实际执行一致：
+// 含店铺活动时整体交给 remove_reprice_readd 任务，否则同步处理并重新报名第一个官方活动
+func (s *PromotionService) planUnifiedProcessLoss(req *dto.UnifiedProcessLossRequest) (*dto.UnifiedProcessLossResponse, error) {
+	plan := newOperationPlan()
+	s.checkPlanWritable(plan, req.ShopID)
+	respond := func() (*dto.UnifiedProcessLossResponse, error) {
+		return &dto.UnifiedProcessLossResponse{Mode: "dry_run", Plan: plan, Message: summarizeOperationPlan(plan)}, nil
+	}
+
+	officialActions, shopActions, err := s.findRoutedActions(req.ShopID, req.RejoinActionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shopActions) > 0 {
+		lossProducts, err := s.promotionRepo.FindLossProductsByIDs(req.LossProductIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get loss products: %w", err)
+		}
+		if err := planRemoveRepriceReaddJob(plan, "unified_process_loss", shopActions, lossRepriceItems(lossProducts)); err != nil {
+			return nil, err
+		}
+		return respond()
+	}
+
+	if err := s.planProcessLoss(plan, req.LossProductIDs, firstRejoinAction(officialActions)); err != nil {
+		return nil, err
+	}
+	return respond()
+}
+
+// planUnifiedRepricePromote UnifiedRepricePromote 的试运行，路由与实际执行一致
+func (s *PromotionService) planUnifiedRepricePromote(req *dto.UnifiedRepricePromoteRequest) (*dto.UnifiedRepricePromoteResponse, error) {
+	plan := newOperationPlan()
+	s.checkPlanWritable(plan, req.ShopID)
+	respond := func() (*dto.UnifiedRepricePromoteResponse, error) {
+		return &dto.UnifiedRepricePromoteResponse{Mode: "dry_run", Plan: plan, Message: summarizeOperationPlan(plan)}, nil
+	}
+
+	officialActions, shopActions, err := s.findRoutedActions(req.ShopID, req.ReenrollActionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shopActions) > 0 {
+		if err := planRemoveRepriceReaddJob(plan, "unified_reprice_promote", shopActions, req.Products); err != nil {
+			return nil, err
+		}
+		return respond()
+	}
+
+	if err := s.planRemoveReprice(plan, req.ShopID, req.Products, officialActions); err != nil {
+		return nil, err
+	}
+	return respond()
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+)
+
+func TestPlanUnifiedShopActionsJob(t *testing.T) {
+	t.Parallel()
+
+	plan := newOperationPlan()
+	actions := []model.PromotionAction{{ID: 7, Source: "shop", SourceActionID: "s-1", Title: "Shop sale"}}
+	if err := planUnifiedShopActionsJob(plan, model.AutomationJobTypePromoUnifiedRemove, actions, []string{"A", " A ", "B", ""}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.ShopJobs) != 1 {
+		t.Fatalf("jobs = %+v", plan.ShopJobs)
+	}
+	job := plan.ShopJobs[0]
+	if job.Operation != "remove" || len(job.Items) != 2 || job.ShopActions[0].PromotionActionID != 7 {
+		t.Fatalf("job = %+v, want remove with deduplicated SKUs", job)
+	}
+
+	if err := planUnifiedShopActionsJob(plan, model.AutomationJobTypePromoUnifiedEnroll, actions, []string{" "}); err == nil {
+		t.Fatal("empty SKU list should fail like the real job creation")
+	}
+}
+
+func TestPlanRemoveRepriceReaddJob(t *testing.T) {
+	t.Parallel()
+
+	plan := newOperationPlan()
+	items := []dto.RepriceItem{{SourceSKU: "A", NewPrice: 99.9}, {SourceSKU: " ", NewPrice: 10}}
+	if err := planRemoveRepriceReaddJob(plan, "unified_reprice_promote", nil, items); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	job := plan.ShopJobs[0]
+	if job.JobType != model.AutomationJobTypeRemoveRepriceReadd || job.Reason != "unified_reprice_promote" {
+		t.Fatalf("job = %+v", job)
+	}
+	if len(job.Items) != 1 || job.Items[0].TargetPrice != 99.9 {
+		t.Fatalf("items = %+v, want blank SKU dropped", job.Items)
+	}
+}
+
+func TestPlanOfficialCallsAndSummary(t *testing.T) {
+	t.Parallel()
+
+	plan := newOperationPlan()
+	product := model.Product{SourceSKU: "A", OzonProductID: 42, CurrentPrice: 120}
+	exitCalls := exitCallsFromRecords([]model.PromotedProduct{{ActionID: 1, PromotionType: "custom"}}, []model.PromotionAction{{ActionID: 1, Title: "Old"}})
+	planOfficialSteps(plan, repriceProductSteps(product, exitCalls, 100, []model.PromotionAction{{ActionID: 2, Title: "New"}}))
+	plan.Skipped = append(plan.Skipped, dto.PlannedSkip{SourceSKU: "B", Reason: "商品未找到"})
+
+	if got := plan.OfficialCalls[0]; got.Endpoint != ozonEndpointDeactivate || got.ActionTitle != "Old" {
+		t.Fatalf("deactivate call = %+v, want titled exit", got)
+	}
+	if got := plan.OfficialCalls[2]; got.Endpoint != ozonEndpointActivate || got.Price != 120 || got.ActionTitle != "New" {
+		t.Fatalf("activate call = %+v, want current price like enrollProductToAction", got)
+	}
+	if got := plan.OfficialCalls[1]; got.Operation != "update_price" || got.Price != 100 {
+		t.Fatalf("price call = %+v", got)
+	}
+	if msg := summarizeOperationPlan(plan); !strings.Contains(msg, "3 次") || !strings.Contains(msg, "跳过 1 个") {
+		t.Fatalf("summary = %q", msg)
+	}
+}
+
+func TestExitCallsFromRecordsNamesActions(t *testing.T) {
+	t.Parallel()
+
+	records := []model.PromotedProduct{
+		{ActionID: 11, PromotionType: "custom", ActionPrice: 90},
+		{ActionID: 22, PromotionType: "elastic"},
+	}
+	actions := []model.PromotionAction{{ActionID: 11, Title: "Sale", DisplayName: "Autumn"}}
+	calls := exitCallsFromRecords(records, actions)
+	if len(calls) != 2 || calls[0].Operation != officialCallDeactivate {
+		t.Fatalf("calls = %+v", calls)
+	}
+	if calls[0].ActionTitle != "Autumn" || calls[0].PromotionType != "custom" || calls[0].ActionPrice != 90 {
+		t.Fatalf("first call = %+v, want display name and record fields", calls[0])
+	}
+	if calls[1].ActionTitle != "活动 #22" {
+		t.Fatalf("unknown action title = %q, want action id fallback", calls[1].ActionTitle)
+	}
+}
+
+func TestPlanShopJobsMatchExecutionInputs(t *testing.T) {
+	t.Parallel()
+
+	shopActions := []model.PromotionAction{{ID: 7, Source: "shop", SourceActionID: " s-1 ", Title: "Shop sale", DisplayName: "Autumn"}}
+	skus := []string{"A", " A ", "B", ""}
+	products := []dto.RepriceItem{{SourceSKU: " A ", NewPrice: 99.9}, {SourceSKU: "", NewPrice: 10}, {SourceSKU: "B", NewPrice: 50}}
+
+	plan := newOperationPlan()
+	if err := planUnifiedShopActionsJob(plan, model.AutomationJobTypePromoUnifiedEnroll, shopActions, skus); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := planRemoveRepriceReaddJob(plan, "unified_reprice_promote", shopActions, products); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	enrollItems, err := unifiedShopActionsJobItems(skus)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	readdItems, err := removeRepriceReaddJobItems(products)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for index, jobItems := range [][]model.AutomationJobItem{enrollItems, readdItems} {
+		planned := plan.ShopJobs[index]
+		if len(planned.Items) != len(jobItems) {
+			t.Fatalf("job %s planned %d items, execution creates %d", planned.JobType, len(planned.Items), len(jobItems))
+		}
+		for i, item := range jobItems {
+			if planned.Items[i].SourceSKU != item.SourceSKU {
+				t.Fatalf("job %s item %d sku = %q, execution = %q", planned.JobType, i, planned.Items[i].SourceSKU, item.SourceSKU)
+			}
+		}
+		meta := buildShopActionsMeta(shopActions)
+		if len(planned.ShopActions) != len(meta) || planned.ShopActions[0].SourceActionID != meta[0].SourceActionID || planned.ShopActions[0].Title != meta[0].Title {
+			t.Fatalf("job %s shop actions = %+v, execution meta = %+v", planned.JobType, planned.ShopActions, meta)
+		}
+	}
+	for i, item := range readdItems {
+		if plan.ShopJobs[1].Items[i].TargetPrice != item.TargetPrice {
+			t.Fatalf("readd item %d price = %v, execution = %v", i, plan.ShopJobs[1].Items[i].TargetPrice, item.TargetPrice)
+		}
+	}
+	if plan.ShopJobs[0].Operation != unifiedShopActionsOperation(model.AutomationJobTypePromoUnifiedEnroll) {
+		t.Fatalf("operation = %q", plan.ShopJobs[0].Operation)
+	}
+}
+
+func TestPlanActivateMatchesEnrollPayload(t *testing.T) {
+	t.Parallel()
+
+	product := model.Product{SourceSKU: "A", OzonProductID: 42, CurrentPrice: 120}
+	plan := newOperationPlan()
+	planOfficialSteps(plan, officialProductSteps{Product: product, Enroll: enrollCalls([]model.PromotionAction{{ActionID: 2, Title: "New"}})})
+
+	want := activateProductItem(product)
+	got := plan.OfficialCalls[0]
+	if got.OzonProductID != want.ProductID || got.Price != want.ActionPrice {
+		t.Fatalf("planned activate = %+v, execution payload = %+v", got, want)
+	}
+}
+
+func TestLossRoutingHelpers(t *testing.T) {
+	t.Parallel()
+
+	inputs := lossRepriceItems([]model.LossProduct{
+		{NewPrice: 80, Product: model.Product{SourceSKU: "A"}},
+		{NewPrice: 60, Product: model.Product{SourceSKU: "B"}},
+	})
+	if len(inputs) != 2 || inputs[0].SourceSKU != "A" || inputs[1].NewPrice != 60 {
+		t.Fatalf("lossRepriceItems() = %+v", inputs)
+	}
+
+	if got := firstRejoinAction(nil); got != nil {
+		t.Fatalf("firstRejoinAction(nil) = %+v, want nil", got)
+	}
+	actions := []model.PromotionAction{{ID: 1, ActionID: 11}, {ID: 2, ActionID: 22}}
+	if got := firstRejoinAction(actions); got == nil || got.ActionID != 11 {
+		t.Fatalf("firstRejoinAction() = %+v, want first official action", got)
+	}
+}
@@ -0,0 +1,592 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+)
+
+const (
+	promotionExpirationScanInterval = 10 * time.Minute
+	promotionExpirationStaleAfter   = 2 * time.Hour
+	// promotionExpirationUncoveredLimit 记录中保存的脱离商品明细上限，计数不受影响
+	promotionExpirationUncoveredLimit = 500
+)
+
+type promotionExpirationPayload struct {
+	ExpirationID uint `json:"expiration_id"`
+	ShopID       uint `json:"shop_id"`
+}
+
+// StartActionLifecycle 定时巡检已过结束时间的活动：置为 expired，退出本地推广记录，
+// 按配置报名后续活动，并记录处理后不在任何活动中的商品
+func (s *PromotionService) StartActionLifecycle() {
+	_ = s.promotionRepo.MarkStalePromotionExpirationsFailed(time.Now().Add(-promotionExpirationStaleAfter))
+
+	s.loops.Go(promotionExpirationScanInterval, s.scanExpiredActions)
+}
+
+func (s *PromotionService) scanExpiredActions(now time.Time) {
+	shops, err := s.shopRepo.FindActive()
+	if err != nil {
+		return
+	}
+
+	for _, shop := range shops {
+		actions, err := s.promotionRepo.FindEndedActivePromotionActions(shop.ID, now)
+		if err != nil {
+			continue
+		}
+		for _, action := range actions {
+			s.expireAction(action)
+		}
+	}
+}
+
+// expireAction 将活动置为 expired 并提交到期处理；同一结束时间已处理过的活动只改状态
+func (s *PromotionService) expireAction(action model.PromotionAction) {
+	if action.DateEnd == nil {
+		return
+	}
+	expiration := &model.PromotionActionExpiration{
+		ShopID:            action.ShopID,
+		PromotionActionID: action.ID,
+		ActionID:          action.ActionID,
+		Source:            action.Source,
+		Title:             displayActionName(action),
+		DateEnd:           *action.DateEnd,
+		Status:            model.PromotionExpirationStatusPending,
+	}
+	created, err := s.promotionRepo.ExpirePromotionAction(expiration)
+	if err != nil || !created {
+		return
+	}
+
+	payload := promotionExpirationPayload{ExpirationID: expiration.ID, ShopID: expiration.ShopID}
+	if s.taskQueue == nil {
+		go func() { _ = s.handleExpirationTask(context.Background(), payload) }()
+		return
+	}
+	if err := s.taskQueue.Enqueue(model.BackgroundTaskTypePromotionExpiration, payload); err != nil {
+		s.finishExpiration(expiration, model.PromotionExpirationStatusFailed, "提交到期处理失败: "+err.Error())
+	}
+}
+
+// registerExpirationTasks 到期处理通过持久化任务队列执行
+func (s *PromotionService) registerExpirationTasks(queue *TaskQueue) {
+	queue.Register(model.BackgroundTaskTypePromotionExpiration, TaskOptions{
+		MaxAttempts: 3,
+		Timeout:     10 * time.Minute,
+	}, TypedTaskHandler(s.handleExpirationTask))
+}
+
+func (s *PromotionService) handleExpirationTask(ctx context.Context, payload promotionExpirationPayload) error {
+	expiration, err := s.promotionRepo.FindPromotionExpirationByIDAndShop(payload.ExpirationID, payload.ShopID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if expiration.Status != model.PromotionExpirationStatusPending && expiration.Status != model.PromotionExpirationStatusRunning {
+		return nil
+	}
+
+	expiration.Status = model.PromotionExpirationStatusRunning
+	if err := s.promotionRepo.UpdatePromotionExpiration(expiration); err != nil {
+		return err
+	}
+
+	if err := s.processExpiration(ctx, expiration); err != nil {
+		if ctx.Err() != nil {
+			// 服务停止：记录退回排队状态，任务退还队列后继续处理
+			expiration.Status = model.PromotionExpirationStatusPending
+			_ = s.promotionRepo.UpdatePromotionExpiration(expiration)
+			return err
+		}
+		s.finishExpiration(expiration, model.PromotionExpirationStatusFailed, err.Error())
+	}
+	return nil
+}
+
+// processExpiration 处理单个到期活动。重试时以首次保存的商品列表为准：
+// 已退出的推广记录不会重复退出，已提交过店铺活动任务的不再重复提交
+func (s *PromotionService) processExpiration(ctx context.Context, expiration *model.PromotionActionExpiration) error {
+	action, err := s.promotionRepo.FindPromotionActionByIDAndShop(expiration.PromotionActionID, expiration.ShopID)
+	if err != nil {
+		return fmt.Errorf("活动不存在: %w", err)
+	}
+	shopID := expiration.ShopID
+	official := action.Source != "shop"
+	now := time.Now()
+	source := participationSource{
+		Origin:    model.PromotionParticipationOriginActionExpired,
+		Reference: fmt.Sprintf("action_expiration:%d", expiration.ID),
+	}
+
+	var promoted []model.PromotedProduct
+	if official {
+		promoted, err = s.promotionRepo.FindActivePromotedProductsByAction(shopID, action.ActionID)
+		if err != nil {
+			return fmt.Errorf("查询推广记录失败: %w", err)
+		}
+	}
+
+	firstPass := len(expiration.SKUs) == 0
+	if firstPass {
+		actionProducts, err := s.promotionRepo.ListCalendarActionProducts(shopID, []uint{action.ID})
+		if err != nil {
+			return fmt.Errorf("查询活动商品缓存失败: %w", err)
+		}
+		candidates, err := s.promotionRepo.ListCalendarActionCandidates(shopID, []uint{action.ID})
+		if err != nil {
+			return fmt.Errorf("查询活动候选缓存失败: %w", err)
+		}
+		skus := expiredActionSKUs(promoted, actionProducts, candidates)
+		expiration.SKUs, _ = json.Marshal(skus)
+		expiration.SKUCount = len(skus)
+		if err := s.promotionRepo.UpdatePromotionExpiration(expiration); err != nil {
+			return err
+		}
+	}
+	skus := decodeExpirationSKUs(expiration.SKUs)
+	products, err := s.productRepo.FindBySourceSKUs(shopID, skus)
+	if err != nil {
+		return fmt.Errorf("查询商品失败: %w", err)
+	}
+
+	// 官方活动退出本地推广记录，商品没有其他有效推广记录时清除推广标记；
+	// 店铺活动没有推广记录，只补记退出流水
+	events := make([]model.PromotionParticipationEvent, 0, len(skus))
+	exited := make(map[uint]struct{})
+	for _, row := range promoted {
+		if err := s.promotionRepo.ExitPromotionAction(row.ProductID, action.ActionID); err != nil {
+			return fmt.Errorf("退出推广记录失败: %w", err)
+		}
+		events = append(events, source.actionEvent(model.PromotionParticipationLeft, row.Product, "official", action.ActionID, action.Title, row.ActionPrice))
+		if _, ok := exited[row.ProductID]; ok {
+			continue
+		}
+		exited[row.ProductID] = struct{}{}
+		expiration.ExitedCount++
+		if remaining, listErr := s.promotionRepo.FindPromotedProductsByProductID(row.ProductID); listErr == nil && len(remaining) == 0 {
+			_ = s.productRepo.UpdatePromotedStatus(row.ProductID, false)
+			expiration.UnpromotedCount++
+		}
+	}
+	if !official && firstPass {
+		for _, sku := range skus {
+			if product, ok := products[sku]; ok {
+				events = append(events, source.actionEvent(model.PromotionParticipationLeft, product, "shop", action.ActionID, action.Title, 0))
+				expiration.ExitedCount++
+			}
+		}
+	}
+	s.recordParticipation(shopID, events...)
+	if err := s.promotionRepo.UpdatePromotionExpiration(expiration); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	enrolled, notes := s.enrollExpirationSuccessors(expiration, action, skus, products, source, now)
+
+	covered, err := s.currentlyCoveredSKUs(shopID, now)
+	if err != nil {
+		return err
+	}
+	for sku := range enrolled {
+		covered[sku] = struct{}{}
+	}
+	uncovered := uncoveredExpiredSKUs(skus, covered)
+	expiration.UncoveredCount = len(uncovered)
+	if len(uncovered) > promotionExpirationUncoveredLimit {
+		uncovered = uncovered[:promotionExpirationUncoveredLimit]
+	}
+	expiration.UncoveredSKUs, _ = json.Marshal(uncovered)
+
+	status := model.PromotionExpirationStatusSuccess
+	if expiration.SuccessorFailed > 0 || len(notes) > 0 {
+		status = model.PromotionExpirationStatusPartialSuccess
+	}
+	s.finishExpiration(expiration, status, strings.Join(notes, "；"))
+	return nil
+}
+
+// enrollExpirationSuccessors 将到期活动的商品报名到配置的后续活动中商品是候选的活动：
+// 官方活动直接调用 Ozon 报名，店铺活动提交浏览器插件任务。返回已报名或已提交任务的 SKU 与未能报名的原因
+func (s *PromotionService) enrollExpirationSuccessors(expiration *model.PromotionActionExpiration, action *model.PromotionAction, skus []string, products map[string]model.Product, source participationSource, now time.Time) (map[string]struct{}, []string) {
+	enrolled := make(map[string]struct{})
+	successorIDs := decodeActionIDs(action.SuccessorActionIDs)
+	if len(successorIDs) == 0 || len(skus) == 0 {
+		return enrolled, nil
+	}
+
+	shopID := expiration.ShopID
+	configured, err := s.promotionRepo.FindPromotionActionsByIDs(shopID, successorIDs)
+	if err != nil {
+		return enrolled, []string{"查询后续活动失败: " + err.Error()}
+	}
+	successors := runningSuccessors(action, configured, now)
+	if len(successors) == 0 {
+		return enrolled, []string{"后续活动均已结束或不存在，未自动报名"}
+	}
+	if err := s.shopGuard.CheckWritable(shopID); err != nil {
+		return enrolled, []string{"店铺暂停写入，未报名后续活动: " + err.Error()}
+	}
+	candidates, err := s.promotionRepo.ListActionCandidatesByActionIDsAndSourceSKUs(shopID, actionIDsForActions(successors), skus)
+	if err != nil {
+		return enrolled, []string{"查询后续活动候选商品失败: " + err.Error()}
+	}
+	plan := planSuccessorEnrollment(successors, candidates)
+	if len(plan) == 0 {
+		return enrolled, nil
+	}
+
+	shop, err := s.shopRepo.GetWithCredentials(shopID)
+	if err != nil {
+		return enrolled, []string{"店铺不存在: " + err.Error()}
+	}
+	client := s.shopGuard.OzonClient(shop)
+	notes := make([]string, 0)
+	jobSubmitted := len(decodeActionIDs(expiration.JobIDs)) > 0
+	for _, successor := range successors {
+		successorSKUs := plan[successor.ID]
+		if len(successorSKUs) == 0 {
+			continue
+		}
+
+		if successor.Source == "shop" {
+			// 首次处理已提交过任务的，重试时不再重复提交
+			if jobSubmitted {
+				for _, sku := range successorSKUs {
+					enrolled[sku] = struct{}{}
+				}
+				continue
+			}
+			job, jobErr := s.CreateUnifiedShopActionsJob(shop.OwnerID, shopID, model.AutomationJobTypePromoUnifiedEnroll, []model.PromotionAction{successor}, successorSKUs)
+			if jobErr != nil {
+				expiration.SuccessorFailed += len(successorSKUs)
+				notes = append(notes, fmt.Sprintf("%s 提交任务失败: %s", displayActionName(successor), jobErr.Error()))
+				continue
+			}
+			jobIDs := append(decodeActionIDs(expiration.JobIDs), job.ID)
+			expiration.JobIDs, _ = json.Marshal(uniqueUints(jobIDs))
+			expiration.SuccessorEnrolled += len(successorSKUs)
+			for _, sku := range successorSKUs {
+				enrolled[sku] = struct{}{}
+			}
+			continue
+		}
+
+		failed := 0
+		lastError := ""
+		for _, sku := range successorSKUs {
+			product, ok := products[sku]
+			if !ok {
+				failed++
+				lastError = "商品未找到: " + sku
+				continue
+			}
+			if err := s.enrollProductToAction(client, successor.ActionID, product, "custom", source); err != nil {
+				failed++
+				lastError = err.Error()
+				continue
+			}
+			_ = s.productRepo.UpdatePromotedStatus(product.ID, true)
+			expiration.SuccessorEnrolled++
+			enrolled[sku] = struct{}{}
+		}
+		if failed > 0 {
+			expiration.SuccessorFailed += failed
+			notes = append(notes, fmt.Sprintf("%s 有 %d 个商品报名失败: %s", displayActionName(successor), failed, lastError))
+		}
+	}
+	_ = s.promotionRepo.UpdatePromotionExpiration(expiration)
+	return enrolled, notes
+}
+
+// currentlyCoveredSKUs 当前仍在进行中的活动里参与的商品：官方推广记录、活动商品缓存与候选同步结果。
+// 推广记录所属活动不在本地缓存中时无法判断是否结束，按仍在活动中处理
+func (s *PromotionService) currentlyCoveredSKUs(shopID uint, now time.Time) (map[string]struct{}, error) {
+	actions, err := s.promotionRepo.FindPromotionActionsByShopID(shopID)
+	if err != nil {
+		return nil, fmt.Errorf("查询促销活动失败: %w", err)
+	}
+	running := make([]model.PromotionAction, 0, len(actions))
+	for _, action := range actions {
+		if promotionActionRunning(action, now) {
+			running = append(running, action)
+		}
+	}
+	runningIDs := actionIDsForActions(running)
+
+	promoted, err := s.promotionRepo.FindActivePromotedProducts(shopID)
+	if err != nil {
+		return nil, fmt.Errorf("查询已推广商品失败: %w", err)
+	}
+	actionProducts, err := s.promotionRepo.ListCalendarActionProducts(shopID, runningIDs)
+	if err != nil {
+		return nil, fmt.Errorf("查询活动商品缓存失败: %w", err)
+	}
+	candidates, err := s.promotionRepo.ListCalendarActionCandidates(shopID, runningIDs)
+	if err != nil {
+		return nil, fmt.Errorf("查询活动候选缓存失败: %w", err)
+	}
+	return coveredPromotionSKUs(actions, running, promoted, actionProducts, candidates), nil
+}
+
+func (s *PromotionService) finishExpiration(expiration *model.PromotionActionExpiration, status, message string) {
+	completedAt := time.Now()
+	expiration.Status = status
+	expiration.ErrorMessage = message
+	expiration.CompletedAt = &completedAt
+	_ = s.promotionRepo.UpdatePromotionExpiration(expiration)
+}
+
+// expiredActionSKUs 到期时参与活动的商品：本地推广记录、活动商品缓存中 active 的商品，
+// 以及候选同步结果为已参与的商品
+func expiredActionSKUs(promoted []model.PromotedProduct, actionProducts []model.PromotionActionProduct, candidates []model.PromotionActionCandidate) []string {
+	skus := make([]string, 0, len(promoted)+len(actionProducts))
+	for _, row := range promoted {
+		skus = append(skus, row.Product.SourceSKU)
+	}
+	for _, row := range actionProducts {
+		if row.Status == "active" {
+			skus = append(skus, row.SourceSKU)
+		}
+	}
+	for _, row := range candidates {
+		if row.Status == model.PromotionActionCandidateStatusActive || row.Status == model.PromotionActionCandidateStatusAlreadyActive {
+			skus = append(skus, row.SourceSKU)
+		}
+	}
+	result := make([]string, 0, len(skus))
+	for _, sku := range uniqueStrings(skus) {
+		if strings.TrimSpace(sku) != "" {
+			result = append(result, sku)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// promotionActionRunning 活动为 active、已开始且未结束；没有开始 / 结束时间的视为已开始 / 未结束
+func promotionActionRunning(action model.PromotionAction, now time.Time) bool {
+	if action.Status != model.PromotionActionStatusActive {
+		return false
+	}
+	if action.DateStart != nil && action.DateStart.After(now) {
+		return false
+	}
+	return action.DateEnd == nil || action.DateEnd.After(now)
+}
+
+// runningSuccessors 按配置顺序筛出可报名的后续活动：未过期、未结束且不是到期活动本身，允许尚未开始的活动
+func runningSuccessors(action *model.PromotionAction, configured []model.PromotionAction, now time.Time) []model.PromotionAction {
+	byID := make(map[uint]model.PromotionAction, len(configured))
+	for _, successor := range configured {
+		byID[successor.ID] = successor
+	}
+	result := make([]model.PromotionAction, 0, len(configured))
+	for _, id := range decodeActionIDs(action.SuccessorActionIDs) {
+		successor, ok := byID[id]
+		if !ok || successor.ID == action.ID || successor.Status != model.PromotionActionStatusActive {
+			continue
+		}
+		if successor.DateEnd != nil && !successor.DateEnd.After(now) {
+			continue
+		}
+		result = append(result, successor)
+	}
+	return result
+}
+
+// planSuccessorEnrollment 按候选缓存分配各后续活动要报名的 SKU，商品只报名其为候选的活动
+func planSuccessorEnrollment(successors []model.PromotionAction, candidates []model.PromotionActionCandidate) map[uint][]string {
+	allowed := make(map[uint]struct{}, len(successors))
+	for _, successor := range successors {
+		allowed[successor.ID] = struct{}{}
+	}
+	plan := make(map[uint][]string)
+	for _, row := range candidates {
+		if row.Status != model.PromotionActionCandidateStatusCandidate {
+			continue
+		}
+		if _, ok := allowed[row.PromotionActionID]; !ok {
+			continue
+		}
+		plan[row.PromotionActionID] = append(plan[row.PromotionActionID], row.SourceSKU)
+	}
+	for actionID, skus := range plan {
+		skus = uniqueStrings(skus)
+		sort.Strings(skus)
+		plan[actionID] = skus
+	}
+	return plan
+}
+
+// coveredPromotionSKUs 汇总仍在进行中的活动里参与的商品。actions 为店铺全部活动，
+// running 为其中进行中的活动；推广记录所属官方活动不在 actions 中时视为仍在进行
+func coveredPromotionSKUs(actions, running []model.PromotionAction, promoted []model.PromotedProduct, actionProducts []model.PromotionActionProduct, candidates []model.PromotionActionCandidate) map[string]struct{} {
+	known := make(map[int64]struct{}, len(actions))
+	for _, action := range actions {
+		if action.Source != "shop" {
+			known[action.ActionID] = struct{}{}
+		}
+	}
+	runningOfficial := make(map[int64]struct{}, len(running))
+	runningIDs := make(map[uint]struct{}, len(running))
+	for _, action := range running {
+		runningIDs[action.ID] = struct{}{}
+		if action.Source != "shop" {
+			runningOfficial[action.ActionID] = struct{}{}
+		}
+	}
+
+	covered := make(map[string]struct{})
+	for _, row := range promoted {
+		_, isKnown := known[row.ActionID]
+		_, isRunning := runningOfficial[row.ActionID]
+		if !isKnown || isRunning {
+			covered[row.Product.SourceSKU] = struct{}{}
+		}
+	}
+	for _, row := range actionProducts {
+		if _, ok := runningIDs[row.PromotionActionID]; ok && row.Status == "active" {
+			covered[row.SourceSKU] = struct{}{}
+		}
+	}
+	for _, row := range candidates {
+		if _, ok := runningIDs[row.PromotionActionID]; !ok {
+			continue
+		}
+		if row.Status == model.PromotionActionCandidateStatusActive || row.Status == model.PromotionActionCandidateStatusAlreadyActive {
+			covered[row.SourceSKU] = struct{}{}
+		}
+	}
+	return covered
+}
+
+// uncoveredExpiredSKUs 到期活动的商品中不在 covered 里的部分，保持 skus 的顺序
+func uncoveredExpiredSKUs(skus []string, covered map[string]struct{}) []string {
+	result := make([]string, 0)
+	for _, sku := range skus {
+		if _, ok := covered[sku]; !ok {
+			result = append(result, sku)
+		}
+	}
+	return result
+}
+
+func decodeExpirationSKUs(raw datatypes.JSON) []string {
+	if len(raw) == 0 {
+		return []string{}
+	}
+	skus := make([]string, 0)
+	_ = json.Unmarshal(raw, &skus)
+	return skus
+}
+
+// UpdateActionSuccessors 设置活动到期后自动报名的后续活动，传空列表表示不自动报名
+func (s *PromotionService) UpdateActionSuccessors(shopID, id uint, successorIDs []uint) error {
+	action, err := s.promotionRepo.FindPromotionActionByIDAndShop(id, shopID)
+	if err != nil {
+		return err
+	}
+
+	successorIDs = uniqueUints(successorIDs)
+	for _, successorID := range successorIDs {
+		if successorID == action.ID {
+			return fmt.Errorf("后续活动不能是活动本身")
+		}
+	}
+	if len(successorIDs) > 0 {
+		successors, err := s.promotionRepo.FindPromotionActionsByIDs(shopID, successorIDs)
+		if err != nil {
+			return err
+		}
+		if len(successors) != len(successorIDs) {
+			return fmt.Errorf("后续活动不存在或不属于该店铺")
+		}
+	}
+
+	encoded, _ := json.Marshal(successorIDs)
+	return s.promotionRepo.UpdatePromotionActionSuccessors(action.ID, encoded)
+}
+
+// ListPromotionExpirations 活动到期处理记录，附带未读的脱离提醒数
+func (s *PromotionService) ListPromotionExpirations(req *dto.PromotionExpirationListRequest) (*dto.PromotionExpirationListResponse, error) {
+	expirations, total, err := s.promotionRepo.ListPromotionExpirations(req.ShopID, req.UnreadOnly, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := s.promotionRepo.CountUnreadPromotionExpirations(req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.PromotionExpirationResponse, 0, len(expirations))
+	for index := range expirations {
+		items = append(items, *toPromotionExpirationDTO(&expirations[index], false))
+	}
+	return &dto.PromotionExpirationListResponse{
+		Total:       total,
+		UnreadCount: unread,
+		Page:        req.Page,
+		PageSize:    req.PageSize,
+		Items:       items,
+	}, nil
+}
+
+// GetPromotionExpiration 查询到期处理记录及脱离全部活动的商品
+func (s *PromotionService) GetPromotionExpiration(shopID, id uint) (*dto.PromotionExpirationResponse, error) {
+	expiration, err := s.promotionRepo.FindPromotionExpirationByIDAndShop(id, shopID)
+	if err != nil {
+		return nil, err
+	}
+	return toPromotionExpirationDTO(expiration, true), nil
+}
+
+// MarkPromotionExpirationsRead 标记到期提醒已读，返回本次标记的数量
+func (s *PromotionService) MarkPromotionExpirationsRead(req *dto.PromotionExpirationReadRequest) (int64, error) {
+	return s.promotionRepo.MarkPromotionExpirationsRead(req.ShopID, uniqueUints(req.IDs))
+}
+
+func toPromotionExpirationDTO(expiration *model.PromotionActionExpiration, withSKUs bool) *dto.PromotionExpirationResponse {
+	resp := &dto.PromotionExpirationResponse{
+		ID:                expiration.ID,
+		ShopID:            expiration.ShopID,
+		PromotionActionID: expiration.PromotionActionID,
+		ActionID:          expiration.ActionID,
+		Source:            expiration.Source,
+		Title:             expiration.Title,
+		DateEnd:           expiration.DateEnd.Format("2006-01-02 15:04:05"),
+		Status:            expiration.Status,
+		SKUCount:          expiration.SKUCount,
+		ExitedCount:       expiration.ExitedCount,
+		UnpromotedCount:   expiration.UnpromotedCount,
+		SuccessorEnrolled: expiration.SuccessorEnrolled,
+		SuccessorFailed:   expiration.SuccessorFailed,
+		JobIDs:            decodeActionIDs(expiration.JobIDs),
+		UncoveredCount:    expiration.UncoveredCount,
+		ErrorMessage:      expiration.ErrorMessage,
+		Read:              expiration.ReadAt != nil,
+		CompletedAt:       formatOptionalTime(expiration.CompletedAt),
+		CreatedAt:         expiration.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if withSKUs {
+		resp.UncoveredSKUs = decodeExpirationSKUs(expiration.UncoveredSKUs)
+	}
+	return resp
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"ozon-manager/internal/model"
+)
+
+func TestExpiredActionSKUs(t *testing.T) {
+	t.Parallel()
+
+	promoted := []model.PromotedProduct{{Product: model.Product{SourceSKU: "B"}}}
+	actionProducts := []model.PromotionActionProduct{
+		{SourceSKU: "A", Status: "active"},
+		{SourceSKU: "C", Status: "inactive"},
+	}
+	candidates := []model.PromotionActionCandidate{
+		{SourceSKU: "D", Status: model.PromotionActionCandidateStatusAlreadyActive},
+		{SourceSKU: "E", Status: model.PromotionActionCandidateStatusCandidate},
+		{SourceSKU: "A", Status: model.PromotionActionCandidateStatusActive},
+	}
+
+	got := expiredActionSKUs(promoted, actionProducts, candidates)
+	if want := []string{"A", "B", "D"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("skus = %v, want %v", got, want)
+	}
+}
+
+func TestPromotionActionRunning(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	cases := []struct {
+		name   string
+		action model.PromotionAction
+		want   bool
+	}{
+		{"open", model.PromotionAction{Status: "active"}, true},
+		{"running", model.PromotionAction{Status: "active", DateStart: &past, DateEnd: &future}, true},
+		{"ended", model.PromotionAction{Status: "active", DateEnd: &past}, false},
+		{"upcoming", model.PromotionAction{Status: "active", DateStart: &future}, false},
+		{"expired", model.PromotionAction{Status: "expired", DateEnd: &future}, false},
+	}
+	for _, tc := range cases {
+		if got := promotionActionRunning(tc.action, now); got != tc.want {
+			t.Fatalf("%s: running = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRunningSuccessorsKeepsConfiguredOrder(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	past, future := now.Add(-time.Hour), now.Add(24*time.Hour)
+	action := &model.PromotionAction{ID: 1, SuccessorActionIDs: []byte(`[4,1,3,2,9]`)}
+	configured := []model.PromotionAction{
+		{ID: 1, Status: "active"},
+		{ID: 2, Status: "active", DateStart: &future},
+		{ID: 3, Status: "active", DateEnd: &past},
+		{ID: 4, Status: "active", DateEnd: &future},
+	}
+
+	got := runningSuccessors(action, configured, now)
+	ids := make([]uint, 0, len(got))
+	for _, successor := range got {
+		ids = append(ids, successor.ID)
+	}
+	if want := []uint{4, 2}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("successors = %v, want %v", ids, want)
+	}
+}
+
+func TestPlanSuccessorEnrollmentOnlyCandidates(t *testing.T) {
+	t.Parallel()
+
+	successors := []model.PromotionAction{{ID: 2}, {ID: 3}}
+	candidates := []model.PromotionActionCandidate{
+		{PromotionActionID: 2, SourceSKU: "B", Status: model.PromotionActionCandidateStatusCandidate},
+		{PromotionActionID: 2, SourceSKU: "A", Status: model.PromotionActionCandidateStatusCandidate},
+		{PromotionActionID: 3, SourceSKU: "A", Status: model.PromotionActionCandidateStatusAlreadyActive},
+		{PromotionActionID: 5, SourceSKU: "C", Status: model.PromotionActionCandidateStatusCandidate},
+	}
+
+	plan := planSuccessorEnrollment(successors, candidates)
+	if len(plan) != 1 || !reflect.DeepEqual(plan[2], []string{"A", "B"}) {
+		t.Fatalf("plan = %v", plan)
+	}
+}
+
+func TestCoveredPromotionSKUs(t *testing.T) {
+	t.Parallel()
+
+	actions := []model.PromotionAction{
+		{ID: 1, ActionID: 100, Source: "official"},
+		{ID: 2, ActionID: 200, Source: "official"},
+		{ID: 3, ActionID: 300, Source: "shop"},
+	}
+	running := []model.PromotionAction{actions[1], actions[2]}
+	promoted := []model.PromotedProduct{
+		{ActionID: 100, Product: model.Product{SourceSKU: "ended"}},
+		{ActionID: 200, Product: model.Product{SourceSKU: "official"}},
+		{ActionID: 999, Product: model.Product{SourceSKU: "unknown"}},
+	}
+	actionProducts := []model.PromotionActionProduct{
+		{PromotionActionID: 3, SourceSKU: "shop", Status: "active"},
+		{PromotionActionID: 1, SourceSKU: "ended-cache", Status: "active"},
+	}
+	candidates := []model.PromotionActionCandidate{
+		{PromotionActionID: 3, SourceSKU: "declared", Status: model.PromotionActionCandidateStatusAlreadyActive},
+		{PromotionActionID: 3, SourceSKU: "candidate", Status: model.PromotionActionCandidateStatusCandidate},
+	}
+
+	covered := coveredPromotionSKUs(actions, running, promoted, actionProducts, candidates)
+	skus := []string{"ended", "official", "unknown", "shop", "ended-cache", "declared", "candidate"}
+	got := uncoveredExpiredSKUs(skus, covered)
+	if want := []string{"ended", "ended-cache", "candidate"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("uncovered = %v, want %v", got, want)
+	}
+}
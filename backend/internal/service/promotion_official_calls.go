@@ -0,0 +1,128 @@
+package service
+
+import (
+	"ozon-manager/internal/model"
+)
+
+// 官方活动的逐个商品调用先生成步骤列表，实际执行与试运行消费同一份列表：
+// 执行按步骤调用 Ozon 并写库，试运行把步骤转为计划，修改执行流程只需修改这里的构建函数
+
+const (
+	officialCallActivate    = "activate"
+	officialCallDeactivate  = "deactivate"
+	officialCallUpdatePrice = "update_price"
+)
+
+// officialCall 单个商品的一次官方活动调用
+type officialCall struct {
+	Operation   string
+	ActionID    int64
+	ActionTitle string
+	// PromotionType / ActionPrice 退出时用于更新本地推广记录与参与流水
+	PromotionType string
+	ActionPrice   float64
+	// Price 改价的目标价
+	Price float64
+}
+
+// officialProductSteps 单个商品依次执行的调用：退出 → 改价 → 报名
+type officialProductSteps struct {
+	Product model.Product
+	Exit    []officialCall
+	Reprice *officialCall
+	Enroll  []officialCall
+}
+
+// calls 按执行顺序展开全部调用
+func (steps officialProductSteps) calls() []officialCall {
+	calls := make([]officialCall, 0, len(steps.Exit)+len(steps.Enroll)+1)
+	calls = append(calls, steps.Exit...)
+	if steps.Reprice != nil {
+		calls = append(calls, *steps.Reprice)
+	}
+	return append(calls, steps.Enroll...)
+}
+
+// enrollCalls 报名到指定官方活动
+func enrollCalls(actions []model.PromotionAction) []officialCall {
+	calls := make([]officialCall, 0, len(actions))
+	for _, action := range actions {
+		calls = append(calls, officialCall{
+			Operation:   officialCallActivate,
+			ActionID:    action.ActionID,
+			ActionTitle: displayActionName(action),
+		})
+	}
+	return calls
+}
+
+// removeCalls 退出指定官方活动
+func removeCalls(actions []model.PromotionAction) []officialCall {
+	calls := make([]officialCall, 0, len(actions))
+	for _, action := range actions {
+		calls = append(calls, officialCall{
+			Operation:   officialCallDeactivate,
+			ActionID:    action.ActionID,
+			ActionTitle: displayActionName(action),
+		})
+	}
+	return calls
+}
+
+// exitCallsFromRecords 按本地推广记录逐个退出；活动名称取自活动列表，找不到时显示活动 ID
+func exitCallsFromRecords(records []model.PromotedProduct, actions []model.PromotionAction) []officialCall {
+	titles := make(map[int64]string, len(actions))
+	for _, action := range actions {
+		titles[action.ActionID] = displayActionName(action)
+	}
+	calls := make([]officialCall, 0, len(records))
+	for _, record := range records {
+		title, ok := titles[record.ActionID]
+		if !ok {
+			title = displayActionName(model.PromotionAction{ActionID: record.ActionID})
+		}
+		calls = append(calls, officialCall{
+			Operation:     officialCallDeactivate,
+			ActionID:      record.ActionID,
+			ActionTitle:   title,
+			PromotionType: record.PromotionType,
+			ActionPrice:   record.ActionPrice,
+		})
+	}
+	return calls
+}
+
+// exitAllCalls 商品当前参与的全部官方活动的退出调用
+func (s *PromotionService) exitAllCalls(product model.Product) ([]officialCall, error) {
+	records, err := s.promotionRepo.FindPromotedProductsByProductID(product.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	actionIDs := make([]int64, 0, len(records))
+	for _, record := range records {
+		actionIDs = append(actionIDs, record.ActionID)
+	}
+	actions, _ := s.promotionRepo.FindPromotionActionsByActionIDs(product.ShopID, actionIDs)
+	return exitCallsFromRecords(records, actions), nil
+}
+
+// repriceProductSteps 亏损处理与改价推广：退出全部活动 → 改价 → 重新报名（报名价为改价前的当前价）
+func repriceProductSteps(product model.Product, exitCalls []officialCall, newPrice float64, rejoinActions []model.PromotionAction) officialProductSteps {
+	return officialProductSteps{
+		Product: product,
+		Exit:    exitCalls,
+		Reprice: &officialCall{Operation: officialCallUpdatePrice, Price: newPrice},
+		Enroll:  enrollCalls(rejoinActions),
+	}
+}
+
+// rejoinActionList 亏损处理只重新报名一个活动，未指定时为空
+func rejoinActionList(rejoinAction *model.PromotionAction) []model.PromotionAction {
+	if rejoinAction == nil {
+		return nil
+	}
+	return []model.PromotionAction{*rejoinAction}
+}
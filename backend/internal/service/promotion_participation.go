@@ -0,0 +1,333 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+	"ozon-manager/pkg/automation/protocol"
+)
+
+// participationSource 一次写入推广状态的操作来源，随每条参与流水一并记录
+type participationSource struct {
+	Origin      string
+	TriggeredBy *uint
+	Reference   string
+}
+
+func userParticipationSource(origin string, userID uint) participationSource {
+	source := participationSource{Origin: origin}
+	if userID > 0 {
+		triggeredBy := userID
+		source.TriggeredBy = &triggeredBy
+	}
+	return source
+}
+
+func (source participationSource) event(eventType string, product model.Product) model.PromotionParticipationEvent {
+	return model.PromotionParticipationEvent{
+		ShopID:        product.ShopID,
+		ProductID:     product.ID,
+		SourceSKU:     product.SourceSKU,
+		OzonProductID: product.OzonProductID,
+		EventType:     eventType,
+		Price:         product.CurrentPrice,
+		Origin:        source.Origin,
+		TriggeredBy:   source.TriggeredBy,
+		Reference:     source.Reference,
+	}
+}
+
+func (source participationSource) actionEvent(eventType string, product model.Product, actionSource string, actionID int64, actionTitle string, actionPrice float64) model.PromotionParticipationEvent {
+	event := source.event(eventType, product)
+	event.ActionSource = actionSource
+	event.ActionID = actionID
+	event.ActionTitle = actionTitle
+	event.ActionPrice = actionPrice
+	return event
+}
+
+func (source participationSource) repricedEvent(product model.Product, newPrice float64) model.PromotionParticipationEvent {
+	event := source.event(model.PromotionParticipationRepriced, product)
+	event.PreviousPrice = product.CurrentPrice
+	event.Price = newPrice
+	return event
+}
+
+// recordParticipation 追加参与流水，缺少活动名称的官方活动按店铺活动缓存补齐。
+// 流水为尽力记录，写入失败不影响促销操作本身。
+func (s *PromotionService) recordParticipation(shopID uint, events ...model.PromotionParticipationEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	missingTitles := make([]int64, 0)
+	for index := range events {
+		events[index].ShopID = shopID
+		if events[index].ActionID != 0 && events[index].ActionTitle == "" {
+			missingTitles = append(missingTitles, events[index].ActionID)
+		}
+	}
+	if len(missingTitles) > 0 {
+		if actions, err := s.promotionRepo.FindPromotionActionsByActionIDs(shopID, missingTitles); err == nil {
+			titles := make(map[int64]string, len(actions))
+			for _, action := range actions {
+				titles[action.ActionID] = action.Title
+			}
+			for index := range events {
+				if events[index].ActionTitle == "" {
+					events[index].ActionTitle = titles[events[index].ActionID]
+				}
+			}
+		}
+	}
+
+	_ = s.promotionRepo.CreateParticipationEvents(events)
+}
+
+// reconcileParticipationEvents 将对账补记与退出的推广记录转为参与流水，需在截断差异明细之前调用
+func reconcileParticipationEvents(report *model.PromotionReconcileReport, plan promotionReconcilePlan) []model.PromotionParticipationEvent {
+	type activateKey struct {
+		productID uint
+		actionID  int64
+	}
+	prices := make(map[activateKey]float64, len(plan.Activate))
+	for _, row := range plan.Activate {
+		prices[activateKey{productID: row.ProductID, actionID: row.ActionID}] = row.ActionPrice
+	}
+
+	source := participationSource{
+		Origin:    model.PromotionParticipationOriginReconcile,
+		Reference: fmt.Sprintf("reconcile_report:%d", report.ID),
+	}
+	events := make([]model.PromotionParticipationEvent, 0, len(plan.Activate)+len(plan.ExitIDs))
+	for _, drift := range plan.Drift {
+		eventType := ""
+		switch drift.Type {
+		case model.PromotionDriftMissingLocal:
+			eventType = model.PromotionParticipationJoined
+		case model.PromotionDriftMissingRemote, model.PromotionDriftActionEnded:
+			eventType = model.PromotionParticipationLeft
+		default:
+			continue
+		}
+
+		product := model.Product{
+			ID:            drift.ProductID,
+			ShopID:        report.ShopID,
+			SourceSKU:     drift.SourceSKU,
+			OzonProductID: drift.OzonProductID,
+		}
+		price := prices[activateKey{productID: drift.ProductID, actionID: drift.ActionID}]
+		event := source.actionEvent(eventType, product, "official", drift.ActionID, drift.ActionTitle, price)
+		event.Note = drift.Detail
+		events = append(events, event)
+	}
+	return events
+}
+
+// automationJobParticipationEvents 按店铺活动任务的明细结果生成参与流水：
+// 统一报名 / 退出看整体状态，移除-改价-重新添加按各步骤状态分别记录
+func automationJobParticipationEvents(job *model.AutomationJob, shopActions []protocol.ShopActionRef, products map[string]model.Product) []model.PromotionParticipationEvent {
+	source := participationSource{
+		Origin:    model.PromotionParticipationOriginAutomationJob,
+		Reference: fmt.Sprintf("automation_job:%d", job.ID),
+	}
+	if job.CreatedBy > 0 {
+		createdBy := job.CreatedBy
+		source.TriggeredBy = &createdBy
+	}
+
+	events := make([]model.PromotionParticipationEvent, 0)
+	appendActionEvents := func(eventType string, product model.Product) {
+		for _, action := range shopActions {
+			events = append(events, source.actionEvent(eventType, product, "shop", hashToActionID(action.SourceActionID), action.Title, 0))
+		}
+	}
+
+	for _, item := range job.Items {
+		product, ok := products[item.SourceSKU]
+		if !ok {
+			continue
+		}
+		switch job.JobType {
+		case model.AutomationJobTypePromoUnifiedEnroll:
+			if item.OverallStatus == model.AutomationStepStatusSuccess {
+				appendActionEvents(model.PromotionParticipationJoined, product)
+			}
+		case model.AutomationJobTypePromoUnifiedRemove:
+			if item.OverallStatus == model.AutomationStepStatusSuccess {
+				appendActionEvents(model.PromotionParticipationLeft, product)
+			}
+		case model.AutomationJobTypeRemoveRepriceReadd:
+			if item.StepExitStatus == model.AutomationStepStatusSuccess {
+				appendActionEvents(model.PromotionParticipationLeft, product)
+			}
+			if item.StepRepriceStatus == model.AutomationStepStatusSuccess {
+				// 插件改价时本地价格已更新，改价前价格无法还原
+				event := source.event(model.PromotionParticipationRepriced, product)
+				event.Price = item.TargetPrice
+				events = append(events, event)
+			}
+			if item.StepReaddStatus == model.AutomationStepStatusSuccess {
+				appendActionEvents(model.PromotionParticipationJoined, product)
+			}
+		}
+	}
+	return events
+}
+
+// continueRecordParticipation 店铺活动任务结束后按明细结果写参与流水，
+// 并为未经后端改价接口的改价（如 Agent 在页面内改价）补记价格变动。
+// 流水为尽力记录，读取产物或写入失败都不影响任务结果。
+func (s *PromotionService) continueRecordParticipation(job *model.AutomationJob) error {
+	var shopActions []protocol.ShopActionRef
+	switch job.JobType {
+	case model.AutomationJobTypePromoUnifiedEnroll, model.AutomationJobTypePromoUnifiedRemove:
+		artifact, err := s.automationService.GetLatestArtifact(job.ID, "promo_unified_meta")
+		if err != nil {
+			return nil
+		}
+		var meta protocol.PromoUnifiedMeta
+		if json.Unmarshal(artifact.Meta, &meta) != nil {
+			return nil
+		}
+		shopActions = meta.ShopActions
+	case model.AutomationJobTypeRemoveRepriceReadd:
+		artifact, err := s.automationService.GetLatestArtifact(job.ID, "remove_reprice_readd_meta")
+		if err != nil {
+			return nil
+		}
+		var meta protocol.RemoveRepriceReaddMeta
+		if json.Unmarshal(artifact.Meta, &meta) != nil {
+			return nil
+		}
+		shopActions = meta.ShopActions
+	default:
+		return nil
+	}
+
+	skus := make([]string, 0, len(job.Items))
+	for _, item := range job.Items {
+		skus = append(skus, item.SourceSKU)
+	}
+	products, err := s.productRepo.FindBySourceSKUs(job.ShopID, uniqueSKUs(skus))
+	if err != nil {
+		return nil
+	}
+	s.recordParticipation(job.ShopID, automationJobParticipationEvents(job, shopActions, products)...)
+	if job.JobType == model.AutomationJobTypeRemoveRepriceReadd {
+		s.priceHistory.recordJobReprices(job, products)
+	}
+	return nil
+}
+
+// GetProductParticipation 商品促销参与时间线
+func (s *PromotionService) GetProductParticipation(shopID, productID uint, req *dto.ProductParticipationRequest) (*dto.ProductParticipationResponse, error) {
+	product, err := s.productRepo.FindByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.ShopID != shopID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	events, total, err := s.promotionRepo.ListProductParticipationEvents(shopID, productID, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ProductParticipationResponse{
+		ProductID: product.ID,
+		SourceSKU: product.SourceSKU,
+		Total:     total,
+		Page:      req.Page,
+		PageSize:  req.PageSize,
+		Items:     toParticipationEventDTOs(events),
+	}, nil
+}
+
+// GetActionParticipation 活动加入 / 退出报表，actionID 为本地活动 ID
+func (s *PromotionService) GetActionParticipation(actionID uint, req *dto.ActionParticipationRequest) (*dto.ActionParticipationResponse, error) {
+	action, err := s.promotionRepo.FindPromotionActionByIDAndShop(actionID, req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := parseDateOnly(req.DateFrom)
+	if err != nil {
+		return nil, fmt.Errorf("开始日期格式错误，应为 YYYY-MM-DD")
+	}
+	to, err := parseDateOnly(req.DateTo)
+	if err != nil {
+		return nil, fmt.Errorf("结束日期格式错误，应为 YYYY-MM-DD")
+	}
+	if to != nil {
+		// 结束日期含当天
+		end := to.AddDate(0, 0, 1)
+		to = &end
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, fmt.Errorf("开始日期不能晚于结束日期")
+	}
+	eventType := req.EventType
+	if eventType != "" && eventType != model.PromotionParticipationJoined && eventType != model.PromotionParticipationLeft {
+		return nil, fmt.Errorf("event_type 仅支持 joined / left")
+	}
+
+	eventCounts, productCounts, err := s.promotionRepo.CountActionParticipationEvents(req.ShopID, action.ActionID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	events, total, err := s.promotionRepo.ListActionParticipationEvents(req.ShopID, action.ActionID, eventType, from, to, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	title := action.DisplayName
+	if title == "" {
+		title = action.Title
+	}
+	return &dto.ActionParticipationResponse{
+		ActionID:       action.ID,
+		OzonActionID:   action.ActionID,
+		ActionTitle:    title,
+		Source:         action.Source,
+		DateFrom:       req.DateFrom,
+		DateTo:         req.DateTo,
+		JoinedCount:    eventCounts[model.PromotionParticipationJoined],
+		LeftCount:      eventCounts[model.PromotionParticipationLeft],
+		JoinedProducts: productCounts[model.PromotionParticipationJoined],
+		LeftProducts:   productCounts[model.PromotionParticipationLeft],
+		Total:          total,
+		Page:           req.Page,
+		PageSize:       req.PageSize,
+		Items:          toParticipationEventDTOs(events),
+	}, nil
+}
+
+func toParticipationEventDTOs(events []model.PromotionParticipationEvent) []dto.PromotionParticipationEventResponse {
+	items := make([]dto.PromotionParticipationEventResponse, 0, len(events))
+	for _, event := range events {
+		items = append(items, dto.PromotionParticipationEventResponse{
+			ID:            event.ID,
+			ProductID:     event.ProductID,
+			SourceSKU:     event.SourceSKU,
+			OzonProductID: event.OzonProductID,
+			EventType:     event.EventType,
+			ActionSource:  event.ActionSource,
+			ActionID:      event.ActionID,
+			ActionTitle:   event.ActionTitle,
+			ActionPrice:   event.ActionPrice,
+			Price:         event.Price,
+			PreviousPrice: event.PreviousPrice,
+			Origin:        event.Origin,
+			TriggeredBy:   event.TriggeredBy,
+			Reference:     event.Reference,
+			Note:          event.Note,
+			CreatedAt:     event.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return items
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+	"ozon-manager/pkg/automation/protocol"
+)
+
+func TestReconcileParticipationEventsFollowDrift(t *testing.T) {
+	t.Parallel()
+
+	report := &model.PromotionReconcileReport{ID: 7, ShopID: 3}
+	plan := promotionReconcilePlan{
+		Activate: []model.PromotedProduct{{ProductID: 3, ActionID: 9001, ActionPrice: 380}},
+		ExitIDs:  []uint{12},
+		Drift: []dto.PromotionDriftItem{
+			{Type: model.PromotionDriftMissingRemote, ProductID: 2, SourceSKU: "SKU-202", ActionID: 9001, Detail: "removed"},
+			{Type: model.PromotionDriftMissingLocal, ProductID: 3, SourceSKU: "SKU-303", ActionID: 9001, ActionTitle: "弹性促销"},
+			{Type: model.PromotionDriftUnknownProduct, OzonProductID: 999, ActionID: 9001},
+			{Type: model.PromotionDriftFlagMismatch, ProductID: 4},
+		},
+	}
+
+	events := reconcileParticipationEvents(report, plan)
+	if len(events) != 2 {
+		t.Fatalf("events = %+v, want 2", events)
+	}
+	left, joined := events[0], events[1]
+	if left.EventType != model.PromotionParticipationLeft || left.ProductID != 2 || left.Note != "removed" {
+		t.Fatalf("left event = %+v", left)
+	}
+	if joined.EventType != model.PromotionParticipationJoined || joined.ProductID != 3 || joined.ActionPrice != 380 || joined.ActionTitle != "弹性促销" {
+		t.Fatalf("joined event = %+v", joined)
+	}
+	for _, event := range events {
+		if event.ShopID != 3 || event.Origin != model.PromotionParticipationOriginReconcile || event.Reference != "reconcile_report:7" || event.TriggeredBy != nil {
+			t.Fatalf("event source = %+v", event)
+		}
+	}
+}
+
+func TestAutomationJobParticipationEventsByStep(t *testing.T) {
+	t.Parallel()
+
+	products := map[string]model.Product{
+		"SKU-1": {ID: 1, ShopID: 3, SourceSKU: "SKU-1", CurrentPrice: 120},
+		"SKU-2": {ID: 2, ShopID: 3, SourceSKU: "SKU-2", CurrentPrice: 80},
+	}
+	shopActions := []protocol.ShopActionRef{{ActionDBID: 22, SourceActionID: "shop-28", Title: "店铺 28"}}
+	job := &model.AutomationJob{
+		ID:        5,
+		CreatedBy: 9,
+		JobType:   model.AutomationJobTypeRemoveRepriceReadd,
+		Items: []model.AutomationJobItem{
+			{
+				SourceSKU:         "SKU-1",
+				TargetPrice:       99,
+				StepExitStatus:    model.AutomationStepStatusSuccess,
+				StepRepriceStatus: model.AutomationStepStatusSuccess,
+				StepReaddStatus:   model.AutomationStepStatusFailed,
+			},
+			{
+				SourceSKU:         "SKU-2",
+				StepExitStatus:    model.AutomationStepStatusFailed,
+				StepRepriceStatus: model.AutomationStepStatusSkipped,
+				StepReaddStatus:   model.AutomationStepStatusSkipped,
+			},
+			{SourceSKU: "SKU-UNKNOWN", StepExitStatus: model.AutomationStepStatusSuccess},
+		},
+	}
+
+	events := automationJobParticipationEvents(job, shopActions, products)
+	if len(events) != 2 {
+		t.Fatalf("events = %+v, want left + repriced for SKU-1", events)
+	}
+	if events[0].EventType != model.PromotionParticipationLeft || events[0].ActionSource != "shop" || events[0].ActionID != hashToActionID("shop-28") {
+		t.Fatalf("left event = %+v", events[0])
+	}
+	if events[1].EventType != model.PromotionParticipationRepriced || events[1].Price != 99 || events[1].ActionID != 0 {
+		t.Fatalf("repriced event = %+v", events[1])
+	}
+	if events[0].TriggeredBy == nil || *events[0].TriggeredBy != 9 || events[0].Reference != "automation_job:5" {
+		t.Fatalf("event source = %+v", events[0])
+	}
+
+	job.JobType = model.AutomationJobTypePromoUnifiedEnroll
+	job.Items = []model.AutomationJobItem{
+		{SourceSKU: "SKU-1", OverallStatus: model.AutomationStepStatusSuccess},
+		{SourceSKU: "SKU-2", OverallStatus: model.AutomationStepStatusFailed},
+	}
+	events = automationJobParticipationEvents(job, shopActions, products)
+	if len(events) != 1 || events[0].EventType != model.PromotionParticipationJoined || events[0].ProductID != 1 {
+		t.Fatalf("unified enroll events = %+v", events)
+	}
+}
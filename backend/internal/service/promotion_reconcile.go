@@ -0,0 +1,487 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+	"ozon-manager/pkg/ozon"
+)
+
+const (
+	promotionReconcileSchedulerInterval = time.Hour
+	// promotionReconcileInterval 店铺距上次对账超过该时间后由定时扫描自动对账
+	promotionReconcileInterval   = 6 * time.Hour
+	promotionReconcileStaleAfter = 2 * time.Hour
+	promotionReconcilePageSize   = 200
+	// promotionReconcileDriftLimit 报告中保存的差异明细上限，计数不受影响
+	promotionReconcileDriftLimit = 500
+)
+
+type promotionReconcilePayload struct {
+	ReportID uint `json:"report_id"`
+	ShopID   uint `json:"shop_id"`
+}
+
+// promotionReconcileInput 对账所需的 Ozon 与本地状态
+type promotionReconcileInput struct {
+	// RemoteProducts 成功拉取的 Ozon 活动 → 活动内商品 Ozon ID → 活动价
+	RemoteProducts map[int64]map[int64]float64
+	// FailedActions 拉取商品失败的活动，本次不处理其本地记录
+	FailedActions map[int64]struct{}
+	// OfficialActionIDs 本地缓存的官方活动，不在 Ozon 活动列表中的视为已结束
+	OfficialActionIDs map[int64]struct{}
+	ActionTitles      map[int64]string
+	// LocalRows 店铺有效的推广记录（需预加载 Product）
+	LocalRows []model.PromotedProduct
+	// Products Ozon 活动内商品对应的本地商品，按 Ozon 商品 ID 索引
+	Products map[int64]model.Product
+}
+
+type promotionReconcilePlan struct {
+	Activate     []model.PromotedProduct
+	ExitIDs      []uint
+	Drift        []dto.PromotionDriftItem
+	AddedCount   int
+	ExitedCount  int
+	UnknownCount int
+}
+
+// ConfigureTaskQueue 注册促销服务的后台任务：对账、批量操作撤销与活动到期处理，
+// 服务停止时中断的任务退还队列后继续
+func (s *PromotionService) ConfigureTaskQueue(queue *TaskQueue) {
+	s.taskQueue = queue
+	queue.Register(model.BackgroundTaskTypePromotionReconcile, TaskOptions{
+		MaxAttempts: 2,
+		Timeout:     5 * time.Minute,
+	}, TypedTaskHandler(s.handleReconcileTask))
+	s.registerBulkOperationTasks(queue)
+	s.registerExpirationTasks(queue)
+}
+
+// StartReconciler 定时为启用的店铺对账，每个店铺间隔 promotionReconcileInterval
+func (s *PromotionService) StartReconciler() {
+	_ = s.promotionRepo.MarkStaleReconcileReportsFailed(time.Now().Add(-promotionReconcileStaleAfter))
+
+	s.loops.Go(promotionReconcileSchedulerInterval, s.scanReconcileDue)
+}
+
+// StopReconciler 停止定时对账与活动到期巡检，执行中的对账与到期处理由任务队列排空
+func (s *PromotionService) StopReconciler() {
+	s.loops.Stop()
+}
+
+func (s *PromotionService) scanReconcileDue(now time.Time) {
+	shops, err := s.shopRepo.FindActive()
+	if err != nil {
+		return
+	}
+
+	for _, shop := range shops {
+		latest, err := s.promotionRepo.FindLatestReconcileReport(shop.ID)
+		if err == nil && now.Sub(latest.CreatedAt) < promotionReconcileInterval {
+			continue
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		_, _ = s.startReconcile(shop.ID, model.PromotionReconcileTriggerScheduled)
+	}
+}
+
+// StartReconcile 手动触发店铺促销状态对账
+func (s *PromotionService) StartReconcile(shopID uint) (*dto.PromotionReconcileReportResponse, error) {
+	report, err := s.startReconcile(shopID, model.PromotionReconcileTriggerManual)
+	if err != nil {
+		return nil, err
+	}
+	return toPromotionReconcileReportDTO(report, false), nil
+}
+
+func (s *PromotionService) startReconcile(shopID uint, triggerMode string) (*model.PromotionReconcileReport, error) {
+	if active, err := s.promotionRepo.FindActiveReconcileReport(shopID); err == nil && active != nil {
+		return nil, fmt.Errorf("该店铺已有对账正在执行中")
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	report := &model.PromotionReconcileReport{
+		ShopID:      shopID,
+		TriggerMode: triggerMode,
+		Status:      model.PromotionReconcileStatusPending,
+		Drift:       []byte("[]"),
+	}
+	if err := s.promotionRepo.CreateReconcileReport(report); err != nil {
+		return nil, err
+	}
+
+	payload := promotionReconcilePayload{ReportID: report.ID, ShopID: shopID}
+	if s.taskQueue == nil {
+		go func() { _ = s.handleReconcileTask(context.Background(), payload) }()
+		return report, nil
+	}
+	if err := s.taskQueue.Enqueue(model.BackgroundTaskTypePromotionReconcile, payload); err != nil {
+		s.finishReconcile(report, model.PromotionReconcileStatusFailed, "提交对账失败: "+err.Error())
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *PromotionService) handleReconcileTask(ctx context.Context, payload promotionReconcilePayload) error {
+	report, err := s.promotionRepo.FindReconcileReportByIDAndShop(payload.ReportID, payload.ShopID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if report.Status != model.PromotionReconcileStatusPending && report.Status != model.PromotionReconcileStatusRunning {
+		return nil
+	}
+
+	startedAt := time.Now()
+	report.Status = model.PromotionReconcileStatusRunning
+	report.StartedAt = &startedAt
+	if err := s.promotionRepo.UpdateReconcileReport(report); err != nil {
+		return err
+	}
+
+	if err := s.reconcileShop(ctx, report); err != nil {
+		if ctx.Err() != nil {
+			// 服务停止：报告退回排队状态，任务退还队列后重新对账
+			report.Status = model.PromotionReconcileStatusPending
+			_ = s.promotionRepo.UpdateReconcileReport(report)
+			return err
+		}
+		s.finishReconcile(report, model.PromotionReconcileStatusFailed, err.Error())
+	}
+	return nil
+}
+
+// reconcileShop 拉取 Ozon 活动列表和各活动商品，与本地推广记录比对并修正，结果写入 report
+func (s *PromotionService) reconcileShop(ctx context.Context, report *model.PromotionReconcileReport) error {
+	shop, err := s.shopRepo.GetWithCredentials(report.ShopID)
+	if err != nil {
+		return fmt.Errorf("shop not found: %w", err)
+	}
+	client := s.shopGuard.OzonClient(shop)
+
+	actionsResp, err := client.GetActions()
+	if err != nil {
+		return fmt.Errorf("获取 Ozon 活动列表失败: %w", err)
+	}
+
+	input := promotionReconcileInput{
+		RemoteProducts:    make(map[int64]map[int64]float64),
+		FailedActions:     make(map[int64]struct{}),
+		OfficialActionIDs: make(map[int64]struct{}),
+		ActionTitles:      make(map[int64]string),
+	}
+	localActions, err := s.promotionRepo.FindPromotionActionsByShopID(report.ShopID)
+	if err != nil {
+		return err
+	}
+	for _, action := range localActions {
+		if action.Source == "official" {
+			input.OfficialActionIDs[action.ActionID] = struct{}{}
+			input.ActionTitles[action.ActionID] = action.Title
+		}
+	}
+
+	input.LocalRows, err = s.promotionRepo.FindActivePromotedProducts(report.ShopID)
+	if err != nil {
+		return err
+	}
+	localActionIDs := make(map[int64]struct{})
+	for _, row := range input.LocalRows {
+		localActionIDs[row.ActionID] = struct{}{}
+	}
+
+	failureMessages := make([]string, 0)
+	remoteOzonIDs := make([]int64, 0)
+	for _, action := range actionsResp.Result {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		input.ActionTitles[action.ID] = action.Title
+		report.ActionsChecked++
+
+		_, hasLocal := localActionIDs[action.ID]
+		if action.ParticipatingProducts == 0 && !hasLocal {
+			input.RemoteProducts[action.ID] = map[int64]float64{}
+			continue
+		}
+		products, err := fetchActionProductPrices(client, action.ID)
+		if err != nil {
+			input.FailedActions[action.ID] = struct{}{}
+			report.ActionsFailed++
+			failureMessages = append(failureMessages, fmt.Sprintf("活动 %d: %s", action.ID, err.Error()))
+			continue
+		}
+		input.RemoteProducts[action.ID] = products
+		for ozonProductID := range products {
+			remoteOzonIDs = append(remoteOzonIDs, ozonProductID)
+		}
+		report.RemoteProducts += len(products)
+	}
+
+	input.Products, err = s.productRepo.FindByOzonProductIDs(report.ShopID, remoteOzonIDs)
+	if err != nil {
+		return err
+	}
+
+	plan := planPromotionReconcile(input)
+	if err := s.promotionRepo.ApplyPromotionReconcile(plan.Activate, plan.ExitIDs); err != nil {
+		return fmt.Errorf("写入对账结果失败: %w", err)
+	}
+	s.recordParticipation(report.ShopID, reconcileParticipationEvents(report, plan)...)
+
+	mismatches, err := s.promotionRepo.FindPromotedFlagMismatches(report.ShopID)
+	if err != nil {
+		return err
+	}
+	for _, product := range mismatches {
+		if err := s.productRepo.UpdatePromotedStatus(product.ID, !product.IsPromoted); err != nil {
+			return err
+		}
+		detail := "已标记为已推广"
+		if product.IsPromoted {
+			detail = "已无有效推广记录，取消已推广标记"
+		}
+		plan.Drift = append(plan.Drift, dto.PromotionDriftItem{
+			Type:          model.PromotionDriftFlagMismatch,
+			ProductID:     product.ID,
+			SourceSKU:     product.SourceSKU,
+			OzonProductID: product.OzonProductID,
+			Detail:        detail,
+		})
+	}
+
+	report.AddedCount = plan.AddedCount
+	report.ExitedCount = plan.ExitedCount
+	report.UnknownCount = plan.UnknownCount
+	report.FlagsFixedCount = len(mismatches)
+	if len(plan.Drift) > promotionReconcileDriftLimit {
+		plan.Drift = plan.Drift[:promotionReconcileDriftLimit]
+	}
+	report.Drift, _ = json.Marshal(plan.Drift)
+
+	status := model.PromotionReconcileStatusSuccess
+	if report.ActionsFailed > 0 {
+		status = model.PromotionReconcileStatusPartialSuccess
+	}
+	s.finishReconcile(report, status, strings.Join(failureMessages, "; "))
+	return nil
+}
+
+func (s *PromotionService) finishReconcile(report *model.PromotionReconcileReport, status, message string) {
+	completedAt := time.Now()
+	report.Status = status
+	report.ErrorMessage = message
+	report.CompletedAt = &completedAt
+	_ = s.promotionRepo.UpdateReconcileReport(report)
+}
+
+// fetchActionProductPrices 分页拉取官方活动内全部商品，返回 Ozon 商品 ID → 活动价
+func fetchActionProductPrices(client *ozon.Client, actionID int64) (map[int64]float64, error) {
+	products := make(map[int64]float64)
+	seenLastIDs := make(map[string]struct{})
+	lastID := ""
+	for {
+		resp, err := client.GetActionProducts(actionID, promotionReconcilePageSize, lastID)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Result.Products) == 0 {
+			break
+		}
+		for _, item := range resp.Result.Products {
+			if ozonProductID := resolveOfficialActionProductID(item); ozonProductID > 0 {
+				products[ozonProductID] = item.ActionPrice
+			}
+		}
+
+		nextLastID := strings.TrimSpace(resp.Result.LastID)
+		if nextLastID == "" {
+			break
+		}
+		if _, exists := seenLastIDs[nextLastID]; exists {
+			break
+		}
+		seenLastIDs[nextLastID] = struct{}{}
+		lastID = nextLastID
+	}
+	return products, nil
+}
+
+// planPromotionReconcile 以 Ozon 为准计算本地推广记录的修正：
+//   - Ozon 活动中有、本地无有效记录：补记（missing_local）
+//   - 本地有效记录所属活动已拉取但商品不在其中：退出（missing_remote）
+//   - 本地有效记录所属官方活动已不在 Ozon 活动列表：退出（action_ended）
+//
+// 拉取失败的活动与店铺活动（无法通过 Ozon API 核对）的记录保持不变
+func planPromotionReconcile(input promotionReconcileInput) promotionReconcilePlan {
+	plan := promotionReconcilePlan{
+		Activate: make([]model.PromotedProduct, 0),
+		ExitIDs:  make([]uint, 0),
+		Drift:    make([]dto.PromotionDriftItem, 0),
+	}
+
+	type localKey struct {
+		productID uint
+		actionID  int64
+	}
+	localKeys := make(map[localKey]struct{}, len(input.LocalRows))
+	for _, row := range input.LocalRows {
+		localKeys[localKey{productID: row.ProductID, actionID: row.ActionID}] = struct{}{}
+
+		driftType := ""
+		detail := ""
+		if remote, fetched := input.RemoteProducts[row.ActionID]; fetched {
+			if _, exists := remote[row.Product.OzonProductID]; !exists {
+				driftType = model.PromotionDriftMissingRemote
+				detail = "Ozon 活动中已没有该商品，本地记录已退出"
+			}
+		} else if _, failed := input.FailedActions[row.ActionID]; !failed {
+			if _, official := input.OfficialActionIDs[row.ActionID]; official {
+				driftType = model.PromotionDriftActionEnded
+				detail = "活动已不在 Ozon 活动列表中，本地记录已退出"
+			}
+		}
+		if driftType == "" {
+			continue
+		}
+
+		plan.ExitIDs = append(plan.ExitIDs, row.ID)
+		plan.ExitedCount++
+		plan.Drift = append(plan.Drift, dto.PromotionDriftItem{
+			Type:          driftType,
+			ProductID:     row.ProductID,
+			SourceSKU:     row.Product.SourceSKU,
+			OzonProductID: row.Product.OzonProductID,
+			ActionID:      row.ActionID,
+			ActionTitle:   input.ActionTitles[row.ActionID],
+			Detail:        detail,
+		})
+	}
+
+	actionIDs := make([]int64, 0, len(input.RemoteProducts))
+	for actionID := range input.RemoteProducts {
+		actionIDs = append(actionIDs, actionID)
+	}
+	sort.Slice(actionIDs, func(i, j int) bool { return actionIDs[i] < actionIDs[j] })
+
+	for _, actionID := range actionIDs {
+		remote := input.RemoteProducts[actionID]
+		ozonProductIDs := make([]int64, 0, len(remote))
+		for ozonProductID := range remote {
+			ozonProductIDs = append(ozonProductIDs, ozonProductID)
+		}
+		sort.Slice(ozonProductIDs, func(i, j int) bool { return ozonProductIDs[i] < ozonProductIDs[j] })
+
+		for _, ozonProductID := range ozonProductIDs {
+			product, ok := input.Products[ozonProductID]
+			if !ok {
+				plan.UnknownCount++
+				plan.Drift = append(plan.Drift, dto.PromotionDriftItem{
+					Type:          model.PromotionDriftUnknownProduct,
+					OzonProductID: ozonProductID,
+					ActionID:      actionID,
+					ActionTitle:   input.ActionTitles[actionID],
+					Detail:        "本地商品表中没有该商品，请先同步商品",
+				})
+				continue
+			}
+			if _, exists := localKeys[localKey{productID: product.ID, actionID: actionID}]; exists {
+				continue
+			}
+
+			plan.Activate = append(plan.Activate, model.PromotedProduct{
+				ProductID:     product.ID,
+				PromotionType: "custom",
+				ActionID:      actionID,
+				ActionPrice:   remote[ozonProductID],
+				Status:        "active",
+			})
+			plan.AddedCount++
+			plan.Drift = append(plan.Drift, dto.PromotionDriftItem{
+				Type:          model.PromotionDriftMissingLocal,
+				ProductID:     product.ID,
+				SourceSKU:     product.SourceSKU,
+				OzonProductID: ozonProductID,
+				ActionID:      actionID,
+				ActionTitle:   input.ActionTitles[actionID],
+				Detail:        "Ozon 活动中已有该商品，已补记本地推广记录",
+			})
+		}
+	}
+	return plan
+}
+
+// ListReconcileReports 分页查询店铺对账报告
+func (s *PromotionService) ListReconcileReports(req *dto.PromotionReconcileListRequest) (*dto.PromotionReconcileListResponse, error) {
+	reports, total, err := s.promotionRepo.ListReconcileReports(req.ShopID, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.PromotionReconcileReportResponse, 0, len(reports))
+	for index := range reports {
+		items = append(items, *toPromotionReconcileReportDTO(&reports[index], false))
+	}
+	return &dto.PromotionReconcileListResponse{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Items:    items,
+	}, nil
+}
+
+// GetReconcileReport 查询对账报告及差异明细
+func (s *PromotionService) GetReconcileReport(shopID, reportID uint) (*dto.PromotionReconcileReportResponse, error) {
+	report, err := s.promotionRepo.FindReconcileReportByIDAndShop(reportID, shopID)
+	if err != nil {
+		return nil, err
+	}
+	return toPromotionReconcileReportDTO(report, true), nil
+}
+
+func toPromotionReconcileReportDTO(report *model.PromotionReconcileReport, withDrift bool) *dto.PromotionReconcileReportResponse {
+	resp := &dto.PromotionReconcileReportResponse{
+		ID:              report.ID,
+		ShopID:          report.ShopID,
+		TriggerMode:     report.TriggerMode,
+		Status:          report.Status,
+		ActionsChecked:  report.ActionsChecked,
+		ActionsFailed:   report.ActionsFailed,
+		RemoteProducts:  report.RemoteProducts,
+		AddedCount:      report.AddedCount,
+		ExitedCount:     report.ExitedCount,
+		FlagsFixedCount: report.FlagsFixedCount,
+		UnknownCount:    report.UnknownCount,
+		ErrorMessage:    report.ErrorMessage,
+		CreatedAt:       report.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if report.StartedAt != nil {
+		resp.StartedAt = report.StartedAt.Format("2006-01-02 15:04:05")
+	}
+	if report.CompletedAt != nil {
+		resp.CompletedAt = report.CompletedAt.Format("2006-01-02 15:04:05")
+	}
+	if withDrift {
+		resp.Drift = make([]dto.PromotionDriftItem, 0)
+		if len(report.Drift) > 0 {
+			_ = json.Unmarshal(report.Drift, &resp.Drift)
+		}
+		total := report.AddedCount + report.ExitedCount + report.FlagsFixedCount + report.UnknownCount
+		resp.DriftTruncated = len(resp.Drift) < total
+	}
+	return resp
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"testing"
+
+	"ozon-manager/internal/model"
+)
+
+func TestPlanPromotionReconcileFixesDriftBothWays(t *testing.T) {
+	t.Parallel()
+
+	kept := model.Product{ID: 1, OzonProductID: 101, SourceSKU: "SKU-101"}
+	removedInUI := model.Product{ID: 2, OzonProductID: 202, SourceSKU: "SKU-202"}
+	addedInUI := model.Product{ID: 3, OzonProductID: 303, SourceSKU: "SKU-303"}
+	endedAction := model.Product{ID: 4, OzonProductID: 404, SourceSKU: "SKU-404"}
+	shopAction := model.Product{ID: 5, OzonProductID: 505, SourceSKU: "SKU-505"}
+	failedAction := model.Product{ID: 6, OzonProductID: 606, SourceSKU: "SKU-606"}
+
+	input := promotionReconcileInput{
+		RemoteProducts: map[int64]map[int64]float64{
+			9001: {101: 450, 303: 380, 999: 100},
+		},
+		FailedActions:     map[int64]struct{}{9003: {}},
+		OfficialActionIDs: map[int64]struct{}{9001: {}, 9002: {}, 9003: {}},
+		ActionTitles:      map[int64]string{9001: "弹性促销", 9002: "已结束活动"},
+		LocalRows: []model.PromotedProduct{
+			{ID: 11, ProductID: 1, ActionID: 9001, Status: "active", Product: kept},
+			{ID: 12, ProductID: 2, ActionID: 9001, Status: "active", Product: removedInUI},
+			{ID: 13, ProductID: 4, ActionID: 9002, Status: "active", Product: endedAction},
+			{ID: 14, ProductID: 5, ActionID: 123456789, Status: "active", Product: shopAction},
+			{ID: 15, ProductID: 6, ActionID: 9003, Status: "active", Product: failedAction},
+		},
+		Products: map[int64]model.Product{101: kept, 303: addedInUI},
+	}
+
+	plan := planPromotionReconcile(input)
+
+	if len(plan.ExitIDs) != 2 || plan.ExitIDs[0] != 12 || plan.ExitIDs[1] != 13 {
+		t.Fatalf("exit ids = %v, want [12 13]", plan.ExitIDs)
+	}
+	if len(plan.Activate) != 1 || plan.Activate[0].ProductID != 3 || plan.Activate[0].ActionID != 9001 || plan.Activate[0].ActionPrice != 380 {
+		t.Fatalf("activate = %+v, want product 3 in action 9001 at 380", plan.Activate)
+	}
+	if plan.AddedCount != 1 || plan.ExitedCount != 2 || plan.UnknownCount != 1 {
+		t.Fatalf("counts added=%d exited=%d unknown=%d, want 1/2/1", plan.AddedCount, plan.ExitedCount, plan.UnknownCount)
+	}
+
+	wantTypes := map[string]int{
+		model.PromotionDriftMissingRemote:  1,
+		model.PromotionDriftActionEnded:    1,
+		model.PromotionDriftMissingLocal:   1,
+		model.PromotionDriftUnknownProduct: 1,
+	}
+	gotTypes := make(map[string]int)
+	for _, item := range plan.Drift {
+		gotTypes[item.Type]++
+	}
+	for driftType, want := range wantTypes {
+		if gotTypes[driftType] != want {
+			t.Fatalf("drift types = %v, want %v", gotTypes, wantTypes)
+		}
+	}
+}
+
+func TestPlanPromotionReconcileNoDrift(t *testing.T) {
+	t.Parallel()
+
+	product := model.Product{ID: 1, OzonProductID: 101, SourceSKU: "SKU-101"}
+	plan := planPromotionReconcile(promotionReconcileInput{
+		RemoteProducts:    map[int64]map[int64]float64{9001: {101: 450}},
+		OfficialActionIDs: map[int64]struct{}{9001: {}},
+		LocalRows:         []model.PromotedProduct{{ID: 11, ProductID: 1, ActionID: 9001, Status: "active", Product: product}},
+		Products:          map[int64]model.Product{101: product},
+	})
+	if len(plan.Activate)+len(plan.ExitIDs)+len(plan.Drift) != 0 {
+		t.Fatalf("plan = %+v, want no changes", plan)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"ozon-manager/internal/dto"
 	"ozon-manager/internal/model"
 	"ozon-manager/internal/repository"
+	"ozon-manager/pkg/automation/protocol"
 	"ozon-manager/pkg/ozon"
 )
 
@@ -19,6 +20,11 @@ type PromotionService struct {
 	promotionRepo     *repository.PromotionRepository
 	shopRepo          *repository.ShopRepository
 	automationService *AutomationService
+	shopGuard         *ShopGuard
+	taskQueue         *TaskQueue
+	loops             backgroundLoops
+
+	priceHistory *PriceHistoryService
 }
 
 func NewPromotionService(
@@ -31,22 +37,37 @@ func NewPromotionService(
 	if len(automationService) > 0 {
 		autoSvc = automationService[0]
 	}
-	return &PromotionService{
+	s := &PromotionService{
 		productRepo:       productRepo,
 		promotionRepo:     promotionRepo,
 		shopRepo:          shopRepo,
 		automationService: autoSvc,
+		shopGuard:         resolveShopGuard(shopRepo, autoSvc),
+	}
+	if autoSvc != nil {
+		autoSvc.RegisterContinuation(model.AutomationContinuationImportShopActions, s.continueImportShopActions)
+		autoSvc.RegisterContinuation(model.AutomationContinuationImportActionProducts, s.continueImportActionProducts)
+		autoSvc.RegisterContinuation(model.AutomationContinuationRecordParticipation, s.continueRecordParticipation)
 	}
+	return s
+}
+
+// ConfigurePriceHistory 亏损处理与改价导入时记录价格变动
+func (s *PromotionService) ConfigurePriceHistory(priceHistory *PriceHistoryService) {
+	s.priceHistory = priceHistory
 }
 
 // 功能1: BatchEnrollPromotions 批量报名促销活动
-func (s *PromotionService) BatchEnrollPromotions(req *dto.BatchEnrollRequest) (*dto.BatchEnrollResponse, error) {
+func (s *PromotionService) BatchEnrollPromotions(userID uint, req *dto.BatchEnrollRequest) (*dto.BatchEnrollResponse, error) {
+	if err := s.shopGuard.CheckWritable(req.ShopID); err != nil {
+		return nil, err
+	}
 	shop, err := s.shopRepo.GetWithCredentials(req.ShopID)
 	if err != nil {
 		return nil, fmt.Errorf("shop not found: %w", err)
 	}
 
-	client := ozon.NewClient(shop.ClientID, shop.ApiKey)
+	client := s.shopGuard.OzonClient(shop)
 
 	products, err := s.productRepo.FindEligible(req.ShopID, req.ExcludeLoss, req.ExcludePromoted)
 	if err != nil {
@@ -60,11 +81,15 @@ func (s *PromotionService) BatchEnrollPromotions(req *dto.BatchEnrollRequest) (*
 	if len(actions) == 0 {
 		return nil, fmt.Errorf("no active actions found")
 	}
+	if _, err := s.snapshotBulkOperation(userID, req.ShopID, model.BulkOperationTypeBatchEnroll, bulkOperationTargets(products, nil)); err != nil {
+		return nil, err
+	}
 
 	response := &dto.BatchEnrollResponse{
 		Success: true,
 		Details: make([]dto.EnrollDetail, 0),
 	}
+	source := userParticipationSource(model.PromotionParticipationOriginBatchEnroll, userID)
 
 	for _, product := range products {
 		detail := dto.EnrollDetail{
@@ -75,7 +100,7 @@ func (s *PromotionService) BatchEnrollPromotions(req *dto.BatchEnrollRequest) (*
 
 		hasSuccess := false
 		for _, action := range actions {
-			err := s.enrollProductToAction(client, action.ActionID, product, "custom")
+			err := s.enrollProductToAction(client, action.ActionID, product, "custom", source)
 			if err != nil {
 				detail.Error = err.Error()
 			} else {
@@ -97,13 +122,16 @@ func (s *PromotionService) BatchEnrollPromotions(req *dto.BatchEnrollRequest) (*
 	return response, nil
 }
 
-func (s *PromotionService) enrollProductToAction(client *ozon.Client, actionID int64, product model.Product, promotionType string) error {
-	items := []ozon.ActivateProductItem{
-		{
-			ProductID:   product.OzonProductID,
-			ActionPrice: product.CurrentPrice,
-		},
+// activateProductItem 报名请求的商品项：以商品当前价报名
+func activateProductItem(product model.Product) ozon.ActivateProductItem {
+	return ozon.ActivateProductItem{
+		ProductID:   product.OzonProductID,
+		ActionPrice: product.CurrentPrice,
 	}
+}
+
+func (s *PromotionService) enrollProductToAction(client *ozon.Client, actionID int64, product model.Product, promotionType string, source participationSource) error {
+	items := []ozon.ActivateProductItem{activateProductItem(product)}
 
 	_, err := client.ActivateProducts(actionID, items)
 	if err != nil {
@@ -119,18 +147,22 @@ func (s *PromotionService) enrollProductToAction(client *ozon.Client, actionID i
 		Status:        "active",
 	}
 	s.promotionRepo.CreatePromotedProduct(promotedProduct)
+	s.recordParticipation(product.ShopID, source.actionEvent(model.PromotionParticipationJoined, product, "official", actionID, "", items[0].ActionPrice))
 
 	return nil
 }
 
 // 功能2: ProcessLossProducts 处理亏损商品
-func (s *PromotionService) ProcessLossProducts(req *dto.ProcessLossRequest) (*dto.ProcessLossResponse, error) {
+func (s *PromotionService) ProcessLossProducts(userID uint, req *dto.ProcessLossRequest) (*dto.ProcessLossResponse, error) {
+	if err := s.shopGuard.CheckWritable(req.ShopID); err != nil {
+		return nil, err
+	}
 	shop, err := s.shopRepo.GetWithCredentials(req.ShopID)
 	if err != nil {
 		return nil, fmt.Errorf("shop not found: %w", err)
 	}
 
-	client := ozon.NewClient(shop.ClientID, shop.ApiKey)
+	client := s.shopGuard.OzonClient(shop)
 
 	lossProducts, err := s.promotionRepo.FindLossProductsByIDs(req.LossProductIDs)
 	if err != nil {
@@ -141,6 +173,9 @@ func (s *PromotionService) ProcessLossProducts(req *dto.ProcessLossRequest) (*dt
 	if err != nil {
 		return nil, fmt.Errorf("failed to get actions: %w", err)
 	}
+	if _, err := s.snapshotBulkOperation(userID, req.ShopID, model.BulkOperationTypeProcessLoss, lossProductTargets(lossProducts)); err != nil {
+		return nil, err
+	}
 
 	response := &dto.ProcessLossResponse{
 		Success: true,
@@ -149,8 +184,10 @@ func (s *PromotionService) ProcessLossProducts(req *dto.ProcessLossRequest) (*dt
 
 	for _, lp := range lossProducts {
 		product := lp.Product
+		source := userParticipationSource(model.PromotionParticipationOriginProcessLoss, userID)
+		source.Reference = fmt.Sprintf("loss_product:%d", lp.ID)
 
-		err := s.exitAllPromotions(client, req.ShopID, product)
+		err := s.exitAllPromotions(client, req.ShopID, product, source)
 		if err != nil {
 			response.Steps.ExitPromotion.Failed++
 		} else {
@@ -166,12 +203,14 @@ func (s *PromotionService) ProcessLossProducts(req *dto.ProcessLossRequest) (*dt
 			response.Steps.PriceUpdate.Success++
 			s.productRepo.UpdatePrice(product.ID, lp.NewPrice)
 			s.promotionRepo.UpdateLossProductStep(lp.ID, "price_updated", true)
+			s.recordParticipation(req.ShopID, source.repricedEvent(product, lp.NewPrice))
+			s.priceHistory.Record(appliedPriceChange(product, lp.NewPrice, model.PriceSourceLossProcess, source.TriggeredBy, source.Reference))
 		}
 
 		if len(actions) > 0 {
 			stepFailed := false
 			for _, action := range actions {
-				err := s.enrollProductToAction(client, action.ActionID, product, "custom")
+				err := s.enrollProductToAction(client, action.ActionID, product, "custom", source)
 				if err != nil {
 					stepFailed = true
 				}
@@ -192,19 +231,24 @@ func (s *PromotionService) ProcessLossProducts(req *dto.ProcessLossRequest) (*dt
 	return response, nil
 }
 
-func (s *PromotionService) exitAllPromotions(client *ozon.Client, shopID uint, product model.Product) error {
+func (s *PromotionService) exitAllPromotions(client *ozon.Client, shopID uint, product model.Product, source participationSource) error {
 	// 获取商品参与的所有促销
-	promotedProducts, err := s.promotionRepo.FindPromotedProductsByProductID(product.ID)
+	calls, err := s.exitAllCalls(product)
 	if err != nil {
 		return err
 	}
+	return s.exitPromotions(client, shopID, product, calls, source)
+}
 
-	for _, pp := range promotedProducts {
-		_, err := client.DeactivateProducts(pp.ActionID, []int64{product.OzonProductID})
+// exitPromotions 按 exitAllCalls 生成的步骤逐个退出，任一失败即停止
+func (s *PromotionService) exitPromotions(client *ozon.Client, shopID uint, product model.Product, calls []officialCall, source participationSource) error {
+	for _, call := range calls {
+		_, err := client.DeactivateProducts(call.ActionID, []int64{product.OzonProductID})
 		if err != nil {
 			return err
 		}
-		s.promotionRepo.ExitPromotion(product.ID, pp.PromotionType)
+		s.promotionRepo.ExitPromotion(product.ID, call.PromotionType)
+		s.recordParticipation(shopID, source.actionEvent(model.PromotionParticipationLeft, product, "official", call.ActionID, call.ActionTitle, call.ActionPrice))
 	}
 
 	// 更新商品推广状态
@@ -214,13 +258,21 @@ func (s *PromotionService) exitAllPromotions(client *ozon.Client, shopID uint, p
 }
 
 // 功能4: RemoveRepricePromote 移除-改价-重新推广
-func (s *PromotionService) RemoveRepricePromote(req *dto.RemoveRepricePromoteRequest) error {
+func (s *PromotionService) RemoveRepricePromote(userID uint, req *dto.RemoveRepricePromoteRequest) error {
+	if err := s.shopGuard.CheckWritable(req.ShopID); err != nil {
+		return err
+	}
 	shop, err := s.shopRepo.GetWithCredentials(req.ShopID)
 	if err != nil {
 		return fmt.Errorf("shop not found: %w", err)
 	}
 
-	client := ozon.NewClient(shop.ClientID, shop.ApiKey)
+	client := s.shopGuard.OzonClient(shop)
+	source := userParticipationSource(model.PromotionParticipationOriginRemoveReprice, userID)
+
+	if _, err := s.snapshotRepriceItems(userID, req.ShopID, model.BulkOperationTypeRemoveReprice, req.Products, nil); err != nil {
+		return err
+	}
 
 	for _, item := range req.Products {
 		product, err := s.productRepo.FindBySourceSKU(req.ShopID, item.SourceSKU)
@@ -228,15 +280,18 @@ func (s *PromotionService) RemoveRepricePromote(req *dto.RemoveRepricePromoteReq
 			continue
 		}
 
-		s.exitAllPromotions(client, req.ShopID, *product)
+		s.exitAllPromotions(client, req.ShopID, *product, source)
 
 		priceStr := strconv.FormatFloat(item.NewPrice, 'f', 2, 64)
-		client.UpdateSinglePrice(product.OzonProductID, priceStr, "", "")
+		if client.UpdateSinglePrice(product.OzonProductID, priceStr, "", "") == nil {
+			s.recordParticipation(req.ShopID, source.repricedEvent(*product, item.NewPrice))
+			s.priceHistory.Record(appliedPriceChange(*product, item.NewPrice, model.PriceSourceRepriceImport, source.TriggeredBy, source.Reference))
+		}
 		s.productRepo.UpdatePrice(product.ID, item.NewPrice)
 
 		actions, _ := s.promotionRepo.FindActivePromotionActions(req.ShopID)
 		for _, action := range actions {
-			s.enrollProductToAction(client, action.ActionID, *product, "custom")
+			s.enrollProductToAction(client, action.ActionID, *product, "custom", source)
 		}
 
 		if len(actions) > 0 {
@@ -253,7 +308,7 @@ func (s *PromotionService) SyncPromotionActions(shopID uint) ([]model.PromotionA
 		return nil, fmt.Errorf("shop not found: %w", err)
 	}
 
-	client := ozon.NewClient(shop.ClientID, shop.ApiKey)
+	client := s.shopGuard.OzonClient(shop)
 
 	actionsResp, err := client.GetActions()
 	if err != nil {
@@ -382,15 +437,13 @@ func (s *PromotionService) DeletePromotionAction(shopID uint, id uint) error {
 }
 
 // BatchEnrollToActions 批量报名到指定的促销活动
-func (s *PromotionService) BatchEnrollToActions(req *dto.BatchEnrollV2Request) (*dto.BatchEnrollResponse, error) {
-	// 获取店铺凭证
-	shop, err := s.shopRepo.GetWithCredentials(req.ShopID)
-	if err != nil {
-		return nil, fmt.Errorf("shop not found: %w", err)
+func (s *PromotionService) BatchEnrollToActions(userID uint, req *dto.BatchEnrollV2Request) (*dto.BatchEnrollResponse, error) {
+	if !req.DryRun {
+		if err := s.shopGuard.CheckWritable(req.ShopID); err != nil {
+			return nil, err
+		}
 	}
 
-	client := ozon.NewClient(shop.ClientID, shop.ApiKey)
-
 	// 获取符合条件的商品
 	products, err := s.productRepo.FindEligible(req.ShopID, req.ExcludeLoss, req.ExcludePromoted)
 	if err != nil {
@@ -407,10 +460,38 @@ func (s *PromotionService) BatchEnrollToActions(req *dto.BatchEnrollV2Request) (
 		return nil, fmt.Errorf("no valid actions found")
 	}
 
+	if req.DryRun {
+		plan := newOperationPlan()
+		s.checkPlanWritable(plan, req.ShopID)
+		calls := enrollCalls(actions)
+		for _, product := range products {
+			planOfficialSteps(plan, officialProductSteps{Product: product, Enroll: calls})
+		}
+		return &dto.BatchEnrollResponse{Success: true, Plan: plan}, nil
+	}
+
+	if _, err := s.snapshotBulkOperation(userID, req.ShopID, model.BulkOperationTypeBatchEnroll, bulkOperationTargets(products, nil)); err != nil {
+		return nil, err
+	}
+	return s.enrollToActions(userID, req.ShopID, products, actions)
+}
+
+// enrollToActions 将商品逐个报名到官方活动，调用方负责保存操作快照
+func (s *PromotionService) enrollToActions(userID, shopID uint, products []model.Product, actions []model.PromotionAction) (*dto.BatchEnrollResponse, error) {
+	// 获取店铺凭证
+	shop, err := s.shopRepo.GetWithCredentials(shopID)
+	if err != nil {
+		return nil, fmt.Errorf("shop not found: %w", err)
+	}
+
+	client := s.shopGuard.OzonClient(shop)
+
 	response := &dto.BatchEnrollResponse{
 		Success: true,
 		Details: make([]dto.EnrollDetail, 0),
 	}
+	source := userParticipationSource(model.PromotionParticipationOriginBatchEnroll, userID)
+	calls := enrollCalls(actions)
 
 	// 批量处理商品
 	for _, product := range products {
@@ -421,11 +502,11 @@ func (s *PromotionService) BatchEnrollToActions(req *dto.BatchEnrollV2Request) (
 		}
 
 		hasSuccess := false
-		for _, action := range actions {
+		for _, call := range calls {
 			// 确定促销类型
 			promotionType := "custom"
 
-			err := s.enrollProductToAction(client, action.ActionID, product, promotionType)
+			err := s.enrollProductToAction(client, call.ActionID, product, promotionType, source)
 			if err != nil {
 				detail.Error = err.Error()
 			} else {
@@ -448,14 +529,11 @@ func (s *PromotionService) BatchEnrollToActions(req *dto.BatchEnrollV2Request) (
 }
 
 // ProcessLossProductsV2 处理亏损商品（支持选择重新报名活动）
-func (s *PromotionService) ProcessLossProductsV2(req *dto.ProcessLossV2Request) (*dto.ProcessLossResponse, error) {
-	shop, err := s.shopRepo.GetWithCredentials(req.ShopID)
-	if err != nil {
-		return nil, fmt.Errorf("shop not found: %w", err)
+func (s *PromotionService) ProcessLossProductsV2(userID uint, req *dto.ProcessLossV2Request) (*dto.ProcessLossResponse, error) {
+	if err := s.shopGuard.CheckWritable(req.ShopID); err != nil {
+		return nil, err
 	}
 
-	client := ozon.NewClient(shop.ClientID, shop.ApiKey)
-
 	// 获取亏损商品记录
 	lossProducts, err := s.promotionRepo.FindLossProductsByIDs(req.LossProductIDs)
 	if err != nil {
@@ -468,6 +546,21 @@ func (s *PromotionService) ProcessLossProductsV2(req *dto.ProcessLossV2Request)
 		rejoinAction, _ = s.promotionRepo.FindPromotionActionByActionID(req.ShopID, *req.RejoinActionID)
 	}
 
+	if _, err := s.snapshotBulkOperation(userID, req.ShopID, model.BulkOperationTypeProcessLoss, lossProductTargets(lossProducts)); err != nil {
+		return nil, err
+	}
+	return s.processLossProductsV2(userID, req.ShopID, lossProducts, rejoinAction)
+}
+
+// processLossProductsV2 退出促销 → 改价 → 重新报名指定活动，调用方负责保存操作快照
+func (s *PromotionService) processLossProductsV2(userID, shopID uint, lossProducts []model.LossProduct, rejoinAction *model.PromotionAction) (*dto.ProcessLossResponse, error) {
+	shop, err := s.shopRepo.GetWithCredentials(shopID)
+	if err != nil {
+		return nil, fmt.Errorf("shop not found: %w", err)
+	}
+
+	client := s.shopGuard.OzonClient(shop)
+
 	response := &dto.ProcessLossResponse{
 		Success: true,
 		Steps:   dto.ProcessSteps{},
@@ -475,9 +568,16 @@ func (s *PromotionService) ProcessLossProductsV2(req *dto.ProcessLossV2Request)
 
 	for _, lp := range lossProducts {
 		product := lp.Product
+		source := userParticipationSource(model.PromotionParticipationOriginProcessLoss, userID)
+		source.Reference = fmt.Sprintf("loss_product:%d", lp.ID)
+
+		exitCalls, err := s.exitAllCalls(product)
+		steps := repriceProductSteps(product, exitCalls, lp.NewPrice, rejoinActionList(rejoinAction))
 
 		// Step 1: 退出所有促销活动
-		err := s.exitAllPromotions(client, req.ShopID, product)
+		if err == nil {
+			err = s.exitPromotions(client, shopID, product, steps.Exit, source)
+		}
 		if err != nil {
 			response.Steps.ExitPromotion.Failed++
 		} else {
@@ -486,22 +586,28 @@ func (s *PromotionService) ProcessLossProductsV2(req *dto.ProcessLossV2Request)
 		}
 
 		// Step 2: 改价
-		priceStr := strconv.FormatFloat(lp.NewPrice, 'f', 2, 64)
+		newPrice := steps.Reprice.Price
+		priceStr := strconv.FormatFloat(newPrice, 'f', 2, 64)
 		err = client.UpdateSinglePrice(product.OzonProductID, priceStr, "", "")
 		if err != nil {
 			response.Steps.PriceUpdate.Failed++
 		} else {
 			response.Steps.PriceUpdate.Success++
-			s.productRepo.UpdatePrice(product.ID, lp.NewPrice)
+			s.productRepo.UpdatePrice(product.ID, newPrice)
 			s.promotionRepo.UpdateLossProductStep(lp.ID, "price_updated", true)
+			s.recordParticipation(shopID, source.repricedEvent(product, newPrice))
+			s.priceHistory.Record(appliedPriceChange(product, newPrice, model.PriceSourceLossProcess, source.TriggeredBy, source.Reference))
 		}
 
 		// Step 3: 重新报名指定活动
-		if rejoinAction != nil {
-			promotionType := "custom"
-
-			err := s.enrollProductToAction(client, rejoinAction.ActionID, product, promotionType)
-			if err != nil {
+		if len(steps.Enroll) > 0 {
+			stepFailed := false
+			for _, call := range steps.Enroll {
+				if err := s.enrollProductToAction(client, call.ActionID, product, "custom", source); err != nil {
+					stepFailed = true
+				}
+			}
+			if stepFailed {
 				response.Steps.RejoinPromotions.Failed++
 			} else {
 				response.Steps.RejoinPromotions.Success++
@@ -518,43 +624,64 @@ func (s *PromotionService) ProcessLossProductsV2(req *dto.ProcessLossV2Request)
 }
 
 // RemoveRepricePromoteV2 移除-改价-重新推广（支持选择活动）
-func (s *PromotionService) RemoveRepricePromoteV2(req *dto.RemoveRepricePromoteV2Request) error {
-	shop, err := s.shopRepo.GetWithCredentials(req.ShopID)
-	if err != nil {
-		return fmt.Errorf("shop not found: %w", err)
+func (s *PromotionService) RemoveRepricePromoteV2(userID uint, req *dto.RemoveRepricePromoteV2Request) error {
+	if err := s.shopGuard.CheckWritable(req.ShopID); err != nil {
+		return err
 	}
 
-	client := ozon.NewClient(shop.ClientID, shop.ApiKey)
-
 	// 获取要重新报名的活动
 	var reenrollActions []model.PromotionAction
 	if len(req.ReenrollActionIDs) > 0 {
 		reenrollActions, _ = s.promotionRepo.FindPromotionActionsByActionIDs(req.ShopID, req.ReenrollActionIDs)
 	}
 
-	for _, item := range req.Products {
+	if _, err := s.snapshotRepriceItems(userID, req.ShopID, model.BulkOperationTypeRemoveReprice, req.Products, nil); err != nil {
+		return err
+	}
+	return s.removeRepricePromoteV2(userID, req.ShopID, req.Products, reenrollActions)
+}
+
+// removeRepricePromoteV2 退出全部官方活动 → 改价 → 重新报名指定官方活动，调用方负责保存操作快照
+func (s *PromotionService) removeRepricePromoteV2(userID, shopID uint, products []dto.RepriceItem, reenrollActions []model.PromotionAction) error {
+	shop, err := s.shopRepo.GetWithCredentials(shopID)
+	if err != nil {
+		return fmt.Errorf("shop not found: %w", err)
+	}
+
+	client := s.shopGuard.OzonClient(shop)
+	source := userParticipationSource(model.PromotionParticipationOriginRemoveReprice, userID)
+
+	for _, item := range products {
 		// 查找商品
-		product, err := s.productRepo.FindBySourceSKU(req.ShopID, item.SourceSKU)
+		product, err := s.productRepo.FindBySourceSKU(shopID, item.SourceSKU)
 		if err != nil {
 			continue
 		}
 
+		exitCalls, err := s.exitAllCalls(*product)
+		steps := repriceProductSteps(*product, exitCalls, item.NewPrice, reenrollActions)
+
 		// Step 1: 从所有促销活动中移除
-		s.exitAllPromotions(client, req.ShopID, *product)
+		if err == nil {
+			s.exitPromotions(client, shopID, *product, steps.Exit, source)
+		}
 
 		// Step 2: 改价
-		priceStr := strconv.FormatFloat(item.NewPrice, 'f', 2, 64)
-		client.UpdateSinglePrice(product.OzonProductID, priceStr, "", "")
-		s.productRepo.UpdatePrice(product.ID, item.NewPrice)
+		newPrice := steps.Reprice.Price
+		priceStr := strconv.FormatFloat(newPrice, 'f', 2, 64)
+		if client.UpdateSinglePrice(product.OzonProductID, priceStr, "", "") == nil {
+			s.recordParticipation(shopID, source.repricedEvent(*product, newPrice))
+			s.priceHistory.Record(appliedPriceChange(*product, newPrice, model.PriceSourceRepriceImport, source.TriggeredBy, source.Reference))
+		}
+		s.productRepo.UpdatePrice(product.ID, newPrice)
 
 		// Step 3: 重新添加到指定的促销活动
-		for _, action := range reenrollActions {
-			promotionType := "custom"
-			s.enrollProductToAction(client, action.ActionID, *product, promotionType)
+		for _, call := range steps.Enroll {
+			s.enrollProductToAction(client, call.ActionID, *product, "custom", source)
 		}
 
 		// 更新推广状态
-		if len(reenrollActions) > 0 {
+		if len(steps.Enroll) > 0 {
 			s.productRepo.UpdatePromotedStatus(product.ID, true)
 		}
 	}
@@ -635,11 +762,8 @@ type shopActionProductSnapshotItem struct {
 	Status           string  `json:"status"`
 }
 
-const (
-	shopActionsSyncWaitTimeout    = 45 * time.Second
-	actionProductsSyncWaitTimeout = 45 * time.Second
-)
-
+// SyncPromotionActionsV2 同步官方活动并发起店铺活动同步工作流。
+// 店铺活动由执行端抓取，完成后经续接动作导入，接口不再等待执行端，前端凭 workflow_id 轮询进度。
 func (s *PromotionService) SyncPromotionActionsV2(shopID uint, userID uint) (*dto.SyncActionsResult, error) {
 	baseActions, err := s.SyncPromotionActions(shopID)
 	if err != nil {
@@ -656,52 +780,60 @@ func (s *PromotionService) SyncPromotionActionsV2(shopID uint, userID uint) (*dt
 		PartialErrors:   map[string]string{},
 	}
 
+	if allActions, listErr := s.promotionRepo.FindPromotionActionsByShopID(shopID); listErr == nil {
+		_, shopActions := splitActionsBySource(allActions)
+		result.Actions = allActions
+		result.SyncSummary.ShopCount = len(shopActions)
+	}
+
 	if s.automationService == nil {
 		result.PartialErrors["shop"] = "automation service unavailable"
 		return result, nil
 	}
 
-	job, err := s.automationService.CreateSyncShopActionsJob(userID, shopID)
+	workflowID, err := s.startShopActionsSyncWorkflow(userID, shopID)
 	if err != nil {
 		result.PartialErrors["shop"] = err.Error()
 		return result, nil
 	}
+	result.ShopSyncPending = true
+	result.WorkflowID = &workflowID
+	return result, nil
+}
 
-	waitedJob, waitErr := s.automationService.WaitForJobCompletion(job.ID, shopActionsSyncWaitTimeout)
-	if waitErr != nil {
-		result.ShopSyncPending = true
-		result.PartialErrors["shop"] = "shop actions sync still running in background"
-
-		latestCompletedJob, latestJobErr := s.automationService.FindLatestCompletedSyncShopActionsJob(shopID)
-		if latestJobErr == nil && latestCompletedJob != nil {
-			if importedCount, importErr := s.importShopActionsFromJob(shopID, latestCompletedJob.ID); importErr == nil {
-				result.SyncSummary.ShopCount = importedCount
-				if allActions, listErr := s.promotionRepo.FindPromotionActionsByShopID(shopID); listErr == nil {
-					result.Actions = allActions
-				}
-			}
-		}
-		return result, nil
+// startShopActionsSyncWorkflow 创建店铺活动同步工作流；已有进行中的同步时复用其工作流
+func (s *PromotionService) startShopActionsSyncWorkflow(userID, shopID uint) (uint, error) {
+	if existing, err := s.automationService.FindActiveJobByTypeAndShop(model.AutomationJobTypeSyncShopActions, shopID); err == nil && existing.WorkflowID != nil {
+		return *existing.WorkflowID, nil
 	}
 
-	if waitedJob.Status != model.AutomationJobStatusSuccess && waitedJob.Status != model.AutomationJobStatusPartialSuccess {
-		result.PartialErrors["shop"] = automationJobFailureMessage(waitedJob, "shop actions sync failed")
-		return result, nil
+	workflow, err := s.automationService.CreateWorkflow(userID, shopID, model.AutomationWorkflowTypeSyncShopActions)
+	if err != nil {
+		return 0, err
 	}
-
-	importedCount, err := s.importShopActionsFromJob(shopID, waitedJob.ID)
+	_, err = s.automationService.CreateSyncShopActionsJob(userID, shopID, &AutomationJobLink{
+		WorkflowID: &workflow.ID,
+		OnSuccess:  model.AutomationContinuationImportShopActions,
+	})
 	if err != nil {
-		result.PartialErrors["shop"] = err.Error()
-		return result, nil
+		_ = s.automationService.FailWorkflow(workflow.ID, err.Error())
+		return 0, err
 	}
-	result.SyncSummary.ShopCount = importedCount
+	return workflow.ID, nil
+}
 
-	allActions, listErr := s.promotionRepo.FindPromotionActionsByShopID(shopID)
-	if listErr == nil {
-		result.Actions = allActions
+// continueImportShopActions 店铺活动同步任务成功后导入快照
+func (s *PromotionService) continueImportShopActions(job *model.AutomationJob) error {
+	importedCount, err := s.importShopActionsFromJob(job.ShopID, job.ID)
+	if err != nil {
+		return err
 	}
-
-	return result, nil
+	if job.WorkflowID != nil {
+		_ = s.automationService.SetWorkflowResult(*job.WorkflowID, map[string]interface{}{
+			"shop_count": importedCount,
+		})
+	}
+	return nil
 }
 
 func (s *PromotionService) importShopActionsFromJob(shopID uint, jobID uint) (int, error) {
@@ -766,15 +898,19 @@ func (s *PromotionService) GetActionProducts(actionID uint, req *dto.ActionProdu
 		}
 	}
 
+	var workflowID *uint
 	if shouldRefresh {
 		if action.Source == "official" {
 			if err := s.refreshOfficialActionProducts(action); err != nil {
 				return nil, err
 			}
 		} else if action.Source == "shop" {
-			if err := s.refreshShopActionProducts(action, userID); err != nil {
+			// 店铺活动商品由执行端抓取，先返回缓存，前端凭 workflow_id 轮询，完成后重新拉取列表
+			id, err := s.startActionProductsSyncWorkflow(userID, action)
+			if err != nil {
 				return nil, err
 			}
+			workflowID = &id
 		}
 	}
 
@@ -824,6 +960,8 @@ func (s *PromotionService) GetActionProducts(actionID uint, req *dto.ActionProdu
 		Page:           req.Page,
 		PageSize:       req.PageSize,
 		Items:          respItems,
+		SyncPending:    workflowID != nil,
+		WorkflowID:     workflowID,
 	}, nil
 }
 
@@ -832,7 +970,7 @@ func (s *PromotionService) refreshOfficialActionProducts(action *model.Promotion
 	if err != nil {
 		return err
 	}
-	client := ozon.NewClient(shop.ClientID, shop.ApiKey)
+	client := s.shopGuard.OzonClient(shop)
 
 	const pageSize = 200
 	lastID := ""
@@ -921,30 +1059,66 @@ func resolveOfficialActionProductID(item ozon.ActionProduct) int64 {
 	return item.ID
 }
 
-func (s *PromotionService) refreshShopActionProducts(action *model.PromotionAction, userID uint) error {
+// startActionProductsSyncWorkflow 创建店铺活动商品同步工作流；该活动已有进行中的同步时复用其工作流
+func (s *PromotionService) startActionProductsSyncWorkflow(userID uint, action *model.PromotionAction) (uint, error) {
 	if s.automationService == nil {
-		return fmt.Errorf("automation service unavailable")
+		return 0, fmt.Errorf("automation service unavailable")
+	}
+	if existing, err := s.automationService.FindActiveSyncActionProductsJob(action.ShopID, action.ID); err == nil && existing.WorkflowID != nil {
+		return *existing.WorkflowID, nil
 	}
-	job, err := s.automationService.CreateSyncActionProductsJob(userID, action.ShopID, action.ID, action.SourceActionID)
+
+	workflow, err := s.automationService.CreateWorkflow(userID, action.ShopID, model.AutomationWorkflowTypeSyncActionProducts)
+	if err != nil {
+		return 0, err
+	}
+	_, err = s.automationService.CreateSyncActionProductsJob(userID, action.ShopID, action.ID, action.SourceActionID, &AutomationJobLink{
+		WorkflowID: &workflow.ID,
+		OnSuccess:  model.AutomationContinuationImportActionProducts,
+	})
+	if err != nil {
+		_ = s.automationService.FailWorkflow(workflow.ID, err.Error())
+		return 0, err
+	}
+	return workflow.ID, nil
+}
+
+// continueImportActionProducts 店铺活动商品同步任务成功后导入快照
+func (s *PromotionService) continueImportActionProducts(job *model.AutomationJob) error {
+	metaArtifact, err := s.automationService.GetLatestArtifact(job.ID, "sync_action_products_meta")
 	if err != nil {
 		return err
 	}
-	waitedJob, waitErr := s.automationService.WaitForJobCompletion(job.ID, actionProductsSyncWaitTimeout)
-	if waitErr != nil {
-		return fmt.Errorf("shop action products sync timeout")
+	meta := protocol.SyncActionMeta{}
+	if err := json.Unmarshal(metaArtifact.Meta, &meta); err != nil {
+		return err
 	}
-	if waitedJob.Status != model.AutomationJobStatusSuccess && waitedJob.Status != model.AutomationJobStatusPartialSuccess {
-		return fmt.Errorf("%s", automationJobFailureMessage(waitedJob, "shop action products sync failed"))
+	action, err := s.promotionRepo.FindPromotionActionByIDAndShop(meta.PromotionActionID, job.ShopID)
+	if err != nil {
+		return fmt.Errorf("action not found")
 	}
 
-	artifact, err := s.automationService.GetLatestArtifact(waitedJob.ID, "action_products_snapshot")
+	productCount, err := s.importShopActionProductsFromJob(action, job.ID)
 	if err != nil {
 		return err
 	}
+	if job.WorkflowID != nil {
+		_ = s.automationService.SetWorkflowResult(*job.WorkflowID, map[string]interface{}{
+			"product_count": productCount,
+		})
+	}
+	return nil
+}
+
+func (s *PromotionService) importShopActionProductsFromJob(action *model.PromotionAction, jobID uint) (int, error) {
+	artifact, err := s.automationService.GetLatestArtifact(jobID, "action_products_snapshot")
+	if err != nil {
+		return 0, err
+	}
 
 	snapshot := shopActionProductsSnapshot{}
 	if err := json.Unmarshal(artifact.Meta, &snapshot); err != nil {
-		return err
+		return 0, err
 	}
 
 	products := make([]model.PromotionActionProduct, 0, len(snapshot.Items))
@@ -1043,7 +1217,10 @@ func (s *PromotionService) refreshShopActionProducts(action *model.PromotionActi
 		})
 	}
 
-	return s.promotionRepo.ReplaceActionProducts(action, products)
+	if err := s.promotionRepo.ReplaceActionProducts(action, products); err != nil {
+		return 0, err
+	}
+	return len(products), nil
 }
 
 func (s *PromotionService) findLocalProduct(shopID uint, sourceSKU string, ozonProductID int64) (*model.Product, error) {
@@ -1129,17 +1306,17 @@ func splitActionsBySource(actions []model.PromotionAction) ([]model.PromotionAct
 	return official, shop
 }
 
-func buildShopActionsMeta(actions []model.PromotionAction) []map[string]interface{} {
-	result := make([]map[string]interface{}, 0, len(actions))
+func buildShopActionsMeta(actions []model.PromotionAction) []protocol.ShopActionRef {
+	result := make([]protocol.ShopActionRef, 0, len(actions))
 	for _, action := range actions {
 		title := strings.TrimSpace(action.DisplayName)
 		if title == "" {
 			title = strings.TrimSpace(action.Title)
 		}
-		result = append(result, map[string]interface{}{
-			"action_db_id":     action.ID,
-			"source_action_id": strings.TrimSpace(action.SourceActionID),
-			"title":            title,
+		result = append(result, protocol.ShopActionRef{
+			ActionDBID:     action.ID,
+			SourceActionID: strings.TrimSpace(action.SourceActionID),
+			Title:          title,
 		})
 	}
 	return result
@@ -1174,17 +1351,19 @@ func (s *PromotionService) collectEligibleSKUs(shopID uint, excludeLoss bool, ex
 	return uniqueSKUs(skus), nil
 }
 
-func (s *PromotionService) removeFromOfficialActions(shopID uint, officialActions []model.PromotionAction, sourceSKUs []string) (*dto.BatchEnrollResponse, error) {
+func (s *PromotionService) removeFromOfficialActions(userID, shopID uint, officialActions []model.PromotionAction, sourceSKUs []string) (*dto.BatchEnrollResponse, error) {
 	shop, err := s.shopRepo.GetWithCredentials(shopID)
 	if err != nil {
 		return nil, fmt.Errorf("shop not found: %w", err)
 	}
-	client := ozon.NewClient(shop.ClientID, shop.ApiKey)
+	client := s.shopGuard.OzonClient(shop)
 
 	result := &dto.BatchEnrollResponse{
 		Success: true,
 		Details: make([]dto.EnrollDetail, 0, len(sourceSKUs)),
 	}
+	source := userParticipationSource(model.PromotionParticipationOriginUnifiedRemove, userID)
+	calls := removeCalls(officialActions)
 
 	for _, sku := range sourceSKUs {
 		product, findErr := s.productRepo.FindBySourceSKU(shopID, sku)
@@ -1199,11 +1378,17 @@ func (s *PromotionService) removeFromOfficialActions(shopID uint, officialAction
 		}
 
 		hasError := false
-		for _, action := range officialActions {
-			_, deErr := client.DeactivateProducts(action.ActionID, []int64{product.OzonProductID})
+		for _, call := range calls {
+			_, deErr := client.DeactivateProducts(call.ActionID, []int64{product.OzonProductID})
 			if deErr != nil {
 				hasError = true
+				continue
 			}
+			_ = s.promotionRepo.ExitPromotionAction(product.ID, call.ActionID)
+			s.recordParticipation(shopID, source.actionEvent(model.PromotionParticipationLeft, *product, "official", call.ActionID, call.ActionTitle, 0))
+		}
+		if remaining, listErr := s.promotionRepo.FindPromotedProductsByProductID(product.ID); listErr == nil && len(remaining) == 0 {
+			_ = s.productRepo.UpdatePromotedStatus(product.ID, false)
 		}
 
 		if hasError {
@@ -1232,11 +1417,8 @@ func (s *PromotionService) removeFromOfficialActions(shopID uint, officialAction
 	return result, nil
 }
 
-func (s *PromotionService) CreateUnifiedShopActionsJob(userID, shopID uint, jobType string, shopActions []model.PromotionAction, skus []string) (*model.AutomationJob, error) {
-	if s.automationService == nil {
-		return nil, fmt.Errorf("automation service unavailable")
-	}
-
+// unifiedShopActionsJobItems 店铺活动报名/退出任务的明细，SKU 去重，目标价仅为占位
+func unifiedShopActionsJobItems(skus []string) ([]model.AutomationJobItem, error) {
 	items := make([]model.AutomationJobItem, 0, len(skus))
 	for _, sku := range uniqueSKUs(skus) {
 		items = append(items, model.AutomationJobItem{
@@ -1251,27 +1433,46 @@ func (s *PromotionService) CreateUnifiedShopActionsJob(userID, shopID uint, jobT
 	if len(items) == 0 {
 		return nil, fmt.Errorf("没有可处理的 SKU")
 	}
+	return items, nil
+}
+
+func unifiedShopActionsOperation(jobType string) string {
+	if jobType == model.AutomationJobTypePromoUnifiedRemove {
+		return "remove"
+	}
+	return "declare"
+}
+
+func (s *PromotionService) CreateUnifiedShopActionsJob(userID, shopID uint, jobType string, shopActions []model.PromotionAction, skus []string) (*model.AutomationJob, error) {
+	if err := s.shopGuard.CheckWritable(shopID); err != nil {
+		return nil, err
+	}
+	if s.automationService == nil {
+		return nil, fmt.Errorf("automation service unavailable")
+	}
+
+	items, err := unifiedShopActionsJobItems(skus)
+	if err != nil {
+		return nil, err
+	}
 
 	job := &model.AutomationJob{
 		ShopID:     shopID,
 		CreatedBy:  userID,
 		JobType:    jobType,
 		Status:     model.AutomationJobStatusPending,
+		Priority:   model.AutomationJobPriorityInteractive,
 		RateLimit:  1,
 		TotalItems: len(items),
+		OnSuccess:  model.AutomationContinuationRecordParticipation,
 	}
 	if err := s.automationService.CreateJobWithItems(job, items); err != nil {
 		return nil, err
 	}
 
-	actionPayload := buildShopActionsMeta(shopActions)
-	operation := "declare"
-	if jobType == model.AutomationJobTypePromoUnifiedRemove {
-		operation = "remove"
-	}
-	meta := map[string]interface{}{
-		"operation":    operation,
-		"shop_actions": actionPayload,
+	meta := protocol.PromoUnifiedMeta{
+		Operation:   unifiedShopActionsOperation(jobType),
+		ShopActions: buildShopActionsMeta(shopActions),
 	}
 	if err := s.automationService.CreateArtifact(job.ID, "promo_unified_meta", meta); err != nil {
 		return nil, err
@@ -1282,6 +1483,11 @@ func (s *PromotionService) CreateUnifiedShopActionsJob(userID, shopID uint, jobT
 
 // UnifiedEnroll 统一报名入口：根据活动 source 自动路由
 func (s *PromotionService) UnifiedEnroll(userID uint, req *dto.UnifiedEnrollRequest) (*dto.UnifiedOperationResponse, error) {
+	if !req.DryRun {
+		if err := s.shopGuard.CheckWritable(req.ShopID); err != nil {
+			return nil, err
+		}
+	}
 	actions, err := s.promotionRepo.FindPromotionActionsByIDs(req.ShopID, req.ActionIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get actions: %w", err)
@@ -1296,21 +1502,80 @@ func (s *PromotionService) UnifiedEnroll(userID uint, req *dto.UnifiedEnrollRequ
 		officialActionIDs = append(officialActionIDs, action.ActionID)
 	}
 
+	var skus []string
+	if len(shopActions) > 0 {
+		skus = uniqueSKUs(req.SourceSKUs)
+		if len(skus) == 0 {
+			skus, err = s.collectEligibleSKUs(req.ShopID, req.ExcludeLoss, req.ExcludePromoted)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(skus) == 0 {
+			return nil, fmt.Errorf("没有找到可报名的商品")
+		}
+	}
+
+	// 执行前为官方活动与店铺活动涉及的商品保存一份快照，撤销时整体恢复
+	var operation *model.BulkOperation
+	var officialProducts []model.Product
+	if !req.DryRun {
+		targets := make([]bulkOperationTarget, 0)
+		if len(officialActions) > 0 {
+			officialProducts, err = s.productRepo.FindEligible(req.ShopID, req.ExcludeLoss, req.ExcludePromoted)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get eligible products: %w", err)
+			}
+			targets = append(targets, bulkOperationTargets(officialProducts, nil)...)
+		}
+		if len(shopActions) > 0 {
+			shopTargets, targetErr := s.skuTargets(req.ShopID, skus, shopActions)
+			if targetErr != nil {
+				return nil, fmt.Errorf("保存操作快照失败: %w", targetErr)
+			}
+			targets = append(targets, shopTargets...)
+		}
+		operation, err = s.snapshotBulkOperation(userID, req.ShopID, model.BulkOperationTypeUnifiedEnroll, targets)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var officialResult *dto.BatchEnrollResponse
-	if len(officialActionIDs) > 0 {
+	if len(officialActionIDs) > 0 && !req.DryRun {
+		officialResult, err = s.enrollToActions(userID, req.ShopID, officialProducts, officialActions)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(officialActionIDs) > 0 {
 		enrollReq := &dto.BatchEnrollV2Request{
 			ShopID:          req.ShopID,
 			ActionIDs:       officialActionIDs,
 			ExcludeLoss:     req.ExcludeLoss,
 			ExcludePromoted: req.ExcludePromoted,
+			DryRun:          req.DryRun,
 		}
-		officialResult, err = s.BatchEnrollToActions(enrollReq)
+		officialResult, err = s.BatchEnrollToActions(userID, enrollReq)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// 试运行：官方活动的计划由 BatchEnrollToActions 生成，店铺活动追加到同一计划
+	var plan *dto.OperationPlan
+	if req.DryRun {
+		if officialResult != nil {
+			plan = officialResult.Plan
+		} else {
+			plan = newOperationPlan()
+			s.checkPlanWritable(plan, req.ShopID)
+		}
+	}
+
 	if len(shopActions) == 0 {
+		if plan != nil {
+			return &dto.UnifiedOperationResponse{Mode: "dry_run", Plan: plan, Message: summarizeOperationPlan(plan)}, nil
+		}
 		if officialResult == nil {
 			return nil, fmt.Errorf("未找到可操作的活动")
 		}
@@ -1321,21 +1586,18 @@ func (s *PromotionService) UnifiedEnroll(userID uint, req *dto.UnifiedEnrollRequ
 		}, nil
 	}
 
-	skus := uniqueSKUs(req.SourceSKUs)
-	if len(skus) == 0 {
-		skus, err = s.collectEligibleSKUs(req.ShopID, req.ExcludeLoss, req.ExcludePromoted)
-		if err != nil {
+	if plan != nil {
+		if err := planUnifiedShopActionsJob(plan, model.AutomationJobTypePromoUnifiedEnroll, shopActions, skus); err != nil {
 			return nil, err
 		}
-	}
-	if len(skus) == 0 {
-		return nil, fmt.Errorf("没有找到可报名的商品")
+		return &dto.UnifiedOperationResponse{Mode: "dry_run", Plan: plan, Message: summarizeOperationPlan(plan)}, nil
 	}
 
 	job, err := s.CreateUnifiedShopActionsJob(userID, req.ShopID, model.AutomationJobTypePromoUnifiedEnroll, shopActions, skus)
 	if err != nil {
 		return nil, fmt.Errorf("创建店铺促销申报任务失败: %w", err)
 	}
+	s.linkBulkOperationJobs(operation, job.ID)
 
 	msg := fmt.Sprintf("已创建店铺促销申报任务 #%d，等待浏览器插件执行", job.ID)
 	if officialResult != nil {
@@ -1352,6 +1614,11 @@ func (s *PromotionService) UnifiedEnroll(userID uint, req *dto.UnifiedEnrollRequ
 
 // UnifiedRemove 统一退出入口：根据活动 source 自动路由
 func (s *PromotionService) UnifiedRemove(userID uint, req *dto.UnifiedRemoveRequest) (*dto.UnifiedOperationResponse, error) {
+	if !req.DryRun {
+		if err := s.shopGuard.CheckWritable(req.ShopID); err != nil {
+			return nil, err
+		}
+	}
 	actions, err := s.promotionRepo.FindPromotionActionsByIDs(req.ShopID, req.ActionIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get actions: %w", err)
@@ -1366,9 +1633,30 @@ func (s *PromotionService) UnifiedRemove(userID uint, req *dto.UnifiedRemoveRequ
 	}
 
 	officialActions, shopActions := splitActionsBySource(actions)
+	if req.DryRun {
+		plan := newOperationPlan()
+		s.checkPlanWritable(plan, req.ShopID)
+		s.planOfficialRemove(plan, req.ShopID, officialActions, sourceSKUs)
+		if len(shopActions) > 0 {
+			if err := planUnifiedShopActionsJob(plan, model.AutomationJobTypePromoUnifiedRemove, shopActions, sourceSKUs); err != nil {
+				return nil, err
+			}
+		}
+		return &dto.UnifiedOperationResponse{Mode: "dry_run", Plan: plan, Message: summarizeOperationPlan(plan)}, nil
+	}
+
+	targets, err := s.skuTargets(req.ShopID, sourceSKUs, shopActions)
+	if err != nil {
+		return nil, fmt.Errorf("保存操作快照失败: %w", err)
+	}
+	operation, err := s.snapshotBulkOperation(userID, req.ShopID, model.BulkOperationTypeUnifiedRemove, targets)
+	if err != nil {
+		return nil, err
+	}
+
 	var officialResult *dto.BatchEnrollResponse
 	if len(officialActions) > 0 {
-		officialResult, err = s.removeFromOfficialActions(req.ShopID, officialActions, sourceSKUs)
+		officialResult, err = s.removeFromOfficialActions(userID, req.ShopID, officialActions, sourceSKUs)
 		if err != nil {
 			return nil, err
 		}
@@ -1389,6 +1677,7 @@ func (s *PromotionService) UnifiedRemove(userID uint, req *dto.UnifiedRemoveRequ
 	if err != nil {
 		return nil, fmt.Errorf("创建店铺促销退出任务失败: %w", err)
 	}
+	s.linkBulkOperationJobs(operation, job.ID)
 
 	msg := fmt.Sprintf("已创建店铺促销退出任务 #%d，等待浏览器插件执行", job.ID)
 	if officialResult != nil {
@@ -1405,51 +1694,37 @@ func (s *PromotionService) UnifiedRemove(userID uint, req *dto.UnifiedRemoveRequ
 
 // UnifiedProcessLoss 统一亏损处理入口
 func (s *PromotionService) UnifiedProcessLoss(userID uint, req *dto.UnifiedProcessLossRequest) (*dto.UnifiedProcessLossResponse, error) {
-	if len(req.RejoinActionIDs) == 0 {
-		result, err := s.ProcessLossProductsV2(&dto.ProcessLossV2Request{
-			ShopID:         req.ShopID,
-			LossProductIDs: req.LossProductIDs,
-			RejoinActionID: nil,
-		})
-		if err != nil {
-			return nil, err
-		}
-		return &dto.UnifiedProcessLossResponse{
-			Mode:    "sync",
-			Result:  result,
-			Message: "同步处理完成",
-		}, nil
+	if req.DryRun {
+		return s.planUnifiedProcessLoss(req)
+	}
+	if err := s.shopGuard.CheckWritable(req.ShopID); err != nil {
+		return nil, err
 	}
 
-	actions, err := s.promotionRepo.FindPromotionActionsByIDs(req.ShopID, req.RejoinActionIDs)
+	officialActions, shopActions, err := s.findRoutedActions(req.ShopID, req.RejoinActionIDs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get actions: %w", err)
+		return nil, err
 	}
-	if len(actions) == 0 {
-		return nil, fmt.Errorf("未找到有效的促销活动")
+
+	lossProducts, err := s.promotionRepo.FindLossProductsByIDs(req.LossProductIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loss products: %w", err)
+	}
+	operation, err := s.snapshotBulkOperation(userID, req.ShopID, model.BulkOperationTypeUnifiedProcessLoss, bulkOperationTargets(lossProductsOf(lossProducts), shopActions))
+	if err != nil {
+		return nil, err
 	}
-	officialActions, shopActions := splitActionsBySource(actions)
 
 	if len(shopActions) > 0 {
-		lossProducts, listErr := s.promotionRepo.FindLossProductsByIDs(req.LossProductIDs)
-		if listErr != nil {
-			return nil, fmt.Errorf("failed to get loss products: %w", listErr)
-		}
-		inputs := make([]dto.RepriceItem, 0, len(lossProducts))
-		for _, lossProduct := range lossProducts {
-			inputs = append(inputs, dto.RepriceItem{
-				SourceSKU: lossProduct.Product.SourceSKU,
-				NewPrice:  lossProduct.NewPrice,
-			})
-		}
-		job, createErr := s.createRemoveRepriceReaddJob(userID, req.ShopID, inputs, map[string]interface{}{
-			"reason":            "unified_process_loss",
-			"rejoin_action_ids": req.RejoinActionIDs,
-			"shop_actions":      buildShopActionsMeta(shopActions),
+		job, createErr := s.createRemoveRepriceReaddJob(userID, req.ShopID, lossRepriceItems(lossProducts), &protocol.RemoveRepriceReaddMeta{
+			Reason:          "unified_process_loss",
+			RejoinActionIDs: req.RejoinActionIDs,
+			ShopActions:     buildShopActionsMeta(shopActions),
 		})
 		if createErr != nil {
 			return nil, createErr
 		}
+		s.linkBulkOperationJobs(operation, job.ID)
 		return &dto.UnifiedProcessLossResponse{
 			Mode:    "async",
 			JobID:   &job.ID,
@@ -1457,15 +1732,7 @@ func (s *PromotionService) UnifiedProcessLoss(userID uint, req *dto.UnifiedProce
 		}, nil
 	}
 
-	var rejoinActionID *int64
-	if len(officialActions) > 0 {
-		rejoinActionID = &officialActions[0].ActionID
-	}
-	result, err := s.ProcessLossProductsV2(&dto.ProcessLossV2Request{
-		ShopID:         req.ShopID,
-		LossProductIDs: req.LossProductIDs,
-		RejoinActionID: rejoinActionID,
-	})
+	result, err := s.processLossProductsV2(userID, req.ShopID, lossProducts, firstRejoinAction(officialActions))
 	if err != nil {
 		return nil, err
 	}
@@ -1476,14 +1743,8 @@ func (s *PromotionService) UnifiedProcessLoss(userID uint, req *dto.UnifiedProce
 	}, nil
 }
 
-func (s *PromotionService) createRemoveRepriceReaddJob(userID, shopID uint, products []dto.RepriceItem, meta map[string]interface{}) (*model.AutomationJob, error) {
-	if s.automationService == nil {
-		return nil, fmt.Errorf("automation service unavailable")
-	}
-	if len(products) == 0 {
-		return nil, fmt.Errorf("没有可处理的商品")
-	}
-
+// removeRepriceReaddJobItems 退出-改价-重新报名任务的明细，跳过空 SKU
+func removeRepriceReaddJobItems(products []dto.RepriceItem) ([]model.AutomationJobItem, error) {
 	items := make([]model.AutomationJobItem, 0, len(products))
 	for _, product := range products {
 		if strings.TrimSpace(product.SourceSKU) == "" {
@@ -1501,19 +1762,68 @@ func (s *PromotionService) createRemoveRepriceReaddJob(userID, shopID uint, prod
 	if len(items) == 0 {
 		return nil, fmt.Errorf("没有可处理的商品")
 	}
+	return items, nil
+}
+
+// lossRepriceItems 亏损记录转为改价明细，交给 remove_reprice_readd 任务
+func lossRepriceItems(lossProducts []model.LossProduct) []dto.RepriceItem {
+	inputs := make([]dto.RepriceItem, 0, len(lossProducts))
+	for _, lossProduct := range lossProducts {
+		inputs = append(inputs, dto.RepriceItem{
+			SourceSKU: lossProduct.Product.SourceSKU,
+			NewPrice:  lossProduct.NewPrice,
+		})
+	}
+	return inputs
+}
+
+// firstRejoinAction 同步亏损处理只重新报名第一个官方活动
+func firstRejoinAction(officialActions []model.PromotionAction) *model.PromotionAction {
+	if len(officialActions) == 0 {
+		return nil
+	}
+	return &officialActions[0]
+}
+
+// findRoutedActions 查询指定的活动并按来源拆分，未传活动时返回空
+func (s *PromotionService) findRoutedActions(shopID uint, actionIDs []uint) ([]model.PromotionAction, []model.PromotionAction, error) {
+	if len(actionIDs) == 0 {
+		return nil, nil, nil
+	}
+	actions, err := s.promotionRepo.FindPromotionActionsByIDs(shopID, actionIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get actions: %w", err)
+	}
+	if len(actions) == 0 {
+		return nil, nil, fmt.Errorf("未找到有效的促销活动")
+	}
+	officialActions, shopActions := splitActionsBySource(actions)
+	return officialActions, shopActions, nil
+}
+
+func (s *PromotionService) createRemoveRepriceReaddJob(userID, shopID uint, products []dto.RepriceItem, meta *protocol.RemoveRepriceReaddMeta) (*model.AutomationJob, error) {
+	if s.automationService == nil {
+		return nil, fmt.Errorf("automation service unavailable")
+	}
+	items, err := removeRepriceReaddJobItems(products)
+	if err != nil {
+		return nil, err
+	}
 
 	job := &model.AutomationJob{
 		ShopID:     shopID,
 		CreatedBy:  userID,
 		JobType:    model.AutomationJobTypeRemoveRepriceReadd,
 		Status:     model.AutomationJobStatusPending,
+		Priority:   model.AutomationJobPriorityInteractive,
 		RateLimit:  1,
 		TotalItems: len(items),
+		OnSuccess:  model.AutomationContinuationRecordParticipation,
 	}
 	if err := s.automationService.CreateJobWithItems(job, items); err != nil {
 		return nil, err
 	}
-	if len(meta) > 0 {
+	if meta != nil {
 		_ = s.automationService.CreateArtifact(job.ID, "remove_reprice_readd_meta", meta)
 	}
 	return s.automationService.FindJobByIDAndShop(job.ID, shopID)
@@ -1521,46 +1831,33 @@ func (s *PromotionService) createRemoveRepriceReaddJob(userID, shopID uint, prod
 
 // UnifiedRepricePromote 统一改价推广入口
 func (s *PromotionService) UnifiedRepricePromote(userID uint, req *dto.UnifiedRepricePromoteRequest) (*dto.UnifiedRepricePromoteResponse, error) {
-	if len(req.ReenrollActionIDs) == 0 {
-		err := s.RemoveRepricePromoteV2(&dto.RemoveRepricePromoteV2Request{
-			ShopID:            req.ShopID,
-			Products:          req.Products,
-			ReenrollActionIDs: []int64{},
-		})
-		if err != nil {
-			return nil, err
-		}
-		return &dto.UnifiedRepricePromoteResponse{
-			Mode: "sync",
-			Result: &dto.UnifiedRepricePromoteResult{
-				Success:          true,
-				RemoveCount:      len(req.Products),
-				PriceUpdateCount: len(req.Products),
-				PromoteCount:     0,
-				FailedCount:      0,
-			},
-			Message: "同步处理完成",
-		}, nil
+	if req.DryRun {
+		return s.planUnifiedRepricePromote(req)
+	}
+	if err := s.shopGuard.CheckWritable(req.ShopID); err != nil {
+		return nil, err
 	}
 
-	actions, err := s.promotionRepo.FindPromotionActionsByIDs(req.ShopID, req.ReenrollActionIDs)
+	officialActions, shopActions, err := s.findRoutedActions(req.ShopID, req.ReenrollActionIDs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get actions: %w", err)
+		return nil, err
 	}
-	if len(actions) == 0 {
-		return nil, fmt.Errorf("未找到有效的促销活动")
+
+	operation, err := s.snapshotRepriceItems(userID, req.ShopID, model.BulkOperationTypeUnifiedRepricePromote, req.Products, shopActions)
+	if err != nil {
+		return nil, err
 	}
-	officialActions, shopActions := splitActionsBySource(actions)
 
 	if len(shopActions) > 0 {
-		job, createErr := s.createRemoveRepriceReaddJob(userID, req.ShopID, req.Products, map[string]interface{}{
-			"reason":              "unified_reprice_promote",
-			"reenroll_action_ids": req.ReenrollActionIDs,
-			"shop_actions":        buildShopActionsMeta(shopActions),
+		job, createErr := s.createRemoveRepriceReaddJob(userID, req.ShopID, req.Products, &protocol.RemoveRepriceReaddMeta{
+			Reason:            "unified_reprice_promote",
+			ReenrollActionIDs: req.ReenrollActionIDs,
+			ShopActions:       buildShopActionsMeta(shopActions),
 		})
 		if createErr != nil {
 			return nil, createErr
 		}
+		s.linkBulkOperationJobs(operation, job.ID)
 		return &dto.UnifiedRepricePromoteResponse{
 			Mode:    "async",
 			JobID:   &job.ID,
@@ -1568,24 +1865,20 @@ func (s *PromotionService) UnifiedRepricePromote(userID uint, req *dto.UnifiedRe
 		}, nil
 	}
 
-	officialActionIDs := make([]int64, 0, len(officialActions))
-	for _, action := range officialActions {
-		officialActionIDs = append(officialActionIDs, action.ActionID)
-	}
-	if err := s.RemoveRepricePromoteV2(&dto.RemoveRepricePromoteV2Request{
-		ShopID:            req.ShopID,
-		Products:          req.Products,
-		ReenrollActionIDs: officialActionIDs,
-	}); err != nil {
+	if err := s.removeRepricePromoteV2(userID, req.ShopID, req.Products, officialActions); err != nil {
 		return nil, err
 	}
+	promoteCount := 0
+	if len(officialActions) > 0 {
+		promoteCount = len(req.Products)
+	}
 	return &dto.UnifiedRepricePromoteResponse{
 		Mode: "sync",
 		Result: &dto.UnifiedRepricePromoteResult{
 			Success:          true,
 			RemoveCount:      len(req.Products),
 			PriceUpdateCount: len(req.Products),
-			PromoteCount:     len(req.Products),
+			PromoteCount:     promoteCount,
 			FailedCount:      0,
 		},
 		Message: "同步处理完成",
@@ -1593,7 +1886,10 @@ func (s *PromotionService) UnifiedRepricePromote(userID uint, req *dto.UnifiedRe
 }
 
 // CreateShopActionJob 创建店铺促销操作的 automation job
-func (s *PromotionService) CreateShopActionJob(userID, shopID uint, jobType string, sourceActionID string, skus []string) (*model.AutomationJob, error) {
+func (s *PromotionService) CreateShopActionJob(userID, shopID uint, jobType string, sourceActionID string, skus []string, priority int) (*model.AutomationJob, error) {
+	if err := s.shopGuard.CheckWritable(shopID); err != nil {
+		return nil, err
+	}
 	if s.automationService == nil {
 		return nil, fmt.Errorf("automation service unavailable")
 	}
@@ -1603,6 +1899,7 @@ func (s *PromotionService) CreateShopActionJob(userID, shopID uint, jobType stri
 		CreatedBy:  userID,
 		JobType:    jobType,
 		Status:     model.AutomationJobStatusPending,
+		Priority:   priority,
 		RateLimit:  1,
 		TotalItems: len(skus),
 	}
@@ -1624,9 +1921,7 @@ func (s *PromotionService) CreateShopActionJob(userID, shopID uint, jobType stri
 	}
 
 	// 通过 artifact 存储 meta，Agent 轮询时会读取
-	meta := map[string]interface{}{
-		"source_action_id": sourceActionID,
-	}
+	meta := protocol.ShopActionMeta{SourceActionID: sourceActionID}
 	if err := s.automationService.CreateArtifact(job.ID, "shop_action_meta", meta); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+	"ozon-manager/internal/repository"
+)
+
+const promotionSimulationStatusIneligible = "ineligible"
+
+// ConfigureProductCosts 注入成本价来源，未配置时模拟结果不计算毛利
+func (s *AutoPromotionService) ConfigureProductCosts(costRepo *repository.RepriceRuleRepository) {
+	s.costRepo = costRepo
+}
+
+// Simulate 模拟报名促销的财务影响：逐个活动、逐个 SKU 计算活动价、折扣与毛利，
+// 只读本地缓存（商品、候选、已报名、成本价），不调用 Ozon，也不创建运行
+func (s *AutoPromotionService) Simulate(req *dto.PromotionSimulationRequest) (*dto.PromotionSimulationResponse, error) {
+	officialIDs := uniqueUints(req.OfficialActionIDs)
+	shopIDs := uniqueUints(req.ShopActionIDs)
+	skus := make([]string, 0, len(req.SourceSKUs))
+	for _, sku := range req.SourceSKUs {
+		if trimmed := strings.TrimSpace(sku); trimmed != "" {
+			skus = append(skus, trimmed)
+		}
+	}
+	skus = uniqueStrings(skus)
+
+	var config *model.AutoPromotionConfig
+	if req.ConfigID != nil {
+		found, err := s.autoRepo.FindConfigByIDAndShop(*req.ConfigID, req.ShopID)
+		if err != nil {
+			return nil, fmt.Errorf("配置不存在: %w", err)
+		}
+		config = found
+		officialIDs = uniqueUints(decodeActionIDs(config.OfficialActionIDs))
+		shopIDs = uniqueUints(decodeActionIDs(config.ShopActionIDs))
+	}
+	if len(officialIDs)+len(shopIDs) == 0 {
+		return nil, fmt.Errorf("请至少选择一个促销活动")
+	}
+
+	actions, err := s.resolveActions(req.ShopID, officialIDs, shopIDs)
+	if err != nil {
+		return nil, err
+	}
+	officialActions, shopActions := splitActionsBySource(actions)
+	orderedActions := append(append([]model.PromotionAction{}, officialActions...), shopActions...)
+
+	resp := &dto.PromotionSimulationResponse{MissingSKUs: make([]string, 0)}
+	// selected 按配置模拟时运行会提交的活动与 SKU，候选但被选品规则排除的记为不符合
+	var selected map[uint]map[string]struct{}
+	if config != nil {
+		// 与定时运行一致：按配置的目标日期与选品规则从目录中选品
+		rule := decodeSelectionRule(config.SelectionRules)
+		target := configTarget(config)
+		now := time.Now()
+		targetFrom, targetTo := target.resolve(dateOnlyValue(now))
+		from, to := resolveListingDateRange(rule, targetFrom, targetTo, now)
+		selection, err := s.selectByRule(req.ShopID, from, to, rule, target.excludeEnrolled(), officialActions, shopActions)
+		if err != nil {
+			return nil, err
+		}
+		skus = sortedStateKeys(selection.States)
+		selected = make(map[uint]map[string]struct{})
+		for sku, state := range selection.States {
+			for _, result := range append(append([]dto.AutoPromotionActionResult{}, state.OfficialResults...), state.ShopResults...) {
+				if selected[result.PromotionActionID] == nil {
+					selected[result.PromotionActionID] = make(map[string]struct{})
+				}
+				selected[result.PromotionActionID][sku] = struct{}{}
+			}
+		}
+		resp.ListingDateFrom = selection.ListingDateFrom.Format("2006-01-02")
+		resp.ListingDateTo = selection.ListingDateTo.Format("2006-01-02")
+	} else if len(skus) == 0 {
+		return nil, fmt.Errorf("请提供要模拟的 SKU 或选择自动加促销配置")
+	}
+
+	products, err := s.productRepo.FindBySourceSKUs(req.ShopID, skus)
+	if err != nil {
+		return nil, fmt.Errorf("查询商品失败: %w", err)
+	}
+	presentSKUs := make([]string, 0, len(skus))
+	for _, sku := range skus {
+		if _, exists := products[sku]; !exists {
+			resp.MissingSKUs = append(resp.MissingSKUs, sku)
+			continue
+		}
+		presentSKUs = append(presentSKUs, sku)
+	}
+	sort.Strings(presentSKUs)
+
+	officialActionIDs := actionIDsForActions(officialActions)
+	candidates, err := s.promotionRepo.ListActionCandidatesByActionIDsAndSourceSKUs(req.ShopID, actionIDsForActions(orderedActions), presentSKUs)
+	if err != nil {
+		return nil, fmt.Errorf("查询活动候选缓存失败: %w", err)
+	}
+	existing, err := s.promotionRepo.ListActionProductsByActionIDsAndSourceSKUs(req.ShopID, officialActionIDs, presentSKUs)
+	if err != nil {
+		return nil, fmt.Errorf("查询官方活动已报名缓存失败: %w", err)
+	}
+	costs := map[string]float64{}
+	if s.costRepo != nil {
+		if costs, err = s.costRepo.FindCostMap(req.ShopID); err != nil {
+			return nil, fmt.Errorf("查询成本价失败: %w", err)
+		}
+	}
+
+	candidateMap := groupCandidatesByActionAndSKU(candidates)
+	existingMap := groupActionProductsByActionAndSKU(existing)
+	resp.Items = make([]dto.PromotionSimulationItem, 0, len(orderedActions)*len(presentSKUs))
+	for _, action := range orderedActions {
+		for _, sku := range presentSKUs {
+			input := promotionSimulationInput{Product: products[sku], Cost: costs[sku]}
+			if item, ok := existingMap[action.ID][sku]; ok {
+				input.Existing = &item
+			}
+			if candidate, ok := candidateMap[action.ID][sku]; ok {
+				input.Candidate = &candidate
+			}
+			if selected != nil {
+				_, ok := selected[action.ID][sku]
+				input.RuleExcluded = !ok
+			}
+			resp.Items = append(resp.Items, simulatePromotionItem(action, input))
+		}
+	}
+	resp.Actions = summarizePromotionSimulation(orderedActions, resp.Items)
+	return resp, nil
+}
+
+type promotionSimulationInput struct {
+	Product model.Product
+	// Cost 成本价，0 表示未录入
+	Cost float64
+	// Existing 官方活动已报名缓存，Candidate 活动候选缓存
+	Existing  *model.PromotionActionProduct
+	Candidate *model.PromotionActionCandidate
+	// RuleExcluded 按配置模拟时该活动被选品规则排除，运行不会提交
+	RuleExcluded bool
+}
+
+// simulatePromotionItem 按执行时的同一套规则判断状态并选择活动价：
+// 官方活动已报名的取报名价，候选按 chooseOfficialActionPrice；店铺活动候选中 active 视为已在活动中
+func simulatePromotionItem(action model.PromotionAction, input promotionSimulationInput) dto.PromotionSimulationItem {
+	currentPrice := input.Product.CurrentPrice
+	item := dto.PromotionSimulationItem{
+		PromotionActionID: action.ID,
+		ActionTitle:       displayActionName(action),
+		Source:            action.Source,
+		SourceSKU:         input.Product.SourceSKU,
+		ProductName:       input.Product.Name,
+		CurrentPrice:      currentPrice,
+	}
+
+	switch {
+	case action.Source == "official" && input.Existing != nil:
+		item.Status = model.PromotionActionCandidateStatusAlreadyActive
+		item.MaxActionPrice = input.Existing.MaxActionPrice
+		item.ActionPrice = input.Existing.ActionPrice
+		if item.ActionPrice <= 0 {
+			item.ActionPrice = chooseOfficialActionPrice(currentPrice, 0, input.Existing.MaxActionPrice)
+		}
+	case input.Candidate != nil:
+		item.Status = model.PromotionActionCandidateStatusCandidate
+		if action.Source == "shop" && input.Candidate.Status == model.PromotionActionCandidateStatusActive {
+			item.Status = model.PromotionActionCandidateStatusAlreadyActive
+		}
+		item.MaxActionPrice = input.Candidate.MaxActionPrice
+		item.ActionPrice = chooseOfficialActionPrice(currentPrice, input.Candidate.ActionPrice, input.Candidate.MaxActionPrice)
+		if item.ActionPrice <= 0 {
+			item.Status = promotionSimulationStatusIneligible
+			item.Reason = "未找到合法的活动价"
+		} else if item.Status == model.PromotionActionCandidateStatusCandidate && input.RuleExcluded {
+			item.Status = promotionSimulationStatusIneligible
+			item.Reason = "不满足配置的选品规则"
+		}
+	default:
+		item.Status = promotionSimulationStatusIneligible
+		item.Reason = "不是该活动的候选商品"
+	}
+
+	if item.Status == promotionSimulationStatusIneligible {
+		item.ActionPrice = 0
+		return item
+	}
+	item.DiscountPercent = roundPercent(calculateDiscountPercent(currentPrice, item.ActionPrice))
+	if input.Cost > 0 {
+		item.HasCost = true
+		item.Cost = input.Cost
+		item.ProfitPerUnit = math.Round((item.ActionPrice-input.Cost)*100) / 100
+		item.MarginPercent = roundPercent((item.ActionPrice - input.Cost) / item.ActionPrice * 100)
+	}
+	return item
+}
+
+// summarizePromotionSimulation 按活动汇总；折扣与毛利为参与（候选及已在活动中）SKU 的算术平均
+func summarizePromotionSimulation(actions []model.PromotionAction, items []dto.PromotionSimulationItem) []dto.PromotionSimulationActionSummary {
+	summaries := make([]dto.PromotionSimulationActionSummary, 0, len(actions))
+	indexByAction := make(map[uint]int, len(actions))
+	for _, action := range actions {
+		indexByAction[action.ID] = len(summaries)
+		summaries = append(summaries, dto.PromotionSimulationActionSummary{
+			PromotionActionID: action.ID,
+			ActionID:          action.ActionID,
+			SourceActionID:    action.SourceActionID,
+			Title:             displayActionName(action),
+			Source:            action.Source,
+		})
+	}
+
+	discountTotals := make([]float64, len(summaries))
+	marginTotals := make([]float64, len(summaries))
+	for _, item := range items {
+		index, ok := indexByAction[item.PromotionActionID]
+		if !ok {
+			continue
+		}
+		summary := &summaries[index]
+		switch item.Status {
+		case model.PromotionActionCandidateStatusCandidate:
+			summary.CandidateCount++
+		case model.PromotionActionCandidateStatusAlreadyActive:
+			summary.AlreadyActiveCount++
+		default:
+			summary.IneligibleCount++
+			continue
+		}
+
+		discountTotals[index] += item.DiscountPercent
+		summary.CurrentRevenue += item.CurrentPrice
+		summary.ActionRevenue += item.ActionPrice
+		if item.HasCost {
+			summary.WithCostCount++
+			summary.ActionProfit += item.ProfitPerUnit
+			marginTotals[index] += item.MarginPercent
+			if item.ProfitPerUnit < 0 {
+				summary.LossCount++
+			}
+		}
+	}
+
+	for index := range summaries {
+		summary := &summaries[index]
+		if participating := summary.CandidateCount + summary.AlreadyActiveCount; participating > 0 {
+			summary.AvgDiscountPercent = roundPercent(discountTotals[index] / float64(participating))
+		}
+		if summary.WithCostCount > 0 {
+			summary.AvgMarginPercent = roundPercent(marginTotals[index] / float64(summary.WithCostCount))
+		}
+		summary.CurrentRevenue = math.Round(summary.CurrentRevenue*100) / 100
+		summary.ActionRevenue = math.Round(summary.ActionRevenue*100) / 100
+		summary.ActionProfit = math.Round(summary.ActionProfit*100) / 100
+	}
+	return summaries
+}
+
+func roundPercent(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"testing"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+)
+
+func TestSimulatePromotionItemOfficialCandidate(t *testing.T) {
+	t.Parallel()
+
+	action := model.PromotionAction{ID: 1, Source: "official", Title: "Spring"}
+	product := model.Product{SourceSKU: "SKU-1", CurrentPrice: 200}
+	item := simulatePromotionItem(action, promotionSimulationInput{
+		Product:   product,
+		Cost:      120,
+		Candidate: &model.PromotionActionCandidate{MaxActionPrice: 150},
+	})
+	if item.Status != model.PromotionActionCandidateStatusCandidate || item.ActionPrice != 150 {
+		t.Fatalf("item = %+v, want candidate at max action price", item)
+	}
+	if item.DiscountPercent != 25 || item.ProfitPerUnit != 30 || item.MarginPercent != 20 || !item.HasCost {
+		t.Fatalf("item = %+v, want 25%% discount and 20%% margin", item)
+	}
+
+	excluded := simulatePromotionItem(action, promotionSimulationInput{
+		Product:      product,
+		Candidate:    &model.PromotionActionCandidate{MaxActionPrice: 150},
+		RuleExcluded: true,
+	})
+	if excluded.Status != promotionSimulationStatusIneligible || excluded.ActionPrice != 0 {
+		t.Fatalf("rule excluded item = %+v, want ineligible", excluded)
+	}
+}
+
+func TestSimulatePromotionItemStatuses(t *testing.T) {
+	t.Parallel()
+
+	product := model.Product{SourceSKU: "SKU-1", CurrentPrice: 100}
+	official := model.PromotionAction{ID: 1, Source: "official"}
+	active := simulatePromotionItem(official, promotionSimulationInput{
+		Product:  product,
+		Existing: &model.PromotionActionProduct{ActionPrice: 90},
+	})
+	if active.Status != model.PromotionActionCandidateStatusAlreadyActive || active.ActionPrice != 90 || active.HasCost {
+		t.Fatalf("existing official item = %+v", active)
+	}
+
+	shop := model.PromotionAction{ID: 2, Source: "shop"}
+	shopActive := simulatePromotionItem(shop, promotionSimulationInput{
+		Product:   product,
+		Candidate: &model.PromotionActionCandidate{ActionPrice: 80, Status: model.PromotionActionCandidateStatusActive},
+	})
+	if shopActive.Status != model.PromotionActionCandidateStatusAlreadyActive || shopActive.DiscountPercent != 20 {
+		t.Fatalf("active shop candidate = %+v", shopActive)
+	}
+
+	if item := simulatePromotionItem(shop, promotionSimulationInput{Product: product}); item.Status != promotionSimulationStatusIneligible {
+		t.Fatalf("non-candidate = %+v, want ineligible", item)
+	}
+}
+
+func TestSummarizePromotionSimulation(t *testing.T) {
+	t.Parallel()
+
+	actions := []model.PromotionAction{{ID: 1, Source: "official", Title: "A"}, {ID: 2, Source: "shop", Title: "B"}}
+	items := []dto.PromotionSimulationItem{
+		{PromotionActionID: 1, Status: model.PromotionActionCandidateStatusCandidate, CurrentPrice: 100, ActionPrice: 80, DiscountPercent: 20, HasCost: true, ProfitPerUnit: 10, MarginPercent: 12.5},
+		{PromotionActionID: 1, Status: model.PromotionActionCandidateStatusAlreadyActive, CurrentPrice: 200, ActionPrice: 180, DiscountPercent: 10, HasCost: true, ProfitPerUnit: -20, MarginPercent: -11.11},
+		{PromotionActionID: 1, Status: promotionSimulationStatusIneligible, CurrentPrice: 300},
+		{PromotionActionID: 2, Status: model.PromotionActionCandidateStatusCandidate, CurrentPrice: 50, ActionPrice: 40, DiscountPercent: 20},
+	}
+
+	summaries := summarizePromotionSimulation(actions, items)
+	if len(summaries) != 2 {
+		t.Fatalf("summaries = %+v", summaries)
+	}
+	first := summaries[0]
+	if first.CandidateCount != 1 || first.AlreadyActiveCount != 1 || first.IneligibleCount != 1 {
+		t.Fatalf("counts = %+v", first)
+	}
+	if first.AvgDiscountPercent != 15 || first.CurrentRevenue != 300 || first.ActionRevenue != 260 {
+		t.Fatalf("revenue = %+v, ineligible rows must not count", first)
+	}
+	if first.WithCostCount != 2 || first.ActionProfit != -10 || first.LossCount != 1 || first.AvgMarginPercent != 0.7 {
+		t.Fatalf("margin = %+v", first)
+	}
+	if second := summaries[1]; second.WithCostCount != 0 || second.AvgMarginPercent != 0 || second.ActionRevenue != 40 {
+		t.Fatalf("second = %+v", second)
+	}
+}
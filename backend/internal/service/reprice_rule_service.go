@@ -0,0 +1,710 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+	"ozon-manager/internal/repository"
+	"ozon-manager/pkg/automation/protocol"
+	"ozon-manager/pkg/ozon"
+)
+
+const (
+	repriceRuleNameMaxLen = 100
+	repriceApplyMaxItems  = 5000
+
+	repriceDecisionChange    = "change"
+	repriceDecisionUnchanged = "unchanged"
+	repriceDecisionSkipped   = "skipped"
+)
+
+// RepriceRuleService 规则改价：按店铺规则、成本价与活动最高价计算建议价格，
+// 试运行返回差异供人工确认，确认后执行。在店铺活动中的商品通过“退出-改价-重新报名”任务改价，
+// 其余商品直接调用 Ozon 改价接口
+type RepriceRuleService struct {
+	ruleRepo         *repository.RepriceRuleRepository
+	productRepo      *repository.ProductRepository
+	promotionRepo    *repository.PromotionRepository
+	catalogRepo      *repository.OzonCatalogRepository
+	shopRepo         *repository.ShopRepository
+	shopGuard        *ShopGuard
+	promotionService *PromotionService
+	priceHistory     *PriceHistoryService
+}
+
+func NewRepriceRuleService(
+	ruleRepo *repository.RepriceRuleRepository,
+	productRepo *repository.ProductRepository,
+	promotionRepo *repository.PromotionRepository,
+	catalogRepo *repository.OzonCatalogRepository,
+	shopRepo *repository.ShopRepository,
+	automationService *AutomationService,
+	promotionService *PromotionService,
+	priceHistory *PriceHistoryService,
+) *RepriceRuleService {
+	return &RepriceRuleService{
+		ruleRepo:         ruleRepo,
+		productRepo:      productRepo,
+		promotionRepo:    promotionRepo,
+		catalogRepo:      catalogRepo,
+		shopRepo:         shopRepo,
+		shopGuard:        resolveShopGuard(shopRepo, automationService),
+		promotionService: promotionService,
+		priceHistory:     priceHistory,
+	}
+}
+
+func (s *RepriceRuleService) ListRules(shopID uint) ([]dto.RepriceRuleResponse, error) {
+	rules, err := s.ruleRepo.ListRulesByShop(shopID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]dto.RepriceRuleResponse, 0, len(rules))
+	for index := range rules {
+		items = append(items, toRepriceRuleDTO(&rules[index]))
+	}
+	return items, nil
+}
+
+func (s *RepriceRuleService) CreateRule(userID uint, req *dto.RepriceRuleRequest) (*dto.RepriceRuleResponse, error) {
+	return s.saveRule(req, &model.RepriceRule{ShopID: req.ShopID, CreatedBy: userID})
+}
+
+func (s *RepriceRuleService) UpdateRule(req *dto.RepriceRuleRequest) (*dto.RepriceRuleResponse, error) {
+	rule, err := s.ruleRepo.FindRuleByIDAndShop(req.ID, req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	return s.saveRule(req, rule)
+}
+
+func (s *RepriceRuleService) DeleteRule(shopID, ruleID uint) error {
+	deleted, err := s.ruleRepo.DeleteRule(ruleID, shopID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *RepriceRuleService) saveRule(req *dto.RepriceRuleRequest, rule *model.RepriceRule) (*dto.RepriceRuleResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("规则名称不能为空")
+	}
+	if utf8.RuneCountInString(name) > repriceRuleNameMaxLen {
+		return nil, fmt.Errorf("规则名称不能超过 %d 个字符", repriceRuleNameMaxLen)
+	}
+	switch req.PriceEnding {
+	case model.RepricePriceEndingNone, model.RepricePriceEnding90, model.RepricePriceEnding99:
+	default:
+		return nil, fmt.Errorf("不支持的价格尾数: %s", req.PriceEnding)
+	}
+	if req.TargetMarginPercent < 0 || req.TargetMarginPercent >= 100 {
+		return nil, fmt.Errorf("目标毛利率应在 0 到 100 之间")
+	}
+	if req.MinPrice > 0 && req.MaxPrice > 0 && req.MinPrice > req.MaxPrice {
+		return nil, fmt.Errorf("最低价不能高于最高价")
+	}
+	if req.TargetMarginPercent == 0 && !req.KeepActionEligible && req.PriceEnding == "" && req.MinPrice == 0 && req.MaxPrice == 0 {
+		return nil, fmt.Errorf("规则至少需要设置目标毛利率、活动限价、价格尾数或价格上下限之一")
+	}
+
+	others, err := s.ruleRepo.ListRulesByShop(req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range others {
+		if other.ID != rule.ID && other.Name == name {
+			return nil, fmt.Errorf("规则名称「%s」已存在", name)
+		}
+	}
+
+	rule.Name = name
+	rule.Enabled = req.Enabled
+	rule.Priority = req.Priority
+	rule.SKUPrefix = strings.TrimSpace(req.SKUPrefix)
+	rule.TargetMarginPercent = req.TargetMarginPercent
+	rule.KeepActionEligible = req.KeepActionEligible
+	rule.PriceEnding = req.PriceEnding
+	rule.MinPrice = req.MinPrice
+	rule.MaxPrice = req.MaxPrice
+	if rule.ID == 0 {
+		err = s.ruleRepo.CreateRule(rule)
+	} else {
+		err = s.ruleRepo.UpdateRule(rule)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp := toRepriceRuleDTO(rule)
+	return &resp, nil
+}
+
+// SaveCosts 批量写入成本价，成本为 0 的 SKU 删除已有成本价
+func (s *RepriceRuleService) SaveCosts(userID uint, req *dto.SaveProductCostsRequest) (*dto.SaveProductCostsResponse, error) {
+	costs := make([]model.ProductCost, 0, len(req.Items))
+	removed := make([]string, 0)
+	seen := make(map[string]struct{}, len(req.Items))
+	for _, item := range req.Items {
+		sku := strings.TrimSpace(item.SourceSKU)
+		if sku == "" {
+			return nil, fmt.Errorf("SKU 不能为空")
+		}
+		if _, exists := seen[sku]; exists {
+			return nil, fmt.Errorf("SKU %s 重复", sku)
+		}
+		seen[sku] = struct{}{}
+		if item.Cost <= 0 {
+			removed = append(removed, sku)
+			continue
+		}
+		costs = append(costs, model.ProductCost{
+			ShopID:    req.ShopID,
+			SourceSKU: sku,
+			Cost:      math.Round(item.Cost*100) / 100,
+			UpdatedBy: userID,
+		})
+	}
+
+	if err := s.ruleRepo.UpsertCosts(costs); err != nil {
+		return nil, err
+	}
+	if err := s.ruleRepo.DeleteCosts(req.ShopID, removed); err != nil {
+		return nil, err
+	}
+	return &dto.SaveProductCostsResponse{SavedCount: len(costs), DeletedCount: len(removed)}, nil
+}
+
+func (s *RepriceRuleService) ListCosts(req *dto.ProductCostListRequest) (*dto.ProductCostListResponse, error) {
+	costs, total, err := s.ruleRepo.ListCosts(req.ShopID, strings.TrimSpace(req.Keyword), req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]dto.ProductCostItem, 0, len(costs))
+	for _, cost := range costs {
+		items = append(items, dto.ProductCostItem{
+			SourceSKU: cost.SourceSKU,
+			Cost:      cost.Cost,
+			UpdatedAt: cost.UpdatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return &dto.ProductCostListResponse{
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Items:    items,
+	}, nil
+}
+
+// Preview 试运行：按规则计算每个商品的建议价格，不改价
+func (s *RepriceRuleService) Preview(req *dto.RepricePreviewRequest) (*dto.RepricePreviewResponse, error) {
+	rules, err := s.selectRules(req.ShopID, req.RuleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var products []model.Product
+	if skus := uniqueSKUs(req.SourceSKUs); len(skus) > 0 {
+		found, findErr := s.productRepo.FindBySourceSKUs(req.ShopID, skus)
+		if findErr != nil {
+			return nil, findErr
+		}
+		for _, product := range found {
+			products = append(products, product)
+		}
+	} else {
+		products, err = s.productRepo.FindByShopID(req.ShopID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].SourceSKU < products[j].SourceSKU })
+
+	productIDs := make([]int64, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.OzonProductID)
+	}
+	catalog, err := s.catalogRepo.FindExistingByProductIDs(req.ShopID, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	costs, err := s.ruleRepo.FindCostMap(req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	exposure, err := s.loadActionExposure(req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	pendingLoss, err := s.pendingLossProducts(req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.RepricePreviewResponse{Items: make([]dto.RepriceProposal, 0)}
+	for _, product := range products {
+		if product.Status == "archived" {
+			continue
+		}
+		rule := matchRepriceRule(rules, product.SourceSKU)
+		if rule == nil {
+			continue
+		}
+
+		currentPrice := product.CurrentPrice
+		if item, ok := catalog[product.OzonProductID]; ok && item.Price > 0 {
+			currentPrice = item.Price
+		}
+		input := repriceInput{
+			CurrentPrice:  currentPrice,
+			Cost:          costs[product.SourceSKU],
+			ActionCeiling: exposure.ceilings[product.SourceSKU],
+		}
+		outcome := evaluateRepriceRule(rule, input)
+		if _, ok := pendingLoss[product.ID]; ok && outcome.Decision == repriceDecisionChange {
+			outcome = repriceOutcome{Decision: repriceDecisionSkipped, Note: "商品有待处理的亏损记录，以亏损处理改价为准"}
+		}
+
+		resp.Summary.Evaluated++
+		switch outcome.Decision {
+		case repriceDecisionChange:
+			resp.Summary.Changed++
+		case repriceDecisionUnchanged:
+			resp.Summary.Unchanged++
+		default:
+			resp.Summary.Skipped++
+		}
+		inShopActions := len(exposure.shopActions[product.SourceSKU]) > 0
+		if inShopActions && outcome.Decision == repriceDecisionChange {
+			resp.Summary.InShopActions++
+		}
+		if req.OnlyChanges && outcome.Decision != repriceDecisionChange {
+			continue
+		}
+		resp.Items = append(resp.Items, dto.RepriceProposal{
+			ProductID:     product.ID,
+			SourceSKU:     product.SourceSKU,
+			Name:          product.Name,
+			RuleID:        rule.ID,
+			RuleName:      rule.Name,
+			CurrentPrice:  currentPrice,
+			Cost:          input.Cost,
+			ActionCeiling: input.ActionCeiling,
+			ProposedPrice: outcome.Price,
+			Decision:      outcome.Decision,
+			Note:          outcome.Note,
+			InShopActions: inShopActions,
+		})
+	}
+	return resp, nil
+}
+
+// Apply 执行人工确认后的价格：在店铺活动中的商品按所在活动分组创建 remove_reprice_readd 任务，
+// 其余商品直接调用 Ozon 改价接口并记录价格流水
+func (s *RepriceRuleService) Apply(userID uint, req *dto.RepriceApplyRequest) (*dto.RepriceApplyResponse, error) {
+	if err := s.shopGuard.CheckWritable(req.ShopID); err != nil {
+		return nil, err
+	}
+	if len(req.Items) > repriceApplyMaxItems {
+		return nil, fmt.Errorf("单次最多执行 %d 个商品", repriceApplyMaxItems)
+	}
+
+	prices := make(map[string]float64, len(req.Items))
+	skus := make([]string, 0, len(req.Items))
+	for _, item := range req.Items {
+		sku := strings.TrimSpace(item.SourceSKU)
+		if sku == "" {
+			return nil, fmt.Errorf("SKU 不能为空")
+		}
+		if _, exists := prices[sku]; exists {
+			return nil, fmt.Errorf("SKU %s 重复", sku)
+		}
+		prices[sku] = math.Round(item.NewPrice*100) / 100
+		skus = append(skus, sku)
+	}
+
+	products, err := s.productRepo.FindBySourceSKUs(req.ShopID, skus)
+	if err != nil {
+		return nil, err
+	}
+	exposure, err := s.loadActionExposure(req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	pendingLoss, err := s.pendingLossProducts(req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.RepriceApplyResponse{JobIDs: []uint{}, Failures: []dto.RepriceApplyFailure{}}
+	direct := make([]model.Product, 0)
+	groups := make(map[string][]dto.RepriceItem)
+	groupActions := make(map[string][]model.PromotionAction)
+	targets := make([]bulkOperationTarget, 0, len(skus))
+	for _, sku := range skus {
+		product, ok := products[sku]
+		if !ok {
+			resp.Failures = append(resp.Failures, dto.RepriceApplyFailure{SourceSKU: sku, Message: "本地商品不存在，请先同步商品"})
+			continue
+		}
+		if _, ok := pendingLoss[product.ID]; ok {
+			resp.Failures = append(resp.Failures, dto.RepriceApplyFailure{SourceSKU: sku, Message: "商品有待处理的亏损记录，以亏损处理改价为准"})
+			continue
+		}
+		actions := exposure.shopActions[sku]
+		targets = append(targets, bulkOperationTarget{Product: product, ShopActions: actions})
+		if len(actions) == 0 {
+			direct = append(direct, product)
+			continue
+		}
+		key := shopActionGroupKey(actions)
+		groups[key] = append(groups[key], dto.RepriceItem{SourceSKU: sku, NewPrice: prices[sku]})
+		groupActions[key] = actions
+	}
+
+	operation, err := s.promotionService.snapshotBulkOperation(userID, req.ShopID, model.BulkOperationTypeRepriceRule, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		job, createErr := s.promotionService.createRemoveRepriceReaddJob(userID, req.ShopID, groups[key], &protocol.RemoveRepriceReaddMeta{
+			Reason:      "reprice_rule",
+			ShopActions: buildShopActionsMeta(groupActions[key]),
+		})
+		if createErr != nil {
+			for _, item := range groups[key] {
+				resp.Failures = append(resp.Failures, dto.RepriceApplyFailure{SourceSKU: item.SourceSKU, Message: "创建改价任务失败: " + createErr.Error()})
+			}
+			continue
+		}
+		resp.JobIDs = append(resp.JobIDs, job.ID)
+		resp.JobItemCount += len(groups[key])
+	}
+	s.promotionService.linkBulkOperationJobs(operation, resp.JobIDs...)
+
+	if len(direct) > 0 {
+		updated, failures, pushErr := s.pushDirectPrices(userID, req.ShopID, direct, prices)
+		if pushErr != nil {
+			return nil, pushErr
+		}
+		resp.DirectUpdated = updated
+		resp.Failures = append(resp.Failures, failures...)
+	}
+
+	resp.Message = fmt.Sprintf("直接改价 %d 个，创建 %d 个活动改价任务（%d 个商品），失败 %d 个",
+		resp.DirectUpdated, len(resp.JobIDs), resp.JobItemCount, len(resp.Failures))
+	return resp, nil
+}
+
+// pushDirectPrices 分批调用 Ozon 改价接口，成功的商品更新本地售价并记录价格流水
+func (s *RepriceRuleService) pushDirectPrices(userID, shopID uint, products []model.Product, prices map[string]float64) (int, []dto.RepriceApplyFailure, error) {
+	shop, err := s.shopRepo.GetWithCredentials(shopID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("shop not found: %w", err)
+	}
+	client := s.shopGuard.OzonClient(shop)
+
+	updated := 0
+	failures := make([]dto.RepriceApplyFailure, 0)
+	for start := 0; start < len(products); start += pricePlanBatchSize {
+		end := start + pricePlanBatchSize
+		if end > len(products) {
+			end = len(products)
+		}
+		batch := products[start:end]
+
+		items := make([]ozon.PriceItem, 0, len(batch))
+		for _, product := range batch {
+			items = append(items, ozon.PriceItem{
+				ProductID: product.OzonProductID,
+				Price:     strconv.FormatFloat(prices[product.SourceSKU], 'f', 2, 64),
+			})
+		}
+		resp, err := client.UpdatePrices(items)
+		results := make(map[int64]ozon.PriceUpdateResult)
+		if err == nil {
+			for _, result := range resp.Result {
+				results[result.ProductID] = result
+			}
+		}
+
+		history := make([]model.PriceHistory, 0, len(batch))
+		for _, product := range batch {
+			result, ok := results[product.OzonProductID]
+			switch {
+			case err != nil:
+				failures = append(failures, dto.RepriceApplyFailure{SourceSKU: product.SourceSKU, Message: err.Error()})
+			case !ok:
+				failures = append(failures, dto.RepriceApplyFailure{SourceSKU: product.SourceSKU, Message: "Ozon 未返回改价结果"})
+			case !result.Updated:
+				message := "Ozon 拒绝改价"
+				if len(result.Errors) > 0 {
+					message = result.Errors[0].Message
+				}
+				failures = append(failures, dto.RepriceApplyFailure{SourceSKU: product.SourceSKU, Message: message})
+			default:
+				newPrice := prices[product.SourceSKU]
+				_ = s.productRepo.UpdatePrice(product.ID, newPrice)
+				history = append(history, appliedPriceChange(product, newPrice, model.PriceSourceRepriceRule, userIDPointer(userID), "reprice_rule"))
+				updated++
+			}
+		}
+		s.priceHistory.Record(history...)
+	}
+	return updated, failures, nil
+}
+
+// selectRules 返回指定的规则；未指定时返回全部已启用规则，均按匹配顺序排列
+func (s *RepriceRuleService) selectRules(shopID uint, ruleIDs []uint) ([]model.RepriceRule, error) {
+	all, err := s.ruleRepo.ListRulesByShop(shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]model.RepriceRule, 0, len(all))
+	if len(ruleIDs) == 0 {
+		for _, rule := range all {
+			if rule.Enabled {
+				selected = append(selected, rule)
+			}
+		}
+	} else {
+		wanted := make(map[uint]struct{}, len(ruleIDs))
+		for _, id := range ruleIDs {
+			wanted[id] = struct{}{}
+		}
+		for _, rule := range all {
+			if _, ok := wanted[rule.ID]; ok {
+				selected = append(selected, rule)
+			}
+		}
+		if len(selected) != len(wanted) {
+			return nil, fmt.Errorf("部分规则不存在")
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("没有可用的改价规则，请先创建并启用规则")
+	}
+	return selected, nil
+}
+
+// repriceActionExposure 商品在进行中活动里的限价与店铺活动参与情况
+type repriceActionExposure struct {
+	// ceilings SKU → 所在及候选活动中最低的最高活动价
+	ceilings map[string]float64
+	// shopActions SKU → 当前参与的店铺活动
+	shopActions map[string][]model.PromotionAction
+}
+
+func (s *RepriceRuleService) loadActionExposure(shopID uint) (*repriceActionExposure, error) {
+	actions, err := s.promotionRepo.FindActivePromotionActions(shopID)
+	if err != nil {
+		return nil, err
+	}
+	actionsByID := make(map[uint]model.PromotionAction, len(actions))
+	actionIDs := make([]uint, 0, len(actions))
+	for _, action := range actions {
+		actionsByID[action.ID] = action
+		actionIDs = append(actionIDs, action.ID)
+	}
+	actionProducts, candidates, err := s.promotionRepo.ListActionPriceCeilings(shopID, actionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	exposure := &repriceActionExposure{
+		ceilings:    make(map[string]float64),
+		shopActions: make(map[string][]model.PromotionAction),
+	}
+	for _, row := range actionProducts {
+		exposure.ceilings[row.SourceSKU] = lowerCeiling(exposure.ceilings[row.SourceSKU], row.MaxActionPrice)
+		if action := actionsByID[row.PromotionActionID]; action.Source == "shop" {
+			exposure.shopActions[row.SourceSKU] = append(exposure.shopActions[row.SourceSKU], action)
+		}
+	}
+	for _, row := range candidates {
+		if row.Status == model.PromotionActionCandidateStatusInactive {
+			continue
+		}
+		exposure.ceilings[row.SourceSKU] = lowerCeiling(exposure.ceilings[row.SourceSKU], row.MaxActionPrice)
+	}
+	return exposure, nil
+}
+
+func (s *RepriceRuleService) pendingLossProducts(shopID uint) (map[uint]struct{}, error) {
+	lossProducts, err := s.promotionRepo.FindUnprocessedLossProducts(shopID)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]struct{}, len(lossProducts))
+	for _, lp := range lossProducts {
+		result[lp.ProductID] = struct{}{}
+	}
+	return result, nil
+}
+
+// lowerCeiling 取两个限价中较低的一个，0 表示无限价
+func lowerCeiling(current, candidate float64) float64 {
+	if candidate <= 0 {
+		return current
+	}
+	if current <= 0 || candidate < current {
+		return candidate
+	}
+	return current
+}
+
+// shopActionGroupKey 同一组店铺活动的商品合并到一个任务，任务内每个商品都会退出并重新报名组内全部活动
+func shopActionGroupKey(actions []model.PromotionAction) string {
+	ids := make([]int, 0, len(actions))
+	for _, action := range actions {
+		ids = append(ids, int(action.ID))
+	}
+	sort.Ints(ids)
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.Itoa(id))
+	}
+	return strings.Join(parts, ",")
+}
+
+// matchRepriceRule 按顺序返回第一条 SKU 前缀匹配的规则
+func matchRepriceRule(rules []model.RepriceRule, sku string) *model.RepriceRule {
+	for index := range rules {
+		if rules[index].SKUPrefix == "" || strings.HasPrefix(sku, rules[index].SKUPrefix) {
+			return &rules[index]
+		}
+	}
+	return nil
+}
+
+type repriceInput struct {
+	CurrentPrice  float64
+	Cost          float64
+	ActionCeiling float64
+}
+
+type repriceOutcome struct {
+	Price    float64
+	Decision string
+	Note     string
+}
+
+// evaluateRepriceRule 计算单个商品的建议价格：
+//  1. 设置了目标毛利率时按 成本 / (1 - 毛利率) 定价，否则以当前售价为基准；
+//  2. 上限取最高价与（开启活动限价时）最高活动价中较低者，超过上限时压到上限；低于最低价时抬到最低价；
+//  3. 按价格尾数取整，被上限压价时向下取整，否则向上取整，取整后仍须在上下限之内
+func evaluateRepriceRule(rule *model.RepriceRule, input repriceInput) repriceOutcome {
+	base := input.CurrentPrice
+	if rule.TargetMarginPercent > 0 {
+		if input.Cost <= 0 {
+			return repriceOutcome{Decision: repriceDecisionSkipped, Note: "缺少成本价"}
+		}
+		base = input.Cost / (1 - rule.TargetMarginPercent/100)
+	}
+	if base <= 0 {
+		return repriceOutcome{Decision: repriceDecisionSkipped, Note: "缺少当前售价"}
+	}
+
+	upper := rule.MaxPrice
+	if rule.KeepActionEligible {
+		upper = lowerCeiling(upper, input.ActionCeiling)
+	}
+	lower := rule.MinPrice
+	if upper > 0 && lower > upper {
+		return repriceOutcome{Decision: repriceDecisionSkipped, Note: fmt.Sprintf("最低价 %.2f 高于上限 %.2f", lower, upper)}
+	}
+
+	price := base
+	roundDown := false
+	note := ""
+	if upper > 0 && price > upper {
+		price = upper
+		roundDown = true
+		note = fmt.Sprintf("受上限 %.2f 限制", upper)
+	}
+	if lower > 0 && price < lower {
+		price = lower
+		note = fmt.Sprintf("按最低价 %.2f", lower)
+	}
+
+	price = applyPriceEnding(price, rule.PriceEnding, roundDown)
+	if upper > 0 && price > upper+0.001 {
+		price = applyPriceEnding(upper, rule.PriceEnding, true)
+	}
+	if price <= 0 || (lower > 0 && price < lower-0.001) {
+		return repriceOutcome{Decision: repriceDecisionSkipped, Note: fmt.Sprintf("上下限之间没有尾数为 %s 的价格", rule.PriceEnding)}
+	}
+
+	if rule.TargetMarginPercent > 0 && price < base-0.001 {
+		marginNote := fmt.Sprintf("毛利率 %.1f%% 低于目标", (price-input.Cost)/price*100)
+		if note == "" {
+			note = marginNote
+		} else {
+			note += "，" + marginNote
+		}
+	}
+	if !priceChanged(input.CurrentPrice, price) {
+		return repriceOutcome{Price: price, Decision: repriceDecisionUnchanged, Note: note}
+	}
+	return repriceOutcome{Price: price, Decision: repriceDecisionChange, Note: note}
+}
+
+// applyPriceEnding 把价格调整为指定尾数：向下取整得到不高于原价的 X.90 / X.99，向上取整得到不低于原价的
+func applyPriceEnding(price float64, ending string, down bool) float64 {
+	price = math.Round(price*100) / 100
+	var fraction float64
+	switch ending {
+	case model.RepricePriceEnding90:
+		fraction = 0.90
+	case model.RepricePriceEnding99:
+		fraction = 0.99
+	default:
+		return price
+	}
+
+	candidate := math.Floor(price) + fraction
+	if down {
+		if candidate > price+0.001 {
+			candidate--
+		}
+	} else if candidate < price-0.001 {
+		candidate++
+	}
+	return math.Round(candidate*100) / 100
+}
+
+func toRepriceRuleDTO(rule *model.RepriceRule) dto.RepriceRuleResponse {
+	return dto.RepriceRuleResponse{
+		ID:                  rule.ID,
+		ShopID:              rule.ShopID,
+		Name:                rule.Name,
+		Enabled:             rule.Enabled,
+		Priority:            rule.Priority,
+		SKUPrefix:           rule.SKUPrefix,
+		TargetMarginPercent: rule.TargetMarginPercent,
+		KeepActionEligible:  rule.KeepActionEligible,
+		PriceEnding:         rule.PriceEnding,
+		MinPrice:            rule.MinPrice,
+		MaxPrice:            rule.MaxPrice,
+		CreatedBy:           rule.CreatedBy,
+		CreatedAt:           rule.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:           rule.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"ozon-manager/internal/model"
+)
+
+func TestApplyPriceEnding(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		price  float64
+		ending string
+		down   bool
+		want   float64
+	}{
+		{123.45, model.RepricePriceEndingNone, false, 123.45},
+		{123.45, model.RepricePriceEnding90, false, 123.90},
+		{123.95, model.RepricePriceEnding90, false, 124.90},
+		{123.95, model.RepricePriceEnding90, true, 123.90},
+		{123.50, model.RepricePriceEnding99, true, 122.99},
+		{123.99, model.RepricePriceEnding99, true, 123.99},
+	}
+	for _, tc := range cases {
+		if got := applyPriceEnding(tc.price, tc.ending, tc.down); got != tc.want {
+			t.Fatalf("applyPriceEnding(%v, %q, %v) = %v, want %v", tc.price, tc.ending, tc.down, got, tc.want)
+		}
+	}
+}
+
+func TestEvaluateRepriceRuleTargetMargin(t *testing.T) {
+	t.Parallel()
+
+	rule := &model.RepriceRule{TargetMarginPercent: 20, PriceEnding: model.RepricePriceEnding90}
+	outcome := evaluateRepriceRule(rule, repriceInput{CurrentPrice: 100, Cost: 80})
+	if outcome.Decision != repriceDecisionChange || outcome.Price != 100.90 {
+		t.Fatalf("outcome = %+v, want change to 100.90", outcome)
+	}
+
+	if outcome := evaluateRepriceRule(rule, repriceInput{CurrentPrice: 100}); outcome.Decision != repriceDecisionSkipped {
+		t.Fatalf("missing cost should skip, got %+v", outcome)
+	}
+}
+
+func TestEvaluateRepriceRuleKeepsActionEligible(t *testing.T) {
+	t.Parallel()
+
+	rule := &model.RepriceRule{TargetMarginPercent: 30, KeepActionEligible: true, PriceEnding: model.RepricePriceEnding99}
+	outcome := evaluateRepriceRule(rule, repriceInput{CurrentPrice: 150, Cost: 100, ActionCeiling: 130.5})
+	if outcome.Decision != repriceDecisionChange || outcome.Price != 129.99 {
+		t.Fatalf("outcome = %+v, want 129.99 under action ceiling", outcome)
+	}
+	if !strings.Contains(outcome.Note, "毛利率") {
+		t.Fatalf("capped price should note the reduced margin, got %q", outcome.Note)
+	}
+
+	rule.KeepActionEligible = false
+	if outcome := evaluateRepriceRule(rule, repriceInput{CurrentPrice: 150, Cost: 100, ActionCeiling: 130.5}); outcome.Price != 142.99 {
+		t.Fatalf("ceiling ignored when disabled, got %+v", outcome)
+	}
+}
+
+func TestEvaluateRepriceRuleBounds(t *testing.T) {
+	t.Parallel()
+
+	rule := &model.RepriceRule{MinPrice: 200, MaxPrice: 300}
+	if outcome := evaluateRepriceRule(rule, repriceInput{CurrentPrice: 150}); outcome.Price != 200 || outcome.Decision != repriceDecisionChange {
+		t.Fatalf("below min = %+v", outcome)
+	}
+	if outcome := evaluateRepriceRule(rule, repriceInput{CurrentPrice: 250}); outcome.Decision != repriceDecisionUnchanged {
+		t.Fatalf("within bounds = %+v", outcome)
+	}
+
+	narrow := &model.RepriceRule{MinPrice: 100.10, MaxPrice: 100.50, PriceEnding: model.RepricePriceEnding90}
+	if outcome := evaluateRepriceRule(narrow, repriceInput{CurrentPrice: 120}); outcome.Decision != repriceDecisionSkipped {
+		t.Fatalf("no valid ending between bounds should skip, got %+v", outcome)
+	}
+}
+
+func TestMatchRepriceRuleByPrefixAndOrder(t *testing.T) {
+	t.Parallel()
+
+	rules := []model.RepriceRule{
+		{ID: 1, SKUPrefix: "AB-"},
+		{ID: 2},
+	}
+	if rule := matchRepriceRule(rules, "AB-001"); rule == nil || rule.ID != 1 {
+		t.Fatalf("prefix rule should match first, got %+v", rule)
+	}
+	if rule := matchRepriceRule(rules, "CD-001"); rule == nil || rule.ID != 2 {
+		t.Fatalf("catch-all rule should match, got %+v", rule)
+	}
+	if rule := matchRepriceRule(rules[:1], "CD-001"); rule != nil {
+		t.Fatalf("unmatched SKU should have no rule, got %+v", rule)
+	}
+}
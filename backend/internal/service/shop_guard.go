@@ -0,0 +1,483 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+	"ozon-manager/internal/repository"
+	"ozon-manager/pkg/ozon"
+)
+
+// 触发熔断的故障类别，其余失败（数据不满足条件等）不计入
+const (
+	shopFailureClassAuth        = "auth"
+	shopFailureClassRateLimit   = "rate_limit"
+	shopFailureClassUnavailable = "unavailable"
+)
+
+// shopFailureStatusPattern 错误信息中的 HTTP 状态码，须带 status / HTTP 前缀（Ozon API 为 "API error (status 401)"），
+// 避免 SKU、价格等数字被当成状态码
+var shopFailureStatusPattern = regexp.MustCompile(`(?:status(?: code)?|http(?:/[\d.]+)?)\s*[:=]?\s*(\d{3})\b`)
+
+// 按状态码归类，未列出的状态码不计入熔断
+var shopFailureStatusClasses = map[string]string{
+	"401": shopFailureClassAuth,
+	"403": shopFailureClassAuth,
+	"429": shopFailureClassRateLimit,
+	"500": shopFailureClassUnavailable,
+	"502": shopFailureClassUnavailable,
+	"503": shopFailureClassUnavailable,
+	"504": shopFailureClassUnavailable,
+}
+
+// 登录态失效 / 凭证无效；只收录完整短语，页面文案中出现的 login、forbidden 等单词不计入
+var shopAuthFailureMarkers = []string{
+	"unauthorized", "403 forbidden",
+	"not logged in", "login required", "please login", "please log in", "session expired",
+	"未登录", "登录失效", "登录已过期", "会话过期", "会话已过期",
+}
+
+var shopRateLimitFailureMarkers = []string{
+	"429 too many requests", "too many requests", "rate limit exceeded", "rate limited", "限流",
+}
+
+// 网络、超时、Ozon 服务端或浏览器不可用
+var shopUnavailableFailureMarkers = []string{
+	"timeout", "timed out", "超时",
+	"failed to execute request", "connection refused", "connection reset", "no such host",
+	"net::", "econnreset", "econnrefused", "socket hang up",
+	"network error", "network is unreachable", "网络错误", "网络异常", "网络连接失败",
+	"bad gateway", "service unavailable", "gateway timeout",
+	"target closed", "browser has been closed",
+}
+
+// classifyShopFailure 将执行端或 Ozon API 的错误归入熔断故障类别，无法归类返回空字符串。
+// 先按状态码归类，再按短语匹配
+func classifyShopFailure(message string) string {
+	normalized := strings.ToLower(strings.TrimSpace(message))
+	if normalized == "" {
+		return ""
+	}
+	for _, match := range shopFailureStatusPattern.FindAllStringSubmatch(normalized, -1) {
+		if class, ok := shopFailureStatusClasses[match[1]]; ok {
+			return class
+		}
+	}
+	groups := []struct {
+		class   string
+		markers []string
+	}{
+		{shopFailureClassAuth, shopAuthFailureMarkers},
+		{shopFailureClassRateLimit, shopRateLimitFailureMarkers},
+		{shopFailureClassUnavailable, shopUnavailableFailureMarkers},
+	}
+	for _, group := range groups {
+		for _, marker := range group.markers {
+			if strings.Contains(normalized, marker) {
+				return group.class
+			}
+		}
+	}
+	return ""
+}
+
+// ShopCircuitBreakerOptions 店铺熔断参数
+type ShopCircuitBreakerOptions struct {
+	// Threshold 同一来源同类故障连续出现的次数，达到后熔断
+	Threshold int
+	// Cooldown 熔断持续时间，到期后放行试探：成功即恢复，再次失败立即重新熔断
+	Cooldown time.Duration
+}
+
+var defaultShopCircuitBreakerOptions = ShopCircuitBreakerOptions{
+	Threshold: 5,
+	Cooldown:  30 * time.Minute,
+}
+
+// shopWritePauseCacheTTL 暂停开关的缓存时间；本实例修改开关时立即失效，其他实例最多延迟该时长生效
+const shopWritePauseCacheTTL = 30 * time.Second
+
+// ShopBlockedError 店铺暂停写入或处于熔断中，操作被拒绝
+type ShopBlockedError struct {
+	ShopID uint
+	Reason string
+}
+
+func (e *ShopBlockedError) Error() string {
+	return e.Reason
+}
+
+type shopBreakerKey struct {
+	shopID uint
+	source string
+}
+
+type shopWritePause struct {
+	paused   bool
+	reason   string
+	loadedAt time.Time
+}
+
+type shopFailureStreak struct {
+	class     string
+	count     int
+	lastError string
+}
+
+// ShopGuard 店铺级写保护：手动暂停写操作开关，以及按来源（执行端 / Ozon API）统计连续故障的熔断器。
+// 连续计数只保存在内存中，熔断状态持久化到 shop_circuit_breakers，重启后仍然生效。
+type ShopGuard struct {
+	shopRepo *repository.ShopRepository
+	options  ShopCircuitBreakerOptions
+	now      func() time.Time
+	findShop func(shopID uint) (*model.Shop, error)
+
+	mu       sync.Mutex
+	loaded   bool
+	streaks  map[shopBreakerKey]*shopFailureStreak
+	breakers map[shopBreakerKey]model.ShopCircuitBreaker
+	// pauses 暂停开关缓存，避免调度扫描与批量写操作逐次查询店铺
+	pauses map[uint]shopWritePause
+}
+
+func NewShopGuard(shopRepo *repository.ShopRepository, options ShopCircuitBreakerOptions) *ShopGuard {
+	if options.Threshold <= 0 {
+		options.Threshold = defaultShopCircuitBreakerOptions.Threshold
+	}
+	if options.Cooldown <= 0 {
+		options.Cooldown = defaultShopCircuitBreakerOptions.Cooldown
+	}
+	guard := &ShopGuard{
+		shopRepo: shopRepo,
+		options:  options,
+		now:      time.Now,
+		streaks:  make(map[shopBreakerKey]*shopFailureStreak),
+		breakers: make(map[shopBreakerKey]model.ShopCircuitBreaker),
+		pauses:   make(map[uint]shopWritePause),
+	}
+	if shopRepo != nil {
+		guard.findShop = shopRepo.FindByID
+	}
+	return guard
+}
+
+// ensureLoadedLocked 首次使用时从数据库加载熔断状态，调用方需持有 mu
+func (g *ShopGuard) ensureLoadedLocked() {
+	if g.loaded || g.shopRepo == nil {
+		return
+	}
+	breakers, err := g.shopRepo.ListCircuitBreakers()
+	if err != nil {
+		return
+	}
+	for _, breaker := range breakers {
+		g.breakers[shopBreakerKey{shopID: breaker.ShopID, source: breaker.Source}] = breaker
+	}
+	g.loaded = true
+}
+
+// openBreaker 返回仍在熔断期内的记录
+func (g *ShopGuard) openBreaker(shopID uint, source string) (model.ShopCircuitBreaker, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.ensureLoadedLocked()
+	breaker, ok := g.breakers[shopBreakerKey{shopID: shopID, source: source}]
+	if !ok || !g.now().Before(breaker.OpenUntil) {
+		return model.ShopCircuitBreaker{}, false
+	}
+	return breaker, true
+}
+
+// writesPaused 读取暂停开关，shopWritePauseCacheTTL 内复用上次查询结果
+func (g *ShopGuard) writesPaused(shopID uint) (bool, string) {
+	if g.findShop == nil {
+		return false, ""
+	}
+	now := g.now()
+	g.mu.Lock()
+	cached, ok := g.pauses[shopID]
+	g.mu.Unlock()
+	if ok && now.Sub(cached.loadedAt) < shopWritePauseCacheTTL {
+		return cached.paused, cached.reason
+	}
+
+	shop, err := g.findShop(shopID)
+	if err != nil {
+		return false, ""
+	}
+	pause := shopWritePause{paused: shop.WritesPaused, reason: strings.TrimSpace(shop.WritesPausedReason), loadedAt: now}
+	g.mu.Lock()
+	g.pauses[shopID] = pause
+	g.mu.Unlock()
+	return pause.paused, pause.reason
+}
+
+func pausedError(shopID uint, reason string) error {
+	message := "店铺已暂停自动写操作"
+	if reason != "" {
+		message += "：" + reason
+	}
+	return &ShopBlockedError{ShopID: shopID, Reason: message}
+}
+
+func breakerError(breaker model.ShopCircuitBreaker) error {
+	target := "执行端"
+	if breaker.Source == model.ShopFailureSourceOzonAPI {
+		target = "Ozon API"
+	}
+	return &ShopBlockedError{
+		ShopID: breaker.ShopID,
+		Reason: fmt.Sprintf("店铺%s连续 %d 次 %s 故障已熔断，%s 后自动试探恢复", target, breaker.ConsecutiveFailures, breaker.FailureClass, breaker.OpenUntil.Format("2006-01-02 15:04:05")),
+	}
+}
+
+// CheckWritable 校验能否直接调用 Ozon 写接口或创建写类任务：暂停写入或 Ozon API 熔断时拒绝
+func (g *ShopGuard) CheckWritable(shopID uint) error {
+	if g == nil {
+		return nil
+	}
+	if paused, reason := g.writesPaused(shopID); paused {
+		return pausedError(shopID, reason)
+	}
+	if breaker, open := g.openBreaker(shopID, model.ShopFailureSourceOzonAPI); open {
+		return breakerError(breaker)
+	}
+	return nil
+}
+
+// CheckDispatch 校验任务能否派发给执行端：执行端熔断时全部暂停，暂停写入时只放行只读同步任务
+func (g *ShopGuard) CheckDispatch(shopID uint, jobType string) error {
+	if g == nil {
+		return nil
+	}
+	if breaker, open := g.openBreaker(shopID, model.ShopFailureSourceExecutor); open {
+		return breakerError(breaker)
+	}
+	if automationIdempotentJobTypes[jobType] {
+		return nil
+	}
+	if paused, reason := g.writesPaused(shopID); paused {
+		return pausedError(shopID, reason)
+	}
+	return nil
+}
+
+// DispatchableJobTypes 过滤出当前可派发给该店铺执行端的任务类型
+func (g *ShopGuard) DispatchableJobTypes(shopID uint, jobTypes []string) []string {
+	if g == nil {
+		return jobTypes
+	}
+	if _, open := g.openBreaker(shopID, model.ShopFailureSourceExecutor); open {
+		return nil
+	}
+	if paused, _ := g.writesPaused(shopID); !paused {
+		return jobTypes
+	}
+	result := make([]string, 0, len(jobTypes))
+	for _, jobType := range jobTypes {
+		if automationIdempotentJobTypes[jobType] {
+			result = append(result, jobType)
+		}
+	}
+	return result
+}
+
+// CheckScheduledRun 定时任务（自动加促销）需要执行端与 Ozon API 均可用且未暂停写入
+func (g *ShopGuard) CheckScheduledRun(shopID uint) error {
+	if g == nil {
+		return nil
+	}
+	if err := g.CheckWritable(shopID); err != nil {
+		return err
+	}
+	if breaker, open := g.openBreaker(shopID, model.ShopFailureSourceExecutor); open {
+		return breakerError(breaker)
+	}
+	return nil
+}
+
+// RecordFailure 记录一次失败；同一来源同类故障连续达到阈值时熔断，试探期内再次失败立即重新熔断
+func (g *ShopGuard) RecordFailure(shopID uint, source, message string) {
+	if g == nil || shopID == 0 {
+		return
+	}
+	class := classifyShopFailure(message)
+	key := shopBreakerKey{shopID: shopID, source: source}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.ensureLoadedLocked()
+
+	if class == "" {
+		delete(g.streaks, key)
+		return
+	}
+
+	now := g.now()
+	if breaker, ok := g.breakers[key]; ok {
+		if now.Before(breaker.OpenUntil) || breaker.FailureClass != class {
+			return
+		}
+		breaker.ConsecutiveFailures++
+		breaker.LastError = message
+		breaker.OpenedAt = now
+		breaker.OpenUntil = now.Add(g.options.Cooldown)
+		g.persistLocked(key, breaker)
+		return
+	}
+
+	streak, ok := g.streaks[key]
+	if !ok || streak.class != class {
+		streak = &shopFailureStreak{class: class}
+		g.streaks[key] = streak
+	}
+	streak.count++
+	streak.lastError = message
+	if streak.count < g.options.Threshold {
+		return
+	}
+
+	delete(g.streaks, key)
+	g.persistLocked(key, model.ShopCircuitBreaker{
+		ShopID:              shopID,
+		Source:              source,
+		FailureClass:        class,
+		ConsecutiveFailures: streak.count,
+		LastError:           streak.lastError,
+		OpenedAt:            now,
+		OpenUntil:           now.Add(g.options.Cooldown),
+	})
+}
+
+func (g *ShopGuard) persistLocked(key shopBreakerKey, breaker model.ShopCircuitBreaker) {
+	g.breakers[key] = breaker
+	if g.shopRepo != nil {
+		_ = g.shopRepo.UpsertCircuitBreaker(&breaker)
+	}
+}
+
+// RecordSuccess 记录一次成功，清空连续故障计数并关闭该来源的熔断
+func (g *ShopGuard) RecordSuccess(shopID uint, source string) {
+	if g == nil || shopID == 0 {
+		return
+	}
+	key := shopBreakerKey{shopID: shopID, source: source}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.ensureLoadedLocked()
+
+	delete(g.streaks, key)
+	if _, ok := g.breakers[key]; !ok {
+		return
+	}
+	delete(g.breakers, key)
+	if g.shopRepo != nil {
+		_ = g.shopRepo.DeleteCircuitBreakers(shopID, source)
+	}
+}
+
+// OzonClient 创建店铺的 Ozon API 客户端，请求结果计入该店铺的 Ozon API 熔断统计
+func (g *ShopGuard) OzonClient(shop *model.Shop) *ozon.Client {
+	client := ozon.NewClient(shop.ClientID, shop.ApiKey)
+	if g == nil {
+		return client
+	}
+	shopID := shop.ID
+	return client.WithObserver(func(err error) {
+		if err != nil {
+			g.RecordFailure(shopID, model.ShopFailureSourceOzonAPI, err.Error())
+			return
+		}
+		g.RecordSuccess(shopID, model.ShopFailureSourceOzonAPI)
+	})
+}
+
+// PauseWrites 手动暂停 / 恢复店铺全部自动写操作
+func (g *ShopGuard) PauseWrites(shopID uint, paused bool, reason string, userID uint) error {
+	if !paused {
+		reason = ""
+	}
+	if err := g.shopRepo.UpdateWritesPaused(shopID, paused, strings.TrimSpace(reason), &userID); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	delete(g.pauses, shopID)
+	g.mu.Unlock()
+	return nil
+}
+
+// ResetBreakers 人工关闭店铺熔断，source 为空时关闭全部来源
+func (g *ShopGuard) ResetBreakers(shopID uint, source string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.ensureLoadedLocked()
+
+	if err := g.shopRepo.DeleteCircuitBreakers(shopID, source); err != nil {
+		return err
+	}
+	for key := range g.breakers {
+		if key.shopID == shopID && (source == "" || key.source == source) {
+			delete(g.breakers, key)
+		}
+	}
+	for key := range g.streaks {
+		if key.shopID == shopID && (source == "" || key.source == source) {
+			delete(g.streaks, key)
+		}
+	}
+	return nil
+}
+
+// Status 返回店铺的暂停开关与熔断状态
+func (g *ShopGuard) Status(shop *model.Shop) *dto.ShopGuardStatus {
+	status := &dto.ShopGuardStatus{
+		ShopID:             shop.ID,
+		WritesPaused:       shop.WritesPaused,
+		WritesPausedReason: shop.WritesPausedReason,
+		Breakers:           []dto.ShopCircuitBreakerStatus{},
+	}
+	if shop.WritesPausedAt != nil {
+		status.WritesPausedAt = shop.WritesPausedAt.Format("2006-01-02 15:04:05")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.ensureLoadedLocked()
+
+	now := g.now()
+	for _, source := range []string{model.ShopFailureSourceExecutor, model.ShopFailureSourceOzonAPI} {
+		key := shopBreakerKey{shopID: shop.ID, source: source}
+		item := dto.ShopCircuitBreakerStatus{Source: source, State: "closed"}
+		if breaker, ok := g.breakers[key]; ok {
+			item.State = "half_open"
+			if now.Before(breaker.OpenUntil) {
+				item.State = "open"
+			}
+			item.FailureClass = breaker.FailureClass
+			item.ConsecutiveFailures = breaker.ConsecutiveFailures
+			item.LastError = breaker.LastError
+			item.OpenedAt = breaker.OpenedAt.Format("2006-01-02 15:04:05")
+			item.OpenUntil = breaker.OpenUntil.Format("2006-01-02 15:04:05")
+		} else if streak, ok := g.streaks[key]; ok {
+			item.FailureClass = streak.class
+			item.ConsecutiveFailures = streak.count
+			item.LastError = streak.lastError
+		}
+		status.Breakers = append(status.Breakers, item)
+	}
+	return status
+}
+
+// resolveShopGuard 优先复用自动化服务上的保护器，保证熔断状态在各服务之间共享
+func resolveShopGuard(shopRepo *repository.ShopRepository, automationService *AutomationService) *ShopGuard {
+	if guard := automationService.ShopGuard(); guard != nil {
+		return guard
+	}
+	return NewShopGuard(shopRepo, defaultShopCircuitBreakerOptions)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"ozon-manager/internal/model"
+)
+
+func newTestShopGuard(threshold int, now *time.Time) *ShopGuard {
+	guard := NewShopGuard(nil, ShopCircuitBreakerOptions{Threshold: threshold, Cooldown: 10 * time.Minute})
+	guard.now = func() time.Time { return *now }
+	return guard
+}
+
+func TestClassifyShopFailure(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"获取候选商品失败: 403 Forbidden":                   shopFailureClassAuth,
+		"API error: status 401, body: unauthorized": shopFailureClassAuth,
+		"API error: status 429, body: {}":           shopFailureClassRateLimit,
+		"timeout: job exceeded 30m without report":  shopFailureClassUnavailable,
+		"failed to execute request: EOF":            shopFailureClassUnavailable,
+		"API error (status 503): upstream":          shopFailureClassUnavailable,
+		"request failed with status code 429":       shopFailureClassRateLimit,
+		"not logged in: please login manually":      shopFailureClassAuth,
+		"API error (status 400): invalid sku 4291":  "",
+		"button forbidden in current page layout":   "",
+		"login link text changed on action page":    "",
+		"network tab selector missing":              "",
+		"商品不在候选列表中":                                 "",
+		"":                                          "",
+	}
+	for message, want := range cases {
+		if got := classifyShopFailure(message); got != want {
+			t.Errorf("classifyShopFailure(%q) = %q, want %q", message, got, want)
+		}
+	}
+}
+
+func TestShopGuardOpensAfterConsecutiveFailuresOfSameClass(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	guard := newTestShopGuard(3, &now)
+
+	guard.RecordFailure(1, model.ShopFailureSourceExecutor, "status 503")
+	guard.RecordFailure(1, model.ShopFailureSourceExecutor, "status 502")
+	if err := guard.CheckDispatch(1, model.AutomationJobTypeShopActionDeclare); err != nil {
+		t.Fatalf("breaker opened before threshold: %v", err)
+	}
+
+	guard.RecordFailure(1, model.ShopFailureSourceExecutor, "timeout")
+	err := guard.CheckDispatch(1, model.AutomationJobTypeSyncActionCandidates)
+	var blocked *ShopBlockedError
+	if !errors.As(err, &blocked) || blocked.ShopID != 1 {
+		t.Fatalf("CheckDispatch() = %v, want ShopBlockedError", err)
+	}
+	if got := guard.DispatchableJobTypes(1, []string{model.AutomationJobTypeSyncActionCandidates}); len(got) != 0 {
+		t.Fatalf("DispatchableJobTypes() = %v, want none", got)
+	}
+	if err := guard.CheckWritable(1); err != nil {
+		t.Fatalf("executor breaker should not block ozon api writes: %v", err)
+	}
+	if err := guard.CheckScheduledRun(1); err == nil {
+		t.Fatalf("executor breaker should block scheduled runs")
+	}
+	if err := guard.CheckDispatch(2, model.AutomationJobTypeShopActionDeclare); err != nil {
+		t.Fatalf("breaker leaked to another shop: %v", err)
+	}
+}
+
+func TestShopGuardStreakResetsOnDifferentClassOrSuccess(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	guard := newTestShopGuard(2, &now)
+
+	guard.RecordFailure(1, model.ShopFailureSourceOzonAPI, "status 429")
+	guard.RecordFailure(1, model.ShopFailureSourceOzonAPI, "status 401")
+	if err := guard.CheckWritable(1); err != nil {
+		t.Fatalf("different failure classes should not open breaker: %v", err)
+	}
+
+	guard.RecordSuccess(1, model.ShopFailureSourceOzonAPI)
+	guard.RecordFailure(1, model.ShopFailureSourceOzonAPI, "status 401")
+	if err := guard.CheckWritable(1); err != nil {
+		t.Fatalf("success should reset streak: %v", err)
+	}
+
+	guard.RecordFailure(1, model.ShopFailureSourceOzonAPI, "unauthorized")
+	if err := guard.CheckWritable(1); err == nil {
+		t.Fatalf("expected ozon api breaker to open")
+	}
+}
+
+func TestShopGuardHalfOpenReopensOnFailureAndClosesOnSuccess(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	guard := newTestShopGuard(1, &now)
+
+	guard.RecordFailure(1, model.ShopFailureSourceExecutor, "net::ERR_CONNECTION_RESET")
+	if err := guard.CheckDispatch(1, model.AutomationJobTypeShopActionDeclare); err == nil {
+		t.Fatalf("expected breaker to open")
+	}
+
+	now = now.Add(11 * time.Minute)
+	if err := guard.CheckDispatch(1, model.AutomationJobTypeShopActionDeclare); err != nil {
+		t.Fatalf("half-open breaker should allow a probe: %v", err)
+	}
+	guard.RecordFailure(1, model.ShopFailureSourceExecutor, "net::ERR_TIMED_OUT")
+	if err := guard.CheckDispatch(1, model.AutomationJobTypeShopActionDeclare); err == nil {
+		t.Fatalf("failed probe should reopen breaker")
+	}
+
+	now = now.Add(11 * time.Minute)
+	guard.RecordSuccess(1, model.ShopFailureSourceExecutor)
+	status := guard.Status(&model.Shop{ID: 1})
+	for _, breaker := range status.Breakers {
+		if breaker.State != "closed" {
+			t.Fatalf("breaker %s state = %s, want closed", breaker.Source, breaker.State)
+		}
+	}
+}
+
+func TestShopGuardCachesWritePauseFlag(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	guard := newTestShopGuard(3, &now)
+	lookups := 0
+	paused := true
+	guard.findShop = func(shopID uint) (*model.Shop, error) {
+		lookups++
+		return &model.Shop{ID: shopID, WritesPaused: paused, WritesPausedReason: " 大促冻结 "}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		var blocked *ShopBlockedError
+		if err := guard.CheckWritable(1); !errors.As(err, &blocked) || blocked.Reason != "店铺已暂停自动写操作：大促冻结" {
+			t.Fatalf("CheckWritable() = %v, want paused error", err)
+		}
+	}
+	if lookups != 1 {
+		t.Fatalf("lookups = %d, want one per cache period", lookups)
+	}
+
+	paused = false
+	now = now.Add(shopWritePauseCacheTTL)
+	if err := guard.CheckWritable(1); err != nil {
+		t.Fatalf("CheckWritable() after cache expiry = %v, want nil", err)
+	}
+	if lookups != 2 {
+		t.Fatalf("lookups = %d, want reload after expiry", lookups)
+	}
+}
@@ -21,14 +21,23 @@ var (
 )
 
 type ShopService struct {
-	shopRepo *repository.ShopRepository
-	userRepo *repository.UserRepository
+	shopRepo  *repository.ShopRepository
+	userRepo  *repository.UserRepository
+	shopGuard *ShopGuard
 }
 
 func NewShopService(shopRepo *repository.ShopRepository, userRepo *repository.UserRepository) *ShopService {
 	return &ShopService{
-		shopRepo: shopRepo,
-		userRepo: userRepo,
+		shopRepo:  shopRepo,
+		userRepo:  userRepo,
+		shopGuard: NewShopGuard(shopRepo, defaultShopCircuitBreakerOptions),
+	}
+}
+
+// ConfigureShopGuard 注入与自动化服务共享的店铺写保护器
+func (s *ShopService) ConfigureShopGuard(guard *ShopGuard) {
+	if guard != nil {
+		s.shopGuard = guard
 	}
 }
 
@@ -387,6 +396,48 @@ func (s *ShopService) UpdateMyShopExecutionEngine(shopID uint, ownerID uint, mod
 	}, nil
 }
 
+func (s *ShopService) findMyShop(shopID uint, ownerID uint) (*model.Shop, error) {
+	shop, err := s.shopRepo.FindByID(shopID)
+	if err != nil {
+		return nil, ErrShopNotFound
+	}
+	if shop.OwnerID != ownerID {
+		return nil, ErrShopNotBelongToYou
+	}
+	return shop, nil
+}
+
+// GetMyShopGuard 获取店铺暂停写入开关与熔断状态
+func (s *ShopService) GetMyShopGuard(shopID uint, ownerID uint) (*dto.ShopGuardStatus, error) {
+	shop, err := s.findMyShop(shopID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.shopGuard.Status(shop), nil
+}
+
+// UpdateMyShopWritePause 暂停 / 恢复店铺全部自动写操作
+func (s *ShopService) UpdateMyShopWritePause(shopID uint, ownerID uint, req *dto.UpdateShopWritePauseRequest) (*dto.ShopGuardStatus, error) {
+	if _, err := s.findMyShop(shopID, ownerID); err != nil {
+		return nil, err
+	}
+	if err := s.shopGuard.PauseWrites(shopID, *req.Paused, req.Reason, ownerID); err != nil {
+		return nil, err
+	}
+	return s.GetMyShopGuard(shopID, ownerID)
+}
+
+// ResetMyShopCircuitBreakers 人工关闭店铺熔断，source 为空时关闭全部来源
+func (s *ShopService) ResetMyShopCircuitBreakers(shopID uint, ownerID uint, source string) (*dto.ShopGuardStatus, error) {
+	if _, err := s.findMyShop(shopID, ownerID); err != nil {
+		return nil, err
+	}
+	if err := s.shopGuard.ResetBreakers(shopID, source); err != nil {
+		return nil, err
+	}
+	return s.GetMyShopGuard(shopID, ownerID)
+}
+
 func normalizeShopClientID(clientID string) (string, error) {
 	trimmed := strings.TrimSpace(clientID)
 	if trimmed == "" {
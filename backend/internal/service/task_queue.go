@@ -0,0 +1,425 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"ozon-manager/internal/model"
+	"ozon-manager/internal/repository"
+)
+
+const (
+	taskQueueJanitorInterval = time.Hour
+	// taskQueueReleaseGrace 排空超时后取消执行中的任务，再等待其退还队列的时间
+	taskQueueReleaseGrace = 5 * time.Second
+)
+
+// backgroundTaskStore 后台任务的持久化存储，*repository.BackgroundTaskRepository 即满足该接口
+type backgroundTaskStore interface {
+	Create(task *model.BackgroundTask) error
+	Claim(taskTypes []string, workerID string, now time.Time) (*model.BackgroundTask, error)
+	Extend(taskID uint, workerID string, lockedUntil time.Time) (bool, error)
+	Complete(taskID uint, workerID string, now time.Time) error
+	Retry(taskID uint, workerID string, runAt time.Time, message string) error
+	Fail(taskID uint, workerID string, now time.Time, message string) error
+	Release(taskID uint, workerID string, now time.Time, message string) error
+	DeleteFinishedBefore(before time.Time) (int64, error)
+}
+
+// TaskHandler 后台任务处理函数，返回错误时按任务类型的重试策略重新排队。
+// 服务停止时 ctx 会被取消，处理函数应尽快返回，任务将退还队列在下次启动后重新执行。
+type TaskHandler func(ctx context.Context, payload json.RawMessage) error
+
+// TypedTaskHandler 将处理函数包装为 TaskHandler，载荷按 JSON 解码为 T，解码失败不重试
+func TypedTaskHandler[T any](fn func(ctx context.Context, payload T) error) TaskHandler {
+	return func(ctx context.Context, raw json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return PermanentTaskError(fmt.Errorf("invalid task payload: %w", err))
+		}
+		return fn(ctx, payload)
+	}
+}
+
+type permanentTaskError struct {
+	err error
+}
+
+func (e *permanentTaskError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentTaskError) Unwrap() error {
+	return e.err
+}
+
+// PermanentTaskError 标记不可重试的错误，任务直接置为 failed
+func PermanentTaskError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentTaskError{err: err}
+}
+
+// TaskOptions 单个任务类型的执行策略
+type TaskOptions struct {
+	// MaxAttempts 含首次执行在内的最多执行次数
+	MaxAttempts int
+	// Timeout 可见性超时：执行中每隔 Timeout/3 续期一次，进程崩溃后超过该时间任务可被重新领取
+	Timeout   time.Duration
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// OnFailed 任务最终失败（不可重试或次数用尽）后回调，供业务收尾因此停滞的状态
+	OnFailed func(payload json.RawMessage, message string)
+}
+
+var defaultTaskOptions = TaskOptions{
+	MaxAttempts: 3,
+	Timeout:     5 * time.Minute,
+	BaseDelay:   10 * time.Second,
+	MaxDelay:    10 * time.Minute,
+}
+
+func (o TaskOptions) withDefaults() TaskOptions {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = defaultTaskOptions.MaxAttempts
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTaskOptions.Timeout
+	}
+	if o.BaseDelay <= 0 {
+		o.BaseDelay = defaultTaskOptions.BaseDelay
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = defaultTaskOptions.MaxDelay
+	}
+	return o
+}
+
+// retryDelay 第 attempt 次执行失败后的重试间隔：BaseDelay 按次数翻倍，不超过 MaxDelay
+func (o TaskOptions) retryDelay(attempt int) time.Duration {
+	delay := o.BaseDelay
+	for i := 1; i < attempt; i++ {
+		if delay >= o.MaxDelay/2 {
+			return o.MaxDelay
+		}
+		delay *= 2
+	}
+	if delay > o.MaxDelay {
+		return o.MaxDelay
+	}
+	return delay
+}
+
+// TaskQueueOptions 队列运行参数
+type TaskQueueOptions struct {
+	Workers      int
+	PollInterval time.Duration
+	// Retention 成功任务的保留时间，失败任务不自动清理
+	Retention time.Duration
+}
+
+var defaultTaskQueueOptions = TaskQueueOptions{
+	Workers:      4,
+	PollInterval: time.Second,
+	Retention:    7 * 24 * time.Hour,
+}
+
+type registeredTask struct {
+	options TaskOptions
+	handler TaskHandler
+}
+
+// TaskQueue 基于 PostgreSQL（SKIP LOCKED）的进程内后台任务队列：任务先落库再执行，
+// 失败按类型重试，执行中定期续期可见性超时，停止时排空执行中的任务。
+type TaskQueue struct {
+	store   backgroundTaskStore
+	options TaskQueueOptions
+	// instanceID 本进程标识（主机名-进程号），worker 标识在其后追加序号
+	instanceID string
+	now        func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string]registeredTask
+	started  bool
+
+	wake     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewTaskQueue(taskRepo *repository.BackgroundTaskRepository, options TaskQueueOptions) *TaskQueue {
+	return newTaskQueue(taskRepo, options)
+}
+
+func newTaskQueue(store backgroundTaskStore, options TaskQueueOptions) *TaskQueue {
+	if options.Workers <= 0 {
+		options.Workers = defaultTaskQueueOptions.Workers
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = defaultTaskQueueOptions.PollInterval
+	}
+	if options.Retention <= 0 {
+		options.Retention = defaultTaskQueueOptions.Retention
+	}
+	hostname, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TaskQueue{
+		store:      store,
+		options:    options,
+		instanceID: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		now:        time.Now,
+		handlers:   make(map[string]registeredTask),
+		wake:       make(chan struct{}, options.Workers),
+		stop:       make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Register 注册任务类型的处理函数，需在 Start 之前完成
+func (q *TaskQueue) Register(taskType string, options TaskOptions, handler TaskHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[taskType] = registeredTask{options: options.withDefaults(), handler: handler}
+}
+
+// workerID 单个 worker 的租约标识，同一进程内的 worker 互不相同
+func (q *TaskQueue) workerID(index int) string {
+	return fmt.Sprintf("%s-%d", q.instanceID, index)
+}
+
+func (q *TaskQueue) registered(taskType string) (registeredTask, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	task, ok := q.handlers[taskType]
+	return task, ok
+}
+
+func (q *TaskQueue) taskTypes() []string {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	types := make([]string, 0, len(q.handlers))
+	for taskType := range q.handlers {
+		types = append(types, taskType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Enqueue 持久化一个待执行任务并唤醒空闲 worker
+func (q *TaskQueue) Enqueue(taskType string, payload interface{}) error {
+	task, ok := q.registered(taskType)
+	if !ok {
+		return fmt.Errorf("unknown task type: %s", taskType)
+	}
+	select {
+	case <-q.stop:
+		return fmt.Errorf("task queue is shutting down")
+	default:
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("invalid task payload: %w", err)
+	}
+	if err := q.store.Create(&model.BackgroundTask{
+		TaskType:       taskType,
+		Payload:        raw,
+		Status:         model.BackgroundTaskStatusPending,
+		MaxAttempts:    task.options.MaxAttempts,
+		TimeoutSeconds: int(task.options.Timeout / time.Second),
+		RunAt:          q.now(),
+	}); err != nil {
+		return err
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start 启动 worker 与已完成任务的清理
+func (q *TaskQueue) Start() {
+	q.mu.Lock()
+	if q.started {
+		q.mu.Unlock()
+		return
+	}
+	q.started = true
+	q.mu.Unlock()
+
+	for index := 0; index < q.options.Workers; index++ {
+		q.wg.Add(1)
+		go q.runWorker(q.workerID(index))
+	}
+	q.wg.Add(1)
+	go q.runJanitor()
+}
+
+// Shutdown 停止领取新任务并等待执行中的任务完成；ctx 到期时取消执行中的任务，
+// 被中断的任务退还队列（不计入执行次数），下次启动后重新执行
+func (q *TaskQueue) Shutdown(ctx context.Context) error {
+	q.stopOnce.Do(func() { close(q.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+	}
+
+	q.cancel()
+	select {
+	case <-done:
+	case <-time.After(taskQueueReleaseGrace):
+	}
+	return ctx.Err()
+}
+
+func (q *TaskQueue) stopping() bool {
+	select {
+	case <-q.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *TaskQueue) runWorker(workerID string) {
+	defer q.wg.Done()
+	ticker := time.NewTicker(q.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for !q.stopping() && q.processNext(workerID) {
+		}
+		select {
+		case <-q.stop:
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *TaskQueue) runJanitor() {
+	defer q.wg.Done()
+	ticker := time.NewTicker(taskQueueJanitorInterval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = q.store.DeleteFinishedBefore(q.now().Add(-q.options.Retention))
+		select {
+		case <-q.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processNext 领取并执行一个任务，没有可领取的任务时返回 false
+func (q *TaskQueue) processNext(workerID string) bool {
+	task, err := q.store.Claim(q.taskTypes(), workerID, q.now())
+	if err != nil || task == nil {
+		return false
+	}
+	q.execute(task, workerID)
+	return true
+}
+
+func (q *TaskQueue) execute(task *model.BackgroundTask, workerID string) {
+	registered, ok := q.registered(task.TaskType)
+	if !ok {
+		_ = q.store.Fail(task.ID, workerID, q.now(), "unknown task type: "+task.TaskType)
+		return
+	}
+	// 可见性超时后被重新领取，次数已用尽
+	if task.Attempts > task.MaxAttempts {
+		message := fmt.Sprintf("任务执行 %d 次后仍未完成（可见性超时）", task.MaxAttempts)
+		if task.LastError != "" {
+			message += ": " + task.LastError
+		}
+		q.fail(task, workerID, registered.options, message)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(q.ctx)
+	defer cancel()
+	done := make(chan struct{})
+	go q.keepAlive(task.ID, workerID, registered.options.Timeout, cancel, done)
+	err := invokeTaskHandler(ctx, registered.handler, json.RawMessage(task.Payload))
+	close(done)
+
+	now := q.now()
+	var permanent *permanentTaskError
+	switch {
+	case err == nil:
+		_ = q.store.Complete(task.ID, workerID, now)
+	case q.ctx.Err() != nil:
+		_ = q.store.Release(task.ID, workerID, now, "服务停止，任务已退还队列: "+err.Error())
+	case errors.As(err, &permanent) || task.Attempts >= task.MaxAttempts:
+		q.fail(task, workerID, registered.options, err.Error())
+	default:
+		_ = q.store.Retry(task.ID, workerID, now.Add(registered.options.retryDelay(task.Attempts)), err.Error())
+	}
+}
+
+// fail 任务置为最终失败并通知任务类型的 OnFailed 回调
+func (q *TaskQueue) fail(task *model.BackgroundTask, workerID string, options TaskOptions, message string) {
+	if err := q.store.Fail(task.ID, workerID, q.now(), message); err != nil {
+		return
+	}
+	if options.OnFailed != nil {
+		options.OnFailed(json.RawMessage(task.Payload), message)
+	}
+}
+
+// keepAlive 执行期间定期续期；租约被他人接管时取消本次执行
+func (q *TaskQueue) keepAlive(taskID uint, workerID string, timeout time.Duration, cancel context.CancelFunc, done <-chan struct{}) {
+	interval := timeout / 3
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			owned, err := q.store.Extend(taskID, workerID, q.now().Add(timeout))
+			if err == nil && !owned {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func invokeTaskHandler(ctx context.Context, handler TaskHandler, payload json.RawMessage) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return handler(ctx, payload)
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"ozon-manager/internal/model"
+)
+
+// memoryTaskStore 内存版任务存储，语义与 BackgroundTaskRepository 一致
+type memoryTaskStore struct {
+	mu     sync.Mutex
+	nextID uint
+	tasks  map[uint]*model.BackgroundTask
+}
+
+func newMemoryTaskStore() *memoryTaskStore {
+	return &memoryTaskStore{tasks: make(map[uint]*model.BackgroundTask)}
+}
+
+func (m *memoryTaskStore) Create(task *model.BackgroundTask) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	task.ID = m.nextID
+	copied := *task
+	m.tasks[task.ID] = &copied
+	return nil
+}
+
+func (m *memoryTaskStore) Claim(taskTypes []string, workerID string, now time.Time) (*model.BackgroundTask, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id := uint(1); id <= m.nextID; id++ {
+		task, ok := m.tasks[id]
+		if !ok || !containsString(taskTypes, task.TaskType) {
+			continue
+		}
+		due := task.Status == model.BackgroundTaskStatusPending && !task.RunAt.After(now)
+		expired := task.Status == model.BackgroundTaskStatusRunning && task.LockedUntil != nil && task.LockedUntil.Before(now)
+		if !due && !expired {
+			continue
+		}
+		lockedUntil := now.Add(time.Duration(task.TimeoutSeconds) * time.Second)
+		task.Status = model.BackgroundTaskStatusRunning
+		task.Attempts++
+		task.LockedBy = workerID
+		task.LockedUntil = &lockedUntil
+		copied := *task
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (m *memoryTaskStore) owned(taskID uint, workerID string) *model.BackgroundTask {
+	task, ok := m.tasks[taskID]
+	if !ok || task.Status != model.BackgroundTaskStatusRunning || task.LockedBy != workerID {
+		return nil
+	}
+	return task
+}
+
+func (m *memoryTaskStore) Extend(taskID uint, workerID string, lockedUntil time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	task := m.owned(taskID, workerID)
+	if task == nil {
+		return false, nil
+	}
+	task.LockedUntil = &lockedUntil
+	return true, nil
+}
+
+func (m *memoryTaskStore) finish(taskID uint, workerID, status string, runAt *time.Time, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	task := m.owned(taskID, workerID)
+	if task == nil {
+		return
+	}
+	task.Status = status
+	task.LockedBy = ""
+	task.LockedUntil = nil
+	task.LastError = message
+	if runAt != nil {
+		task.RunAt = *runAt
+	}
+}
+
+func (m *memoryTaskStore) Complete(taskID uint, workerID string, now time.Time) error {
+	m.finish(taskID, workerID, model.BackgroundTaskStatusSucceeded, nil, "")
+	return nil
+}
+
+func (m *memoryTaskStore) Retry(taskID uint, workerID string, runAt time.Time, message string) error {
+	m.finish(taskID, workerID, model.BackgroundTaskStatusPending, &runAt, message)
+	return nil
+}
+
+func (m *memoryTaskStore) Fail(taskID uint, workerID string, now time.Time, message string) error {
+	m.finish(taskID, workerID, model.BackgroundTaskStatusFailed, nil, message)
+	return nil
+}
+
+func (m *memoryTaskStore) Release(taskID uint, workerID string, now time.Time, message string) error {
+	m.mu.Lock()
+	if task := m.owned(taskID, workerID); task != nil && task.Attempts > 0 {
+		task.Attempts--
+	}
+	m.mu.Unlock()
+	m.finish(taskID, workerID, model.BackgroundTaskStatusPending, &now, message)
+	return nil
+}
+
+func (m *memoryTaskStore) DeleteFinishedBefore(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *memoryTaskStore) get(taskID uint) model.BackgroundTask {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return *m.tasks[taskID]
+}
+
+type testTaskPayload struct {
+	Value string `json:"value"`
+}
+
+func TestTaskQueueCompletesTypedTask(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryTaskStore()
+	queue := newTaskQueue(store, TaskQueueOptions{Workers: 1})
+	var got string
+	queue.Register("test", TaskOptions{}, TypedTaskHandler(func(ctx context.Context, payload testTaskPayload) error {
+		got = payload.Value
+		return nil
+	}))
+
+	if err := queue.Enqueue("test", testTaskPayload{Value: "hello"}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if !queue.processNext(queue.workerID(0)) {
+		t.Fatalf("processNext() found no task")
+	}
+	if got != "hello" {
+		t.Fatalf("payload = %q, want hello", got)
+	}
+	if task := store.get(1); task.Status != model.BackgroundTaskStatusSucceeded {
+		t.Fatalf("status = %s, want succeeded", task.Status)
+	}
+	if queue.processNext(queue.workerID(0)) {
+		t.Fatalf("succeeded task was claimed again")
+	}
+}
+
+func TestTaskQueueWorkersHoldDistinctLeases(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryTaskStore()
+	queue := newTaskQueue(store, TaskQueueOptions{Workers: 2})
+	var lockedBy string
+	queue.Register("test", TaskOptions{}, func(ctx context.Context, payload json.RawMessage) error {
+		lockedBy = store.get(1).LockedBy
+		return nil
+	})
+
+	if queue.workerID(0) == queue.workerID(1) {
+		t.Fatalf("workers share lease id %q", queue.workerID(0))
+	}
+	if err := queue.Enqueue("test", testTaskPayload{}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	queue.processNext(queue.workerID(1))
+	if lockedBy != queue.workerID(1) {
+		t.Fatalf("locked_by = %q, want %q", lockedBy, queue.workerID(1))
+	}
+}
+
+func TestTaskOptionsRetryDelay(t *testing.T) {
+	t.Parallel()
+
+	options := TaskOptions{BaseDelay: 10 * time.Second, MaxDelay: time.Minute}
+	cases := map[int]time.Duration{
+		0: 10 * time.Second,
+		1: 10 * time.Second,
+		2: 20 * time.Second,
+		3: 40 * time.Second,
+		4: time.Minute,
+		9: time.Minute,
+	}
+	for attempt, want := range cases {
+		if got := options.retryDelay(attempt); got != want {
+			t.Fatalf("retryDelay(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
+
+func TestTaskQueueRetriesWithBackoffThenFails(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	store := newMemoryTaskStore()
+	queue := newTaskQueue(store, TaskQueueOptions{Workers: 1})
+	queue.now = func() time.Time { return now }
+	calls := 0
+	failedMessages := make([]string, 0)
+	options := TaskOptions{MaxAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, OnFailed: func(payload json.RawMessage, message string) {
+		failedMessages = append(failedMessages, message)
+	}}
+	queue.Register("test", options, func(ctx context.Context, payload json.RawMessage) error {
+		calls++
+		return errors.New("database is down")
+	})
+
+	if err := queue.Enqueue("test", testTaskPayload{}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	queue.processNext(queue.workerID(0))
+	task := store.get(1)
+	if task.Status != model.BackgroundTaskStatusPending || !task.RunAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("after first failure status=%s run_at=%s", task.Status, task.RunAt)
+	}
+	if queue.processNext(queue.workerID(0)) {
+		t.Fatalf("task claimed before its retry time")
+	}
+	if len(failedMessages) != 0 {
+		t.Fatalf("OnFailed called before attempts were exhausted: %v", failedMessages)
+	}
+
+	now = now.Add(time.Minute)
+	queue.processNext(queue.workerID(0))
+	task = store.get(1)
+	if task.Status != model.BackgroundTaskStatusFailed || task.LastError != "database is down" || calls != 2 {
+		t.Fatalf("after last attempt status=%s error=%q calls=%d", task.Status, task.LastError, calls)
+	}
+	if len(failedMessages) != 1 || failedMessages[0] != "database is down" {
+		t.Fatalf("OnFailed messages = %v, want one call with the last error", failedMessages)
+	}
+}
+
+func TestTaskQueueInvalidPayloadIsNotRetried(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryTaskStore()
+	queue := newTaskQueue(store, TaskQueueOptions{Workers: 1})
+	queue.Register("test", TaskOptions{MaxAttempts: 5}, TypedTaskHandler(func(ctx context.Context, payload testTaskPayload) error {
+		return nil
+	}))
+
+	if err := queue.Enqueue("test", []int{1, 2}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	queue.processNext(queue.workerID(0))
+	if task := store.get(1); task.Status != model.BackgroundTaskStatusFailed || task.Attempts != 1 {
+		t.Fatalf("status=%s attempts=%d, want failed after one attempt", task.Status, task.Attempts)
+	}
+}
+
+func TestTaskQueueFailsTaskReclaimedAfterLastAttemptExpired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	store := newMemoryTaskStore()
+	queue := newTaskQueue(store, TaskQueueOptions{Workers: 1})
+	queue.now = func() time.Time { return now }
+	called := false
+	queue.Register("test", TaskOptions{MaxAttempts: 1, Timeout: time.Minute}, func(ctx context.Context, payload json.RawMessage) error {
+		called = true
+		return nil
+	})
+
+	if err := queue.Enqueue("test", testTaskPayload{}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	// 模拟 worker 领取后进程崩溃，没有续期
+	if _, err := store.Claim([]string{"test"}, "crashed-worker", now); err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+
+	now = now.Add(30 * time.Second)
+	if queue.processNext(queue.workerID(0)) {
+		t.Fatalf("task reclaimed before visibility timeout")
+	}
+	now = now.Add(time.Minute)
+	if !queue.processNext(queue.workerID(0)) {
+		t.Fatalf("expired task was not reclaimed")
+	}
+	if task := store.get(1); task.Status != model.BackgroundTaskStatusFailed || called {
+		t.Fatalf("status=%s called=%v, want failed without running", task.Status, called)
+	}
+}
+
+func TestTaskQueueShutdownReleasesInterruptedTask(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryTaskStore()
+	queue := newTaskQueue(store, TaskQueueOptions{Workers: 1, PollInterval: 10 * time.Millisecond})
+	started := make(chan struct{})
+	queue.Register("test", TaskOptions{}, func(ctx context.Context, payload json.RawMessage) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if err := queue.Enqueue("test", testTaskPayload{}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	queue.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := queue.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() error = %v, want deadline exceeded", err)
+	}
+	task := store.get(1)
+	if task.Status != model.BackgroundTaskStatusPending || task.Attempts != 0 {
+		t.Fatalf("status=%s attempts=%d, want released as pending", task.Status, task.Attempts)
+	}
+	if err := queue.Enqueue("test", testTaskPayload{}); err == nil {
+		t.Fatalf("Enqueue() after shutdown should fail")
+	}
+}
@@ -36,6 +36,10 @@ CREATE TABLE IF NOT EXISTS shops (
     is_active       BOOLEAN DEFAULT true,
     execution_engine_mode VARCHAR(20) NOT NULL DEFAULT 'auto',
     owner_id        INTEGER REFERENCES users(id),
+    writes_paused   BOOLEAN NOT NULL DEFAULT false,
+    writes_paused_reason VARCHAR(255),
+    writes_paused_at TIMESTAMP,
+    writes_paused_by INTEGER REFERENCES users(id),
     created_at      TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at      TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
@@ -65,6 +69,7 @@ CREATE TABLE IF NOT EXISTS products (
     status              VARCHAR(20) DEFAULT 'active',  -- active / inactive / archived
     is_loss             BOOLEAN DEFAULT false,
     is_promoted         BOOLEAN DEFAULT false,
+    tags                JSONB NOT NULL DEFAULT '[]'::jsonb,  -- 本地标签，用于自动加促销选品
     last_synced_at      TIMESTAMP,
     created_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -126,6 +131,7 @@ CREATE TABLE IF NOT EXISTS promotion_actions (
     status              VARCHAR(20) DEFAULT 'active',  -- active / expired / disabled
     sort_order          INTEGER DEFAULT 0,  -- 排序顺序
     source_payload      JSONB,
+    successor_action_ids JSONB,  -- 到期后自动报名的后续活动
     last_synced_at      TIMESTAMP,
     last_products_synced_at TIMESTAMP,
     created_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -197,14 +203,18 @@ CREATE TABLE IF NOT EXISTS promotion_action_candidates (
 CREATE TABLE IF NOT EXISTS auto_promotion_configs (
     id                  SERIAL PRIMARY KEY,
     shop_id             INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    name                VARCHAR(100) NOT NULL DEFAULT '默认配置',
     enabled             BOOLEAN NOT NULL DEFAULT false,
     schedule_time       VARCHAR(5) NOT NULL DEFAULT '09:05',
     target_date         DATE NOT NULL,
     official_action_ids JSONB NOT NULL DEFAULT '[]'::jsonb,
     shop_action_ids     JSONB NOT NULL DEFAULT '[]'::jsonb,
+    target_mode         VARCHAR(20) NOT NULL DEFAULT 'fixed_date',
+    target_offset_days  INTEGER NOT NULL DEFAULT 0,
+    selection_rules     JSONB NOT NULL DEFAULT '{}'::jsonb,
     created_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(shop_id)
+    UNIQUE(shop_id, name)
 );
 
 -- ============================================================
@@ -227,6 +237,8 @@ CREATE TABLE IF NOT EXISTS auto_promotion_runs (
     skipped_items       INTEGER DEFAULT 0,
     config_snapshot     JSONB DEFAULT '{}'::jsonb,
     error_message       TEXT,
+    candidate_job_ids   JSONB,
+    waiting_job_ids     JSONB,
     started_at          TIMESTAMP,
     completed_at        TIMESTAMP,
     created_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -275,6 +287,8 @@ CREATE TABLE IF NOT EXISTS ozon_product_catalog_items (
     stock_total           INTEGER DEFAULT 0,
     stock_fbo             INTEGER DEFAULT 0,
     stock_fbs             INTEGER DEFAULT 0,
+    category_id           BIGINT NOT NULL DEFAULT 0,  -- Ozon description_category_id
+    type_id               BIGINT NOT NULL DEFAULT 0,
     listing_date          TIMESTAMP,
     listing_date_source   VARCHAR(20) NOT NULL DEFAULT 'local_sync', -- ozon / local_sync
     sync_token            VARCHAR(64),
@@ -304,7 +318,26 @@ CREATE TABLE IF NOT EXISTS operation_logs (
 );
 
 -- ============================================================
--- 15. 自动化任务主表
+-- 15. 自动化工作流表
+-- ============================================================
+CREATE TABLE IF NOT EXISTS automation_workflows (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id),
+    created_by              INTEGER NOT NULL REFERENCES users(id),
+    workflow_type           VARCHAR(50) NOT NULL,
+    status                  VARCHAR(30) NOT NULL DEFAULT 'running',
+    total_jobs              INTEGER DEFAULT 0,
+    completed_jobs          INTEGER DEFAULT 0,
+    failed_jobs             INTEGER DEFAULT 0,
+    error_message           TEXT,
+    result                  JSONB,
+    completed_at            TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ============================================================
+-- 16. 自动化任务主表
 -- ============================================================
 CREATE TABLE IF NOT EXISTS automation_jobs (
     id                      SERIAL PRIMARY KEY,
@@ -313,6 +346,10 @@ CREATE TABLE IF NOT EXISTS automation_jobs (
     assigned_agent_id       INTEGER,
     job_type                VARCHAR(50) NOT NULL,
     status                  VARCHAR(30) NOT NULL DEFAULT 'pending',
+    priority                INTEGER NOT NULL DEFAULT 50,
+    workflow_id             INTEGER REFERENCES automation_workflows(id) ON DELETE SET NULL,
+    on_success              VARCHAR(50),
+    continuation_status     VARCHAR(20),
     dry_run                 BOOLEAN DEFAULT false,
     requires_confirmation   BOOLEAN DEFAULT false,
     rate_limit              INTEGER DEFAULT 30,
@@ -320,6 +357,8 @@ CREATE TABLE IF NOT EXISTS automation_jobs (
     success_items           INTEGER DEFAULT 0,
     failed_items            INTEGER DEFAULT 0,
     error_message           TEXT,
+    requeue_count           INTEGER DEFAULT 0,
+    next_retry_at           TIMESTAMP,
     started_at              TIMESTAMP,
     completed_at            TIMESTAMP,
     created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -327,7 +366,7 @@ CREATE TABLE IF NOT EXISTS automation_jobs (
 );
 
 -- ============================================================
--- 16. 自动化任务明细表
+-- 17. 自动化任务明细表
 -- ============================================================
 CREATE TABLE IF NOT EXISTS automation_job_items (
     id                      SERIAL PRIMARY KEY,
@@ -343,13 +382,15 @@ CREATE TABLE IF NOT EXISTS automation_job_items (
     step_reprice_error      TEXT,
     step_readd_error        TEXT,
     retry_count             INTEGER DEFAULT 0,
+    next_retry_at           TIMESTAMP,
+    last_error_kind         VARCHAR(20),
     created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     UNIQUE(job_id, source_sku)
 );
 
 -- ============================================================
--- 17. 自动化Agent表
+-- 18. 自动化Agent表
 -- ============================================================
 CREATE TABLE IF NOT EXISTS automation_agents (
     id                      SERIAL PRIMARY KEY,
@@ -364,7 +405,7 @@ CREATE TABLE IF NOT EXISTS automation_agents (
 );
 
 -- ============================================================
--- 18. 自动化任务事件表
+-- 19. 自动化任务事件表
 -- ============================================================
 CREATE TABLE IF NOT EXISTS automation_job_events (
     id                      SERIAL PRIMARY KEY,
@@ -377,7 +418,7 @@ CREATE TABLE IF NOT EXISTS automation_job_events (
 );
 
 -- ============================================================
--- 19. 自动化产物索引表
+-- 20. 自动化产物索引表
 -- ============================================================
 CREATE TABLE IF NOT EXISTS automation_artifacts (
     id                      SERIAL PRIMARY KEY,
@@ -386,10 +427,262 @@ CREATE TABLE IF NOT EXISTS automation_artifacts (
     artifact_type           VARCHAR(50) NOT NULL,
     storage_path            VARCHAR(500) NOT NULL,
     checksum                VARCHAR(128),
+    content_type            VARCHAR(100),
+    size_bytes              BIGINT DEFAULT 0,
     meta                    JSONB,
     created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- ============================================================
+-- 21. 自动化任务依赖表
+-- ============================================================
+CREATE TABLE IF NOT EXISTS automation_job_dependencies (
+    id                      SERIAL PRIMARY KEY,
+    job_id                  INTEGER NOT NULL REFERENCES automation_jobs(id) ON DELETE CASCADE,
+    depends_on_job_id       INTEGER NOT NULL REFERENCES automation_jobs(id) ON DELETE CASCADE,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ============================================================
+-- 22. 店铺熔断表
+-- ============================================================
+CREATE TABLE IF NOT EXISTS shop_circuit_breakers (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    source                  VARCHAR(20) NOT NULL,
+    failure_class           VARCHAR(30) NOT NULL,
+    consecutive_failures    INTEGER NOT NULL DEFAULT 0,
+    last_error              TEXT,
+    opened_at               TIMESTAMP NOT NULL,
+    open_until              TIMESTAMP NOT NULL,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ============================================================
+-- 23. 后台任务队列表
+-- ============================================================
+CREATE TABLE IF NOT EXISTS background_tasks (
+    id                      SERIAL PRIMARY KEY,
+    task_type               VARCHAR(50) NOT NULL,
+    payload                 JSONB,
+    status                  VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts                INTEGER NOT NULL DEFAULT 0,
+    max_attempts            INTEGER NOT NULL DEFAULT 3,
+    timeout_seconds         INTEGER NOT NULL DEFAULT 300,
+    run_at                  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_by               VARCHAR(120),
+    locked_until            TIMESTAMP,
+    last_error              TEXT,
+    started_at              TIMESTAMP,
+    completed_at            TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ============================================================
+-- 24. 促销状态对账报告表
+-- ============================================================
+CREATE TABLE IF NOT EXISTS promotion_reconcile_reports (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    trigger_mode            VARCHAR(20) NOT NULL,
+    status                  VARCHAR(20) NOT NULL DEFAULT 'pending',
+    actions_checked         INTEGER NOT NULL DEFAULT 0,
+    actions_failed          INTEGER NOT NULL DEFAULT 0,
+    remote_products         INTEGER NOT NULL DEFAULT 0,
+    added_count             INTEGER NOT NULL DEFAULT 0,
+    exited_count            INTEGER NOT NULL DEFAULT 0,
+    flags_fixed_count       INTEGER NOT NULL DEFAULT 0,
+    unknown_count           INTEGER NOT NULL DEFAULT 0,
+    drift                   JSONB NOT NULL DEFAULT '[]'::jsonb,
+    error_message           TEXT,
+    started_at              TIMESTAMP,
+    completed_at            TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ============================================================
+-- 25. 促销参与流水表（只追加）
+-- ============================================================
+CREATE TABLE IF NOT EXISTS promotion_participation_events (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    product_id              INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    source_sku              VARCHAR(100),
+    ozon_product_id         BIGINT,
+    event_type              VARCHAR(20) NOT NULL,
+    action_source           VARCHAR(20),
+    action_id               BIGINT,
+    action_title            VARCHAR(200),
+    action_price            DECIMAL(12, 2),
+    price                   DECIMAL(12, 2),
+    previous_price          DECIMAL(12, 2),
+    origin                  VARCHAR(30) NOT NULL,
+    triggered_by            INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reference               VARCHAR(100),
+    note                    TEXT,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ============================================================
+-- 26. 价格变动流水表
+-- ============================================================
+CREATE TABLE IF NOT EXISTS price_history (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    product_id              INTEGER REFERENCES products(id) ON DELETE SET NULL,
+    ozon_product_id         BIGINT,
+    source_sku              VARCHAR(120),
+    price_type              VARCHAR(30) NOT NULL,
+    old_price               DECIMAL(12, 2),
+    new_price               DECIMAL(12, 2),
+    currency                VARCHAR(10),
+    action_id               BIGINT,
+    source                  VARCHAR(30) NOT NULL,
+    changed_by              INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    job_id                  INTEGER,
+    reference               VARCHAR(100),
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ============================================================
+-- 27. 定时改价计划表
+-- ============================================================
+CREATE TABLE IF NOT EXISTS price_plans (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    name                    VARCHAR(100) NOT NULL,
+    status                  VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+    start_at                TIMESTAMP NOT NULL,
+    end_at                  TIMESTAMP NOT NULL,
+    created_by              INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    item_count              INTEGER NOT NULL DEFAULT 0,
+    applied_count           INTEGER NOT NULL DEFAULT 0,
+    skipped_count           INTEGER NOT NULL DEFAULT 0,
+    failed_count            INTEGER NOT NULL DEFAULT 0,
+    reverted_count          INTEGER NOT NULL DEFAULT 0,
+    error_message           TEXT,
+    applied_at              TIMESTAMP,
+    reverted_at             TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS price_plan_items (
+    id                      SERIAL PRIMARY KEY,
+    plan_id                 INTEGER NOT NULL REFERENCES price_plans(id) ON DELETE CASCADE,
+    product_id              INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    source_sku              VARCHAR(120) NOT NULL,
+    ozon_product_id         BIGINT,
+    target_price            DECIMAL(12, 2) NOT NULL,
+    previous_price          DECIMAL(12, 2),
+    status                  VARCHAR(20) NOT NULL DEFAULT 'pending',
+    note                    TEXT,
+    applied_at              TIMESTAMP,
+    reverted_at             TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ============================================================
+-- 28. 改价规则与商品成本价表
+-- ============================================================
+CREATE TABLE IF NOT EXISTS reprice_rules (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    name                    VARCHAR(100) NOT NULL,
+    enabled                 BOOLEAN NOT NULL DEFAULT true,
+    priority                INTEGER NOT NULL DEFAULT 0,
+    sku_prefix              VARCHAR(120),
+    target_margin_percent   DECIMAL(6, 2) NOT NULL DEFAULT 0,
+    keep_action_eligible    BOOLEAN NOT NULL DEFAULT false,
+    price_ending            VARCHAR(10),
+    min_price               DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    max_price               DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    created_by              INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS product_costs (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    source_sku              VARCHAR(120) NOT NULL,
+    cost                    DECIMAL(12, 2) NOT NULL,
+    updated_by              INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ============================================================
+-- 29. 批量操作快照表（用于撤销）
+-- ============================================================
+CREATE TABLE IF NOT EXISTS bulk_operations (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    operation_type          VARCHAR(40) NOT NULL,
+    status                  VARCHAR(20) NOT NULL DEFAULT 'applied',
+    created_by              INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    item_count              INTEGER NOT NULL DEFAULT 0,
+    job_ids                 JSONB,
+    restored_count          INTEGER NOT NULL DEFAULT 0,
+    failed_count            INTEGER NOT NULL DEFAULT 0,
+    revert_job_ids          JSONB,
+    error_message           TEXT,
+    reverted_by             INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reverted_at             TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS bulk_operation_items (
+    id                      SERIAL PRIMARY KEY,
+    operation_id            INTEGER NOT NULL REFERENCES bulk_operations(id) ON DELETE CASCADE,
+    product_id              INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    source_sku              VARCHAR(120) NOT NULL,
+    ozon_product_id         BIGINT,
+    previous_price          DECIMAL(12, 2),
+    was_promoted            BOOLEAN NOT NULL DEFAULT false,
+    official_actions        JSONB,
+    shop_actions            JSONB,
+    revert_status           VARCHAR(20) NOT NULL DEFAULT 'pending',
+    revert_note             TEXT,
+    reverted_at             TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ============================================================
+-- 30. 活动到期处理记录表
+-- ============================================================
+CREATE TABLE IF NOT EXISTS promotion_action_expirations (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    promotion_action_id     INTEGER NOT NULL REFERENCES promotion_actions(id) ON DELETE CASCADE,
+    action_id               BIGINT,
+    source                  VARCHAR(20),
+    title                   VARCHAR(200),
+    date_end                TIMESTAMP NOT NULL,
+    status                  VARCHAR(20) NOT NULL DEFAULT 'pending',
+    skus                    JSONB,
+    sku_count               INTEGER NOT NULL DEFAULT 0,
+    exited_count            INTEGER NOT NULL DEFAULT 0,
+    unpromoted_count        INTEGER NOT NULL DEFAULT 0,
+    successor_enrolled      INTEGER NOT NULL DEFAULT 0,
+    successor_failed        INTEGER NOT NULL DEFAULT 0,
+    job_ids                 JSONB,
+    uncovered_count         INTEGER NOT NULL DEFAULT 0,
+    uncovered_skus          JSONB,
+    error_message           TEXT,
+    read_at                 TIMESTAMP,
+    completed_at            TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(promotion_action_id, date_end)
+);
+
 -- ============================================================
 -- 索引
 -- ============================================================
@@ -429,17 +722,52 @@ CREATE INDEX IF NOT EXISTS idx_ozon_catalog_shop_product ON ozon_product_catalog
 CREATE INDEX IF NOT EXISTS idx_ozon_catalog_shop_date ON ozon_product_catalog_items(shop_id, listing_date);
 CREATE INDEX IF NOT EXISTS idx_ozon_catalog_shop_visibility ON ozon_product_catalog_items(shop_id, visibility);
 CREATE INDEX IF NOT EXISTS idx_ozon_catalog_shop_offer ON ozon_product_catalog_items(shop_id, offer_id);
+CREATE INDEX IF NOT EXISTS idx_ozon_catalog_shop_category ON ozon_product_catalog_items(shop_id, category_id);
 CREATE INDEX IF NOT EXISTS idx_ozon_catalog_sync_token ON ozon_product_catalog_items(sync_token);
 CREATE INDEX IF NOT EXISTS idx_automation_jobs_shop_id ON automation_jobs(shop_id);
 CREATE INDEX IF NOT EXISTS idx_automation_jobs_status ON automation_jobs(status);
 CREATE INDEX IF NOT EXISTS idx_automation_jobs_created_by ON automation_jobs(created_by);
 CREATE INDEX IF NOT EXISTS idx_automation_jobs_assigned_agent_id ON automation_jobs(assigned_agent_id);
+CREATE INDEX IF NOT EXISTS idx_automation_jobs_priority ON automation_jobs(priority);
+CREATE INDEX IF NOT EXISTS idx_automation_jobs_dispatch ON automation_jobs(status, shop_id, priority DESC, created_at);
+CREATE INDEX IF NOT EXISTS idx_automation_jobs_status_started_at ON automation_jobs(status, started_at);
+CREATE INDEX IF NOT EXISTS idx_automation_jobs_next_retry_at ON automation_jobs(next_retry_at);
 CREATE INDEX IF NOT EXISTS idx_automation_job_items_job_id ON automation_job_items(job_id);
 CREATE INDEX IF NOT EXISTS idx_automation_job_items_product_id ON automation_job_items(product_id);
 CREATE INDEX IF NOT EXISTS idx_automation_job_items_overall_status ON automation_job_items(overall_status);
 CREATE INDEX IF NOT EXISTS idx_automation_agents_status ON automation_agents(status);
 CREATE INDEX IF NOT EXISTS idx_automation_job_events_job_id ON automation_job_events(job_id);
 CREATE INDEX IF NOT EXISTS idx_automation_artifacts_job_id ON automation_artifacts(job_id);
+CREATE INDEX IF NOT EXISTS idx_automation_artifacts_created_at ON automation_artifacts(created_at);
+CREATE INDEX IF NOT EXISTS idx_automation_workflows_shop_id ON automation_workflows(shop_id);
+CREATE INDEX IF NOT EXISTS idx_automation_workflows_status ON automation_workflows(status);
+CREATE INDEX IF NOT EXISTS idx_automation_workflows_workflow_type ON automation_workflows(workflow_type);
+CREATE INDEX IF NOT EXISTS idx_automation_jobs_workflow_id ON automation_jobs(workflow_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_automation_job_dependency ON automation_job_dependencies(job_id, depends_on_job_id);
+CREATE INDEX IF NOT EXISTS idx_automation_job_dependencies_depends_on ON automation_job_dependencies(depends_on_job_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shop_circuit_breaker ON shop_circuit_breakers(shop_id, source);
+CREATE INDEX IF NOT EXISTS idx_background_tasks_task_type ON background_tasks(task_type);
+CREATE INDEX IF NOT EXISTS idx_background_tasks_claim ON background_tasks(status, run_at);
+CREATE INDEX IF NOT EXISTS idx_background_tasks_locked_until ON background_tasks(locked_until) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS idx_background_tasks_completed_at ON background_tasks(completed_at);
+CREATE INDEX IF NOT EXISTS idx_promotion_reconcile_reports_shop_created ON promotion_reconcile_reports(shop_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_promoted_products_action_id ON promoted_products(action_id);
+CREATE INDEX IF NOT EXISTS idx_promotion_participation_events_product ON promotion_participation_events(product_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_promotion_participation_events_action ON promotion_participation_events(shop_id, action_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_price_history_shop_created ON price_history(shop_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_price_history_shop_sku ON price_history(shop_id, source_sku, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_price_history_job_id ON price_history(job_id);
+CREATE INDEX IF NOT EXISTS idx_price_plans_shop_status ON price_plans(shop_id, status);
+CREATE INDEX IF NOT EXISTS idx_price_plans_status_start ON price_plans(status, start_at);
+CREATE INDEX IF NOT EXISTS idx_price_plans_status_end ON price_plans(status, end_at);
+CREATE INDEX IF NOT EXISTS idx_price_plan_items_plan_id ON price_plan_items(plan_id);
+CREATE INDEX IF NOT EXISTS idx_price_plan_items_product_id ON price_plan_items(product_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reprice_rule_shop_name ON reprice_rules(shop_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_cost_shop_sku ON product_costs(shop_id, source_sku);
+CREATE INDEX IF NOT EXISTS idx_bulk_operations_shop_created ON bulk_operations(shop_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_bulk_operation_items_operation_id ON bulk_operation_items(operation_id);
+CREATE INDEX IF NOT EXISTS idx_promotion_action_expirations_shop_created ON promotion_action_expirations(shop_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_promotion_actions_status_date_end ON promotion_actions(status, date_end);
 
 -- ============================================================
 -- 初始数据：超级管理员账户
@@ -0,0 +1,23 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_auto_promotion_multi_configs.sql
+-- 适用范围: 已存在 auto_promotion_configs 表的历史数据库
+-- 用途: 每个店铺允许多个具名自动加促销配置（各自的执行时间、活动与选品规则），
+--       去掉 shop_id 唯一约束，改为 (shop_id, name) 唯一；历史配置命名为“默认配置”
+-- 执行前检查:
+--   1. 确认数据库已包含 auto_promotion_configs 表。
+--   2. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 ALTER/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+ALTER TABLE auto_promotion_configs ADD COLUMN IF NOT EXISTS name VARCHAR(100) NOT NULL DEFAULT '默认配置';
+
+ALTER TABLE auto_promotion_configs DROP CONSTRAINT IF EXISTS auto_promotion_configs_shop_id_key;
+-- 早期由 ORM 建表时 shop_id 上为唯一索引，统一重建为普通索引
+DROP INDEX IF EXISTS idx_auto_promotion_configs_shop_id;
+CREATE INDEX IF NOT EXISTS idx_auto_promotion_configs_shop_id ON auto_promotion_configs(shop_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_auto_promotion_config_shop_name ON auto_promotion_configs(shop_id, name);
+
+COMMIT;
@@ -0,0 +1,18 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_auto_promotion_relative_dates.sql
+-- 适用范围: 已存在 auto_promotion_configs 表的历史数据库
+-- 用途: 自动加促销支持相对日期（触发日前第 N 天上架、最近 N 天上架且未报名），
+--       历史配置默认保持固定目标日期
+-- 执行前检查:
+--   1. 确认数据库已包含 auto_promotion_configs 表。
+--   2. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 ALTER 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+ALTER TABLE auto_promotion_configs ADD COLUMN IF NOT EXISTS target_mode VARCHAR(20) NOT NULL DEFAULT 'fixed_date';
+ALTER TABLE auto_promotion_configs ADD COLUMN IF NOT EXISTS target_offset_days INTEGER NOT NULL DEFAULT 0;
+
+COMMIT;
@@ -0,0 +1,23 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_auto_promotion_selection_rules.sql
+-- 适用范围: 已存在 auto_promotion_configs、ozon_product_catalog_items、products 表的历史数据库
+-- 用途: 自动加促销选品规则（上架日期区间、类目、库存、价格与折扣、可见性、标签、SKU 包含/排除），
+--       目录补充 Ozon 类目 / 类型 ID，商品补充本地标签
+-- 执行前检查:
+--   1. 确认数据库已包含 auto_promotion_configs、ozon_product_catalog_items、products 表。
+--   2. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 ALTER/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+ALTER TABLE auto_promotion_configs ADD COLUMN IF NOT EXISTS selection_rules JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+ALTER TABLE ozon_product_catalog_items ADD COLUMN IF NOT EXISTS category_id BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE ozon_product_catalog_items ADD COLUMN IF NOT EXISTS type_id BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_ozon_catalog_shop_category ON ozon_product_catalog_items(shop_id, category_id);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+COMMIT;
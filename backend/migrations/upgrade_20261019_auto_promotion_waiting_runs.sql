@@ -0,0 +1,17 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_auto_promotion_waiting_runs.sql
+-- 适用范围: 已包含 auto_promotion_runs 表的数据库
+-- 用途: 自动加促销运行等待执行端任务时挂起为 waiting，不再占用后台任务同步等待；记录已提交的候选同步任务与等待中的任务
+-- 执行前检查:
+--   1. 确认数据库已包含 auto_promotion_runs 表。
+--   2. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 CREATE/ALTER/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+ALTER TABLE auto_promotion_runs ADD COLUMN IF NOT EXISTS candidate_job_ids JSONB;
+ALTER TABLE auto_promotion_runs ADD COLUMN IF NOT EXISTS waiting_job_ids JSONB;
+
+COMMIT;
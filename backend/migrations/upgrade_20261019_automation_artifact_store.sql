@@ -0,0 +1,19 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_automation_artifact_store.sql
+-- 适用范围: 已存在 automation_artifacts 表的历史数据库
+-- 用途: 产物支持外部存储（本地文件系统 / S3 兼容存储），记录内容类型与大小，支持按保留期清理
+-- 执行前检查:
+--   1. 确认数据库已包含 automation_artifacts 表。
+--   2. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 ALTER/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+ALTER TABLE automation_artifacts ADD COLUMN IF NOT EXISTS content_type VARCHAR(100);
+ALTER TABLE automation_artifacts ADD COLUMN IF NOT EXISTS size_bytes BIGINT DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_automation_artifacts_created_at ON automation_artifacts(created_at);
+
+COMMIT;
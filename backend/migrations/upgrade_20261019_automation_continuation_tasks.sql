@@ -0,0 +1,23 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_automation_continuation_tasks.sql
+-- 适用范围: 已执行 upgrade_20261019_automation_workflows.sql 的数据库
+-- 用途: 续接动作改为后台任务执行，记录每个任务续接动作的执行状态，避免重复回报时重复执行
+-- 执行前检查:
+--   1. 确认数据库已包含 automation_jobs.on_success 列。
+--   2. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 CREATE/ALTER/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+ALTER TABLE automation_jobs ADD COLUMN IF NOT EXISTS continuation_status VARCHAR(20);
+
+-- 升级前已结束的任务视为续接动作已执行
+UPDATE automation_jobs
+SET continuation_status = 'done'
+WHERE on_success IS NOT NULL AND on_success <> ''
+  AND status IN ('success', 'partial_success', 'failed', 'canceled')
+  AND continuation_status IS NULL;
+
+COMMIT;
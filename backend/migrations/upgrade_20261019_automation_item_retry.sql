@@ -0,0 +1,20 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_automation_item_retry.sql
+-- 适用范围: 已存在 automation_jobs / automation_job_items 表的历史数据库
+-- 用途: 支持任务明细按策略自动重试（指数退避）与死信状态
+-- 执行前检查:
+--   1. 确认数据库已包含 automation_jobs 与 automation_job_items 表。
+--   2. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 ALTER/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+ALTER TABLE automation_jobs ADD COLUMN IF NOT EXISTS next_retry_at TIMESTAMP;
+ALTER TABLE automation_job_items ADD COLUMN IF NOT EXISTS next_retry_at TIMESTAMP;
+ALTER TABLE automation_job_items ADD COLUMN IF NOT EXISTS last_error_kind VARCHAR(20);
+
+CREATE INDEX IF NOT EXISTS idx_automation_jobs_next_retry_at ON automation_jobs(next_retry_at);
+
+COMMIT;
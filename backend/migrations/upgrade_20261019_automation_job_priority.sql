@@ -0,0 +1,19 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_automation_job_priority.sql
+-- 适用范围: 已存在 automation_jobs 表的历史数据库
+-- 用途: 为自动化任务增加优先级字段，支持交互任务优先与跨店铺公平调度
+-- 执行前检查:
+--   1. 确认数据库已包含 automation_jobs 表。
+--   2. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 ALTER/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+ALTER TABLE automation_jobs ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 50;
+
+CREATE INDEX IF NOT EXISTS idx_automation_jobs_priority ON automation_jobs(priority);
+CREATE INDEX IF NOT EXISTS idx_automation_jobs_dispatch ON automation_jobs(status, shop_id, priority DESC, created_at);
+
+COMMIT;
@@ -0,0 +1,18 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_automation_job_timeouts.sql
+-- 适用范围: 已存在 automation_jobs 表的历史数据库
+-- 用途: 记录任务超时后重新排队的次数，配合后台超时巡检使用
+-- 执行前检查:
+--   1. 确认数据库已包含 automation_jobs 表。
+--   2. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 ALTER/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+ALTER TABLE automation_jobs ADD COLUMN IF NOT EXISTS requeue_count INTEGER DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_automation_jobs_status_started_at ON automation_jobs(status, started_at);
+
+COMMIT;
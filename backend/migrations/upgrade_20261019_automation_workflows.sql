@@ -0,0 +1,47 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_automation_workflows.sql
+-- 适用范围: 已存在 automation_jobs 表的历史数据库
+-- 用途: 新增自动化工作流与任务依赖，支持任务链式执行与成功后的续接动作
+-- 执行前检查:
+--   1. 确认数据库已包含 automation_jobs 表。
+--   2. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 CREATE/ALTER/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS automation_workflows (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id),
+    created_by              INTEGER NOT NULL REFERENCES users(id),
+    workflow_type           VARCHAR(50) NOT NULL,
+    status                  VARCHAR(30) NOT NULL DEFAULT 'running',
+    total_jobs              INTEGER DEFAULT 0,
+    completed_jobs          INTEGER DEFAULT 0,
+    failed_jobs             INTEGER DEFAULT 0,
+    error_message           TEXT,
+    result                  JSONB,
+    completed_at            TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE automation_jobs ADD COLUMN IF NOT EXISTS workflow_id INTEGER REFERENCES automation_workflows(id) ON DELETE SET NULL;
+ALTER TABLE automation_jobs ADD COLUMN IF NOT EXISTS on_success VARCHAR(50);
+
+CREATE TABLE IF NOT EXISTS automation_job_dependencies (
+    id                      SERIAL PRIMARY KEY,
+    job_id                  INTEGER NOT NULL REFERENCES automation_jobs(id) ON DELETE CASCADE,
+    depends_on_job_id       INTEGER NOT NULL REFERENCES automation_jobs(id) ON DELETE CASCADE,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_automation_workflows_shop_id ON automation_workflows(shop_id);
+CREATE INDEX IF NOT EXISTS idx_automation_workflows_status ON automation_workflows(status);
+CREATE INDEX IF NOT EXISTS idx_automation_workflows_workflow_type ON automation_workflows(workflow_type);
+CREATE INDEX IF NOT EXISTS idx_automation_jobs_workflow_id ON automation_jobs(workflow_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_automation_job_dependency ON automation_job_dependencies(job_id, depends_on_job_id);
+CREATE INDEX IF NOT EXISTS idx_automation_job_dependencies_depends_on ON automation_job_dependencies(depends_on_job_id);
+
+COMMIT;
@@ -0,0 +1,36 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_background_tasks.sql
+-- 适用范围: 所有历史数据库
+-- 用途: 持久化后台任务队列（操作日志写入、Ozon 商品目录刷新、自动加促销运行），进程重启后任务不丢失
+-- 执行前检查:
+--   1. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 CREATE TABLE/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS background_tasks (
+    id                      SERIAL PRIMARY KEY,
+    task_type               VARCHAR(50) NOT NULL,
+    payload                 JSONB,
+    status                  VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts                INTEGER NOT NULL DEFAULT 0,
+    max_attempts            INTEGER NOT NULL DEFAULT 3,
+    timeout_seconds         INTEGER NOT NULL DEFAULT 300,
+    run_at                  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_by               VARCHAR(120),
+    locked_until            TIMESTAMP,
+    last_error              TEXT,
+    started_at              TIMESTAMP,
+    completed_at            TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_background_tasks_task_type ON background_tasks(task_type);
+CREATE INDEX IF NOT EXISTS idx_background_tasks_claim ON background_tasks(status, run_at);
+CREATE INDEX IF NOT EXISTS idx_background_tasks_locked_until ON background_tasks(locked_until) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS idx_background_tasks_completed_at ON background_tasks(completed_at);
+
+COMMIT;
@@ -0,0 +1,51 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_bulk_operations.sql
+-- 适用范围: 所有历史数据库
+-- 用途: 批量改价 / 报名操作快照表，记录操作前各商品的价格与活动参与情况，用于撤销操作
+-- 执行前检查:
+--   1. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 CREATE TABLE/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS bulk_operations (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    operation_type          VARCHAR(40) NOT NULL,
+    status                  VARCHAR(20) NOT NULL DEFAULT 'applied',
+    created_by              INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    item_count              INTEGER NOT NULL DEFAULT 0,
+    job_ids                 JSONB,
+    restored_count          INTEGER NOT NULL DEFAULT 0,
+    failed_count            INTEGER NOT NULL DEFAULT 0,
+    revert_job_ids          JSONB,
+    error_message           TEXT,
+    reverted_by             INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reverted_at             TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS bulk_operation_items (
+    id                      SERIAL PRIMARY KEY,
+    operation_id            INTEGER NOT NULL REFERENCES bulk_operations(id) ON DELETE CASCADE,
+    product_id              INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    source_sku              VARCHAR(120) NOT NULL,
+    ozon_product_id         BIGINT,
+    previous_price          DECIMAL(12, 2),
+    was_promoted            BOOLEAN NOT NULL DEFAULT false,
+    official_actions        JSONB,
+    shop_actions            JSONB,
+    revert_status           VARCHAR(20) NOT NULL DEFAULT 'pending',
+    revert_note             TEXT,
+    reverted_at             TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_bulk_operations_shop_created ON bulk_operations(shop_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_bulk_operation_items_operation_id ON bulk_operation_items(operation_id);
+
+COMMIT;
@@ -0,0 +1,35 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_price_history.sql
+-- 适用范围: 所有历史数据库
+-- 用途: 价格变动流水表，记录同步观测到的和本系统下发的每次价格变化及来源、操作人与任务
+-- 执行前检查:
+--   1. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 CREATE TABLE/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS price_history (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    product_id              INTEGER REFERENCES products(id) ON DELETE SET NULL,
+    ozon_product_id         BIGINT,
+    source_sku              VARCHAR(120),
+    price_type              VARCHAR(30) NOT NULL,
+    old_price               DECIMAL(12, 2),
+    new_price               DECIMAL(12, 2),
+    currency                VARCHAR(10),
+    action_id               BIGINT,
+    source                  VARCHAR(30) NOT NULL,
+    changed_by              INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    job_id                  INTEGER,
+    reference               VARCHAR(100),
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_shop_created ON price_history(shop_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_price_history_shop_sku ON price_history(shop_id, source_sku, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_price_history_job_id ON price_history(job_id);
+
+COMMIT;
@@ -0,0 +1,55 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_price_plans.sql
+-- 适用范围: 所有历史数据库
+-- 用途: 定时改价计划表，计划开始时批量改为目标价，结束时恢复为开始前价格
+-- 执行前检查:
+--   1. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 CREATE TABLE/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS price_plans (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    name                    VARCHAR(100) NOT NULL,
+    status                  VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+    start_at                TIMESTAMP NOT NULL,
+    end_at                  TIMESTAMP NOT NULL,
+    created_by              INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    item_count              INTEGER NOT NULL DEFAULT 0,
+    applied_count           INTEGER NOT NULL DEFAULT 0,
+    skipped_count           INTEGER NOT NULL DEFAULT 0,
+    failed_count            INTEGER NOT NULL DEFAULT 0,
+    reverted_count          INTEGER NOT NULL DEFAULT 0,
+    error_message           TEXT,
+    applied_at              TIMESTAMP,
+    reverted_at             TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS price_plan_items (
+    id                      SERIAL PRIMARY KEY,
+    plan_id                 INTEGER NOT NULL REFERENCES price_plans(id) ON DELETE CASCADE,
+    product_id              INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    source_sku              VARCHAR(120) NOT NULL,
+    ozon_product_id         BIGINT,
+    target_price            DECIMAL(12, 2) NOT NULL,
+    previous_price          DECIMAL(12, 2),
+    status                  VARCHAR(20) NOT NULL DEFAULT 'pending',
+    note                    TEXT,
+    applied_at              TIMESTAMP,
+    reverted_at             TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_plans_shop_status ON price_plans(shop_id, status);
+CREATE INDEX IF NOT EXISTS idx_price_plans_status_start ON price_plans(status, start_at);
+CREATE INDEX IF NOT EXISTS idx_price_plans_status_end ON price_plans(status, end_at);
+CREATE INDEX IF NOT EXISTS idx_price_plan_items_plan_id ON price_plan_items(plan_id);
+CREATE INDEX IF NOT EXISTS idx_price_plan_items_product_id ON price_plan_items(product_id);
+
+COMMIT;
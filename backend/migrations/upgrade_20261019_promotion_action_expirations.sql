@@ -0,0 +1,44 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_promotion_action_expirations.sql
+-- 适用范围: 所有历史数据库
+-- 用途: 活动到期处理记录表，以及活动到期后自动报名的后续活动配置
+-- 执行前检查:
+--   1. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 CREATE TABLE/INDEX 与 ALTER 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+ALTER TABLE promotion_actions ADD COLUMN IF NOT EXISTS successor_action_ids JSONB;
+
+CREATE TABLE IF NOT EXISTS promotion_action_expirations (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    promotion_action_id     INTEGER NOT NULL REFERENCES promotion_actions(id) ON DELETE CASCADE,
+    action_id               BIGINT,
+    source                  VARCHAR(20),
+    title                   VARCHAR(200),
+    date_end                TIMESTAMP NOT NULL,
+    status                  VARCHAR(20) NOT NULL DEFAULT 'pending',
+    skus                    JSONB,
+    sku_count               INTEGER NOT NULL DEFAULT 0,
+    exited_count            INTEGER NOT NULL DEFAULT 0,
+    unpromoted_count        INTEGER NOT NULL DEFAULT 0,
+    successor_enrolled      INTEGER NOT NULL DEFAULT 0,
+    successor_failed        INTEGER NOT NULL DEFAULT 0,
+    job_ids                 JSONB,
+    uncovered_count         INTEGER NOT NULL DEFAULT 0,
+    uncovered_skus          JSONB,
+    error_message           TEXT,
+    read_at                 TIMESTAMP,
+    completed_at            TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(promotion_action_id, date_end)
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotion_action_expirations_shop_created ON promotion_action_expirations(shop_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_promotion_actions_status_date_end ON promotion_actions(status, date_end);
+
+COMMIT;
@@ -0,0 +1,36 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_promotion_participation_ledger.sql
+-- 适用范围: 所有历史数据库
+-- 用途: 促销参与流水表，只追加记录商品加入 / 退出活动及改价的时间、价格与操作人
+-- 执行前检查:
+--   1. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 CREATE TABLE/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS promotion_participation_events (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    product_id              INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    source_sku              VARCHAR(100),
+    ozon_product_id         BIGINT,
+    event_type              VARCHAR(20) NOT NULL,
+    action_source           VARCHAR(20),
+    action_id               BIGINT,
+    action_title            VARCHAR(200),
+    action_price            DECIMAL(12, 2),
+    price                   DECIMAL(12, 2),
+    previous_price          DECIMAL(12, 2),
+    origin                  VARCHAR(30) NOT NULL,
+    triggered_by            INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reference               VARCHAR(100),
+    note                    TEXT,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotion_participation_events_product ON promotion_participation_events(product_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_promotion_participation_events_action ON promotion_participation_events(shop_id, action_id, created_at DESC);
+
+COMMIT;
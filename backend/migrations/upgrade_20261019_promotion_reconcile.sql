@@ -0,0 +1,36 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_promotion_reconcile.sql
+-- 适用范围: 所有历史数据库
+-- 用途: 促销状态对账报告表，记录以 Ozon 活动商品为准修正本地推广记录的结果
+-- 执行前检查:
+--   1. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 CREATE TABLE/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS promotion_reconcile_reports (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    trigger_mode            VARCHAR(20) NOT NULL,
+    status                  VARCHAR(20) NOT NULL DEFAULT 'pending',
+    actions_checked         INTEGER NOT NULL DEFAULT 0,
+    actions_failed          INTEGER NOT NULL DEFAULT 0,
+    remote_products         INTEGER NOT NULL DEFAULT 0,
+    added_count             INTEGER NOT NULL DEFAULT 0,
+    exited_count            INTEGER NOT NULL DEFAULT 0,
+    flags_fixed_count       INTEGER NOT NULL DEFAULT 0,
+    unknown_count           INTEGER NOT NULL DEFAULT 0,
+    drift                   JSONB NOT NULL DEFAULT '[]'::jsonb,
+    error_message           TEXT,
+    started_at              TIMESTAMP,
+    completed_at            TIMESTAMP,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotion_reconcile_reports_shop_created ON promotion_reconcile_reports(shop_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_promoted_products_action_id ON promoted_products(action_id);
+
+COMMIT;
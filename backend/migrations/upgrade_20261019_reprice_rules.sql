@@ -0,0 +1,43 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_reprice_rules.sql
+-- 适用范围: 所有历史数据库
+-- 用途: 店铺改价规则与商品成本价表，按规则生成建议价格后人工确认执行
+-- 执行前检查:
+--   1. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 CREATE TABLE/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS reprice_rules (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    name                    VARCHAR(100) NOT NULL,
+    enabled                 BOOLEAN NOT NULL DEFAULT true,
+    priority                INTEGER NOT NULL DEFAULT 0,
+    sku_prefix              VARCHAR(120),
+    target_margin_percent   DECIMAL(6, 2) NOT NULL DEFAULT 0,
+    keep_action_eligible    BOOLEAN NOT NULL DEFAULT false,
+    price_ending            VARCHAR(10),
+    min_price               DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    max_price               DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    created_by              INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS product_costs (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    source_sku              VARCHAR(120) NOT NULL,
+    cost                    DECIMAL(12, 2) NOT NULL,
+    updated_by              INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reprice_rule_shop_name ON reprice_rules(shop_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_cost_shop_sku ON product_costs(shop_id, source_sku);
+
+COMMIT;
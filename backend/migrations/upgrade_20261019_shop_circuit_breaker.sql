@@ -0,0 +1,34 @@
+-- Ozon Manager 增量升级脚本
+-- 文件: upgrade_20261019_shop_circuit_breaker.sql
+-- 适用范围: 已存在 shops 表的历史数据库
+-- 用途: 店铺手动暂停写操作开关，以及按店铺记录执行端 / Ozon API 连续故障熔断
+-- 执行前检查:
+--   1. 确认数据库已包含 shops、users 表。
+--   2. 建议在执行前备份数据库。
+-- 失败处理建议:
+--   1. 若脚本中断，先回滚当前事务或恢复备份后重试。
+--   2. 所有 ALTER/INDEX 均尽量幂等，可在排障后重复执行。
+
+BEGIN;
+
+ALTER TABLE shops ADD COLUMN IF NOT EXISTS writes_paused BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE shops ADD COLUMN IF NOT EXISTS writes_paused_reason VARCHAR(255);
+ALTER TABLE shops ADD COLUMN IF NOT EXISTS writes_paused_at TIMESTAMP;
+ALTER TABLE shops ADD COLUMN IF NOT EXISTS writes_paused_by INTEGER REFERENCES users(id);
+
+CREATE TABLE IF NOT EXISTS shop_circuit_breakers (
+    id                      SERIAL PRIMARY KEY,
+    shop_id                 INTEGER NOT NULL REFERENCES shops(id) ON DELETE CASCADE,
+    source                  VARCHAR(20) NOT NULL,
+    failure_class           VARCHAR(30) NOT NULL,
+    consecutive_failures    INTEGER NOT NULL DEFAULT 0,
+    last_error              TEXT,
+    opened_at               TIMESTAMP NOT NULL,
+    open_until              TIMESTAMP NOT NULL,
+    created_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at              TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shop_circuit_breaker ON shop_circuit_breakers(shop_id, source);
+
+COMMIT;
@@ -0,0 +1,76 @@
+package artifactstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LocalStore 将产物保存在本地目录
+type LocalStore struct {
+	dir string
+}
+
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("artifact local dir is empty")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create artifact dir: %w", err)
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+func (s *LocalStore) Scheme() string {
+	return "local"
+}
+
+func (s *LocalStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	fullPath, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o750); err != nil {
+		return err
+	}
+
+	// 先写临时文件再改名，避免读取到写了一半的产物
+	tmpPath := fullPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, fullPath)
+}
+
+func (s *LocalStore) Get(_ context.Context, key string) ([]byte, error) {
+	fullPath, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(fullPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	fullPath, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fullPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) resolve(key string) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(cleaned)), nil
+}
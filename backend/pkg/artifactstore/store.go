@@ -0,0 +1,72 @@
+// Package artifactstore 自动化任务产物的存储后端（本地文件系统 / S3 兼容对象存储）
+package artifactstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrNotFound 产物不存在
+var ErrNotFound = errors.New("artifact not found")
+
+// Store 产物存储后端。key 为相对路径，如 jobs/12/shop_actions_snapshot/xxx.json
+type Store interface {
+	// Scheme 存储后端标识，写入 storage_path 前缀（local / s3）
+	Scheme() string
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// URI 生成写入数据库的 storage_path，如 local://jobs/12/a.json
+func URI(store Store, key string) string {
+	return store.Scheme() + "://" + key
+}
+
+// ParseURI 解析 storage_path，返回后端标识与 key
+func ParseURI(uri string) (string, string, bool) {
+	scheme, key, ok := strings.Cut(uri, "://")
+	if !ok || scheme == "" || key == "" {
+		return "", "", false
+	}
+	return scheme, key, true
+}
+
+// Checksum 计算内容的 SHA-256（十六进制）
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyChecksum 校验内容与期望的 SHA-256 是否一致，期望值为空时跳过
+func VerifyChecksum(data []byte, expected string) error {
+	expected = strings.ToLower(strings.TrimSpace(expected))
+	if expected == "" {
+		return nil
+	}
+	if actual := Checksum(data); actual != expected {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expected, actual)
+	}
+	return nil
+}
+
+// cleanKey 规范化 key 并拒绝越界路径
+func cleanKey(key string) (string, error) {
+	key = strings.TrimSpace(strings.ReplaceAll(key, "\\", "/"))
+	cleaned := path.Clean("/" + key)
+	cleaned = strings.TrimPrefix(cleaned, "/")
+	if cleaned == "" || cleaned == "." {
+		return "", fmt.Errorf("invalid artifact key: %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("invalid artifact key: %q", key)
+		}
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,236 @@
+package agentclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+	"ozon-manager/pkg/automation/protocol"
+	"ozon-manager/pkg/ozon"
+)
+
+// fakeAgentServer 模拟服务端 Agent 通道：记录心跳与回报，poll 依次下发预置任务
+type fakeAgentServer struct {
+	mu         sync.Mutex
+	token      string
+	minVersion int
+	jobs       []*dto.AgentJobPayload
+	heartbeats []dto.AgentHeartbeatRequest
+	reports    []dto.AgentReportRequest
+}
+
+func (f *fakeAgentServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.token != "" && r.Header.Get("X-Agent-Token") != f.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(dto.Response{Code: 401, Message: "无效的执行端令牌"})
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	var data interface{}
+	switch r.URL.Path {
+	case "/api/v1/automation/agent/heartbeat":
+		var req dto.AgentHeartbeatRequest
+		_ = json.Unmarshal(body, &req)
+		f.heartbeats = append(f.heartbeats, req)
+		if req.ProtocolVersion < f.minVersion {
+			w.WriteHeader(http.StatusUpgradeRequired)
+			_ = json.NewEncoder(w).Encode(dto.Response{Code: 426, Message: "protocol version is not supported"})
+			return
+		}
+	case "/api/v1/automation/agent/poll":
+		resp := dto.AgentPollResponse{}
+		if len(f.jobs) > 0 {
+			resp.Job = f.jobs[0]
+			f.jobs = f.jobs[1:]
+		}
+		data = resp
+	case "/api/v1/automation/agent/report":
+		var req dto.AgentReportRequest
+		_ = json.Unmarshal(body, &req)
+		f.reports = append(f.reports, req)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(dto.Response{Code: 200, Message: "success", Data: data})
+}
+
+func newTestRunner(t *testing.T, server *fakeAgentServer, token string, executor Executor) *Runner {
+	t.Helper()
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	client, err := NewClient(Options{BaseURL: httpServer.URL, AgentKey: "go-agent-test", Token: token})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return NewRunner(client, executor, RunnerOptions{ShopIDs: []uint{7}, Logger: log.New(io.Discard, "", 0)})
+}
+
+func TestRunnerExecutesAndReportsJob(t *testing.T) {
+	t.Parallel()
+
+	server := &fakeAgentServer{
+		token: "secret",
+		jobs: []*dto.AgentJobPayload{{
+			JobID:   41,
+			ShopID:  7,
+			JobType: model.AutomationJobTypeShopActionDeclare,
+			Items:   []dto.AutomationJobCreateItem{{SourceSKU: "sku-1"}, {SourceSKU: "sku-2"}},
+		}},
+	}
+	runner := newTestRunner(t, server, "secret", NewRouter(NewMockExecutor()))
+
+	handled, err := runner.RunOnce(context.Background())
+	if err != nil || !handled {
+		t.Fatalf("RunOnce() = %v, %v", handled, err)
+	}
+	handled, err = runner.RunOnce(context.Background())
+	if err != nil || handled {
+		t.Fatalf("RunOnce() without job = %v, %v", handled, err)
+	}
+
+	if len(server.heartbeats) != 2 {
+		t.Fatalf("heartbeats = %d, want 2", len(server.heartbeats))
+	}
+	if server.heartbeats[0].ProtocolVersion != protocol.Version {
+		t.Fatalf("heartbeat protocol_version = %d, want %d", server.heartbeats[0].ProtocolVersion, protocol.Version)
+	}
+	caps := server.heartbeats[0].Capabilities
+	if caps["kind"] != RunnerKind || len(caps["job_types"].([]interface{})) != len(NewMockExecutor().JobTypes()) {
+		t.Fatalf("unexpected capabilities: %v", caps)
+	}
+	if len(server.reports) != 1 {
+		t.Fatalf("reports = %d, want 1", len(server.reports))
+	}
+	report := server.reports[0]
+	if report.JobID != 41 || report.Status != ReportStatusSuccess || len(report.Results) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.Results[0].StepExitStatus != StatusSkipped || report.Results[0].StepReaddStatus != StatusSuccess {
+		t.Fatalf("declare job should only run readd step: %+v", report.Results[0])
+	}
+}
+
+func TestRunnerReportsFailureForUnsupportedJob(t *testing.T) {
+	t.Parallel()
+
+	server := &fakeAgentServer{
+		jobs: []*dto.AgentJobPayload{{
+			JobID:   42,
+			JobType: "unknown_type",
+			Items:   []dto.AutomationJobCreateItem{{SourceSKU: "sku-1"}},
+		}},
+	}
+	runner := newTestRunner(t, server, "", NewRouter(NewMockExecutor()))
+
+	if _, err := runner.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	if len(server.reports) != 1 || server.reports[0].Status != ReportStatusFailed {
+		t.Fatalf("unexpected reports: %+v", server.reports)
+	}
+	if server.reports[0].Results[0].StepExitError != "unsupported job type: unknown_type" {
+		t.Fatalf("unexpected error: %q", server.reports[0].Results[0].StepExitError)
+	}
+}
+
+func TestRunnerStopsOnIncompatibleProtocol(t *testing.T) {
+	t.Parallel()
+
+	server := &fakeAgentServer{minVersion: protocol.Version + 1}
+	runner := newTestRunner(t, server, "", NewRouter(NewMockExecutor()))
+
+	err := runner.Run(context.Background())
+	if !IsIncompatibleProtocol(err) {
+		t.Fatalf("Run() error = %v, want incompatible protocol", err)
+	}
+	if len(server.heartbeats) != 1 {
+		t.Fatalf("heartbeats = %d, want runner to stop after the first rejection", len(server.heartbeats))
+	}
+}
+
+func TestClientReturnsAPIErrorOnRejectedToken(t *testing.T) {
+	t.Parallel()
+
+	server := &fakeAgentServer{token: "secret"}
+	runner := newTestRunner(t, server, "wrong", NewMockExecutor())
+
+	_, err := runner.RunOnce(context.Background())
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("RunOnce() error = %v, want 401 APIError", err)
+	}
+}
+
+type fakeOzonActions struct {
+	pages map[string]*ozon.ActionProductsResponse
+}
+
+func (f *fakeOzonActions) GetActionCandidates(actionID int64, limit int, lastID string) (*ozon.ActionCandidatesResponse, error) {
+	return &ozon.ActionCandidatesResponse{}, nil
+}
+
+func (f *fakeOzonActions) GetActionProducts(actionID int64, limit int, lastID string) (*ozon.ActionProductsResponse, error) {
+	if actionID != 900 {
+		return nil, fmt.Errorf("unexpected action id %d", actionID)
+	}
+	return f.pages[lastID], nil
+}
+
+func TestOzonAPIExecutorPaginatesActionProducts(t *testing.T) {
+	t.Parallel()
+
+	firstPage := &ozon.ActionProductsResponse{}
+	for index := 0; index < ozonActionPageSize; index++ {
+		firstPage.Result.Products = append(firstPage.Result.Products, ozon.ActionProduct{ProductID: int64(index + 1), ActionPrice: 10})
+	}
+	firstPage.Result.LastID = "page-2"
+	secondPage := &ozon.ActionProductsResponse{}
+	secondPage.Result.Products = []ozon.ActionProduct{{ProductID: 501, ActionPrice: 20, Stock: 3}}
+
+	api := &fakeOzonActions{pages: map[string]*ozon.ActionProductsResponse{"": firstPage, "page-2": secondPage}}
+	executor := NewOzonAPIExecutor(func(shopID uint) (OzonActionsAPI, bool) {
+		return api, shopID == 7
+	})
+
+	job := &dto.AgentJobPayload{
+		JobID:   43,
+		ShopID:  7,
+		JobType: model.AutomationJobTypeSyncActionProducts,
+		Items:   []dto.AutomationJobCreateItem{{SourceSKU: "__sync_action_products__:5"}},
+		Meta:    map[string]interface{}{"source_action_id": "900"},
+	}
+	result, err := executor.Execute(context.Background(), job)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	items := result.Meta["items"].([]map[string]interface{})
+	if len(items) != ozonActionPageSize+1 || items[ozonActionPageSize]["ozon_product_id"] != int64(501) {
+		t.Fatalf("unexpected snapshot items: %d", len(items))
+	}
+	if result.Items[0].SourceSKU != "__sync_action_products__:5" || SummarizeStatus(result.Items) != ReportStatusSuccess {
+		t.Fatalf("unexpected item results: %+v", result.Items)
+	}
+
+	job.Meta = map[string]interface{}{"source_action_id": "shop-demo-1"}
+	if _, err := executor.Execute(context.Background(), job); err == nil {
+		t.Fatalf("Execute() should reject non-official action ids")
+	}
+	job.ShopID = 8
+	if _, err := executor.Execute(context.Background(), job); err == nil {
+		t.Fatalf("Execute() should fail without shop credentials")
+	}
+}
@@ -0,0 +1,225 @@
+// Package agentclient 自动化执行端协议（心跳 / 拉任务 / 回报 / 产物上传）的 Go 客户端与执行框架
+package agentclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/pkg/artifactstore"
+	"ozon-manager/pkg/automation/protocol"
+)
+
+const (
+	agentAPIPrefix       = "/api/v1/automation/agent"
+	defaultClientTimeout = 30 * time.Second
+	agentTokenHeader     = "X-Agent-Token"
+)
+
+// Options 执行端身份与服务端地址
+type Options struct {
+	BaseURL  string
+	AgentKey string
+	Name     string
+	Hostname string
+	// Token 与服务端 automation.agent_token 一致；服务端配置令牌后未携带会被拒绝，产物上传始终需要
+	Token      string
+	HTTPClient *http.Client
+}
+
+// Client 封装执行端协议的 HTTP 调用
+type Client struct {
+	baseURL    string
+	agentKey   string
+	name       string
+	hostname   string
+	token      string
+	httpClient *http.Client
+}
+
+// APIError 服务端返回的非 2xx 响应
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("agent api error (status %d): %s", e.StatusCode, e.Message)
+}
+
+// IsIncompatibleProtocol 判断错误是否为服务端拒绝了本执行端的协议版本（HTTP 426）
+func IsIncompatibleProtocol(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUpgradeRequired
+}
+
+func NewClient(opts Options) (*Client, error) {
+	if strings.TrimSpace(opts.BaseURL) == "" {
+		return nil, fmt.Errorf("base url is required")
+	}
+	if strings.TrimSpace(opts.AgentKey) == "" {
+		return nil, fmt.Errorf("agent key is required")
+	}
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultClientTimeout}
+	}
+	name := strings.TrimSpace(opts.Name)
+	if name == "" {
+		name = opts.AgentKey
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(opts.BaseURL, "/"),
+		agentKey:   strings.TrimSpace(opts.AgentKey),
+		name:       name,
+		hostname:   opts.Hostname,
+		token:      opts.Token,
+		httpClient: httpClient,
+	}, nil
+}
+
+func (c *Client) AgentKey() string {
+	return c.agentKey
+}
+
+// Heartbeat 上报心跳与能力声明；首次心跳即完成执行端注册
+func (c *Client) Heartbeat(ctx context.Context, capabilities dto.AgentCapabilities) error {
+	capabilityMap := map[string]interface{}{}
+	raw, err := json.Marshal(capabilities)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, &capabilityMap); err != nil {
+		return err
+	}
+	req := dto.AgentHeartbeatRequest{
+		AgentKey:        c.agentKey,
+		Name:            c.name,
+		Hostname:        c.hostname,
+		ProtocolVersion: protocol.Version,
+		Capabilities:    capabilityMap,
+	}
+	return c.postJSON(ctx, "/heartbeat", req, nil)
+}
+
+// Poll 拉取一个任务，无任务时返回 nil
+func (c *Client) Poll(ctx context.Context) (*dto.AgentJobPayload, error) {
+	var resp dto.AgentPollResponse
+	if err := c.postJSON(ctx, "/poll", dto.AgentPollRequest{AgentKey: c.agentKey}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Job, nil
+}
+
+// Report 回报任务执行结果
+func (c *Client) Report(ctx context.Context, jobID uint, status string, results []dto.AgentItemResult, meta map[string]interface{}) error {
+	req := dto.AgentReportRequest{
+		AgentKey: c.agentKey,
+		JobID:    jobID,
+		Status:   status,
+		Results:  results,
+		Meta:     meta,
+	}
+	return c.postJSON(ctx, "/report", req, nil)
+}
+
+// UploadArtifact 上传任务产物，附带 SHA-256 供服务端校验
+func (c *Client) UploadArtifact(ctx context.Context, jobID uint, artifactType, fileName, contentType string, data []byte) (*dto.AutomationArtifactItem, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	fields := map[string]string{
+		"agent_key":     c.agentKey,
+		"job_id":        strconv.FormatUint(uint64(jobID), 10),
+		"artifact_type": artifactType,
+		"sha256":        artifactstore.Checksum(data),
+	}
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return nil, err
+		}
+	}
+	header := make(map[string][]string)
+	header["Content-Disposition"] = []string{fmt.Sprintf(`form-data; name="file"; filename=%q`, fileName)}
+	if contentType != "" {
+		header["Content-Type"] = []string{contentType}
+	}
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var artifact dto.AutomationArtifactItem
+	if err := c.do(ctx, "/artifacts", writer.FormDataContentType(), &body, &artifact); err != nil {
+		return nil, err
+	}
+	return &artifact, nil
+}
+
+func (c *Client) postJSON(ctx context.Context, path string, payload interface{}, out interface{}) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	return c.do(ctx, path, "application/json", bytes.NewReader(raw), out)
+}
+
+func (c *Client) do(ctx context.Context, path, contentType string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+agentAPIPrefix+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if c.token != "" {
+		req.Header.Set(agentTokenHeader, c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var envelope struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	decodeErr := json.Unmarshal(respBody, &envelope)
+	if resp.StatusCode >= 400 {
+		message := strings.TrimSpace(string(respBody))
+		if decodeErr == nil && envelope.Message != "" {
+			message = envelope.Message
+		}
+		return &APIError{StatusCode: resp.StatusCode, Message: message}
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", decodeErr)
+	}
+	if out == nil || len(envelope.Data) == 0 || string(envelope.Data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response data: %w", err)
+	}
+	return nil
+}
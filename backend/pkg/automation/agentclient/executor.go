@@ -0,0 +1,120 @@
+package agentclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"ozon-manager/internal/dto"
+)
+
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+
+	ReportStatusSuccess        = "success"
+	ReportStatusPartialSuccess = "partial_success"
+	ReportStatusFailed         = "failed"
+)
+
+// Result 执行器对一个任务的执行结果
+type Result struct {
+	Items []dto.AgentItemResult
+	// Meta 随回报提交的产物（如同步快照），服务端按任务类型存为对应 artifact
+	Meta map[string]interface{}
+}
+
+// Executor 执行某些任务类型的执行器。
+// Execute 返回 error 表示任务整体无法执行，Runner 会把全部明细回报为失败。
+type Executor interface {
+	Name() string
+	JobTypes() []string
+	Execute(ctx context.Context, job *dto.AgentJobPayload) (*Result, error)
+}
+
+// Router 按任务类型把任务分派给对应执行器，先注册的执行器优先
+type Router struct {
+	executors []Executor
+}
+
+func NewRouter(executors ...Executor) *Router {
+	return &Router{executors: executors}
+}
+
+func (r *Router) Name() string {
+	return "router"
+}
+
+// JobTypes 返回所有执行器支持的任务类型（去重、排序），用于能力声明
+func (r *Router) JobTypes() []string {
+	seen := make(map[string]struct{})
+	result := make([]string, 0)
+	for _, executor := range r.executors {
+		for _, jobType := range executor.JobTypes() {
+			if _, ok := seen[jobType]; ok {
+				continue
+			}
+			seen[jobType] = struct{}{}
+			result = append(result, jobType)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (r *Router) Execute(ctx context.Context, job *dto.AgentJobPayload) (*Result, error) {
+	for _, executor := range r.executors {
+		for _, jobType := range executor.JobTypes() {
+			if jobType == job.JobType {
+				return executor.Execute(ctx, job)
+			}
+		}
+	}
+	return nil, fmt.Errorf("unsupported job type: %s", job.JobType)
+}
+
+// SummarizeStatus 由明细结果推导回报状态：无失败为 success，全部失败为 failed，其余为 partial_success
+func SummarizeStatus(items []dto.AgentItemResult) string {
+	failed := 0
+	for _, item := range items {
+		if item.OverallStatus == StatusFailed {
+			failed++
+		}
+	}
+	switch {
+	case failed == 0:
+		return ReportStatusSuccess
+	case failed == len(items):
+		return ReportStatusFailed
+	default:
+		return ReportStatusPartialSuccess
+	}
+}
+
+// FailedItems 为任务的全部明细生成失败结果
+func FailedItems(job *dto.AgentJobPayload, message string) []dto.AgentItemResult {
+	results := make([]dto.AgentItemResult, 0, len(job.Items))
+	for _, item := range job.Items {
+		results = append(results, dto.AgentItemResult{
+			SourceSKU:         item.SourceSKU,
+			OverallStatus:     StatusFailed,
+			StepExitStatus:    StatusFailed,
+			StepRepriceStatus: StatusSkipped,
+			StepReaddStatus:   StatusSkipped,
+			StepExitError:     message,
+		})
+	}
+	return results
+}
+
+// syncItemResult 同步类任务只有一个占位明细，整体成功时三步均记为成功
+func syncItemResult(sourceSKU string) dto.AgentItemResult {
+	return dto.AgentItemResult{
+		SourceSKU:         sourceSKU,
+		OverallStatus:     StatusSuccess,
+		StepExitStatus:    StatusSuccess,
+		StepRepriceStatus: StatusSuccess,
+		StepReaddStatus:   StatusSuccess,
+	}
+}
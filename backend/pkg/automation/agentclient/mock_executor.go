@@ -0,0 +1,90 @@
+package agentclient
+
+import (
+	"context"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+)
+
+// MockExecutor 不访问 Ozon 的模拟执行器，行为与 Node Agent 的 mock 模式一致，用于联调与端到端测试
+type MockExecutor struct{}
+
+func NewMockExecutor() *MockExecutor {
+	return &MockExecutor{}
+}
+
+func (e *MockExecutor) Name() string {
+	return "mock-executor"
+}
+
+func (e *MockExecutor) JobTypes() []string {
+	return []string{
+		model.AutomationJobTypeSyncShopActions,
+		model.AutomationJobTypeSyncActionCandidates,
+		model.AutomationJobTypeSyncActionProducts,
+		model.AutomationJobTypeShopActionDeclare,
+		model.AutomationJobTypeShopActionRemove,
+		model.AutomationJobTypePromoUnifiedEnroll,
+		model.AutomationJobTypePromoUnifiedRemove,
+		model.AutomationJobTypeRemoveRepriceReadd,
+	}
+}
+
+func (e *MockExecutor) Execute(ctx context.Context, job *dto.AgentJobPayload) (*Result, error) {
+	switch job.JobType {
+	case model.AutomationJobTypeSyncShopActions:
+		return &Result{
+			Items: []dto.AgentItemResult{syncItemResult(firstItemSKU(job, "__sync_shop_actions__"))},
+			Meta: map[string]interface{}{
+				"actions": []map[string]interface{}{{
+					"source_action_id":             "shop-demo-1",
+					"title":                        "店铺促销(模拟) #1",
+					"action_type":                  "SHOP_PRIVATE_PROMO",
+					"participating_products_count": 2,
+					"potential_products_count":     5,
+				}},
+			},
+		}, nil
+	case model.AutomationJobTypeSyncActionCandidates, model.AutomationJobTypeSyncActionProducts:
+		return &Result{
+			Items: []dto.AgentItemResult{syncItemResult(firstItemSKU(job, "__"+job.JobType+"__"))},
+			Meta: map[string]interface{}{
+				"items": []map[string]interface{}{
+					{"source_sku": "demo-sku-001", "ozon_product_id": 10001, "price": 99.9, "action_price": 89.9, "stock": 12, "status": "active"},
+					{"source_sku": "demo-sku-002", "ozon_product_id": 10002, "price": 119.9, "action_price": 109.9, "stock": 8, "status": "active"},
+				},
+			},
+		}, nil
+	}
+
+	exit, reprice, readd := StatusSuccess, StatusSuccess, StatusSuccess
+	switch job.JobType {
+	case model.AutomationJobTypeShopActionDeclare, model.AutomationJobTypePromoUnifiedEnroll:
+		exit, reprice = StatusSkipped, StatusSkipped
+	case model.AutomationJobTypeShopActionRemove, model.AutomationJobTypePromoUnifiedRemove:
+		reprice, readd = StatusSkipped, StatusSkipped
+	}
+
+	results := make([]dto.AgentItemResult, 0, len(job.Items))
+	for _, item := range job.Items {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		results = append(results, dto.AgentItemResult{
+			SourceSKU:         item.SourceSKU,
+			OverallStatus:     StatusSuccess,
+			StepExitStatus:    exit,
+			StepRepriceStatus: reprice,
+			StepReaddStatus:   readd,
+		})
+	}
+	return &Result{Items: results}, nil
+}
+
+func firstItemSKU(job *dto.AgentJobPayload, fallback string) string {
+	if len(job.Items) > 0 && job.Items[0].SourceSKU != "" {
+		return job.Items[0].SourceSKU
+	}
+	return fallback
+}
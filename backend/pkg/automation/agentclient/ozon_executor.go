@@ -0,0 +1,133 @@
+package agentclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ozon-manager/internal/dto"
+	"ozon-manager/internal/model"
+	"ozon-manager/pkg/ozon"
+)
+
+const ozonActionPageSize = 100
+
+// OzonActionsAPI 官方活动接口，*ozon.Client 即满足该接口
+type OzonActionsAPI interface {
+	GetActionCandidates(actionID int64, limit int, lastID string) (*ozon.ActionCandidatesResponse, error)
+	GetActionProducts(actionID int64, limit int, lastID string) (*ozon.ActionProductsResponse, error)
+}
+
+// OzonAPIExecutor 通过 Ozon Seller API 执行官方活动的同步任务，无需浏览器。
+// clientForShop 返回店铺对应的 API 客户端，未配置凭证的店铺返回 false。
+type OzonAPIExecutor struct {
+	clientForShop func(shopID uint) (OzonActionsAPI, bool)
+}
+
+func NewOzonAPIExecutor(clientForShop func(shopID uint) (OzonActionsAPI, bool)) *OzonAPIExecutor {
+	return &OzonAPIExecutor{clientForShop: clientForShop}
+}
+
+func (e *OzonAPIExecutor) Name() string {
+	return "ozon-api-executor"
+}
+
+func (e *OzonAPIExecutor) JobTypes() []string {
+	return []string{
+		model.AutomationJobTypeSyncActionCandidates,
+		model.AutomationJobTypeSyncActionProducts,
+	}
+}
+
+func (e *OzonAPIExecutor) Execute(ctx context.Context, job *dto.AgentJobPayload) (*Result, error) {
+	client, ok := e.clientForShop(job.ShopID)
+	if !ok {
+		return nil, fmt.Errorf("no ozon api credentials for shop %d", job.ShopID)
+	}
+	actionID, err := officialActionID(job.Meta)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []map[string]interface{}
+	switch job.JobType {
+	case model.AutomationJobTypeSyncActionCandidates:
+		items, err = fetchActionCandidates(ctx, client, actionID)
+	case model.AutomationJobTypeSyncActionProducts:
+		items, err = fetchActionProducts(ctx, client, actionID)
+	default:
+		return nil, fmt.Errorf("unsupported job type: %s", job.JobType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Items: []dto.AgentItemResult{syncItemResult(firstItemSKU(job, "__"+job.JobType+"__"))},
+		Meta:  map[string]interface{}{"items": items},
+	}, nil
+}
+
+// officialActionID 官方活动的 source_action_id 为数字，店铺自建活动只能走浏览器执行
+func officialActionID(meta map[string]interface{}) (int64, error) {
+	raw := strings.TrimSpace(fmt.Sprint(meta["source_action_id"]))
+	actionID, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || actionID <= 0 {
+		return 0, fmt.Errorf("source_action_id %q is not an official action id", raw)
+	}
+	return actionID, nil
+}
+
+func fetchActionCandidates(ctx context.Context, client OzonActionsAPI, actionID int64) ([]map[string]interface{}, error) {
+	items := make([]map[string]interface{}, 0)
+	lastID := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := client.GetActionCandidates(actionID, ozonActionPageSize, lastID)
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range resp.Result.Products {
+			items = append(items, actionSnapshotItem(product.ProductID, product.Price, product.ActionPrice, product.MaxActionPrice, product.Stock, "candidate"))
+		}
+		if len(resp.Result.Products) < ozonActionPageSize || resp.Result.LastID == "" || resp.Result.LastID == lastID {
+			return items, nil
+		}
+		lastID = resp.Result.LastID
+	}
+}
+
+func fetchActionProducts(ctx context.Context, client OzonActionsAPI, actionID int64) ([]map[string]interface{}, error) {
+	items := make([]map[string]interface{}, 0)
+	lastID := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := client.GetActionProducts(actionID, ozonActionPageSize, lastID)
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range resp.Result.Products {
+			items = append(items, actionSnapshotItem(product.ProductID, product.Price, product.ActionPrice, product.MaxActionPrice, product.Stock, "active"))
+		}
+		if len(resp.Result.Products) < ozonActionPageSize || resp.Result.LastID == "" || resp.Result.LastID == lastID {
+			return items, nil
+		}
+		lastID = resp.Result.LastID
+	}
+}
+
+func actionSnapshotItem(productID int64, price, actionPrice, maxActionPrice float64, stock int, status string) map[string]interface{} {
+	return map[string]interface{}{
+		"ozon_product_id":  productID,
+		"price":            price,
+		"action_price":     actionPrice,
+		"max_action_price": maxActionPrice,
+		"stock":            stock,
+		"status":           status,
+	}
+}
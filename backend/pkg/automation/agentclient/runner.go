@@ -0,0 +1,119 @@
+package agentclient
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"ozon-manager/internal/dto"
+)
+
+const (
+	defaultPollInterval = 8 * time.Second
+	// RunnerKind 能力声明中的执行端类型
+	RunnerKind    = "go-agent"
+	RunnerVersion = "1.0.0"
+)
+
+// RunnerOptions 执行循环配置
+type RunnerOptions struct {
+	PollInterval time.Duration
+	// ShopIDs 声明服务的店铺，为空表示不限
+	ShopIDs []uint
+	Logger  *log.Logger
+}
+
+// Runner 执行端主循环：心跳 → 拉任务 → 执行 → 回报，每次只执行一个任务
+type Runner struct {
+	client       *Client
+	executor     Executor
+	pollInterval time.Duration
+	shopIDs      []uint
+	logger       *log.Logger
+}
+
+func NewRunner(client *Client, executor Executor, opts RunnerOptions) *Runner {
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Runner{
+		client:       client,
+		executor:     executor,
+		pollInterval: interval,
+		shopIDs:      opts.ShopIDs,
+		logger:       logger,
+	}
+}
+
+// Capabilities 当前执行端的能力声明
+func (r *Runner) Capabilities() dto.AgentCapabilities {
+	return dto.AgentCapabilities{
+		Kind:           RunnerKind,
+		Version:        RunnerVersion,
+		JobTypes:       r.executor.JobTypes(),
+		ShopIDs:        r.shopIDs,
+		MaxConcurrency: 1,
+	}
+}
+
+// Run 持续轮询直到 ctx 取消；正在执行的任务会先完成回报再退出
+func (r *Runner) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RunOnce(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			// 协议版本不被服务端接受时重试无意义，直接退出提示升级
+			if IsIncompatibleProtocol(err) {
+				return err
+			}
+			r.logger.Printf("[Agent] loop error: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 执行一轮心跳与拉取，返回本轮是否处理了任务
+func (r *Runner) RunOnce(ctx context.Context) (bool, error) {
+	if err := r.client.Heartbeat(ctx, r.Capabilities()); err != nil {
+		return false, err
+	}
+	job, err := r.client.Poll(ctx)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	r.logger.Printf("[Agent] picked job #%d (%s), items=%d", job.JobID, job.JobType, len(job.Items))
+	// 任务已被领取，回报不受外层取消影响，避免任务卡在 running 等待超时巡检
+	reportCtx := context.WithoutCancel(ctx)
+	result, execErr := r.executor.Execute(ctx, job)
+	if execErr != nil {
+		items := FailedItems(job, execErr.Error())
+		if reportErr := r.client.Report(reportCtx, job.JobID, ReportStatusFailed, items, nil); reportErr != nil {
+			return true, reportErr
+		}
+		r.logger.Printf("[Agent] job #%d failed: %v", job.JobID, execErr)
+		return true, nil
+	}
+
+	items := result.Items
+	if len(items) == 0 {
+		items = FailedItems(job, "executor returned no results")
+	}
+	status := SummarizeStatus(items)
+	if err := r.client.Report(reportCtx, job.JobID, status, items, result.Meta); err != nil {
+		return true, err
+	}
+	r.logger.Printf("[Agent] reported job #%d: %s", job.JobID, status)
+	return true, nil
+}
@@ -0,0 +1,37 @@
+package protocol
+
+// 服务端随任务下发给执行端的 meta（拉任务响应中的 job.meta），按任务类型区分。
+// 字段定义与 schema/v1.json 中同名 $defs 保持一致，由 schema_test 校验。
+
+// SyncActionMeta sync_action_candidates / sync_action_products 任务的 meta
+type SyncActionMeta struct {
+	PromotionActionID uint   `json:"promotion_action_id"`
+	SourceActionID    string `json:"source_action_id"`
+}
+
+// ShopActionMeta shop_action_declare / shop_action_remove 任务的 meta
+type ShopActionMeta struct {
+	SourceActionID string `json:"source_action_id"`
+}
+
+// ShopActionRef 任务涉及的店铺活动
+type ShopActionRef struct {
+	ActionDBID     uint   `json:"action_db_id"`
+	SourceActionID string `json:"source_action_id"`
+	Title          string `json:"title"`
+}
+
+// PromoUnifiedMeta promo_unified_enroll / promo_unified_remove 任务的 meta
+type PromoUnifiedMeta struct {
+	// Operation declare / remove
+	Operation   string          `json:"operation"`
+	ShopActions []ShopActionRef `json:"shop_actions"`
+}
+
+// RemoveRepriceReaddMeta remove_reprice_readd 任务的 meta
+type RemoveRepriceReaddMeta struct {
+	Reason            string          `json:"reason"`
+	RejoinActionIDs   []uint          `json:"rejoin_action_ids,omitempty"`
+	ReenrollActionIDs []uint          `json:"reenroll_action_ids,omitempty"`
+	ShopActions       []ShopActionRef `json:"shop_actions"`
+}
@@ -0,0 +1,88 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"ozon-manager/internal/dto"
+)
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		client  int
+		want    int
+		wantErr bool
+	}{
+		{client: 0, want: LegacyVersion},
+		{client: Version, want: Version},
+		{client: -1, wantErr: true},
+		{client: Version + 1, wantErr: true},
+	}
+	for _, tc := range cases {
+		got, err := Negotiate(tc.client)
+		if tc.wantErr {
+			var incompatible *IncompatibleVersionError
+			if !errors.As(err, &incompatible) || incompatible.ClientVersion != tc.client {
+				t.Fatalf("Negotiate(%d) error = %v, want IncompatibleVersionError", tc.client, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Fatalf("Negotiate(%d) = %d, %v, want %d", tc.client, got, err, tc.want)
+		}
+	}
+
+	if msg := (&IncompatibleVersionError{ClientVersion: Version + 1}).Error(); !strings.Contains(msg, "upgrade the server") {
+		t.Fatalf("unexpected message for newer executor: %s", msg)
+	}
+}
+
+func TestSchemaVersionMatchesConstant(t *testing.T) {
+	t.Parallel()
+
+	var doc struct {
+		Version int `json:"x-protocol-version"`
+	}
+	if err := json.Unmarshal(SchemaV1(), &doc); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	if doc.Version != Version {
+		t.Fatalf("schema x-protocol-version = %d, want %d", doc.Version, Version)
+	}
+}
+
+// TestMessagesMatchSchema Go 结构体与 schema/v1.json 必须同步修改
+func TestMessagesMatchSchema(t *testing.T) {
+	t.Parallel()
+
+	messages := map[string]interface{}{
+		"AgentCapabilities":         dto.AgentCapabilities{},
+		"AgentHeartbeatRequest":     dto.AgentHeartbeatRequest{},
+		"AgentHeartbeatResponse":    dto.AgentHeartbeatResponse{},
+		"AgentPollRequest":          dto.AgentPollRequest{},
+		"AgentPollResponse":         dto.AgentPollResponse{},
+		"AutomationJobCreateItem":   dto.AutomationJobCreateItem{},
+		"AgentJobPayload":           dto.AgentJobPayload{},
+		"AgentItemResult":           dto.AgentItemResult{},
+		"AgentReportRequest":        dto.AgentReportRequest{},
+		"ExtensionRegisterRequest":  dto.ExtensionRegisterRequest{},
+		"ExtensionRegisterResponse": dto.ExtensionRegisterResponse{},
+		"ExtensionPollRequest":      dto.ExtensionPollRequest{},
+		"ExtensionPollResponse":     dto.ExtensionPollResponse{},
+		"ExtensionReportRequest":    dto.ExtensionReportRequest{},
+		"SyncActionMeta":            SyncActionMeta{},
+		"ShopActionMeta":            ShopActionMeta{},
+		"ShopActionRef":             ShopActionRef{},
+		"PromoUnifiedMeta":          PromoUnifiedMeta{},
+		"RemoveRepriceReaddMeta":    RemoveRepriceReaddMeta{},
+	}
+	for definition, value := range messages {
+		for _, problem := range CheckStruct(definition, value) {
+			t.Error(problem)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package protocol
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed schema/v1.json
+var schemaFS embed.FS
+
+// SchemaV1 返回协议 v1 的 JSON Schema 原文
+func SchemaV1() []byte {
+	data, _ := schemaFS.ReadFile("schema/v1.json")
+	return data
+}
+
+type schemaDocument struct {
+	Defs map[string]schemaDefinition `json:"$defs"`
+}
+
+type schemaDefinition struct {
+	Type       interface{}                `json:"type"`
+	Required   []string                   `json:"required"`
+	Properties map[string]schemaReference `json:"properties"`
+}
+
+type schemaReference struct {
+	Type interface{} `json:"type"`
+	Ref  string      `json:"$ref"`
+}
+
+func loadSchemaDefinition(name string) (*schemaDefinition, error) {
+	var doc schemaDocument
+	if err := json.Unmarshal(SchemaV1(), &doc); err != nil {
+		return nil, fmt.Errorf("invalid protocol schema: %w", err)
+	}
+	def, ok := doc.Defs[name]
+	if !ok {
+		return nil, fmt.Errorf("schema definition %s not found", name)
+	}
+	return &def, nil
+}
+
+// CheckStruct 校验 Go 结构体与 schema 中同名定义一致：字段集合相同、JSON 类型匹配，
+// 且 binding:"required" 的字段在 schema 中声明为 required。返回发现的全部不一致。
+func CheckStruct(definition string, value interface{}) []string {
+	return checkStruct(definition, value, true)
+}
+
+// CheckStructFields 只校验 Go 结构体的字段都在 schema 中声明且类型匹配，允许 schema 描述更多可选字段
+func CheckStructFields(definition string, value interface{}) []string {
+	return checkStruct(definition, value, false)
+}
+
+func checkStruct(definition string, value interface{}, exact bool) []string {
+	def, err := loadSchemaDefinition(definition)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	typ := reflect.TypeOf(value)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return []string{fmt.Sprintf("%s: %s is not a struct", definition, typ)}
+	}
+
+	problems := make([]string, 0)
+	fields := make(map[string]struct{})
+	for index := 0; index < typ.NumField(); index++ {
+		field := typ.Field(index)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		fields[name] = struct{}{}
+
+		property, ok := def.Properties[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: field %s is not declared in schema", definition, name))
+			continue
+		}
+		if expected := jsonTypeOf(field.Type); property.Ref == "" && !schemaTypeAllows(property.Type, expected) {
+			problems = append(problems, fmt.Sprintf("%s: field %s has type %s, schema declares %v", definition, name, expected, property.Type))
+		}
+		if isBindingRequired(field.Tag.Get("binding")) && !containsString(def.Required, name) {
+			problems = append(problems, fmt.Sprintf("%s: field %s is required by binding but optional in schema", definition, name))
+		}
+	}
+
+	for _, name := range def.Required {
+		if _, ok := fields[name]; !ok {
+			problems = append(problems, fmt.Sprintf("%s: required property %s has no Go field", definition, name))
+		}
+	}
+	if exact {
+		names := make([]string, 0, len(def.Properties))
+		for name := range def.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if _, ok := fields[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: schema property %s has no Go field", definition, name))
+			}
+		}
+	}
+	return problems
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func jsonTypeOf(typ reflect.Type) string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == timeType {
+		return "string"
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func schemaTypeAllows(declared interface{}, expected string) bool {
+	switch value := declared.(type) {
+	case string:
+		return value == expected || (value == "number" && expected == "integer")
+	case []interface{}:
+		for _, item := range value {
+			if schemaTypeAllows(item, expected) {
+				return true
+			}
+		}
+		return false
+	case nil:
+		return true
+	}
+	return false
+}
+
+func isBindingRequired(tag string) bool {
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
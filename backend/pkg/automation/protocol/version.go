@@ -0,0 +1,38 @@
+// Package protocol 执行端（本地 Agent / 浏览器插件）与服务端之间的版本化协议：
+// 版本协商规则、任务 meta 的类型定义，以及 schema/ 下的 JSON Schema。
+package protocol
+
+import "fmt"
+
+const (
+	// Version 服务端当前实现的协议版本
+	Version = 1
+	// MinSupportedVersion 服务端仍兼容的最低协议版本
+	MinSupportedVersion = 1
+	// LegacyVersion 未声明 protocol_version 的旧版执行端按此版本处理
+	LegacyVersion = 1
+)
+
+// IncompatibleVersionError 执行端协议版本超出服务端支持范围
+type IncompatibleVersionError struct {
+	ClientVersion int
+}
+
+func (e *IncompatibleVersionError) Error() string {
+	hint := "please upgrade the executor"
+	if e.ClientVersion > Version {
+		hint = "please upgrade the server or downgrade the executor"
+	}
+	return fmt.Sprintf("protocol version %d is not supported, server supports %d-%d; %s", e.ClientVersion, MinSupportedVersion, Version, hint)
+}
+
+// Negotiate 返回双方共同使用的协议版本；执行端版本低于最低兼容版本或高于服务端版本时返回 IncompatibleVersionError
+func Negotiate(clientVersion int) (int, error) {
+	if clientVersion == 0 {
+		clientVersion = LegacyVersion
+	}
+	if clientVersion < MinSupportedVersion || clientVersion > Version {
+		return 0, &IncompatibleVersionError{ClientVersion: clientVersion}
+	}
+	return clientVersion, nil
+}
@@ -124,3 +124,97 @@ func CreateLossTemplate() (*excelize.File, error) {
 func CreateRepriceTemplate() (*excelize.File, error) {
 	return CreateLossTemplate() // 格式相同
 }
+
+// SimulationActionSummary 促销模拟的活动汇总行
+type SimulationActionSummary struct {
+	Title              string
+	Source             string
+	CandidateCount     int
+	AlreadyActiveCount int
+	IneligibleCount    int
+	AvgDiscountPercent float64
+	CurrentRevenue     float64
+	ActionRevenue      float64
+	WithCostCount      int
+	ActionProfit       float64
+	AvgMarginPercent   float64
+	LossCount          int
+}
+
+// SimulationItem 促销模拟的 SKU 明细行，HasCost 为 false 时毛利列留空
+type SimulationItem struct {
+	ActionTitle     string
+	SourceSKU       string
+	ProductName     string
+	Status          string
+	Reason          string
+	CurrentPrice    float64
+	ActionPrice     float64
+	MaxActionPrice  float64
+	DiscountPercent float64
+	HasCost         bool
+	Cost            float64
+	MarginPercent   float64
+	ProfitPerUnit   float64
+}
+
+// ExportPromotionSimulation 导出促销模拟结果：活动汇总与 SKU 明细两个工作表
+func ExportPromotionSimulation(summaries []SimulationActionSummary, items []SimulationItem) (*excelize.File, error) {
+	f := excelize.NewFile()
+
+	style, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{
+			Type:    "pattern",
+			Color:   []string{"#CCCCCC"},
+			Pattern: 1,
+		},
+	})
+
+	summarySheet := "活动汇总"
+	index, err := f.NewSheet(summarySheet)
+	if err != nil {
+		return nil, err
+	}
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	summaryHeaders := []string{"活动", "来源", "候选", "已在活动中", "不符合", "平均折扣(%)", "当前价合计", "活动价合计", "有成本SKU", "活动价毛利合计", "平均毛利率(%)", "亏损SKU"}
+	f.SetSheetRow(summarySheet, "A1", &summaryHeaders)
+	f.SetCellStyle(summarySheet, "A1", "L1", style)
+	for i, summary := range summaries {
+		row := []interface{}{
+			summary.Title, summary.Source, summary.CandidateCount, summary.AlreadyActiveCount, summary.IneligibleCount,
+			summary.AvgDiscountPercent, summary.CurrentRevenue, summary.ActionRevenue,
+			summary.WithCostCount, summary.ActionProfit, summary.AvgMarginPercent, summary.LossCount,
+		}
+		f.SetSheetRow(summarySheet, fmt.Sprintf("A%d", i+2), &row)
+	}
+	f.SetColWidth(summarySheet, "A", "A", 40)
+	f.SetColWidth(summarySheet, "B", "L", 14)
+
+	itemSheet := "SKU明细"
+	if _, err := f.NewSheet(itemSheet); err != nil {
+		return nil, err
+	}
+	itemHeaders := []string{"活动", "Source SKU", "商品名称", "状态", "原因", "当前价", "活动价", "最高活动价", "折扣(%)", "成本价", "毛利率(%)", "单件毛利"}
+	f.SetSheetRow(itemSheet, "A1", &itemHeaders)
+	f.SetCellStyle(itemSheet, "A1", "L1", style)
+	for i, item := range items {
+		row := []interface{}{
+			item.ActionTitle, item.SourceSKU, item.ProductName, item.Status, item.Reason,
+			item.CurrentPrice, item.ActionPrice, item.MaxActionPrice, item.DiscountPercent,
+		}
+		if item.HasCost {
+			row = append(row, item.Cost, item.MarginPercent, item.ProfitPerUnit)
+		}
+		f.SetSheetRow(itemSheet, fmt.Sprintf("A%d", i+2), &row)
+	}
+	f.SetColWidth(itemSheet, "A", "A", 40)
+	f.SetColWidth(itemSheet, "B", "B", 20)
+	f.SetColWidth(itemSheet, "C", "C", 40)
+	f.SetColWidth(itemSheet, "D", "E", 20)
+	f.SetColWidth(itemSheet, "F", "L", 12)
+
+	return f, nil
+}
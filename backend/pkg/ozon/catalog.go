@@ -120,7 +120,10 @@ type ProductInfoListItem struct {
 	OldPrice       string `json:"old_price"`
 	MinPrice       string `json:"min_price"`
 	Visible        bool   `json:"visible"`
-	Status         struct {
+	// DescriptionCategoryID / TypeID 商品所属类目与类型
+	DescriptionCategoryID int64 `json:"description_category_id"`
+	TypeID                int64 `json:"type_id"`
+	Status                struct {
 		State string `json:"state"`
 	} `json:"status"`
 	PrimaryImage string                 `json:"-"`
@@ -24,6 +24,7 @@ type Client struct {
 	clientID   string
 	apiKey     string
 	httpClient *http.Client
+	observer   func(err error)
 }
 
 // NewClient 创建Ozon API客户端
@@ -37,8 +38,22 @@ func NewClient(clientID, apiKey string) *Client {
 	}
 }
 
+// WithObserver 设置请求结果回调，每次请求结束后以请求错误（成功为 nil）调用，用于统计店铺 API 故障
+func (c *Client) WithObserver(observer func(err error)) *Client {
+	c.observer = observer
+	return c
+}
+
 // doRequest 执行HTTP请求
 func (c *Client) doRequest(method, path string, body interface{}) ([]byte, error) {
+	respBody, err := c.sendRequest(method, path, body)
+	if c.observer != nil {
+		c.observer(err)
+	}
+	return respBody, err
+}
+
+func (c *Client) sendRequest(method, path string, body interface{}) ([]byte, error) {
 	var reqBody io.Reader
 	if body != nil {
 		jsonData, err := json.Marshal(body)
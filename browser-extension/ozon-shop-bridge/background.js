@@ -2,6 +2,8 @@ const SELLER_BASE_URL = 'https://seller.ozon.ru'
 const POLL_ALARM = 'ozon_manager_extension_poll'
 const AUTH_SYNC_SCRIPT_ID = 'ozon_manager_auth_sync_dynamic'
 const DEFAULT_POLL_INTERVAL_MS = 5000
+// 与服务端协商的执行端协议版本，见 backend/pkg/automation/protocol/schema
+const PROTOCOL_VERSION = 1
 
 const DEFAULT_STATE = {
   enabled: true,
@@ -175,6 +177,7 @@ async function pollOnce() {
       {
         shop_id: state.shopId,
         extension_id: state.extensionId,
+        protocol_version: PROTOCOL_VERSION,
       },
     )
 
@@ -251,6 +254,7 @@ async function registerExtension(state) {
       extension_id: state.extensionId,
       name: 'Chrome Extension',
       version: chrome.runtime.getManifest().version,
+      protocol_version: PROTOCOL_VERSION,
     },
   )
 }
@@ -681,7 +685,7 @@ async function executeRemoveRepriceReadd(tabID, job, state) {
 
     if (exitSuccess) {
       try {
-        await repriceByBackend(state, job.shop_id, sourceSKU, targetPrice)
+        await repriceByBackend(state, job.shop_id, sourceSKU, targetPrice, job.job_id)
         repriceSuccess = true
       } catch (error) {
         repriceSuccess = false
@@ -869,7 +873,7 @@ function makeRemoveRepriceReaddResult(
   }
 }
 
-async function repriceByBackend(state, shopID, sourceSKU, newPrice) {
+async function repriceByBackend(state, shopID, sourceSKU, newPrice, jobID) {
   if (!state?.apiBaseUrl || !state?.authToken) {
     throw new Error('缺少后端地址或登录 token，无法改价')
   }
@@ -881,6 +885,7 @@ async function repriceByBackend(state, shopID, sourceSKU, newPrice) {
       shop_id: shopID,
       source_sku: sourceSKU,
       new_price: Number(newPrice),
+      job_id: jobID,
     },
   )
 }
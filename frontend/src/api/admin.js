@@ -41,3 +41,8 @@ export function getSystemOverview() {
 export function getExtensionStatus() {
   return request.get('/admin/extension-status')
 }
+
+// 获取自动化任务队列指标
+export function getAutomationQueueMetrics() {
+  return request.get('/admin/automation/queue-metrics')
+}
@@ -6,3 +6,37 @@ export function getJobDetail(jobId, shopId) {
         params: { shop_id: shopId }
     })
 }
+
+// 获取工作流汇总状态
+export function getWorkflow(workflowId, shopId) {
+    return request.get(`/automation/workflows/${workflowId}`, {
+        params: { shop_id: shopId }
+    })
+}
+
+// 获取执行端（Agent / 插件）状态与能力声明
+export function getAgents() {
+    return request.get('/automation/agents')
+}
+
+// 诊断店铺待执行任务无人领取的原因
+export function getDispatchDiagnostics(shopId) {
+    return request.get('/automation/dispatch-diagnostics', {
+        params: { shop_id: shopId }
+    })
+}
+
+// 获取任务产物列表
+export function getJobArtifacts(jobId, shopId) {
+    return request.get(`/automation/jobs/${jobId}/artifacts`, {
+        params: { shop_id: shopId }
+    })
+}
+
+// 下载任务产物
+export function downloadArtifact(artifactId, shopId) {
+    return request.get(`/automation/artifacts/${artifactId}/download`, {
+        params: { shop_id: shopId },
+        responseType: 'blob'
+    })
+}
@@ -29,3 +29,8 @@ export function getOzonCatalog(params) {
 export function refreshOzonCatalog(payload) {
   return request.post('/products/ozon-catalog/refresh', payload)
 }
+
+// 查询价格变动记录
+export function getPriceHistory(params) {
+  return request.get('/products/price-history', { params })
+}
@@ -106,6 +106,35 @@ export function unifiedRepricePromote(data) {
   return request.post('/promotions/unified-reprice-promote', data)
 }
 
+// ========== 推广状态对账 ==============
+
+// 以 Ozon 活动商品为准修正本地推广记录
+export function startPromotionReconcile(shopId) {
+  return request.post('/promotions/reconcile', { shop_id: shopId })
+}
+
+export function listPromotionReconcileReports(params) {
+  return request.get('/promotions/reconcile/reports', { params })
+}
+
+export function getPromotionReconcileReport(reportId, shopId) {
+  return request.get(`/promotions/reconcile/reports/${reportId}`, {
+    params: { shop_id: shopId }
+  })
+}
+
+// ========== 促销参与流水 ==============
+
+// 商品加入 / 退出活动与改价的时间线
+export function getProductParticipation(productId, params) {
+  return request.get(`/promotions/participation/products/${productId}`, { params })
+}
+
+// 活动在时间范围内的加入 / 退出报表
+export function getActionParticipation(actionId, params) {
+  return request.get(`/promotions/actions/${actionId}/participation`, { params })
+}
+
 // ========== 自动加促销 ==============
 
 export function getAutoPromotionConfig(shopId) {
@@ -118,6 +147,32 @@ export function updateAutoPromotionConfig(data) {
   return request.put('/promotions/auto-add/config', data)
 }
 
+// 店铺可有多个具名配置，各自的执行时间、活动与选品规则
+export function listAutoPromotionConfigs(shopId) {
+  return request.get('/promotions/auto-add/configs', {
+    params: { shop_id: shopId }
+  })
+}
+
+export function createAutoPromotionConfig(data) {
+  return request.post('/promotions/auto-add/configs', data)
+}
+
+export function updateAutoPromotionConfigById(configId, data) {
+  return request.put(`/promotions/auto-add/configs/${configId}`, data)
+}
+
+export function deleteAutoPromotionConfig(configId, shopId) {
+  return request.delete(`/promotions/auto-add/configs/${configId}`, {
+    params: { shop_id: shopId }
+  })
+}
+
+// 按选品规则预览匹配与被排除的商品，不创建任务
+export function previewAutoPromotionSelection(data) {
+  return request.post('/promotions/auto-add/preview', data)
+}
+
 export function startAutoPromotionRun(data) {
   return request.post('/promotions/auto-add/runs', data)
 }
@@ -132,6 +187,140 @@ export function getAutoPromotionRunDetail(runId, shopId) {
   })
 }
 
+// 只重试运行中失败或未完成的商品
+export function retryAutoPromotionRunFailed(runId, shopId) {
+  return request.post(`/promotions/auto-add/runs/${runId}/retry-failed`, { shop_id: shopId })
+}
+
+// ========== 促销日历 ==============
+
+// 活动重叠、脱离促销的时间点与候选活动
+export function getPromotionCalendar(params) {
+  return request.get('/promotions/calendar', { params })
+}
+
+// ========== 活动到期 ==============
+
+// 设置活动到期后自动报名的后续活动，传空数组表示不自动报名
+export function updateActionSuccessors(actionId, shopId, successorActionIds) {
+  return request.put(`/promotions/actions/${actionId}/successors`, {
+    shop_id: shopId,
+    successor_action_ids: successorActionIds
+  })
+}
+
+export function listPromotionExpirations(params) {
+  return request.get('/promotions/expirations', { params })
+}
+
+export function getPromotionExpiration(expirationId, shopId) {
+  return request.get(`/promotions/expirations/${expirationId}`, {
+    params: { shop_id: shopId }
+  })
+}
+
+// ids 为空时标记店铺全部提醒为已读
+export function markPromotionExpirationsRead(shopId, ids = []) {
+  return request.post('/promotions/expirations/read', { shop_id: shopId, ids })
+}
+
+// ========== 批量操作撤销 ==============
+
+export function listBulkOperations(params) {
+  return request.get('/promotions/operations', { params })
+}
+
+export function getBulkOperation(operationId, shopId) {
+  return request.get(`/promotions/operations/${operationId}`, {
+    params: { shop_id: shopId }
+  })
+}
+
+// 按操作前的快照恢复价格与活动参与，失败的商品可再次撤销重试
+export function revertBulkOperation(operationId, shopId) {
+  return request.post(`/promotions/operations/${operationId}/revert`, { shop_id: shopId })
+}
+
+// ========== 定时改价计划 ==============
+
+export function listPricePlans(params) {
+  return request.get('/price-plans', { params })
+}
+
+export function getPricePlan(planId, shopId) {
+  return request.get(`/price-plans/${planId}`, {
+    params: { shop_id: shopId }
+  })
+}
+
+export function createPricePlan(data) {
+  return request.post('/price-plans', data)
+}
+
+// 取消尚未开始的计划
+export function cancelPricePlan(planId, shopId) {
+  return request.post(`/price-plans/${planId}/cancel`, { shop_id: shopId })
+}
+
+// 提前结束并恢复原价，失败的计划可重试恢复
+export function endPricePlan(planId, shopId) {
+  return request.post(`/price-plans/${planId}/end`, { shop_id: shopId })
+}
+
+// ========== 规则改价 ==============
+
+export function listRepriceRules(shopId) {
+  return request.get('/reprice-rules', {
+    params: { shop_id: shopId }
+  })
+}
+
+export function createRepriceRule(data) {
+  return request.post('/reprice-rules', data)
+}
+
+export function updateRepriceRule(ruleId, data) {
+  return request.put(`/reprice-rules/${ruleId}`, data)
+}
+
+export function deleteRepriceRule(ruleId, shopId) {
+  return request.delete(`/reprice-rules/${ruleId}`, {
+    params: { shop_id: shopId }
+  })
+}
+
+export function listProductCosts(params) {
+  return request.get('/reprice-rules/costs', { params })
+}
+
+// 批量保存成本价，成本为 0 表示删除
+export function saveProductCosts(data) {
+  return request.put('/reprice-rules/costs', data)
+}
+
+// 试运行：按规则计算建议价格，不改价
+export function previewReprice(data) {
+  return request.post('/reprice-rules/preview', data)
+}
+
+// 执行确认后的建议价格
+export function applyReprice(data) {
+  return request.post('/reprice-rules/apply', data)
+}
+
+// ========== 促销模拟 ==============
+
+// 模拟报名的活动价、折扣与毛利，只读本地缓存不调用 Ozon
+export function simulatePromotion(data) {
+  return request.post('/promotions/simulate', data)
+}
+
+export function exportPromotionSimulation(data) {
+  return request.post('/promotions/simulate/export', data, {
+    responseType: 'blob'
+  })
+}
+
 // ========== Excel 相关 ==============
 
 // 导入亏损商品
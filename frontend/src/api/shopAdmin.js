@@ -31,6 +31,21 @@ export function updateMyShopExecutionEngine(id, executionEngineMode) {
   })
 }
 
+// 获取店铺暂停写入与熔断状态
+export function getMyShopGuard(id) {
+  return request.get(`/my/shops/${id}/guard`)
+}
+
+// 暂停 / 恢复店铺自动写操作
+export function updateMyShopWritePause(id, paused, reason = '') {
+  return request.put(`/my/shops/${id}/write-pause`, { paused, reason })
+}
+
+// 重置店铺熔断，source 省略时重置全部来源
+export function resetMyShopCircuitBreakers(id, source) {
+  return request.post(`/my/shops/${id}/circuit-breakers/reset`, source ? { source } : {})
+}
+
 // 删除店铺
 export function deleteMyShop(id) {
   return request.delete(`/my/shops/${id}`)
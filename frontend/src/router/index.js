@@ -55,6 +55,42 @@ const routes = [
         component: () => import('@/views/promotions/Reprice.vue'),
         meta: { requiresBusinessRole: true }
       },
+      {
+        path: 'promotions/price-plans',
+        name: 'PricePlans',
+        component: () => import('@/views/promotions/PricePlans.vue'),
+        meta: { requiresBusinessRole: true }
+      },
+      {
+        path: 'promotions/calendar',
+        name: 'PromotionCalendar',
+        component: () => import('@/views/promotions/Calendar.vue'),
+        meta: { requiresBusinessRole: true }
+      },
+      {
+        path: 'promotions/expirations',
+        name: 'PromotionExpirations',
+        component: () => import('@/views/promotions/Expirations.vue'),
+        meta: { requiresBusinessRole: true }
+      },
+      {
+        path: 'promotions/operations',
+        name: 'BulkOperations',
+        component: () => import('@/views/promotions/BulkOperations.vue'),
+        meta: { requiresBusinessRole: true }
+      },
+      {
+        path: 'promotions/reprice-rules',
+        name: 'RepriceRules',
+        component: () => import('@/views/promotions/RepriceRules.vue'),
+        meta: { requiresBusinessRole: true }
+      },
+      {
+        path: 'promotions/simulate',
+        name: 'PromotionSimulate',
+        component: () => import('@/views/promotions/Simulate.vue'),
+        meta: { requiresBusinessRole: true }
+      },
       {
         path: 'promotions/actions',
         name: 'ActionList',